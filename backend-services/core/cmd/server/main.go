@@ -16,29 +16,98 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"log"
 	"log/slog"
+	"net"
 	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
+	"github.com/opensuperapp/opensuperapp/backend-services/core/internal/auth"
 	"github.com/opensuperapp/opensuperapp/backend-services/core/internal/config"
 	"github.com/opensuperapp/opensuperapp/backend-services/core/internal/database"
 	"github.com/opensuperapp/opensuperapp/backend-services/core/internal/router"
+	"github.com/opensuperapp/opensuperapp/backend-services/core/internal/tracing"
 
 	_ "github.com/opensuperapp/opensuperapp/backend-services/core/plugins"
 )
 
+// tracingShutdownTimeout bounds how long buffered spans may take to export on exit.
+const tracingShutdownTimeout = 5 * time.Second
+
 func main() {
+	if err := run(); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// run serves HTTP until the process is interrupted or the server fails, then shuts down in order:
+// in-flight requests first, then background workers, then the database connection.
+func run() error {
+	// Log the request ID with every record logged in a request's context
+	slog.SetDefault(slog.New(auth.NewRequestIDLogHandler(slog.NewTextHandler(os.Stderr, nil))))
+
 	// Load configuration
 	cfg := config.Load()
 
+	// Export traces when a collector is configured; spans still buffered are flushed on exit
+	shutdownTracing, err := tracing.Setup(context.Background(), cfg.OTelExporterEndpoint, cfg.OTelServiceName)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), tracingShutdownTimeout)
+		defer cancel()
+		if err := shutdownTracing(ctx); err != nil {
+			slog.Error("Failed to flush traces", "error", err)
+		}
+	}()
+
 	// Connect to the database
 	db := database.Connect(cfg)
 	defer database.Close(db)
 
-	// Initialize HTTP routes
-	mux := router.NewRouter(db, cfg)
+	// Initialize HTTP routes and background workers
+	mux, shutdownWorkers := router.NewRouter(db, cfg)
+	defer shutdownWorkers()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
 
 	// Start the server
+	listener, err := net.Listen("tcp", ":"+cfg.ServerPort)
+	if err != nil {
+		return err
+	}
 	slog.Info("Starting server", "port", cfg.ServerPort)
-	log.Fatal(http.ListenAndServe(":"+cfg.ServerPort, mux))
+	server := &http.Server{Handler: mux}
+	// The deferred calls then stop the workers, including the notification scheduler, and close the database
+	return serve(ctx, server, listener, time.Duration(cfg.DrainTimeoutSec)*time.Second)
+}
+
+// serve runs server on listener until it fails or ctx is done. It then stops accepting connections
+// and waits up to drainTimeout for in-flight requests to complete.
+func serve(ctx context.Context, server *http.Server, listener net.Listener, drainTimeout time.Duration) error {
+	serverErr := make(chan error, 1)
+	go func() {
+		serverErr <- server.Serve(listener)
+	}()
+
+	select {
+	case err := <-serverErr:
+		if errors.Is(err, http.ErrServerClosed) {
+			return nil
+		}
+		return err
+	case <-ctx.Done():
+	}
+
+	slog.Info("Shutting down server", "drain_timeout", drainTimeout)
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), drainTimeout)
+	defer cancel()
+	return server.Shutdown(shutdownCtx)
 }
@@ -0,0 +1,33 @@
+// Copyright (c) 2025 WSO2 LLC. (https://www.wso2.com).
+//
+// WSO2 LLC. licenses this file to you under the Apache License,
+// Version 2.0 (the "License"); you may not use this file except
+// in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+package dto
+
+// Batch endpoints report the outcome of every item of the request in `results`. The response is
+// 200 or 201 when every item succeeded, 207 Multi-Status with `partial` set when only some did, and
+// 400 when none did.
+const (
+	BatchItemSucceeded = "succeeded"
+	BatchItemFailed    = "failed"
+	BatchItemSkipped   = "skipped" // not processed because of other items, e.g. an atomic batch with invalid items
+)
+
+// BatchItemResult is the outcome of one item of a batch request
+type BatchItemResult struct {
+	Index  int    `json:"index"`         // 0-based position in the request
+	Key    string `json:"key,omitempty"` // identifies the item, e.g. the user's email
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
@@ -0,0 +1,34 @@
+// Copyright (c) 2025 WSO2 LLC. (https://www.wso2.com).
+//
+// WSO2 LLC. licenses this file to you under the Apache License,
+// Version 2.0 (the "License"); you may not use this file except
+// in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+package dto
+
+type CategoryResponse struct {
+	ID          int     `json:"id"`
+	Name        string  `json:"name"`
+	Slug        string  `json:"slug"`
+	Description *string `json:"description,omitempty"`
+}
+
+type CreateCategoryRequest struct {
+	Name        string  `json:"name" validate:"required,max=255"`
+	Slug        string  `json:"slug" validate:"required,max=64"`
+	Description *string `json:"description,omitempty"`
+}
+
+// SetMicroAppCategoriesRequest replaces the categories of a micro app; an empty list clears them
+type SetMicroAppCategoriesRequest struct {
+	CategorySlugs []string `json:"categorySlugs" validate:"required"`
+}
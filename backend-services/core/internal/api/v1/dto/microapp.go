@@ -25,6 +25,7 @@ type MicroAppResponse struct {
 	Versions    []MicroAppVersionResponse `json:"versions,omitempty"`
 	Roles       []MicroAppRoleResponse    `json:"roles,omitempty"`
 	Configs     []MicroAppConfigResponse  `json:"configs,omitempty"`
+	Categories  []CategoryResponse        `json:"categories,omitempty"`
 }
 
 type CreateMicroAppRequest struct {
@@ -36,4 +37,14 @@ type CreateMicroAppRequest struct {
 	Versions    []CreateMicroAppVersionRequest `json:"versions,omitempty" validate:"omitempty,dive"`
 	Roles       []CreateMicroAppRoleRequest    `json:"roles,omitempty" validate:"omitempty,dive"`
 	Configs     []CreateMicroAppConfigRequest  `json:"configs,omitempty" validate:"omitempty,dive"`
+	// CategorySlugs replaces the micro app's categories when set; an empty list clears them
+	CategorySlugs []string `json:"categorySlugs,omitempty"`
+}
+
+// PaginatedMicroAppsResponse is one page of micro apps matching the list filters
+type PaginatedMicroAppsResponse struct {
+	Items []MicroAppResponse `json:"items"`
+	Total int                `json:"total"` // micro apps matching the filters, across all pages
+	Page  int                `json:"page"`
+	Limit int                `json:"limit"`
 }
@@ -15,7 +15,10 @@
 // under the License.
 package dto
 
-import "encoding/json"
+import (
+	"encoding/json"
+	"time"
+)
 
 type MicroAppConfigResponse struct {
 	ConfigKey   string          `json:"configKey"`
@@ -26,3 +29,21 @@ type CreateMicroAppConfigRequest struct {
 	ConfigKey   string          `json:"configKey" validate:"required"`
 	ConfigValue json.RawMessage `json:"configValue" validate:"required"`
 }
+
+// MicroAppConfigConflictResponse is an upsert that overwrote a value another user had changed shortly before
+type MicroAppConfigConflictResponse struct {
+	ID           int64           `json:"id"`
+	ConfigKey    string          `json:"configKey"`
+	OldValue     json.RawMessage `json:"oldValue"`
+	NewValue     json.RawMessage `json:"newValue"`
+	OldUpdatedBy string          `json:"oldUpdatedBy"`
+	OldUpdatedAt time.Time       `json:"oldUpdatedAt"`
+	NewUpdatedBy string          `json:"newUpdatedBy"`
+	DetectedAt   time.Time       `json:"detectedAt"`
+}
+
+type MicroAppConfigConflictsResponse struct {
+	Conflicts []MicroAppConfigConflictResponse `json:"conflicts"`
+	Limit     int                              `json:"limit"`
+	Offset    int                              `json:"offset"`
+}
@@ -16,14 +16,16 @@
 package dto
 
 type MicroAppVersionResponse struct {
-	ID           int     `json:"id"`
-	MicroAppID   string  `json:"microAppId"`
-	Version      string  `json:"version"`
-	Build        int     `json:"build"`
-	ReleaseNotes *string `json:"releaseNotes,omitempty"`
-	IconURL      *string `json:"iconUrl,omitempty"`
-	DownloadURL  string  `json:"downloadUrl"`
-	Active       int     `json:"active"`
+	ID            int     `json:"id"`
+	MicroAppID    string  `json:"microAppId"`
+	Version       string  `json:"version"`
+	Build         int     `json:"build"`
+	ReleaseNotes  *string `json:"releaseNotes,omitempty"`
+	IconURL       *string `json:"iconUrl,omitempty"`
+	DownloadURL   string  `json:"downloadUrl"`
+	ContentSHA256 *string `json:"contentSha256,omitempty"`
+	Signature     *string `json:"signature,omitempty"`
+	Active        int     `json:"active"`
 }
 
 type CreateMicroAppVersionRequest struct {
@@ -32,4 +34,8 @@ type CreateMicroAppVersionRequest struct {
 	ReleaseNotes *string `json:"releaseNotes,omitempty"`
 	IconURL      *string `json:"iconUrl,omitempty"`
 	DownloadURL  string  `json:"downloadUrl" validate:"required"`
+	// ContentSHA256 is only needed for bundles uploaded without the core service; otherwise the
+	// checksum recorded at upload is used, and one given here must match it
+	ContentSHA256 *string `json:"contentSha256,omitempty" validate:"omitempty,len=64,hexadecimal"`
+	Signature     *string `json:"signature,omitempty" validate:"omitempty,max=4096"`
 }
@@ -15,27 +15,341 @@
 // under the License.
 package dto
 
+import "time"
+
 type RegisterDeviceTokenRequest struct {
 	Email    string `json:"email" validate:"required,email"`
 	Token    string `json:"token" validate:"required"`
-	Platform string `json:"platform" validate:"required,oneof=ios android"`
+	Platform string `json:"platform" validate:"required,oneof=ios android web"`
+	AppBuild *int   `json:"appBuild,omitempty" validate:"omitempty,min=0"` // app build number, used to target minBuild sends
 }
 
 type DeactivateDeviceTokenRequest struct {
 	Email    string `json:"email" validate:"required,email"`
 	Token    string `json:"token" validate:"required"`
-	Platform string `json:"platform" validate:"required,oneof=ios android"`
+	Platform string `json:"platform" validate:"required,oneof=ios android web"`
+}
+
+type RevokeUserDevicesRequest struct {
+	Email string `json:"email" validate:"required,email"`
+}
+
+type RevokeUserDevicesResponse struct {
+	Email                           string `json:"email"`
+	DevicesDeactivated              int64  `json:"devicesDeactivated"`
+	ScheduledNotificationsUpdated   int64  `json:"scheduledNotificationsUpdated"`
+	ScheduledNotificationsCancelled int64  `json:"scheduledNotificationsCancelled"`
+	GroupMembershipsRemoved         int64  `json:"groupMembershipsRemoved"`
+}
+
+type DeactivateDevicesResponse struct {
+	DevicesDeactivated int64 `json:"devicesDeactivated"`
+}
+
+// DeviceResponse is one of the caller's registered devices
+type DeviceResponse struct {
+	ID           int64     `json:"id"`
+	Platform     string    `json:"platform"`
+	Token        string    `json:"token"`
+	AppBuild     *int      `json:"appBuild,omitempty"`
+	RegisteredAt time.Time `json:"registeredAt"`
+	UpdatedAt    time.Time `json:"updatedAt"`
+}
+
+type DeviceListResponse struct {
+	Devices []DeviceResponse `json:"devices"`
 }
 
 type SendNotificationRequest struct {
-	UserEmails []string               `json:"userEmails" validate:"required,min=1,dive,email"`
-	Title      string                 `json:"title" validate:"required"`
-	Body       string                 `json:"body" validate:"required"`
+	UserEmails []string               `json:"userEmails" validate:"required_without=Topics,omitempty,min=1,dive,email"`
+	Topics     []string               `json:"topics,omitempty" validate:"required_without=UserEmails,omitempty,max=20,dive,required,max=200"` // microapp topics, sent without loading device tokens
+	Title      string                 `json:"title" validate:"required_without=TemplateKey"`
+	Body       string                 `json:"body" validate:"required_without=TemplateKey"`
 	Data       map[string]interface{} `json:"data,omitempty"`
+	Receipt    bool                   `json:"receipt,omitempty"` // issue a signed receipt per recipient
+	// Recipients already sent this dedup key within maxAgeSeconds are skipped
+	DedupKey      string `json:"dedupKey,omitempty" validate:"omitempty,max=255"`
+	MaxAgeSeconds int    `json:"maxAgeSeconds,omitempty" validate:"required_with=DedupKey,omitempty,min=1,max=2592000"`
+	// Devices replace an earlier notification with the same collapse key instead of stacking it
+	CollapseKey string `json:"collapseKey,omitempty" validate:"omitempty,max=64"`
+	// Seconds to keep the notification for an offline device; 0 delivers now or never, omitting it uses the provider default
+	TTL *int `json:"ttl,omitempty" validate:"omitempty,min=0,max=2419200"`
+	// Image shown in the expanded notification; must be served over https
+	ImageURL string `json:"imageUrl,omitempty" validate:"omitempty,max=2048,url,startswith=https://"`
+	// iOS app icon badge count; 0 clears the badge and omitting it sets the badge to 1
+	Badge *int `json:"badge,omitempty" validate:"omitempty,min=0,max=99999"`
+	// Recipients whose latest registered device reports an older app build, or none, are skipped
+	MinBuild int `json:"minBuild,omitempty" validate:"omitempty,min=1"`
+	// Localized copies keyed by locale; users without a matching locale get Title and Body
+	Localized map[string]LocalizedContent `json:"localized,omitempty" validate:"omitempty,max=50,dive,keys,required,max=35,endkeys"`
+	// Queue the notification for the scheduled notification worker instead of sending it now
+	ScheduledAt *time.Time `json:"scheduledAt,omitempty"`
+	// What to do when the recipients have more devices than one send allows: truncate (default) or reject
+	TokenLimit string `json:"tokenLimit,omitempty" validate:"omitempty,oneof=truncate reject"`
+	// Stored template that fills in Title and Body when they are empty, rendered with TemplateVars
+	TemplateKey  string            `json:"templateKey,omitempty" validate:"omitempty,max=100"`
+	TemplateVars map[string]string `json:"templateVars,omitempty"`
+}
+
+// LocalizedContent is the title and body of a notification in one locale
+type LocalizedContent struct {
+	Title string `json:"title" validate:"required"`
+	Body  string `json:"body" validate:"required"`
 }
 
 type NotificationResponse struct {
+	Success           int                          `json:"success"`
+	Failed            int                          `json:"failed"`
+	SkippedDuplicates int                          `json:"skippedDuplicates,omitempty"`
+	SkippedBelowBuild int                          `json:"skippedBelowMinBuild,omitempty"`
+	SkippedOptedOut   int                          `json:"skippedOptedOut,omitempty"`
+	Dropped           int                          `json:"dropped,omitempty"` // devices beyond the per-send limit, included in Failed
+	Message           string                       `json:"message"`
+	Receipts          []NotificationReceiptSummary `json:"receipts,omitempty"`
+	Topics            []TopicSendResult            `json:"topics,omitempty"`
+	Locales           []LocaleSendResult           `json:"locales,omitempty"`
+}
+
+// LocaleSendResult is the outcome for the recipients of one localized copy; Locale is empty for the default copy
+type LocaleSendResult struct {
+	Locale  string `json:"locale"`
+	Users   int    `json:"users"`
+	Success int    `json:"success"`
+	Failed  int    `json:"failed"`
+}
+
+// TopicSendResult is the outcome of sending to one topic of a SendNotificationRequest
+type TopicSendResult struct {
+	Topic     string `json:"topic"`
+	MessageID string `json:"messageId,omitempty"`
+	Error     string `json:"error,omitempty"`
+}
+
+type NotificationReceiptSummary struct {
+	ID        int64  `json:"id"`
+	Recipient string `json:"recipient"`
+}
+
+type NotificationReceiptResponse struct {
+	ID          int64     `json:"id"`
+	MicroappID  string    `json:"microappId"`
+	Recipient   string    `json:"recipient"`
+	ContentHash string    `json:"contentHash"`
+	Status      string    `json:"status"`
+	IssuedAt    time.Time `json:"issuedAt"`
+	KeyID       string    `json:"keyId"`
+	Signature   string    `json:"signature"`
+	Verified    bool      `json:"verified"`
+}
+
+type SendToGroupsRequest struct {
+	Groups []string               `json:"groups" validate:"required,min=1,dive,required"`
+	Title  string                 `json:"title" validate:"required"`
+	Body   string                 `json:"body" validate:"required"`
+	Data   map[string]interface{} `json:"data,omitempty"`
+}
+
+type PreviewSendToGroupsRequest struct {
+	Groups []string `json:"groups" validate:"required,min=1,dive,required"`
+}
+
+// GroupAudiencePreview is the reach of one group; users already counted under an earlier group are excluded
+type GroupAudiencePreview struct {
+	Group           string `json:"group"`
+	Users           int    `json:"users"` // users who would be sent to, after opt-outs
+	SkippedOptedOut int    `json:"skippedOptedOut,omitempty"`
+	Devices         int    `json:"devices"` // unique active device tokens
+}
+
+type PreviewSendToGroupsResponse struct {
+	Groups          []GroupAudiencePreview `json:"groups"`
+	Users           int                    `json:"users"`
+	SkippedOptedOut int                    `json:"skippedOptedOut,omitempty"`
+	Devices         int                    `json:"devices"`
+	Warnings        []string               `json:"warnings"`
+}
+
+type GroupNotificationResult struct {
+	Group           string `json:"group"`
+	Users           int    `json:"users"`
+	SkippedOptedOut int    `json:"skippedOptedOut,omitempty"`
+	Success         int    `json:"success"`
+	Failed          int    `json:"failed"`
+}
+
+type SendToGroupsResponse struct {
+	Groups  []GroupNotificationResult `json:"groups"`
+	Success int                       `json:"success"`
+	Failed  int                       `json:"failed"`
+	Message string                    `json:"message"`
+}
+
+type TopicSubscriptionRequest struct {
+	UserEmails []string `json:"userEmails" validate:"required,min=1,dive,email"`
+	Topic      string   `json:"topic" validate:"required,max=200"`
+}
+
+type TopicSubscriptionResponse struct {
 	Success int    `json:"success"`
 	Failed  int    `json:"failed"`
 	Message string `json:"message"`
 }
+
+type SendTopicNotificationRequest struct {
+	Topic string                 `json:"topic" validate:"required,max=200"`
+	Title string                 `json:"title" validate:"required"`
+	Body  string                 `json:"body" validate:"required"`
+	Data  map[string]interface{} `json:"data,omitempty"`
+}
+
+type TopicNotificationResponse struct {
+	MessageID string `json:"messageId"`
+	Message   string `json:"message"`
+}
+
+type ScheduleNotificationRequest struct {
+	UserEmails []string               `json:"userEmails" validate:"required,min=1,dive,email"`
+	Title      string                 `json:"title" validate:"required"`
+	Body       string                 `json:"body" validate:"required"`
+	Data       map[string]interface{} `json:"data,omitempty"`
+	SendAt     time.Time              `json:"sendAt" validate:"required"`
+}
+
+type ScheduledNotificationResponse struct {
+	ID             int64     `json:"id"`
+	SendAt         time.Time `json:"sendAt"`
+	Status         string    `json:"status"`
+	CoalescedSends int       `json:"coalescedSends,omitempty"` // Sends merged into a coalesced notification, including this one
+}
+
+type NotificationHistoryItem struct {
+	ID         int64                  `json:"id"`
+	MicroappID string                 `json:"microappId,omitempty"`
+	Title      string                 `json:"title"`
+	Body       string                 `json:"body"`
+	Data       map[string]interface{} `json:"data,omitempty"`
+	Status     string                 `json:"status"`
+	SentAt     time.Time              `json:"sentAt"`
+	ReadAt     *time.Time             `json:"readAt,omitempty"`
+}
+
+type NotificationHistoryResponse struct {
+	Notifications []NotificationHistoryItem `json:"notifications"`
+	Limit         int                       `json:"limit"`
+	Offset        int                       `json:"offset"`
+	NextCursor    string                    `json:"nextCursor,omitempty"` // pass as before= for older notifications
+	PrevCursor    string                    `json:"prevCursor,omitempty"` // pass as after= for newer notifications
+}
+
+type MarkNotificationReadResponse struct {
+	ID     int64     `json:"id"`
+	ReadAt time.Time `json:"readAt"`
+}
+
+type UnreadCountResponse struct {
+	Count int64 `json:"count"`
+}
+
+// DeliveryWebhookRequest reports a delivery state change for the message FCM sent to one device
+type DeliveryWebhookRequest struct {
+	MessageID string `json:"messageId" validate:"required,max=255"`
+	Status    string `json:"status" validate:"required,oneof=delivered failed read"`
+}
+
+type DeliveryWebhookResponse struct {
+	NotificationID int64  `json:"notificationId"`
+	MessageID      string `json:"messageId"`
+	Status         string `json:"status"`         // status of the device the message was sent to
+	DeliveryStatus string `json:"deliveryStatus"` // status of the notification across the recipient's devices
+}
+
+type DeviceDeliveryStatus struct {
+	FCMMessageID string    `json:"fcmMessageId,omitempty"`
+	Status       string    `json:"status"`
+	UpdatedAt    time.Time `json:"updatedAt"`
+}
+
+type NotificationDeliveryStatusResponse struct {
+	ID             int64                  `json:"id"`
+	Recipient      string                 `json:"recipient"`
+	Status         string                 `json:"status"`
+	DeliveryStatus string                 `json:"deliveryStatus"`
+	FCMMessageID   string                 `json:"fcmMessageId,omitempty"`
+	FailureReason  string                 `json:"failureReason,omitempty"`
+	SentAt         time.Time              `json:"sentAt"`
+	ReadAt         *time.Time             `json:"readAt,omitempty"`
+	Devices        []DeviceDeliveryStatus `json:"devices"`
+}
+
+type UpsertNotificationTemplateRequest struct {
+	TemplateKey string `json:"templateKey" validate:"required,max=100"`
+	Locale      string `json:"locale,omitempty" validate:"omitempty,max=35"` // empty for the default translation
+	Title       string `json:"title" validate:"required,max=1024"`           // text/template source, e.g. "Hi {{.firstName}}"
+	Body        string `json:"body" validate:"required"`
+	// Data sent with notifications that use this template, under keys the sender leaves unset
+	DefaultData map[string]interface{} `json:"defaultData,omitempty"`
+	// Replacements for recipients' devices on a platform, keyed by ios, android or web
+	PlatformOverrides map[string]NotificationTemplateOverride `json:"platformOverrides,omitempty" validate:"omitempty,dive,keys,oneof=ios android web,endkeys"`
+}
+
+// NotificationTemplateOverride replaces the title, body or data of a template on one platform.
+// An empty title or body keeps the base template's.
+type NotificationTemplateOverride struct {
+	Title string                 `json:"title,omitempty" validate:"max=1024"`
+	Body  string                 `json:"body,omitempty"`
+	Data  map[string]interface{} `json:"data,omitempty"`
+}
+
+type NotificationTemplateResponse struct {
+	TemplateKey       string                                  `json:"templateKey"`
+	Locale            string                                  `json:"locale,omitempty"`
+	Title             string                                  `json:"title"`
+	Body              string                                  `json:"body"`
+	DefaultData       map[string]interface{}                  `json:"defaultData,omitempty"`
+	PlatformOverrides map[string]NotificationTemplateOverride `json:"platformOverrides,omitempty"`
+	UpdatedAt         time.Time                               `json:"updatedAt"`
+}
+
+type SendTemplateNotificationRequest struct {
+	TemplateKey string                 `json:"templateKey" validate:"required,max=100"`
+	Locale      string                 `json:"locale,omitempty" validate:"omitempty,max=35"`
+	Recipients  []TemplateRecipient    `json:"recipients" validate:"required,min=1,dive"`
+	Data        map[string]interface{} `json:"data,omitempty"`
+}
+
+// TemplateRecipient is a user and the variables their copy of the template is rendered with
+type TemplateRecipient struct {
+	UserEmail string            `json:"userEmail" validate:"required,email"`
+	Variables map[string]string `json:"variables,omitempty"`
+}
+
+type SendTemplateNotificationResponse struct {
+	Success         int                     `json:"success"`
+	Failed          int                     `json:"failed"`
+	Batches         int                     `json:"batches"` // distinct rendered messages sent
+	SkippedOptedOut int                     `json:"skippedOptedOut,omitempty"`
+	Message         string                  `json:"message"`
+	RenderFailures  []TemplateRenderFailure `json:"renderFailures,omitempty"`
+	Partial         bool                    `json:"partial"`
+	Results         []BatchItemResult       `json:"results"` // render outcome of each distinct recipient, keyed by email
+}
+
+// TemplateRenderFailure is a recipient that was skipped because the template could not be rendered for them
+type TemplateRenderFailure struct {
+	UserEmail string `json:"userEmail"`
+	Error     string `json:"error"`
+}
+
+// NotificationPreference is whether the user opted out of one micro app's notifications
+type NotificationPreference struct {
+	MicroappID string `json:"microappId" validate:"required,max=100"`
+	OptedOut   *bool  `json:"optedOut" validate:"required"`
+}
+
+type NotificationPreferencesRequest struct {
+	Preferences []NotificationPreference `json:"preferences" validate:"required,min=1,max=100,dive"`
+}
+
+type NotificationPreferencesResponse struct {
+	Preferences []NotificationPreference `json:"preferences"`
+}
@@ -15,6 +15,8 @@
 // under the License.
 package dto
 
+import "encoding/json"
+
 type TokenExchangeRequest struct {
 	MicroappID string `json:"microapp_id" validate:"required"`
 	Scope      string `json:"scope,omitempty"`
@@ -25,3 +27,15 @@ type TokenExchangeResponse struct {
 	TokenType   string `json:"token_type"`
 	ExpiresIn   int    `json:"expires_in"`
 }
+
+type TokenPreviewRequest struct {
+	UserEmail  string `json:"user_email" validate:"required,email"`
+	MicroappID string `json:"microapp_id" validate:"required"`
+	Scope      string `json:"scope,omitempty"`
+}
+
+// TokenPreviewResponse holds the claims a user-context token would carry; no token is issued
+type TokenPreviewResponse struct {
+	Claims    json.RawMessage `json:"claims"`
+	ExpiresIn int             `json:"expires_in"`
+}
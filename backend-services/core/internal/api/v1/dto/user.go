@@ -15,6 +15,8 @@
 // under the License.
 package dto
 
+import "time"
+
 type UserResponse struct {
 	Email         string  `json:"workEmail"`
 	FirstName     string  `json:"firstName"`
@@ -23,6 +25,13 @@ type UserResponse struct {
 	Location      *string `json:"location,omitempty"`
 }
 
+type UserListResponse struct {
+	Users  []UserResponse `json:"users"`
+	Total  int64          `json:"total"` // users matching the search, across all pages
+	Limit  int            `json:"limit"`
+	Offset int            `json:"offset"`
+}
+
 type UpsertUserRequest struct {
 	Email         string  `json:"workEmail" validate:"required,email"`
 	FirstName     string  `json:"firstName" validate:"required,min=1"`
@@ -30,3 +39,57 @@ type UpsertUserRequest struct {
 	UserThumbnail *string `json:"userThumbnail,omitempty"`
 	Location      *string `json:"location,omitempty"`
 }
+
+// BulkUpsertUsersResponse follows the batch convention, with a result per user keyed by email
+type BulkUpsertUsersResponse struct {
+	Message  string            `json:"message"`
+	Upserted int               `json:"upserted"`
+	Failed   int               `json:"failed"`
+	Partial  bool              `json:"partial"`
+	Results  []BatchItemResult `json:"results"`
+}
+
+type UserImportJobResponse struct {
+	JobID         int64                `json:"jobId"`
+	Status        string               `json:"status"` // processing or completed
+	Format        string               `json:"format"`
+	TotalRows     int                  `json:"totalRows"`
+	ProcessedRows int                  `json:"processedRows"`
+	ImportedRows  int                  `json:"importedRows"`
+	FailedRows    int                  `json:"failedRows"`
+	Errors        []UserImportRowError `json:"errors,omitempty"`
+	CreatedBy     string               `json:"createdBy"`
+	CreatedAt     time.Time            `json:"createdAt"`
+	CompletedAt   *time.Time           `json:"completedAt,omitempty"`
+}
+
+// UserImportRowError is a user from the import file that was not imported
+type UserImportRowError struct {
+	Row   int    `json:"row"` // 1-based position in the file, not counting the CSV header
+	Email string `json:"workEmail,omitempty"`
+	Error string `json:"error"`
+}
+
+// UserDataExportResponse is the status of a user data export. DownloadURL is set once it has completed.
+type UserDataExportResponse struct {
+	ExportID    int64      `json:"exportId"`
+	UserEmail   string     `json:"userEmail"`
+	Status      string     `json:"status"` // processing, completed or failed
+	Error       *string    `json:"error,omitempty"`
+	SizeBytes   int64      `json:"sizeBytes,omitempty"`
+	DownloadURL string     `json:"downloadUrl,omitempty"`
+	RequestedBy string     `json:"requestedBy"`
+	CreatedAt   time.Time  `json:"createdAt"`
+	CompletedAt *time.Time `json:"completedAt,omitempty"`
+}
+
+// UserErasureResponse is the audit record of a user erasure. Categories holds the action taken
+// ("delete" or "anonymize") and the rows affected per data category.
+type UserErasureResponse struct {
+	ErasureID      int64          `json:"erasureId"`
+	Pseudonym      string         `json:"pseudonym"` // Email of the rows that were anonymized rather than deleted
+	Categories     map[string]any `json:"categories"`
+	ProfileDeleted bool           `json:"profileDeleted"`
+	RequestedBy    string         `json:"requestedBy"`
+	ErasedAt       time.Time      `json:"erasedAt"`
+}
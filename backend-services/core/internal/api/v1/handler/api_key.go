@@ -0,0 +1,253 @@
+// Copyright (c) 2025 WSO2 LLC. (https://www.wso2.com).
+//
+// WSO2 LLC. licenses this file to you under the Apache License,
+// Version 2.0 (the "License"); you may not use this file except
+// in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+package handler
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/opensuperapp/opensuperapp/backend-services/core/internal/api/v1/dto"
+	"github.com/opensuperapp/opensuperapp/backend-services/core/internal/auth"
+	"github.com/opensuperapp/opensuperapp/backend-services/core/internal/models"
+	"github.com/opensuperapp/opensuperapp/backend-services/core/internal/services"
+
+	"github.com/go-chi/chi/v5"
+	"gorm.io/gorm"
+)
+
+type APIKeyHandler struct {
+	db *gorm.DB
+}
+
+func NewAPIKeyHandler(db *gorm.DB) *APIKeyHandler {
+	return &APIKeyHandler{db: db}
+}
+
+// Create issues a new API key for a micro app. The plaintext key is only returned in this response.
+func (h *APIKeyHandler) Create(w http.ResponseWriter, r *http.Request) {
+	userInfo, ok := auth.GetUserInfo(r.Context())
+	if !ok {
+		http.Error(w, errUserInfoNotFound, http.StatusUnauthorized)
+		return
+	}
+	appID := chi.URLParam(r, urlParamAppID)
+	if !validateContentType(w, r) {
+		return
+	}
+	limitRequestBody(w, r, 0)
+	var req dto.CreateAPIKeyRequest
+	if !decodeJSONBody(w, r, &req) {
+		return
+	}
+	if !validateStruct(w, &req) {
+		return
+	}
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(time.Now()) {
+		http.Error(w, errAPIKeyExpiryInPast, http.StatusBadRequest)
+		return
+	}
+	if !h.requireActiveMicroApp(w, r, appID) {
+		return
+	}
+
+	created, err := h.issueKey(h.db, appID, req.Name, strings.Join(req.Scopes, " "), userInfo.Email, req.ExpiresAt)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to create API key", "error", err, "appID", appID)
+		http.Error(w, errFailedToCreateAPIKey, http.StatusInternalServerError)
+		return
+	}
+	slog.InfoContext(r.Context(), "API key created", "key_id", created.ID, "key_prefix", created.Prefix, "appID", appID, "created_by", userInfo.Email)
+	writeJSON(w, http.StatusCreated, created)
+}
+
+// apiKeySort lists API keys in the order they were created by default.
+var apiKeySort = listSort{
+	fields: map[string]string{
+		"id":         "id",
+		"name":       "name",
+		"createdAt":  "created_at",
+		"lastUsedAt": "last_used_at",
+		"expiresAt":  "expires_at",
+	},
+	defaultField: "id",
+	tieBreaker:   "id",
+}
+
+// List returns the API keys of a micro app, including revoked ones, without their secrets.
+// Every key is listed unless a limit or offset selects a page.
+func (h *APIKeyHandler) List(w http.ResponseWriter, r *http.Request) {
+	appID := chi.URLParam(r, urlParamAppID)
+	params, err := parseListParams(r, apiKeySort)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	var keys []models.MicroAppAPIKey
+	query := h.db.Where("microapp_id = ?", appID).Order(params.order)
+	if params.paged {
+		query = query.Limit(params.limit).Offset(params.offset)
+	}
+	if err := query.Find(&keys).Error; err != nil {
+		slog.ErrorContext(r.Context(), "Failed to fetch API keys", "error", err, "appID", appID)
+		http.Error(w, errFailedToFetchAPIKeys, http.StatusInternalServerError)
+		return
+	}
+	response := make([]dto.APIKeyResponse, len(keys))
+	for i, key := range keys {
+		response[i] = toAPIKeyResponse(key)
+	}
+	writeJSON(w, http.StatusOK, response)
+}
+
+// Revoke disables an API key. Requests using it are rejected from then on.
+func (h *APIKeyHandler) Revoke(w http.ResponseWriter, r *http.Request) {
+	userInfo, ok := auth.GetUserInfo(r.Context())
+	if !ok {
+		http.Error(w, errUserInfoNotFound, http.StatusUnauthorized)
+		return
+	}
+	key, ok := h.loadActiveKey(w, r)
+	if !ok {
+		return
+	}
+	if err := h.revoke(h.db, key.ID); err != nil {
+		slog.ErrorContext(r.Context(), "Failed to revoke API key", "error", err, "key_id", key.ID)
+		http.Error(w, errFailedToRevokeAPIKey, http.StatusInternalServerError)
+		return
+	}
+	slog.InfoContext(r.Context(), "API key revoked", "key_id", key.ID, "key_prefix", key.KeyPrefix, "appID", key.MicroappID, "revoked_by", userInfo.Email)
+	writeJSON(w, http.StatusOK, map[string]string{"message": msgAPIKeyRevoked})
+}
+
+// Rotate issues a replacement key with the same name and scopes and revokes the old one.
+// A key that expires is replaced by one with the same lifetime, counted from now.
+func (h *APIKeyHandler) Rotate(w http.ResponseWriter, r *http.Request) {
+	userInfo, ok := auth.GetUserInfo(r.Context())
+	if !ok {
+		http.Error(w, errUserInfoNotFound, http.StatusUnauthorized)
+		return
+	}
+	key, ok := h.loadActiveKey(w, r)
+	if !ok {
+		return
+	}
+
+	var expiresAt *time.Time
+	if key.ExpiresAt != nil {
+		expiry := time.Now().Add(key.ExpiresAt.Sub(key.CreatedAt))
+		expiresAt = &expiry
+	}
+	var created *dto.CreatedAPIKeyResponse
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		if err := h.revoke(tx, key.ID); err != nil {
+			return err
+		}
+		var err error
+		created, err = h.issueKey(tx, key.MicroappID, key.Name, key.Scopes, userInfo.Email, expiresAt)
+		return err
+	})
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to rotate API key", "error", err, "key_id", key.ID)
+		http.Error(w, errFailedToRotateAPIKey, http.StatusInternalServerError)
+		return
+	}
+	slog.InfoContext(r.Context(), "API key rotated", "old_key_id", key.ID, "new_key_id", created.ID, "appID", key.MicroappID, "rotated_by", userInfo.Email)
+	writeJSON(w, http.StatusCreated, created)
+}
+
+// issueKey generates and stores a new key.
+func (h *APIKeyHandler) issueKey(db *gorm.DB, appID, name, scopes, createdBy string, expiresAt *time.Time) (*dto.CreatedAPIKeyResponse, error) {
+	plaintext, prefix, hash, err := services.GenerateAPIKey()
+	if err != nil {
+		return nil, err
+	}
+	key := models.MicroAppAPIKey{
+		MicroappID: appID,
+		Name:       name,
+		KeyPrefix:  prefix,
+		KeyHash:    hash,
+		Scopes:     scopes,
+		CreatedBy:  createdBy,
+		ExpiresAt:  expiresAt,
+	}
+	if err := db.Create(&key).Error; err != nil {
+		return nil, err
+	}
+	return &dto.CreatedAPIKeyResponse{APIKeyResponse: toAPIKeyResponse(key), Key: plaintext}, nil
+}
+
+// revoke marks a key as revoked.
+func (h *APIKeyHandler) revoke(db *gorm.DB, keyID int64) error {
+	return db.Model(&models.MicroAppAPIKey{}).
+		Where("id = ? AND revoked_at IS NULL", keyID).
+		Update("revoked_at", time.Now()).Error
+}
+
+// loadActiveKey loads the unrevoked key named in the URL, writing an error response if there is none.
+func (h *APIKeyHandler) loadActiveKey(w http.ResponseWriter, r *http.Request) (*models.MicroAppAPIKey, bool) {
+	appID := chi.URLParam(r, urlParamAppID)
+	keyID, err := strconv.ParseInt(chi.URLParam(r, urlParamKeyID), 10, 64)
+	if err != nil || keyID <= 0 {
+		http.Error(w, errInvalidAPIKeyID, http.StatusBadRequest)
+		return nil, false
+	}
+	var key models.MicroAppAPIKey
+	if err := h.db.Where("id = ? AND microapp_id = ? AND revoked_at IS NULL", keyID, appID).First(&key).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			http.Error(w, errAPIKeyNotFound, http.StatusNotFound)
+		} else {
+			slog.ErrorContext(r.Context(), "Failed to fetch API key", "error", err, "key_id", keyID)
+			http.Error(w, errFailedToFetchAPIKeys, http.StatusInternalServerError)
+		}
+		return nil, false
+	}
+	return &key, true
+}
+
+// requireActiveMicroApp writes a 404 unless the micro app exists and is active.
+func (h *APIKeyHandler) requireActiveMicroApp(w http.ResponseWriter, r *http.Request, appID string) bool {
+	var count int64
+	if err := h.db.Model(&models.MicroApp{}).
+		Where("micro_app_id = ? AND active = ?", appID, models.StatusActive).
+		Count(&count).Error; err != nil {
+		slog.ErrorContext(r.Context(), "Failed to fetch micro app", "error", err, "appID", appID)
+		http.Error(w, errFailedToFetchMicroApp, http.StatusInternalServerError)
+		return false
+	}
+	if count == 0 {
+		http.Error(w, errMicroAppNotFound, http.StatusNotFound)
+		return false
+	}
+	return true
+}
+
+func toAPIKeyResponse(key models.MicroAppAPIKey) dto.APIKeyResponse {
+	return dto.APIKeyResponse{
+		ID:         key.ID,
+		Name:       key.Name,
+		Prefix:     key.KeyPrefix,
+		Scopes:     strings.Fields(key.Scopes),
+		CreatedBy:  key.CreatedBy,
+		CreatedAt:  key.CreatedAt,
+		LastUsedAt: key.LastUsedAt,
+		ExpiresAt:  key.ExpiresAt,
+		RevokedAt:  key.RevokedAt,
+	}
+}
@@ -0,0 +1,41 @@
+// Copyright (c) 2025 WSO2 LLC. (https://www.wso2.com).
+//
+// WSO2 LLC. licenses this file to you under the Apache License,
+// Version 2.0 (the "License"); you may not use this file except
+// in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+package handler
+
+import (
+	"net/http"
+
+	"github.com/opensuperapp/opensuperapp/backend-services/core/internal/api/v1/dto"
+)
+
+// batchStatus returns the status of a batch response: successStatus when every item succeeded,
+// 207 Multi-Status when only some did and 400 when none did. partial is true for 207.
+func batchStatus(results []dto.BatchItemResult, successStatus int) (status int, partial bool) {
+	succeeded := 0
+	for _, result := range results {
+		if result.Status == dto.BatchItemSucceeded {
+			succeeded++
+		}
+	}
+	switch succeeded {
+	case len(results):
+		return successStatus, false
+	case 0:
+		return http.StatusBadRequest, false
+	default:
+		return http.StatusMultiStatus, true
+	}
+}
@@ -0,0 +1,217 @@
+// Copyright (c) 2025 WSO2 LLC. (https://www.wso2.com).
+//
+// WSO2 LLC. licenses this file to you under the Apache License,
+// Version 2.0 (the "License"); you may not use this file except
+// in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"regexp"
+	"slices"
+
+	"github.com/opensuperapp/opensuperapp/backend-services/core/internal/api/v1/dto"
+	"github.com/opensuperapp/opensuperapp/backend-services/core/internal/auth"
+	"github.com/opensuperapp/opensuperapp/backend-services/core/internal/models"
+
+	"github.com/go-chi/chi/v5"
+	"gorm.io/gorm"
+)
+
+// categorySlugPattern accepts lowercase words joined by single hyphens, e.g. "team-chat"
+var categorySlugPattern = regexp.MustCompile(`^[a-z0-9]+(-[a-z0-9]+)*$`)
+
+// errNoSuchCategory is returned by setMicroAppCategories for a slug no category has
+var errNoSuchCategory = errors.New(errUnknownCategory)
+
+type CategoryHandler struct {
+	db *gorm.DB
+}
+
+func NewCategoryHandler(db *gorm.DB) *CategoryHandler {
+	return &CategoryHandler{db: db}
+}
+
+// List returns every category, by name.
+func (h *CategoryHandler) List(w http.ResponseWriter, r *http.Request) {
+	var categories []models.Category
+	if err := h.db.Order("name, id").Find(&categories).Error; err != nil {
+		slog.ErrorContext(r.Context(), "Failed to fetch categories", "error", err)
+		http.Error(w, errFailedToFetchCategories, http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusOK, toCategoryResponses(categories))
+}
+
+// Create adds a category. Slugs are unique.
+func (h *CategoryHandler) Create(w http.ResponseWriter, r *http.Request) {
+	userInfo, ok := auth.GetUserInfo(r.Context())
+	if !ok {
+		http.Error(w, errUserInfoNotFound, http.StatusUnauthorized)
+		return
+	}
+	if !validateContentType(w, r) {
+		return
+	}
+	limitRequestBody(w, r, 0)
+	var req dto.CreateCategoryRequest
+	if !decodeJSONBody(w, r, &req) {
+		return
+	}
+	if !validateStruct(w, &req) {
+		return
+	}
+	if !categorySlugPattern.MatchString(req.Slug) {
+		http.Error(w, errInvalidCategorySlug, http.StatusBadRequest)
+		return
+	}
+
+	var count int64
+	if err := h.db.Model(&models.Category{}).Where("slug = ?", req.Slug).Count(&count).Error; err != nil {
+		slog.ErrorContext(r.Context(), "Failed to check category slug", "error", err, "slug", req.Slug)
+		http.Error(w, errFailedToCreateCategory, http.StatusInternalServerError)
+		return
+	}
+	if count > 0 {
+		http.Error(w, errCategoryExists, http.StatusConflict)
+		return
+	}
+	category := models.Category{
+		Name:        req.Name,
+		Slug:        req.Slug,
+		Description: req.Description,
+		CreatedBy:   userInfo.Email,
+	}
+	if err := h.db.Create(&category).Error; err != nil {
+		slog.ErrorContext(r.Context(), "Failed to create category", "error", err, "slug", req.Slug)
+		http.Error(w, errFailedToCreateCategory, http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusCreated, toCategoryResponse(category))
+}
+
+// Delete removes a category and its assignments. The micro apps themselves are kept.
+func (h *CategoryHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	slug := chi.URLParam(r, urlParamCategorySlug)
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		var category models.Category
+		if err := tx.Where("slug = ?", slug).First(&category).Error; err != nil {
+			return err
+		}
+		// The foreign key cascades in MySQL; deleting here as well keeps other databases consistent
+		if err := tx.Where("category_id = ?", category.ID).Delete(&models.MicroAppCategory{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&category).Error
+	})
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		http.Error(w, errCategoryNotFound, http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to delete category", "error", err, "slug", slug)
+		http.Error(w, errFailedToDeleteCategory, http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// SetMicroAppCategories replaces the categories a micro app is assigned to.
+func (h *CategoryHandler) SetMicroAppCategories(w http.ResponseWriter, r *http.Request) {
+	microApp, ok := requestMicroApp(w, r, h.db)
+	if !ok {
+		return
+	}
+	if !validateContentType(w, r) {
+		return
+	}
+	limitRequestBody(w, r, 0)
+	var req dto.SetMicroAppCategoriesRequest
+	if !decodeJSONBody(w, r, &req) {
+		return
+	}
+	if !validateStruct(w, &req) {
+		return
+	}
+
+	var categories []models.Category
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		categories, err = setMicroAppCategories(tx, microApp.MicroAppID, req.CategorySlugs)
+		return err
+	})
+	if errors.Is(err, errNoSuchCategory) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to set micro app categories", "error", err, "appID", microApp.MicroAppID)
+		http.Error(w, errFailedToSetCategories, http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusOK, toCategoryResponses(categories))
+}
+
+// setMicroAppCategories replaces the categories of a micro app with those having the given slugs,
+// returned by name. It fails with errNoSuchCategory, naming the slug, when one has no category.
+func setMicroAppCategories(tx *gorm.DB, appID string, slugs []string) ([]models.Category, error) {
+	slugs = slices.Compact(slices.Sorted(slices.Values(slugs)))
+	var categories []models.Category
+	if len(slugs) > 0 {
+		if err := tx.Where("slug IN ?", slugs).Order("name, id").Find(&categories).Error; err != nil {
+			return nil, err
+		}
+	}
+	if len(categories) != len(slugs) {
+		for _, slug := range slugs {
+			if !slices.ContainsFunc(categories, func(c models.Category) bool { return c.Slug == slug }) {
+				return nil, fmt.Errorf("%w: %s", errNoSuchCategory, slug)
+			}
+		}
+	}
+
+	if err := tx.Where("micro_app_id = ?", appID).Delete(&models.MicroAppCategory{}).Error; err != nil {
+		return nil, err
+	}
+	if len(categories) == 0 {
+		return categories, nil
+	}
+	rows := make([]models.MicroAppCategory, len(categories))
+	for i, c := range categories {
+		rows[i] = models.MicroAppCategory{MicroAppID: appID, CategoryID: c.ID}
+	}
+	if err := tx.Create(&rows).Error; err != nil {
+		return nil, err
+	}
+	return categories, nil
+}
+
+func toCategoryResponse(c models.Category) dto.CategoryResponse {
+	return dto.CategoryResponse{
+		ID:          c.ID,
+		Name:        c.Name,
+		Slug:        c.Slug,
+		Description: c.Description,
+	}
+}
+
+func toCategoryResponses(categories []models.Category) []dto.CategoryResponse {
+	response := make([]dto.CategoryResponse, len(categories))
+	for i, c := range categories {
+		response[i] = toCategoryResponse(c)
+	}
+	return response
+}
@@ -0,0 +1,166 @@
+// Copyright (c) 2025 WSO2 LLC. (https://www.wso2.com).
+//
+// WSO2 LLC. licenses this file to you under the Apache License,
+// Version 2.0 (the "License"); you may not use this file except
+// in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+package handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/opensuperapp/opensuperapp/backend-services/core/internal/api/v1/dto"
+	"github.com/opensuperapp/opensuperapp/backend-services/core/internal/auth"
+	"github.com/opensuperapp/opensuperapp/backend-services/core/internal/models"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// createCategory creates a category as an admin and returns the response status
+func createCategory(h *CategoryHandler, name, slug string) int {
+	body, _ := json.Marshal(dto.CreateCategoryRequest{Name: name, Slug: slug})
+	r := httptest.NewRequest(http.MethodPost, "/categories", bytes.NewReader(body))
+	r.Header.Set(headerContentType, contentTypeJSON)
+	r = auth.SetUserInfo(r, &auth.CustomJwtPayload{Email: "admin@example.com"})
+	w := httptest.NewRecorder()
+	h.Create(w, r)
+	return w.Code
+}
+
+// setCategories replaces the categories of a micro app
+func setCategories(h *CategoryHandler, appID string, slugs ...string) *httptest.ResponseRecorder {
+	body, _ := json.Marshal(dto.SetMicroAppCategoriesRequest{CategorySlugs: append([]string{}, slugs...)})
+	r := httptest.NewRequest(http.MethodPut, "/micro-apps/"+appID+"/categories", bytes.NewReader(body))
+	r.Header.Set(headerContentType, contentTypeJSON)
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add(urlParamAppID, appID)
+	r = r.WithContext(context.WithValue(r.Context(), chi.RouteCtxKey, rctx))
+	w := httptest.NewRecorder()
+	h.SetMicroAppCategories(w, r)
+	return w
+}
+
+// appIDs returns the IDs of the micro apps in a page
+func appIDs(page dto.PaginatedMicroAppsResponse) []string {
+	ids := make([]string, len(page.Items))
+	for i, app := range page.Items {
+		ids[i] = app.AppID
+	}
+	return ids
+}
+
+// TestCategories_AssignAndFilter tests creating categories, assigning them to micro apps through
+// both endpoints, and filtering the micro app list by category
+func TestCategories_AssignAndFilter(t *testing.T) {
+	db := setupMicroAppTestDB(t)
+	categories := NewCategoryHandler(db)
+	apps := NewMicroAppHandler(db, 0)
+	for _, appID := range []string{"chat", "payroll", "leave"} {
+		seedMicroApp(t, db, appID, []string{"employees"}, 1)
+	}
+
+	if code := createCategory(categories, "Productivity", "productivity"); code != http.StatusCreated {
+		t.Fatalf("Expected status 201, got %d", code)
+	}
+	if code := createCategory(categories, "Communication", "communication"); code != http.StatusCreated {
+		t.Fatalf("Expected status 201, got %d", code)
+	}
+	if code := createCategory(categories, "Duplicate", "productivity"); code != http.StatusConflict {
+		t.Errorf("Expected status 409 for a duplicate slug, got %d", code)
+	}
+	if code := createCategory(categories, "Bad", "Not A Slug"); code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for an invalid slug, got %d", code)
+	}
+
+	w := setCategories(categories, "chat", "communication", "productivity", "communication")
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var assigned []dto.CategoryResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &assigned); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if len(assigned) != 2 || assigned[0].Slug != "communication" || assigned[1].Slug != "productivity" {
+		t.Errorf("Expected communication and productivity by name, got %+v", assigned)
+	}
+	if w := setCategories(categories, "payroll", "productivity", "finance"); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for an unknown category, got %d", w.Code)
+	}
+
+	// Upserting a micro app with categorySlugs replaces its categories
+	body, _ := json.Marshal(dto.CreateMicroAppRequest{AppID: "payroll", Name: "payroll", CategorySlugs: []string{"productivity"}})
+	r := httptest.NewRequest(http.MethodPost, "/micro-apps", bytes.NewReader(body))
+	r.Header.Set(headerContentType, contentTypeJSON)
+	r = auth.SetUserInfo(r, &auth.CustomJwtPayload{Email: "admin@example.com"})
+	w = httptest.NewRecorder()
+	apps.Upsert(w, r)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status 201, got %d: %s", w.Code, w.Body.String())
+	}
+	var upserted dto.MicroAppResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &upserted); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if len(upserted.Categories) != 1 || upserted.Categories[0].Slug != "productivity" {
+		t.Errorf("Expected payroll in productivity, got %+v", upserted.Categories)
+	}
+
+	page := getMicroAppPage(t, apps, "page=1&category=productivity")
+	if got := appIDs(page); len(got) != 2 || got[0] != "chat" || got[1] != "payroll" || page.Total != 2 {
+		t.Errorf("Expected chat and payroll in productivity, got %v (total %d)", got, page.Total)
+	}
+	if got := appIDs(getMicroAppPage(t, apps, "page=1&category=communication&search=ch")); len(got) != 1 || got[0] != "chat" {
+		t.Errorf("Expected only chat in communication, got %v", got)
+	}
+	if got := appIDs(getMicroAppPage(t, apps, "page=1&category=unused")); len(got) != 0 {
+		t.Errorf("Expected no apps in an unknown category, got %v", got)
+	}
+
+	// Clearing a micro app's categories, and deleting a category, drop their assignments
+	if w := setCategories(categories, "chat"); w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if got := appIDs(getMicroAppPage(t, apps, "page=1&category=productivity")); len(got) != 1 || got[0] != "payroll" {
+		t.Errorf("Expected only payroll in productivity, got %v", got)
+	}
+	r = httptest.NewRequest(http.MethodDelete, "/categories/productivity", nil)
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add(urlParamCategorySlug, "productivity")
+	r = r.WithContext(context.WithValue(r.Context(), chi.RouteCtxKey, rctx))
+	w = httptest.NewRecorder()
+	categories.Delete(w, r)
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("Expected status 204, got %d", w.Code)
+	}
+	var remaining int64
+	db.Model(&models.MicroAppCategory{}).Count(&remaining)
+	if remaining != 0 {
+		t.Errorf("Expected the category's assignments to be deleted, %d remain", remaining)
+	}
+}
+
+// TestGetAll_InvalidCategory tests that a malformed category filter is rejected
+func TestGetAll_InvalidCategory(t *testing.T) {
+	h := NewMicroAppHandler(setupMicroAppTestDB(t), 0)
+	req := httptest.NewRequest(http.MethodGet, "/micro-apps?category=Not%20A%20Slug", nil)
+	req = auth.SetUserInfo(req, &auth.CustomJwtPayload{Email: "alice@example.com", Groups: []string{"employees"}})
+	w := httptest.NewRecorder()
+	h.GetAll(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400, got %d", w.Code)
+	}
+}
@@ -19,26 +19,89 @@ import "time"
 
 const (
 	// Request body size limits
-	defaultMaxRequestBodySize = 1 << 20 // 1MB
-	userRequestBodyLimit      = 1 << 20 // 1MB default limit
-	IdPResponseBodyLimit      = 1 << 20 // 1MB
+	defaultMaxRequestBodySize = 1 << 20  // 1MB
+	userRequestBodyLimit      = 1 << 20  // 1MB default limit
+	userImportBodyLimit       = 10 << 20 // 10MB
+	IdPResponseBodyLimit      = 1 << 20  // 1MB
 
 	// HTTP timeout
 	defaultHTTPTimeout = 10 * time.Second
 
+	// Pagination
+	defaultPageLimit = 20
+	maxPageLimit     = 100
+	sortOrderAsc     = "asc"
+	sortOrderDesc    = "desc"
+
+	// Longest accepted search term
+	maxSearchLength = 100
+
 	// HTTP Headers and Content Types
 	headerContentType      = "Content-Type"
 	headerCacheControl     = "Cache-Control"
+	headerRetryAfter       = "Retry-After"
+	headerSurrogateKey     = "Surrogate-Key"
+	headerCacheTag         = "Cache-Tag"
+	headerETag             = "ETag"
+	headerIfNoneMatch      = "If-None-Match"
+	headerRange            = "Range"
+	headerIfRange          = "If-Range"
+	headerContentRange     = "Content-Range"
+	headerAcceptRanges     = "Accept-Ranges"
+	headerContentLength    = "Content-Length"
+	headerContentSHA256    = "X-Content-SHA256"
+	acceptRangesBytes      = "bytes"
 	contentTypeHeader      = "Content-Type"
 	contentTypeJSON        = "application/json"
+	contentTypeMultipart   = "multipart/form-data"
 	contentTypeForm        = "application/x-www-form-urlencoded"
+	contentTypeCSV         = "text/csv"
 	contentDisposition     = "Content-Disposition"
+	headerContentTypeOpts  = "X-Content-Type-Options"
+	contentTypeOptsNoSniff = "nosniff"
 	applicationOctetStream = "application/octet-stream"
 	cacheControlPublic     = "public, max-age=3600"
+	cacheControlNoCache    = "public, no-cache" // cacheable, but revalidated with the ETag before each use
+
+	// Surrogate key of JWKS responses, purged from the CDN by the token service when its keys change
+	surrogateKeyJWKS = "jwks"
+
+	// Device platforms. Web tokens are validated separately on registration
+	platformIOS     = "ios"
+	platformAndroid = "android"
+	platformWeb     = "web"
 
 	// URL and Query Parameters
-	QueryParamFileName = "fileName"
-	urlParamAppID      = "appID"
+	QueryParamFileName   = "fileName"
+	urlParamAppID        = "appID"
+	urlParamScheduleID   = "scheduleID"
+	urlParamReceiptID    = "receiptID"
+	urlParamNotifID      = "notificationID"
+	urlParamKeyID        = "keyID"
+	urlParamTemplateKey  = "templateKey"
+	urlParamJobID        = "jobID"
+	urlParamExportID     = "exportID"
+	urlParamCategorySlug = "slug"
+	queryParamLimit      = "limit"
+	queryParamOffset     = "offset"
+	queryParamSort       = "sort"
+	queryParamOrder      = "order"
+	queryParamBefore     = "before"
+	queryParamAfter      = "after"
+	queryParamMicroappID = "microappId"
+	queryParamLatestOnly = "latestOnly"
+	queryParamSearch     = "search"
+	queryParamPage       = "page"
+	queryParamActive     = "active"
+	queryParamMandatory  = "mandatory"
+	queryParamCategory   = "category"
+	queryParamAtomic     = "atomic"
+	queryParamOp         = "op"
+	formFieldFile        = "file"
+
+	// Presigned URL operations
+	presignOpUpload   = "upload"
+	presignOpDownload = "download"
 
 	// Token Types
 	tokenTypeBearer = "Bearer"
@@ -55,6 +118,9 @@ const (
 	// HTTP Methods
 	httpMethodPost = "POST"
 
+	// Notification log failure reasons are stored in a VARCHAR(500) column
+	maxFailureReasonLength = 500
+
 	// Notification Status
 	statusSent           = "sent"
 	statusPartialFailure = "partial_failure"
@@ -77,12 +143,24 @@ const (
 	errDeletingFile      = "error deleting file"
 	errDBfileService     = "This endpoint only works with DB file service"
 	errFileNotFound      = "file not found"
+	errRangeUnsatisfied  = "requested range not satisfiable"
+	errInvalidPresignOp  = "op must be one of: upload, download"
+	errFileTypeDenied    = "file extension must be one of: %s"
+	errFileTypeMismatch  = "file content does not match the %s extension"
+	errPresignFile       = "error creating presigned URL"
+	errPresignNotSupport = "the configured file service does not support presigned URLs"
+	errRecordFileOwner   = "error recording the microapp that owns the file"
+	errRecordChecksum    = "error recording the checksum of the file"
+	errMultipartInvalid  = "invalid multipart/form-data body"
+	errMultipartNoFile   = "multipart upload must include a file part"
 
 	// MicroApp Version Handler Error Messages
 	errMissingMicroAppID     = "missing micro_app_id"
+	errInvalidLatestOnly     = "latestOnly must be true or false"
 	errMicroAppNotFound      = "micro app not found"
 	errFailedToFetchMicroApp = "failed to fetch micro app"
 	errFailedToUpsertVersion = "failed to upsert version"
+	errChecksumMismatch      = "contentSha256 does not match the uploaded file at downloadUrl"
 
 	// MicroApp Handler Error Messages
 	errFailedToFetchMicroApps       = "failed to fetch micro apps"
@@ -96,15 +174,94 @@ const (
 	errUserNotAuthorizedToAccessApp = "User not authorized to access micro app"
 	errNoGroupsFoundForUser         = "No groups found for the user"
 	errNoMicroAppsFoundForGroups    = "No micro apps found for the given groups"
+	errFailedToFetchConfigConflicts = "failed to fetch config conflicts"
 
 	// Notification Handler Error Messages
-	errEmailDoesNotMatchAuthUser       = "email does not match authenticated user"
-	errFailedToRegisterDeviceToken     = "failed to register device token"
-	errFailedToDeactivateDeviceToken   = "failed to deactivate device token"
-	errDeviceTokenNotFound             = "device token not found"
-	errNotificationServiceNotAvailable = "notification service not available"
-	errFailedToFetchDeviceTokens       = "failed to fetch device tokens"
-	errFailedToSendNotifications       = "failed to send notifications"
+	errEmailDoesNotMatchAuthUser        = "email does not match authenticated user"
+	errFailedToRegisterDeviceToken      = "failed to register device token"
+	errUnsupportedPlatform              = "platform must be one of ios, android or web"
+	errInvalidWebPushToken              = "web token must be an FCM registration token from the web SDK, not a push subscription"
+	errFailedToDeactivateDeviceToken    = "failed to deactivate device token"
+	errDeviceTokenNotFound              = "device token not found"
+	errFailedToRevokeUserDevices        = "failed to revoke user devices"
+	errNotificationServiceNotAvailable  = "notification service not available"
+	errFailedToFetchDeviceTokens        = "failed to fetch device tokens"
+	errFailedToFetchUserLocales         = "failed to fetch user locales"
+	errFailedToFetchAppBuilds           = "failed to fetch app builds"
+	errNotificationQuotaExceeded        = "notification quota exceeded"
+	errFailedToCheckQuota               = "failed to check notification quota"
+	errFailedToSendNotifications        = "failed to send notifications"
+	errFailedToResolveGroups            = "failed to resolve group members"
+	errFailedToCheckDuplicates          = "failed to check for duplicate notifications"
+	errFailedToCheckPreferences         = "failed to check notification preferences"
+	errFailedToFetchPreferences         = "failed to fetch notification preferences"
+	errFailedToUpdatePreferences        = "failed to update notification preferences"
+	errFailedToFetchNotificationHistory = "failed to fetch notification history"
+	errInvalidNotificationID            = "invalid notification id"
+	errNotificationNotFound             = "notification not found"
+	errFailedToMarkNotificationRead     = "failed to mark notification as read"
+	errFailedToListDevices              = "failed to list devices"
+	errFailedToCountUnread              = "failed to count unread notifications"
+	errInvalidTopicName                 = "topic may only contain letters, digits and -_.~%"
+	errFailedToSubscribeToTopic         = "failed to subscribe to topic"
+	errFailedToUnsubscribeFromTopic     = "failed to unsubscribe from topic"
+	errFailedToSendTopicNotification    = "failed to send topic notification"
+	errSendAtMustBeInFuture             = "sendAt must be in the future"
+	errScheduledAtMustBeInFuture        = "scheduledAt must be in the future"
+	errScheduledSendUnsupported         = "scheduledAt cannot be combined with topics, receipt, dedupKey, minBuild, localized or the reject tokenLimit"
+	errScheduledPlatformOverrides       = "scheduledAt cannot be combined with a template that has platform overrides"
+	errFailedToScheduleNotification     = "failed to schedule notification"
+	errFailedToCoalesceNotification     = "failed to coalesce notification"
+	errInvalidScheduleID                = "invalid schedule id"
+	errScheduledNotificationNotFound    = "scheduled notification not found"
+	errScheduledNotificationNotPending  = "scheduled notification is no longer pending"
+	errFailedToCancelNotification       = "failed to cancel scheduled notification"
+	errReceiptsNotConfigured            = "notification receipts are not configured"
+	errInvalidReceiptID                 = "invalid receipt id"
+	errReceiptNotFound                  = "notification receipt not found"
+	errFailedToFetchReceipt             = "failed to fetch notification receipt"
+	errInvalidNotificationTemplate      = "invalid notification template"
+	errFailedToUpsertTemplate           = "failed to upsert notification template"
+	errNotificationTemplateNotFound     = "notification template not found"
+	errFailedToFetchTemplate            = "failed to fetch notification template"
+	errFailedToRenderTemplate           = "failed to render notification template"
+	errFailedToDeleteTemplate           = "failed to delete notification template"
+	errTokenLimitExceeded               = "recipients have more devices than one send allows"
+	errTokenLimitRejectWithTopics       = "the reject tokenLimit cannot be combined with topics"
+	errImageURLNotAllowed               = "imageUrl must be an asset uploaded for this microapp or hosted on an allowed host"
+	errFailedToCheckImageURL            = "failed to check imageUrl"
+	errDeliveryNotFound                 = "notification delivery not found"
+	errFailedToUpdateDeliveryStatus     = "failed to update delivery status"
+	errFailedToFetchDeliveryStatus      = "failed to fetch delivery status"
+
+	// API Key Handler Error Messages
+	errFailedToCreateAPIKey = "failed to create API key"
+	errFailedToFetchAPIKeys = "failed to fetch API keys"
+	errFailedToRevokeAPIKey = "failed to revoke API key"
+	errFailedToRotateAPIKey = "failed to rotate API key"
+	errInvalidAPIKeyID      = "invalid API key id"
+	errAPIKeyNotFound       = "API key not found"
+	errAPIKeyExpiryInPast   = "expiresAt must be in the future"
+
+	// Group Send Preview Warnings
+	warnGroupTruncated    = "group %s has %d active devices; only the first %d are sent to per request, %d would be dropped"
+	warnGroupHasNoDevices = "group %s has users but none of them has an active device"
+
+	// Pagination Error Messages
+	errInvalidLimit   = "limit must be a positive integer"
+	errInvalidPage    = "page must be a positive integer"
+	errPageOffset     = "use only one of page and offset"
+	errInvalidFlag    = "%s must be 0 or 1"
+	errSearchTooLong  = "search must be at most 100 characters"
+	errInvalidOffset  = "offset must be a non-negative integer"
+	errInvalidCursor  = "invalid cursor"
+	errCursorConflict = "use only one of before, after and offset"
+	errInvalidSort    = "sort must be one of: %s"
+	errInvalidOrder   = "order must be asc or desc"
+	errCursorOrder    = "before and after can only be used with the default sort and order"
+
+	// Debug Handler Error Messages
+	errUnknownValidator = "unknown validator"
 
 	// Token Handler Error Messages
 	errMicroAppNotFoundOrInactive = "microapp not found or inactive"
@@ -135,7 +292,40 @@ const (
 	errFailedToUpsertBulkUsers = "failed to upsert bulk users"
 	errFailedToUpsertUser      = "failed to upsert user"
 	errMissingEmailParameter   = "missing email parameter"
+	errInvalidEmailParameter   = "email parameter must be a valid email address"
 	errFailedToDeleteUser      = "failed to delete user"
+	errInvalidAtomic           = "atomic must be true or false"
+	errBulkUsersNotUpserted    = "No users were upserted because some are invalid"
+
+	// User Import Handler Error Messages
+	errUnsupportedImportFormat = "Content-Type must be text/csv or application/json"
+	errInvalidImportFile       = "invalid import file"
+	errEmptyImportFile         = "import file has no users"
+	errFailedToCreateImportJob = "failed to create user import job"
+	errInvalidImportJobID      = "invalid import job ID"
+	errImportJobNotFound       = "user import job not found"
+	errFailedToFetchImportJob  = "failed to fetch user import job"
+
+	// User Data Export Handler Error Messages
+	errDataExportForbidden     = "only admins can export another user's data"
+	errFailedToCreateExport    = "failed to create user data export"
+	errInvalidDataExportID     = "invalid data export ID"
+	errDataExportNotFound      = "user data export not found"
+	errFailedToFetchDataExport = "failed to fetch user data export"
+	errDataExportNotReady      = "user data export has not completed"
+
+	// User Erasure Handler Error Messages
+	errFailedToEraseUser = "failed to erase user data"
+
+	// Category Handler Error Messages
+	errInvalidCategorySlug     = "slug must be lowercase letters and digits, joined by single hyphens"
+	errCategoryExists          = "a category with this slug already exists"
+	errCategoryNotFound        = "category not found"
+	errUnknownCategory         = "unknown category"
+	errFailedToFetchCategories = "failed to fetch categories"
+	errFailedToCreateCategory  = "failed to create category"
+	errFailedToDeleteCategory  = "failed to delete category"
+	errFailedToSetCategories   = "failed to set micro app categories"
 
 	// URL Parameters
 	paramEmail = "email"
@@ -144,9 +334,19 @@ const (
 	msgSuccessFileUpload                = "File uploaded successfully."
 	msgMicroAppDeactivatedSuccessfully  = "Micro app deactivated successfully"
 	msgNoActiveDeviceTokensFound        = "No active device tokens found"
+	msgAllRecipientsDeduplicated        = "All recipients were already notified within the dedup window"
+	msgAllRecipientsBelowMinBuild       = "No recipient has an app build at or above the minimum"
+	msgAllRecipientsOptedOut            = "All recipients opted out of this micro app's notifications"
 	msgNotificationsSentSuccessfully    = "Notifications sent successfully"
+	msgTopicSubscriptionUpdated         = "Topic subscriptions updated"
+	msgTopicNotificationSent            = "Topic notification sent successfully"
+	msgScheduledNotificationCancelled   = "Scheduled notification cancelled"
+	msgNoTemplateRecipientsRendered     = "Template could not be rendered for any recipient"
+	msgAPIKeyRevoked                    = "API key revoked"
 	msgConfigurationUpdatedSuccessfully = "Configuration updated successfully"
 	msgUsersBulkSuccess                 = "Users created/updated successfully"
+	msgUsersBulkPartial                 = "Some users were created/updated; the invalid ones were skipped"
 	msgUserUpsertSuccess                = "User created/updated successfully"
+	msgPermissionsReloaded              = "Permission cache reloaded"
 	msgUserDeleteSuccess                = "User deleted successfully"
 )
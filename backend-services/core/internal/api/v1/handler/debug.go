@@ -0,0 +1,79 @@
+// Copyright (c) 2025 WSO2 LLC. (https://www.wso2.com).
+//
+// WSO2 LLC. licenses this file to you under the Apache License,
+// Version 2.0 (the "License"); you may not use this file except
+// in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+package handler
+
+import (
+	"log/slog"
+	"net/http"
+	"sort"
+
+	"github.com/opensuperapp/opensuperapp/backend-services/core/internal/services"
+)
+
+const queryParamValidator = "validator"
+
+// DebugHandler serves diagnostic endpoints for tuning the token validation path.
+// It is only mounted when debug endpoints are enabled and never exposes key material.
+type DebugHandler struct {
+	validators map[string]services.JWKSCacheInspector
+}
+
+func NewDebugHandler(validators map[string]services.JWKSCacheInspector) *DebugHandler {
+	return &DebugHandler{
+		validators: validators,
+	}
+}
+
+// GetJWKSCacheStats returns the JWKS cache state of every registered validator.
+func (h *DebugHandler) GetJWKSCacheStats(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, h.collectStats(h.validatorNames()))
+}
+
+// RefreshJWKSCache forces a JWKS refresh for one validator (?validator=name) or all of them.
+func (h *DebugHandler) RefreshJWKSCache(w http.ResponseWriter, r *http.Request) {
+	names := h.validatorNames()
+	if name := r.URL.Query().Get(queryParamValidator); name != "" {
+		if _, ok := h.validators[name]; !ok {
+			http.Error(w, errUnknownValidator, http.StatusNotFound)
+			return
+		}
+		names = []string{name}
+	}
+	for _, name := range names {
+		if err := h.validators[name].ForceRefresh(); err != nil {
+			// The failure is recorded in lastRefreshError and returned with the stats
+			slog.WarnContext(r.Context(), "Forced JWKS refresh failed", "validator", name, "error", err)
+		}
+	}
+	writeJSON(w, http.StatusOK, h.collectStats(names))
+}
+
+func (h *DebugHandler) validatorNames() []string {
+	names := make([]string, 0, len(h.validators))
+	for name := range h.validators {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (h *DebugHandler) collectStats(names []string) map[string]services.JWKSCacheStats {
+	stats := make(map[string]services.JWKSCacheStats, len(names))
+	for _, name := range names {
+		stats[name] = h.validators[name].CacheStats()
+	}
+	return stats
+}
@@ -16,13 +16,24 @@
 package handler
 
 import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"io"
 	"log/slog"
+	"maps"
+	"mime"
 	"net/http"
 	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
 
+	"github.com/opensuperapp/opensuperapp/backend-services/core/internal/models"
+	"github.com/opensuperapp/opensuperapp/backend-services/core/internal/services"
 	fileservice "github.com/opensuperapp/opensuperapp/backend-services/core/plugins/file-service"
 
 	"github.com/go-chi/chi/v5"
@@ -30,13 +41,33 @@ import (
 )
 
 type fileUploadResponse struct {
-	Message     string `json:"message"`
-	DownloadURL string `json:"downloadUrl"`
+	Message       string `json:"message"`
+	DownloadURL   string `json:"downloadUrl"`
+	ContentSHA256 string `json:"contentSha256"`
 }
 
+type presignResponse struct {
+	URL       string    `json:"url"`
+	Method    string    `json:"method"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+// allowedFileTypes maps the extensions of files that can be stored (microapp bundles and the
+// icon and banner images) to the content type http.DetectContentType must find in their bytes
+var allowedFileTypes = map[string]string{
+	".zip":  "application/zip",
+	".png":  "image/png",
+	".jpg":  "image/jpeg",
+	".jpeg": "image/jpeg",
+}
+
+// sniffLen is how many leading bytes http.DetectContentType considers
+const sniffLen = 512
+
 type FileHandler struct {
 	fileService   fileservice.FileService
-	maxUploadSize int64 // Maximum upload size in bytes
+	maxUploadSize int64    // Maximum upload size in bytes
+	db            *gorm.DB // Records the microapp that owns each upload; nil ignores microappId
 }
 
 func NewFileHandler(fileService fileservice.FileService, maxUploadSizeMB int) *FileHandler {
@@ -46,40 +77,147 @@ func NewFileHandler(fileService fileservice.FileService, maxUploadSizeMB int) *F
 	}
 }
 
+// WithAssetOwners records the microapp named by the microappId query parameter of an upload as
+// the owner of the file, and returns the handler
+func (h *FileHandler) WithAssetOwners(db *gorm.DB) *FileHandler {
+	h.db = db
+	return h
+}
+
 func (h *FileHandler) UploadFile(w http.ResponseWriter, r *http.Request) {
 	fileName, err := validateFileName(r.URL.Query().Get(QueryParamFileName))
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
+	wantType, ok := allowedFileType(fileName)
+	if !ok {
+		http.Error(w, fileTypeDeniedMessage(), http.StatusBadRequest)
+		return
+	}
+	microappID := r.URL.Query().Get(queryParamMicroappID)
+	if microappID != "" && h.db != nil {
+		var count int64
+		if err := h.db.Model(&models.MicroApp{}).Where("micro_app_id = ?", microappID).Count(&count).Error; err != nil {
+			slog.ErrorContext(r.Context(), errFailedToFetchMicroApp, "error", err, "microapp_id", microappID)
+			http.Error(w, errFailedToFetchMicroApp, http.StatusInternalServerError)
+			return
+		}
+		if count == 0 {
+			http.Error(w, errMicroAppNotFound, http.StatusNotFound)
+			return
+		}
+	}
 	r.Body = http.MaxBytesReader(w, r.Body, h.maxUploadSize)
-	content, err := io.ReadAll(r.Body)
+	defer r.Body.Close()
+	body, size, err := uploadBody(r)
 	if err != nil {
-		slog.Error(errReadingBody, "error", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	content := &recordingReader{r: body}
+	head := make([]byte, sniffLen)
+	n, err := io.ReadFull(content, head)
+	if err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, io.ErrUnexpectedEOF) {
+		slog.ErrorContext(r.Context(), errReadingBody, "error", err)
 		http.Error(w, errReadingBody, http.StatusBadRequest)
 		return
 	}
-	defer r.Body.Close()
-	if len(content) == 0 {
+	head = head[:n]
+	if len(head) == 0 {
 		http.Error(w, errFileContentEmpty, http.StatusBadRequest)
 		return
 	}
-	downloadURL, err := h.fileService.UploadFile(fileName, content)
+	// Check the magic bytes so, for example, an HTML page cannot be stored as icon.png
+	if detected := http.DetectContentType(head); detected != wantType {
+		slog.WarnContext(r.Context(), "Rejected upload with mismatched content", "fileName", fileName, "detected", detected, "expected", wantType)
+		http.Error(w, fmt.Sprintf(errFileTypeMismatch, filepath.Ext(fileName)), http.StatusBadRequest)
+		return
+	}
+	// The rest of the content streams to the file service instead of being buffered here, and is
+	// hashed on the way so microapp versions can publish the checksum of their bundle
+	hash := sha256.New()
+	downloadURL, err := h.fileService.UploadFileStream(fileName, io.TeeReader(io.MultiReader(bytes.NewReader(head), content), hash), size)
 	if err != nil {
-		slog.Error(errUploadingFile, "error", err, "fileName", fileName)
+		if content.err != nil {
+			// The client's upload failed or went over the size limit, not the file service
+			slog.ErrorContext(r.Context(), errReadingBody, "error", content.err, "fileName", fileName)
+			http.Error(w, errReadingBody, http.StatusBadRequest)
+			return
+		}
+		slog.ErrorContext(r.Context(), errUploadingFile, "error", err, "fileName", fileName)
 		http.Error(w, errUploadingFile, http.StatusInternalServerError)
 		return
 	}
+	checksum := hex.EncodeToString(hash.Sum(nil))
+	if h.db != nil {
+		// A file replaced without a microappId no longer belongs to its earlier owner
+		if microappID != "" {
+			err = services.RecordMicroAppAsset(h.db, fileName, microappID, downloadURL)
+		} else {
+			err = services.ForgetMicroAppAsset(h.db, fileName)
+		}
+		if err != nil {
+			slog.ErrorContext(r.Context(), errRecordFileOwner, "error", err, "fileName", fileName, "microapp_id", microappID)
+			http.Error(w, errRecordFileOwner, http.StatusInternalServerError)
+			return
+		}
+		if err := services.RecordFileChecksum(h.db, fileName, downloadURL, checksum); err != nil {
+			slog.ErrorContext(r.Context(), errRecordChecksum, "error", err, "fileName", fileName)
+			http.Error(w, errRecordChecksum, http.StatusInternalServerError)
+			return
+		}
+	}
 	response := fileUploadResponse{
-		Message:     msgSuccessFileUpload,
-		DownloadURL: downloadURL,
+		Message:       msgSuccessFileUpload,
+		DownloadURL:   downloadURL,
+		ContentSHA256: checksum,
 	}
 	if err := writeJSON(w, http.StatusCreated, response); err != nil {
-		slog.Error(errFailedToWriteResponse, "error", err)
+		slog.ErrorContext(r.Context(), errFailedToWriteResponse, "error", err)
 		http.Error(w, errFailedToWriteResponse, http.StatusInternalServerError)
 	}
 }
 
+// uploadBody returns the file content of an upload and its size, or -1 when unknown. A
+// multipart/form-data request carries the file in its "file" part; any other request carries
+// it as the whole body.
+func uploadBody(r *http.Request) (io.Reader, int64, error) {
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get(headerContentType))
+	if mediaType != contentTypeMultipart {
+		return r.Body, r.ContentLength, nil
+	}
+	mr, err := r.MultipartReader()
+	if err != nil {
+		return nil, 0, errors.New(errMultipartInvalid)
+	}
+	for {
+		part, err := mr.NextPart()
+		if err != nil {
+			// io.EOF here means the form ended without a file part
+			return nil, 0, errors.New(errMultipartNoFile)
+		}
+		if part.FormName() == formFieldFile {
+			return part, -1, nil
+		}
+	}
+}
+
+// recordingReader keeps the first error of the underlying reader, so a failed upload can be
+// told apart from a failure in the file service reading it
+type recordingReader struct {
+	r   io.Reader
+	err error
+}
+
+func (rr *recordingReader) Read(p []byte) (int, error) {
+	n, err := rr.r.Read(p)
+	if err != nil && err != io.EOF && rr.err == nil {
+		rr.err = err
+	}
+	return n, err
+}
+
 // DeleteFile handles file deletion by fileName
 func (h *FileHandler) DeleteFile(w http.ResponseWriter, r *http.Request) {
 	fileName, err := validateFileName(r.URL.Query().Get(QueryParamFileName))
@@ -89,21 +227,79 @@ func (h *FileHandler) DeleteFile(w http.ResponseWriter, r *http.Request) {
 	}
 	err = h.fileService.DeleteFile(fileName)
 	if err != nil {
-		slog.Error(errDeletingFile, "error", err, "fileName", fileName)
+		slog.ErrorContext(r.Context(), errDeletingFile, "error", err, "fileName", fileName)
 		http.Error(w, errDeletingFile, http.StatusInternalServerError)
 		return
 	}
+	if h.db != nil {
+		if err := services.ForgetMicroAppAsset(h.db, fileName); err != nil {
+			// The file is gone, so a leftover owner row only matches a URL that no longer serves anything
+			slog.WarnContext(r.Context(), "Failed to remove the owner of a deleted file", "error", err, "fileName", fileName)
+		}
+		if err := services.ForgetFileChecksum(h.db, fileName); err != nil {
+			slog.WarnContext(r.Context(), "Failed to remove the checksum of a deleted file", "error", err, "fileName", fileName)
+		}
+	}
 	w.WriteHeader(http.StatusNoContent)
 }
 
-// Note: This handler is only called when FileServiceType is "db",
+// PresignFile returns a presigned URL for uploading or downloading a file directly from the
+// file service, so large microapp bundles do not pass through the core service
+func (h *FileHandler) PresignFile(w http.ResponseWriter, r *http.Request) {
+	fileName, err := validateFileName(r.URL.Query().Get(QueryParamFileName))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if _, ok := allowedFileType(fileName); !ok {
+		http.Error(w, fileTypeDeniedMessage(), http.StatusBadRequest)
+		return
+	}
+
+	var url, method string
+	switch r.URL.Query().Get(queryParamOp) {
+	case presignOpUpload:
+		method = http.MethodPut
+		url, err = h.fileService.PresignUpload(fileName)
+	case presignOpDownload:
+		method = http.MethodGet
+		url, err = h.fileService.PresignDownload(fileName)
+	default:
+		http.Error(w, errInvalidPresignOp, http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		if errors.Is(err, fileservice.ErrPresignUnsupported) {
+			http.Error(w, errPresignNotSupport, http.StatusNotImplemented)
+			return
+		}
+		slog.ErrorContext(r.Context(), errPresignFile, "error", err, "fileName", fileName)
+		http.Error(w, errPresignFile, http.StatusInternalServerError)
+		return
+	}
+
+	response := presignResponse{
+		URL:       url,
+		Method:    method,
+		ExpiresAt: time.Now().Add(fileservice.PresignExpiry).UTC(),
+	}
+	if err := writeJSON(w, http.StatusOK, response); err != nil {
+		slog.ErrorContext(r.Context(), errFailedToWriteResponse, "error", err)
+		http.Error(w, errFailedToWriteResponse, http.StatusInternalServerError)
+	}
+}
+
+// Note: The download route is only registered when FileServiceType is "db",
 //
 //	so the service will always be database as the file service.
 type DBFileService interface {
-	GetBlobContent(fileName string) ([]byte, error)
+	// OpenBlob returns the file's size, content type and ETag, and reads ranges of its content on demand
+	OpenBlob(fileName string) (fileservice.Blob, error)
 }
 
-// DownloadMicroAppFile handles public file download
+// DownloadMicroAppFile handles public file download. A single byte range may be requested with
+// the Range header, so interrupted downloads of large bundles can be resumed. The X-Content-SHA256
+// header always carries the checksum of the whole file, for clients to verify once they have it all.
 func (h *FileHandler) DownloadMicroAppFile(w http.ResponseWriter, r *http.Request) {
 	fileName, err := validateFileName(chi.URLParam(r, QueryParamFileName))
 	if err != nil {
@@ -115,27 +311,142 @@ func (h *FileHandler) DownloadMicroAppFile(w http.ResponseWriter, r *http.Reques
 		http.Error(w, errDBfileService, http.StatusInternalServerError)
 		return
 	}
-	content, err := dbService.GetBlobContent(fileName)
+	blob, err := dbService.OpenBlob(fileName)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			http.Error(w, errFileNotFound, http.StatusNotFound)
 			return
 		}
-		slog.Error(errDownloadingFile, "error", err, "fileName", fileName)
+		slog.ErrorContext(r.Context(), errDownloadingFile, "error", err, "fileName", fileName)
 		http.Error(w, errDownloadingFile, http.StatusInternalServerError)
 		return
 	}
+	// Files can be replaced under the same name, so clients revalidate and skip unchanged downloads
+	etag := blob.ETag()
+	w.Header().Set(headerETag, etag)
+	w.Header().Set(headerContentSHA256, blob.SHA256())
+	w.Header().Set(headerCacheControl, cacheControlNoCache)
+	w.Header().Set(headerAcceptRanges, acceptRangesBytes)
+	if etagMatches(r.Header.Get(headerIfNoneMatch), etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	// A range is only served while the file is still the one the client started downloading
+	size := blob.Size()
+	offset, length, status := int64(0), size, http.StatusOK
+	if rangeHeader := r.Header.Get(headerRange); rangeHeader != "" && ifRangeMatches(r.Header.Get(headerIfRange), etag) {
+		start, n, ok, err := parseByteRange(rangeHeader, size)
+		if err != nil {
+			w.Header().Set(headerContentRange, fmt.Sprintf("bytes */%d", size))
+			http.Error(w, err.Error(), http.StatusRequestedRangeNotSatisfiable)
+			return
+		}
+		if ok {
+			offset, length, status = start, n, http.StatusPartialContent
+			w.Header().Set(headerContentRange, fmt.Sprintf("bytes %d-%d/%d", start, start+n-1, size))
+		}
+	}
+	body, err := blob.ReadRange(offset, length)
+	if err != nil {
+		slog.ErrorContext(r.Context(), errDownloadingFile, "error", err, "fileName", fileName)
+		http.Error(w, errDownloadingFile, http.StatusInternalServerError)
+		return
+	}
+	defer body.Close()
+
 	safeFileName := sanitizeForHeader(fileName)
-	w.Header().Set(contentTypeHeader, applicationOctetStream)
+	contentType := blob.ContentType()
+	// Files stored before content types were recorded have none
+	if contentType == "" {
+		contentType = applicationOctetStream
+	}
+	w.Header().Set(contentTypeHeader, contentType)
+	w.Header().Set(headerContentTypeOpts, contentTypeOptsNoSniff)
 	w.Header().Set(contentDisposition, fmt.Sprintf("attachment; filename=\"%s\"", safeFileName))
-	w.WriteHeader(http.StatusOK)
-	if _, err := w.Write(content); err != nil {
-		slog.Error(errFailedToWriteResponse, "error", err, "fileName", fileName)
+	w.Header().Set(headerContentLength, strconv.FormatInt(length, 10))
+	w.WriteHeader(status)
+	if _, err := io.Copy(w, body); err != nil {
+		slog.ErrorContext(r.Context(), errFailedToWriteResponse, "error", err, "fileName", fileName)
 	}
 }
 
 // helper functions
 
+// etagMatches reports whether an If-None-Match header value matches etag. Weak validators
+// match their strong form, as the weak comparison of RFC 9110 requires.
+func etagMatches(ifNoneMatch, etag string) bool {
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// errUnsatisfiableRange is returned by parseByteRange for a range that starts beyond the end of the file
+var errUnsatisfiableRange = errors.New(errRangeUnsatisfied)
+
+// ifRangeMatches reports whether a Range may be honoured under an If-Range header value. Only a
+// strong match of the current ETag does; a date or stale ETag gets the whole file instead.
+func ifRangeMatches(ifRange, etag string) bool {
+	return ifRange == "" || strings.TrimSpace(ifRange) == etag
+}
+
+// parseByteRange resolves a Range header of the form bytes=start-end, bytes=start- or bytes=-suffix
+// against a file of size bytes, returning the offset and length to serve. ok is false when the
+// header is to be ignored and the whole file served: it is malformed, not in bytes, or lists
+// several ranges. A range that starts beyond the end of the file is unsatisfiable.
+func parseByteRange(header string, size int64) (offset, length int64, ok bool, err error) {
+	spec, found := strings.CutPrefix(header, "bytes=")
+	if !found || strings.Contains(spec, ",") {
+		return 0, 0, false, nil
+	}
+	first, last, found := strings.Cut(strings.TrimSpace(spec), "-")
+	if !found {
+		return 0, 0, false, nil
+	}
+	first, last = strings.TrimSpace(first), strings.TrimSpace(last)
+	if first == "" {
+		// The last n bytes
+		n, valid := parseRangeValue(last)
+		if !valid {
+			return 0, 0, false, nil
+		}
+		n = min(n, size)
+		if n == 0 {
+			return 0, 0, false, errUnsatisfiableRange
+		}
+		return size - n, n, true, nil
+	}
+	start, valid := parseRangeValue(first)
+	if !valid {
+		return 0, 0, false, nil
+	}
+	end := size - 1
+	if last != "" {
+		e, valid := parseRangeValue(last)
+		if !valid || e < start {
+			return 0, 0, false, nil
+		}
+		end = min(e, size-1)
+	}
+	if start >= size {
+		return 0, 0, false, errUnsatisfiableRange
+	}
+	return start, end - start + 1, true, nil
+}
+
+// parseRangeValue parses a byte position, which is digits only
+func parseRangeValue(s string) (int64, bool) {
+	if s == "" || strings.Trim(s, "0123456789") != "" {
+		return 0, false
+	}
+	n, err := strconv.ParseInt(s, 10, 64)
+	return n, err == nil
+}
+
 // validateFileName checks if the provided fileName is non-empty and sanitizes it by extracting the base name.
 // It returns an error if the fileName is empty or resolves to "." or ".." after sanitization.
 // The function returns the sanitized file name if valid, otherwise an appropriate error.
@@ -149,3 +460,15 @@ func validateFileName(fileName string) (string, error) {
 	}
 	return sanitized, nil
 }
+
+// allowedFileType returns the content type expected for the file's extension, and whether the
+// extension may be stored at all
+func allowedFileType(fileName string) (string, bool) {
+	contentType, ok := allowedFileTypes[strings.ToLower(filepath.Ext(fileName))]
+	return contentType, ok
+}
+
+// fileTypeDeniedMessage lists the allowed extensions for a rejected file
+func fileTypeDeniedMessage() string {
+	return fmt.Sprintf(errFileTypeDenied, strings.Join(slices.Sorted(maps.Keys(allowedFileTypes)), ", "))
+}
@@ -0,0 +1,439 @@
+// Copyright (c) 2025 WSO2 LLC. (https://www.wso2.com).
+//
+// WSO2 LLC. licenses this file to you under the Apache License,
+// Version 2.0 (the "License"); you may not use this file except
+// in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+package handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/opensuperapp/opensuperapp/backend-services/core/internal/models"
+	fileservice "github.com/opensuperapp/opensuperapp/backend-services/core/plugins/file-service"
+
+	"github.com/go-chi/chi/v5"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// fakePresignFileService stores files in memory and presigns URLs under a fixed host, or fails with err when set
+type fakePresignFileService struct {
+	err   error
+	files map[string][]byte
+}
+
+func (f *fakePresignFileService) UploadFile(fileName string, content []byte) (string, error) {
+	if f.files == nil {
+		f.files = make(map[string][]byte)
+	}
+	f.files[fileName] = content
+	return "https://files.example.com/" + fileName, nil
+}
+
+func (f *fakePresignFileService) UploadFileStream(fileName string, r io.Reader, size int64) (string, error) {
+	content, err := io.ReadAll(r)
+	if err != nil {
+		return "", err
+	}
+	return f.UploadFile(fileName, content)
+}
+
+func (f *fakePresignFileService) OpenBlob(fileName string) (fileservice.Blob, error) {
+	content, ok := f.files[fileName]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return fileservice.NewMemoryBlob(content, http.DetectContentType(content)), nil
+}
+
+func (f *fakePresignFileService) DeleteFile(fileName string) error {
+	return nil
+}
+
+func (f *fakePresignFileService) PresignUpload(fileName string) (string, error) {
+	if f.err != nil {
+		return "", f.err
+	}
+	return "https://files.example.com/" + fileName + "?op=put", nil
+}
+
+func (f *fakePresignFileService) PresignDownload(fileName string) (string, error) {
+	if f.err != nil {
+		return "", f.err
+	}
+	return "https://files.example.com/" + fileName + "?op=get", nil
+}
+
+// TestPresignFile tests presigned URL requests for each operation and the filename and extension checks
+func TestPresignFile(t *testing.T) {
+	tests := []struct {
+		name       string
+		query      string
+		err        error
+		wantStatus int
+		wantURL    string
+		wantMethod string
+	}{
+		{"upload", "fileName=app.zip&op=upload", nil, http.StatusOK, "https://files.example.com/app.zip?op=put", http.MethodPut},
+		{"download", "fileName=icon.PNG&op=download", nil, http.StatusOK, "https://files.example.com/icon.PNG?op=get", http.MethodGet},
+		{"path is stripped", "fileName=../../etc/app.zip&op=upload", nil, http.StatusOK, "https://files.example.com/app.zip?op=put", http.MethodPut},
+		{"missing fileName", "op=upload", nil, http.StatusBadRequest, "", ""},
+		{"extension not allowed", "fileName=run.sh&op=upload", nil, http.StatusBadRequest, "", ""},
+		{"no extension", "fileName=app&op=upload", nil, http.StatusBadRequest, "", ""},
+		{"missing op", "fileName=app.zip", nil, http.StatusBadRequest, "", ""},
+		{"unknown op", "fileName=app.zip&op=delete", nil, http.StatusBadRequest, "", ""},
+		{"unsupported backend", "fileName=app.zip&op=upload", fileservice.ErrPresignUnsupported, http.StatusNotImplemented, "", ""},
+		{"backend failure", "fileName=app.zip&op=download", errors.New("store unavailable"), http.StatusInternalServerError, "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := NewFileHandler(&fakePresignFileService{err: tt.err}, 10)
+			w := httptest.NewRecorder()
+			h.PresignFile(w, httptest.NewRequest(http.MethodGet, "/files/presign?"+tt.query, nil))
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("Expected status %d, got %d. Body: %s", tt.wantStatus, w.Code, w.Body.String())
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+
+			var resp presignResponse
+			if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("Failed to parse response: %v", err)
+			}
+			if resp.URL != tt.wantURL {
+				t.Errorf("Expected url %q, got %q", tt.wantURL, resp.URL)
+			}
+			if resp.Method != tt.wantMethod {
+				t.Errorf("Expected method %s, got %s", tt.wantMethod, resp.Method)
+			}
+			if until := time.Until(resp.ExpiresAt); until <= 0 || until > fileservice.PresignExpiry {
+				t.Errorf("Expected expiry within %s, got %s", fileservice.PresignExpiry, resp.ExpiresAt)
+			}
+		})
+	}
+}
+
+var (
+	testPNG = append([]byte("\x89PNG\r\n\x1a\n"), make([]byte, 16)...)
+	testZIP = append([]byte("PK\x03\x04"), make([]byte, 26)...)
+)
+
+// TestUploadFile_ContentValidation tests that uploads are accepted only when the content matches the extension
+func TestUploadFile_ContentValidation(t *testing.T) {
+	tests := []struct {
+		name       string
+		fileName   string
+		content    []byte
+		wantStatus int
+	}{
+		{"png", "icon.png", testPNG, http.StatusCreated},
+		{"zip", "app.ZIP", testZIP, http.StatusCreated},
+		{"html as png", "icon.png", []byte("<html><script>alert(1)</script></html>"), http.StatusBadRequest},
+		{"png as jpg", "icon.jpg", testPNG, http.StatusBadRequest},
+		{"zip as png", "icon.png", testZIP, http.StatusBadRequest},
+		{"extension not allowed", "page.html", []byte("<html></html>"), http.StatusBadRequest},
+		{"empty content", "icon.png", nil, http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fs := &fakePresignFileService{}
+			h := NewFileHandler(fs, 10)
+			w := httptest.NewRecorder()
+			h.UploadFile(w, httptest.NewRequest(http.MethodPost, "/files?fileName="+tt.fileName, bytes.NewReader(tt.content)))
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("Expected status %d, got %d. Body: %s", tt.wantStatus, w.Code, w.Body.String())
+			}
+			if _, stored := fs.files[tt.fileName]; stored != (tt.wantStatus == http.StatusCreated) {
+				t.Errorf("Expected stored=%v for %s", tt.wantStatus == http.StatusCreated, tt.fileName)
+			}
+		})
+	}
+}
+
+// TestUploadFile_Multipart tests uploads sent as multipart/form-data, where the file is the "file" part
+func TestUploadFile_Multipart(t *testing.T) {
+	form := func(fields map[string]string, fileContent []byte) (*bytes.Buffer, string) {
+		var buf bytes.Buffer
+		mw := multipart.NewWriter(&buf)
+		for name, value := range fields {
+			mw.WriteField(name, value)
+		}
+		if fileContent != nil {
+			part, _ := mw.CreateFormFile("file", "upload")
+			part.Write(fileContent)
+		}
+		mw.Close()
+		return &buf, mw.FormDataContentType()
+	}
+
+	tests := []struct {
+		name       string
+		fields     map[string]string
+		content    []byte
+		wantStatus int
+	}{
+		{"file part", nil, testPNG, http.StatusCreated},
+		{"field before the file part", map[string]string{"note": "promo"}, testPNG, http.StatusCreated},
+		{"no file part", map[string]string{"note": "promo"}, nil, http.StatusBadRequest},
+		{"mismatched content", nil, []byte("<html></html>"), http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fs := &fakePresignFileService{}
+			h := NewFileHandler(fs, 10)
+			body, contentType := form(tt.fields, tt.content)
+			req := httptest.NewRequest(http.MethodPost, "/files?fileName=icon.png", body)
+			req.Header.Set(headerContentType, contentType)
+			w := httptest.NewRecorder()
+
+			h.UploadFile(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("Expected status %d, got %d. Body: %s", tt.wantStatus, w.Code, w.Body.String())
+			}
+			if tt.wantStatus == http.StatusCreated && !bytes.Equal(fs.files["icon.png"], tt.content) {
+				t.Errorf("Expected the file part to be stored as-is, got %d bytes", len(fs.files["icon.png"]))
+			}
+		})
+	}
+}
+
+// TestUploadFile_TooLarge tests that content over the size limit is rejected after the sniffed prefix passes
+func TestUploadFile_TooLarge(t *testing.T) {
+	fs := &fakePresignFileService{}
+	h := NewFileHandler(fs, 1)
+	content := append(append([]byte{}, testPNG...), make([]byte, 1<<20)...)
+	w := httptest.NewRecorder()
+
+	h.UploadFile(w, httptest.NewRequest(http.MethodPost, "/files?fileName=icon.png", bytes.NewReader(content)))
+
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("Expected status 400, got %d. Body: %s", w.Code, w.Body.String())
+	}
+	if _, stored := fs.files["icon.png"]; stored {
+		t.Error("Expected an oversized file not to be stored")
+	}
+}
+
+// TestUploadFile_RecordsOwner tests that an upload with a microappId records the owner, and that a
+// later upload without one clears it
+func TestUploadFile_RecordsOwner(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("Failed to open test database: %v", err)
+	}
+	if err := db.AutoMigrate(&models.MicroApp{}, &models.MicroAppAsset{}, &models.FileChecksum{}); err != nil {
+		t.Fatalf("Failed to migrate database: %v", err)
+	}
+	if err := db.Create(&models.MicroApp{MicroAppID: "app-1", Name: "App One", CreatedBy: "admin@example.com"}).Error; err != nil {
+		t.Fatalf("Failed to seed micro app: %v", err)
+	}
+	h := NewFileHandler(&fakePresignFileService{}, 10).WithAssetOwners(db)
+
+	upload := func(query string) int {
+		w := httptest.NewRecorder()
+		h.UploadFile(w, httptest.NewRequest(http.MethodPost, "/files?"+query, bytes.NewReader(testPNG)))
+		return w.Code
+	}
+	owner := func() string {
+		var asset models.MicroAppAsset
+		if err := db.Where("file_name = ?", "promo.png").First(&asset).Error; err != nil {
+			return ""
+		}
+		return asset.MicroAppID
+	}
+
+	if code := upload("fileName=promo.png&microappId=app-1"); code != http.StatusCreated {
+		t.Fatalf("Expected status 201, got %d", code)
+	}
+	if got := owner(); got != "app-1" {
+		t.Errorf("Expected promo.png to be owned by app-1, got %q", got)
+	}
+	if code := upload("fileName=promo.png&microappId=unknown"); code != http.StatusNotFound {
+		t.Errorf("Expected status 404 for an unknown microapp, got %d", code)
+	}
+	if code := upload("fileName=promo.png"); code != http.StatusCreated {
+		t.Fatalf("Expected status 201, got %d", code)
+	}
+	if got := owner(); got != "" {
+		t.Errorf("Expected the owner to be cleared, got %q", got)
+	}
+}
+
+// TestDownloadMicroAppFile_ContentType tests that downloads are served with the stored content type
+func TestDownloadMicroAppFile_ContentType(t *testing.T) {
+	fs := &fakePresignFileService{files: map[string][]byte{"icon.png": testPNG}}
+	h := NewFileHandler(fs, 10)
+
+	download := func(fileName string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodGet, "/public/micro-app-files/download/"+fileName, nil)
+		rctx := chi.NewRouteContext()
+		rctx.URLParams.Add(QueryParamFileName, fileName)
+		r = r.WithContext(context.WithValue(r.Context(), chi.RouteCtxKey, rctx))
+		w := httptest.NewRecorder()
+		h.DownloadMicroAppFile(w, r)
+		return w
+	}
+
+	w := download("icon.png")
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", w.Code)
+	}
+	if got := w.Header().Get("Content-Type"); got != "image/png" {
+		t.Errorf("Expected Content-Type image/png, got %q", got)
+	}
+	if got := w.Header().Get("X-Content-Type-Options"); got != "nosniff" {
+		t.Errorf("Expected X-Content-Type-Options nosniff, got %q", got)
+	}
+	if !bytes.Equal(w.Body.Bytes(), testPNG) {
+		t.Error("Expected the stored content to be served")
+	}
+
+	if w := download("missing.png"); w.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404 for a missing file, got %d", w.Code)
+	}
+}
+
+// TestDownloadMicroAppFile_ETag tests that a matching If-None-Match gets 304 without a body, and that
+// replacing the file changes the ETag
+func TestDownloadMicroAppFile_ETag(t *testing.T) {
+	fs := &fakePresignFileService{files: map[string][]byte{"icon.png": testPNG}}
+	h := NewFileHandler(fs, 10)
+
+	download := func(ifNoneMatch string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodGet, "/public/micro-app-files/download/icon.png", nil)
+		if ifNoneMatch != "" {
+			r.Header.Set("If-None-Match", ifNoneMatch)
+		}
+		rctx := chi.NewRouteContext()
+		rctx.URLParams.Add(QueryParamFileName, "icon.png")
+		r = r.WithContext(context.WithValue(r.Context(), chi.RouteCtxKey, rctx))
+		w := httptest.NewRecorder()
+		h.DownloadMicroAppFile(w, r)
+		return w
+	}
+
+	w := download("")
+	etag := w.Header().Get("ETag")
+	if w.Code != http.StatusOK || etag == "" {
+		t.Fatalf("Expected status 200 with an ETag, got %d and %q", w.Code, etag)
+	}
+	if got := w.Header().Get("Cache-Control"); got != "public, no-cache" {
+		t.Errorf("Expected Cache-Control public, no-cache, got %q", got)
+	}
+	if got := w.Header().Get("Content-Disposition"); got != `attachment; filename="icon.png"` {
+		t.Errorf("Expected Content-Disposition to be kept, got %q", got)
+	}
+
+	for _, ifNoneMatch := range []string{etag, "W/" + etag, `"stale", ` + etag, "*"} {
+		w := download(ifNoneMatch)
+		if w.Code != http.StatusNotModified {
+			t.Errorf("Expected status 304 for If-None-Match %s, got %d", ifNoneMatch, w.Code)
+		}
+		if w.Body.Len() != 0 {
+			t.Errorf("Expected no body with 304, got %d bytes", w.Body.Len())
+		}
+		if got := w.Header().Get("ETag"); got != etag {
+			t.Errorf("Expected the ETag on 304, got %q", got)
+		}
+	}
+
+	fs.files["icon.png"] = append(append([]byte{}, testPNG...), 1)
+	if w := download(etag); w.Code != http.StatusOK {
+		t.Errorf("Expected status 200 once the file changed, got %d", w.Code)
+	} else if w.Header().Get("ETag") == etag {
+		t.Error("Expected a new ETag once the file changed")
+	}
+}
+
+// TestDownloadMicroAppFile_Range tests that a single byte range is served as 206 with Content-Range,
+// that unsatisfiable ranges get 416, and that ranges the server does not honour get the whole file
+func TestDownloadMicroAppFile_Range(t *testing.T) {
+	content := []byte("0123456789")
+	fs := &fakePresignFileService{files: map[string][]byte{"bundle.zip": content}}
+	h := NewFileHandler(fs, 10)
+
+	download := func(rangeHeader, ifRange string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodGet, "/public/micro-app-files/download/bundle.zip", nil)
+		r.Header.Set("Range", rangeHeader)
+		if ifRange != "" {
+			r.Header.Set("If-Range", ifRange)
+		}
+		rctx := chi.NewRouteContext()
+		rctx.URLParams.Add(QueryParamFileName, "bundle.zip")
+		r = r.WithContext(context.WithValue(r.Context(), chi.RouteCtxKey, rctx))
+		w := httptest.NewRecorder()
+		h.DownloadMicroAppFile(w, r)
+		return w
+	}
+	etag := download("", "").Header().Get("ETag")
+
+	tests := []struct {
+		name         string
+		rangeHeader  string
+		ifRange      string
+		code         int
+		contentRange string
+		body         string
+	}{
+		{"no range", "", "", http.StatusOK, "", "0123456789"},
+		{"closed range", "bytes=2-5", "", http.StatusPartialContent, "bytes 2-5/10", "2345"},
+		{"open range", "bytes=7-", "", http.StatusPartialContent, "bytes 7-9/10", "789"},
+		{"suffix range", "bytes=-3", "", http.StatusPartialContent, "bytes 7-9/10", "789"},
+		{"end past the file", "bytes=8-100", "", http.StatusPartialContent, "bytes 8-9/10", "89"},
+		{"matching If-Range", "bytes=0-0", etag, http.StatusPartialContent, "bytes 0-0/10", "0"},
+		{"stale If-Range", "bytes=0-0", `"stale"`, http.StatusOK, "", "0123456789"},
+		{"start past the file", "bytes=10-", "", http.StatusRequestedRangeNotSatisfiable, "bytes */10", ""},
+		{"empty suffix", "bytes=-0", "", http.StatusRequestedRangeNotSatisfiable, "bytes */10", ""},
+		{"several ranges", "bytes=0-1,4-5", "", http.StatusOK, "", "0123456789"},
+		{"malformed", "bytes=5-2", "", http.StatusOK, "", "0123456789"},
+		{"other unit", "items=0-1", "", http.StatusOK, "", "0123456789"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := download(tt.rangeHeader, tt.ifRange)
+			if w.Code != tt.code {
+				t.Fatalf("Expected status %d, got %d: %s", tt.code, w.Code, w.Body.String())
+			}
+			if got := w.Header().Get("Content-Range"); got != tt.contentRange {
+				t.Errorf("Expected Content-Range %q, got %q", tt.contentRange, got)
+			}
+			if got := w.Header().Get("Accept-Ranges"); got != "bytes" {
+				t.Errorf("Expected Accept-Ranges bytes, got %q", got)
+			}
+			if tt.body != "" {
+				if w.Body.String() != tt.body {
+					t.Errorf("Expected body %q, got %q", tt.body, w.Body.String())
+				}
+				if got := w.Header().Get("Content-Length"); got != strconv.Itoa(len(tt.body)) {
+					t.Errorf("Expected Content-Length %d, got %q", len(tt.body), got)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,75 @@
+// Copyright (c) 2025 WSO2 LLC. (https://www.wso2.com).
+//
+// WSO2 LLC. licenses this file to you under the Apache License,
+// Version 2.0 (the "License"); you may not use this file except
+// in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+package handler
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// readinessTimeout bounds the database check so a hung connection fails the probe instead of stalling it
+const readinessTimeout = 2 * time.Second
+
+const (
+	healthOK    = "ok"
+	healthError = "error"
+)
+
+// HealthStatus is the readiness probe response
+type HealthStatus struct {
+	DB    string `json:"db"`
+	Error string `json:"error,omitempty"`
+}
+
+// HealthHandler serves the liveness and readiness probes. The core service signs no tokens of its
+// own, so readiness only depends on the database.
+type HealthHandler struct {
+	db *gorm.DB
+}
+
+func NewHealthHandler(db *gorm.DB) *HealthHandler {
+	return &HealthHandler{db: db}
+}
+
+// Live reports that the process is up. It checks nothing else, so a slow dependency never gets the pod restarted.
+func (h *HealthHandler) Live(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]string{"status": healthOK})
+}
+
+// Ready reports whether the database answers a ping within readinessTimeout
+func (h *HealthHandler) Ready(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), readinessTimeout)
+	defer cancel()
+
+	if err := pingDB(ctx, h.db); err != nil {
+		slog.WarnContext(r.Context(), "Readiness check failed: database unavailable", "error", err)
+		writeJSON(w, http.StatusServiceUnavailable, HealthStatus{DB: healthError, Error: err.Error()})
+		return
+	}
+	writeJSON(w, http.StatusOK, HealthStatus{DB: healthOK})
+}
+
+func pingDB(ctx context.Context, db *gorm.DB) error {
+	sqlDB, err := db.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.PingContext(ctx)
+}
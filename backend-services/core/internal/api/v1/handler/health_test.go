@@ -0,0 +1,78 @@
+// Copyright (c) 2025 WSO2 LLC. (https://www.wso2.com).
+//
+// WSO2 LLC. licenses this file to you under the Apache License,
+// Version 2.0 (the "License"); you may not use this file except
+// in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// TestHealthReady tests the readiness probe against a working database and a closed one
+func TestHealthReady(t *testing.T) {
+	openDB := func() *gorm.DB {
+		db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+		if err != nil {
+			t.Fatalf("Failed to open test database: %v", err)
+		}
+		return db
+	}
+	brokenDB := openDB()
+	sqlDB, err := brokenDB.DB()
+	if err != nil {
+		t.Fatalf("Failed to get sql.DB: %v", err)
+	}
+	sqlDB.Close()
+
+	tests := []struct {
+		name     string
+		db       *gorm.DB
+		code     int
+		expected HealthStatus
+	}{
+		{"ready", openDB(), http.StatusOK, HealthStatus{DB: "ok"}},
+		{"broken database", brokenDB, http.StatusServiceUnavailable, HealthStatus{DB: "error", Error: "sql: database is closed"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			NewHealthHandler(tt.db).Ready(w, httptest.NewRequest(http.MethodGet, "/health/ready", nil))
+			if w.Code != tt.code {
+				t.Errorf("Expected status %d, got %d: %s", tt.code, w.Code, w.Body.String())
+			}
+			var status HealthStatus
+			if err := json.Unmarshal(w.Body.Bytes(), &status); err != nil {
+				t.Fatalf("Failed to decode response: %v", err)
+			}
+			if status != tt.expected {
+				t.Errorf("Expected %+v, got %+v", tt.expected, status)
+			}
+		})
+	}
+}
+
+// TestHealthLive tests that the liveness probe succeeds without touching the database
+func TestHealthLive(t *testing.T) {
+	w := httptest.NewRecorder()
+	NewHealthHandler(nil).Live(w, httptest.NewRequest(http.MethodGet, "/health/live", nil))
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected status 200, got %d", w.Code)
+	}
+}
@@ -16,77 +16,194 @@
 package handler
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"log/slog"
 	"net/http"
+	"net/url"
 	"slices"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/opensuperapp/opensuperapp/backend-services/core/internal/api/v1/dto"
 	"github.com/opensuperapp/opensuperapp/backend-services/core/internal/auth"
 	"github.com/opensuperapp/opensuperapp/backend-services/core/internal/models"
+	"github.com/opensuperapp/opensuperapp/backend-services/core/internal/services"
 
 	"github.com/go-chi/chi/v5"
 	"gorm.io/gorm"
 )
 
 type MicroAppHandler struct {
-	db *gorm.DB
+	db             *gorm.DB
+	conflictWindow time.Duration // Config overwrites of another user's change within this window are logged; 0 disables
 }
 
-func NewMicroAppHandler(db *gorm.DB) *MicroAppHandler {
-	return &MicroAppHandler{db: db}
+func NewMicroAppHandler(db *gorm.DB, conflictWindow time.Duration) *MicroAppHandler {
+	return &MicroAppHandler{db: db, conflictWindow: conflictWindow}
 }
 
-// MicroAppHandler to handle fetching all micro apps
+// microAppSort lists micro apps by name by default.
+var microAppSort = listSort{
+	fields: map[string]string{
+		"name":  "name",
+		"appId": "micro_app_id",
+	},
+	defaultField: "name",
+	tieBreaker:   "micro_app_id",
+}
+
+// MicroAppHandler to handle fetching all micro apps.
+// Every active app is listed unless a limit or offset selects a page. The page, search, active,
+// mandatory and category parameters select a page of the matching apps, returned with their total.
 func (h *MicroAppHandler) GetAll(w http.ResponseWriter, r *http.Request) {
 	userInfo, ok := auth.GetUserInfo(r.Context())
 	if !ok {
 		http.Error(w, errUserInfoNotFound, http.StatusUnauthorized)
 		return
 	}
-	authorizedAppIDs, err := h.getMicroAppIDsByGroups(userInfo.Groups)
+	params, err := parseListParams(r, microAppSort)
 	if err != nil {
-		slog.Error(errFailedToGetAuthorizedAppIDs, "error", err, "groups", userInfo.Groups)
-		http.Error(w, errFailedToFetchMicroApps, http.StatusInternalServerError)
+		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
-	if len(authorizedAppIDs) == 0 {
-		if err := writeJSON(w, http.StatusOK, []dto.MicroAppResponse{}); err != nil {
-			slog.Error("Failed to write JSON response", "error", err)
-			http.Error(w, errFailedToWriteResponse, http.StatusInternalServerError)
-		}
+	filters, err := parseMicroAppFilters(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
-	var apps []models.MicroApp
-	// Fetch only active micro apps with their active versions, roles, and configs that the user has access to
-	if err := h.db.Where("active = ? AND micro_app_id IN ?", models.StatusActive, authorizedAppIDs).
-		Preload("Versions", "active = ?", models.StatusActive).
-		Preload("Roles", "active = ?", models.StatusActive).
-		Preload("Configs", "active = ?", models.StatusActive).
-		Find(&apps).Error; err != nil {
-		slog.Error(errFailedToFetchMicroAppsFromDB, "error", err)
+	authorizedAppIDs, err := h.getMicroAppIDsByGroups(r.Context(), userInfo.Groups)
+	if err != nil {
+		slog.ErrorContext(r.Context(), errFailedToGetAuthorizedAppIDs, "error", err, "groups", userInfo.Groups)
 		http.Error(w, errFailedToFetchMicroApps, http.StatusInternalServerError)
 		return
 	}
-	var response []dto.MicroAppResponse
+
+	var apps []models.MicroApp
+	var total int64
+	if len(authorizedAppIDs) > 0 {
+		// Fetch the matching micro apps with their active versions, roles, and configs that the user has access to
+		query := h.db.Model(&models.MicroApp{}).
+			Where("micro_app_id IN ?", authorizedAppIDs).
+			Scopes(models.WithSearch(filters.search), models.WithActive(filters.active), models.WithMandatory(filters.mandatory),
+				models.WithCategory(filters.category))
+		if filters.paginated {
+			if err := query.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+				slog.ErrorContext(r.Context(), errFailedToFetchMicroAppsFromDB, "error", err)
+				http.Error(w, errFailedToFetchMicroApps, http.StatusInternalServerError)
+				return
+			}
+			query = query.Scopes(models.WithPagination(filters.page, params.limit))
+		} else if params.paged {
+			query = query.Limit(params.limit).Offset(params.offset)
+		}
+		if err := preloadActiveAssociations(query.Order(params.order)).Find(&apps).Error; err != nil {
+			slog.ErrorContext(r.Context(), errFailedToFetchMicroAppsFromDB, "error", err)
+			http.Error(w, errFailedToFetchMicroApps, http.StatusInternalServerError)
+			return
+		}
+	}
+	items := make([]dto.MicroAppResponse, 0, len(apps))
 	for _, app := range apps {
-		appResponse := h.convertToResponseFromPreloaded(app)
-		response = append(response, appResponse)
+		appResponse := h.convertToResponseFromPreloaded(r.Context(), app)
+		items = append(items, appResponse)
+	}
+
+	var response any = items
+	if filters.paginated {
+		response = dto.PaginatedMicroAppsResponse{
+			Items: items,
+			Total: int(total),
+			Page:  filters.page,
+			Limit: params.limit,
+		}
 	}
 	if err := writeJSON(w, http.StatusOK, response); err != nil {
-		slog.Error("Failed to write JSON response", "error", err)
+		slog.ErrorContext(r.Context(), "Failed to write JSON response", "error", err)
 		http.Error(w, errFailedToWriteResponse, http.StatusInternalServerError)
 	}
 }
 
-// MicroAppHandler to handle fetching a micro app by ID
+// microAppFilters are the parsed page, search, active, mandatory and category parameters of a micro app list.
+type microAppFilters struct {
+	paginated bool // the request set page, so it gets a page with the total instead of the plain list
+	page      int  // 1-based
+	search    string
+	active    *int // only active apps when the request does not set it
+	mandatory *int
+	category  string // slug
+}
+
+// Parses the micro app list filters. A page cannot be combined with an offset.
+func parseMicroAppFilters(r *http.Request) (microAppFilters, error) {
+	query := r.URL.Query()
+	active := models.StatusActive
+	filters := microAppFilters{
+		paginated: query.Has(queryParamPage),
+		page:      1,
+		search:    strings.TrimSpace(query.Get(queryParamSearch)),
+		active:    &active,
+		category:  query.Get(queryParamCategory),
+	}
+	if v := query.Get(queryParamPage); v != "" {
+		page, err := strconv.Atoi(v)
+		if err != nil || page <= 0 {
+			return microAppFilters{}, errors.New(errInvalidPage)
+		}
+		filters.page = page
+	}
+	if filters.paginated && query.Has(queryParamOffset) {
+		return microAppFilters{}, errors.New(errPageOffset)
+	}
+	if len(filters.search) > maxSearchLength {
+		return microAppFilters{}, errors.New(errSearchTooLong)
+	}
+	if query.Has(queryParamCategory) && !categorySlugPattern.MatchString(filters.category) {
+		return microAppFilters{}, errors.New(errInvalidCategorySlug)
+	}
+	var err error
+	if filters.active, err = parseFlagParam(query, queryParamActive, filters.active); err != nil {
+		return microAppFilters{}, err
+	}
+	if filters.mandatory, err = parseFlagParam(query, queryParamMandatory, nil); err != nil {
+		return microAppFilters{}, err
+	}
+	return filters, nil
+}
+
+// Parses a 0 or 1 query parameter, returning fallback when it is not set.
+func parseFlagParam(query url.Values, name string, fallback *int) (*int, error) {
+	if !query.Has(name) {
+		return fallback, nil
+	}
+	switch v := query.Get(name); v {
+	case "0", "1":
+		value, _ := strconv.Atoi(v)
+		return &value, nil
+	default:
+		return nil, fmt.Errorf(errInvalidFlag, name)
+	}
+}
+
+// MicroAppHandler to handle fetching a micro app by ID.
+// With ?latestOnly=true only the highest-build active version is returned.
 func (h *MicroAppHandler) GetByID(w http.ResponseWriter, r *http.Request) {
 	id := chi.URLParam(r, urlParamAppID)
 	if id == "" {
 		http.Error(w, errMissingMicroAppID, http.StatusBadRequest)
 		return
 	}
+	latestOnly := false
+	if v := r.URL.Query().Get(queryParamLatestOnly); v != "" {
+		var err error
+		if latestOnly, err = strconv.ParseBool(v); err != nil {
+			http.Error(w, errInvalidLatestOnly, http.StatusBadRequest)
+			return
+		}
+	}
 	// Get user info from context (set by auth middleware)
 	userInfo, ok := auth.GetUserInfo(r.Context())
 	if !ok {
@@ -94,37 +211,38 @@ func (h *MicroAppHandler) GetByID(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	// Get app IDs the user has access to based on their groups
-	authorizedAppIDs, err := h.getMicroAppIDsByGroups(userInfo.Groups)
+	authorizedAppIDs, err := h.getMicroAppIDsByGroups(r.Context(), userInfo.Groups)
 	if err != nil {
-		slog.Error(errFailedToGetAuthorizedAppIDs, "error", err, "groups", userInfo.Groups)
+		slog.ErrorContext(r.Context(), errFailedToGetAuthorizedAppIDs, "error", err, "groups", userInfo.Groups)
 		http.Error(w, errFailedToFetchMicroApp, http.StatusInternalServerError)
 		return
 	}
 	// Check if the requested app ID is in the user's authorized list
 	isAuthorized := slices.Contains(authorizedAppIDs, id)
 	if !isAuthorized {
-		slog.Warn(errUserNotAuthorizedToAccessApp, "appID", id, "email", userInfo.Email, "groups", userInfo.Groups)
+		slog.WarnContext(r.Context(), errUserNotAuthorizedToAccessApp, "appID", id, "email", userInfo.Email, "groups", userInfo.Groups)
 		http.Error(w, errForbidden, http.StatusForbidden)
 		return
 	}
 	var app models.MicroApp
-	if err := h.db.Where("micro_app_id = ? AND active = ?", id, models.StatusActive).
-		Preload("Versions", "active = ?", models.StatusActive).
-		Preload("Roles", "active = ?", models.StatusActive).
-		Preload("Configs", "active = ?", models.StatusActive).
+	if err := preloadActiveAssociations(h.db.Where("micro_app_id = ? AND active = ?", id, models.StatusActive)).
 		First(&app).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			http.Error(w, errMicroAppNotFound, http.StatusNotFound)
 		} else {
-			slog.Error("Failed to fetch micro app", "error", err, "appID", id)
+			slog.ErrorContext(r.Context(), "Failed to fetch micro app", "error", err, "appID", id)
 			http.Error(w, errFailedToFetchMicroApp, http.StatusInternalServerError)
 		}
 		return
 	}
-	appResponse := h.convertToResponseFromPreloaded(app)
+	// Versions are preloaded newest build first
+	if latestOnly && len(app.Versions) > 1 {
+		app.Versions = app.Versions[:1]
+	}
+	appResponse := h.convertToResponseFromPreloaded(r.Context(), app)
 
 	if err := writeJSON(w, http.StatusOK, appResponse); err != nil {
-		slog.Error("Failed to write JSON response", "error", err)
+		slog.ErrorContext(r.Context(), "Failed to write JSON response", "error", err)
 		http.Error(w, errFailedToWriteResponse, http.StatusInternalServerError)
 	}
 }
@@ -144,8 +262,7 @@ func (h *MicroAppHandler) Upsert(w http.ResponseWriter, r *http.Request) {
 	}
 	limitRequestBody(w, r, 0) // 1MB default limit
 	var req dto.CreateMicroAppRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, errInvalidRequestBody, http.StatusBadRequest)
+	if !decodeJSONBody(w, r, &req) {
 		return
 	}
 	// Validate request
@@ -176,6 +293,10 @@ func (h *MicroAppHandler) Upsert(w http.ResponseWriter, r *http.Request) {
 		// Upsert versions if provided
 		if len(req.Versions) > 0 {
 			for _, versionReq := range req.Versions {
+				checksum, err := bundleChecksum(tx, versionReq)
+				if err != nil {
+					return err
+				}
 				version := models.MicroAppVersion{}
 				versionResult := tx.Where("micro_app_id = ? AND version = ? AND build = ?", req.AppID, versionReq.Version, versionReq.Build).
 					Assign(models.MicroAppVersion{
@@ -184,7 +305,7 @@ func (h *MicroAppHandler) Upsert(w http.ResponseWriter, r *http.Request) {
 						DownloadURL:  versionReq.DownloadURL,
 						Active:       models.StatusActive,
 						UpdatedBy:    &userEmail,
-					}).
+					}, integrityColumns(checksum, versionReq.Signature)).
 					Attrs(models.MicroAppVersion{
 						MicroAppID: req.AppID,
 						Version:    versionReq.Version,
@@ -217,9 +338,22 @@ func (h *MicroAppHandler) Upsert(w http.ResponseWriter, r *http.Request) {
 				}
 			}
 		}
+		// Replace categories if provided
+		if req.CategorySlugs != nil {
+			if _, err := setMicroAppCategories(tx, req.AppID, req.CategorySlugs); err != nil {
+				return err
+			}
+		}
 		// Upsert configs if provided
 		if len(req.Configs) > 0 {
 			for _, configReq := range req.Configs {
+				conflict, err := services.RecordConfigConflict(tx, req.AppID, configReq.ConfigKey, configReq.ConfigValue, userEmail, h.conflictWindow, time.Now())
+				if err != nil {
+					return err
+				}
+				if conflict {
+					slog.WarnContext(r.Context(), "Config upsert overwrote a recent change by another user", "appID", req.AppID, "configKey", configReq.ConfigKey, "updated_by", userEmail)
+				}
 				config := models.MicroAppConfig{}
 				configResult := tx.Where("micro_app_id = ? AND config_key = ?", req.AppID, configReq.ConfigKey).
 					Assign(models.MicroAppConfig{
@@ -241,24 +375,29 @@ func (h *MicroAppHandler) Upsert(w http.ResponseWriter, r *http.Request) {
 		}
 		return nil
 	})
+	if errors.Is(err, errBundleChecksumMismatch) {
+		http.Error(w, errChecksumMismatch, http.StatusBadRequest)
+		return
+	}
+	if errors.Is(err, errNoSuchCategory) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
 	if err != nil {
-		slog.Error("Failed to upsert micro app", "error", err, "appID", req.AppID)
+		slog.ErrorContext(r.Context(), "Failed to upsert micro app", "error", err, "appID", req.AppID)
 		http.Error(w, errFailedToUpsertMicroApp, http.StatusInternalServerError)
 		return
 	}
 	// Reload with preloaded relations for response
-	if err := h.db.Where("micro_app_id = ?", req.AppID).
-		Preload("Versions", "active = ?", models.StatusActive).
-		Preload("Roles", "active = ?", models.StatusActive).
-		Preload("Configs", "active = ?", models.StatusActive).
+	if err := preloadActiveAssociations(h.db.Where("micro_app_id = ?", req.AppID)).
 		First(&app).Error; err != nil {
-		slog.Error(errFailedToReloadMicroApp, "error", err, "appID", req.AppID)
+		slog.ErrorContext(r.Context(), errFailedToReloadMicroApp, "error", err, "appID", req.AppID)
 		http.Error(w, errFailedToFetchMicroApp, http.StatusInternalServerError)
 		return
 	}
-	appResponse := h.convertToResponseFromPreloaded(app)
+	appResponse := h.convertToResponseFromPreloaded(r.Context(), app)
 	if err := writeJSON(w, http.StatusCreated, appResponse); err != nil {
-		slog.Error("Failed to write JSON response", "error", err)
+		slog.ErrorContext(r.Context(), "Failed to write JSON response", "error", err)
 		http.Error(w, errFailedToWriteResponse, http.StatusInternalServerError)
 	}
 }
@@ -275,7 +414,7 @@ func (h *MicroAppHandler) Deactivate(w http.ResponseWriter, r *http.Request) {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			http.Error(w, errMicroAppNotFound, http.StatusNotFound)
 		} else {
-			slog.Error("Failed to fetch micro app", "error", err, "appID", id)
+			slog.ErrorContext(r.Context(), "Failed to fetch micro app", "error", err, "appID", id)
 			http.Error(w, errFailedToFetchMicroApp, http.StatusInternalServerError)
 		}
 		return
@@ -297,12 +436,12 @@ func (h *MicroAppHandler) Deactivate(w http.ResponseWriter, r *http.Request) {
 		return nil
 	})
 	if err != nil {
-		slog.Error("Failed to deactivate micro app", "error", err, "appID", id)
+		slog.ErrorContext(r.Context(), "Failed to deactivate micro app", "error", err, "appID", id)
 		http.Error(w, errFailedToDeactivateMicroApp, http.StatusInternalServerError)
 		return
 	}
 	if err := writeJSON(w, http.StatusOK, map[string]string{"message": msgMicroAppDeactivatedSuccessfully}); err != nil {
-		slog.Error("Failed to write JSON response", "error", err)
+		slog.ErrorContext(r.Context(), "Failed to write JSON response", "error", err)
 		http.Error(w, errFailedToWriteResponse, http.StatusInternalServerError)
 	}
 }
@@ -310,9 +449,9 @@ func (h *MicroAppHandler) Deactivate(w http.ResponseWriter, r *http.Request) {
 // Helper Functions
 
 // Fetches micro app IDs accessible by the given user groups
-func (h *MicroAppHandler) getMicroAppIDsByGroups(groups []string) ([]string, error) {
+func (h *MicroAppHandler) getMicroAppIDsByGroups(ctx context.Context, groups []string) ([]string, error) {
 	if len(groups) == 0 {
-		slog.Warn(errNoGroupsFoundForUser)
+		slog.WarnContext(ctx, errNoGroupsFoundForUser)
 		return []string{}, nil
 	}
 	var appIDs []string
@@ -323,26 +462,31 @@ func (h *MicroAppHandler) getMicroAppIDsByGroups(groups []string) ([]string, err
 		return nil, err
 	}
 	if len(appIDs) == 0 {
-		slog.Warn(errNoMicroAppsFoundForGroups, "groups", groups)
+		slog.WarnContext(ctx, errNoMicroAppsFoundForGroups, "groups", groups)
 		return []string{}, nil
 	}
 	return appIDs, nil
 }
 
+// Preloads the active versions, roles and configs of the queried micro apps.
+// Versions are ordered newest build first so that clients can take the first as the latest.
+func preloadActiveAssociations(query *gorm.DB) *gorm.DB {
+	return query.
+		Preload("Versions", func(db *gorm.DB) *gorm.DB {
+			return db.Where("active = ?", models.StatusActive).Order("build DESC")
+		}).
+		Preload("Roles", "active = ?", models.StatusActive).
+		Preload("Configs", "active = ?", models.StatusActive).
+		Preload("Categories", func(db *gorm.DB) *gorm.DB {
+			return db.Order("categories.name, categories.id")
+		})
+}
+
 // Converts a MicroApp model with preloaded versions, roles, and configs to response DTO
-func (h *MicroAppHandler) convertToResponseFromPreloaded(app models.MicroApp) dto.MicroAppResponse {
+func (h *MicroAppHandler) convertToResponseFromPreloaded(ctx context.Context, app models.MicroApp) dto.MicroAppResponse {
 	var versionResponses []dto.MicroAppVersionResponse
 	for _, v := range app.Versions {
-		versionResponses = append(versionResponses, dto.MicroAppVersionResponse{
-			ID:           v.ID,
-			MicroAppID:   v.MicroAppID,
-			Version:      v.Version,
-			Build:        v.Build,
-			ReleaseNotes: v.ReleaseNotes,
-			IconURL:      v.IconURL,
-			DownloadURL:  v.DownloadURL,
-			Active:       v.Active,
-		})
+		versionResponses = append(versionResponses, toMicroAppVersionResponse(v))
 	}
 	var roleResponses []dto.MicroAppRoleResponse
 	for _, r := range app.Roles {
@@ -358,7 +502,7 @@ func (h *MicroAppHandler) convertToResponseFromPreloaded(app models.MicroApp) dt
 		// Marshal JSONMap to json.RawMessage
 		configValueBytes, err := json.Marshal(c.ConfigValue)
 		if err != nil {
-			slog.Error(errFailedToMarshalConfigValue, "configKey", c.ConfigKey, "error", err)
+			slog.ErrorContext(ctx, errFailedToMarshalConfigValue, "configKey", c.ConfigKey, "error", err)
 			continue
 		}
 		configResponses = append(configResponses, dto.MicroAppConfigResponse{
@@ -376,5 +520,54 @@ func (h *MicroAppHandler) convertToResponseFromPreloaded(app models.MicroApp) dt
 		Versions:    versionResponses,
 		Roles:       roleResponses,
 		Configs:     configResponses,
+		Categories:  toCategoryResponses(app.Categories),
+	}
+}
+
+// configConflictSort lists config conflicts newest first by default.
+var configConflictSort = listSort{
+	fields: map[string]string{
+		"detectedAt": "detected_at",
+		"configKey":  "config_key",
+	},
+	defaultField: "detectedAt",
+	defaultDesc:  true,
+	tieBreaker:   "id",
+}
+
+// ListConfigConflicts returns the logged config overwrites of a micro app, newest first.
+func (h *MicroAppHandler) ListConfigConflicts(w http.ResponseWriter, r *http.Request) {
+	microApp, ok := requestMicroApp(w, r, h.db)
+	if !ok {
+		return
+	}
+	appID := microApp.MicroAppID
+	params, err := parseListParams(r, configConflictSort)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	var conflicts []models.MicroAppConfigConflict
+	if err := h.db.Where("micro_app_id = ?", appID).
+		Order(params.order).
+		Limit(params.limit).Offset(params.offset).
+		Find(&conflicts).Error; err != nil {
+		slog.ErrorContext(r.Context(), "Failed to fetch config conflicts", "error", err, "appID", appID)
+		http.Error(w, errFailedToFetchConfigConflicts, http.StatusInternalServerError)
+		return
+	}
+	items := make([]dto.MicroAppConfigConflictResponse, len(conflicts))
+	for i, c := range conflicts {
+		items[i] = dto.MicroAppConfigConflictResponse{
+			ID:           c.ID,
+			ConfigKey:    c.ConfigKey,
+			OldValue:     c.OldValue,
+			NewValue:     c.NewValue,
+			OldUpdatedBy: c.OldUpdatedBy,
+			OldUpdatedAt: c.OldUpdatedAt,
+			NewUpdatedBy: c.NewUpdatedBy,
+			DetectedAt:   c.DetectedAt,
+		}
 	}
+	writeJSON(w, http.StatusOK, dto.MicroAppConfigConflictsResponse{Conflicts: items, Limit: params.limit, Offset: params.offset})
 }
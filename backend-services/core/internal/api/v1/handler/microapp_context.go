@@ -0,0 +1,79 @@
+// Copyright (c) 2025 WSO2 LLC. (https://www.wso2.com).
+//
+// WSO2 LLC. licenses this file to you under the Apache License,
+// Version 2.0 (the "License"); you may not use this file except
+// in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+package handler
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/opensuperapp/opensuperapp/backend-services/core/internal/models"
+
+	"github.com/go-chi/chi/v5"
+	"gorm.io/gorm"
+)
+
+type microAppContextKey struct{}
+
+// LoadMicroApp resolves the active micro app named by the appID URL parameter once per request
+// and stores it in the request context for MicroAppFromContext. Unknown or inactive micro apps get 404.
+func LoadMicroApp(db *gorm.DB) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			microApp, ok := loadActiveMicroApp(w, r, db)
+			if !ok {
+				return
+			}
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), microAppContextKey{}, microApp)))
+		})
+	}
+}
+
+// MicroAppFromContext returns the micro app stored by LoadMicroApp, if any
+func MicroAppFromContext(ctx context.Context) (*models.MicroApp, bool) {
+	microApp, ok := ctx.Value(microAppContextKey{}).(*models.MicroApp)
+	return microApp, ok
+}
+
+// requestMicroApp returns the micro app loaded by LoadMicroApp, loading it itself when the
+// handler is served without the middleware. It writes the error response when it returns false.
+func requestMicroApp(w http.ResponseWriter, r *http.Request, db *gorm.DB) (*models.MicroApp, bool) {
+	if microApp, ok := MicroAppFromContext(r.Context()); ok {
+		return microApp, true
+	}
+	return loadActiveMicroApp(w, r, db)
+}
+
+func loadActiveMicroApp(w http.ResponseWriter, r *http.Request, db *gorm.DB) (*models.MicroApp, bool) {
+	appID := chi.URLParam(r, urlParamAppID)
+	if appID == "" {
+		http.Error(w, errMissingMicroAppID, http.StatusBadRequest)
+		return nil, false
+	}
+	var microApp models.MicroApp
+	if err := db.WithContext(r.Context()).Where("micro_app_id = ? AND active = ?", appID, models.StatusActive).
+		First(&microApp).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			http.Error(w, errMicroAppNotFoundOrInactive, http.StatusNotFound)
+		} else {
+			slog.ErrorContext(r.Context(), "Failed to fetch micro app", "error", err, "appID", appID)
+			http.Error(w, errFailedToFetchMicroApp, http.StatusInternalServerError)
+		}
+		return nil, false
+	}
+	return &microApp, true
+}
@@ -0,0 +1,116 @@
+// Copyright (c) 2025 WSO2 LLC. (https://www.wso2.com).
+//
+// WSO2 LLC. licenses this file to you under the Apache License,
+// Version 2.0 (the "License"); you may not use this file except
+// in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/opensuperapp/opensuperapp/backend-services/core/internal/auth"
+	"github.com/opensuperapp/opensuperapp/backend-services/core/internal/models"
+	"gorm.io/gorm"
+)
+
+// seedMicroApps creates an active "payroll" and an inactive "legacy" micro app
+func seedMicroApps(t *testing.T, db *gorm.DB) {
+	t.Helper()
+	for _, app := range []models.MicroApp{
+		{MicroAppID: "payroll", Name: "Payroll", CreatedBy: "admin@example.com"},
+		{MicroAppID: "legacy", Name: "Legacy", CreatedBy: "admin@example.com"},
+	} {
+		if err := db.Create(&app).Error; err != nil {
+			t.Fatalf("Failed to seed micro app: %v", err)
+		}
+	}
+	if err := db.Model(&models.MicroApp{}).Where("micro_app_id = ?", "legacy").
+		UpdateColumn("active", models.StatusInactive).Error; err != nil {
+		t.Fatalf("Failed to deactivate micro app: %v", err)
+	}
+}
+
+// TestLoadMicroApp tests that the middleware rejects unknown and inactive micro apps and passes active ones on
+func TestLoadMicroApp(t *testing.T) {
+	db := setupMicroAppTestDB(t)
+	seedMicroApps(t, db)
+
+	var loaded *models.MicroApp
+	r := chi.NewRouter()
+	r.With(LoadMicroApp(db)).Get("/micro-apps/{appID}", func(w http.ResponseWriter, r *http.Request) {
+		microApp, ok := MicroAppFromContext(r.Context())
+		if !ok {
+			t.Error("Expected the micro app in the request context")
+		}
+		loaded = microApp
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	tests := []struct {
+		appID string
+		code  int
+	}{
+		{"payroll", http.StatusNoContent},
+		{"legacy", http.StatusNotFound},
+		{"missing", http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		loaded = nil
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/micro-apps/"+tt.appID, nil))
+		if w.Code != tt.code {
+			t.Errorf("%s: expected status %d, got %d: %s", tt.appID, tt.code, w.Code, w.Body.String())
+		}
+		if tt.code == http.StatusNotFound && loaded != nil {
+			t.Errorf("%s: expected the handler not to run", tt.appID)
+		}
+	}
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/micro-apps/payroll", nil))
+	if loaded == nil || loaded.MicroAppID != "payroll" || loaded.Name != "Payroll" {
+		t.Errorf("Expected the payroll micro app to be loaded, got %+v", loaded)
+	}
+}
+
+// TestUpsertVersion_InactiveMicroApp tests that versions cannot be added to an inactive micro app
+func TestUpsertVersion_InactiveMicroApp(t *testing.T) {
+	db := setupMicroAppTestDB(t)
+	seedMicroApps(t, db)
+	h := NewMicroAppVersionHandler(db)
+
+	r := chi.NewRouter()
+	r.With(LoadMicroApp(db)).Post("/micro-apps/{appID}/versions", h.UpsertVersion)
+
+	body := `{"version":"1.0.0","build":1,"releaseNotes":"First","iconUrl":"https://example.com/icon.png","downloadUrl":"https://example.com/app.zip"}`
+	for appID, code := range map[string]int{"payroll": http.StatusCreated, "legacy": http.StatusNotFound} {
+		req := httptest.NewRequest(http.MethodPost, "/micro-apps/"+appID+"/versions", strings.NewReader(body))
+		req.Header.Set(headerContentType, contentTypeJSON)
+		req = auth.SetUserInfo(req, &auth.CustomJwtPayload{Email: "admin@example.com"})
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		if w.Code != code {
+			t.Errorf("%s: expected status %d, got %d: %s", appID, code, w.Code, w.Body.String())
+		}
+	}
+
+	var count int64
+	db.Model(&models.MicroAppVersion{}).Where("micro_app_id = ?", "legacy").Count(&count)
+	if count != 0 {
+		t.Errorf("Expected no versions for the inactive micro app, got %d", count)
+	}
+}
@@ -0,0 +1,414 @@
+// Copyright (c) 2025 WSO2 LLC. (https://www.wso2.com).
+//
+// WSO2 LLC. licenses this file to you under the Apache License,
+// Version 2.0 (the "License"); you may not use this file except
+// in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+package handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/opensuperapp/opensuperapp/backend-services/core/internal/api/v1/dto"
+	"github.com/opensuperapp/opensuperapp/backend-services/core/internal/auth"
+	"github.com/opensuperapp/opensuperapp/backend-services/core/internal/config"
+	"github.com/opensuperapp/opensuperapp/backend-services/core/internal/models"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// setupMicroAppTestDB creates an in-memory SQLite database with the micro app tables
+func setupMicroAppTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("Failed to open test database: %v", err)
+	}
+	if err := db.AutoMigrate(&models.MicroApp{}, &models.MicroAppVersion{}, &models.MicroAppRole{},
+		&models.MicroAppConfig{}, &models.MicroAppConfigConflict{}, &models.MicroAppAsset{}, &models.FileChecksum{},
+		&models.Category{}, &models.MicroAppCategory{}); err != nil {
+		t.Fatalf("Failed to migrate database: %v", err)
+	}
+	return db
+}
+
+// upsertConfig upserts the payroll micro app with one config value as the given admin
+func upsertConfig(t *testing.T, h *MicroAppHandler, email, key, value string) {
+	t.Helper()
+	body, _ := json.Marshal(dto.CreateMicroAppRequest{
+		AppID:   "payroll",
+		Name:    "Payroll",
+		Configs: []dto.CreateMicroAppConfigRequest{{ConfigKey: key, ConfigValue: json.RawMessage(value)}},
+	})
+	req := httptest.NewRequest(http.MethodPost, "/micro-apps", bytes.NewReader(body))
+	req.Header.Set(headerContentType, contentTypeJSON)
+	req = auth.SetUserInfo(req, &auth.CustomJwtPayload{Email: email})
+	w := httptest.NewRecorder()
+	h.Upsert(w, req)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status 201, got %d: %s", w.Code, w.Body.String())
+	}
+}
+
+// TestUpsert_LogsConfigConflicts tests that near-simultaneous overwrites by different admins are logged
+func TestUpsert_LogsConfigConflicts(t *testing.T) {
+	db := setupMicroAppTestDB(t)
+	h := NewMicroAppHandler(db, time.Minute)
+
+	upsertConfig(t, h, "alice@example.com", "theme", `{"color":"blue"}`)
+	// Bob overwrites Alice's change moments later
+	upsertConfig(t, h, "bob@example.com", "theme", `{"color":"red"}`)
+	// Bob changing his own value, and re-sending the same value, are not conflicts
+	upsertConfig(t, h, "bob@example.com", "theme", `{"color":"green"}`)
+	upsertConfig(t, h, "alice@example.com", "theme", `{ "color": "green" }`)
+
+	// A change made long ago is not a conflict either
+	upsertConfig(t, h, "alice@example.com", "limits", `{"max":1}`)
+	if err := db.Model(&models.MicroAppConfig{}).Where("config_key = ?", "limits").
+		UpdateColumn("updated_at", time.Now().Add(-time.Hour)).Error; err != nil {
+		t.Fatalf("Failed to age config: %v", err)
+	}
+	upsertConfig(t, h, "bob@example.com", "limits", `{"max":2}`)
+
+	req := httptest.NewRequest(http.MethodGet, "/micro-apps/payroll/config-conflicts", nil)
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add(urlParamAppID, "payroll")
+	req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+	w := httptest.NewRecorder()
+	h.ListConfigConflicts(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	var resp dto.MicroAppConfigConflictsResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if len(resp.Conflicts) != 1 {
+		t.Fatalf("Expected 1 conflict, got %+v", resp.Conflicts)
+	}
+	c := resp.Conflicts[0]
+	if c.ConfigKey != "theme" || c.OldUpdatedBy != "alice@example.com" || c.NewUpdatedBy != "bob@example.com" {
+		t.Errorf("Expected alice's theme to be overwritten by bob, got %+v", c)
+	}
+	if string(c.OldValue) != `{"color":"blue"}` || string(c.NewValue) != `{"color":"red"}` {
+		t.Errorf("Expected blue to be replaced by red, got %s and %s", c.OldValue, c.NewValue)
+	}
+}
+
+// TestUpsert_ConfigConflictsDisabled tests that nothing is logged when the conflict window is zero
+func TestUpsert_ConfigConflictsDisabled(t *testing.T) {
+	db := setupMicroAppTestDB(t)
+	h := NewMicroAppHandler(db, 0)
+
+	upsertConfig(t, h, "alice@example.com", "theme", `{"color":"blue"}`)
+	upsertConfig(t, h, "bob@example.com", "theme", `{"color":"red"}`)
+
+	var count int64
+	db.Model(&models.MicroAppConfigConflict{}).Count(&count)
+	if count != 0 {
+		t.Errorf("Expected no conflicts to be logged, got %d", count)
+	}
+}
+
+// seedMicroApp inserts an active micro app visible to the given roles with a version per build
+func seedMicroApp(t *testing.T, db *gorm.DB, appID string, roles []string, builds ...int) {
+	t.Helper()
+	app := models.MicroApp{MicroAppID: appID, Name: appID, CreatedBy: "admin@example.com"}
+	if err := db.Create(&app).Error; err != nil {
+		t.Fatalf("Failed to seed micro app: %v", err)
+	}
+	for _, role := range roles {
+		if err := db.Create(&models.MicroAppRole{MicroAppID: appID, Role: role, CreatedBy: "admin@example.com"}).Error; err != nil {
+			t.Fatalf("Failed to seed micro app role: %v", err)
+		}
+	}
+	for _, build := range builds {
+		version := models.MicroAppVersion{MicroAppID: appID, Version: "1.0." + strconv.Itoa(build), Build: build,
+			DownloadURL: "https://cdn.example.com/" + appID + ".zip", CreatedBy: "admin@example.com"}
+		if err := db.Create(&version).Error; err != nil {
+			t.Fatalf("Failed to seed micro app version: %v", err)
+		}
+	}
+}
+
+// getAllMicroApps lists the micro apps visible to a user in the given groups
+func getAllMicroApps(t *testing.T, h *MicroAppHandler, groups []string) (string, []dto.MicroAppResponse) {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodGet, "/micro-apps", nil)
+	req = auth.SetUserInfo(req, &auth.CustomJwtPayload{Email: "alice@example.com", Groups: groups})
+	w := httptest.NewRecorder()
+	h.GetAll(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var apps []dto.MicroAppResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &apps); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	return strings.TrimSpace(w.Body.String()), apps
+}
+
+// TestGetAll_AuthorizedApps tests that users see the active apps of their groups with the latest version first
+func TestGetAll_AuthorizedApps(t *testing.T) {
+	db := setupMicroAppTestDB(t)
+	h := NewMicroAppHandler(db, 0)
+
+	seedMicroApp(t, db, "payroll", []string{"employees"}, 3, 12, 7)
+	seedMicroApp(t, db, "audit", []string{"auditors"}, 1)
+	seedMicroApp(t, db, "retired", []string{"employees"}, 1)
+	if err := db.Model(&models.MicroApp{}).Where("micro_app_id = ?", "retired").Update("active", models.StatusInactive).Error; err != nil {
+		t.Fatalf("Failed to deactivate micro app: %v", err)
+	}
+
+	_, apps := getAllMicroApps(t, h, []string{"employees"})
+	if len(apps) != 1 || apps[0].AppID != "payroll" {
+		t.Fatalf("Expected only payroll, got %+v", apps)
+	}
+	var builds []int
+	for _, v := range apps[0].Versions {
+		builds = append(builds, v.Build)
+	}
+	if !reflect.DeepEqual(builds, []int{12, 7, 3}) {
+		t.Errorf("Expected versions newest build first, got %v", builds)
+	}
+	if len(apps[0].Roles) != 1 || apps[0].Roles[0].Role != "employees" {
+		t.Errorf("Expected the employees role, got %+v", apps[0].Roles)
+	}
+
+	for _, groups := range [][]string{nil, {"contractors"}} {
+		if body, _ := getAllMicroApps(t, h, groups); body != "[]" {
+			t.Errorf("Expected an empty list for groups %v, got %s", groups, body)
+		}
+	}
+}
+
+// getMicroAppPage lists a page of the micro apps visible to employees with the given query
+func getMicroAppPage(t *testing.T, h *MicroAppHandler, query string) dto.PaginatedMicroAppsResponse {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodGet, "/micro-apps?"+query, nil)
+	req = auth.SetUserInfo(req, &auth.CustomJwtPayload{Email: "alice@example.com", Groups: []string{"employees"}})
+	w := httptest.NewRecorder()
+	h.GetAll(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200 for %q, got %d: %s", query, w.Code, w.Body.String())
+	}
+	var page dto.PaginatedMicroAppsResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &page); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	return page
+}
+
+// TestGetAll_Filters tests each combination of the search, active, mandatory and page parameters
+func TestGetAll_Filters(t *testing.T) {
+	db := setupMicroAppTestDB(t)
+	h := NewMicroAppHandler(db, 0)
+
+	for _, appID := range []string{"payroll", "payroll-archive", "leave", "travel", "retired"} {
+		seedMicroApp(t, db, appID, []string{"employees"}, 1)
+	}
+	seedMicroApp(t, db, "audit", []string{"auditors"}, 1)
+	if err := db.Model(&models.MicroApp{}).Where("micro_app_id IN ?", []string{"payroll-archive", "retired"}).
+		Update("active", models.StatusInactive).Error; err != nil {
+		t.Fatalf("Failed to deactivate micro apps: %v", err)
+	}
+	if err := db.Model(&models.MicroApp{}).Where("micro_app_id IN ?", []string{"payroll", "retired"}).
+		Update("mandatory", 1).Error; err != nil {
+		t.Fatalf("Failed to make micro apps mandatory: %v", err)
+	}
+
+	tests := []struct {
+		query string
+		want  []string
+		total int
+		page  int
+		limit int
+	}{
+		{"page=1", []string{"leave", "payroll", "travel"}, 3, 1, defaultPageLimit},
+		{"page=1&limit=2", []string{"leave", "payroll"}, 3, 1, 2},
+		{"page=2&limit=2", []string{"travel"}, 3, 2, 2},
+		{"page=3&limit=2", []string{}, 3, 3, 2},
+		{"page=1&search=PAY", []string{"payroll"}, 1, 1, defaultPageLimit},
+		{"page=1&search=roll&active=0", []string{"payroll-archive"}, 1, 1, defaultPageLimit},
+		{"page=1&search=%25", []string{}, 0, 1, defaultPageLimit},
+		{"page=1&active=0", []string{"payroll-archive", "retired"}, 2, 1, defaultPageLimit},
+		{"page=1&mandatory=1", []string{"payroll"}, 1, 1, defaultPageLimit},
+		{"page=1&mandatory=0&sort=name&order=desc", []string{"travel", "leave"}, 2, 1, defaultPageLimit},
+		{"page=1&active=0&mandatory=1", []string{"retired"}, 1, 1, defaultPageLimit},
+		{"active=1&mandatory=0&page=2&limit=1", []string{"travel"}, 2, 2, 1},
+	}
+	for _, tt := range tests {
+		page := getMicroAppPage(t, h, tt.query)
+		got := make([]string, 0, len(page.Items))
+		for _, app := range page.Items {
+			got = append(got, app.AppID)
+		}
+		if !reflect.DeepEqual(got, tt.want) || page.Total != tt.total || page.Page != tt.page || page.Limit != tt.limit {
+			t.Errorf("%s: expected %v (total %d, page %d, limit %d), got %v (total %d, page %d, limit %d)",
+				tt.query, tt.want, tt.total, tt.page, tt.limit, got, page.Total, page.Page, page.Limit)
+		}
+	}
+
+	// Without page the response is the plain array of active apps, filtered when filters are set
+	if _, apps := getAllMicroApps(t, h, []string{"employees"}); len(apps) != 3 {
+		t.Errorf("Expected the 3 active apps as a plain list, got %+v", apps)
+	}
+	req := httptest.NewRequest(http.MethodGet, "/micro-apps?search=roll&active=0", nil)
+	req = auth.SetUserInfo(req, &auth.CustomJwtPayload{Email: "alice@example.com", Groups: []string{"employees"}})
+	w := httptest.NewRecorder()
+	h.GetAll(w, req)
+	var apps []dto.MicroAppResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &apps); err != nil {
+		t.Fatalf("Expected a plain list for filters without page, got %s", w.Body.String())
+	}
+	if len(apps) != 1 || apps[0].AppID != "payroll-archive" {
+		t.Errorf("Expected only payroll-archive, got %+v", apps)
+	}
+}
+
+// TestGetAll_InvalidFilters tests that malformed list filters are rejected
+func TestGetAll_InvalidFilters(t *testing.T) {
+	h := NewMicroAppHandler(setupMicroAppTestDB(t), 0)
+	for query, want := range map[string]string{
+		"page=0":                             errInvalidPage,
+		"page=abc":                           errInvalidPage,
+		"page=2&offset=20":                   errPageOffset,
+		"active=yes":                         "active must be 0 or 1",
+		"mandatory=2":                        "mandatory must be 0 or 1",
+		"search=" + strings.Repeat("a", 101): errSearchTooLong,
+	} {
+		req := httptest.NewRequest(http.MethodGet, "/micro-apps?"+query, nil)
+		req = auth.SetUserInfo(req, &auth.CustomJwtPayload{Email: "alice@example.com", Groups: []string{"employees"}})
+		w := httptest.NewRecorder()
+		h.GetAll(w, req)
+		if w.Code != http.StatusBadRequest || strings.TrimSpace(w.Body.String()) != want {
+			t.Errorf("%s: expected 400 %q, got %d %q", query, want, w.Code, w.Body.String())
+		}
+	}
+}
+
+// getMicroAppByID fetches a micro app as a user in the given groups
+func getMicroAppByID(h *MicroAppHandler, appID, query string, groups []string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, "/micro-apps/"+appID+query, nil)
+	req = auth.SetUserInfo(req, &auth.CustomJwtPayload{Email: "alice@example.com", Groups: groups})
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add(urlParamAppID, appID)
+	req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+	w := httptest.NewRecorder()
+	h.GetByID(w, req)
+	return w
+}
+
+// TestGetByID_LatestOnly tests fetching one authorized app with all versions or only the latest
+func TestGetByID_LatestOnly(t *testing.T) {
+	db := setupMicroAppTestDB(t)
+	h := NewMicroAppHandler(db, 0)
+
+	seedMicroApp(t, db, "payroll", []string{"employees"}, 3, 12, 7)
+	if err := db.Model(&models.MicroAppVersion{}).Where("build = ?", 12).Update("active", models.StatusInactive).Error; err != nil {
+		t.Fatalf("Failed to deactivate version: %v", err)
+	}
+
+	tests := []struct {
+		query      string
+		wantBuilds []int
+	}{
+		{"", []int{7, 3}},
+		{"?latestOnly=false", []int{7, 3}},
+		{"?latestOnly=true", []int{7}},
+	}
+	for _, tt := range tests {
+		w := getMicroAppByID(h, "payroll", tt.query, []string{"employees"})
+		if w.Code != http.StatusOK {
+			t.Fatalf("%q: expected status 200, got %d: %s", tt.query, w.Code, w.Body.String())
+		}
+		var app dto.MicroAppResponse
+		if err := json.Unmarshal(w.Body.Bytes(), &app); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+		var builds []int
+		for _, v := range app.Versions {
+			builds = append(builds, v.Build)
+		}
+		if !reflect.DeepEqual(builds, tt.wantBuilds) {
+			t.Errorf("%q: expected builds %v, got %v", tt.query, tt.wantBuilds, builds)
+		}
+	}
+
+	if w := getMicroAppByID(h, "payroll", "?latestOnly=maybe", []string{"employees"}); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for an invalid latestOnly, got %d", w.Code)
+	}
+	if w := getMicroAppByID(h, "payroll", "", []string{"contractors"}); w.Code != http.StatusForbidden {
+		t.Errorf("Expected status 403 for an unauthorized user, got %d", w.Code)
+	}
+	if err := db.Model(&models.MicroApp{}).Where("micro_app_id = ?", "payroll").Update("active", models.StatusInactive).Error; err != nil {
+		t.Fatalf("Failed to deactivate micro app: %v", err)
+	}
+	if w := getMicroAppByID(h, "payroll", "", []string{"employees"}); w.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404 for an inactive app, got %d", w.Code)
+	}
+}
+
+// TestDeactivate_CascadesAndBlocksTokenExchange tests that deactivating a micro app deactivates its
+// versions, roles and configs, and that tokens can no longer be exchanged for it
+func TestDeactivate_CascadesAndBlocksTokenExchange(t *testing.T) {
+	db := setupMicroAppTestDB(t)
+	h := NewMicroAppHandler(db, 0)
+	seedMicroApp(t, db, "payroll", []string{"employees"}, 1, 2)
+	upsertConfig(t, h, "admin@example.com", "theme", `"dark"`)
+
+	req := httptest.NewRequest(http.MethodDelete, "/micro-apps/payroll", nil)
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add(urlParamAppID, "payroll")
+	req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+	w := httptest.NewRecorder()
+	h.Deactivate(w, req)
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), msgMicroAppDeactivatedSuccessfully) {
+		t.Fatalf("Expected status 200 with the success message, got %d: %s", w.Code, w.Body.String())
+	}
+
+	for _, model := range []any{&models.MicroApp{}, &models.MicroAppVersion{}, &models.MicroAppRole{}, &models.MicroAppConfig{}} {
+		var active int64
+		if err := db.Model(model).Where("micro_app_id = ? AND active = ?", "payroll", models.StatusActive).Count(&active).Error; err != nil {
+			t.Fatalf("Failed to count active rows: %v", err)
+		}
+		if active != 0 {
+			t.Errorf("Expected no active %T rows, got %d", model, active)
+		}
+	}
+
+	idp := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("Expected no token to be requested for an inactive micro app")
+	}))
+	defer idp.Close()
+	tokenHandler := NewTokenHandler(db, &config.Config{InternalIdPBaseURL: idp.URL}, nil)
+	body, _ := json.Marshal(dto.TokenExchangeRequest{MicroappID: "payroll"})
+	req = httptest.NewRequest(http.MethodPost, "/token/exchange", bytes.NewReader(body))
+	req.Header.Set(headerContentType, contentTypeJSON)
+	req = auth.SetUserInfo(req, &auth.CustomJwtPayload{Email: "alice@example.com", Groups: []string{"employees"}})
+	w = httptest.NewRecorder()
+	tokenHandler.ExchangeToken(w, req)
+	if w.Code != http.StatusNotFound || !strings.Contains(w.Body.String(), errMicroAppNotFoundOrInactive) {
+		t.Errorf("Expected status 404 for an inactive micro app, got %d: %s", w.Code, w.Body.String())
+	}
+}
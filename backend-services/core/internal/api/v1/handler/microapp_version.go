@@ -16,16 +16,16 @@
 package handler
 
 import (
-	"encoding/json"
 	"errors"
 	"log/slog"
 	"net/http"
+	"strings"
 
 	"github.com/opensuperapp/opensuperapp/backend-services/core/internal/api/v1/dto"
 	"github.com/opensuperapp/opensuperapp/backend-services/core/internal/auth"
 	"github.com/opensuperapp/opensuperapp/backend-services/core/internal/models"
+	"github.com/opensuperapp/opensuperapp/backend-services/core/internal/services"
 
-	"github.com/go-chi/chi/v5"
 	"gorm.io/gorm"
 )
 
@@ -45,34 +45,32 @@ func (h *MicroAppVersionHandler) UpsertVersion(w http.ResponseWriter, r *http.Re
 		return
 	}
 	userEmail := userInfo.Email
-	appID := chi.URLParam(r, urlParamAppID)
-	if appID == "" {
-		http.Error(w, errMissingMicroAppID, http.StatusBadRequest)
-		return
-	}
-	var microApp models.MicroApp
-	if err := h.db.Where("micro_app_id = ?", appID).First(&microApp).Error; err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			slog.Error("Failed to fetch micro app", "error", err, "appID", appID)
-			http.Error(w, errMicroAppNotFound, http.StatusNotFound)
-		} else {
-			slog.Error("Failed to fetch micro app", "error", err, "appID", appID)
-			http.Error(w, errFailedToFetchMicroApp, http.StatusInternalServerError)
-		}
+	microApp, ok := requestMicroApp(w, r, h.db)
+	if !ok {
 		return
 	}
+	appID := microApp.MicroAppID
 	if !validateContentType(w, r) {
 		return
 	}
 	limitRequestBody(w, r, 0)
 	var req dto.CreateMicroAppVersionRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, errInvalidRequestBody, http.StatusBadRequest)
+	if !decodeJSONBody(w, r, &req) {
 		return
 	}
 	if !validateStruct(w, &req) {
 		return
 	}
+	checksum, err := bundleChecksum(h.db, req)
+	if err != nil {
+		if errors.Is(err, errBundleChecksumMismatch) {
+			http.Error(w, errChecksumMismatch, http.StatusBadRequest)
+			return
+		}
+		slog.ErrorContext(r.Context(), "Failed to look up bundle checksum", "error", err, "appID", appID, "downloadUrl", req.DownloadURL)
+		http.Error(w, errFailedToUpsertVersion, http.StatusInternalServerError)
+		return
+	}
 	version := models.MicroAppVersion{}
 	result := h.db.Where("micro_app_id = ? AND version = ? AND build = ?", appID, req.Version, req.Build).
 		Assign(models.MicroAppVersion{
@@ -81,7 +79,7 @@ func (h *MicroAppVersionHandler) UpsertVersion(w http.ResponseWriter, r *http.Re
 			DownloadURL:  req.DownloadURL,
 			Active:       models.StatusActive,
 			UpdatedBy:    &userEmail,
-		}).
+		}, integrityColumns(checksum, req.Signature)).
 		Attrs(models.MicroAppVersion{
 			MicroAppID: appID,
 			Version:    req.Version,
@@ -90,21 +88,57 @@ func (h *MicroAppVersionHandler) UpsertVersion(w http.ResponseWriter, r *http.Re
 		}).FirstOrCreate(&version)
 
 	if result.Error != nil {
-		slog.Error("Failed to upsert version", "error", result.Error, "appID", appID, "version", req.Version, "build", req.Build)
+		slog.ErrorContext(r.Context(), "Failed to upsert version", "error", result.Error, "appID", appID, "version", req.Version, "build", req.Build)
 		http.Error(w, errFailedToUpsertVersion, http.StatusInternalServerError)
 		return
 	}
-	if err := writeJSON(w, http.StatusCreated, dto.MicroAppVersionResponse{
-		ID:           version.ID,
-		MicroAppID:   version.MicroAppID,
-		Version:      version.Version,
-		Build:        version.Build,
-		ReleaseNotes: version.ReleaseNotes,
-		IconURL:      version.IconURL,
-		DownloadURL:  version.DownloadURL,
-		Active:       version.Active,
-	}); err != nil {
-		slog.Error("Failed to write JSON response", "error", err)
+	if err := writeJSON(w, http.StatusCreated, toMicroAppVersionResponse(version)); err != nil {
+		slog.ErrorContext(r.Context(), "Failed to write JSON response", "error", err)
 		http.Error(w, errFailedToWriteResponse, http.StatusInternalServerError)
 	}
 }
+
+// errBundleChecksumMismatch is returned by bundleChecksum when a version's contentSha256 differs
+// from the checksum recorded when its bundle was uploaded
+var errBundleChecksumMismatch = errors.New(errChecksumMismatch)
+
+// bundleChecksum returns the checksum to publish with a version: the one recorded when its bundle
+// was uploaded through the core service, otherwise the one given in the request, or nil.
+func bundleChecksum(db *gorm.DB, req dto.CreateMicroAppVersionRequest) (*string, error) {
+	recorded, err := services.FileChecksumForURL(db, req.DownloadURL)
+	if err != nil {
+		return nil, err
+	}
+	if req.ContentSHA256 == nil {
+		if recorded == "" {
+			return nil, nil
+		}
+		return &recorded, nil
+	}
+	given := strings.ToLower(*req.ContentSHA256)
+	if recorded != "" && given != recorded {
+		return nil, errBundleChecksumMismatch
+	}
+	return &given, nil
+}
+
+// integrityColumns sets a version's checksum and signature even when nil, so a version whose
+// bundle changed does not keep publishing the checksum of the old one
+func integrityColumns(checksum, signature *string) map[string]any {
+	return map[string]any{"content_sha256": checksum, "signature": signature}
+}
+
+func toMicroAppVersionResponse(v models.MicroAppVersion) dto.MicroAppVersionResponse {
+	return dto.MicroAppVersionResponse{
+		ID:            v.ID,
+		MicroAppID:    v.MicroAppID,
+		Version:       v.Version,
+		Build:         v.Build,
+		ReleaseNotes:  v.ReleaseNotes,
+		IconURL:       v.IconURL,
+		DownloadURL:   v.DownloadURL,
+		ContentSHA256: v.ContentSHA256,
+		Signature:     v.Signature,
+		Active:        v.Active,
+	}
+}
@@ -0,0 +1,170 @@
+// Copyright (c) 2025 WSO2 LLC. (https://www.wso2.com).
+//
+// WSO2 LLC. licenses this file to you under the Apache License,
+// Version 2.0 (the "License"); you may not use this file except
+// in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+package handler
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/opensuperapp/opensuperapp/backend-services/core/internal/api/v1/dto"
+	"github.com/opensuperapp/opensuperapp/backend-services/core/internal/auth"
+	"github.com/opensuperapp/opensuperapp/backend-services/core/internal/models"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// upsertVersion upserts a version of the payroll micro app and returns the response
+func upsertVersion(h *MicroAppVersionHandler, req dto.CreateMicroAppVersionRequest) *httptest.ResponseRecorder {
+	body, _ := json.Marshal(req)
+	r := httptest.NewRequest(http.MethodPost, "/micro-apps/payroll/versions", bytes.NewReader(body))
+	r.Header.Set(headerContentType, contentTypeJSON)
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add(urlParamAppID, "payroll")
+	r = r.WithContext(context.WithValue(r.Context(), chi.RouteCtxKey, rctx))
+	r = auth.SetUserInfo(r, &auth.CustomJwtPayload{Email: "admin@example.com"})
+	w := httptest.NewRecorder()
+	h.UpsertVersion(w, r)
+	return w
+}
+
+// TestUpsertVersion_BundleChecksum tests that the checksum computed when a bundle is uploaded is
+// stored with the version, returned with it, and matches the header and content of the download
+func TestUpsertVersion_BundleChecksum(t *testing.T) {
+	db := setupMicroAppTestDB(t)
+	if err := db.Create(&models.MicroApp{MicroAppID: "payroll", Name: "Payroll", CreatedBy: "admin@example.com"}).Error; err != nil {
+		t.Fatalf("Failed to seed micro app: %v", err)
+	}
+	fs := &fakePresignFileService{}
+	files := NewFileHandler(fs, 10).WithAssetOwners(db)
+	versions := NewMicroAppVersionHandler(db)
+
+	w := httptest.NewRecorder()
+	files.UploadFile(w, httptest.NewRequest(http.MethodPost, "/files?fileName=payroll.zip&microappId=payroll", bytes.NewReader(testZIP)))
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status 201, got %d: %s", w.Code, w.Body.String())
+	}
+	var uploaded fileUploadResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &uploaded); err != nil {
+		t.Fatalf("Failed to decode upload response: %v", err)
+	}
+	sum := sha256.Sum256(testZIP)
+	want := hex.EncodeToString(sum[:])
+	if uploaded.ContentSHA256 != want {
+		t.Fatalf("Expected upload checksum %s, got %s", want, uploaded.ContentSHA256)
+	}
+
+	w = upsertVersion(versions, dto.CreateMicroAppVersionRequest{Version: "1.0.0", Build: 1, DownloadURL: uploaded.DownloadURL})
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status 201, got %d: %s", w.Code, w.Body.String())
+	}
+	var version dto.MicroAppVersionResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &version); err != nil {
+		t.Fatalf("Failed to decode version response: %v", err)
+	}
+	if version.ContentSHA256 == nil || *version.ContentSHA256 != want {
+		t.Fatalf("Expected version checksum %s, got %v", want, version.ContentSHA256)
+	}
+	var stored models.MicroAppVersion
+	if err := db.First(&stored, version.ID).Error; err != nil {
+		t.Fatalf("Failed to load version: %v", err)
+	}
+	if stored.ContentSHA256 == nil || *stored.ContentSHA256 != want {
+		t.Errorf("Expected stored checksum %s, got %v", want, stored.ContentSHA256)
+	}
+
+	r := httptest.NewRequest(http.MethodGet, "/public/micro-app-files/download/payroll.zip", nil)
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add(QueryParamFileName, "payroll.zip")
+	r = r.WithContext(context.WithValue(r.Context(), chi.RouteCtxKey, rctx))
+	w = httptest.NewRecorder()
+	files.DownloadMicroAppFile(w, r)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", w.Code)
+	}
+	served := sha256.Sum256(w.Body.Bytes())
+	if got := w.Header().Get("X-Content-SHA256"); got != want || hex.EncodeToString(served[:]) != want {
+		t.Errorf("Expected X-Content-SHA256 and the served content to hash to %s, got header %q", want, got)
+	}
+
+	// A checksum given for an uploaded bundle must match the recorded one
+	other := strings.Repeat("0", 64)
+	w = upsertVersion(versions, dto.CreateMicroAppVersionRequest{Version: "1.0.1", Build: 2, DownloadURL: uploaded.DownloadURL, ContentSHA256: &other})
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for a mismatched checksum, got %d", w.Code)
+	}
+	upper := strings.ToUpper(want)
+	w = upsertVersion(versions, dto.CreateMicroAppVersionRequest{Version: "1.0.1", Build: 2, DownloadURL: uploaded.DownloadURL, ContentSHA256: &upper})
+	if w.Code != http.StatusCreated {
+		t.Errorf("Expected status 201 for a matching checksum in upper case, got %d: %s", w.Code, w.Body.String())
+	}
+}
+
+// TestUpsertVersion_ExternalBundle tests that a bundle uploaded without the core service takes the
+// checksum and signature given in the request, and that re-upserting without them clears both
+func TestUpsertVersion_ExternalBundle(t *testing.T) {
+	db := setupMicroAppTestDB(t)
+	if err := db.Create(&models.MicroApp{MicroAppID: "payroll", Name: "Payroll", CreatedBy: "admin@example.com"}).Error; err != nil {
+		t.Fatalf("Failed to seed micro app: %v", err)
+	}
+	h := NewMicroAppVersionHandler(db)
+	checksum := strings.Repeat("ab", 32)
+	signature := "MEUCIQDsig"
+	req := dto.CreateMicroAppVersionRequest{
+		Version:       "2.0.0",
+		Build:         3,
+		DownloadURL:   "https://cdn.example.com/payroll-2.0.0.zip",
+		ContentSHA256: &checksum,
+		Signature:     &signature,
+	}
+
+	w := upsertVersion(h, req)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status 201, got %d: %s", w.Code, w.Body.String())
+	}
+	var version dto.MicroAppVersionResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &version); err != nil {
+		t.Fatalf("Failed to decode version response: %v", err)
+	}
+	if version.ContentSHA256 == nil || *version.ContentSHA256 != checksum || version.Signature == nil || *version.Signature != signature {
+		t.Errorf("Expected the given checksum and signature, got %+v", version)
+	}
+
+	req.ContentSHA256, req.Signature = nil, nil
+	req.DownloadURL = "https://cdn.example.com/payroll-2.0.0-fixed.zip"
+	if w := upsertVersion(h, req); w.Code != http.StatusCreated {
+		t.Fatalf("Expected status 201, got %d: %s", w.Code, w.Body.String())
+	}
+	var stored models.MicroAppVersion
+	if err := db.First(&stored, version.ID).Error; err != nil {
+		t.Fatalf("Failed to load version: %v", err)
+	}
+	if stored.ContentSHA256 != nil || stored.Signature != nil {
+		t.Errorf("Expected the checksum and signature of the replaced bundle to be cleared, got %v and %v", stored.ContentSHA256, stored.Signature)
+	}
+
+	bad := "not-a-checksum"
+	req.ContentSHA256 = &bad
+	if w := upsertVersion(h, req); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for a malformed checksum, got %d", w.Code)
+	}
+}
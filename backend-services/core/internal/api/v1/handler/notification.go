@@ -16,29 +16,102 @@
 package handler
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"log/slog"
+	"math"
 	"net/http"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/opensuperapp/opensuperapp/backend-services/core/internal/api/v1/dto"
 	"github.com/opensuperapp/opensuperapp/backend-services/core/internal/auth"
+	"github.com/opensuperapp/opensuperapp/backend-services/core/internal/metrics"
 	"github.com/opensuperapp/opensuperapp/backend-services/core/internal/models"
 	"github.com/opensuperapp/opensuperapp/backend-services/core/internal/services"
 
+	"github.com/go-chi/chi/v5"
 	"gorm.io/gorm"
 )
 
+// topicNamePattern matches the characters FCM accepts in a topic name.
+var topicNamePattern = regexp.MustCompile(`^[a-zA-Z0-9\-_.~%]+$`)
+
+// webPushTokenPattern matches an FCM registration token issued to a browser. A raw Web Push
+// subscription (JSON with an endpoint and keys) cannot be sent to through FCM and is rejected.
+var webPushTokenPattern = regexp.MustCompile(`^[a-zA-Z0-9\-_:]{32,}$`)
+
+// supportedPlatforms are the values of the device_tokens platform enum
+var supportedPlatforms = []string{platformIOS, platformAndroid, platformWeb}
+
 type NotificationHandler struct {
-	db         *gorm.DB
-	fcmService services.NotificationService
+	db            *gorm.DB
+	fcmService    services.NotificationService
+	receiptSigner *services.ReceiptSigner
+	defaultSender services.SenderIdentity
+	invalidTokens services.InvalidTokenHandler      // Told about tokens FCM reported as no longer registered
+	quota         *services.QuotaService            // Limits sends per microapp; nil disables quotas
+	metrics       *metrics.NotificationMetrics      // Counts sends per microapp; nil records nothing
+	imagePolicy   *services.NotificationImagePolicy // Vets imageUrl against microapp assets; nil allows any https image
+	coalescer     *services.NotificationCoalescer   // Merges sends within a microapp's coalescing window; nil sends immediately
+	maxTokens     int                               // Unique tokens one send reaches, as configured on the FCM service
 }
 
-func NewNotificationHandler(db *gorm.DB, fcmService services.NotificationService) *NotificationHandler {
+func NewNotificationHandler(db *gorm.DB, fcmService services.NotificationService, receiptSigner *services.ReceiptSigner, defaultSender services.SenderIdentity) *NotificationHandler {
 	return &NotificationHandler{
-		db:         db,
-		fcmService: fcmService,
+		db:            db,
+		fcmService:    fcmService,
+		receiptSigner: receiptSigner,
+		defaultSender: defaultSender,
+		invalidTokens: services.NewDeviceTokenDeactivator(db),
+		maxTokens:     services.FCMAbsoluteLimit,
+	}
+}
+
+// WithInvalidTokenHandler replaces the handler told about invalid device tokens after a send and
+// returns the handler. By default the tokens are deactivated in the device_tokens table.
+func (h *NotificationHandler) WithInvalidTokenHandler(invalidTokens services.InvalidTokenHandler) *NotificationHandler {
+	h.invalidTokens = invalidTokens
+	return h
+}
+
+// WithQuotaService enables per-microapp send quotas and returns the handler
+func (h *NotificationHandler) WithQuotaService(quota *services.QuotaService) *NotificationHandler {
+	h.quota = quota
+	return h
+}
+
+// WithImagePolicy requires notification images to pass the policy and returns the handler
+func (h *NotificationHandler) WithImagePolicy(policy *services.NotificationImagePolicy) *NotificationHandler {
+	h.imagePolicy = policy
+	return h
+}
+
+// WithCoalescer holds sends of microapps with a coalescing window for merging and returns the handler
+func (h *NotificationHandler) WithCoalescer(coalescer *services.NotificationCoalescer) *NotificationHandler {
+	h.coalescer = coalescer
+	return h
+}
+
+// WithMaxTokensPerSend sets the number of unique tokens one send reaches, which must match the
+// limit the FCM service was configured with, and returns the handler. A limit <= 0 keeps the default.
+func (h *NotificationHandler) WithMaxTokensPerSend(limit int) *NotificationHandler {
+	if limit > 0 {
+		h.maxTokens = limit
 	}
+	return h
+}
+
+// WithMetrics enables the per-microapp send counters and returns the handler
+func (h *NotificationHandler) WithMetrics(m *metrics.NotificationMetrics) *NotificationHandler {
+	h.metrics = m
+	return h
 }
 
 func (h *NotificationHandler) RegisterDeviceToken(w http.ResponseWriter, r *http.Request) {
@@ -52,8 +125,10 @@ func (h *NotificationHandler) RegisterDeviceToken(w http.ResponseWriter, r *http
 	}
 	limitRequestBody(w, r, 0)
 	var req dto.RegisterDeviceTokenRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, errInvalidRequestBody, http.StatusBadRequest)
+	if !decodeJSONBody(w, r, &req) {
+		return
+	}
+	if !normalizePlatform(w, &req.Platform) {
 		return
 	}
 	if !validateStruct(w, &req) {
@@ -63,28 +138,87 @@ func (h *NotificationHandler) RegisterDeviceToken(w http.ResponseWriter, r *http
 		http.Error(w, errEmailDoesNotMatchAuthUser, http.StatusForbidden)
 		return
 	}
+	if req.Platform == platformWeb && !webPushTokenPattern.MatchString(req.Token) {
+		http.Error(w, errInvalidWebPushToken, http.StatusBadRequest)
+		return
+	}
 	deviceToken := models.DeviceToken{
 		UserEmail:   req.Email,
 		DeviceToken: req.Token,
 		Platform:    req.Platform,
 		IsActive:    true,
 	}
-	result := h.db.Where("user_email = ? AND platform = ?", req.Email, req.Platform).
+	// Keyed on the token as well, so each of the user's devices on a platform keeps its own row
+	result := h.db.Where("user_email = ? AND platform = ? AND device_token = ?", req.Email, req.Platform, req.Token).
 		Assign(models.DeviceToken{
-			DeviceToken: req.Token,
-			IsActive:    true,
+			AppBuild:   req.AppBuild,
+			LastSeenAt: time.Now(),
+			IsActive:   true,
 		}).
 		FirstOrCreate(&deviceToken)
 
 	if result.Error != nil {
-		slog.Error("Failed to register device token", "error", result.Error, "email", req.Email)
+		slog.ErrorContext(r.Context(), "Failed to register device token", "error", result.Error, "email", req.Email)
 		http.Error(w, errFailedToRegisterDeviceToken, http.StatusInternalServerError)
 		return
 	}
-	slog.Info("Device token registered successfully", "email", req.Email, "platform", req.Platform)
+	// Keep group memberships current so group-addressed notifications reach this user
+	if err := h.syncUserGroups(userInfo.Email, userInfo.Groups); err != nil {
+		slog.WarnContext(r.Context(), "Failed to sync user groups", "error", err, "email", userInfo.Email)
+	}
+	slog.InfoContext(r.Context(), "Device token registered successfully", "email", req.Email, "platform", req.Platform)
 	w.WriteHeader(http.StatusCreated)
 }
 
+// deviceSort lists devices most recently registered first by default.
+var deviceSort = listSort{
+	fields: map[string]string{
+		"updatedAt":    "updated_at",
+		"registeredAt": "created_at",
+		"platform":     "platform",
+	},
+	defaultField: "updatedAt",
+	defaultDesc:  true,
+	tieBreaker:   "id",
+}
+
+// ListDevices returns the caller's active devices, most recently registered first.
+// Every device is listed unless a limit or offset selects a page.
+func (h *NotificationHandler) ListDevices(w http.ResponseWriter, r *http.Request) {
+	userInfo, ok := auth.GetUserInfo(r.Context())
+	if !ok {
+		http.Error(w, errUserInfoNotFound, http.StatusUnauthorized)
+		return
+	}
+	params, err := parseListParams(r, deviceSort)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	var devices []models.DeviceToken
+	query := h.db.Where("user_email = ? AND is_active = ?", userInfo.Email, true).Order(params.order)
+	if params.paged {
+		query = query.Limit(params.limit).Offset(params.offset)
+	}
+	if err := query.Find(&devices).Error; err != nil {
+		slog.ErrorContext(r.Context(), "Failed to list devices", "error", err, "email", userInfo.Email)
+		http.Error(w, errFailedToListDevices, http.StatusInternalServerError)
+		return
+	}
+	resp := dto.DeviceListResponse{Devices: make([]dto.DeviceResponse, 0, len(devices))}
+	for _, device := range devices {
+		resp.Devices = append(resp.Devices, dto.DeviceResponse{
+			ID:           device.ID,
+			Platform:     device.Platform,
+			Token:        device.DeviceToken,
+			AppBuild:     device.AppBuild,
+			RegisteredAt: device.CreatedAt,
+			UpdatedAt:    device.UpdatedAt,
+		})
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
 func (h *NotificationHandler) DeactivateDeviceToken(w http.ResponseWriter, r *http.Request) {
 	userInfo, ok := auth.GetUserInfo(r.Context())
 	if !ok {
@@ -96,8 +230,10 @@ func (h *NotificationHandler) DeactivateDeviceToken(w http.ResponseWriter, r *ht
 	}
 	limitRequestBody(w, r, 0)
 	var req dto.DeactivateDeviceTokenRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, errInvalidRequestBody, http.StatusBadRequest)
+	if !decodeJSONBody(w, r, &req) {
+		return
+	}
+	if !normalizePlatform(w, &req.Platform) {
 		return
 	}
 	if !validateStruct(w, &req) {
@@ -116,11 +252,11 @@ func (h *NotificationHandler) DeactivateDeviceToken(w http.ResponseWriter, r *ht
 
 	if result.Error != nil {
 		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
-			slog.Warn("Device token not found for deactivation", "email", req.Email, "platform", req.Platform)
+			slog.WarnContext(r.Context(), "Device token not found for deactivation", "email", req.Email, "platform", req.Platform)
 			http.Error(w, errDeviceTokenNotFound, http.StatusNotFound)
 			return
 		}
-		slog.Error("Failed to find device token", "error", result.Error, "email", req.Email)
+		slog.ErrorContext(r.Context(), "Failed to find device token", "error", result.Error, "email", req.Email)
 		http.Error(w, errFailedToDeactivateDeviceToken, http.StatusInternalServerError)
 		return
 	}
@@ -128,16 +264,254 @@ func (h *NotificationHandler) DeactivateDeviceToken(w http.ResponseWriter, r *ht
 	// Update to deactivate
 	deviceToken.IsActive = false
 	if err := h.db.Save(&deviceToken).Error; err != nil {
-		slog.Error("Failed to deactivate device token", "error", err, "email", req.Email)
+		slog.ErrorContext(r.Context(), "Failed to deactivate device token", "error", err, "email", req.Email)
 		http.Error(w, errFailedToDeactivateDeviceToken, http.StatusInternalServerError)
 		return
 	}
 
-	slog.Info("Device token deactivated successfully", "email", req.Email, "platform", req.Platform)
+	slog.InfoContext(r.Context(), "Device token deactivated successfully", "email", req.Email, "platform", req.Platform)
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(map[string]string{"message": "Device token deactivated successfully"})
 }
 
+// RevokeUserDevices deactivates every device token of a user and drops the user from pending
+// notification targeting. It is called by the IdP or an admin when an account is disabled or
+// signed out everywhere, and is idempotent: repeating it reports zero changes.
+func (h *NotificationHandler) RevokeUserDevices(w http.ResponseWriter, r *http.Request) {
+	actor, ok := auth.GetUserInfo(r.Context())
+	if !ok {
+		http.Error(w, errUserInfoNotFound, http.StatusUnauthorized)
+		return
+	}
+	if !validateContentType(w, r) {
+		return
+	}
+	limitRequestBody(w, r, 0)
+	var req dto.RevokeUserDevicesRequest
+	if !decodeJSONBody(w, r, &req) {
+		return
+	}
+	if !validateStruct(w, &req) {
+		return
+	}
+
+	h.revokeUserDevices(w, r, actor.Email, req.Email)
+}
+
+// RevokeUserDevicesByEmail is the REST form of RevokeUserDevices, for the user in the email URL parameter
+func (h *NotificationHandler) RevokeUserDevicesByEmail(w http.ResponseWriter, r *http.Request) {
+	actor, ok := auth.GetUserInfo(r.Context())
+	if !ok {
+		http.Error(w, errUserInfoNotFound, http.StatusUnauthorized)
+		return
+	}
+	email := chi.URLParam(r, paramEmail)
+	if err := validate.Var(email, "required,email"); err != nil {
+		http.Error(w, errInvalidEmailParameter, http.StatusBadRequest)
+		return
+	}
+
+	h.revokeUserDevices(w, r, actor.Email, email)
+}
+
+// DeactivateAllDevices deactivates every device token of the caller, to sign out everywhere.
+// DeactivateDeviceToken remains for signing out of a single device.
+func (h *NotificationHandler) DeactivateAllDevices(w http.ResponseWriter, r *http.Request) {
+	userInfo, ok := auth.GetUserInfo(r.Context())
+	if !ok {
+		http.Error(w, errUserInfoNotFound, http.StatusUnauthorized)
+		return
+	}
+	result := h.db.Model(&models.DeviceToken{}).
+		Where("user_email = ? AND is_active = ?", userInfo.Email, true).
+		Update("is_active", false)
+	if result.Error != nil {
+		slog.ErrorContext(r.Context(), "Failed to deactivate devices", "error", result.Error, "email", userInfo.Email)
+		http.Error(w, errFailedToDeactivateDeviceToken, http.StatusInternalServerError)
+		return
+	}
+	slog.InfoContext(r.Context(), "All devices deactivated", "email", userInfo.Email, "devices_deactivated", result.RowsAffected)
+	writeJSON(w, http.StatusOK, dto.DeactivateDevicesResponse{DevicesDeactivated: result.RowsAffected})
+}
+
+// notificationHistorySort lists the notification history newest first by default.
+var notificationHistorySort = listSort{
+	fields:       map[string]string{"sentAt": "sent_at"},
+	defaultField: "sentAt",
+	defaultDesc:  true,
+	tieBreaker:   "id",
+}
+
+// GetNotificationHistory returns the notifications sent to the authenticated user, newest first.
+// Pages are selected by offset, or by a before/after cursor from a previous response. Cursors
+// follow the default newest-first order, so they cannot be combined with another sort.
+func (h *NotificationHandler) GetNotificationHistory(w http.ResponseWriter, r *http.Request) {
+	userInfo, ok := auth.GetUserInfo(r.Context())
+	if !ok {
+		http.Error(w, errUserInfoNotFound, http.StatusUnauthorized)
+		return
+	}
+	params, err := parseListParams(r, notificationHistorySort)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	limit, offset := params.limit, params.offset
+	before, after := r.URL.Query().Get(queryParamBefore), r.URL.Query().Get(queryParamAfter)
+	if before != "" && after != "" || (before != "" || after != "") && offset > 0 {
+		http.Error(w, errCursorConflict, http.StatusBadRequest)
+		return
+	}
+	cursors := params.isDefaultSort(notificationHistorySort)
+	if (before != "" || after != "") && !cursors {
+		http.Error(w, errCursorOrder, http.StatusBadRequest)
+		return
+	}
+	// Always scope to the caller; never accept an email from the request
+	query := h.db.Where("user_email = ?", userInfo.Email)
+	if microappID := r.URL.Query().Get(queryParamMicroappID); microappID != "" {
+		query = query.Where("microapp_id = ?", microappID)
+	}
+	// One extra row is fetched to tell whether another page follows
+	order := params.order
+	switch {
+	case before != "":
+		cursor, err := decodePageCursor(before)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		query = query.Where("sent_at < ? OR (sent_at = ? AND id < ?)", cursor.At, cursor.At, cursor.ID)
+	case after != "":
+		cursor, err := decodePageCursor(after)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		// Walk towards newer rows from the cursor, then restore newest-first order below
+		query = query.Where("sent_at > ? OR (sent_at = ? AND id > ?)", cursor.At, cursor.At, cursor.ID)
+		order = "sent_at ASC, id ASC"
+	default:
+		query = query.Offset(offset)
+	}
+	var logs []models.NotificationLog
+	if err := query.Order(order).Limit(limit + 1).Find(&logs).Error; err != nil {
+		slog.ErrorContext(r.Context(), "Failed to fetch notification history", "error", err, "email", userInfo.Email)
+		http.Error(w, errFailedToFetchNotificationHistory, http.StatusInternalServerError)
+		return
+	}
+	hasMore := len(logs) > limit
+	if hasMore {
+		logs = logs[:limit]
+	}
+	if after != "" {
+		slices.Reverse(logs)
+	}
+	items := make([]dto.NotificationHistoryItem, len(logs))
+	for i, log := range logs {
+		items[i] = dto.NotificationHistoryItem{
+			ID:         log.ID,
+			MicroappID: derefString(log.MicroappID),
+			Title:      derefString(log.Title),
+			Body:       derefString(log.Body),
+			Data:       log.Data,
+			Status:     derefString(log.Status),
+			SentAt:     log.SentAt,
+			ReadAt:     log.ReadAt,
+		}
+	}
+	resp := dto.NotificationHistoryResponse{Notifications: items, Limit: limit, Offset: offset}
+	if len(logs) > 0 && cursors {
+		newest, oldest := logs[0], logs[len(logs)-1]
+		// Older rows remain past a full page, or behind an after cursor
+		if hasMore || after != "" {
+			resp.NextCursor = encodePageCursor(oldest.ID, oldest.SentAt)
+		}
+		// Newer rows remain past a full page walking up, or ahead of a before cursor or offset
+		if (after != "" && hasMore) || before != "" || offset > 0 {
+			resp.PrevCursor = encodePageCursor(newest.ID, newest.SentAt)
+		}
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// MarkNotificationRead stamps read_at on one of the authenticated user's notifications.
+// Marking an already read notification keeps the original timestamp.
+func (h *NotificationHandler) MarkNotificationRead(w http.ResponseWriter, r *http.Request) {
+	userInfo, ok := auth.GetUserInfo(r.Context())
+	if !ok {
+		http.Error(w, errUserInfoNotFound, http.StatusUnauthorized)
+		return
+	}
+	notificationID, err := strconv.ParseInt(chi.URLParam(r, urlParamNotifID), 10, 64)
+	if err != nil {
+		http.Error(w, errInvalidNotificationID, http.StatusBadRequest)
+		return
+	}
+	var log models.NotificationLog
+	// Scoped to the caller so users cannot touch other users' rows
+	if err := h.db.Where("id = ? AND user_email = ?", notificationID, userInfo.Email).First(&log).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			http.Error(w, errNotificationNotFound, http.StatusNotFound)
+			return
+		}
+		slog.ErrorContext(r.Context(), "Failed to fetch notification", "error", err, "id", notificationID)
+		http.Error(w, errFailedToMarkNotificationRead, http.StatusInternalServerError)
+		return
+	}
+	if log.ReadAt == nil {
+		now := time.Now()
+		marked := false
+		err := h.db.Transaction(func(tx *gorm.DB) error {
+			result := tx.Model(&models.NotificationLog{}).
+				Where("id = ? AND user_email = ? AND read_at IS NULL", notificationID, userInfo.Email).
+				Updates(map[string]interface{}{"read_at": now, "delivery_status": models.DeliveryStatusRead})
+			if result.Error != nil {
+				return result.Error
+			}
+			marked = result.RowsAffected > 0
+			// Opening the notification on one device reads it on every device it reached
+			return tx.Model(&models.NotificationDelivery{}).
+				Where("notification_log_id = ? AND status IN ?", notificationID,
+					[]string{models.DeliveryStatusPending, models.DeliveryStatusDelivered}).
+				Update("status", models.DeliveryStatusRead).Error
+		})
+		if err != nil {
+			slog.ErrorContext(r.Context(), "Failed to mark notification as read", "error", err, "id", notificationID)
+			http.Error(w, errFailedToMarkNotificationRead, http.StatusInternalServerError)
+			return
+		}
+		if marked {
+			log.ReadAt = &now
+		} else if err := h.db.Select("read_at").Where("id = ?", notificationID).First(&log).Error; err != nil {
+			// Marked read concurrently; report the stored timestamp
+			slog.ErrorContext(r.Context(), "Failed to reload notification", "error", err, "id", notificationID)
+			http.Error(w, errFailedToMarkNotificationRead, http.StatusInternalServerError)
+			return
+		}
+	}
+	writeJSON(w, http.StatusOK, dto.MarkNotificationReadResponse{ID: log.ID, ReadAt: *log.ReadAt})
+}
+
+// GetUnreadCount returns how many of the authenticated user's notifications have not been read.
+func (h *NotificationHandler) GetUnreadCount(w http.ResponseWriter, r *http.Request) {
+	userInfo, ok := auth.GetUserInfo(r.Context())
+	if !ok {
+		http.Error(w, errUserInfoNotFound, http.StatusUnauthorized)
+		return
+	}
+	var count int64
+	// Served by the (user_email, read_at) index rather than a scan of the user's notifications
+	if err := h.db.Model(&models.NotificationLog{}).
+		Where("user_email = ? AND read_at IS NULL", userInfo.Email).
+		Count(&count).Error; err != nil {
+		slog.ErrorContext(r.Context(), "Failed to count unread notifications", "error", err, "email", userInfo.Email)
+		http.Error(w, errFailedToCountUnread, http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusOK, dto.UnreadCountResponse{Count: count})
+}
+
 func (h *NotificationHandler) SendNotification(w http.ResponseWriter, r *http.Request) {
 	if h.fcmService == nil {
 		http.Error(w, errNotificationServiceNotAvailable, http.StatusServiceUnavailable)
@@ -148,97 +522,1294 @@ func (h *NotificationHandler) SendNotification(w http.ResponseWriter, r *http.Re
 	}
 	limitRequestBody(w, r, 0)
 	var req dto.SendNotificationRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, errInvalidRequestBody, http.StatusBadRequest)
+	if !decodeJSONBody(w, r, &req) {
 		return
 	}
 	if !validateStruct(w, &req) {
 		return
 	}
+	if req.Receipt && h.receiptSigner == nil {
+		http.Error(w, errReceiptsNotConfigured, http.StatusBadRequest)
+		return
+	}
+	for _, topic := range req.Topics {
+		if !topicNamePattern.MatchString(topic) {
+			http.Error(w, errInvalidTopicName, http.StatusBadRequest)
+			return
+		}
+	}
+	rejectTokenLimit := services.TokenLimitPolicy(req.TokenLimit) == services.TokenLimitReject
+	if rejectTokenLimit && len(req.Topics) > 0 {
+		// Topics are sent before devices, so a rejected send could not take them back
+		http.Error(w, errTokenLimitRejectWithTopics, http.StatusBadRequest)
+		return
+	}
+	if req.ScheduledAt != nil {
+		// Per-recipient filtering and receipts happen at send time, which the worker does not repeat
+		if len(req.Topics) > 0 || req.Receipt || req.DedupKey != "" || req.MinBuild > 0 || len(req.Localized) > 0 || rejectTokenLimit {
+			http.Error(w, errScheduledSendUnsupported, http.StatusBadRequest)
+			return
+		}
+		if !req.ScheduledAt.After(time.Now()) {
+			http.Error(w, errScheduledAtMustBeInFuture, http.StatusBadRequest)
+			return
+		}
+	}
 	// in this context client id is the microapp id
 	microappID, err := h.getClientID(r)
 	if err != nil {
-		slog.Error(errClientIDInvalid, "error", err)
+		slog.ErrorContext(r.Context(), errClientIDInvalid, "error", err)
 		http.Error(w, errClientIDInvalid, http.StatusUnauthorized)
 		return
 	}
-	var deviceTokens []models.DeviceToken
-	if err := h.db.Where("user_email IN ? AND is_active = ?", req.UserEmails, true).Find(&deviceTokens).Error; err != nil {
-		slog.Error("Failed to fetch device tokens", "error", err)
-		http.Error(w, errFailedToFetchDeviceTokens, http.StatusInternalServerError)
+	var overrides map[string]platformVariant
+	if req.TemplateKey != "" {
+		var ok bool
+		if overrides, ok = h.applyTemplate(w, r, microappID, &req); !ok {
+			return
+		}
+	}
+	if req.ScheduledAt != nil && len(overrides) > 0 {
+		// The worker sends one message to every device, so the overrides would be lost
+		http.Error(w, errScheduledPlatformOverrides, http.StatusBadRequest)
 		return
 	}
-	if len(deviceTokens) == 0 {
-		slog.Warn("No active device tokens found for users", "users", req.UserEmails)
-		writeJSON(w, http.StatusOK, dto.NotificationResponse{Success: 0, Failed: 0, Message: msgNoActiveDeviceTokensFound})
+	if req.ImageURL != "" && !h.checkImageURL(w, r, microappID, req.ImageURL) {
 		return
 	}
-	tokens := make([]string, len(deviceTokens))
-	for i, dt := range deviceTokens {
-		tokens[i] = dt.DeviceToken
+	// Every requested recipient and topic counts against the quota, even if later skipped
+	if !h.chargeQuota(w, r, microappID, len(req.UserEmails)+len(req.Topics)) {
+		return
 	}
-	dataStr := h.prepareFCMData(req.Data, microappID)
-	successCount, failureCount, err := h.fcmService.SendMulticastNotification(r.Context(), tokens, req.Title, req.Body, dataStr)
+	fcmData := func(data map[string]interface{}) map[string]string {
+		dataStr := h.prepareFCMData(data, microappID)
+		if req.CollapseKey != "" {
+			dataStr[services.DataKeyCollapseKey] = req.CollapseKey
+		}
+		if req.TTL != nil {
+			dataStr[services.DataKeyTTL] = strconv.Itoa(*req.TTL)
+		}
+		if req.ImageURL != "" {
+			dataStr[services.DataKeyImageURL] = req.ImageURL
+		}
+		if req.Badge != nil {
+			dataStr[services.DataKeyBadge] = strconv.Itoa(*req.Badge)
+		}
+		return dataStr
+	}
+	dataStr := fcmData(req.Data)
+	if req.ScheduledAt != nil {
+		h.storeScheduledNotification(w, r, microappID, req.UserEmails, req.Title, req.Body, dataStr, *req.ScheduledAt)
+		return
+	}
+	// Like scheduled sends, coalesced sends are filtered per recipient when dispatched, so sends that
+	// need filtering or a response about the delivery go out immediately
+	coalescable := len(req.Topics) == 0 && !req.Receipt && req.DedupKey == "" && req.MinBuild == 0 && len(req.Localized) == 0 && !rejectTokenLimit && len(overrides) == 0
+	if coalescable && h.coalescer != nil {
+		coalesced, ok := h.coalesce(w, r, microappID, req.UserEmails, req.Title, req.Body, dataStr)
+		if !ok || coalesced {
+			return
+		}
+	}
+	response := dto.NotificationResponse{Message: msgNotificationsSentSuccessfully}
+	if len(req.Topics) > 0 {
+		// Topic subscribers' platforms are unknown, so they get the base message
+		response.Topics, response.Success, response.Failed = h.sendToTopics(r.Context(), microappID, req.Topics, req.Title, req.Body, dataStr)
+		if len(req.UserEmails) == 0 {
+			writeJSON(w, http.StatusOK, response)
+			return
+		}
+	}
+	recipients, skippedOptedOut, err := services.FilterOptedOutRecipients(h.db, microappID, req.UserEmails)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to check notification preferences", "error", err, "microapp_id", microappID)
+		http.Error(w, errFailedToCheckPreferences, http.StatusInternalServerError)
+		return
+	}
+	response.SkippedOptedOut = skippedOptedOut
+	if len(recipients) == 0 {
+		slog.InfoContext(r.Context(), "All recipients opted out", "skipped", skippedOptedOut, "microapp_id", microappID)
+		response.Message = msgAllRecipientsOptedOut
+		writeJSON(w, http.StatusOK, response)
+		return
+	}
+	if req.DedupKey != "" {
+		maxAge := time.Duration(req.MaxAgeSeconds) * time.Second
+		recipients, response.SkippedDuplicates, err = h.filterDuplicateRecipients(microappID, req.DedupKey, recipients, maxAge)
+		if err != nil {
+			slog.ErrorContext(r.Context(), "Failed to check for duplicate notifications", "error", err, "microapp_id", microappID)
+			http.Error(w, errFailedToCheckDuplicates, http.StatusInternalServerError)
+			return
+		}
+		if len(recipients) == 0 {
+			slog.InfoContext(r.Context(), "All recipients deduplicated", "dedup_key", req.DedupKey, "skipped", response.SkippedDuplicates, "microapp_id", microappID)
+			response.Message = msgAllRecipientsDeduplicated
+			writeJSON(w, http.StatusOK, response)
+			return
+		}
+	}
+	if req.MinBuild > 0 {
+		recipients, response.SkippedBelowBuild, err = h.filterRecipientsByMinBuild(recipients, req.MinBuild)
+		if err != nil {
+			slog.ErrorContext(r.Context(), "Failed to check recipient app builds", "error", err, "microapp_id", microappID)
+			http.Error(w, errFailedToFetchAppBuilds, http.StatusInternalServerError)
+			return
+		}
+		if len(recipients) == 0 {
+			slog.InfoContext(r.Context(), "All recipients below minimum app build", "min_build", req.MinBuild, "skipped", response.SkippedBelowBuild, "microapp_id", microappID)
+			response.Message = msgAllRecipientsBelowMinBuild
+			writeJSON(w, http.StatusOK, response)
+			return
+		}
+	}
+	// Locales are resolved before tokens are loaded so each copy goes out as its own batch
+	copies, err := h.localizeRecipients(recipients, &req)
 	if err != nil {
-		slog.Error("Failed to send notifications", "error", err)
-		http.Error(w, errFailedToSendNotifications, http.StatusInternalServerError)
+		slog.ErrorContext(r.Context(), "Failed to fetch user locales", "error", err, "microapp_id", microappID)
+		http.Error(w, errFailedToFetchUserLocales, http.StatusInternalServerError)
 		return
 	}
-	status := statusSent
-	if failureCount > 0 {
-		status = statusPartialFailure
+	// Devices of every copy are loaded before any is sent so that a rejected request sends nothing
+	devicesByCopy := make([][]models.DeviceToken, len(copies))
+	for i, c := range copies {
+		devices, err := h.getActiveDevices(c.userEmails)
+		if err != nil {
+			slog.ErrorContext(r.Context(), "Failed to fetch device tokens", "error", err)
+			http.Error(w, errFailedToFetchDeviceTokens, http.StatusInternalServerError)
+			return
+		}
+		if rejectTokenLimit && countUniqueTokens(devices) > h.maxTokens {
+			slog.WarnContext(r.Context(), "Rejected send over the token limit", "devices", len(devices), "limit", h.maxTokens, "microapp_id", microappID)
+			http.Error(w, errTokenLimitExceeded, http.StatusBadRequest)
+			return
+		}
+		devicesByCopy[i] = devices
+	}
+	ctx := r.Context()
+	if req.TokenLimit != "" {
+		ctx = services.WithTokenLimitPolicy(ctx, services.TokenLimitPolicy(req.TokenLimit))
+	}
+	sent := false
+	for i, c := range copies {
+		devices := devicesByCopy[i]
+		if len(devices) == 0 {
+			slog.WarnContext(r.Context(), "No active device tokens found for users", "users", c.userEmails, "locale", c.locale)
+			if len(req.Localized) > 0 {
+				response.Locales = append(response.Locales, dto.LocaleSendResult{Locale: c.locale, Users: len(c.userEmails)})
+			}
+			continue
+		}
+		sent = true
+		groups := []deviceGroup{{devices: devices}}
+		if c.locale == "" {
+			// Platform overrides replace the default copy only, a localized copy takes precedence
+			groups = groupDevicesByVariant(devices, overrides)
+		}
+		var successCount, failureCount int
+		var deadTokens []string
+		var report deliveryReport
+		for _, group := range groups {
+			title, body, data := c.title, c.body, dataStr
+			if variant, ok := overrides[group.platform]; ok {
+				title, body, data = variant.message.title, variant.message.body, fcmData(variant.data)
+			}
+			success, failure, dead, groupReport, err := h.sendToDevices(ctx, group.devices, title, body, data)
+			if errors.Is(err, services.ErrTokenLimitExceeded) {
+				// A provider configured with a lower limit than the default rejected the send
+				slog.WarnContext(r.Context(), "Rejected send over the token limit", "error", err, "microapp_id", microappID)
+				http.Error(w, errTokenLimitExceeded, http.StatusBadRequest)
+				return
+			}
+			if err != nil {
+				slog.ErrorContext(r.Context(), "Failed to send notifications", "error", err, "platform", group.platform)
+				http.Error(w, errFailedToSendNotifications, http.StatusInternalServerError)
+				return
+			}
+			successCount += success
+			failureCount += failure
+			deadTokens = append(deadTokens, dead...)
+			report = report.merge(groupReport)
+		}
+		services.PruneInvalidTokens(r.Context(), h.invalidTokens, deadTokens)
+		h.metrics.RecordSent(microappID, successCount, failureCount)
+		status := statusSent
+		if failureCount > 0 {
+			status = statusPartialFailure
+		}
+		h.logNotifications(r.Context(), c.userEmails, c.title, c.body, microappID, status, req.Data, req.DedupKey, report)
+		slog.InfoContext(r.Context(), "Notifications sent", "success", successCount, "failed", failureCount, "skipped_duplicates", response.SkippedDuplicates, "locale", c.locale, "microapp_id", microappID)
+		response.Success += successCount
+		response.Failed += failureCount
+		response.Dropped += report.dropped()
+		if len(req.Localized) > 0 {
+			response.Locales = append(response.Locales, dto.LocaleSendResult{
+				Locale:  c.locale,
+				Users:   len(c.userEmails),
+				Success: successCount,
+				Failed:  failureCount,
+			})
+		}
+		if req.Receipt {
+			response.Receipts = append(response.Receipts, h.issueReceipts(r.Context(), c.userEmails, c.title, c.body, dataStr, microappID, status)...)
+		}
+	}
+	if !sent {
+		response.Message = msgNoActiveDeviceTokensFound
 	}
-	h.logNotifications(req.UserEmails, req.Title, req.Body, microappID, status, req.Data)
-	slog.Info("Notifications sent", "success", successCount, "failed", failureCount, "microapp_id", microappID)
-	response := dto.NotificationResponse{Success: successCount, Failed: failureCount, Message: msgNotificationsSentSuccessfully}
 	writeJSON(w, http.StatusOK, response)
 }
 
-// helper functions
+// localizedCopy is the title and body sent to the recipients whose preferred locale it matched
+type localizedCopy struct {
+	locale     string
+	title      string
+	body       string
+	userEmails []string
+}
 
-func (h *NotificationHandler) getClientID(r *http.Request) (string, error) {
-	serviceInfo, ok := auth.GetServiceInfo(r.Context())
-	if !ok {
-		return "", errors.New(errServiceInfoNotFound)
+// localizeRecipients groups recipients by the localized copy that best matches their stored
+// locale. Recipients without a match, and all recipients of an unlocalized request, get the
+// default copy, which is always listed first. Other copies are ordered by locale.
+func (h *NotificationHandler) localizeRecipients(recipients []string, req *dto.SendNotificationRequest) ([]localizedCopy, error) {
+	if len(req.Localized) == 0 {
+		return []localizedCopy{{title: req.Title, body: req.Body, userEmails: recipients}}, nil
 	}
-	if serviceInfo.ClientID == "" {
-		slog.Warn("Client ID is empty in service info")
-		return "", errors.New(errClientIDEmpty)
+	userLocales, err := services.UserLocales(h.db, recipients)
+	if err != nil {
+		return nil, err
 	}
-	return serviceInfo.ClientID, nil
+	available := make([]string, 0, len(req.Localized))
+	for locale := range req.Localized {
+		available = append(available, locale)
+	}
+	sort.Strings(available)
+
+	byLocale := make(map[string][]string)
+	for _, email := range recipients {
+		locale := services.MatchLocale(userLocales[email], available)
+		byLocale[locale] = append(byLocale[locale], email)
+	}
+	copies := make([]localizedCopy, 0, len(byLocale))
+	if emails, ok := byLocale[""]; ok {
+		copies = append(copies, localizedCopy{title: req.Title, body: req.Body, userEmails: emails})
+	}
+	for _, locale := range available {
+		emails, ok := byLocale[locale]
+		if !ok {
+			continue
+		}
+		content := req.Localized[locale]
+		copies = append(copies, localizedCopy{locale: locale, title: content.Title, body: content.Body, userEmails: emails})
+	}
+	return copies, nil
 }
 
-func (h *NotificationHandler) prepareFCMData(data map[string]interface{}, microappID string) map[string]string {
-	// Converts the given data map to a map of string to string, marshalling non-string values to JSON strings.
-	// Also adds the microappID to the data if it's not empty.
-	dataStr := make(map[string]string)
-	for k, v := range data {
-		if str, ok := v.(string); ok {
-			dataStr[k] = str
+// sendToTopics sends the notification to each of the microapp's topics. A failed topic is logged
+// and reported in its result rather than failing the request, so user delivery still goes ahead.
+func (h *NotificationHandler) sendToTopics(ctx context.Context, microappID string, topics []string, title, body string, data map[string]string) ([]dto.TopicSendResult, int, int) {
+	results := make([]dto.TopicSendResult, 0, len(topics))
+	success, failed := 0, 0
+	seen := make(map[string]struct{}, len(topics))
+	for _, topic := range topics {
+		// FCM topic names are case-sensitive, so only exact repeats are skipped
+		if _, ok := seen[topic]; ok {
+			continue
+		}
+		seen[topic] = struct{}{}
+		result := dto.TopicSendResult{Topic: topic}
+		messageID, err := h.fcmService.SendToTopic(ctx, microappTopic(microappID, topic), title, body, data)
+		if err != nil {
+			slog.WarnContext(ctx, "Failed to send topic notification", "error", err, "microapp_id", microappID, "topic", topic)
+			result.Error = errFailedToSendTopicNotification
+			failed++
 		} else {
-			if bytes, err := json.Marshal(v); err == nil {
-				dataStr[k] = string(bytes)
-			}
+			slog.InfoContext(ctx, "Topic notification sent", "microapp_id", microappID, "topic", topic, "message_id", messageID)
+			result.MessageID = messageID
+			success++
 		}
+		results = append(results, result)
 	}
-	if microappID != "" {
-		dataStr[dataKeyMicroappID] = microappID
-	}
-	return dataStr
+	return results, success, failed
 }
 
-func (h *NotificationHandler) logNotifications(userEmails []string, title, body, microappID, status string, data map[string]interface{}) {
-	for _, email := range userEmails {
-		log := models.NotificationLog{
-			UserEmail:  email,
-			Title:      &title,
-			Body:       &body,
-			Data:       data,
-			Status:     &status,
-			MicroappID: &microappID,
+// GetNotificationReceipt returns a receipt issued to the calling microapp and whether its signature is still valid.
+func (h *NotificationHandler) GetNotificationReceipt(w http.ResponseWriter, r *http.Request) {
+	if h.receiptSigner == nil {
+		http.Error(w, errReceiptsNotConfigured, http.StatusServiceUnavailable)
+		return
+	}
+	receiptID, err := strconv.ParseInt(chi.URLParam(r, urlParamReceiptID), 10, 64)
+	if err != nil {
+		http.Error(w, errInvalidReceiptID, http.StatusBadRequest)
+		return
+	}
+	microappID, err := h.getClientID(r)
+	if err != nil {
+		slog.ErrorContext(r.Context(), errClientIDInvalid, "error", err)
+		http.Error(w, errClientIDInvalid, http.StatusUnauthorized)
+		return
+	}
+	var receipt models.NotificationReceipt
+	if err := h.db.Where("id = ? AND microapp_id = ?", receiptID, microappID).First(&receipt).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			http.Error(w, errReceiptNotFound, http.StatusNotFound)
+			return
 		}
-		if err := h.db.Create(&log).Error; err != nil {
-			slog.Error("Failed to log notification", "error", err, "email", email)
+		slog.ErrorContext(r.Context(), "Failed to fetch notification receipt", "error", err, "id", receiptID)
+		http.Error(w, errFailedToFetchReceipt, http.StatusInternalServerError)
+		return
+	}
+	verified := receipt.KeyID == h.receiptSigner.KeyID() &&
+		h.receiptSigner.Verify(receiptContent(receipt), receipt.Signature)
+	if !verified {
+		slog.WarnContext(r.Context(), "Notification receipt failed verification", "id", receipt.ID, "key_id", receipt.KeyID)
+	}
+	writeJSON(w, http.StatusOK, dto.NotificationReceiptResponse{
+		ID:          receipt.ID,
+		MicroappID:  receipt.MicroappID,
+		Recipient:   receipt.Recipient,
+		ContentHash: receipt.ContentHash,
+		Status:      receipt.Status,
+		IssuedAt:    receipt.IssuedAt,
+		KeyID:       receipt.KeyID,
+		Signature:   receipt.Signature,
+		Verified:    verified,
+	})
+}
+
+// SendToGroups sends a notification to every member of the given groups.
+// Users in more than one group are notified once, under the first group that contains them.
+func (h *NotificationHandler) SendToGroups(w http.ResponseWriter, r *http.Request) {
+	if h.fcmService == nil {
+		http.Error(w, errNotificationServiceNotAvailable, http.StatusServiceUnavailable)
+		return
+	}
+	if !validateContentType(w, r) {
+		return
+	}
+	limitRequestBody(w, r, 0)
+	var req dto.SendToGroupsRequest
+	if !decodeJSONBody(w, r, &req) {
+		return
+	}
+	if !validateStruct(w, &req) {
+		return
+	}
+	microappID, err := h.getClientID(r)
+	if err != nil {
+		slog.ErrorContext(r.Context(), errClientIDInvalid, "error", err)
+		http.Error(w, errClientIDInvalid, http.StatusUnauthorized)
+		return
+	}
+	audiences, err := h.resolveGroupAudiences(req.Groups)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to resolve group members", "error", err)
+		http.Error(w, errFailedToResolveGroups, http.StatusInternalServerError)
+		return
+	}
+	audienceSize := 0
+	for _, audience := range audiences {
+		audienceSize += len(audience.userEmails)
+	}
+	if !h.chargeQuota(w, r, microappID, audienceSize) {
+		return
+	}
+	dataStr := h.prepareFCMData(req.Data, microappID)
+	response := dto.SendToGroupsResponse{Groups: []dto.GroupNotificationResult{}}
+	for _, audience := range audiences {
+		group, userEmails := audience.group, audience.userEmails
+		result := dto.GroupNotificationResult{Group: group, Users: len(userEmails)}
+		userEmails, result.SkippedOptedOut, err = services.FilterOptedOutRecipients(h.db, microappID, userEmails)
+		if err != nil {
+			slog.ErrorContext(r.Context(), "Failed to check notification preferences", "error", err, "group", group)
+			http.Error(w, errFailedToCheckPreferences, http.StatusInternalServerError)
+			return
+		}
+		if len(userEmails) == 0 {
+			slog.InfoContext(r.Context(), "Skipping group with no users", "group", group, "microapp_id", microappID)
+			response.Groups = append(response.Groups, result)
+			continue
+		}
+		devices, err := h.getActiveDevices(userEmails)
+		if err != nil {
+			slog.ErrorContext(r.Context(), "Failed to fetch device tokens", "error", err, "group", group)
+			http.Error(w, errFailedToFetchDeviceTokens, http.StatusInternalServerError)
+			return
+		}
+		if len(devices) > 0 {
+			var deadTokens []string
+			var report deliveryReport
+			result.Success, result.Failed, deadTokens, report, err = h.sendToDevices(r.Context(), devices, req.Title, req.Body, dataStr)
+			if err != nil {
+				slog.ErrorContext(r.Context(), "Failed to send group notifications", "error", err, "group", group)
+				http.Error(w, errFailedToSendNotifications, http.StatusInternalServerError)
+				return
+			}
+			services.PruneInvalidTokens(r.Context(), h.invalidTokens, deadTokens)
+			h.metrics.RecordSent(microappID, result.Success, result.Failed)
+			status := statusSent
+			if result.Failed > 0 {
+				status = statusPartialFailure
+			}
+			h.logNotifications(r.Context(), userEmails, req.Title, req.Body, microappID, status, req.Data, "", report)
+		}
+		response.Success += result.Success
+		response.Failed += result.Failed
+		response.Groups = append(response.Groups, result)
+	}
+	slog.InfoContext(r.Context(), "Group notifications sent", "groups", req.Groups, "success", response.Success, "failed", response.Failed, "microapp_id", microappID)
+	response.Message = msgNotificationsSentSuccessfully
+	writeJSON(w, http.StatusOK, response)
+}
+
+// PreviewSendToGroups reports how many users and active devices a SendToGroups request with the
+// same groups would reach, without sending anything. Users are deduplicated across groups and
+// users who opted out are skipped exactly as SendToGroups does, and devices are counted by unique
+// token as they are sent. Warnings flag groups that would be truncated or reach no device.
+func (h *NotificationHandler) PreviewSendToGroups(w http.ResponseWriter, r *http.Request) {
+	if !validateContentType(w, r) {
+		return
+	}
+	limitRequestBody(w, r, 0)
+	var req dto.PreviewSendToGroupsRequest
+	if !decodeJSONBody(w, r, &req) {
+		return
+	}
+	if !validateStruct(w, &req) {
+		return
+	}
+	microappID, err := h.getClientID(r)
+	if err != nil {
+		slog.ErrorContext(r.Context(), errClientIDInvalid, "error", err)
+		http.Error(w, errClientIDInvalid, http.StatusUnauthorized)
+		return
+	}
+	audiences, err := h.resolveGroupAudiences(req.Groups)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to resolve group members", "error", err)
+		http.Error(w, errFailedToResolveGroups, http.StatusInternalServerError)
+		return
+	}
+
+	response := dto.PreviewSendToGroupsResponse{Groups: []dto.GroupAudiencePreview{}, Warnings: []string{}}
+	for _, audience := range audiences {
+		preview := dto.GroupAudiencePreview{Group: audience.group}
+		userEmails, skipped, err := services.FilterOptedOutRecipients(h.db, microappID, audience.userEmails)
+		if err != nil {
+			slog.ErrorContext(r.Context(), "Failed to check notification preferences", "error", err, "group", audience.group)
+			http.Error(w, errFailedToCheckPreferences, http.StatusInternalServerError)
+			return
+		}
+		preview.Users, preview.SkippedOptedOut = len(userEmails), skipped
+		if len(userEmails) > 0 {
+			var devices int64
+			if err := h.db.Model(&models.DeviceToken{}).
+				Where("user_email IN ? AND is_active = ?", userEmails, true).
+				Distinct("device_token").
+				Count(&devices).Error; err != nil {
+				slog.ErrorContext(r.Context(), "Failed to count device tokens", "error", err, "group", audience.group)
+				http.Error(w, errFailedToFetchDeviceTokens, http.StatusInternalServerError)
+				return
+			}
+			preview.Devices = int(devices)
+		}
+		switch {
+		case preview.Devices > h.maxTokens:
+			response.Warnings = append(response.Warnings, fmt.Sprintf(warnGroupTruncated,
+				audience.group, preview.Devices, h.maxTokens, preview.Devices-h.maxTokens))
+		case preview.Users > 0 && preview.Devices == 0:
+			response.Warnings = append(response.Warnings, fmt.Sprintf(warnGroupHasNoDevices, audience.group))
+		}
+		response.Users += preview.Users
+		response.SkippedOptedOut += preview.SkippedOptedOut
+		response.Devices += preview.Devices
+		response.Groups = append(response.Groups, preview)
+	}
+	writeJSON(w, http.StatusOK, response)
+}
+
+// groupAudience is the users a group send reaches through one group.
+type groupAudience struct {
+	group      string
+	userEmails []string
+}
+
+// resolveGroupAudiences resolves each unique group to its members. A user in more than one
+// group is counted once, under the first group that contains them.
+func (h *NotificationHandler) resolveGroupAudiences(groups []string) ([]groupAudience, error) {
+	var audiences []groupAudience
+	seen := make(map[string]struct{})
+	for _, group := range uniqueGroups(groups) {
+		var members []string
+		if err := h.db.Model(&models.UserGroup{}).
+			Where("group_name = ?", group).
+			Pluck("user_email", &members).Error; err != nil {
+			return nil, fmt.Errorf("failed to resolve members of group %s: %w", group, err)
+		}
+		userEmails := make([]string, 0, len(members))
+		for _, email := range members {
+			if _, ok := seen[email]; ok {
+				continue
+			}
+			seen[email] = struct{}{}
+			userEmails = append(userEmails, email)
+		}
+		audiences = append(audiences, groupAudience{group: group, userEmails: userEmails})
+	}
+	return audiences, nil
+}
+
+// SubscribeToTopic subscribes the active devices of the given users to a microapp topic.
+func (h *NotificationHandler) SubscribeToTopic(w http.ResponseWriter, r *http.Request) {
+	h.updateTopicSubscription(w, r, true)
+}
+
+// UnsubscribeFromTopic removes the active devices of the given users from a microapp topic.
+func (h *NotificationHandler) UnsubscribeFromTopic(w http.ResponseWriter, r *http.Request) {
+	h.updateTopicSubscription(w, r, false)
+}
+
+// SendToTopic sends a single notification to all devices subscribed to a microapp topic.
+func (h *NotificationHandler) SendToTopic(w http.ResponseWriter, r *http.Request) {
+	if h.fcmService == nil {
+		http.Error(w, errNotificationServiceNotAvailable, http.StatusServiceUnavailable)
+		return
+	}
+	if !validateContentType(w, r) {
+		return
+	}
+	limitRequestBody(w, r, 0)
+	var req dto.SendTopicNotificationRequest
+	if !decodeJSONBody(w, r, &req) {
+		return
+	}
+	if !validateStruct(w, &req) {
+		return
+	}
+	if !topicNamePattern.MatchString(req.Topic) {
+		http.Error(w, errInvalidTopicName, http.StatusBadRequest)
+		return
+	}
+	microappID, err := h.getClientID(r)
+	if err != nil {
+		slog.ErrorContext(r.Context(), errClientIDInvalid, "error", err)
+		http.Error(w, errClientIDInvalid, http.StatusUnauthorized)
+		return
+	}
+	if !h.chargeQuota(w, r, microappID, 1) {
+		return
+	}
+	dataStr := h.prepareFCMData(req.Data, microappID)
+	messageID, err := h.fcmService.SendToTopic(r.Context(), microappTopic(microappID, req.Topic), req.Title, req.Body, dataStr)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to send topic notification", "error", err, "microapp_id", microappID, "topic", req.Topic)
+		http.Error(w, errFailedToSendTopicNotification, http.StatusInternalServerError)
+		return
+	}
+	slog.InfoContext(r.Context(), "Topic notification sent", "microapp_id", microappID, "topic", req.Topic, "message_id", messageID)
+	writeJSON(w, http.StatusOK, dto.TopicNotificationResponse{MessageID: messageID, Message: msgTopicNotificationSent})
+}
+
+// ScheduleNotification stores a notification to be dispatched by the scheduled notification worker at sendAt.
+func (h *NotificationHandler) ScheduleNotification(w http.ResponseWriter, r *http.Request) {
+	if h.fcmService == nil {
+		http.Error(w, errNotificationServiceNotAvailable, http.StatusServiceUnavailable)
+		return
+	}
+	if !validateContentType(w, r) {
+		return
+	}
+	limitRequestBody(w, r, 0)
+	var req dto.ScheduleNotificationRequest
+	if !decodeJSONBody(w, r, &req) {
+		return
+	}
+	if !validateStruct(w, &req) {
+		return
+	}
+	if !req.SendAt.After(time.Now()) {
+		http.Error(w, errSendAtMustBeInFuture, http.StatusBadRequest)
+		return
+	}
+	microappID, err := h.getClientID(r)
+	if err != nil {
+		slog.ErrorContext(r.Context(), errClientIDInvalid, "error", err)
+		http.Error(w, errClientIDInvalid, http.StatusUnauthorized)
+		return
+	}
+	if !h.chargeQuota(w, r, microappID, len(req.UserEmails)) {
+		return
+	}
+	h.storeScheduledNotification(w, r, microappID, req.UserEmails, req.Title, req.Body, h.prepareFCMData(req.Data, microappID), req.SendAt)
+}
+
+// storeScheduledNotification persists a pending notification for the scheduled notification worker
+// and writes the created schedule.
+func (h *NotificationHandler) storeScheduledNotification(w http.ResponseWriter, r *http.Request, microappID string, userEmails []string, title, body string, dataStr map[string]string, sendAt time.Time) {
+	data := models.JSONMap{}
+	for k, v := range dataStr {
+		data[k] = v
+	}
+	scheduled := models.ScheduledNotification{
+		MicroappID: microappID,
+		UserEmails: userEmails,
+		Title:      title,
+		Body:       body,
+		Data:       data,
+		SendAt:     sendAt.UTC(),
+		Status:     models.ScheduledStatusPending,
+	}
+	if err := h.db.Create(&scheduled).Error; err != nil {
+		slog.ErrorContext(r.Context(), "Failed to schedule notification", "error", err, "microapp_id", microappID)
+		http.Error(w, errFailedToScheduleNotification, http.StatusInternalServerError)
+		return
+	}
+	slog.InfoContext(r.Context(), "Notification scheduled", "id", scheduled.ID, "microapp_id", microappID, "send_at", scheduled.SendAt)
+	writeJSON(w, http.StatusCreated, dto.ScheduledNotificationResponse{
+		ID:     scheduled.ID,
+		SendAt: scheduled.SendAt,
+		Status: scheduled.Status,
+	})
+}
+
+// CancelScheduledNotification deletes a pending scheduled notification owned by the calling microapp.
+func (h *NotificationHandler) CancelScheduledNotification(w http.ResponseWriter, r *http.Request) {
+	scheduleID, err := strconv.ParseInt(chi.URLParam(r, urlParamScheduleID), 10, 64)
+	if err != nil {
+		http.Error(w, errInvalidScheduleID, http.StatusBadRequest)
+		return
+	}
+	microappID, err := h.getClientID(r)
+	if err != nil {
+		slog.ErrorContext(r.Context(), errClientIDInvalid, "error", err)
+		http.Error(w, errClientIDInvalid, http.StatusUnauthorized)
+		return
+	}
+	var scheduled models.ScheduledNotification
+	if err := h.db.Where("id = ? AND microapp_id = ?", scheduleID, microappID).First(&scheduled).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			http.Error(w, errScheduledNotificationNotFound, http.StatusNotFound)
+			return
+		}
+		slog.ErrorContext(r.Context(), "Failed to fetch scheduled notification", "error", err, "id", scheduleID)
+		http.Error(w, errFailedToCancelNotification, http.StatusInternalServerError)
+		return
+	}
+	// Only delete while still pending so a row claimed by a worker is never removed mid-dispatch
+	result := h.db.Where("id = ? AND status = ?", scheduleID, models.ScheduledStatusPending).
+		Delete(&models.ScheduledNotification{})
+	if result.Error != nil {
+		slog.ErrorContext(r.Context(), "Failed to cancel scheduled notification", "error", result.Error, "id", scheduleID)
+		http.Error(w, errFailedToCancelNotification, http.StatusInternalServerError)
+		return
+	}
+	if result.RowsAffected == 0 {
+		http.Error(w, errScheduledNotificationNotPending, http.StatusConflict)
+		return
+	}
+	slog.InfoContext(r.Context(), "Scheduled notification cancelled", "id", scheduleID, "microapp_id", microappID)
+	writeJSON(w, http.StatusOK, map[string]string{"message": msgScheduledNotificationCancelled})
+}
+
+// helper functions
+
+func (h *NotificationHandler) updateTopicSubscription(w http.ResponseWriter, r *http.Request, subscribe bool) {
+	if h.fcmService == nil {
+		http.Error(w, errNotificationServiceNotAvailable, http.StatusServiceUnavailable)
+		return
+	}
+	if !validateContentType(w, r) {
+		return
+	}
+	limitRequestBody(w, r, 0)
+	var req dto.TopicSubscriptionRequest
+	if !decodeJSONBody(w, r, &req) {
+		return
+	}
+	if !validateStruct(w, &req) {
+		return
+	}
+	if !topicNamePattern.MatchString(req.Topic) {
+		http.Error(w, errInvalidTopicName, http.StatusBadRequest)
+		return
+	}
+	microappID, err := h.getClientID(r)
+	if err != nil {
+		slog.ErrorContext(r.Context(), errClientIDInvalid, "error", err)
+		http.Error(w, errClientIDInvalid, http.StatusUnauthorized)
+		return
+	}
+	tokens, err := h.getActiveDeviceTokens(req.UserEmails)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to fetch device tokens", "error", err)
+		http.Error(w, errFailedToFetchDeviceTokens, http.StatusInternalServerError)
+		return
+	}
+	if len(tokens) == 0 {
+		slog.WarnContext(r.Context(), "No active device tokens found for users", "users", req.UserEmails)
+		writeJSON(w, http.StatusOK, dto.TopicSubscriptionResponse{Success: 0, Failed: 0, Message: msgNoActiveDeviceTokensFound})
+		return
+	}
+	topic := microappTopic(microappID, req.Topic)
+	var successCount, failureCount int
+	if subscribe {
+		successCount, failureCount, err = h.fcmService.SubscribeToTopic(r.Context(), tokens, topic)
+		if err != nil {
+			slog.ErrorContext(r.Context(), "Failed to subscribe to topic", "error", err, "microapp_id", microappID, "topic", req.Topic)
+			http.Error(w, errFailedToSubscribeToTopic, http.StatusInternalServerError)
+			return
+		}
+	} else {
+		successCount, failureCount, err = h.fcmService.UnsubscribeFromTopic(r.Context(), tokens, topic)
+		if err != nil {
+			slog.ErrorContext(r.Context(), "Failed to unsubscribe from topic", "error", err, "microapp_id", microappID, "topic", req.Topic)
+			http.Error(w, errFailedToUnsubscribeFromTopic, http.StatusInternalServerError)
+			return
+		}
+	}
+	slog.InfoContext(r.Context(), "Topic subscriptions updated", "subscribe", subscribe, "microapp_id", microappID, "topic", req.Topic, "success", successCount, "failed", failureCount)
+	writeJSON(w, http.StatusOK, dto.TopicSubscriptionResponse{Success: successCount, Failed: failureCount, Message: msgTopicSubscriptionUpdated})
+}
+
+// issueReceipts signs and stores a receipt for each recipient of a sent notification.
+// Failures are logged and skipped since the notification has already been sent.
+func (h *NotificationHandler) issueReceipts(ctx context.Context, userEmails []string, title, body string, data map[string]string, microappID, status string) []dto.NotificationReceiptSummary {
+	contentHash, err := services.HashNotificationContent(title, body, data)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to hash notification content", "error", err, "microapp_id", microappID)
+		return nil
+	}
+	issuedAt := time.Now().UTC().Truncate(time.Second)
+	receipts := make([]dto.NotificationReceiptSummary, 0, len(userEmails))
+	for _, email := range userEmails {
+		receipt := models.NotificationReceipt{
+			MicroappID:  microappID,
+			Recipient:   email,
+			ContentHash: contentHash,
+			Status:      status,
+			IssuedAt:    issuedAt,
+			KeyID:       h.receiptSigner.KeyID(),
+		}
+		signature, err := h.receiptSigner.Sign(receiptContent(receipt))
+		if err != nil {
+			slog.ErrorContext(ctx, "Failed to sign notification receipt", "error", err, "email", email)
+			continue
+		}
+		receipt.Signature = signature
+		if err := h.db.Create(&receipt).Error; err != nil {
+			slog.ErrorContext(ctx, "Failed to store notification receipt", "error", err, "email", email)
+			continue
+		}
+		receipts = append(receipts, dto.NotificationReceiptSummary{ID: receipt.ID, Recipient: email})
+	}
+	return receipts
+}
+
+// receiptContent returns the signed fields of a stored receipt.
+func receiptContent(receipt models.NotificationReceipt) services.ReceiptContent {
+	return services.ReceiptContent{
+		MicroappID:  receipt.MicroappID,
+		Recipient:   receipt.Recipient,
+		ContentHash: receipt.ContentHash,
+		Status:      receipt.Status,
+		IssuedAt:    receipt.IssuedAt,
+	}
+}
+
+// chargeQuota charges count sends to the microapp's notification quota. When the quota is
+// exhausted it responds 429 with a Retry-After of the seconds left in the period and returns false.
+func (h *NotificationHandler) chargeQuota(w http.ResponseWriter, r *http.Request, microappID string, count int) bool {
+	if h.quota == nil {
+		return true
+	}
+	err := h.quota.CheckAndIncrement(r.Context(), microappID, count)
+	if err == nil {
+		return true
+	}
+	var exceeded *services.QuotaExceededError
+	if errors.As(err, &exceeded) {
+		retryAfter := int(math.Ceil(exceeded.RetryAfter.Seconds()))
+		if retryAfter < 1 {
+			retryAfter = 1
+		}
+		slog.WarnContext(r.Context(), "Notification quota exceeded", "microapp_id", microappID, "limit", exceeded.Limit, "requested", count)
+		w.Header().Set(headerRetryAfter, strconv.Itoa(retryAfter))
+		http.Error(w, errNotificationQuotaExceeded, http.StatusTooManyRequests)
+		return false
+	}
+	slog.ErrorContext(r.Context(), "Failed to check notification quota", "error", err, "microapp_id", microappID)
+	http.Error(w, errFailedToCheckQuota, http.StatusInternalServerError)
+	return false
+}
+
+// coalesce merges the send into the microapp's pending coalesced notification and writes it, when
+// the microapp has a coalescing window. It reports whether the send was coalesced, and false for
+// ok when it wrote an error.
+func (h *NotificationHandler) coalesce(w http.ResponseWriter, r *http.Request, microappID string, userEmails []string, title, body string, data map[string]string) (coalesced, ok bool) {
+	config, err := h.coalescer.ConfigFor(r.Context(), microappID)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to fetch notification coalescing config", "error", err, "microapp_id", microappID)
+		http.Error(w, errFailedToCoalesceNotification, http.StatusInternalServerError)
+		return false, false
+	}
+	if config == nil {
+		return false, true
+	}
+	n, err := h.coalescer.Coalesce(r.Context(), microappID, userEmails, title, body, data, *config)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to coalesce notification", "error", err, "microapp_id", microappID)
+		http.Error(w, errFailedToCoalesceNotification, http.StatusInternalServerError)
+		return false, false
+	}
+	slog.InfoContext(r.Context(), "Notification coalesced", "id", n.ID, "microapp_id", microappID, "send_at", n.SendAt, "coalesced_sends", n.CoalescedCount)
+	writeJSON(w, http.StatusAccepted, dto.ScheduledNotificationResponse{
+		ID:             n.ID,
+		SendAt:         n.SendAt,
+		Status:         models.ScheduledStatusPending,
+		CoalescedSends: n.CoalescedCount,
+	})
+	return true, true
+}
+
+// checkImageURL reports whether the microapp may attach the image, writing the error response when it may not
+func (h *NotificationHandler) checkImageURL(w http.ResponseWriter, r *http.Request, microappID, imageURL string) bool {
+	if h.imagePolicy == nil {
+		return true
+	}
+	allowed, err := h.imagePolicy.Allowed(microappID, imageURL)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to check notification image", "error", err, "microapp_id", microappID)
+		http.Error(w, errFailedToCheckImageURL, http.StatusInternalServerError)
+		return false
+	}
+	if !allowed {
+		slog.WarnContext(r.Context(), "Rejected notification image", "image_url", imageURL, "microapp_id", microappID)
+		http.Error(w, errImageURLNotAllowed, http.StatusBadRequest)
+		return false
+	}
+	return true
+}
+
+// filterRecipientsByMinBuild drops recipients whose most recently registered active device
+// reports an app build below minBuild, or no build at all. It returns the remaining
+// recipients and how many were skipped.
+func (h *NotificationHandler) filterRecipientsByMinBuild(userEmails []string, minBuild int) ([]string, int, error) {
+	devices, err := h.getActiveDevices(userEmails)
+	if err != nil {
+		return nil, 0, err
+	}
+	latest := make(map[string]models.DeviceToken, len(userEmails))
+	for _, device := range devices {
+		current, ok := latest[device.UserEmail]
+		if !ok || device.UpdatedAt.After(current.UpdatedAt) ||
+			(device.UpdatedAt.Equal(current.UpdatedAt) && device.ID > current.ID) {
+			latest[device.UserEmail] = device
+		}
+	}
+	remaining := make([]string, 0, len(userEmails))
+	skipped := 0
+	for _, email := range userEmails {
+		device, ok := latest[email]
+		if ok && (device.AppBuild == nil || *device.AppBuild < minBuild) {
+			skipped++
+			continue
+		}
+		// Users without an active device are kept so they are reported like any other send
+		remaining = append(remaining, email)
+	}
+	return remaining, skipped, nil
+}
+
+// filterDuplicateRecipients drops recipients the microapp already sent dedupKey to within maxAge.
+// Only sends that reached one of the recipient's devices count, so a retry after a failed send
+// still reaches them. It returns the remaining recipients and how many were skipped.
+func (h *NotificationHandler) filterDuplicateRecipients(microappID, dedupKey string, userEmails []string, maxAge time.Duration) ([]string, int, error) {
+	cutoff := time.Now().Add(-maxAge)
+	var notified []string
+	if err := h.db.Model(&models.NotificationLog{}).
+		Distinct("user_email").
+		Where("dedup_key = ? AND microapp_id = ? AND user_email IN ? AND sent_at >= ?", dedupKey, microappID, userEmails, cutoff).
+		Where("status IN ? AND delivery_status <> ?", []string{statusSent, statusPartialFailure}, models.DeliveryStatusFailed).
+		Pluck("user_email", &notified).Error; err != nil {
+		return nil, 0, err
+	}
+	notifiedSet := make(map[string]struct{}, len(notified))
+	for _, email := range notified {
+		notifiedSet[email] = struct{}{}
+	}
+	remaining := make([]string, 0, len(userEmails))
+	skipped := 0
+	for _, email := range userEmails {
+		if _, ok := notifiedSet[email]; ok {
+			skipped++
+			continue
+		}
+		remaining = append(remaining, email)
+	}
+	return remaining, skipped, nil
+}
+
+// syncUserGroups replaces the stored group memberships of a user with the groups from their token.
+func (h *NotificationHandler) syncUserGroups(email string, groups []string) error {
+	return h.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_email = ?", email).Delete(&models.UserGroup{}).Error; err != nil {
+			return err
+		}
+		groups = uniqueGroups(groups)
+		if len(groups) == 0 {
+			return nil
+		}
+		memberships := make([]models.UserGroup, len(groups))
+		for i, group := range groups {
+			memberships[i] = models.UserGroup{UserEmail: email, GroupName: group}
+		}
+		return tx.Create(&memberships).Error
+	})
+}
+
+// revokeUserDevices deactivates a user's devices, removes them from pending scheduled notifications
+// and drops their group memberships, in one transaction, and writes the counts. Each call is logged
+// with the acting admin and client IP.
+func (h *NotificationHandler) revokeUserDevices(w http.ResponseWriter, r *http.Request, actor, email string) {
+	resp := dto.RevokeUserDevicesResponse{Email: email}
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.DeviceToken{}).
+			Where("user_email = ? AND is_active = ?", email, true).
+			Update("is_active", false)
+		if result.Error != nil {
+			return result.Error
+		}
+		resp.DevicesDeactivated = result.RowsAffected
+
+		updated, cancelled, err := removeFromPendingSchedules(tx, email)
+		if err != nil {
+			return err
+		}
+		resp.ScheduledNotificationsUpdated = updated
+		resp.ScheduledNotificationsCancelled = cancelled
+
+		// Group memberships are re-synced from the token claims on the next device registration
+		result = tx.Where("user_email = ?", email).Delete(&models.UserGroup{})
+		if result.Error != nil {
+			return result.Error
+		}
+		resp.GroupMembershipsRemoved = result.RowsAffected
+		return nil
+	})
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to revoke user devices", "error", err, "email", email, "actor", actor)
+		http.Error(w, errFailedToRevokeUserDevices, http.StatusInternalServerError)
+		return
+	}
+
+	slog.InfoContext(r.Context(), "User devices revoked",
+		"email", email,
+		"actor", actor,
+		"client_ip", auth.ClientIP(r),
+		"devices_deactivated", resp.DevicesDeactivated,
+		"scheduled_updated", resp.ScheduledNotificationsUpdated,
+		"scheduled_cancelled", resp.ScheduledNotificationsCancelled,
+		"groups_removed", resp.GroupMembershipsRemoved)
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// removeFromPendingSchedules drops email from the recipients of every pending scheduled notification.
+// Notifications left without recipients are deleted. Rows already claimed by a worker are left alone;
+// they resolve device tokens at dispatch time, so the deactivated tokens are skipped anyway.
+func removeFromPendingSchedules(tx *gorm.DB, email string) (updated, cancelled int64, err error) {
+	var pending []models.ScheduledNotification
+	if err := tx.Where("status = ? AND user_emails LIKE ?", models.ScheduledStatusPending, "%\""+email+"\"%").
+		Find(&pending).Error; err != nil {
+		return 0, 0, err
+	}
+	for _, scheduled := range pending {
+		remaining := make(models.JSONStringSlice, 0, len(scheduled.UserEmails))
+		for _, recipient := range scheduled.UserEmails {
+			if recipient != email {
+				remaining = append(remaining, recipient)
+			}
+		}
+		if len(remaining) == len(scheduled.UserEmails) {
+			continue
+		}
+		query := tx.Where("id = ? AND status = ?", scheduled.ID, models.ScheduledStatusPending)
+		if len(remaining) == 0 {
+			result := query.Delete(&models.ScheduledNotification{})
+			if result.Error != nil {
+				return 0, 0, result.Error
+			}
+			cancelled += result.RowsAffected
+			continue
+		}
+		result := query.Model(&models.ScheduledNotification{}).Update("user_emails", remaining)
+		if result.Error != nil {
+			return 0, 0, result.Error
+		}
+		updated += result.RowsAffected
+	}
+	return updated, cancelled, nil
+}
+
+// normalizePlatform trims and lowercases a client-reported platform, so that "iOS " matches the
+// ios enum value. An unsupported platform is rejected with 400; an empty one is left to validation.
+func normalizePlatform(w http.ResponseWriter, platform *string) bool {
+	*platform = strings.ToLower(strings.TrimSpace(*platform))
+	if *platform != "" && !slices.Contains(supportedPlatforms, *platform) {
+		http.Error(w, errUnsupportedPlatform, http.StatusBadRequest)
+		return false
+	}
+	return true
+}
+
+// uniqueGroups trims group names and drops empty and duplicate entries, preserving order.
+func uniqueGroups(groups []string) []string {
+	seen := make(map[string]struct{}, len(groups))
+	result := make([]string, 0, len(groups))
+	for _, group := range groups {
+		group = strings.TrimSpace(group)
+		key := strings.ToLower(group)
+		if _, ok := seen[key]; ok || group == "" {
+			continue
+		}
+		seen[key] = struct{}{}
+		result = append(result, group)
+	}
+	return result
+}
+
+// getActiveDevices returns the active devices, with their platforms, registered for the given users.
+func (h *NotificationHandler) getActiveDevices(userEmails []string) ([]models.DeviceToken, error) {
+	var deviceTokens []models.DeviceToken
+	if err := h.db.Where("user_email IN ? AND is_active = ?", userEmails, true).Find(&deviceTokens).Error; err != nil {
+		return nil, err
+	}
+	return deviceTokens, nil
+}
+
+// countUniqueTokens returns the number of distinct device tokens, which is what the send limit applies to.
+func countUniqueTokens(devices []models.DeviceToken) int {
+	tokens := make(map[string]struct{}, len(devices))
+	for _, device := range devices {
+		tokens[device.DeviceToken] = struct{}{}
+	}
+	return len(tokens)
+}
+
+// getActiveDeviceTokens returns the active device tokens registered for the given users.
+func (h *NotificationHandler) getActiveDeviceTokens(userEmails []string) ([]string, error) {
+	deviceTokens, err := h.getActiveDevices(userEmails)
+	if err != nil {
+		return nil, err
+	}
+	tokens := make([]string, len(deviceTokens))
+	for i, dt := range deviceTokens {
+		tokens[i] = dt.DeviceToken
+	}
+	return tokens, nil
+}
+
+// microappTopic namespaces a topic with the microapp ID so microapps cannot
+// send to or manage each other's topics.
+func microappTopic(microappID, topic string) string {
+	return microappID + "." + topic
+}
+
+func (h *NotificationHandler) getClientID(r *http.Request) (string, error) {
+	serviceInfo, ok := auth.GetServiceInfo(r.Context())
+	if !ok {
+		return "", errors.New(errServiceInfoNotFound)
+	}
+	if serviceInfo.ClientID == "" {
+		slog.WarnContext(r.Context(), "Client ID is empty in service info")
+		return "", errors.New(errClientIDEmpty)
+	}
+	return serviceInfo.ClientID, nil
+}
+
+// reservedDataKeys are data keys the notification service turns into delivery options
+var reservedDataKeys = map[string]bool{
+	services.DataKeyCollapseKey: true,
+	services.DataKeyTTL:         true,
+	services.DataKeyImageURL:    true,
+	services.DataKeyBadge:       true,
+}
+
+func (h *NotificationHandler) prepareFCMData(data map[string]interface{}, microappID string) map[string]string {
+	// Converts the given data map to a map of string to string, marshalling non-string values to JSON strings.
+	// Also adds the microappID to the data if it's not empty, along with the sender identity of that microapp.
+	// The collapse key, TTL, image and badge keys are reserved and dropped: only validated request
+	// fields may set them, after the image has passed checkImageURL.
+	dataStr := make(map[string]string)
+	for k, v := range data {
+		if reservedDataKeys[k] {
+			continue
+		}
+		if str, ok := v.(string); ok {
+			dataStr[k] = str
+		} else {
+			if bytes, err := json.Marshal(v); err == nil {
+				dataStr[k] = string(bytes)
+			}
+		}
+	}
+	if microappID != "" {
+		dataStr[dataKeyMicroappID] = microappID
+	}
+	services.ResolveSenderIdentity(h.db, microappID, h.defaultSender).Apply(dataStr)
+	return dataStr
+}
+
+// sendToDevices sends to the devices and, when the notification service reports per-token
+// results, also returns them grouped by recipient. Services that only report counts are
+// sent to as before and yield a nil report.
+func (h *NotificationHandler) sendToDevices(ctx context.Context, devices []models.DeviceToken, title, body string, data map[string]string) (int, int, []string, deliveryReport, error) {
+	results, err := services.SendToDevicesDetailed(ctx, h.fcmService, devices, title, body, data)
+	if errors.Is(err, services.ErrDetailedResultsNotSupported) {
+		successCount, failureCount, deadTokens, err := services.SendToDevices(ctx, h.fcmService, devices, title, body, data)
+		return successCount, failureCount, deadTokens, nil, err
+	}
+	if err != nil {
+		return 0, 0, nil, nil, err
+	}
+	successCount, failureCount, deadTokens := services.SummarizeDeliveryResults(results)
+	return successCount, failureCount, deadTokens, newDeliveryReport(devices, results), nil
+}
+
+// deliveryReport maps each recipient to the results of sending to their devices.
+type deliveryReport map[string][]services.DeliveryResult
+
+// newDeliveryReport groups per-token results by the user the device belongs to.
+func newDeliveryReport(devices []models.DeviceToken, results []services.DeliveryResult) deliveryReport {
+	byToken := make(map[string]services.DeliveryResult, len(results))
+	for _, result := range results {
+		byToken[result.Token] = result
+	}
+	report := make(deliveryReport)
+	for _, device := range devices {
+		if result, ok := byToken[device.DeviceToken]; ok {
+			report[device.UserEmail] = append(report[device.UserEmail], result)
+		}
+	}
+	return report
+}
+
+// merge adds the results of another send to the report. It stays nil while every send is nil.
+func (d deliveryReport) merge(other deliveryReport) deliveryReport {
+	if other == nil {
+		return d
+	}
+	if d == nil {
+		d = make(deliveryReport, len(other))
+	}
+	for email, results := range other {
+		d[email] = append(d[email], results...)
+	}
+	return d
+}
+
+// dropped returns how many devices were beyond the per-send token limit.
+func (d deliveryReport) dropped() int {
+	dropped := 0
+	for _, results := range d {
+		dropped += services.CountDroppedResults(results)
+	}
+	return dropped
+}
+
+// failureReason returns the error of one of the user's devices when none of them was reached.
+func (d deliveryReport) failureReason(email string) (string, bool) {
+	results := d[email]
+	if len(results) == 0 {
+		return "", false
+	}
+	for _, result := range results {
+		if result.Success {
+			return "", false
+		}
+	}
+	reason := results[0].Error
+	if len(reason) > maxFailureReasonLength {
+		reason = reason[:maxFailureReasonLength]
+	}
+	return reason, true
+}
+
+// deliveryStatus returns the initial delivery status of a user's notification and the FCM
+// message ID of one of the devices that accepted it. A notification accepted for any device
+// stays pending until the delivery webhook reports on it; one rejected for every device has
+// failed. Without per-token results the status is pending.
+func (d deliveryReport) deliveryStatus(email string) (string, *string) {
+	results := d[email]
+	if len(results) == 0 {
+		return models.DeliveryStatusPending, nil
+	}
+	for _, result := range results {
+		if result.Success {
+			if result.MessageID == "" {
+				return models.DeliveryStatusPending, nil
+			}
+			messageID := result.MessageID
+			return models.DeliveryStatusPending, &messageID
+		}
+	}
+	return models.DeliveryStatusFailed, nil
+}
+
+// deliveries returns the per-device delivery records of a user's notification.
+func (d deliveryReport) deliveries(email string, logID int64) []models.NotificationDelivery {
+	results := d[email]
+	deliveries := make([]models.NotificationDelivery, 0, len(results))
+	for _, result := range results {
+		delivery := models.NotificationDelivery{
+			NotificationLogID: logID,
+			DeviceTokenHash:   models.HashDeviceToken(result.Token),
+			Status:            models.DeliveryStatusFailed,
+		}
+		if result.Success {
+			delivery.Status = models.DeliveryStatusPending
+		}
+		if result.MessageID != "" {
+			messageID := result.MessageID
+			delivery.FCMMessageID = &messageID
+		}
+		deliveries = append(deliveries, delivery)
+	}
+	return deliveries
+}
+
+// logNotifications writes a log entry per user. report is optional; when it has results for
+// a user, their delivery status, the failure reason if no device was reached and a delivery
+// record per device are persisted with the log.
+func (h *NotificationHandler) logNotifications(ctx context.Context, userEmails []string, title, body, microappID, status string, data map[string]interface{}, dedupKey string, report deliveryReport) {
+	var dedupKeyPtr *string
+	if dedupKey != "" {
+		dedupKeyPtr = &dedupKey
+	}
+	for _, email := range userEmails {
+		log := models.NotificationLog{
+			UserEmail:  email,
+			Title:      &title,
+			Body:       &body,
+			Data:       data,
+			Status:     &status,
+			MicroappID: &microappID,
+			DedupKey:   dedupKeyPtr,
+		}
+		if reason, ok := report.failureReason(email); ok {
+			log.FailureReason = &reason
+		}
+		log.DeliveryStatus, log.FCMMessageID = report.deliveryStatus(email)
+		err := h.db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Create(&log).Error; err != nil {
+				return err
+			}
+			if deliveries := report.deliveries(email, log.ID); len(deliveries) > 0 {
+				return tx.Create(&deliveries).Error
+			}
+			return nil
+		})
+		if err != nil {
+			slog.ErrorContext(ctx, "Failed to log notification", "error", err, "email", email)
 		}
 	}
 }
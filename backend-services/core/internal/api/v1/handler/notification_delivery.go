@@ -0,0 +1,153 @@
+// Copyright (c) 2025 WSO2 LLC. (https://www.wso2.com).
+//
+// WSO2 LLC. licenses this file to you under the Apache License,
+// Version 2.0 (the "License"); you may not use this file except
+// in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+package handler
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/opensuperapp/opensuperapp/backend-services/core/internal/api/v1/dto"
+	"github.com/opensuperapp/opensuperapp/backend-services/core/internal/models"
+	"gorm.io/gorm"
+)
+
+// DeliveryWebhook records a delivery state reported for a message FCM sent to one device.
+// Statuses only move forward, so a late or repeated report never undoes a later one, and
+// the notification's own status follows the furthest state reached on any device.
+func (h *NotificationHandler) DeliveryWebhook(w http.ResponseWriter, r *http.Request) {
+	if !validateContentType(w, r) {
+		return
+	}
+	limitRequestBody(w, r, 0)
+	var req dto.DeliveryWebhookRequest
+	if !decodeJSONBody(w, r, &req) {
+		return
+	}
+	if !validateStruct(w, &req) {
+		return
+	}
+	microappID, err := h.getClientID(r)
+	if err != nil {
+		slog.ErrorContext(r.Context(), errClientIDInvalid, "error", err)
+		http.Error(w, errClientIDInvalid, http.StatusUnauthorized)
+		return
+	}
+
+	var delivery models.NotificationDelivery
+	var log models.NotificationLog
+	err = h.db.Transaction(func(tx *gorm.DB) error {
+		// Joined to the log so that a microapp can only report on its own notifications
+		if err := tx.Joins("JOIN notification_logs ON notification_logs.id = notification_delivery.notification_log_id").
+			Where("notification_delivery.fcm_message_id = ? AND notification_logs.microapp_id = ?", req.MessageID, microappID).
+			First(&delivery).Error; err != nil {
+			return err
+		}
+		if models.DeliveryStatusAdvances(delivery.Status, req.Status) {
+			if err := tx.Model(&models.NotificationDelivery{}).Where("id = ?", delivery.ID).
+				Update("status", req.Status).Error; err != nil {
+				return err
+			}
+			delivery.Status = req.Status
+		}
+		if err := tx.First(&log, delivery.NotificationLogID).Error; err != nil {
+			return err
+		}
+		updates := map[string]interface{}{}
+		if models.DeliveryStatusAdvances(log.DeliveryStatus, delivery.Status) {
+			updates["delivery_status"] = delivery.Status
+			log.DeliveryStatus = delivery.Status
+		}
+		if delivery.Status == models.DeliveryStatusRead && log.ReadAt == nil {
+			now := time.Now()
+			updates["read_at"] = now
+			log.ReadAt = &now
+		}
+		if len(updates) == 0 {
+			return nil
+		}
+		return tx.Model(&models.NotificationLog{}).Where("id = ?", log.ID).Updates(updates).Error
+	})
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			http.Error(w, errDeliveryNotFound, http.StatusNotFound)
+			return
+		}
+		slog.ErrorContext(r.Context(), "Failed to update delivery status", "error", err, "message_id", req.MessageID, "microapp_id", microappID)
+		http.Error(w, errFailedToUpdateDeliveryStatus, http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusOK, dto.DeliveryWebhookResponse{
+		NotificationID: log.ID,
+		MessageID:      req.MessageID,
+		Status:         delivery.Status,
+		DeliveryStatus: log.DeliveryStatus,
+	})
+}
+
+// GetNotificationDeliveryStatus returns the delivery status of one of the calling microapp's
+// notifications, overall and per device.
+func (h *NotificationHandler) GetNotificationDeliveryStatus(w http.ResponseWriter, r *http.Request) {
+	notificationID, err := strconv.ParseInt(chi.URLParam(r, urlParamNotifID), 10, 64)
+	if err != nil {
+		http.Error(w, errInvalidNotificationID, http.StatusBadRequest)
+		return
+	}
+	microappID, err := h.getClientID(r)
+	if err != nil {
+		slog.ErrorContext(r.Context(), errClientIDInvalid, "error", err)
+		http.Error(w, errClientIDInvalid, http.StatusUnauthorized)
+		return
+	}
+	var log models.NotificationLog
+	if err := h.db.Where("id = ? AND microapp_id = ?", notificationID, microappID).First(&log).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			http.Error(w, errNotificationNotFound, http.StatusNotFound)
+			return
+		}
+		slog.ErrorContext(r.Context(), "Failed to fetch notification", "error", err, "id", notificationID)
+		http.Error(w, errFailedToFetchDeliveryStatus, http.StatusInternalServerError)
+		return
+	}
+	var deliveries []models.NotificationDelivery
+	if err := h.db.Where("notification_log_id = ?", log.ID).Order("id").Find(&deliveries).Error; err != nil {
+		slog.ErrorContext(r.Context(), "Failed to fetch notification deliveries", "error", err, "id", notificationID)
+		http.Error(w, errFailedToFetchDeliveryStatus, http.StatusInternalServerError)
+		return
+	}
+	devices := make([]dto.DeviceDeliveryStatus, len(deliveries))
+	for i, delivery := range deliveries {
+		devices[i] = dto.DeviceDeliveryStatus{
+			FCMMessageID: derefString(delivery.FCMMessageID),
+			Status:       delivery.Status,
+			UpdatedAt:    delivery.UpdatedAt,
+		}
+	}
+	writeJSON(w, http.StatusOK, dto.NotificationDeliveryStatusResponse{
+		ID:             log.ID,
+		Recipient:      log.UserEmail,
+		Status:         derefString(log.Status),
+		DeliveryStatus: log.DeliveryStatus,
+		FCMMessageID:   derefString(log.FCMMessageID),
+		FailureReason:  derefString(log.FailureReason),
+		SentAt:         log.SentAt,
+		ReadAt:         log.ReadAt,
+		Devices:        devices,
+	})
+}
@@ -0,0 +1,185 @@
+// Copyright (c) 2025 WSO2 LLC. (https://www.wso2.com).
+//
+// WSO2 LLC. licenses this file to you under the Apache License,
+// Version 2.0 (the "License"); you may not use this file except
+// in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+package handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/opensuperapp/opensuperapp/backend-services/core/internal/api/v1/dto"
+	"github.com/opensuperapp/opensuperapp/backend-services/core/internal/auth"
+	"github.com/opensuperapp/opensuperapp/backend-services/core/internal/models"
+	"github.com/opensuperapp/opensuperapp/backend-services/core/internal/services"
+)
+
+// sendDeliveryTestNotification sends a notification from app-1 to alice's phone and tablet,
+// with the tablet rejected, and returns the handler and the id of the logged notification
+func sendDeliveryTestNotification(t *testing.T) (*NotificationHandler, int64) {
+	t.Helper()
+	db := setupTestDB(t)
+	tokens := []models.DeviceToken{
+		{UserEmail: "alice@example.com", DeviceToken: "alice-phone", Platform: "android", IsActive: true},
+		{UserEmail: "alice@example.com", DeviceToken: "alice-tablet", Platform: "android", IsActive: true},
+	}
+	if err := db.Create(&tokens).Error; err != nil {
+		t.Fatalf("Failed to seed device tokens: %v", err)
+	}
+	svc := &detailedNotificationService{failures: map[string]string{"alice-tablet": "unavailable"}}
+	h := NewNotificationHandler(db, svc, nil, services.SenderIdentity{})
+
+	body, _ := json.Marshal(dto.SendNotificationRequest{UserEmails: []string{"alice@example.com"}, Title: "Hi", Body: "Hello"})
+	req := httptest.NewRequest(http.MethodPost, "/notifications/send", bytes.NewReader(body))
+	req.Header.Set(headerContentType, contentTypeJSON)
+	req = auth.SetServiceInfo(req, &auth.ServiceInfo{ClientID: "app-1"})
+	w := httptest.NewRecorder()
+	h.SendNotification(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	var log models.NotificationLog
+	if err := db.First(&log).Error; err != nil {
+		t.Fatalf("Failed to load notification log: %v", err)
+	}
+	return h, log.ID
+}
+
+// postDeliveryWebhook reports a delivery status as the given microapp
+func postDeliveryWebhook(h *NotificationHandler, clientID, messageID, status string) *httptest.ResponseRecorder {
+	body, _ := json.Marshal(dto.DeliveryWebhookRequest{MessageID: messageID, Status: status})
+	req := httptest.NewRequest(http.MethodPost, "/notifications/delivery-webhook", bytes.NewReader(body))
+	req.Header.Set(headerContentType, contentTypeJSON)
+	req = auth.SetServiceInfo(req, &auth.ServiceInfo{ClientID: clientID})
+	w := httptest.NewRecorder()
+	h.DeliveryWebhook(w, req)
+	return w
+}
+
+// getDeliveryStatus polls the delivery status of a notification as the given microapp
+func getDeliveryStatus(t *testing.T, h *NotificationHandler, clientID string, id int64) (int, dto.NotificationDeliveryStatusResponse) {
+	t.Helper()
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add(urlParamNotifID, strconv.FormatInt(id, 10))
+	req := httptest.NewRequest(http.MethodGet, "/notifications/"+strconv.FormatInt(id, 10)+"/status", nil)
+	req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+	req = auth.SetServiceInfo(req, &auth.ServiceInfo{ClientID: clientID})
+	w := httptest.NewRecorder()
+	h.GetNotificationDeliveryStatus(w, req)
+	var resp dto.NotificationDeliveryStatusResponse
+	if w.Code == http.StatusOK {
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+	}
+	return w.Code, resp
+}
+
+// TestSendNotification_RecordsDeliveries tests that a send records the FCM message ID and a delivery per device
+func TestSendNotification_RecordsDeliveries(t *testing.T) {
+	h, id := sendDeliveryTestNotification(t)
+
+	code, resp := getDeliveryStatus(t, h, "app-1", id)
+	if code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", code)
+	}
+	if resp.DeliveryStatus != models.DeliveryStatusPending || resp.FCMMessageID != "msg-alice-phone" {
+		t.Errorf("Expected a pending notification with message msg-alice-phone, got %q and %q", resp.DeliveryStatus, resp.FCMMessageID)
+	}
+	if len(resp.Devices) != 2 {
+		t.Fatalf("Expected 2 device deliveries, got %d", len(resp.Devices))
+	}
+	if resp.Devices[0].Status != models.DeliveryStatusPending || resp.Devices[0].FCMMessageID != "msg-alice-phone" {
+		t.Errorf("Expected the phone delivery to be pending under msg-alice-phone, got %+v", resp.Devices[0])
+	}
+	if resp.Devices[1].Status != models.DeliveryStatusFailed || resp.Devices[1].FCMMessageID != "" {
+		t.Errorf("Expected the tablet delivery to have failed without a message ID, got %+v", resp.Devices[1])
+	}
+
+	var delivery models.NotificationDelivery
+	h.db.First(&delivery)
+	if delivery.DeviceTokenHash != models.HashDeviceToken("alice-phone") {
+		t.Errorf("Expected the device token to be stored hashed, got %q", delivery.DeviceTokenHash)
+	}
+}
+
+// TestDeliveryWebhook tests that reported statuses advance the delivery and the notification but never regress
+func TestDeliveryWebhook(t *testing.T) {
+	h, id := sendDeliveryTestNotification(t)
+
+	steps := []struct {
+		status         string
+		wantStatus     string
+		wantDelivery   string
+		wantReadStamps bool
+	}{
+		{models.DeliveryStatusDelivered, models.DeliveryStatusDelivered, models.DeliveryStatusDelivered, false},
+		{models.DeliveryStatusRead, models.DeliveryStatusRead, models.DeliveryStatusRead, true},
+		{models.DeliveryStatusDelivered, models.DeliveryStatusRead, models.DeliveryStatusRead, true},
+	}
+	for _, step := range steps {
+		w := postDeliveryWebhook(h, "app-1", "msg-alice-phone", step.status)
+		if w.Code != http.StatusOK {
+			t.Fatalf("Reporting %s: expected status 200, got %d: %s", step.status, w.Code, w.Body.String())
+		}
+		var resp dto.DeliveryWebhookResponse
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+		if resp.NotificationID != id || resp.Status != step.wantStatus || resp.DeliveryStatus != step.wantDelivery {
+			t.Errorf("Reporting %s: expected %s/%s for notification %d, got %+v", step.status, step.wantStatus, step.wantDelivery, id, resp)
+		}
+		var log models.NotificationLog
+		h.db.First(&log, id)
+		if (log.ReadAt != nil) != step.wantReadStamps {
+			t.Errorf("Reporting %s: expected read_at set to be %v, got %v", step.status, step.wantReadStamps, log.ReadAt)
+		}
+	}
+}
+
+// TestDeliveryWebhook_Rejects tests that unknown messages, other microapps' messages and unknown statuses are rejected
+func TestDeliveryWebhook_Rejects(t *testing.T) {
+	h, id := sendDeliveryTestNotification(t)
+
+	tests := []struct {
+		name      string
+		clientID  string
+		messageID string
+		status    string
+		want      int
+	}{
+		{"unknown message", "app-1", "msg-unknown", models.DeliveryStatusDelivered, http.StatusNotFound},
+		{"other microapp", "app-2", "msg-alice-phone", models.DeliveryStatusDelivered, http.StatusNotFound},
+		{"unknown status", "app-1", "msg-alice-phone", "bounced", http.StatusBadRequest},
+		{"pending status", "app-1", "msg-alice-phone", models.DeliveryStatusPending, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if w := postDeliveryWebhook(h, tt.clientID, tt.messageID, tt.status); w.Code != tt.want {
+				t.Errorf("Expected status %d, got %d: %s", tt.want, w.Code, w.Body.String())
+			}
+		})
+	}
+
+	if code, _ := getDeliveryStatus(t, h, "app-2", id); code != http.StatusNotFound {
+		t.Errorf("Expected another microapp's status poll to return 404, got %d", code)
+	}
+}
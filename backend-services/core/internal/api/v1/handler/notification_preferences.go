@@ -0,0 +1,103 @@
+// Copyright (c) 2025 WSO2 LLC. (https://www.wso2.com).
+//
+// WSO2 LLC. licenses this file to you under the Apache License,
+// Version 2.0 (the "License"); you may not use this file except
+// in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+package handler
+
+import (
+	"log/slog"
+	"net/http"
+
+	"github.com/opensuperapp/opensuperapp/backend-services/core/internal/api/v1/dto"
+	"github.com/opensuperapp/opensuperapp/backend-services/core/internal/auth"
+	"github.com/opensuperapp/opensuperapp/backend-services/core/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// GetNotificationPreferences lists the authenticated user's per-microapp notification preferences.
+// Micro apps without a preference send notifications as usual.
+func (h *NotificationHandler) GetNotificationPreferences(w http.ResponseWriter, r *http.Request) {
+	userInfo, ok := auth.GetUserInfo(r.Context())
+	if !ok {
+		http.Error(w, errUserInfoNotFound, http.StatusUnauthorized)
+		return
+	}
+	preferences, err := h.userPreferences(h.db, userInfo.Email)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to fetch notification preferences", "error", err, "email", userInfo.Email)
+		http.Error(w, errFailedToFetchPreferences, http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusOK, dto.NotificationPreferencesResponse{Preferences: preferences})
+}
+
+// UpdateNotificationPreferences sets the authenticated user's opt-out for each listed micro app
+// and returns all of the user's preferences.
+func (h *NotificationHandler) UpdateNotificationPreferences(w http.ResponseWriter, r *http.Request) {
+	if !validateContentType(w, r) {
+		return
+	}
+	limitRequestBody(w, r, 0)
+	userInfo, ok := auth.GetUserInfo(r.Context())
+	if !ok {
+		http.Error(w, errUserInfoNotFound, http.StatusUnauthorized)
+		return
+	}
+	var req dto.NotificationPreferencesRequest
+	if !decodeJSONBody(w, r, &req) {
+		return
+	}
+	if !validateStruct(w, &req) {
+		return
+	}
+	rows := make([]models.NotificationPreference, len(req.Preferences))
+	for i, p := range req.Preferences {
+		rows[i] = models.NotificationPreference{UserEmail: userInfo.Email, MicroappID: p.MicroappID, OptedOut: *p.OptedOut}
+	}
+	var preferences []dto.NotificationPreference
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "user_email"}, {Name: "microapp_id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"opted_out", "updated_at"}),
+		}).Create(&rows).Error; err != nil {
+			return err
+		}
+		var err error
+		preferences, err = h.userPreferences(tx, userInfo.Email)
+		return err
+	})
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to update notification preferences", "error", err, "email", userInfo.Email)
+		http.Error(w, errFailedToUpdatePreferences, http.StatusInternalServerError)
+		return
+	}
+	slog.InfoContext(r.Context(), "Notification preferences updated", "email", userInfo.Email, "count", len(rows))
+	writeJSON(w, http.StatusOK, dto.NotificationPreferencesResponse{Preferences: preferences})
+}
+
+// userPreferences returns the notification preferences of a user, ordered by micro app
+func (h *NotificationHandler) userPreferences(db *gorm.DB, email string) ([]dto.NotificationPreference, error) {
+	var rows []models.NotificationPreference
+	if err := db.Where("user_email = ?", email).Order("microapp_id").Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	preferences := make([]dto.NotificationPreference, len(rows))
+	for i, row := range rows {
+		optedOut := row.OptedOut
+		preferences[i] = dto.NotificationPreference{MicroappID: row.MicroappID, OptedOut: &optedOut}
+	}
+	return preferences, nil
+}
@@ -0,0 +1,133 @@
+// Copyright (c) 2025 WSO2 LLC. (https://www.wso2.com).
+//
+// WSO2 LLC. licenses this file to you under the Apache License,
+// Version 2.0 (the "License"); you may not use this file except
+// in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/opensuperapp/opensuperapp/backend-services/core/internal/api/v1/dto"
+	"github.com/opensuperapp/opensuperapp/backend-services/core/internal/auth"
+	"github.com/opensuperapp/opensuperapp/backend-services/core/internal/models"
+	"github.com/opensuperapp/opensuperapp/backend-services/core/internal/services"
+)
+
+// putPreferences updates alice's notification preferences
+func putPreferences(t *testing.T, h *NotificationHandler, body string) (int, dto.NotificationPreferencesResponse) {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodPut, "/notifications/preferences", bytes.NewBufferString(body))
+	req.Header.Set(headerContentType, contentTypeJSON)
+	req = auth.SetUserInfo(req, &auth.CustomJwtPayload{Email: "alice@example.com"})
+	w := httptest.NewRecorder()
+	h.UpdateNotificationPreferences(w, req)
+	var resp dto.NotificationPreferencesResponse
+	if w.Code == http.StatusOK {
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("Failed to decode preferences: %v", err)
+		}
+	}
+	return w.Code, resp
+}
+
+// TestNotificationPreferences tests setting, changing and listing a user's opt-outs
+func TestNotificationPreferences(t *testing.T) {
+	db := setupTestDB(t)
+	h := NewNotificationHandler(db, &fakeNotificationService{}, nil, services.SenderIdentity{})
+
+	code, resp := putPreferences(t, h, `{"preferences":[{"microappId":"news","optedOut":true},{"microappId":"payroll","optedOut":true}]}`)
+	if code != http.StatusOK || len(resp.Preferences) != 2 {
+		t.Fatalf("Expected two preferences, got %d %+v", code, resp)
+	}
+	// Changing a preference updates the existing row
+	if code, resp = putPreferences(t, h, `{"preferences":[{"microappId":"payroll","optedOut":false}]}`); code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", code)
+	}
+	if len(resp.Preferences) != 2 || resp.Preferences[0].MicroappID != "news" || !*resp.Preferences[0].OptedOut || *resp.Preferences[1].OptedOut {
+		t.Errorf("Expected news opted out and payroll opted in, got %+v", resp.Preferences)
+	}
+
+	w := httptest.NewRecorder()
+	h.GetNotificationPreferences(w, newUserRequest(http.MethodGet, "/notifications/preferences", "bob@example.com", ""))
+	if w.Code != http.StatusOK || w.Body.String() != "{\"preferences\":[]}\n" {
+		t.Errorf("Expected no preferences for another user, got %d %s", w.Code, w.Body.String())
+	}
+
+	for _, body := range []string{`{"preferences":[]}`, `{"preferences":[{"microappId":"news"}]}`, `{"preferences":[{"optedOut":true}]}`} {
+		if code, _ := putPreferences(t, h, body); code != http.StatusBadRequest {
+			t.Errorf("Expected status 400 for %s, got %d", body, code)
+		}
+	}
+}
+
+// TestSendNotification_OptedOut tests that users who opted out of a micro app receive none of its notifications
+func TestSendNotification_OptedOut(t *testing.T) {
+	db := setupTestDB(t)
+	for _, device := range []models.DeviceToken{
+		{UserEmail: "alice@example.com", DeviceToken: "alice-token", Platform: "android", IsActive: true},
+		{UserEmail: "bob@example.com", DeviceToken: "bob-token", Platform: "android", IsActive: true},
+	} {
+		if err := db.Create(&device).Error; err != nil {
+			t.Fatalf("Failed to seed device token: %v", err)
+		}
+	}
+	for _, pref := range []models.NotificationPreference{
+		{UserEmail: "alice@example.com", MicroappID: "app-1", OptedOut: true},
+		{UserEmail: "bob@example.com", MicroappID: "app-2", OptedOut: true},
+	} {
+		if err := db.Create(&pref).Error; err != nil {
+			t.Fatalf("Failed to seed preference: %v", err)
+		}
+	}
+	fake := &fakeNotificationService{}
+	h := NewNotificationHandler(db, fake, nil, services.SenderIdentity{})
+
+	send := func(emails ...string) dto.NotificationResponse {
+		fake.lastTokens = nil
+		body, _ := json.Marshal(dto.SendNotificationRequest{UserEmails: emails, Title: "Hi", Body: "Hello"})
+		req := httptest.NewRequest(http.MethodPost, "/notifications/send", bytes.NewReader(body))
+		req.Header.Set(headerContentType, contentTypeJSON)
+		req = auth.SetServiceInfo(req, &auth.ServiceInfo{ClientID: "app-1"})
+		w := httptest.NewRecorder()
+		h.SendNotification(w, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+		}
+		var resp dto.NotificationResponse
+		json.NewDecoder(w.Body).Decode(&resp)
+		return resp
+	}
+
+	resp := send("alice@example.com", "bob@example.com")
+	if len(fake.lastTokens) != 1 || fake.lastTokens[0] != "bob-token" {
+		t.Errorf("Expected only bob's device to be sent to, got %v", fake.lastTokens)
+	}
+	if resp.SkippedOptedOut != 1 || resp.Success != 1 {
+		t.Errorf("Expected 1 opted-out skip and 1 success, got %+v", resp)
+	}
+	var logged int64
+	db.Model(&models.NotificationLog{}).Where("user_email = ?", "alice@example.com").Count(&logged)
+	if logged != 0 {
+		t.Errorf("Expected no notification logged for alice, got %d", logged)
+	}
+
+	resp = send("alice@example.com")
+	if fake.lastTokens != nil || resp.Success != 0 || resp.Message != msgAllRecipientsOptedOut {
+		t.Errorf("Expected nothing sent when every recipient opted out, got tokens %v and %+v", fake.lastTokens, resp)
+	}
+}
@@ -0,0 +1,564 @@
+// Copyright (c) 2025 WSO2 LLC. (https://www.wso2.com).
+//
+// WSO2 LLC. licenses this file to you under the Apache License,
+// Version 2.0 (the "License"); you may not use this file except
+// in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/opensuperapp/opensuperapp/backend-services/core/internal/api/v1/dto"
+	"github.com/opensuperapp/opensuperapp/backend-services/core/internal/models"
+	"github.com/opensuperapp/opensuperapp/backend-services/core/internal/services"
+
+	"github.com/go-chi/chi/v5"
+	"gorm.io/gorm"
+)
+
+// UpsertNotificationTemplate creates or replaces one of the calling microapp's notification templates.
+// Templates that fail to parse are rejected so that errors surface here rather than at send time.
+func (h *NotificationHandler) UpsertNotificationTemplate(w http.ResponseWriter, r *http.Request) {
+	if !validateContentType(w, r) {
+		return
+	}
+	limitRequestBody(w, r, 0)
+	var req dto.UpsertNotificationTemplateRequest
+	if !decodeJSONBody(w, r, &req) {
+		return
+	}
+	if !validateStruct(w, &req) {
+		return
+	}
+	if _, err := services.CompilePlatformVariants(req.Title, req.Body, toPlatformOverrides(req.PlatformOverrides)); err != nil {
+		http.Error(w, errInvalidNotificationTemplate+": "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	microappID, err := h.getClientID(r)
+	if err != nil {
+		slog.ErrorContext(r.Context(), errClientIDInvalid, "error", err)
+		http.Error(w, errClientIDInvalid, http.StatusUnauthorized)
+		return
+	}
+
+	tmpl, err := h.upsertTemplate(microappID, req)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to upsert notification template", "error", err, "microapp_id", microappID, "template_key", req.TemplateKey)
+		http.Error(w, errFailedToUpsertTemplate, http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusOK, toNotificationTemplateResponse(tmpl))
+}
+
+// notificationTemplateSort lists templates by key and then locale by default.
+var notificationTemplateSort = listSort{
+	fields: map[string]string{
+		"templateKey": "template_key, locale",
+		"locale":      "locale, template_key",
+		"updatedAt":   "updated_at",
+	},
+	defaultField: "templateKey",
+	tieBreaker:   "id",
+}
+
+// ListNotificationTemplates returns every template of a micro app, in all locales,
+// unless a limit or offset selects a page.
+func (h *NotificationHandler) ListNotificationTemplates(w http.ResponseWriter, r *http.Request) {
+	appID := chi.URLParam(r, urlParamAppID)
+	params, err := parseListParams(r, notificationTemplateSort)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	var templates []models.NotificationTemplate
+	query := h.db.Where("microapp_id = ?", appID).Order(params.order)
+	if params.paged {
+		query = query.Limit(params.limit).Offset(params.offset)
+	}
+	if err := query.Find(&templates).Error; err != nil {
+		slog.ErrorContext(r.Context(), "Failed to fetch notification templates", "error", err, "appID", appID)
+		http.Error(w, errFailedToFetchTemplate, http.StatusInternalServerError)
+		return
+	}
+	response := make([]dto.NotificationTemplateResponse, len(templates))
+	for i, tmpl := range templates {
+		response[i] = toNotificationTemplateResponse(tmpl)
+	}
+	writeJSON(w, http.StatusOK, response)
+}
+
+// GetNotificationTemplate returns one translation of a micro app's template. The locale query
+// parameter selects the translation and must match exactly; omitting it returns the default.
+func (h *NotificationHandler) GetNotificationTemplate(w http.ResponseWriter, r *http.Request) {
+	tmpl, ok := h.loadTemplate(w, r)
+	if !ok {
+		return
+	}
+	writeJSON(w, http.StatusOK, toNotificationTemplateResponse(*tmpl))
+}
+
+// PutNotificationTemplate creates or replaces one translation of a micro app's template on the
+// micro app's behalf. The key in the path takes precedence over any key in the body.
+func (h *NotificationHandler) PutNotificationTemplate(w http.ResponseWriter, r *http.Request) {
+	appID := chi.URLParam(r, urlParamAppID)
+	if !validateContentType(w, r) {
+		return
+	}
+	limitRequestBody(w, r, 0)
+	var req dto.UpsertNotificationTemplateRequest
+	if !decodeJSONBody(w, r, &req) {
+		return
+	}
+	req.TemplateKey = chi.URLParam(r, urlParamTemplateKey)
+	if !validateStruct(w, &req) {
+		return
+	}
+	if _, err := services.CompilePlatformVariants(req.Title, req.Body, toPlatformOverrides(req.PlatformOverrides)); err != nil {
+		http.Error(w, errInvalidNotificationTemplate+": "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	var count int64
+	if err := h.db.Model(&models.MicroApp{}).
+		Where("micro_app_id = ? AND active = ?", appID, models.StatusActive).
+		Count(&count).Error; err != nil {
+		slog.ErrorContext(r.Context(), "Failed to fetch micro app", "error", err, "appID", appID)
+		http.Error(w, errFailedToFetchMicroApp, http.StatusInternalServerError)
+		return
+	}
+	if count == 0 {
+		http.Error(w, errMicroAppNotFound, http.StatusNotFound)
+		return
+	}
+
+	tmpl, err := h.upsertTemplate(appID, req)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to upsert notification template", "error", err, "appID", appID, "template_key", req.TemplateKey)
+		http.Error(w, errFailedToUpsertTemplate, http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusOK, toNotificationTemplateResponse(tmpl))
+}
+
+// DeleteNotificationTemplate removes one translation of a micro app's template, selected as in
+// GetNotificationTemplate. Other translations of the same key are kept.
+func (h *NotificationHandler) DeleteNotificationTemplate(w http.ResponseWriter, r *http.Request) {
+	tmpl, ok := h.loadTemplate(w, r)
+	if !ok {
+		return
+	}
+	if err := h.db.Delete(&models.NotificationTemplate{}, tmpl.ID).Error; err != nil {
+		slog.ErrorContext(r.Context(), "Failed to delete notification template", "error", err, "template_id", tmpl.ID)
+		http.Error(w, errFailedToDeleteTemplate, http.StatusInternalServerError)
+		return
+	}
+	slog.InfoContext(r.Context(), "Notification template deleted", "appID", tmpl.MicroappID, "template_key", tmpl.TemplateKey, "locale", tmpl.Locale)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// loadTemplate loads the template named in the URL, writing an error response if there is none.
+func (h *NotificationHandler) loadTemplate(w http.ResponseWriter, r *http.Request) (*models.NotificationTemplate, bool) {
+	appID := chi.URLParam(r, urlParamAppID)
+	key := chi.URLParam(r, urlParamTemplateKey)
+	locale := r.URL.Query().Get("locale")
+	var tmpl models.NotificationTemplate
+	if err := h.db.Where("microapp_id = ? AND template_key = ? AND locale = ?", appID, key, locale).First(&tmpl).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			http.Error(w, errNotificationTemplateNotFound, http.StatusNotFound)
+		} else {
+			slog.ErrorContext(r.Context(), "Failed to fetch notification template", "error", err, "appID", appID, "template_key", key)
+			http.Error(w, errFailedToFetchTemplate, http.StatusInternalServerError)
+		}
+		return nil, false
+	}
+	return &tmpl, true
+}
+
+// upsertTemplate creates or replaces the template with the request's key and locale.
+func (h *NotificationHandler) upsertTemplate(microappID string, req dto.UpsertNotificationTemplateRequest) (models.NotificationTemplate, error) {
+	tmpl := models.NotificationTemplate{}
+	// A map is assigned so that a missing defaultData clears the stored one
+	err := h.db.Where("microapp_id = ? AND template_key = ? AND locale = ?", microappID, req.TemplateKey, req.Locale).
+		Assign(map[string]interface{}{
+			"title_template":     req.Title,
+			"body_template":      req.Body,
+			"default_data":       models.JSONMap(req.DefaultData),
+			"platform_overrides": toPlatformOverrides(req.PlatformOverrides),
+		}).
+		Attrs(models.NotificationTemplate{
+			MicroappID:  microappID,
+			TemplateKey: req.TemplateKey,
+			Locale:      req.Locale,
+		}).FirstOrCreate(&tmpl).Error
+	return tmpl, err
+}
+
+func toNotificationTemplateResponse(tmpl models.NotificationTemplate) dto.NotificationTemplateResponse {
+	response := dto.NotificationTemplateResponse{
+		TemplateKey: tmpl.TemplateKey,
+		Locale:      tmpl.Locale,
+		Title:       tmpl.TitleTemplate,
+		Body:        tmpl.BodyTemplate,
+		DefaultData: tmpl.DefaultData,
+		UpdatedAt:   tmpl.UpdatedAt,
+	}
+	if len(tmpl.PlatformOverrides) > 0 {
+		response.PlatformOverrides = make(map[string]dto.NotificationTemplateOverride, len(tmpl.PlatformOverrides))
+		for platform, override := range tmpl.PlatformOverrides {
+			response.PlatformOverrides[platform] = dto.NotificationTemplateOverride{Title: override.Title, Body: override.Body, Data: override.Data}
+		}
+	}
+	return response
+}
+
+// toPlatformOverrides converts requested overrides to their stored form, nil if there are none.
+func toPlatformOverrides(overrides map[string]dto.NotificationTemplateOverride) models.PlatformOverrides {
+	if len(overrides) == 0 {
+		return nil
+	}
+	stored := make(models.PlatformOverrides, len(overrides))
+	for platform, override := range overrides {
+		stored[platform] = models.NotificationTemplateOverride{Title: override.Title, Body: override.Body, Data: override.Data}
+	}
+	return stored
+}
+
+// applyTemplate fills in the title and body a send request leaves empty by rendering the
+// requested template, and adds the template's default data under keys the request does not set.
+// It returns the variants of the template's platform overrides that differ from the base message,
+// with the explicit title and body of the request taking precedence over theirs as well.
+// It writes an error response and returns false if the template is missing or cannot be rendered.
+func (h *NotificationHandler) applyTemplate(w http.ResponseWriter, r *http.Request, microappID string, req *dto.SendNotificationRequest) (map[string]platformVariant, bool) {
+	stored, err := services.FindNotificationTemplate(h.db, microappID, req.TemplateKey, "")
+	if errors.Is(err, services.ErrNotificationTemplateNotFound) {
+		http.Error(w, errNotificationTemplateNotFound, http.StatusNotFound)
+		return nil, false
+	}
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to fetch notification template", "error", err, "microapp_id", microappID, "template_key", req.TemplateKey)
+		http.Error(w, errFailedToFetchTemplate, http.StatusInternalServerError)
+		return nil, false
+	}
+	variants, err := services.CompilePlatformVariants(stored.TitleTemplate, stored.BodyTemplate, stored.PlatformOverrides)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Stored notification template does not parse", "error", err, "template_id", stored.ID)
+		http.Error(w, errFailedToFetchTemplate, http.StatusInternalServerError)
+		return nil, false
+	}
+	requestData := req.Data
+	ownData := platformsWithOwnData(stored, requestData)
+	messages, _, err := renderVariants(variants, variantPlatforms(variants), req.TemplateVars, ownData)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("%s: %v", errFailedToRenderTemplate, err), http.StatusBadRequest)
+		return nil, false
+	}
+
+	explicitTitle, explicitBody := req.Title, req.Body
+	if req.Title == "" {
+		req.Title = messages[""].title
+	}
+	if req.Body == "" {
+		req.Body = messages[""].body
+	}
+	if data := templateData(stored, "", requestData); len(data) > 0 {
+		req.Data = data
+	}
+	base := renderedMessage{title: req.Title, body: req.Body}
+	overrides := make(map[string]platformVariant)
+	for platform, message := range messages {
+		if platform == "" {
+			continue
+		}
+		if explicitTitle != "" {
+			message.title = explicitTitle
+		}
+		if explicitBody != "" {
+			message.body = explicitBody
+		}
+		if message == base && !ownData[platform] {
+			continue
+		}
+		overrides[platform] = platformVariant{message: message, data: templateData(stored, platform, requestData)}
+	}
+	return overrides, true
+}
+
+// SendTemplateNotification renders one of the calling microapp's templates for each recipient and
+// sends the results. FCM multicast shares one title and body across its tokens, so recipients whose
+// rendered messages are identical are sent together and each distinct message is its own batch.
+// Recipients the template cannot be rendered for (e.g. a missing variable) are skipped and reported.
+func (h *NotificationHandler) SendTemplateNotification(w http.ResponseWriter, r *http.Request) {
+	if h.fcmService == nil {
+		http.Error(w, errNotificationServiceNotAvailable, http.StatusServiceUnavailable)
+		return
+	}
+	if !validateContentType(w, r) {
+		return
+	}
+	limitRequestBody(w, r, 0)
+	var req dto.SendTemplateNotificationRequest
+	if !decodeJSONBody(w, r, &req) {
+		return
+	}
+	if !validateStruct(w, &req) {
+		return
+	}
+	microappID, err := h.getClientID(r)
+	if err != nil {
+		slog.ErrorContext(r.Context(), errClientIDInvalid, "error", err)
+		http.Error(w, errClientIDInvalid, http.StatusUnauthorized)
+		return
+	}
+
+	stored, err := services.FindNotificationTemplate(h.db, microappID, req.TemplateKey, req.Locale)
+	if errors.Is(err, services.ErrNotificationTemplateNotFound) {
+		http.Error(w, errNotificationTemplateNotFound, http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to fetch notification template", "error", err, "microapp_id", microappID, "template_key", req.TemplateKey)
+		http.Error(w, errFailedToFetchTemplate, http.StatusInternalServerError)
+		return
+	}
+	variants, err := services.CompilePlatformVariants(stored.TitleTemplate, stored.BodyTemplate, stored.PlatformOverrides)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Stored notification template does not parse", "error", err, "template_id", stored.ID)
+		http.Error(w, errFailedToFetchTemplate, http.StatusInternalServerError)
+		return
+	}
+	if !h.chargeQuota(w, r, microappID, len(req.Recipients)) {
+		return
+	}
+
+	response := dto.SendTemplateNotificationResponse{Message: msgNotificationsSentSuccessfully}
+	batches := renderTemplateBatches(variants, platformsWithOwnData(stored, req.Data), req.Recipients, &response)
+	status, partial := batchStatus(response.Results, http.StatusOK)
+	response.Partial = partial
+	if len(batches) == 0 {
+		response.Message = msgNoTemplateRecipientsRendered
+		writeJSON(w, status, response)
+		return
+	}
+
+	dataByPlatform := make(map[string]map[string]string, len(variants))
+	for platform := range variants {
+		dataByPlatform[platform] = h.prepareFCMData(templateData(stored, platform, req.Data), microappID)
+	}
+	for _, batch := range batches {
+		recipients, skipped, err := services.FilterOptedOutRecipients(h.db, microappID, batch.userEmails)
+		if err != nil {
+			slog.ErrorContext(r.Context(), "Failed to check notification preferences", "error", err, "microapp_id", microappID)
+			http.Error(w, errFailedToCheckPreferences, http.StatusInternalServerError)
+			return
+		}
+		response.SkippedOptedOut += skipped
+		batch.userEmails = recipients
+		if len(recipients) == 0 {
+			continue
+		}
+		devices, err := h.getActiveDevices(batch.userEmails)
+		if err != nil {
+			slog.ErrorContext(r.Context(), "Failed to fetch device tokens", "error", err)
+			http.Error(w, errFailedToFetchDeviceTokens, http.StatusInternalServerError)
+			return
+		}
+		if len(devices) == 0 {
+			continue
+		}
+		var successCount, failureCount int
+		var deadTokens []string
+		var report deliveryReport
+		for _, group := range groupDevicesByVariant(devices, batch.messages) {
+			message := batch.messages[group.platform]
+			success, failure, dead, groupReport, err := h.sendToDevices(r.Context(), group.devices, message.title, message.body, dataByPlatform[group.platform])
+			if err != nil {
+				slog.ErrorContext(r.Context(), "Failed to send template notifications", "error", err, "template_key", req.TemplateKey, "platform", group.platform)
+				http.Error(w, errFailedToSendNotifications, http.StatusInternalServerError)
+				return
+			}
+			successCount += success
+			failureCount += failure
+			deadTokens = append(deadTokens, dead...)
+			report = report.merge(groupReport)
+			response.Batches++
+		}
+		services.PruneInvalidTokens(r.Context(), h.invalidTokens, deadTokens)
+		logStatus := statusSent
+		if failureCount > 0 {
+			logStatus = statusPartialFailure
+		}
+		base := batch.messages[""]
+		h.logNotifications(r.Context(), batch.userEmails, base.title, base.body, microappID, logStatus, req.Data, "", report)
+		response.Success += successCount
+		response.Failed += failureCount
+	}
+
+	slog.InfoContext(r.Context(), "Template notifications sent",
+		"template_key", req.TemplateKey,
+		"batches", response.Batches,
+		"success", response.Success,
+		"failed", response.Failed,
+		"render_failures", len(response.RenderFailures),
+		"microapp_id", microappID)
+	writeJSON(w, status, response)
+}
+
+// renderedMessage is a title and body rendered for a recipient.
+type renderedMessage struct {
+	title string
+	body  string
+}
+
+// platformVariant is the message and data a platform's override sends in place of the base.
+type platformVariant struct {
+	message renderedMessage
+	data    map[string]interface{}
+}
+
+// templateBatch is the messages rendered identically for a group of users. The base message is
+// under the empty platform; a platform is only listed if its override renders differently or
+// sends data of its own.
+type templateBatch struct {
+	messages   map[string]renderedMessage
+	userEmails []string
+}
+
+// renderTemplateBatches renders the template variants for each recipient and groups recipients
+// with identical output, in the order each message first appears. A recipient listed more than
+// once is rendered with their first entry. Each distinct recipient gets a result in the
+// response, and render failures are also listed in RenderFailures. A recipient fails if any
+// variant cannot be rendered, since their devices' platforms are not known yet.
+func renderTemplateBatches(variants map[string]*services.CompiledNotificationTemplate, ownData map[string]bool, recipients []dto.TemplateRecipient, response *dto.SendTemplateNotificationResponse) []*templateBatch {
+	platforms := variantPlatforms(variants)
+	var batches []*templateBatch
+	byMessages := make(map[string]*templateBatch)
+	seen := make(map[string]struct{}, len(recipients))
+	for i, recipient := range recipients {
+		if _, ok := seen[recipient.UserEmail]; ok {
+			continue
+		}
+		seen[recipient.UserEmail] = struct{}{}
+
+		messages, key, err := renderVariants(variants, platforms, recipient.Variables, ownData)
+		if err != nil {
+			response.Failed++
+			response.RenderFailures = append(response.RenderFailures, dto.TemplateRenderFailure{UserEmail: recipient.UserEmail, Error: err.Error()})
+			response.Results = append(response.Results, dto.BatchItemResult{Index: i, Key: recipient.UserEmail, Status: dto.BatchItemFailed, Error: err.Error()})
+			continue
+		}
+		response.Results = append(response.Results, dto.BatchItemResult{Index: i, Key: recipient.UserEmail, Status: dto.BatchItemSucceeded})
+		batch, ok := byMessages[key]
+		if !ok {
+			batch = &templateBatch{messages: messages}
+			byMessages[key] = batch
+			batches = append(batches, batch)
+		}
+		batch.userEmails = append(batch.userEmails, recipient.UserEmail)
+	}
+	return batches
+}
+
+// variantPlatforms returns the platforms of the variants in order, the base "" first.
+func variantPlatforms(variants map[string]*services.CompiledNotificationTemplate) []string {
+	platforms := make([]string, 0, len(variants))
+	for platform := range variants {
+		platforms = append(platforms, platform)
+	}
+	sort.Strings(platforms)
+	return platforms
+}
+
+// platformsWithOwnData returns the platforms whose override sends different data than the base,
+// so their devices need a batch of their own even when the message renders the same.
+func platformsWithOwnData(tmpl models.NotificationTemplate, requestData map[string]interface{}) map[string]bool {
+	base := templateData(tmpl, "", requestData)
+	ownData := make(map[string]bool)
+	for platform := range tmpl.PlatformOverrides {
+		if !reflect.DeepEqual(templateData(tmpl, platform, requestData), base) {
+			ownData[platform] = true
+		}
+	}
+	return ownData
+}
+
+// renderVariants renders every variant in platform order, dropping overrides that render the same
+// as the base unless they send their own data, and returns the messages with a key that is equal
+// for equal sets of messages.
+func renderVariants(variants map[string]*services.CompiledNotificationTemplate, platforms []string, vars map[string]string, ownData map[string]bool) (map[string]renderedMessage, string, error) {
+	messages := make(map[string]renderedMessage, len(platforms))
+	var key strings.Builder
+	for _, platform := range platforms {
+		title, body, err := variants[platform].Render(vars)
+		if err != nil {
+			if platform != "" {
+				err = fmt.Errorf("%s override: %w", platform, err)
+			}
+			return nil, "", err
+		}
+		message := renderedMessage{title: title, body: body}
+		if platform != "" && message == messages[""] && !ownData[platform] {
+			continue
+		}
+		messages[platform] = message
+		fmt.Fprintf(&key, "%s\x00%s\x00%s\x00", platform, title, body)
+	}
+	return messages, key.String(), nil
+}
+
+// deviceGroup is the devices that receive the same variant of a templated message.
+type deviceGroup struct {
+	platform string
+	devices  []models.DeviceToken
+}
+
+// groupDevicesByVariant splits devices by the variant their platform receives: the platform's
+// own variant if there is one, the base otherwise. The base group comes first.
+func groupDevicesByVariant[V any](devices []models.DeviceToken, variants map[string]V) []deviceGroup {
+	byPlatform := make(map[string][]models.DeviceToken)
+	for _, device := range devices {
+		platform := device.Platform
+		if _, ok := variants[platform]; !ok {
+			platform = ""
+		}
+		byPlatform[platform] = append(byPlatform[platform], device)
+	}
+	platforms := make([]string, 0, len(byPlatform))
+	for platform := range byPlatform {
+		platforms = append(platforms, platform)
+	}
+	sort.Strings(platforms)
+	groups := make([]deviceGroup, len(platforms))
+	for i, platform := range platforms {
+		groups[i] = deviceGroup{platform: platform, devices: byPlatform[platform]}
+	}
+	return groups
+}
+
+// templateData returns the data sent to a platform: the request's data, then the platform
+// override's data, then the template's default data, each only under keys not yet set.
+func templateData(tmpl models.NotificationTemplate, platform string, requestData map[string]interface{}) map[string]interface{} {
+	data := make(map[string]interface{}, len(requestData)+len(tmpl.DefaultData))
+	for _, source := range []map[string]interface{}{requestData, tmpl.PlatformOverrides[platform].Data, tmpl.DefaultData} {
+		for key, value := range source {
+			if _, ok := data[key]; !ok {
+				data[key] = value
+			}
+		}
+	}
+	return data
+}
@@ -0,0 +1,591 @@
+// Copyright (c) 2025 WSO2 LLC. (https://www.wso2.com).
+//
+// WSO2 LLC. licenses this file to you under the Apache License,
+// Version 2.0 (the "License"); you may not use this file except
+// in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+package handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/opensuperapp/opensuperapp/backend-services/core/internal/api/v1/dto"
+	"github.com/opensuperapp/opensuperapp/backend-services/core/internal/auth"
+	"github.com/opensuperapp/opensuperapp/backend-services/core/internal/models"
+	"github.com/opensuperapp/opensuperapp/backend-services/core/internal/services"
+	"gorm.io/gorm"
+)
+
+// batchRecordingService records the title, body and tokens of every multicast send
+type batchRecordingService struct {
+	fakeNotificationService
+	sends []recordedSend
+	data  []map[string]string // The data of each send, in order
+}
+
+type recordedSend struct {
+	title, body string
+	tokens      []string
+}
+
+func (f *batchRecordingService) SendMulticastNotification(ctx context.Context, tokens []string, title string, body string, data map[string]string) (int, int, []string, error) {
+	sorted := append([]string(nil), tokens...)
+	sort.Strings(sorted)
+	f.sends = append(f.sends, recordedSend{title: title, body: body, tokens: sorted})
+	f.data = append(f.data, data)
+	f.lastData = data
+	return len(tokens), 0, nil, nil
+}
+
+// setupTemplateTestDB returns a test database with notification templates and one device per user
+func setupTemplateTestDB(t *testing.T, emails ...string) *gorm.DB {
+	t.Helper()
+	db := setupTestDB(t)
+	if err := db.AutoMigrate(&models.NotificationTemplate{}); err != nil {
+		t.Fatalf("Failed to migrate notification templates: %v", err)
+	}
+	for _, email := range emails {
+		token := models.DeviceToken{UserEmail: email, DeviceToken: email + "-token", Platform: "android", IsActive: true}
+		if err := db.Create(&token).Error; err != nil {
+			t.Fatalf("Failed to seed device token: %v", err)
+		}
+	}
+	return db
+}
+
+func newServiceJSONRequest(t *testing.T, method, target string, payload any) *http.Request {
+	t.Helper()
+	body, err := json.Marshal(payload)
+	if err != nil {
+		t.Fatalf("Failed to marshal request: %v", err)
+	}
+	req := httptest.NewRequest(method, target, bytes.NewReader(body))
+	req.Header.Set(headerContentType, contentTypeJSON)
+	return auth.SetServiceInfo(req, &auth.ServiceInfo{ClientID: "app-1"})
+}
+
+// TestUpsertNotificationTemplate tests that templates are created, replaced and validated
+func TestUpsertNotificationTemplate(t *testing.T) {
+	db := setupTemplateTestDB(t)
+	h := NewNotificationHandler(db, &fakeNotificationService{}, nil, services.SenderIdentity{})
+
+	for _, title := range []string{"Hello {{.firstName}}", "Hi {{.firstName}}"} {
+		w := httptest.NewRecorder()
+		h.UpsertNotificationTemplate(w, newServiceJSONRequest(t, http.MethodPut, "/notifications/templates",
+			dto.UpsertNotificationTemplateRequest{TemplateKey: "welcome", Title: title, Body: "Welcome to {{.team}}"}))
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+		}
+	}
+	var stored []models.NotificationTemplate
+	db.Find(&stored)
+	if len(stored) != 1 || stored[0].TitleTemplate != "Hi {{.firstName}}" || stored[0].MicroappID != "app-1" {
+		t.Fatalf("Expected one replaced template owned by app-1, got %+v", stored)
+	}
+
+	w := httptest.NewRecorder()
+	h.UpsertNotificationTemplate(w, newServiceJSONRequest(t, http.MethodPut, "/notifications/templates",
+		dto.UpsertNotificationTemplateRequest{TemplateKey: "broken", Title: "Hi {{.firstName", Body: "Body"}))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for a template that does not parse, got %d", w.Code)
+	}
+}
+
+// TestSendTemplateNotification tests that recipients with identical rendered messages share a multicast batch
+func TestSendTemplateNotification(t *testing.T) {
+	db := setupTemplateTestDB(t, "alice@example.com", "bob@example.com", "carol@example.com", "dave@example.com")
+	templates := []models.NotificationTemplate{
+		{MicroappID: "app-1", TemplateKey: "shift", TitleTemplate: "Shift update", BodyTemplate: "Your shift starts at {{.time}}"},
+		{MicroappID: "app-1", TemplateKey: "shift", Locale: "fr", TitleTemplate: "Mise à jour", BodyTemplate: "Votre service commence à {{.time}}"},
+		{MicroappID: "app-2", TemplateKey: "shift", TitleTemplate: "Other app", BodyTemplate: "Not yours"},
+	}
+	if err := db.Create(&templates).Error; err != nil {
+		t.Fatalf("Failed to seed templates: %v", err)
+	}
+	svc := &batchRecordingService{}
+	h := NewNotificationHandler(db, svc, nil, services.SenderIdentity{})
+
+	w := httptest.NewRecorder()
+	h.SendTemplateNotification(w, newServiceJSONRequest(t, http.MethodPost, "/notifications/send-template", dto.SendTemplateNotificationRequest{
+		TemplateKey: "shift",
+		Locale:      "en-US",
+		Recipients: []dto.TemplateRecipient{
+			{UserEmail: "alice@example.com", Variables: map[string]string{"time": "09:00"}},
+			{UserEmail: "bob@example.com", Variables: map[string]string{"time": "13:00"}},
+			{UserEmail: "carol@example.com", Variables: map[string]string{"time": "09:00"}},
+			{UserEmail: "dave@example.com"},
+		},
+	}))
+	if w.Code != http.StatusMultiStatus {
+		t.Fatalf("Expected status 207, got %d: %s", w.Code, w.Body.String())
+	}
+
+	var resp dto.SendTemplateNotificationResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if resp.Batches != 2 || resp.Success != 3 || resp.Failed != 1 {
+		t.Errorf("Expected 2 batches, 3 success and 1 failed, got %+v", resp)
+	}
+	if len(resp.RenderFailures) != 1 || resp.RenderFailures[0].UserEmail != "dave@example.com" {
+		t.Errorf("Expected dave to be reported as a render failure, got %+v", resp.RenderFailures)
+	}
+	if !resp.Partial || len(resp.Results) != 4 || resp.Results[0].Status != dto.BatchItemSucceeded ||
+		resp.Results[3].Status != dto.BatchItemFailed || resp.Results[3].Key != "dave@example.com" {
+		t.Errorf("Expected a partial result with dave failed, got %+v", resp.Results)
+	}
+
+	want := []recordedSend{
+		{title: "Shift update", body: "Your shift starts at 09:00", tokens: []string{"alice@example.com-token", "carol@example.com-token"}},
+		{title: "Shift update", body: "Your shift starts at 13:00", tokens: []string{"bob@example.com-token"}},
+	}
+	if !reflect.DeepEqual(svc.sends, want) {
+		t.Errorf("Unexpected sends:\n got %+v\nwant %+v", svc.sends, want)
+	}
+
+	var logged int64
+	db.Model(&models.NotificationLog{}).Count(&logged)
+	if logged != 3 {
+		t.Errorf("Expected 3 notification log entries, got %d", logged)
+	}
+
+	w = httptest.NewRecorder()
+	h.SendTemplateNotification(w, newServiceJSONRequest(t, http.MethodPost, "/notifications/send-template", dto.SendTemplateNotificationRequest{
+		TemplateKey: "shift",
+		Recipients:  []dto.TemplateRecipient{{UserEmail: "dave@example.com"}},
+	}))
+	resp = dto.SendTemplateNotificationResponse{}
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	if w.Code != http.StatusBadRequest || resp.Partial || len(resp.Results) != 1 || resp.Results[0].Status != dto.BatchItemFailed {
+		t.Errorf("Expected a 400 when no recipient renders, got %d %+v", w.Code, resp)
+	}
+}
+
+// TestSendTemplateNotification_Locale tests that the closest translation is used and unknown keys are rejected
+func TestSendTemplateNotification_Locale(t *testing.T) {
+	db := setupTemplateTestDB(t, "alice@example.com")
+	templates := []models.NotificationTemplate{
+		{MicroappID: "app-1", TemplateKey: "shift", TitleTemplate: "Shift update", BodyTemplate: "Starts at {{.time}}"},
+		{MicroappID: "app-1", TemplateKey: "shift", Locale: "fr", TitleTemplate: "Mise à jour", BodyTemplate: "Commence à {{.time}}"},
+	}
+	if err := db.Create(&templates).Error; err != nil {
+		t.Fatalf("Failed to seed templates: %v", err)
+	}
+	svc := &batchRecordingService{}
+	h := NewNotificationHandler(db, svc, nil, services.SenderIdentity{})
+	recipients := []dto.TemplateRecipient{{UserEmail: "alice@example.com", Variables: map[string]string{"time": "09:00"}}}
+
+	w := httptest.NewRecorder()
+	h.SendTemplateNotification(w, newServiceJSONRequest(t, http.MethodPost, "/notifications/send-template",
+		dto.SendTemplateNotificationRequest{TemplateKey: "shift", Locale: "fr-CA", Recipients: recipients}))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var resp dto.SendTemplateNotificationResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil || resp.Partial || len(resp.Results) != 1 {
+		t.Errorf("Expected a non-partial result for the only recipient, got %s", w.Body.String())
+	}
+	if len(svc.sends) != 1 || svc.sends[0].body != "Commence à 09:00" {
+		t.Errorf("Expected the fr translation to be used for fr-CA, got %+v", svc.sends)
+	}
+
+	w = httptest.NewRecorder()
+	h.SendTemplateNotification(w, newServiceJSONRequest(t, http.MethodPost, "/notifications/send-template",
+		dto.SendTemplateNotificationRequest{TemplateKey: "missing", Recipients: recipients}))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404 for an unknown template, got %d", w.Code)
+	}
+}
+
+// TestSendTemplateNotification_PlatformOverrides tests that devices get their platform's override and other platforms the base template
+func TestSendTemplateNotification_PlatformOverrides(t *testing.T) {
+	db := setupTemplateTestDB(t, "alice@example.com", "bob@example.com")
+	if err := db.Create(&models.DeviceToken{UserEmail: "bob@example.com", DeviceToken: "bob-ios-token", Platform: "ios", IsActive: true}).Error; err != nil {
+		t.Fatalf("Failed to seed device token: %v", err)
+	}
+	tmpl := models.NotificationTemplate{
+		MicroappID: "app-1", TemplateKey: "shift", TitleTemplate: "Shift update", BodyTemplate: "Your shift starts at {{.time}}",
+		DefaultData: models.JSONMap{"screen": "shifts"},
+		PlatformOverrides: models.PlatformOverrides{
+			"ios": {Body: "Starts {{.time}}", Data: models.JSONMap{"sound": "chime"}},
+			"web": {Title: "Shift update"},
+		},
+	}
+	if err := db.Create(&tmpl).Error; err != nil {
+		t.Fatalf("Failed to seed template: %v", err)
+	}
+	svc := &batchRecordingService{}
+	h := NewNotificationHandler(db, svc, nil, services.SenderIdentity{})
+
+	w := httptest.NewRecorder()
+	h.SendTemplateNotification(w, newServiceJSONRequest(t, http.MethodPost, "/notifications/send-template", dto.SendTemplateNotificationRequest{
+		TemplateKey: "shift",
+		Recipients: []dto.TemplateRecipient{
+			{UserEmail: "alice@example.com", Variables: map[string]string{"time": "09:00"}},
+			{UserEmail: "bob@example.com", Variables: map[string]string{"time": "09:00"}},
+		},
+	}))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var resp dto.SendTemplateNotificationResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if resp.Batches != 2 || resp.Success != 3 {
+		t.Errorf("Expected 2 batches and 3 successful devices, got %+v", resp)
+	}
+
+	want := []recordedSend{
+		{title: "Shift update", body: "Your shift starts at 09:00", tokens: []string{"alice@example.com-token", "bob@example.com-token"}},
+		{title: "Shift update", body: "Starts 09:00", tokens: []string{"bob-ios-token"}},
+	}
+	if !reflect.DeepEqual(svc.sends, want) {
+		t.Errorf("Unexpected sends:\n got %+v\nwant %+v", svc.sends, want)
+	}
+	if svc.lastData["sound"] != "chime" || svc.lastData["screen"] != "shifts" {
+		t.Errorf("Expected the ios override's data over the default data, got %v", svc.lastData)
+	}
+}
+
+// TestSendTemplateNotification_DataOnlyOverride tests that an override changing only the data still gets its own batch
+func TestSendTemplateNotification_DataOnlyOverride(t *testing.T) {
+	db := setupTemplateTestDB(t, "alice@example.com")
+	if err := db.Create(&models.DeviceToken{UserEmail: "alice@example.com", DeviceToken: "alice-ios-token", Platform: "ios", IsActive: true}).Error; err != nil {
+		t.Fatalf("Failed to seed device token: %v", err)
+	}
+	tmpl := models.NotificationTemplate{
+		MicroappID: "app-1", TemplateKey: "shift", TitleTemplate: "Shift update", BodyTemplate: "Starts {{.time}}",
+		PlatformOverrides: models.PlatformOverrides{"ios": {Data: models.JSONMap{"sound": "chime"}}},
+	}
+	if err := db.Create(&tmpl).Error; err != nil {
+		t.Fatalf("Failed to seed template: %v", err)
+	}
+	svc := &batchRecordingService{}
+	h := NewNotificationHandler(db, svc, nil, services.SenderIdentity{})
+
+	w := httptest.NewRecorder()
+	h.SendTemplateNotification(w, newServiceJSONRequest(t, http.MethodPost, "/notifications/send-template", dto.SendTemplateNotificationRequest{
+		TemplateKey: "shift",
+		Recipients:  []dto.TemplateRecipient{{UserEmail: "alice@example.com", Variables: map[string]string{"time": "09:00"}}},
+	}))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	want := []recordedSend{
+		{title: "Shift update", body: "Starts 09:00", tokens: []string{"alice@example.com-token"}},
+		{title: "Shift update", body: "Starts 09:00", tokens: []string{"alice-ios-token"}},
+	}
+	if !reflect.DeepEqual(svc.sends, want) {
+		t.Fatalf("Unexpected sends:\n got %+v\nwant %+v", svc.sends, want)
+	}
+	if _, ok := svc.data[0]["sound"]; ok {
+		t.Errorf("Expected the android batch without the ios data, got %v", svc.data[0])
+	}
+	if svc.data[1]["sound"] != "chime" {
+		t.Errorf("Expected the ios batch to carry the override's data, got %v", svc.data[1])
+	}
+}
+
+// TestUpsertNotificationTemplate_PlatformOverrides tests that overrides are stored and unknown platforms or broken overrides are rejected
+func TestUpsertNotificationTemplate_PlatformOverrides(t *testing.T) {
+	db := setupTemplateTestDB(t)
+	h := NewNotificationHandler(db, &fakeNotificationService{}, nil, services.SenderIdentity{})
+
+	upsert := func(overrides map[string]dto.NotificationTemplateOverride) int {
+		w := httptest.NewRecorder()
+		h.UpsertNotificationTemplate(w, newServiceJSONRequest(t, http.MethodPut, "/notifications/templates",
+			dto.UpsertNotificationTemplateRequest{TemplateKey: "welcome", Title: "Hello", Body: "Welcome {{.name}}", PlatformOverrides: overrides}))
+		return w.Code
+	}
+	if code := upsert(map[string]dto.NotificationTemplateOverride{"android": {Body: "Hi {{.name}}"}}); code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", code)
+	}
+	var stored models.NotificationTemplate
+	db.First(&stored)
+	if stored.PlatformOverrides["android"].Body != "Hi {{.name}}" {
+		t.Errorf("Expected the android override to be stored, got %+v", stored.PlatformOverrides)
+	}
+	if code := upsert(map[string]dto.NotificationTemplateOverride{"blackberry": {Body: "Hi"}}); code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for an unknown platform, got %d", code)
+	}
+	if code := upsert(map[string]dto.NotificationTemplateOverride{"ios": {Body: "Hi {{.name"}}); code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for an override that does not parse, got %d", code)
+	}
+}
+
+// TestSendNotification_Template tests that a stored template fills in the empty title and body and its default data
+func TestSendNotification_Template(t *testing.T) {
+	db := setupTemplateTestDB(t, "alice@example.com")
+	tmpl := models.NotificationTemplate{
+		MicroappID:    "app-1",
+		TemplateKey:   "payslip",
+		TitleTemplate: "Payslip for {{.month}}",
+		BodyTemplate:  "Your {{.month}} payslip is ready",
+		DefaultData:   models.JSONMap{"screen": "payslips", "tab": "latest"},
+	}
+	if err := db.Create(&tmpl).Error; err != nil {
+		t.Fatalf("Failed to seed template: %v", err)
+	}
+	svc := &batchRecordingService{}
+	h := NewNotificationHandler(db, svc, nil, services.SenderIdentity{})
+
+	w := httptest.NewRecorder()
+	h.SendNotification(w, newServiceJSONRequest(t, http.MethodPost, "/notifications/send", dto.SendNotificationRequest{
+		UserEmails:   []string{"alice@example.com"},
+		Body:         "Payslips are out",
+		Data:         map[string]interface{}{"screen": "home"},
+		TemplateKey:  "payslip",
+		TemplateVars: map[string]string{"month": "May"},
+	}))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if len(svc.sends) != 1 || svc.sends[0].title != "Payslip for May" || svc.sends[0].body != "Payslips are out" {
+		t.Fatalf("Expected the rendered title and the explicit body, got %+v", svc.sends)
+	}
+	if svc.lastData["screen"] != "home" || svc.lastData["tab"] != "latest" {
+		t.Errorf("Expected request data to take precedence over the template defaults, got %v", svc.lastData)
+	}
+
+	w = httptest.NewRecorder()
+	h.SendNotification(w, newServiceJSONRequest(t, http.MethodPost, "/notifications/send", dto.SendNotificationRequest{
+		UserEmails:  []string{"alice@example.com"},
+		TemplateKey: "payslip",
+	}))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for a missing template variable, got %d", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	h.SendNotification(w, newServiceJSONRequest(t, http.MethodPost, "/notifications/send", dto.SendNotificationRequest{
+		UserEmails:  []string{"alice@example.com"},
+		TemplateKey: "missing",
+	}))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404 for an unknown template, got %d", w.Code)
+	}
+	if len(svc.sends) != 1 {
+		t.Errorf("Expected failed renders not to send, got %d sends", len(svc.sends))
+	}
+}
+
+// TestSendNotification_TemplatePlatformOverrides tests that the send endpoint applies a template's platform overrides
+func TestSendNotification_TemplatePlatformOverrides(t *testing.T) {
+	db := setupTemplateTestDB(t, "alice@example.com")
+	for _, device := range []models.DeviceToken{
+		{UserEmail: "alice@example.com", DeviceToken: "alice-ios-token", Platform: "ios", IsActive: true},
+		{UserEmail: "alice@example.com", DeviceToken: "alice-web-token", Platform: "web", IsActive: true},
+	} {
+		if err := db.Create(&device).Error; err != nil {
+			t.Fatalf("Failed to seed device token: %v", err)
+		}
+	}
+	tmpl := models.NotificationTemplate{
+		MicroappID: "app-1", TemplateKey: "payslip", TitleTemplate: "Payslip for {{.month}}", BodyTemplate: "Your {{.month}} payslip is ready",
+		PlatformOverrides: models.PlatformOverrides{
+			"ios": {Body: "{{.month}} payslip ready"},
+			"web": {Data: models.JSONMap{"screen": "payslips"}},
+		},
+	}
+	if err := db.Create(&tmpl).Error; err != nil {
+		t.Fatalf("Failed to seed template: %v", err)
+	}
+	svc := &batchRecordingService{}
+	h := NewNotificationHandler(db, svc, nil, services.SenderIdentity{})
+
+	send := func(req dto.SendNotificationRequest) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		h.SendNotification(w, newServiceJSONRequest(t, http.MethodPost, "/notifications/send", req))
+		return w
+	}
+	if w := send(dto.SendNotificationRequest{UserEmails: []string{"alice@example.com"}, TemplateKey: "payslip", TemplateVars: map[string]string{"month": "May"}}); w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	want := []recordedSend{
+		{title: "Payslip for May", body: "Your May payslip is ready", tokens: []string{"alice@example.com-token"}},
+		{title: "Payslip for May", body: "May payslip ready", tokens: []string{"alice-ios-token"}},
+		{title: "Payslip for May", body: "Your May payslip is ready", tokens: []string{"alice-web-token"}},
+	}
+	if !reflect.DeepEqual(svc.sends, want) {
+		t.Fatalf("Unexpected sends:\n got %+v\nwant %+v", svc.sends, want)
+	}
+	if svc.data[2]["screen"] != "payslips" {
+		t.Errorf("Expected the web send to carry the override's data, got %v", svc.data[2])
+	}
+	if _, ok := svc.data[0]["screen"]; ok {
+		t.Errorf("Expected the base send without the web data, got %v", svc.data[0])
+	}
+
+	// An explicit body takes precedence over the ios override, leaving it the same as the base
+	svc.sends, svc.data = nil, nil
+	if w := send(dto.SendNotificationRequest{UserEmails: []string{"alice@example.com"}, Body: "Payslips are out", TemplateKey: "payslip", TemplateVars: map[string]string{"month": "May"}}); w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if len(svc.sends) != 2 || svc.sends[0].body != "Payslips are out" || len(svc.sends[0].tokens) != 2 {
+		t.Errorf("Expected the ios device in the base batch and the web device on its own, got %+v", svc.sends)
+	}
+
+	future := time.Now().Add(time.Hour)
+	if w := send(dto.SendNotificationRequest{UserEmails: []string{"alice@example.com"}, TemplateKey: "payslip", TemplateVars: map[string]string{"month": "May"}, ScheduledAt: &future}); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for scheduling a template with platform overrides, got %d", w.Code)
+	}
+}
+
+// newTemplateAdminRequest builds an admin request for a micro app's template with the URL params set
+func newTemplateAdminRequest(t *testing.T, method, target, appID, templateKey string, payload any) *http.Request {
+	t.Helper()
+	var body bytes.Buffer
+	if payload != nil {
+		if err := json.NewEncoder(&body).Encode(payload); err != nil {
+			t.Fatalf("Failed to marshal request: %v", err)
+		}
+	}
+	req := httptest.NewRequest(method, target, &body)
+	req.Header.Set(headerContentType, contentTypeJSON)
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add(urlParamAppID, appID)
+	if templateKey != "" {
+		rctx.URLParams.Add(urlParamTemplateKey, templateKey)
+	}
+	req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+	return auth.SetUserInfo(req, &auth.CustomJwtPayload{Email: "admin@example.com"})
+}
+
+// TestNotificationTemplateAdminCRUD tests that admins can manage the templates of a micro app
+func TestNotificationTemplateAdminCRUD(t *testing.T) {
+	db := setupTemplateTestDB(t)
+	if err := db.AutoMigrate(&models.MicroApp{}); err != nil {
+		t.Fatalf("Failed to migrate micro apps: %v", err)
+	}
+	if err := db.Create(&models.MicroApp{MicroAppID: "payroll", Name: "Payroll", CreatedBy: "admin@example.com"}).Error; err != nil {
+		t.Fatalf("Failed to seed micro app: %v", err)
+	}
+	h := NewNotificationHandler(db, nil, nil, services.SenderIdentity{})
+	base := "/micro-apps/payroll/notification-templates/"
+
+	w := httptest.NewRecorder()
+	h.PutNotificationTemplate(w, newTemplateAdminRequest(t, http.MethodPut, base+"payslip", "payroll", "payslip",
+		dto.UpsertNotificationTemplateRequest{Title: "Payslip for {{.month}}", Body: "Ready", DefaultData: map[string]interface{}{"screen": "payslips"}}))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	w = httptest.NewRecorder()
+	h.PutNotificationTemplate(w, newTemplateAdminRequest(t, http.MethodPut, base+"payslip", "payroll", "payslip",
+		dto.UpsertNotificationTemplateRequest{Locale: "fr", Title: "Fiche de paie", Body: "Prête"}))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	h.ListNotificationTemplates(w, newTemplateAdminRequest(t, http.MethodGet, base, "payroll", "", nil))
+	var listed []dto.NotificationTemplateResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &listed); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if len(listed) != 2 || listed[0].Locale != "" || listed[1].Locale != "fr" {
+		t.Fatalf("Expected the default and fr translations, got %+v", listed)
+	}
+	if listed[0].DefaultData["screen"] != "payslips" {
+		t.Errorf("Expected the default data to be stored, got %v", listed[0].DefaultData)
+	}
+
+	w = httptest.NewRecorder()
+	h.DeleteNotificationTemplate(w, newTemplateAdminRequest(t, http.MethodDelete, base+"payslip?locale=fr", "payroll", "payslip", nil))
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("Expected status 204, got %d: %s", w.Code, w.Body.String())
+	}
+	w = httptest.NewRecorder()
+	h.GetNotificationTemplate(w, newTemplateAdminRequest(t, http.MethodGet, base+"payslip?locale=fr", "payroll", "payslip", nil))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404 for the deleted translation, got %d", w.Code)
+	}
+	w = httptest.NewRecorder()
+	h.GetNotificationTemplate(w, newTemplateAdminRequest(t, http.MethodGet, base+"payslip", "payroll", "payslip", nil))
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected the default translation to be kept, got %d", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	h.PutNotificationTemplate(w, newTemplateAdminRequest(t, http.MethodPut, "/micro-apps/unknown/notification-templates/payslip", "unknown", "payslip",
+		dto.UpsertNotificationTemplateRequest{Title: "Title", Body: "Body"}))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404 for an unknown micro app, got %d", w.Code)
+	}
+}
+
+// TestSendNotification_Localized tests that recipients are batched by the copy matching their stored locale
+func TestSendNotification_Localized(t *testing.T) {
+	db := setupTemplateTestDB(t, "alice@example.com", "bob@example.com", "carol@example.com", "dave@example.com")
+	if err := db.AutoMigrate(&models.UserConfig{}); err != nil {
+		t.Fatalf("Failed to migrate user config: %v", err)
+	}
+	for email, locale := range map[string]string{"alice@example.com": `"fr-CA"`, "bob@example.com": `"fr"`, "carol@example.com": `"de"`} {
+		config := models.UserConfig{Email: email, ConfigKey: services.UserConfigKeyLocale, ConfigValue: json.RawMessage(locale), Active: 1, CreatedBy: email, UpdatedBy: email}
+		if err := db.Create(&config).Error; err != nil {
+			t.Fatalf("Failed to seed user locale: %v", err)
+		}
+	}
+	svc := &batchRecordingService{}
+	h := NewNotificationHandler(db, svc, nil, services.SenderIdentity{})
+
+	w := httptest.NewRecorder()
+	h.SendNotification(w, newServiceJSONRequest(t, http.MethodPost, "/notifications/send", dto.SendNotificationRequest{
+		UserEmails: []string{"alice@example.com", "bob@example.com", "carol@example.com", "dave@example.com"},
+		Title:      "Shift update",
+		Body:       "Your shift moved",
+		Localized: map[string]dto.LocalizedContent{
+			"FR": {Title: "Mise à jour", Body: "Votre service a changé"},
+			"es": {Title: "Actualización", Body: "Tu turno cambió"},
+		},
+	}))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	want := []recordedSend{
+		{title: "Shift update", body: "Your shift moved", tokens: []string{"carol@example.com-token", "dave@example.com-token"}},
+		{title: "Mise à jour", body: "Votre service a changé", tokens: []string{"alice@example.com-token", "bob@example.com-token"}},
+	}
+	if !reflect.DeepEqual(svc.sends, want) {
+		t.Errorf("Unexpected sends:\n got %+v\nwant %+v", svc.sends, want)
+	}
+
+	var resp dto.NotificationResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	wantLocales := []dto.LocaleSendResult{
+		{Locale: "", Users: 2, Success: 2},
+		{Locale: "FR", Users: 2, Success: 2},
+	}
+	if resp.Success != 4 || !reflect.DeepEqual(resp.Locales, wantLocales) {
+		t.Errorf("Unexpected response: %+v", resp)
+	}
+
+	var log models.NotificationLog
+	if err := db.Where("user_email = ?", "bob@example.com").First(&log).Error; err != nil {
+		t.Fatalf("Failed to load notification log: %v", err)
+	}
+	if log.Title == nil || *log.Title != "Mise à jour" {
+		t.Errorf("Expected the localized title to be logged, got %v", log.Title)
+	}
+}
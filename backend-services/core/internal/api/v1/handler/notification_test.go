@@ -0,0 +1,1916 @@
+// Copyright (c) 2025 WSO2 LLC. (https://www.wso2.com).
+//
+// WSO2 LLC. licenses this file to you under the Apache License,
+// Version 2.0 (the "License"); you may not use this file except
+// in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+package handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/opensuperapp/opensuperapp/backend-services/core/internal/api/v1/dto"
+	"github.com/opensuperapp/opensuperapp/backend-services/core/internal/auth"
+	"github.com/opensuperapp/opensuperapp/backend-services/core/internal/clocktest"
+	"github.com/opensuperapp/opensuperapp/backend-services/core/internal/metrics"
+	"github.com/opensuperapp/opensuperapp/backend-services/core/internal/models"
+	"github.com/opensuperapp/opensuperapp/backend-services/core/internal/services"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// setupTestDB creates an in-memory SQLite database with the notification tables
+func setupTestDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("Failed to open test database: %v", err)
+	}
+
+	if err := db.AutoMigrate(&models.NotificationLog{}, &models.NotificationDelivery{}, &models.NotificationPreference{}); err != nil {
+		t.Fatalf("Failed to migrate database: %v", err)
+	}
+	createDeviceTokensTable(t, db)
+
+	return db
+}
+
+// createDeviceTokensTable creates device_tokens by hand, since its MySQL enum column cannot be parsed by SQLite
+func createDeviceTokensTable(t *testing.T, db *gorm.DB) {
+	t.Helper()
+	if err := db.Exec(`CREATE TABLE device_tokens (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		user_email VARCHAR(255) NOT NULL,
+		device_token TEXT NOT NULL,
+		platform VARCHAR(10) NOT NULL,
+		app_build INTEGER,
+		last_seen_at DATETIME,
+		created_at DATETIME,
+		updated_at DATETIME,
+		is_active BOOLEAN NOT NULL DEFAULT 1
+	)`).Error; err != nil {
+		t.Fatalf("Failed to create device_tokens table: %v", err)
+	}
+}
+
+// seedNotificationLog inserts a sent log entry for email sent at sentAt with the given dedup key
+func seedNotificationLog(t *testing.T, db *gorm.DB, email, microappID, dedupKey string, sentAt time.Time) *models.NotificationLog {
+	status := statusSent
+	log := models.NotificationLog{
+		UserEmail:  email,
+		MicroappID: &microappID,
+		DedupKey:   &dedupKey,
+		SentAt:     sentAt,
+		Status:     &status,
+	}
+	if err := db.Create(&log).Error; err != nil {
+		t.Fatalf("Failed to seed notification log: %v", err)
+	}
+	return &log
+}
+
+// TestFilterDuplicateRecipients_WithinWindow tests that recipients notified inside the window are skipped
+func TestFilterDuplicateRecipients_WithinWindow(t *testing.T) {
+	db := setupTestDB(t)
+	h := &NotificationHandler{db: db}
+
+	seedNotificationLog(t, db, "alice@example.com", "app-1", "order-42", time.Now().Add(-5*time.Minute))
+
+	remaining, skipped, err := h.filterDuplicateRecipients("app-1", "order-42",
+		[]string{"alice@example.com", "bob@example.com"}, time.Hour)
+	if err != nil {
+		t.Fatalf("filterDuplicateRecipients failed: %v", err)
+	}
+
+	if skipped != 1 {
+		t.Errorf("Expected 1 skipped recipient, got %d", skipped)
+	}
+	if len(remaining) != 1 || remaining[0] != "bob@example.com" {
+		t.Errorf("Expected only bob@example.com to remain, got %v", remaining)
+	}
+}
+
+// TestFilterDuplicateRecipients_OutsideWindow tests that recipients notified before the window are not skipped
+func TestFilterDuplicateRecipients_OutsideWindow(t *testing.T) {
+	db := setupTestDB(t)
+	h := &NotificationHandler{db: db}
+
+	seedNotificationLog(t, db, "alice@example.com", "app-1", "order-42", time.Now().Add(-2*time.Hour))
+
+	remaining, skipped, err := h.filterDuplicateRecipients("app-1", "order-42",
+		[]string{"alice@example.com"}, time.Hour)
+	if err != nil {
+		t.Fatalf("filterDuplicateRecipients failed: %v", err)
+	}
+
+	if skipped != 0 {
+		t.Errorf("Expected no skipped recipients, got %d", skipped)
+	}
+	if len(remaining) != 1 || remaining[0] != "alice@example.com" {
+		t.Errorf("Expected alice@example.com to remain, got %v", remaining)
+	}
+}
+
+// TestFilterDuplicateRecipients_FailedSend tests that a send that reached none of a recipient's devices does not count
+func TestFilterDuplicateRecipients_FailedSend(t *testing.T) {
+	db := setupTestDB(t)
+	h := &NotificationHandler{db: db}
+
+	recent := time.Now().Add(-time.Minute)
+	failed := seedNotificationLog(t, db, "alice@example.com", "app-1", "order-42", recent)
+	if err := db.Model(failed).Update("delivery_status", models.DeliveryStatusFailed).Error; err != nil {
+		t.Fatalf("Failed to mark log failed: %v", err)
+	}
+	partial := seedNotificationLog(t, db, "bob@example.com", "app-1", "order-42", recent)
+	if err := db.Model(partial).Update("status", statusPartialFailure).Error; err != nil {
+		t.Fatalf("Failed to mark log partially failed: %v", err)
+	}
+
+	remaining, skipped, err := h.filterDuplicateRecipients("app-1", "order-42",
+		[]string{"alice@example.com", "bob@example.com"}, time.Hour)
+	if err != nil {
+		t.Fatalf("filterDuplicateRecipients failed: %v", err)
+	}
+
+	if skipped != 1 {
+		t.Errorf("Expected 1 skipped recipient, got %d", skipped)
+	}
+	if len(remaining) != 1 || remaining[0] != "alice@example.com" {
+		t.Errorf("Expected only alice@example.com to remain, got %v", remaining)
+	}
+}
+
+// TestFilterDuplicateRecipients_ScopedToKeyAndMicroapp tests that other dedup keys and microapps do not match
+func TestFilterDuplicateRecipients_ScopedToKeyAndMicroapp(t *testing.T) {
+	db := setupTestDB(t)
+	h := &NotificationHandler{db: db}
+
+	recent := time.Now().Add(-time.Minute)
+	seedNotificationLog(t, db, "alice@example.com", "app-1", "order-41", recent)
+	seedNotificationLog(t, db, "alice@example.com", "app-2", "order-42", recent)
+
+	_, skipped, err := h.filterDuplicateRecipients("app-1", "order-42",
+		[]string{"alice@example.com"}, time.Hour)
+	if err != nil {
+		t.Fatalf("filterDuplicateRecipients failed: %v", err)
+	}
+
+	if skipped != 0 {
+		t.Errorf("Expected no skipped recipients, got %d", skipped)
+	}
+}
+
+// newUserRequest builds a request authenticated as email, with the notificationID URL param set when non-empty
+func newUserRequest(method, target, email, notificationID string) *http.Request {
+	req := httptest.NewRequest(method, target, nil)
+	if notificationID != "" {
+		rctx := chi.NewRouteContext()
+		rctx.URLParams.Add(urlParamNotifID, notificationID)
+		req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+	}
+	return auth.SetUserInfo(req, &auth.CustomJwtPayload{Email: email})
+}
+
+// TestMarkNotificationRead tests that a user can mark their own notification as read
+func TestMarkNotificationRead(t *testing.T) {
+	db := setupTestDB(t)
+	h := &NotificationHandler{db: db}
+
+	log := models.NotificationLog{UserEmail: "alice@example.com"}
+	if err := db.Create(&log).Error; err != nil {
+		t.Fatalf("Failed to seed notification log: %v", err)
+	}
+
+	w := httptest.NewRecorder()
+	h.MarkNotificationRead(w, newUserRequest(http.MethodPost, "/notifications/1/read", "alice@example.com", "1"))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	var resp dto.MarkNotificationReadResponse
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+
+	var stored models.NotificationLog
+	db.First(&stored, log.ID)
+	if stored.ReadAt == nil {
+		t.Fatal("Expected read_at to be set")
+	}
+	if !stored.ReadAt.Equal(resp.ReadAt) {
+		t.Errorf("Expected response readAt %v to match stored %v", resp.ReadAt, stored.ReadAt)
+	}
+	if stored.DeliveryStatus != models.DeliveryStatusRead {
+		t.Errorf("Expected delivery status %q, got %q", models.DeliveryStatusRead, stored.DeliveryStatus)
+	}
+
+	// Marking again keeps the original timestamp
+	w = httptest.NewRecorder()
+	h.MarkNotificationRead(w, newUserRequest(http.MethodPost, "/notifications/1/read", "alice@example.com", "1"))
+	var again dto.MarkNotificationReadResponse
+	if err := json.NewDecoder(w.Body).Decode(&again); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if !again.ReadAt.Equal(resp.ReadAt) {
+		t.Errorf("Expected readAt to stay %v, got %v", resp.ReadAt, again.ReadAt)
+	}
+}
+
+// TestMarkNotificationRead_Deliveries tests that reading a notification marks every device it reached as read
+func TestMarkNotificationRead_Deliveries(t *testing.T) {
+	db := setupTestDB(t)
+	h := &NotificationHandler{db: db}
+
+	logs := []models.NotificationLog{{UserEmail: "alice@example.com"}, {UserEmail: "alice@example.com"}}
+	if err := db.Create(&logs).Error; err != nil {
+		t.Fatalf("Failed to seed notification logs: %v", err)
+	}
+	deliveries := []models.NotificationDelivery{
+		{NotificationLogID: logs[0].ID, DeviceTokenHash: models.HashDeviceToken("phone"), Status: models.DeliveryStatusDelivered},
+		{NotificationLogID: logs[0].ID, DeviceTokenHash: models.HashDeviceToken("tablet"), Status: models.DeliveryStatusPending},
+		{NotificationLogID: logs[0].ID, DeviceTokenHash: models.HashDeviceToken("old-phone"), Status: models.DeliveryStatusFailed},
+		{NotificationLogID: logs[1].ID, DeviceTokenHash: models.HashDeviceToken("phone"), Status: models.DeliveryStatusDelivered},
+	}
+	if err := db.Create(&deliveries).Error; err != nil {
+		t.Fatalf("Failed to seed deliveries: %v", err)
+	}
+
+	w := httptest.NewRecorder()
+	h.MarkNotificationRead(w, newUserRequest(http.MethodPost, "/notifications/1/read", "alice@example.com", "1"))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	var statuses []string
+	db.Model(&models.NotificationDelivery{}).Order("id").Pluck("status", &statuses)
+	want := []string{models.DeliveryStatusRead, models.DeliveryStatusRead, models.DeliveryStatusFailed, models.DeliveryStatusDelivered}
+	if !reflect.DeepEqual(statuses, want) {
+		t.Errorf("Expected delivery statuses %v, got %v", want, statuses)
+	}
+
+	w = httptest.NewRecorder()
+	h.GetUnreadCount(w, newUserRequest(http.MethodGet, "/notifications/unread-count", "alice@example.com", ""))
+	var resp dto.UnreadCountResponse
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if resp.Count != 1 {
+		t.Errorf("Expected 1 unread notification, got %d", resp.Count)
+	}
+}
+
+// TestMarkNotificationRead_OtherUser tests that a user cannot mark another user's notification
+func TestMarkNotificationRead_OtherUser(t *testing.T) {
+	db := setupTestDB(t)
+	h := &NotificationHandler{db: db}
+
+	log := models.NotificationLog{UserEmail: "alice@example.com"}
+	if err := db.Create(&log).Error; err != nil {
+		t.Fatalf("Failed to seed notification log: %v", err)
+	}
+
+	w := httptest.NewRecorder()
+	h.MarkNotificationRead(w, newUserRequest(http.MethodPost, "/notifications/1/read", "bob@example.com", "1"))
+
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404, got %d", w.Code)
+	}
+
+	var stored models.NotificationLog
+	db.First(&stored, log.ID)
+	if stored.ReadAt != nil {
+		t.Error("Expected read_at to remain unset")
+	}
+}
+
+// TestGetUnreadCount tests that only the caller's unread notifications are counted
+func TestGetUnreadCount(t *testing.T) {
+	db := setupTestDB(t)
+	h := &NotificationHandler{db: db}
+
+	readAt := time.Now()
+	logs := []models.NotificationLog{
+		{UserEmail: "alice@example.com"},
+		{UserEmail: "alice@example.com"},
+		{UserEmail: "alice@example.com", ReadAt: &readAt},
+		{UserEmail: "bob@example.com"},
+	}
+	if err := db.Create(&logs).Error; err != nil {
+		t.Fatalf("Failed to seed notification logs: %v", err)
+	}
+
+	w := httptest.NewRecorder()
+	h.GetUnreadCount(w, newUserRequest(http.MethodGet, "/notifications/unread-count", "alice@example.com", ""))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", w.Code)
+	}
+
+	var resp dto.UnreadCountResponse
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if resp.Count != 2 {
+		t.Errorf("Expected 2 unread notifications, got %d", resp.Count)
+	}
+}
+
+// fakeNotificationService reports every sent token as delivered, except the configured dead tokens.
+// The tokens of the last multicast, and the data payload of the last multicast or topic send, are
+// kept for inspection.
+type fakeNotificationService struct {
+	deadTokens []string
+	lastTokens []string
+	lastData   map[string]string
+	topics     []string         // topics sent to, in order
+	topicErrs  map[string]error // topics whose send fails
+}
+
+func (f *fakeNotificationService) SendMulticastNotification(ctx context.Context, tokens []string, title string, body string, data map[string]string) (int, int, []string, error) {
+	f.lastTokens = tokens
+	f.lastData = data
+	return len(tokens) - len(f.deadTokens), len(f.deadTokens), f.deadTokens, nil
+}
+
+func (f *fakeNotificationService) SendToTopic(ctx context.Context, topic string, title string, body string, data map[string]string) (string, error) {
+	f.topics = append(f.topics, topic)
+	f.lastData = data
+	if err := f.topicErrs[topic]; err != nil {
+		return "", err
+	}
+	return "msg-" + topic, nil
+}
+
+func (f *fakeNotificationService) SubscribeToTopic(ctx context.Context, tokens []string, topic string) (int, int, error) {
+	return len(tokens), 0, nil
+}
+
+func (f *fakeNotificationService) UnsubscribeFromTopic(ctx context.Context, tokens []string, topic string) (int, int, error) {
+	return len(tokens), 0, nil
+}
+
+// TestSendNotification_PrunesDeadTokens tests that tokens reported as unregistered are deactivated
+func TestSendNotification_PrunesDeadTokens(t *testing.T) {
+	db := setupTestDB(t)
+	tokens := []models.DeviceToken{
+		{UserEmail: "alice@example.com", DeviceToken: "live-token", Platform: "android", IsActive: true},
+		{UserEmail: "alice@example.com", DeviceToken: "dead-token", Platform: "ios", IsActive: true},
+	}
+	if err := db.Create(&tokens).Error; err != nil {
+		t.Fatalf("Failed to seed device tokens: %v", err)
+	}
+	h := NewNotificationHandler(db, &fakeNotificationService{deadTokens: []string{"dead-token"}}, nil, services.SenderIdentity{})
+
+	body, _ := json.Marshal(dto.SendNotificationRequest{UserEmails: []string{"alice@example.com"}, Title: "Hi", Body: "Hello"})
+	req := httptest.NewRequest(http.MethodPost, "/notifications/send", bytes.NewReader(body))
+	req.Header.Set(headerContentType, contentTypeJSON)
+	req = auth.SetServiceInfo(req, &auth.ServiceInfo{ClientID: "app-1"})
+	w := httptest.NewRecorder()
+
+	h.SendNotification(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	var stored []models.DeviceToken
+	db.Order("id").Find(&stored)
+	if !stored[0].IsActive {
+		t.Error("Expected live-token to remain active")
+	}
+	if stored[1].IsActive {
+		t.Error("Expected dead-token to be deactivated")
+	}
+}
+
+// recordingInvalidTokenHandler records the tokens it is told are invalid
+type recordingInvalidTokenHandler struct {
+	tokens []string
+}
+
+func (r *recordingInvalidTokenHandler) HandleInvalidTokens(ctx context.Context, tokens []string) error {
+	r.tokens = append(r.tokens, tokens...)
+	return nil
+}
+
+// TestSendNotification_InvalidTokenHandler tests that a custom handler receives the tokens reported as unregistered
+func TestSendNotification_InvalidTokenHandler(t *testing.T) {
+	db := setupTestDB(t)
+	tokens := []models.DeviceToken{
+		{UserEmail: "alice@example.com", DeviceToken: "live-token", Platform: "android", IsActive: true},
+		{UserEmail: "alice@example.com", DeviceToken: "dead-token", Platform: "ios", IsActive: true},
+	}
+	if err := db.Create(&tokens).Error; err != nil {
+		t.Fatalf("Failed to seed device tokens: %v", err)
+	}
+	invalid := &recordingInvalidTokenHandler{}
+	h := NewNotificationHandler(db, &fakeNotificationService{deadTokens: []string{"dead-token"}}, nil, services.SenderIdentity{}).
+		WithInvalidTokenHandler(invalid)
+
+	body, _ := json.Marshal(dto.SendNotificationRequest{UserEmails: []string{"alice@example.com"}, Title: "Hi", Body: "Hello"})
+	req := httptest.NewRequest(http.MethodPost, "/notifications/send", bytes.NewReader(body))
+	req.Header.Set(headerContentType, contentTypeJSON)
+	req = auth.SetServiceInfo(req, &auth.ServiceInfo{ClientID: "app-1"})
+	w := httptest.NewRecorder()
+
+	h.SendNotification(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if !reflect.DeepEqual(invalid.tokens, []string{"dead-token"}) {
+		t.Errorf("Expected the handler to receive [dead-token], got %v", invalid.tokens)
+	}
+	var active int64
+	db.Model(&models.DeviceToken{}).Where("is_active = ?", true).Count(&active)
+	if active != 2 {
+		t.Errorf("Expected the replaced default handler not to deactivate tokens, got %d active", active)
+	}
+}
+
+// detailedNotificationService reports per-token results, failing the tokens listed in failures
+type detailedNotificationService struct {
+	fakeNotificationService
+	failures map[string]string
+}
+
+func (f *detailedNotificationService) SendMulticastDetailed(ctx context.Context, tokens []string, title string, body string, data map[string]string) ([]services.DeliveryResult, error) {
+	f.lastTokens = tokens
+	results := make([]services.DeliveryResult, len(tokens))
+	for i, token := range tokens {
+		results[i] = services.DeliveryResult{Token: token, Success: true, MessageID: "msg-" + token}
+		if reason, ok := f.failures[token]; ok {
+			results[i] = services.DeliveryResult{Token: token, Error: reason}
+		}
+	}
+	return results, nil
+}
+
+// limitedNotificationService sends to at most limit tokens, applying the token limit policy of the context
+type limitedNotificationService struct {
+	fakeNotificationService
+	limit int
+}
+
+func (f *limitedNotificationService) SendMulticastDetailed(ctx context.Context, tokens []string, title string, body string, data map[string]string) ([]services.DeliveryResult, error) {
+	var dropped []string
+	if len(tokens) > f.limit {
+		if services.TokenLimitPolicyFromContext(ctx) == services.TokenLimitReject {
+			return nil, services.ErrTokenLimitExceeded
+		}
+		tokens, dropped = tokens[:f.limit], tokens[f.limit:]
+	}
+	f.lastTokens = tokens
+	results := make([]services.DeliveryResult, len(tokens))
+	for i, token := range tokens {
+		results[i] = services.DeliveryResult{Token: token, Success: true}
+	}
+	return append(results, services.DroppedResults(dropped)...), nil
+}
+
+// TestSendNotification_TokenLimit tests that devices over the send limit are reported as dropped, or the send rejected
+func TestSendNotification_TokenLimit(t *testing.T) {
+	tests := []struct {
+		name        string
+		tokenLimit  string
+		topics      []string
+		wantCode    int
+		wantDropped int
+	}{
+		{"default truncates", "", nil, http.StatusOK, 1},
+		{"truncate", "truncate", nil, http.StatusOK, 1},
+		{"reject", "reject", nil, http.StatusBadRequest, 0},
+		{"reject with topics", "reject", []string{"news"}, http.StatusBadRequest, 0},
+		{"unknown policy", "split", nil, http.StatusBadRequest, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := setupTestDB(t)
+			tokens := []models.DeviceToken{
+				{UserEmail: "alice@example.com", DeviceToken: "alice-phone", Platform: "android", IsActive: true},
+				{UserEmail: "alice@example.com", DeviceToken: "alice-tablet", Platform: "android", IsActive: true},
+				{UserEmail: "bob@example.com", DeviceToken: "bob-phone", Platform: "android", IsActive: true},
+			}
+			if err := db.Create(&tokens).Error; err != nil {
+				t.Fatalf("Failed to seed device tokens: %v", err)
+			}
+			svc := &limitedNotificationService{limit: 2}
+			h := NewNotificationHandler(db, svc, nil, services.SenderIdentity{})
+
+			body, _ := json.Marshal(dto.SendNotificationRequest{
+				UserEmails: []string{"alice@example.com", "bob@example.com"},
+				Topics:     tt.topics,
+				Title:      "Hi",
+				Body:       "Hello",
+				TokenLimit: tt.tokenLimit,
+			})
+			req := httptest.NewRequest(http.MethodPost, "/notifications/send", bytes.NewReader(body))
+			req.Header.Set(headerContentType, contentTypeJSON)
+			req = auth.SetServiceInfo(req, &auth.ServiceInfo{ClientID: "app-1"})
+			w := httptest.NewRecorder()
+
+			h.SendNotification(w, req)
+
+			if w.Code != tt.wantCode {
+				t.Fatalf("Expected status %d, got %d: %s", tt.wantCode, w.Code, w.Body.String())
+			}
+			if w.Code != http.StatusOK {
+				var logged int64
+				db.Model(&models.NotificationLog{}).Count(&logged)
+				if logged != 0 {
+					t.Errorf("Expected a rejected send to log nothing, got %d entries", logged)
+				}
+				return
+			}
+			var resp dto.NotificationResponse
+			if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("Failed to decode response: %v", err)
+			}
+			if resp.Success != 2 || resp.Failed != 1 || resp.Dropped != tt.wantDropped {
+				t.Errorf("Expected 2 success, 1 failed and %d dropped, got %+v", tt.wantDropped, resp)
+			}
+		})
+	}
+}
+
+// TestNotificationHandler_ConfiguredTokenLimit tests that a token limit configured below the default applies to rejected sends and preview warnings
+func TestNotificationHandler_ConfiguredTokenLimit(t *testing.T) {
+	db := setupTestDB(t)
+	seedGroups(t, db, map[string][]string{"engineering": {"alice@example.com", "bob@example.com"}})
+	tokens := []models.DeviceToken{
+		{UserEmail: "alice@example.com", DeviceToken: "alice-phone", Platform: "android", IsActive: true},
+		{UserEmail: "alice@example.com", DeviceToken: "alice-tablet", Platform: "android", IsActive: true},
+		{UserEmail: "bob@example.com", DeviceToken: "bob-phone", Platform: "android", IsActive: true},
+	}
+	if err := db.Create(&tokens).Error; err != nil {
+		t.Fatalf("Failed to seed device tokens: %v", err)
+	}
+	fake := &fakeNotificationService{}
+	h := NewNotificationHandler(db, fake, nil, services.SenderIdentity{}).WithMaxTokensPerSend(2)
+
+	body, _ := json.Marshal(dto.SendNotificationRequest{
+		UserEmails: []string{"alice@example.com", "bob@example.com"},
+		Title:      "Hi",
+		Body:       "Hello",
+		TokenLimit: string(services.TokenLimitReject),
+	})
+	req := httptest.NewRequest(http.MethodPost, "/notifications/send", bytes.NewReader(body))
+	req.Header.Set(headerContentType, contentTypeJSON)
+	req = auth.SetServiceInfo(req, &auth.ServiceInfo{ClientID: "app-1"})
+	w := httptest.NewRecorder()
+
+	h.SendNotification(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("Expected status 400 over the configured limit, got %d: %s", w.Code, w.Body.String())
+	}
+	if fake.lastTokens != nil {
+		t.Errorf("Expected nothing to be sent, got %v", fake.lastTokens)
+	}
+
+	resp := previewGroups(t, h, "engineering")
+	if len(resp.Warnings) != 1 || !strings.Contains(resp.Warnings[0], "only the first 2") {
+		t.Errorf("Expected a truncation warning at the configured limit, got %v", resp.Warnings)
+	}
+}
+
+// TestSendNotification_PersistsFailureReason tests that users none of whose devices were reached get the failure reason logged
+func TestSendNotification_PersistsFailureReason(t *testing.T) {
+	db := setupTestDB(t)
+	tokens := []models.DeviceToken{
+		{UserEmail: "alice@example.com", DeviceToken: "alice-phone", Platform: "android", IsActive: true},
+		{UserEmail: "alice@example.com", DeviceToken: "alice-tablet", Platform: "android", IsActive: true},
+		{UserEmail: "bob@example.com", DeviceToken: "bob-phone", Platform: "ios", IsActive: true},
+	}
+	if err := db.Create(&tokens).Error; err != nil {
+		t.Fatalf("Failed to seed device tokens: %v", err)
+	}
+	svc := &detailedNotificationService{failures: map[string]string{
+		"alice-tablet": "unavailable",
+		"bob-phone":    "sender-id-mismatch",
+	}}
+	h := NewNotificationHandler(db, svc, nil, services.SenderIdentity{})
+
+	body, _ := json.Marshal(dto.SendNotificationRequest{UserEmails: []string{"alice@example.com", "bob@example.com"}, Title: "Hi", Body: "Hello"})
+	req := httptest.NewRequest(http.MethodPost, "/notifications/send", bytes.NewReader(body))
+	req.Header.Set(headerContentType, contentTypeJSON)
+	req = auth.SetServiceInfo(req, &auth.ServiceInfo{ClientID: "app-1"})
+	w := httptest.NewRecorder()
+
+	h.SendNotification(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var resp dto.NotificationResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if resp.Success != 1 || resp.Failed != 2 {
+		t.Errorf("Expected 1 success and 2 failed, got %d and %d", resp.Success, resp.Failed)
+	}
+
+	var logs []models.NotificationLog
+	db.Order("user_email").Find(&logs)
+	if len(logs) != 2 {
+		t.Fatalf("Expected 2 log entries, got %d", len(logs))
+	}
+	if logs[0].FailureReason != nil {
+		t.Errorf("Expected no failure reason for alice, who has a reachable device, got %q", *logs[0].FailureReason)
+	}
+	if logs[1].FailureReason == nil || *logs[1].FailureReason != "sender-id-mismatch" {
+		t.Errorf("Expected failure reason sender-id-mismatch for bob, got %v", logs[1].FailureReason)
+	}
+}
+
+// TestSendNotification_AttachesSenderIdentity tests that the identity of the sending microapp is added to the payload
+func TestSendNotification_AttachesSenderIdentity(t *testing.T) {
+	db := setupTestDB(t)
+	// micro_app is created by hand with only the columns the identity lookup reads
+	if err := db.Exec(`CREATE TABLE micro_app (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		micro_app_id VARCHAR(255) NOT NULL,
+		name VARCHAR(1024) NOT NULL,
+		icon_url VARCHAR(2083),
+		active BOOLEAN NOT NULL DEFAULT 1
+	)`).Error; err != nil {
+		t.Fatalf("Failed to create micro_app table: %v", err)
+	}
+	if err := db.Exec(`INSERT INTO micro_app (micro_app_id, name, icon_url, active) VALUES
+		('payroll', 'Payroll', 'https://cdn.example.com/payroll.png', 1),
+		('leave', 'Leave', NULL, 1),
+		('retired', 'Retired App', 'https://cdn.example.com/retired.png', 0)`).Error; err != nil {
+		t.Fatalf("Failed to seed micro apps: %v", err)
+	}
+	if err := db.Create(&models.DeviceToken{UserEmail: "alice@example.com", DeviceToken: "token-1", Platform: "android", IsActive: true}).Error; err != nil {
+		t.Fatalf("Failed to seed device token: %v", err)
+	}
+	defaultSender := services.SenderIdentity{Name: "SuperApp", IconURL: "https://cdn.example.com/superapp.png"}
+
+	tests := []struct {
+		name       string
+		microappID string
+		wantName   string
+		wantIcon   string
+	}{
+		{"microapp with icon", "payroll", "Payroll", "https://cdn.example.com/payroll.png"},
+		{"microapp without icon", "leave", "Leave", "https://cdn.example.com/superapp.png"},
+		{"inactive microapp", "retired", "SuperApp", "https://cdn.example.com/superapp.png"},
+		{"unknown microapp", "unknown", "SuperApp", "https://cdn.example.com/superapp.png"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := &fakeNotificationService{}
+			h := NewNotificationHandler(db, fake, nil, defaultSender)
+
+			body, _ := json.Marshal(dto.SendNotificationRequest{
+				UserEmails: []string{"alice@example.com"},
+				Title:      "Hi",
+				Body:       "Hello",
+				// Senders cannot override the identity resolved for them
+				Data: map[string]interface{}{services.DataKeySenderName: "Spoofed"},
+			})
+			req := httptest.NewRequest(http.MethodPost, "/notifications/send", bytes.NewReader(body))
+			req.Header.Set(headerContentType, contentTypeJSON)
+			req = auth.SetServiceInfo(req, &auth.ServiceInfo{ClientID: tt.microappID})
+			w := httptest.NewRecorder()
+
+			h.SendNotification(w, req)
+
+			if w.Code != http.StatusOK {
+				t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+			}
+			if got := fake.lastData[services.DataKeySenderName]; got != tt.wantName {
+				t.Errorf("Expected sender name %q, got %q", tt.wantName, got)
+			}
+			if got := fake.lastData[services.DataKeySenderIcon]; got != tt.wantIcon {
+				t.Errorf("Expected sender icon %q, got %q", tt.wantIcon, got)
+			}
+			if got := fake.lastData[dataKeyMicroappID]; got != tt.microappID {
+				t.Errorf("Expected microappId %q, got %q", tt.microappID, got)
+			}
+		})
+	}
+}
+
+// TestSendNotification_TTL tests that a non-negative TTL reaches the data payload and a negative one is rejected
+func TestSendNotification_TTL(t *testing.T) {
+	db := setupTestDB(t)
+	if err := db.Create(&models.DeviceToken{UserEmail: "alice@example.com", DeviceToken: "token-1", Platform: "android", IsActive: true}).Error; err != nil {
+		t.Fatalf("Failed to seed device token: %v", err)
+	}
+
+	ttl := func(seconds int) *int { return &seconds }
+	tests := []struct {
+		name       string
+		ttl        *int
+		wantStatus int
+		wantTTL    string
+	}{
+		{"ttl", ttl(600), http.StatusOK, "600"},
+		{"zero ttl", ttl(0), http.StatusOK, "0"},
+		{"no ttl", nil, http.StatusOK, ""},
+		{"negative ttl", ttl(-1), http.StatusBadRequest, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := &fakeNotificationService{}
+			h := NewNotificationHandler(db, fake, nil, services.SenderIdentity{})
+
+			body, _ := json.Marshal(dto.SendNotificationRequest{
+				UserEmails: []string{"alice@example.com"},
+				Title:      "Hi",
+				Body:       "Hello",
+				TTL:        tt.ttl,
+				// The TTL can only be set through ttl
+				Data: map[string]interface{}{services.DataKeyTTL: "99999"},
+			})
+			req := httptest.NewRequest(http.MethodPost, "/notifications/send", bytes.NewReader(body))
+			req.Header.Set(headerContentType, contentTypeJSON)
+			req = auth.SetServiceInfo(req, &auth.ServiceInfo{ClientID: "app-1"})
+			w := httptest.NewRecorder()
+
+			h.SendNotification(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("Expected status %d, got %d: %s", tt.wantStatus, w.Code, w.Body.String())
+			}
+			if got := fake.lastData[services.DataKeyTTL]; got != tt.wantTTL {
+				t.Errorf("Expected ttl %q, got %q", tt.wantTTL, got)
+			}
+		})
+	}
+}
+
+// TestSendNotification_ImageURL tests that only an https image URL from the request reaches the data payload
+func TestSendNotification_ImageURL(t *testing.T) {
+	db := setupTestDB(t)
+	if err := db.Create(&models.DeviceToken{UserEmail: "alice@example.com", DeviceToken: "token-1", Platform: "android", IsActive: true}).Error; err != nil {
+		t.Fatalf("Failed to seed device token: %v", err)
+	}
+
+	tests := []struct {
+		name       string
+		imageURL   string
+		wantStatus int
+		wantImage  string
+	}{
+		{"https image", "https://cdn.example.com/promo.png", http.StatusOK, "https://cdn.example.com/promo.png"},
+		{"no image", "", http.StatusOK, ""},
+		{"plain http image", "http://cdn.example.com/promo.png", http.StatusBadRequest, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := &fakeNotificationService{}
+			h := NewNotificationHandler(db, fake, nil, services.SenderIdentity{})
+
+			body, _ := json.Marshal(dto.SendNotificationRequest{
+				UserEmails: []string{"alice@example.com"},
+				Title:      "Hi",
+				Body:       "Hello",
+				ImageURL:   tt.imageURL,
+				// The image can only be set through imageUrl
+				Data: map[string]interface{}{services.DataKeyImageURL: "https://evil.example.com/x.png"},
+			})
+			req := httptest.NewRequest(http.MethodPost, "/notifications/send", bytes.NewReader(body))
+			req.Header.Set(headerContentType, contentTypeJSON)
+			req = auth.SetServiceInfo(req, &auth.ServiceInfo{ClientID: "app-1"})
+			w := httptest.NewRecorder()
+
+			h.SendNotification(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("Expected status %d, got %d: %s", tt.wantStatus, w.Code, w.Body.String())
+			}
+			if got := fake.lastData[services.DataKeyImageURL]; got != tt.wantImage {
+				t.Errorf("Expected image %q, got %q", tt.wantImage, got)
+			}
+		})
+	}
+}
+
+// TestSendToTopic_ReservedDataKeys tests that endpoints without validated delivery options cannot
+// set the image, collapse key, TTL or badge through the data payload
+func TestSendToTopic_ReservedDataKeys(t *testing.T) {
+	fake := &fakeNotificationService{}
+	h := NewNotificationHandler(setupTestDB(t), fake, nil, services.SenderIdentity{})
+
+	body, _ := json.Marshal(dto.SendTopicNotificationRequest{
+		Topic: "announcements",
+		Title: "Hi",
+		Body:  "Hello",
+		Data: map[string]interface{}{
+			services.DataKeyImageURL:    "https://evil.example.com/x.png",
+			services.DataKeyCollapseKey: strings.Repeat("k", 200),
+			services.DataKeyTTL:         "-1",
+			services.DataKeyBadge:       "7",
+			"orderId":                   "42",
+		},
+	})
+	req := httptest.NewRequest(http.MethodPost, "/notifications/topics/send", bytes.NewReader(body))
+	req.Header.Set(headerContentType, contentTypeJSON)
+	req = auth.SetServiceInfo(req, &auth.ServiceInfo{ClientID: "app-1"})
+	w := httptest.NewRecorder()
+
+	h.SendToTopic(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	for _, key := range []string{services.DataKeyImageURL, services.DataKeyCollapseKey, services.DataKeyTTL, services.DataKeyBadge} {
+		if v, ok := fake.lastData[key]; ok {
+			t.Errorf("Expected reserved key %q to be dropped, got %q", key, v)
+		}
+	}
+	if fake.lastData["orderId"] != "42" {
+		t.Errorf("Expected other data to be kept, got %v", fake.lastData)
+	}
+}
+
+// TestSendNotification_ImagePolicy tests that with an image policy only the microapp's own assets and
+// allowlisted hosts are accepted
+func TestSendNotification_ImagePolicy(t *testing.T) {
+	db := setupTestDB(t)
+	if err := db.AutoMigrate(&models.MicroAppAsset{}); err != nil {
+		t.Fatalf("Failed to migrate micro_app_assets: %v", err)
+	}
+	if err := db.Create(&models.DeviceToken{UserEmail: "alice@example.com", DeviceToken: "token-1", Platform: "android", IsActive: true}).Error; err != nil {
+		t.Fatalf("Failed to seed device token: %v", err)
+	}
+	if err := services.RecordMicroAppAsset(db, "promo.png", "app-1", "https://files.example.com/promo.png"); err != nil {
+		t.Fatalf("Failed to seed asset: %v", err)
+	}
+	if err := services.RecordMicroAppAsset(db, "other.png", "app-2", "https://files.example.com/other.png"); err != nil {
+		t.Fatalf("Failed to seed asset: %v", err)
+	}
+
+	tests := []struct {
+		name       string
+		imageURL   string
+		wantStatus int
+	}{
+		{"owned asset", "https://files.example.com/promo.png", http.StatusOK},
+		{"allowlisted host", "https://cdn.example.com/promo.png", http.StatusOK},
+		{"no image", "", http.StatusOK},
+		{"asset of another microapp", "https://files.example.com/other.png", http.StatusBadRequest},
+		{"arbitrary url", "https://evil.example.com/promo.png", http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := &fakeNotificationService{}
+			h := NewNotificationHandler(db, fake, nil, services.SenderIdentity{}).
+				WithImagePolicy(services.NewNotificationImagePolicy(db, []string{"cdn.example.com"}))
+
+			body, _ := json.Marshal(dto.SendNotificationRequest{UserEmails: []string{"alice@example.com"}, Title: "Hi", Body: "Hello", ImageURL: tt.imageURL})
+			req := httptest.NewRequest(http.MethodPost, "/notifications/send", bytes.NewReader(body))
+			req.Header.Set(headerContentType, contentTypeJSON)
+			req = auth.SetServiceInfo(req, &auth.ServiceInfo{ClientID: "app-1"})
+			w := httptest.NewRecorder()
+
+			h.SendNotification(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("Expected status %d, got %d: %s", tt.wantStatus, w.Code, w.Body.String())
+			}
+			if sent := fake.lastTokens != nil; sent != (tt.wantStatus == http.StatusOK) {
+				t.Errorf("Expected sent=%v, got %v", tt.wantStatus == http.StatusOK, sent)
+			}
+		})
+	}
+}
+
+// TestSendNotification_MinBuild tests that recipients whose latest device is below the minimum build are skipped
+func TestSendNotification_MinBuild(t *testing.T) {
+	db := setupTestDB(t)
+	build := func(n int) *int { return &n }
+	now := time.Now()
+	devices := []models.DeviceToken{
+		{UserEmail: "alice@example.com", DeviceToken: "alice-token", Platform: "android", AppBuild: build(120), IsActive: true, UpdatedAt: now},
+		{UserEmail: "bob@example.com", DeviceToken: "bob-token", Platform: "android", AppBuild: build(90), IsActive: true, UpdatedAt: now},
+		{UserEmail: "carol@example.com", DeviceToken: "carol-token", Platform: "ios", IsActive: true, UpdatedAt: now},
+		// Dave's newest device runs an old build, so his older up-to-date device does not count
+		{UserEmail: "dave@example.com", DeviceToken: "dave-old", Platform: "android", AppBuild: build(150), IsActive: true, UpdatedAt: now.Add(-time.Hour)},
+		{UserEmail: "dave@example.com", DeviceToken: "dave-new", Platform: "ios", AppBuild: build(80), IsActive: true, UpdatedAt: now},
+	}
+	if err := db.Create(&devices).Error; err != nil {
+		t.Fatalf("Failed to seed device tokens: %v", err)
+	}
+	fake := &fakeNotificationService{}
+	h := NewNotificationHandler(db, fake, nil, services.SenderIdentity{})
+
+	send := func(minBuild int, emails ...string) dto.NotificationResponse {
+		t.Helper()
+		body, _ := json.Marshal(dto.SendNotificationRequest{UserEmails: emails, Title: "New feature", Body: "Try it now", MinBuild: minBuild})
+		req := httptest.NewRequest(http.MethodPost, "/notifications/send", bytes.NewReader(body))
+		req.Header.Set(headerContentType, contentTypeJSON)
+		req = auth.SetServiceInfo(req, &auth.ServiceInfo{ClientID: "app-1"})
+		w := httptest.NewRecorder()
+		h.SendNotification(w, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+		}
+		var resp dto.NotificationResponse
+		json.NewDecoder(w.Body).Decode(&resp)
+		return resp
+	}
+
+	resp := send(100, "alice@example.com", "bob@example.com", "carol@example.com", "dave@example.com")
+	if resp.Success != 1 || resp.SkippedBelowBuild != 3 {
+		t.Errorf("Expected 1 success and 3 skipped, got %+v", resp)
+	}
+	if !reflect.DeepEqual(fake.lastTokens, []string{"alice-token"}) {
+		t.Errorf("Expected only alice's device to be sent to, got %v", fake.lastTokens)
+	}
+
+	resp = send(100, "bob@example.com")
+	if resp.Message != msgAllRecipientsBelowMinBuild || resp.SkippedBelowBuild != 1 {
+		t.Errorf("Expected every recipient to be skipped, got %+v", resp)
+	}
+}
+
+// TestSendNotification_Quota tests that a microapp over its quota gets 429 with Retry-After and nothing is sent
+func TestSendNotification_Quota(t *testing.T) {
+	db := setupTestDB(t)
+	if err := db.AutoMigrate(&models.NotificationQuota{}, &models.MicroAppConfig{}); err != nil {
+		t.Fatalf("Failed to migrate quota tables: %v", err)
+	}
+	if err := db.Create(&models.DeviceToken{UserEmail: "alice@example.com", DeviceToken: "token-1", Platform: "android", IsActive: true}).Error; err != nil {
+		t.Fatalf("Failed to seed device token: %v", err)
+	}
+	clock := clocktest.NewFakeClock(time.Date(2025, 1, 15, 10, 59, 30, 0, time.UTC))
+	fake := &fakeNotificationService{}
+	h := NewNotificationHandler(db, fake, nil, services.SenderIdentity{}).
+		WithQuotaService(services.NewQuotaService(db, time.Hour, 2, clock))
+
+	send := func(emails ...string) *httptest.ResponseRecorder {
+		body, _ := json.Marshal(dto.SendNotificationRequest{UserEmails: emails, Title: "Hi", Body: "Hello"})
+		req := httptest.NewRequest(http.MethodPost, "/notifications/send", bytes.NewReader(body))
+		req.Header.Set(headerContentType, contentTypeJSON)
+		req = auth.SetServiceInfo(req, &auth.ServiceInfo{ClientID: "app-1"})
+		w := httptest.NewRecorder()
+		h.SendNotification(w, req)
+		return w
+	}
+
+	if w := send("alice@example.com", "bob@example.com"); w.Code != http.StatusOK {
+		t.Fatalf("Expected the first send to fit the quota, got %d: %s", w.Code, w.Body.String())
+	}
+	fake.lastTokens = nil
+	w := send("alice@example.com")
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("Expected status 429, got %d: %s", w.Code, w.Body.String())
+	}
+	if got := w.Header().Get(headerRetryAfter); got != "30" {
+		t.Errorf("Expected Retry-After 30, got %q", got)
+	}
+	if fake.lastTokens != nil {
+		t.Errorf("Expected nothing to be sent over quota, got %v", fake.lastTokens)
+	}
+
+	clock.Advance(30 * time.Second)
+	if w := send("alice@example.com"); w.Code != http.StatusOK {
+		t.Errorf("Expected the quota to reset in the next period, got %d", w.Code)
+	}
+}
+
+// TestSendToGroups_Quota tests that a group send is charged one send per resolved group member
+func TestSendToGroups_Quota(t *testing.T) {
+	db := setupTestDB(t)
+	if err := db.AutoMigrate(&models.NotificationQuota{}, &models.MicroAppConfig{}); err != nil {
+		t.Fatalf("Failed to migrate quota tables: %v", err)
+	}
+	seedGroups(t, db, map[string][]string{
+		"engineering": {"alice@example.com", "bob@example.com"},
+		"sales":       {"bob@example.com", "carol@example.com"},
+	})
+	if err := db.Create(&models.DeviceToken{UserEmail: "alice@example.com", DeviceToken: "token-1", Platform: "android", IsActive: true}).Error; err != nil {
+		t.Fatalf("Failed to seed device token: %v", err)
+	}
+	clock := clocktest.NewFakeClock(time.Date(2025, 1, 15, 10, 0, 0, 0, time.UTC))
+	fake := &fakeNotificationService{}
+	h := NewNotificationHandler(db, fake, nil, services.SenderIdentity{}).
+		WithQuotaService(services.NewQuotaService(db, time.Hour, 3, clock))
+
+	send := func(groups ...string) *httptest.ResponseRecorder {
+		body, _ := json.Marshal(dto.SendToGroupsRequest{Groups: groups, Title: "Hi", Body: "Hello"})
+		req := httptest.NewRequest(http.MethodPost, "/notifications/groups/send", bytes.NewReader(body))
+		req.Header.Set(headerContentType, contentTypeJSON)
+		req = auth.SetServiceInfo(req, &auth.ServiceInfo{ClientID: "app-1"})
+		w := httptest.NewRecorder()
+		h.SendToGroups(w, req)
+		return w
+	}
+
+	if w := send("engineering", "sales"); w.Code != http.StatusOK {
+		t.Fatalf("Expected the three members to fit the quota, got %d: %s", w.Code, w.Body.String())
+	}
+	fake.lastTokens = nil
+	w := send("engineering")
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("Expected status 429, got %d: %s", w.Code, w.Body.String())
+	}
+	if fake.lastTokens != nil {
+		t.Errorf("Expected nothing to be sent over quota, got %v", fake.lastTokens)
+	}
+}
+
+// TestSendToTopic_Quota tests that a topic send is charged as one send
+func TestSendToTopic_Quota(t *testing.T) {
+	db := setupTestDB(t)
+	if err := db.AutoMigrate(&models.NotificationQuota{}, &models.MicroAppConfig{}); err != nil {
+		t.Fatalf("Failed to migrate quota tables: %v", err)
+	}
+	clock := clocktest.NewFakeClock(time.Date(2025, 1, 15, 10, 0, 0, 0, time.UTC))
+	fake := &fakeNotificationService{}
+	h := NewNotificationHandler(db, fake, nil, services.SenderIdentity{}).
+		WithQuotaService(services.NewQuotaService(db, time.Hour, 1, clock))
+
+	send := func() *httptest.ResponseRecorder {
+		body, _ := json.Marshal(dto.SendTopicNotificationRequest{Topic: "announcements", Title: "Hi", Body: "Hello"})
+		req := httptest.NewRequest(http.MethodPost, "/notifications/topics/send", bytes.NewReader(body))
+		req.Header.Set(headerContentType, contentTypeJSON)
+		req = auth.SetServiceInfo(req, &auth.ServiceInfo{ClientID: "app-1"})
+		w := httptest.NewRecorder()
+		h.SendToTopic(w, req)
+		return w
+	}
+
+	if w := send(); w.Code != http.StatusOK {
+		t.Fatalf("Expected the first topic send to fit the quota, got %d: %s", w.Code, w.Body.String())
+	}
+	if w := send(); w.Code != http.StatusTooManyRequests {
+		t.Fatalf("Expected status 429, got %d: %s", w.Code, w.Body.String())
+	}
+	if len(fake.topics) != 1 {
+		t.Errorf("Expected one topic send, got %v", fake.topics)
+	}
+}
+
+// TestScheduleNotification_Quota tests that scheduling is charged one send per recipient and nothing
+// is stored over quota
+func TestScheduleNotification_Quota(t *testing.T) {
+	db := setupTestDB(t)
+	if err := db.AutoMigrate(&models.NotificationQuota{}, &models.MicroAppConfig{}, &models.ScheduledNotification{}); err != nil {
+		t.Fatalf("Failed to migrate quota tables: %v", err)
+	}
+	clock := clocktest.NewFakeClock(time.Date(2025, 1, 15, 10, 0, 0, 0, time.UTC))
+	h := NewNotificationHandler(db, &fakeNotificationService{}, nil, services.SenderIdentity{}).
+		WithQuotaService(services.NewQuotaService(db, time.Hour, 2, clock))
+
+	schedule := func(emails ...string) *httptest.ResponseRecorder {
+		body, _ := json.Marshal(dto.ScheduleNotificationRequest{UserEmails: emails, Title: "Hi", Body: "Hello", SendAt: time.Now().Add(time.Hour)})
+		req := httptest.NewRequest(http.MethodPost, "/notifications/schedule", bytes.NewReader(body))
+		req.Header.Set(headerContentType, contentTypeJSON)
+		req = auth.SetServiceInfo(req, &auth.ServiceInfo{ClientID: "app-1"})
+		w := httptest.NewRecorder()
+		h.ScheduleNotification(w, req)
+		return w
+	}
+
+	if w := schedule("alice@example.com", "bob@example.com", "carol@example.com"); w.Code != http.StatusTooManyRequests {
+		t.Fatalf("Expected status 429, got %d: %s", w.Code, w.Body.String())
+	}
+	if w := schedule("alice@example.com", "bob@example.com"); w.Code != http.StatusCreated {
+		t.Fatalf("Expected the schedule to fit the quota, got %d: %s", w.Code, w.Body.String())
+	}
+	var count int64
+	if err := db.Model(&models.ScheduledNotification{}).Count(&count).Error; err != nil {
+		t.Fatalf("Failed to count scheduled notifications: %v", err)
+	}
+	if count != 1 {
+		t.Errorf("Expected one scheduled notification, got %d", count)
+	}
+}
+
+// TestSendNotification_Coalescing tests that sends of a microapp with a coalescing window are held
+// as one pending notification, while sends needing per-recipient filtering go out immediately
+func TestSendNotification_Coalescing(t *testing.T) {
+	db := setupTestDB(t)
+	if err := db.AutoMigrate(&models.ScheduledNotification{}, &models.MicroAppConfig{}); err != nil {
+		t.Fatalf("Failed to migrate coalescing tables: %v", err)
+	}
+	if err := db.Create(&models.DeviceToken{UserEmail: "alice@example.com", DeviceToken: "token-1", Platform: "android", IsActive: true}).Error; err != nil {
+		t.Fatalf("Failed to seed device token: %v", err)
+	}
+	if err := db.Create(&models.MicroAppConfig{MicroAppID: "chat", ConfigKey: services.MicroAppConfigKeyNotificationCoalescing,
+		ConfigValue: json.RawMessage(`{"windowSeconds": 30, "strategy": "concatenate"}`), CreatedBy: "admin@example.com"}).Error; err != nil {
+		t.Fatalf("Failed to seed coalescing config: %v", err)
+	}
+	fake := &fakeNotificationService{}
+	h := NewNotificationHandler(db, fake, nil, services.SenderIdentity{}).
+		WithCoalescer(services.NewNotificationCoalescer(db, clocktest.NewFakeClock(time.Date(2025, 1, 15, 10, 0, 0, 0, time.UTC))))
+
+	send := func(clientID string, req dto.SendNotificationRequest) *httptest.ResponseRecorder {
+		body, _ := json.Marshal(req)
+		r := httptest.NewRequest(http.MethodPost, "/notifications/send", bytes.NewReader(body))
+		r.Header.Set(headerContentType, contentTypeJSON)
+		r = auth.SetServiceInfo(r, &auth.ServiceInfo{ClientID: clientID})
+		w := httptest.NewRecorder()
+		h.SendNotification(w, r)
+		return w
+	}
+
+	var first, second dto.ScheduledNotificationResponse
+	for i, resp := range []*dto.ScheduledNotificationResponse{&first, &second} {
+		w := send("chat", dto.SendNotificationRequest{UserEmails: []string{"alice@example.com"}, Title: "Chat", Body: fmt.Sprintf("Message %d", i+1)})
+		if w.Code != http.StatusAccepted {
+			t.Fatalf("Expected status 202, got %d: %s", w.Code, w.Body.String())
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), resp); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+	}
+	if second.ID != first.ID || second.CoalescedSends != 2 || second.Status != models.ScheduledStatusPending {
+		t.Fatalf("Expected the second send to be merged into %+v, got %+v", first, second)
+	}
+	if fake.lastTokens != nil {
+		t.Errorf("Expected nothing to be sent within the window, got %v", fake.lastTokens)
+	}
+	var pending models.ScheduledNotification
+	if err := db.First(&pending, first.ID).Error; err != nil {
+		t.Fatalf("Failed to fetch coalesced notification: %v", err)
+	}
+	if pending.Body != "Message 1\nMessage 2" {
+		t.Errorf("Expected both messages in the body, got %q", pending.Body)
+	}
+
+	// Deduplicated sends and microapps without a window are sent straight away
+	if w := send("chat", dto.SendNotificationRequest{UserEmails: []string{"alice@example.com"}, Title: "Chat", Body: "Once", DedupKey: "once", MaxAgeSeconds: 60}); w.Code != http.StatusOK {
+		t.Errorf("Expected a deduplicated send to go out immediately, got %d: %s", w.Code, w.Body.String())
+	}
+	fake.lastTokens = nil
+	if w := send("news", dto.SendNotificationRequest{UserEmails: []string{"alice@example.com"}, Title: "News", Body: "Headline"}); w.Code != http.StatusOK {
+		t.Errorf("Expected a microapp without a window to send immediately, got %d: %s", w.Code, w.Body.String())
+	}
+	if len(fake.lastTokens) != 1 {
+		t.Errorf("Expected the send to reach alice's device, got %v", fake.lastTokens)
+	}
+}
+
+// TestSendNotification_Metrics tests that device deliveries are counted per microapp and status
+func TestSendNotification_Metrics(t *testing.T) {
+	db := setupTestDB(t)
+	tokens := []models.DeviceToken{
+		{UserEmail: "alice@example.com", DeviceToken: "live-token", Platform: "android", IsActive: true},
+		{UserEmail: "alice@example.com", DeviceToken: "dead-token", Platform: "ios", IsActive: true},
+	}
+	if err := db.Create(&tokens).Error; err != nil {
+		t.Fatalf("Failed to seed device tokens: %v", err)
+	}
+	registry := prometheus.NewRegistry()
+	h := NewNotificationHandler(db, &fakeNotificationService{deadTokens: []string{"dead-token"}}, nil, services.SenderIdentity{}).
+		WithMetrics(metrics.NewNotificationMetrics(registry))
+
+	body, _ := json.Marshal(dto.SendNotificationRequest{UserEmails: []string{"alice@example.com"}, Title: "Hi", Body: "Hello"})
+	req := httptest.NewRequest(http.MethodPost, "/notifications/send", bytes.NewReader(body))
+	req.Header.Set(headerContentType, contentTypeJSON)
+	req = auth.SetServiceInfo(req, &auth.ServiceInfo{ClientID: "app-1"})
+	w := httptest.NewRecorder()
+
+	h.SendNotification(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	expected := `
+# HELP fcm_notifications_sent_total Push notifications sent to devices by micro app and delivery status.
+# TYPE fcm_notifications_sent_total counter
+fcm_notifications_sent_total{microapp_id="app-1",status="failure"} 1
+fcm_notifications_sent_total{microapp_id="app-1",status="success"} 1
+`
+	if err := testutil.GatherAndCompare(registry, strings.NewReader(expected), "fcm_notifications_sent_total"); err != nil {
+		t.Error(err)
+	}
+}
+
+// TestSendNotification_DispatchesByPlatform tests that each platform's tokens go to the provider configured for it
+func TestSendNotification_DispatchesByPlatform(t *testing.T) {
+	db := setupTestDB(t)
+	tokens := []models.DeviceToken{
+		{UserEmail: "alice@example.com", DeviceToken: "android-token", Platform: "android", IsActive: true},
+		{UserEmail: "alice@example.com", DeviceToken: "ios-token", Platform: "ios", IsActive: true},
+	}
+	if err := db.Create(&tokens).Error; err != nil {
+		t.Fatalf("Failed to seed device tokens: %v", err)
+	}
+	fcm := &fakeNotificationService{}
+	apns := &fakeNotificationService{}
+	dispatcher := services.NewPlatformDispatcher(fcm, map[string]services.NotificationService{"ios": apns})
+	h := NewNotificationHandler(db, dispatcher, nil, services.SenderIdentity{})
+
+	body, _ := json.Marshal(dto.SendNotificationRequest{UserEmails: []string{"alice@example.com"}, Title: "Hi", Body: "Hello"})
+	req := httptest.NewRequest(http.MethodPost, "/notifications/send", bytes.NewReader(body))
+	req.Header.Set(headerContentType, contentTypeJSON)
+	req = auth.SetServiceInfo(req, &auth.ServiceInfo{ClientID: "app-1"})
+	w := httptest.NewRecorder()
+
+	h.SendNotification(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if len(fcm.lastTokens) != 1 || fcm.lastTokens[0] != "android-token" {
+		t.Errorf("Expected FCM to receive only android-token, got %v", fcm.lastTokens)
+	}
+	if len(apns.lastTokens) != 1 || apns.lastTokens[0] != "ios-token" {
+		t.Errorf("Expected APNs to receive only ios-token, got %v", apns.lastTokens)
+	}
+
+	var resp dto.NotificationResponse
+	json.NewDecoder(w.Body).Decode(&resp)
+	if resp.Success != 2 {
+		t.Errorf("Expected merged success count 2, got %d", resp.Success)
+	}
+}
+
+// TestSendNotification_Topics tests that topics are sent namespaced to the microapp and that a failed topic does not fail the request
+func TestSendNotification_Topics(t *testing.T) {
+	db := setupTestDB(t)
+	if err := db.Create(&models.DeviceToken{UserEmail: "alice@example.com", DeviceToken: "token-1", Platform: "android", IsActive: true}).Error; err != nil {
+		t.Fatalf("Failed to seed device token: %v", err)
+	}
+	fake := &fakeNotificationService{topicErrs: map[string]error{"app-1.broken": errors.New("fcm unavailable")}}
+	h := NewNotificationHandler(db, fake, nil, services.SenderIdentity{})
+
+	body, _ := json.Marshal(dto.SendNotificationRequest{
+		UserEmails: []string{"alice@example.com"},
+		Topics:     []string{"announcements", "broken", "announcements"},
+		Title:      "Hi",
+		Body:       "Hello",
+	})
+	req := httptest.NewRequest(http.MethodPost, "/notifications/send", bytes.NewReader(body))
+	req.Header.Set(headerContentType, contentTypeJSON)
+	req = auth.SetServiceInfo(req, &auth.ServiceInfo{ClientID: "app-1"})
+	w := httptest.NewRecorder()
+
+	h.SendNotification(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if len(fake.topics) != 2 || fake.topics[0] != "app-1.announcements" || fake.topics[1] != "app-1.broken" {
+		t.Errorf("Expected namespaced topics sent once each, got %v", fake.topics)
+	}
+	if len(fake.lastTokens) != 1 {
+		t.Errorf("Expected user tokens to still be sent, got %v", fake.lastTokens)
+	}
+
+	var resp dto.NotificationResponse
+	json.NewDecoder(w.Body).Decode(&resp)
+	if resp.Success != 2 || resp.Failed != 1 {
+		t.Errorf("Expected 2 success and 1 failed, got %d and %d", resp.Success, resp.Failed)
+	}
+	if len(resp.Topics) != 2 || resp.Topics[0].MessageID != "msg-app-1.announcements" || resp.Topics[1].Error == "" {
+		t.Errorf("Unexpected topic results: %+v", resp.Topics)
+	}
+}
+
+// TestSendNotification_TopicsOnly tests that a request with only topics is accepted and loads no device tokens
+func TestSendNotification_TopicsOnly(t *testing.T) {
+	db := setupTestDB(t)
+	fake := &fakeNotificationService{}
+	h := NewNotificationHandler(db, fake, nil, services.SenderIdentity{})
+
+	body, _ := json.Marshal(dto.SendNotificationRequest{Topics: []string{"announcements"}, Title: "Hi", Body: "Hello"})
+	req := httptest.NewRequest(http.MethodPost, "/notifications/send", bytes.NewReader(body))
+	req.Header.Set(headerContentType, contentTypeJSON)
+	req = auth.SetServiceInfo(req, &auth.ServiceInfo{ClientID: "app-1"})
+	w := httptest.NewRecorder()
+
+	h.SendNotification(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if len(fake.topics) != 1 || fake.lastTokens != nil {
+		t.Errorf("Expected one topic send and no multicast, got topics %v and tokens %v", fake.topics, fake.lastTokens)
+	}
+
+	// Neither userEmails nor topics is rejected
+	body, _ = json.Marshal(dto.SendNotificationRequest{Title: "Hi", Body: "Hello"})
+	req = httptest.NewRequest(http.MethodPost, "/notifications/send", bytes.NewReader(body))
+	req.Header.Set(headerContentType, contentTypeJSON)
+	req = auth.SetServiceInfo(req, &auth.ServiceInfo{ClientID: "app-1"})
+	w = httptest.NewRecorder()
+
+	h.SendNotification(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 without recipients, got %d", w.Code)
+	}
+}
+
+// TestSendNotification_ScheduledAt tests that a send with scheduledAt is stored for the worker instead of being sent
+func TestSendNotification_ScheduledAt(t *testing.T) {
+	db := setupTestDB(t)
+	if err := db.AutoMigrate(&models.ScheduledNotification{}); err != nil {
+		t.Fatalf("Failed to migrate scheduled notifications: %v", err)
+	}
+	if err := db.Create(&models.DeviceToken{UserEmail: "alice@example.com", DeviceToken: "token-1", Platform: "android", IsActive: true}).Error; err != nil {
+		t.Fatalf("Failed to seed device token: %v", err)
+	}
+	fake := &fakeNotificationService{}
+	h := NewNotificationHandler(db, fake, nil, services.SenderIdentity{})
+	send := func(req dto.SendNotificationRequest) *httptest.ResponseRecorder {
+		body, _ := json.Marshal(req)
+		r := httptest.NewRequest(http.MethodPost, "/notifications/send", bytes.NewReader(body))
+		r.Header.Set(headerContentType, contentTypeJSON)
+		r = auth.SetServiceInfo(r, &auth.ServiceInfo{ClientID: "app-1"})
+		w := httptest.NewRecorder()
+		h.SendNotification(w, r)
+		return w
+	}
+
+	sendAt := time.Now().Add(10 * time.Minute).Truncate(time.Second)
+	w := send(dto.SendNotificationRequest{
+		UserEmails:  []string{"alice@example.com"},
+		Title:       "Meeting",
+		Body:        "Starts in 10 minutes",
+		CollapseKey: "meeting-42",
+		ScheduledAt: &sendAt,
+	})
+
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status 201, got %d: %s", w.Code, w.Body.String())
+	}
+	if fake.lastTokens != nil {
+		t.Errorf("Expected nothing to be sent now, got %v", fake.lastTokens)
+	}
+	var resp dto.ScheduledNotificationResponse
+	json.NewDecoder(w.Body).Decode(&resp)
+	var stored models.ScheduledNotification
+	if err := db.First(&stored, resp.ID).Error; err != nil {
+		t.Fatalf("Failed to load scheduled notification: %v", err)
+	}
+	if stored.Status != models.ScheduledStatusPending || !stored.SendAt.Equal(sendAt) || stored.MicroappID != "app-1" {
+		t.Errorf("Unexpected scheduled notification: %+v", stored)
+	}
+	if stored.Data[services.DataKeyCollapseKey] != "meeting-42" {
+		t.Errorf("Expected the collapse key to be kept for dispatch, got %v", stored.Data)
+	}
+
+	past := time.Now().Add(-time.Minute)
+	if w := send(dto.SendNotificationRequest{UserEmails: []string{"alice@example.com"}, Title: "Hi", Body: "Hello", ScheduledAt: &past}); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for a past scheduledAt, got %d", w.Code)
+	}
+	if w := send(dto.SendNotificationRequest{Topics: []string{"news"}, Title: "Hi", Body: "Hello", ScheduledAt: &sendAt}); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for a scheduled topic send, got %d", w.Code)
+	}
+}
+
+// seedGroups creates the user_groups table and adds each user to the given groups
+func seedGroups(t *testing.T, db *gorm.DB, memberships map[string][]string) {
+	t.Helper()
+	if err := db.AutoMigrate(&models.UserGroup{}); err != nil {
+		t.Fatalf("Failed to migrate user groups: %v", err)
+	}
+	for group, emails := range memberships {
+		for _, email := range emails {
+			if err := db.Create(&models.UserGroup{UserEmail: email, GroupName: group}).Error; err != nil {
+				t.Fatalf("Failed to seed group membership: %v", err)
+			}
+		}
+	}
+}
+
+// previewGroups calls PreviewSendToGroups and decodes the response
+func previewGroups(t *testing.T, h *NotificationHandler, groups ...string) dto.PreviewSendToGroupsResponse {
+	t.Helper()
+	body, _ := json.Marshal(dto.PreviewSendToGroupsRequest{Groups: groups})
+	req := httptest.NewRequest(http.MethodPost, "/notifications/groups/preview", bytes.NewReader(body))
+	req.Header.Set(headerContentType, contentTypeJSON)
+	req = auth.SetServiceInfo(req, &auth.ServiceInfo{ClientID: "app-1"})
+	w := httptest.NewRecorder()
+
+	h.PreviewSendToGroups(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var resp dto.PreviewSendToGroupsResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	return resp
+}
+
+// TestPreviewSendToGroups_OverlappingGroups tests that users in several groups are counted once, under their first group
+func TestPreviewSendToGroups_OverlappingGroups(t *testing.T) {
+	db := setupTestDB(t)
+	seedGroups(t, db, map[string][]string{
+		"engineering": {"alice@example.com", "bob@example.com"},
+		"sales":       {"bob@example.com", "carol@example.com"},
+	})
+	tokens := []models.DeviceToken{
+		{UserEmail: "alice@example.com", DeviceToken: "alice-phone", Platform: "android", IsActive: true},
+		{UserEmail: "alice@example.com", DeviceToken: "alice-tablet", Platform: "ios", IsActive: true},
+		{UserEmail: "bob@example.com", DeviceToken: "bob-phone", Platform: "ios", IsActive: true},
+		{UserEmail: "carol@example.com", DeviceToken: "carol-phone", Platform: "android", IsActive: true},
+		{UserEmail: "carol@example.com", DeviceToken: "carol-old", Platform: "android", IsActive: true},
+	}
+	if err := db.Create(&tokens).Error; err != nil {
+		t.Fatalf("Failed to seed device tokens: %v", err)
+	}
+	if err := db.Model(&models.DeviceToken{}).Where("device_token = ?", "carol-old").Update("is_active", false).Error; err != nil {
+		t.Fatalf("Failed to deactivate device token: %v", err)
+	}
+	fake := &fakeNotificationService{}
+	h := NewNotificationHandler(db, fake, nil, services.SenderIdentity{})
+
+	resp := previewGroups(t, h, "engineering", "sales", "Engineering")
+
+	want := []dto.GroupAudiencePreview{
+		{Group: "engineering", Users: 2, Devices: 3},
+		{Group: "sales", Users: 1, Devices: 1},
+	}
+	if !reflect.DeepEqual(resp.Groups, want) {
+		t.Errorf("Unexpected group previews:\n got %+v\nwant %+v", resp.Groups, want)
+	}
+	if resp.Users != 3 || resp.Devices != 4 {
+		t.Errorf("Expected 3 users and 4 devices in total, got %d and %d", resp.Users, resp.Devices)
+	}
+	if len(resp.Warnings) != 0 {
+		t.Errorf("Expected no warnings, got %v", resp.Warnings)
+	}
+	if fake.lastTokens != nil {
+		t.Errorf("Expected nothing to be sent, got %v", fake.lastTokens)
+	}
+}
+
+// TestPreviewSendToGroups_NoActiveDevices tests that groups whose users have no active devices are reported with a warning
+func TestPreviewSendToGroups_NoActiveDevices(t *testing.T) {
+	db := setupTestDB(t)
+	seedGroups(t, db, map[string][]string{"interns": {"dave@example.com"}})
+	// Created first and then deactivated, because a zero IsActive would be replaced by the column default
+	if err := db.Create(&models.DeviceToken{UserEmail: "dave@example.com", DeviceToken: "dave-old", Platform: "ios", IsActive: true}).Error; err != nil {
+		t.Fatalf("Failed to seed device token: %v", err)
+	}
+	if err := db.Model(&models.DeviceToken{}).Where("device_token = ?", "dave-old").Update("is_active", false).Error; err != nil {
+		t.Fatalf("Failed to deactivate device token: %v", err)
+	}
+	h := NewNotificationHandler(db, &fakeNotificationService{}, nil, services.SenderIdentity{})
+
+	resp := previewGroups(t, h, "interns", "empty")
+
+	want := []dto.GroupAudiencePreview{
+		{Group: "interns", Users: 1, Devices: 0},
+		{Group: "empty", Users: 0, Devices: 0},
+	}
+	if !reflect.DeepEqual(resp.Groups, want) {
+		t.Errorf("Unexpected group previews:\n got %+v\nwant %+v", resp.Groups, want)
+	}
+	if len(resp.Warnings) != 1 || !strings.Contains(resp.Warnings[0], "interns") {
+		t.Errorf("Expected one warning about interns, got %v", resp.Warnings)
+	}
+}
+
+// TestPreviewSendToGroups_OptedOutAndSharedTokens tests that the preview skips opted-out users and counts a shared token once, as the send does
+func TestPreviewSendToGroups_OptedOutAndSharedTokens(t *testing.T) {
+	db := setupTestDB(t)
+	seedGroups(t, db, map[string][]string{"engineering": {"alice@example.com", "bob@example.com", "carol@example.com"}})
+	tokens := []models.DeviceToken{
+		{UserEmail: "alice@example.com", DeviceToken: "shared-kiosk", Platform: "android", IsActive: true},
+		{UserEmail: "bob@example.com", DeviceToken: "shared-kiosk", Platform: "android", IsActive: true},
+		{UserEmail: "carol@example.com", DeviceToken: "carol-phone", Platform: "ios", IsActive: true},
+	}
+	if err := db.Create(&tokens).Error; err != nil {
+		t.Fatalf("Failed to seed device tokens: %v", err)
+	}
+	if err := db.Create(&models.NotificationPreference{UserEmail: "carol@example.com", MicroappID: "app-1", OptedOut: true}).Error; err != nil {
+		t.Fatalf("Failed to seed notification preference: %v", err)
+	}
+	h := NewNotificationHandler(db, &fakeNotificationService{}, nil, services.SenderIdentity{})
+
+	resp := previewGroups(t, h, "engineering")
+
+	want := []dto.GroupAudiencePreview{{Group: "engineering", Users: 2, SkippedOptedOut: 1, Devices: 1}}
+	if !reflect.DeepEqual(resp.Groups, want) {
+		t.Errorf("Unexpected group previews:\n got %+v\nwant %+v", resp.Groups, want)
+	}
+	if resp.Users != 2 || resp.SkippedOptedOut != 1 || resp.Devices != 1 {
+		t.Errorf("Expected 2 users, 1 opted out and 1 device in total, got %+v", resp)
+	}
+}
+
+// revokeUserDevices calls RevokeUserDevices as an admin and decodes the response
+func revokeUserDevices(t *testing.T, h *NotificationHandler, email string) dto.RevokeUserDevicesResponse {
+	t.Helper()
+	body, _ := json.Marshal(dto.RevokeUserDevicesRequest{Email: email})
+	req := httptest.NewRequest(http.MethodPost, "/device-tokens/revoke", bytes.NewReader(body))
+	req.Header.Set(headerContentType, contentTypeJSON)
+	req = auth.SetUserInfo(req, &auth.CustomJwtPayload{Email: "admin@example.com"})
+	w := httptest.NewRecorder()
+
+	h.RevokeUserDevices(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var resp dto.RevokeUserDevicesResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	return resp
+}
+
+// TestRevokeUserDevices tests that a revoke deactivates the user's devices, drops them from pending
+// schedules and group memberships, and that repeating it changes nothing
+func TestRevokeUserDevices(t *testing.T) {
+	db := setupTestDB(t)
+	if err := db.AutoMigrate(&models.ScheduledNotification{}); err != nil {
+		t.Fatalf("Failed to migrate scheduled notifications: %v", err)
+	}
+	seedGroups(t, db, map[string][]string{"engineering": {"alice@example.com", "bob@example.com"}})
+	for _, token := range []models.DeviceToken{
+		{UserEmail: "alice@example.com", DeviceToken: "alice-ios", Platform: "ios", IsActive: true},
+		{UserEmail: "alice@example.com", DeviceToken: "alice-android", Platform: "android", IsActive: true},
+		{UserEmail: "bob@example.com", DeviceToken: "bob-ios", Platform: "ios", IsActive: true},
+	} {
+		if err := db.Create(&token).Error; err != nil {
+			t.Fatalf("Failed to seed device token: %v", err)
+		}
+	}
+	sendAt := time.Now().Add(time.Hour)
+	shared := models.ScheduledNotification{MicroappID: "app-1", UserEmails: models.JSONStringSlice{"alice@example.com", "bob@example.com"}, Title: "t", Body: "b", SendAt: sendAt, Status: models.ScheduledStatusPending}
+	aliceOnly := models.ScheduledNotification{MicroappID: "app-1", UserEmails: models.JSONStringSlice{"alice@example.com"}, Title: "t", Body: "b", SendAt: sendAt, Status: models.ScheduledStatusPending}
+	sent := models.ScheduledNotification{MicroappID: "app-1", UserEmails: models.JSONStringSlice{"alice@example.com"}, Title: "t", Body: "b", SendAt: sendAt, Status: models.ScheduledStatusSent}
+	for _, scheduled := range []*models.ScheduledNotification{&shared, &aliceOnly, &sent} {
+		if err := db.Create(scheduled).Error; err != nil {
+			t.Fatalf("Failed to seed scheduled notification: %v", err)
+		}
+	}
+	h := NewNotificationHandler(db, &fakeNotificationService{}, nil, services.SenderIdentity{})
+
+	resp := revokeUserDevices(t, h, "alice@example.com")
+
+	want := dto.RevokeUserDevicesResponse{
+		Email:                           "alice@example.com",
+		DevicesDeactivated:              2,
+		ScheduledNotificationsUpdated:   1,
+		ScheduledNotificationsCancelled: 1,
+		GroupMembershipsRemoved:         1,
+	}
+	if resp != want {
+		t.Errorf("Unexpected response:\n got %+v\nwant %+v", resp, want)
+	}
+
+	var active []string
+	db.Model(&models.DeviceToken{}).Where("is_active = ?", true).Pluck("device_token", &active)
+	if !reflect.DeepEqual(active, []string{"bob-ios"}) {
+		t.Errorf("Expected only bob-ios to stay active, got %v", active)
+	}
+	var reloaded models.ScheduledNotification
+	if err := db.First(&reloaded, shared.ID).Error; err != nil {
+		t.Fatalf("Failed to reload shared schedule: %v", err)
+	}
+	if !reflect.DeepEqual(reloaded.UserEmails, models.JSONStringSlice{"bob@example.com"}) {
+		t.Errorf("Expected shared schedule to keep only bob, got %v", reloaded.UserEmails)
+	}
+	if err := db.First(&models.ScheduledNotification{}, aliceOnly.ID).Error; !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Errorf("Expected alice-only schedule to be cancelled, got %v", err)
+	}
+	if err := db.First(&models.ScheduledNotification{}, sent.ID).Error; err != nil {
+		t.Errorf("Expected sent schedule to be kept, got %v", err)
+	}
+	var groups int64
+	db.Model(&models.UserGroup{}).Where("user_email = ?", "alice@example.com").Count(&groups)
+	if groups != 0 {
+		t.Errorf("Expected alice's group memberships to be removed, got %d", groups)
+	}
+
+	again := revokeUserDevices(t, h, "alice@example.com")
+	if again != (dto.RevokeUserDevicesResponse{Email: "alice@example.com"}) {
+		t.Errorf("Expected a repeated revoke to change nothing, got %+v", again)
+	}
+}
+
+// getHistory fetches a page of alice's notification history with the given query string
+func getHistory(t *testing.T, h *NotificationHandler, query string) (int, dto.NotificationHistoryResponse) {
+	t.Helper()
+	w := httptest.NewRecorder()
+	h.GetNotificationHistory(w, newUserRequest(http.MethodGet, "/notifications?"+query, "alice@example.com", ""))
+	var resp dto.NotificationHistoryResponse
+	if w.Code == http.StatusOK {
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("Failed to decode history: %v", err)
+		}
+	}
+	return w.Code, resp
+}
+
+// historyIDs returns the IDs of a history page in order
+func historyIDs(resp dto.NotificationHistoryResponse) []int64 {
+	ids := make([]int64, len(resp.Notifications))
+	for i, n := range resp.Notifications {
+		ids[i] = n.ID
+	}
+	return ids
+}
+
+// TestGetNotificationHistory_Cursor tests walking the history backwards and forwards with cursors
+func TestGetNotificationHistory_Cursor(t *testing.T) {
+	db := setupTestDB(t)
+	h := &NotificationHandler{db: db}
+
+	base := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	// IDs 1-5 belong to alice; 3 and 4 share a timestamp so the ID breaks the tie
+	for _, minute := range []int{0, 1, 2, 2, 3} {
+		seedNotificationLog(t, db, "alice@example.com", "app-1", "", base.Add(time.Duration(minute)*time.Minute))
+	}
+	seedNotificationLog(t, db, "bob@example.com", "app-1", "", base.Add(10*time.Minute))
+	seedNotificationLog(t, db, "alice@example.com", "app-2", "", base.Add(-time.Minute))
+
+	_, first := getHistory(t, h, "limit=2&microappId=app-1")
+	if got := historyIDs(first); !reflect.DeepEqual(got, []int64{5, 4}) {
+		t.Fatalf("Expected first page [5 4], got %v", got)
+	}
+	if first.NextCursor == "" || first.PrevCursor != "" {
+		t.Fatalf("Expected only a next cursor on the first page, got next %q prev %q", first.NextCursor, first.PrevCursor)
+	}
+
+	_, second := getHistory(t, h, "limit=2&microappId=app-1&before="+first.NextCursor)
+	if got := historyIDs(second); !reflect.DeepEqual(got, []int64{3, 2}) {
+		t.Fatalf("Expected second page [3 2], got %v", got)
+	}
+
+	_, last := getHistory(t, h, "limit=2&microappId=app-1&before="+second.NextCursor)
+	if got := historyIDs(last); !reflect.DeepEqual(got, []int64{1}) {
+		t.Fatalf("Expected last page [1], got %v", got)
+	}
+	if last.NextCursor != "" || last.PrevCursor == "" {
+		t.Errorf("Expected only a prev cursor on the last page, got next %q prev %q", last.NextCursor, last.PrevCursor)
+	}
+
+	// Walking forwards returns pages in newest-first order
+	_, back := getHistory(t, h, "limit=2&microappId=app-1&after="+last.PrevCursor)
+	if got := historyIDs(back); !reflect.DeepEqual(got, []int64{3, 2}) {
+		t.Fatalf("Expected [3 2] walking forwards, got %v", got)
+	}
+	if back.PrevCursor == "" || back.NextCursor == "" {
+		t.Errorf("Expected both cursors walking forwards, got next %q prev %q", back.NextCursor, back.PrevCursor)
+	}
+	_, top := getHistory(t, h, "limit=2&microappId=app-1&after="+back.PrevCursor)
+	if got := historyIDs(top); !reflect.DeepEqual(got, []int64{5, 4}) {
+		t.Fatalf("Expected [5 4] at the top, got %v", got)
+	}
+	if top.PrevCursor != "" {
+		t.Errorf("Expected no prev cursor at the top, got %q", top.PrevCursor)
+	}
+
+	// Without the filter alice's other micro app is included, and bob's notification never is
+	_, all := getHistory(t, h, "limit=100")
+	if got := historyIDs(all); !reflect.DeepEqual(got, []int64{5, 4, 3, 2, 1, 7}) {
+		t.Errorf("Expected all of alice's notifications, got %v", got)
+	}
+
+	// Oldest first is paged by offset and has no cursors
+	_, oldest := getHistory(t, h, "limit=2&offset=1&order=asc")
+	if got := historyIDs(oldest); !reflect.DeepEqual(got, []int64{1, 2}) || oldest.NextCursor != "" || oldest.PrevCursor != "" {
+		t.Errorf("Expected [1 2] oldest first without cursors, got %v next %q prev %q", got, oldest.NextCursor, oldest.PrevCursor)
+	}
+
+	for _, query := range []string{
+		"before=not-a-cursor",
+		"before=" + first.NextCursor + "&after=" + first.NextCursor,
+		"offset=2&after=" + first.NextCursor,
+		"order=asc&before=" + first.NextCursor,
+		"sort=title",
+		"order=newest",
+	} {
+		if code, _ := getHistory(t, h, query); code != http.StatusBadRequest {
+			t.Errorf("Expected status 400 for %q, got %d", query, code)
+		}
+	}
+}
+
+// TestRegisterDeviceToken_Web tests that browsers register FCM web tokens and that push subscriptions are rejected
+func TestRegisterDeviceToken_Web(t *testing.T) {
+	db := setupTestDB(t)
+	h := NewNotificationHandler(db, nil, nil, services.SenderIdentity{})
+	webToken := "fWebTok3n:APA91bH" + strings.Repeat("x-Y_z", 30)
+
+	tests := []struct {
+		name       string
+		platform   string
+		token      string
+		wantStatus int
+	}{
+		{"web registration token", "web", webToken, http.StatusCreated},
+		{"push subscription JSON", "web", `{"endpoint":"https://fcm.googleapis.com/fcm/send/abc","keys":{"p256dh":"k","auth":"a"}}`, http.StatusBadRequest},
+		{"too short", "web", "abc123", http.StatusBadRequest},
+		{"unknown platform", "desktop", webToken, http.StatusBadRequest},
+		{"android token is not checked", "android", "short", http.StatusCreated},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body, _ := json.Marshal(dto.RegisterDeviceTokenRequest{Email: "alice@example.com", Token: tt.token, Platform: tt.platform})
+			req := httptest.NewRequest(http.MethodPost, "/notifications/register", bytes.NewReader(body))
+			req.Header.Set(headerContentType, contentTypeJSON)
+			req = auth.SetUserInfo(req, &auth.CustomJwtPayload{Email: "alice@example.com"})
+			w := httptest.NewRecorder()
+			h.RegisterDeviceToken(w, req)
+			if w.Code != tt.wantStatus {
+				t.Errorf("Expected status %d, got %d: %s", tt.wantStatus, w.Code, w.Body.String())
+			}
+		})
+	}
+
+	var stored models.DeviceToken
+	if err := db.Where("user_email = ? AND platform = ?", "alice@example.com", "web").First(&stored).Error; err != nil || stored.DeviceToken != webToken {
+		t.Errorf("Expected the web token to be stored, got %+v (%v)", stored, err)
+	}
+}
+
+// TestRegisterDeviceToken_PlatformCasing tests that platforms are stored lowercased and trimmed
+func TestRegisterDeviceToken_PlatformCasing(t *testing.T) {
+	db := setupTestDB(t)
+	h := NewNotificationHandler(db, nil, nil, services.SenderIdentity{})
+
+	tests := []struct {
+		platform   string
+		wantStatus int
+		wantStored string
+	}{
+		{"iOS", http.StatusCreated, "ios"},
+		{" Android ", http.StatusCreated, "android"},
+		{"ANDROID", http.StatusCreated, "android"},
+		{"Windows", http.StatusBadRequest, ""},
+		{"  ", http.StatusBadRequest, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.platform, func(t *testing.T) {
+			body, _ := json.Marshal(dto.RegisterDeviceTokenRequest{Email: "alice@example.com", Token: "token-" + tt.platform, Platform: tt.platform})
+			req := httptest.NewRequest(http.MethodPost, "/notifications/register", bytes.NewReader(body))
+			req.Header.Set(headerContentType, contentTypeJSON)
+			req = auth.SetUserInfo(req, &auth.CustomJwtPayload{Email: "alice@example.com"})
+			w := httptest.NewRecorder()
+			h.RegisterDeviceToken(w, req)
+			if w.Code != tt.wantStatus {
+				t.Fatalf("Expected status %d, got %d: %s", tt.wantStatus, w.Code, w.Body.String())
+			}
+			if tt.wantStatus == http.StatusBadRequest && tt.platform == "Windows" && strings.TrimSpace(w.Body.String()) != errUnsupportedPlatform {
+				t.Errorf("Expected %q, got %q", errUnsupportedPlatform, w.Body.String())
+			}
+			if tt.wantStored == "" {
+				return
+			}
+			var stored models.DeviceToken
+			if err := db.Where("device_token = ?", "token-"+tt.platform).First(&stored).Error; err != nil || stored.Platform != tt.wantStored {
+				t.Errorf("Expected platform %q to be stored, got %+v (%v)", tt.wantStored, stored, err)
+			}
+		})
+	}
+
+	// Deactivation normalizes the platform the same way, so it finds the token registered above
+	body, _ := json.Marshal(dto.DeactivateDeviceTokenRequest{Email: "alice@example.com", Token: "token-ANDROID", Platform: "Android"})
+	req := httptest.NewRequest(http.MethodPost, "/notifications/deactivate", bytes.NewReader(body))
+	req.Header.Set(headerContentType, contentTypeJSON)
+	req = auth.SetUserInfo(req, &auth.CustomJwtPayload{Email: "alice@example.com"})
+	w := httptest.NewRecorder()
+	h.DeactivateDeviceToken(w, req)
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected status 200 deactivating with a capitalized platform, got %d: %s", w.Code, w.Body.String())
+	}
+}
+
+// TestRegisterDeviceToken_MultipleDevices tests that a user's devices on one platform coexist and are listed
+func TestRegisterDeviceToken_MultipleDevices(t *testing.T) {
+	db := setupTestDB(t)
+	h := NewNotificationHandler(db, nil, nil, services.SenderIdentity{})
+	register := func(token string) {
+		t.Helper()
+		body, _ := json.Marshal(dto.RegisterDeviceTokenRequest{Email: "alice@example.com", Token: token, Platform: "android"})
+		req := httptest.NewRequest(http.MethodPost, "/device-tokens", bytes.NewReader(body))
+		req.Header.Set(headerContentType, contentTypeJSON)
+		req = auth.SetUserInfo(req, &auth.CustomJwtPayload{Email: "alice@example.com"})
+		w := httptest.NewRecorder()
+		h.RegisterDeviceToken(w, req)
+		if w.Code != http.StatusCreated {
+			t.Fatalf("Expected status 201, got %d: %s", w.Code, w.Body.String())
+		}
+	}
+	register("phone-1")
+	register("phone-2")
+	register("phone-1") // re-registering a device does not add a row
+	oldPhone := models.DeviceToken{UserEmail: "alice@example.com", DeviceToken: "old-phone", Platform: "ios", IsActive: true}
+	db.Create(&oldPhone)
+	db.Model(&oldPhone).Update("is_active", false)
+	db.Create(&models.DeviceToken{UserEmail: "bob@example.com", DeviceToken: "bob-phone", Platform: "android", IsActive: true})
+
+	var count int64
+	db.Model(&models.DeviceToken{}).Where("user_email = ? AND platform = ?", "alice@example.com", "android").Count(&count)
+	if count != 2 {
+		t.Fatalf("Expected 2 android devices, got %d", count)
+	}
+
+	req := auth.SetUserInfo(httptest.NewRequest(http.MethodGet, "/notifications/devices", nil), &auth.CustomJwtPayload{Email: "alice@example.com"})
+	w := httptest.NewRecorder()
+	h.ListDevices(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", w.Code)
+	}
+	var resp dto.DeviceListResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	tokens := map[string]bool{}
+	for _, device := range resp.Devices {
+		tokens[device.Token] = true
+	}
+	if len(resp.Devices) != 2 || !tokens["phone-1"] || !tokens["phone-2"] {
+		t.Errorf("Expected alice's two active devices, got %+v", resp.Devices)
+	}
+}
+
+// TestDeactivateAllDevices tests that signing out everywhere deactivates only the caller's devices
+func TestDeactivateAllDevices(t *testing.T) {
+	db := setupTestDB(t)
+	for _, token := range []models.DeviceToken{
+		{UserEmail: "alice@example.com", DeviceToken: "alice-phone-1", Platform: "android", IsActive: true},
+		{UserEmail: "alice@example.com", DeviceToken: "alice-phone-2", Platform: "android", IsActive: true},
+		{UserEmail: "bob@example.com", DeviceToken: "bob-ios", Platform: "ios", IsActive: true},
+	} {
+		if err := db.Create(&token).Error; err != nil {
+			t.Fatalf("Failed to seed device token: %v", err)
+		}
+	}
+	h := NewNotificationHandler(db, nil, nil, services.SenderIdentity{})
+
+	for _, want := range []int64{2, 0} {
+		req := auth.SetUserInfo(httptest.NewRequest(http.MethodDelete, "/notifications/devices", nil), &auth.CustomJwtPayload{Email: "alice@example.com"})
+		w := httptest.NewRecorder()
+		h.DeactivateAllDevices(w, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d", w.Code)
+		}
+		var resp dto.DeactivateDevicesResponse
+		json.Unmarshal(w.Body.Bytes(), &resp)
+		if resp.DevicesDeactivated != want {
+			t.Errorf("Expected %d devices deactivated, got %d", want, resp.DevicesDeactivated)
+		}
+	}
+
+	var active []string
+	db.Model(&models.DeviceToken{}).Where("is_active = ?", true).Pluck("device_token", &active)
+	if len(active) != 1 || active[0] != "bob-ios" {
+		t.Errorf("Expected only bob's device to stay active, got %v", active)
+	}
+}
+
+// TestRevokeUserDevicesByEmail tests the admin revocation addressed by the email URL parameter
+func TestRevokeUserDevicesByEmail(t *testing.T) {
+	db := setupTestDB(t)
+	if err := db.AutoMigrate(&models.ScheduledNotification{}); err != nil {
+		t.Fatalf("Failed to migrate scheduled notifications: %v", err)
+	}
+	seedGroups(t, db, map[string][]string{"engineering": {"alice@example.com"}})
+	db.Create(&models.DeviceToken{UserEmail: "alice@example.com", DeviceToken: "alice-ios", Platform: "ios", IsActive: true})
+	h := NewNotificationHandler(db, nil, nil, services.SenderIdentity{})
+
+	revoke := func(email string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodDelete, "/admin/users/"+email+"/devices", nil)
+		rctx := chi.NewRouteContext()
+		rctx.URLParams.Add(paramEmail, email)
+		req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+		req = auth.SetUserInfo(req, &auth.CustomJwtPayload{Email: "admin@example.com", Groups: []string{"admin"}})
+		w := httptest.NewRecorder()
+		h.RevokeUserDevicesByEmail(w, req)
+		return w
+	}
+
+	w := revoke("alice@example.com")
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var resp dto.RevokeUserDevicesResponse
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	if resp.Email != "alice@example.com" || resp.DevicesDeactivated != 1 || resp.GroupMembershipsRemoved != 1 {
+		t.Errorf("Expected alice's device to be deactivated, got %+v", resp)
+	}
+	if w := revoke("not-an-email"); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for an invalid email, got %d", w.Code)
+	}
+}
@@ -0,0 +1,40 @@
+// Copyright (c) 2025 WSO2 LLC. (https://www.wso2.com).
+//
+// WSO2 LLC. licenses this file to you under the Apache License,
+// Version 2.0 (the "License"); you may not use this file except
+// in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+package handler
+
+import (
+	"log/slog"
+	"net/http"
+
+	"github.com/opensuperapp/opensuperapp/backend-services/core/internal/auth"
+	"github.com/opensuperapp/opensuperapp/backend-services/core/internal/auth/rbac"
+)
+
+// RBACHandler serves admin endpoints for the database-backed RBAC permissions
+type RBACHandler struct{}
+
+func NewRBACHandler() *RBACHandler {
+	return &RBACHandler{}
+}
+
+// ReloadPermissions drops the cached group permissions, so role_permissions changes apply immediately
+func (h *RBACHandler) ReloadPermissions(w http.ResponseWriter, r *http.Request) {
+	rbac.ReloadPermissions()
+	if userInfo, ok := auth.GetUserInfo(r.Context()); ok {
+		slog.InfoContext(r.Context(), "RBAC permission cache reloaded", "admin", userInfo.Email)
+	}
+	writeJSON(w, http.StatusOK, map[string]string{"message": msgPermissionsReloaded})
+}
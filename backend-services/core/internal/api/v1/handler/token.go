@@ -32,7 +32,11 @@ import (
 	"github.com/opensuperapp/opensuperapp/backend-services/core/internal/config"
 	"github.com/opensuperapp/opensuperapp/backend-services/core/internal/models"
 	"github.com/opensuperapp/opensuperapp/backend-services/core/internal/services"
+	"github.com/opensuperapp/opensuperapp/backend-services/core/internal/tracing"
 
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 	"gorm.io/gorm"
 )
 
@@ -47,7 +51,7 @@ func NewTokenHandler(db *gorm.DB, cfg *config.Config, serviceTokenValidator serv
 	return &TokenHandler{
 		db:                    db,
 		cfg:                   cfg,
-		httpClient:            &http.Client{Timeout: defaultHTTPTimeout},
+		httpClient:            &http.Client{Timeout: defaultHTTPTimeout, Transport: tracing.Transport(http.DefaultTransport)},
 		serviceTokenValidator: serviceTokenValidator,
 	}
 }
@@ -65,32 +69,20 @@ func (h *TokenHandler) ExchangeToken(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	var req dto.TokenExchangeRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, errInvalidRequestBody, http.StatusBadRequest)
+	if !decodeJSONBody(w, r, &req) {
 		return
 	}
 	if req.MicroappID == "" {
 		http.Error(w, errMissingMicroAppID, http.StatusBadRequest)
 		return
 	}
-	// Validate that the microapp exists and is active
-	var microapp models.MicroApp
-	if err := h.db.WithContext(r.Context()).
-		Where("micro_app_id = ? AND active = ?", req.MicroappID, models.StatusActive).
-		First(&microapp).Error; err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			slog.Warn("Microapp not found or inactive", "microappID", req.MicroappID, "user", userInfo.Email)
-			http.Error(w, errMicroAppNotFoundOrInactive, http.StatusNotFound)
-		} else {
-			slog.Error("Failed to validate microapp", "error", err, "microappID", req.MicroappID)
-			http.Error(w, errFailedToValidateMicroApp, http.StatusInternalServerError)
-		}
+	if !h.requireActiveMicroApp(w, r, req.MicroappID, userInfo.Email) {
 		return
 	}
 	// Call internal IDP to generate microapp-scoped token
 	token, expiresIn, err := h.requestMicroappToken(r.Context(), userInfo.Email, req.MicroappID, req.Scope)
 	if err != nil {
-		slog.Error("Failed to exchange token", "error", err, "user", userInfo.Email, "microapp", req.MicroappID)
+		slog.ErrorContext(r.Context(), "Failed to exchange token", "error", err, "user", userInfo.Email, "microapp", req.MicroappID)
 		http.Error(w, errServerError, http.StatusInternalServerError)
 		return
 	}
@@ -100,10 +92,43 @@ func (h *TokenHandler) ExchangeToken(w http.ResponseWriter, r *http.Request) {
 		TokenType:   tokenTypeBearer,
 		ExpiresIn:   expiresIn,
 	}
-	slog.Info("Token exchanged successfully", "user", userInfo.Email, "microapp", req.MicroappID)
+	slog.InfoContext(r.Context(), "Token exchanged successfully", "user", userInfo.Email, "microapp", req.MicroappID)
 	writeJSON(w, http.StatusOK, response)
 }
 
+// PreviewToken returns the claims of the user-context token a user would receive for a microapp,
+// without issuing a token. It is admin only and used to debug scope and audience issues.
+func (h *TokenHandler) PreviewToken(w http.ResponseWriter, r *http.Request) {
+	if !validateContentType(w, r) {
+		return
+	}
+	limitRequestBody(w, r, 0)
+	adminInfo, ok := auth.GetUserInfo(r.Context())
+	if !ok {
+		http.Error(w, errUserInfoNotFound, http.StatusUnauthorized)
+		return
+	}
+	var req dto.TokenPreviewRequest
+	if !decodeJSONBody(w, r, &req) {
+		return
+	}
+	if !validateStruct(w, &req) {
+		return
+	}
+	if !h.requireActiveMicroApp(w, r, req.MicroappID, req.UserEmail) {
+		return
+	}
+	data := userContextForm(req.UserEmail, req.MicroappID, req.Scope)
+	var preview dto.TokenPreviewResponse
+	if err := h.postToIdP(r.Context(), "/oauth/token/user/preview", data, &preview); err != nil {
+		slog.ErrorContext(r.Context(), "Failed to preview token", "error", err, "user", req.UserEmail, "microapp", req.MicroappID)
+		http.Error(w, errServerError, http.StatusInternalServerError)
+		return
+	}
+	slog.InfoContext(r.Context(), "Token previewed", "admin", adminInfo.Email, "user", req.UserEmail, "microapp", req.MicroappID)
+	writeJSON(w, http.StatusOK, preview)
+}
+
 // ProxyOAuthToken proxies OAuth token requests to the internal IDP
 // This allows microapp backends to get service tokens without exposing the IDP
 // Supports: Basic Auth header, form data with credentials, JSON body
@@ -139,8 +164,7 @@ func (h *TokenHandler) ProxyOAuthToken(w http.ResponseWriter, r *http.Request) {
 	} else if mediaType == contentTypeJSON {
 		// JSON body - parse as map to preserve all fields
 		var reqBody map[string]any
-		if err := json.NewDecoder(r.Body).Decode(&reqBody); err != nil {
-			http.Error(w, errInvalidRequestBody, http.StatusBadRequest)
+		if !decodeJSONBody(w, r, &reqBody) {
 			return
 		}
 		// Extract required fields
@@ -189,16 +213,17 @@ func (h *TokenHandler) ProxyOAuthToken(w http.ResponseWriter, r *http.Request) {
 	idpURL := fmt.Sprintf("%s/oauth/token", h.cfg.InternalIdPBaseURL)
 	req, err := http.NewRequestWithContext(r.Context(), http.MethodPost, idpURL, bytes.NewBufferString(forwardBody))
 	if err != nil {
-		slog.Error("Failed to create IDP request", "error", err)
+		slog.ErrorContext(r.Context(), "Failed to create IDP request", "error", err)
 		http.Error(w, errServerError, http.StatusInternalServerError)
 		return
 	}
 
 	req.Header.Set(headerContentType, contentTypeForm)
+	forwardRequestID(req)
 	// Call internal IDP
 	resp, err := h.httpClient.Do(req)
 	if err != nil {
-		slog.Error("Failed to call IDP", "error", err)
+		slog.ErrorContext(r.Context(), "Failed to call IDP", "error", err)
 		http.Error(w, errServerError, http.StatusInternalServerError)
 		return
 	}
@@ -207,7 +232,7 @@ func (h *TokenHandler) ProxyOAuthToken(w http.ResponseWriter, r *http.Request) {
 	limitedBody := io.LimitReader(resp.Body, IdPResponseBodyLimit)
 	body, err := io.ReadAll(limitedBody)
 	if err != nil {
-		slog.Error("Failed to read IDP response", "error", err)
+		slog.ErrorContext(r.Context(), "Failed to read IDP response", "error", err)
 		http.Error(w, errServerError, http.StatusInternalServerError)
 		return
 	}
@@ -217,9 +242,9 @@ func (h *TokenHandler) ProxyOAuthToken(w http.ResponseWriter, r *http.Request) {
 	w.Write(body)
 
 	if resp.StatusCode == http.StatusOK {
-		slog.Info("OAuth token proxied successfully", "client_id", clientID)
+		slog.InfoContext(r.Context(), "OAuth token proxied successfully", "client_id", clientID)
 	} else {
-		slog.Warn("OAuth token request failed", "client_id", clientID, "status", resp.StatusCode)
+		slog.WarnContext(r.Context(), "OAuth token request failed", "client_id", clientID, "status", resp.StatusCode)
 	}
 }
 
@@ -231,19 +256,37 @@ func (h *TokenHandler) GetJWKS(w http.ResponseWriter, r *http.Request) {
 	}
 	jwks, err := h.serviceTokenValidator.GetJWKS()
 	if err != nil {
-		slog.Error("Failed to get JWKS", "error", err)
+		slog.ErrorContext(r.Context(), "Failed to get JWKS", "error", err)
 		http.Error(w, errServerError, http.StatusInternalServerError)
 		return
 	}
 	w.Header().Set(headerContentType, contentTypeJSON)
 	w.Header().Set(headerCacheControl, cacheControlPublic)
+	w.Header().Set(headerSurrogateKey, surrogateKeyJWKS)
+	w.Header().Set(headerCacheTag, surrogateKeyJWKS)
 	w.Write(jwks)
 }
 
-// requestMicroappToken calls the internal IDP to generate a microapp-scoped token
-func (h *TokenHandler) requestMicroappToken(ctx context.Context, userEmail, microappID, scope string) (string, int, error) {
-	// Prepare request to internal IDP
-	idpURL := fmt.Sprintf("%s/oauth/token/user", h.cfg.InternalIdPBaseURL)
+// requireActiveMicroApp checks that the microapp exists and is active, writing the error response otherwise
+func (h *TokenHandler) requireActiveMicroApp(w http.ResponseWriter, r *http.Request, microappID, userEmail string) bool {
+	var microapp models.MicroApp
+	if err := h.db.WithContext(r.Context()).
+		Where("micro_app_id = ? AND active = ?", microappID, models.StatusActive).
+		First(&microapp).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			slog.WarnContext(r.Context(), "Microapp not found or inactive", "microappID", microappID, "user", userEmail)
+			http.Error(w, errMicroAppNotFoundOrInactive, http.StatusNotFound)
+		} else {
+			slog.ErrorContext(r.Context(), "Failed to validate microapp", "error", err, "microappID", microappID)
+			http.Error(w, errFailedToValidateMicroApp, http.StatusInternalServerError)
+		}
+		return false
+	}
+	return true
+}
+
+// userContextForm builds the internal IDP form for a user-context token
+func userContextForm(userEmail, microappID, scope string) url.Values {
 	data := url.Values{}
 	data.Set(paramGrantType, grantTypeUserContext)
 	data.Set(paramUserEmail, userEmail)
@@ -251,30 +294,56 @@ func (h *TokenHandler) requestMicroappToken(ctx context.Context, userEmail, micr
 	if scope != "" {
 		data.Set(paramScope, scope)
 	}
+	return data
+}
+
+// requestMicroappToken calls the internal IDP to generate a microapp-scoped token
+func (h *TokenHandler) requestMicroappToken(ctx context.Context, userEmail, microappID, scope string) (string, int, error) {
+	ctx, span := tracing.Tracer().Start(ctx, "TokenHandler.requestMicroappToken",
+		trace.WithAttributes(attribute.String("microapp.id", microappID)))
+	defer span.End()
+
+	var tokenResp dto.TokenExchangeResponse
+	if err := h.postToIdP(ctx, "/oauth/token/user", userContextForm(userEmail, microappID, scope), &tokenResp); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return "", 0, err
+	}
+	return tokenResp.AccessToken, tokenResp.ExpiresIn, nil
+}
+
+// postToIdP posts a form to the internal IDP and decodes its JSON response into out
+func (h *TokenHandler) postToIdP(ctx context.Context, path string, data url.Values, out any) error {
+	idpURL := fmt.Sprintf("%s%s", h.cfg.InternalIdPBaseURL, path)
 	req, err := http.NewRequestWithContext(ctx, httpMethodPost, idpURL, bytes.NewBufferString(data.Encode()))
 	if err != nil {
-		return "", 0, fmt.Errorf("%s: %w", errFailedToCreateRequest, err)
+		return fmt.Errorf("%s: %w", errFailedToCreateRequest, err)
 	}
 	req.Header.Set(headerContentType, contentTypeForm)
+	forwardRequestID(req)
 
 	// Call internal IDP
 	resp, err := h.httpClient.Do(req)
 	if err != nil {
-		return "", 0, fmt.Errorf("%s: %w", errFailedToCallIDP, err)
+		return fmt.Errorf("%s: %w", errFailedToCallIDP, err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
 		limitedBody := io.LimitReader(resp.Body, IdPResponseBodyLimit)
 		body, _ := io.ReadAll(limitedBody)
-		return "", 0, fmt.Errorf(errIDPReturnedError, resp.StatusCode, string(body))
+		return fmt.Errorf(errIDPReturnedError, resp.StatusCode, string(body))
 	}
 
-	// Parse response
-	var tokenResp dto.TokenExchangeResponse
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("%s: %w", errFailedToParseIDPResponse, err)
+	}
+	return nil
+}
 
-	if err := json.NewDecoder(resp.Body).Decode(&tokenResp); err != nil {
-		return "", 0, fmt.Errorf("%s: %w", errFailedToParseIDPResponse, err)
+// forwardRequestID passes the request ID on to the IDP so its logs can be correlated with core's
+func forwardRequestID(req *http.Request) {
+	if requestID := auth.RequestID(req.Context()); requestID != "" {
+		req.Header.Set(auth.HeaderRequestID, requestID)
 	}
-	return tokenResp.AccessToken, tokenResp.ExpiresIn, nil
 }
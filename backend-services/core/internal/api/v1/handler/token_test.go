@@ -0,0 +1,213 @@
+// Copyright (c) 2025 WSO2 LLC. (https://www.wso2.com).
+//
+// WSO2 LLC. licenses this file to you under the Apache License,
+// Version 2.0 (the "License"); you may not use this file except
+// in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+package handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/opensuperapp/opensuperapp/backend-services/core/internal/api/v1/dto"
+	"github.com/opensuperapp/opensuperapp/backend-services/core/internal/auth"
+	"github.com/opensuperapp/opensuperapp/backend-services/core/internal/config"
+	"github.com/opensuperapp/opensuperapp/backend-services/core/internal/services"
+	"github.com/opensuperapp/opensuperapp/backend-services/core/internal/tracing"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// previewToken posts a token preview request as an admin
+func previewToken(h *TokenHandler, req dto.TokenPreviewRequest) *httptest.ResponseRecorder {
+	body, _ := json.Marshal(req)
+	r := httptest.NewRequest(http.MethodPost, "/token/preview", bytes.NewReader(body))
+	r.Header.Set("Content-Type", "application/json")
+	r = auth.SetUserInfo(r, &auth.CustomJwtPayload{Email: "admin@example.com", Groups: []string{"admin"}})
+	w := httptest.NewRecorder()
+	h.PreviewToken(w, r)
+	return w
+}
+
+// TestPreviewToken tests that a preview is requested from the IDP for active micro apps only
+func TestPreviewToken(t *testing.T) {
+	db := setupMicroAppTestDB(t)
+	seedMicroApp(t, db, "payroll", []string{"employees"}, 1)
+
+	var gotPath string
+	var gotForm map[string][]string
+	idp := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.Path
+		r.ParseForm()
+		gotForm = r.PostForm
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"claims":{"sub":"alice@example.com","aud":["payroll"],"scope":"payroll:read"},"expires_in":300}`))
+	}))
+	defer idp.Close()
+	h := NewTokenHandler(db, &config.Config{InternalIdPBaseURL: idp.URL}, nil)
+
+	w := previewToken(h, dto.TokenPreviewRequest{UserEmail: "alice@example.com", MicroappID: "payroll", Scope: "payroll:read"})
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if gotPath != "/oauth/token/user/preview" {
+		t.Errorf("Expected the IDP preview endpoint, got %s", gotPath)
+	}
+	for key, want := range map[string]string{"grant_type": "user_context", "user_email": "alice@example.com", "microapp_id": "payroll", "scope": "payroll:read"} {
+		if got := gotForm[key]; len(got) != 1 || got[0] != want {
+			t.Errorf("Expected form %s=%s, got %v", key, want, got)
+		}
+	}
+	var resp dto.TokenPreviewResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	var claims map[string]any
+	if err := json.Unmarshal(resp.Claims, &claims); err != nil {
+		t.Fatalf("Failed to decode claims: %v", err)
+	}
+	if resp.ExpiresIn != 300 || claims["sub"] != "alice@example.com" || claims["scope"] != "payroll:read" {
+		t.Errorf("Expected the IDP claims to be returned, got %s", w.Body.String())
+	}
+
+	if w := previewToken(h, dto.TokenPreviewRequest{UserEmail: "alice@example.com", MicroappID: "missing"}); w.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404 for an unknown micro app, got %d", w.Code)
+	}
+	if w := previewToken(h, dto.TokenPreviewRequest{UserEmail: "not-an-email", MicroappID: "payroll"}); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for an invalid email, got %d", w.Code)
+	}
+}
+
+// TestExchangeToken_ForwardsRequestID tests that the request ID reaches the IDP so logs can be correlated
+func TestExchangeToken_ForwardsRequestID(t *testing.T) {
+	db := setupMicroAppTestDB(t)
+	seedMicroApp(t, db, "payroll", []string{"employees"}, 1)
+
+	var gotRequestID string
+	idp := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotRequestID = r.Header.Get(auth.HeaderRequestID)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"access_token":"token","token_type":"Bearer","expires_in":300}`))
+	}))
+	defer idp.Close()
+	h := NewTokenHandler(db, &config.Config{InternalIdPBaseURL: idp.URL}, nil)
+	exchange := auth.RequestIDMiddleware()(http.HandlerFunc(h.ExchangeToken))
+
+	body, _ := json.Marshal(dto.TokenExchangeRequest{MicroappID: "payroll"})
+	r := httptest.NewRequest(http.MethodPost, "/token/exchange", bytes.NewReader(body))
+	r.Header.Set("Content-Type", "application/json")
+	r.Header.Set(auth.HeaderRequestID, "req-123")
+	r = auth.SetUserInfo(r, &auth.CustomJwtPayload{Email: "alice@example.com", Groups: []string{"employees"}})
+	w := httptest.NewRecorder()
+	exchange.ServeHTTP(w, r)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if gotRequestID != "req-123" {
+		t.Errorf("Expected the IDP to receive request ID req-123, got %q", gotRequestID)
+	}
+	if got := w.Header().Get(auth.HeaderRequestID); got != "req-123" {
+		t.Errorf("Expected the response to echo request ID req-123, got %q", got)
+	}
+}
+
+// TestExchangeToken_TraceSpans tests that the token request is traced under the server span and
+// that the trace context reaches the IDP
+func TestExchangeToken_TraceSpans(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	previousProvider, previousPropagator := otel.GetTracerProvider(), otel.GetTextMapPropagator()
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() {
+		otel.SetTracerProvider(previousProvider)
+		otel.SetTextMapPropagator(previousPropagator)
+		provider.Shutdown(context.Background())
+	})
+
+	db := setupMicroAppTestDB(t)
+	seedMicroApp(t, db, "payroll", []string{"employees"}, 1)
+	var gotTraceparent string
+	idp := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotTraceparent = r.Header.Get("traceparent")
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"access_token":"token","token_type":"Bearer","expires_in":300}`))
+	}))
+	defer idp.Close()
+	h := NewTokenHandler(db, &config.Config{InternalIdPBaseURL: idp.URL}, nil)
+	exchange := tracing.Handler(http.HandlerFunc(h.ExchangeToken))
+
+	body, _ := json.Marshal(dto.TokenExchangeRequest{MicroappID: "payroll"})
+	r := httptest.NewRequest(http.MethodPost, "/token/exchange", bytes.NewReader(body))
+	r.Header.Set("Content-Type", "application/json")
+	r = auth.SetUserInfo(r, &auth.CustomJwtPayload{Email: "alice@example.com", Groups: []string{"employees"}})
+	w := httptest.NewRecorder()
+	exchange.ServeHTTP(w, r)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	spans := make(map[string]sdktrace.ReadOnlySpan)
+	for _, span := range recorder.Ended() {
+		spans[span.Name()] = span
+	}
+	server, token, client := spans["POST"], spans["TokenHandler.requestMicroappToken"], spans["HTTP POST"]
+	if server == nil || token == nil || client == nil {
+		t.Fatalf("Expected server, requestMicroappToken and client spans, got %v", spans)
+	}
+	if token.Parent().SpanID() != server.SpanContext().SpanID() {
+		t.Error("Expected requestMicroappToken to be a child of the server span")
+	}
+	if client.Parent().SpanID() != token.SpanContext().SpanID() {
+		t.Error("Expected the IDP call to be a child of requestMicroappToken")
+	}
+	want := "00-" + client.SpanContext().TraceID().String() + "-" + client.SpanContext().SpanID().String() + "-01"
+	if gotTraceparent != want {
+		t.Errorf("Expected the IDP to receive traceparent %s, got %q", want, gotTraceparent)
+	}
+}
+
+// staticJWKSValidator serves a fixed JWKS
+type staticJWKSValidator struct{}
+
+func (staticJWKSValidator) ValidateToken(string) (*services.TokenClaims, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (staticJWKSValidator) GetJWKS() (json.RawMessage, error) {
+	return json.RawMessage(`{"keys":[]}`), nil
+}
+
+// TestGetJWKS_SurrogateKey tests that the JWKS is tagged for CDN purges
+func TestGetJWKS_SurrogateKey(t *testing.T) {
+	h := NewTokenHandler(nil, &config.Config{}, staticJWKSValidator{})
+	w := httptest.NewRecorder()
+	h.GetJWKS(w, httptest.NewRequest(http.MethodGet, "/.well-known/jwks.json", nil))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", w.Code)
+	}
+	for _, header := range []string{headerSurrogateKey, headerCacheTag} {
+		if got := w.Header().Get(header); got != "jwks" {
+			t.Errorf("Expected %s jwks, got %q", header, got)
+		}
+	}
+}
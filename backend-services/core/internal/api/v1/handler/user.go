@@ -23,6 +23,8 @@ import (
 	"io"
 	"log/slog"
 	"net/http"
+	"strconv"
+	"strings"
 
 	"github.com/opensuperapp/opensuperapp/backend-services/core/internal/api/v1/dto"
 	"github.com/opensuperapp/opensuperapp/backend-services/core/internal/auth"
@@ -53,12 +55,12 @@ func (h *UserHandler) GetUserInfo(w http.ResponseWriter, r *http.Request) {
 	}
 	user, err := h.userService.GetUserByEmail(userInfo.Email)
 	if err != nil {
-		slog.Error("Failed to fetch user info", "error", err, "email", userInfo.Email)
+		slog.ErrorContext(r.Context(), "Failed to fetch user info", "error", err, "email", userInfo.Email)
 		http.Error(w, errFailedToFetchUserInfo, http.StatusInternalServerError)
 		return
 	}
 	if user == nil {
-		slog.Warn("User not found", "email", userInfo.Email)
+		slog.WarnContext(r.Context(), "User not found", "email", userInfo.Email)
 		http.Error(w, errUserNotFound, http.StatusNotFound)
 		return
 	}
@@ -70,19 +72,77 @@ func (h *UserHandler) GetUserInfo(w http.ResponseWriter, r *http.Request) {
 		Location:      user.Location,
 	}
 	if err := writeJSON(w, http.StatusOK, response); err != nil {
-		slog.Error("Failed to write JSON response", "error", err)
+		slog.ErrorContext(r.Context(), "Failed to write JSON response", "error", err)
 		http.Error(w, errFailedToWriteResponse, http.StatusInternalServerError)
 	}
 }
 
-// GetAll retrieves all users from the system.
+// userSort maps the sort query parameter to the user service's sort fields, which the user
+// service turns into columns.
+var userSort = listSort{
+	fields: map[string]string{
+		"firstName": userservice.SortByFirstName,
+		"lastName":  userservice.SortByLastName,
+		"email":     userservice.SortByEmail,
+	},
+	defaultField: "firstName",
+}
+
+// GetAll retrieves users from the system. With a limit, offset, sort, order or search query
+// parameter it returns one page of users with the total count; without any it returns every
+// user as a plain list, which existing clients expect.
 func (h *UserHandler) GetAll(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	if query.Has(queryParamLimit) || query.Has(queryParamOffset) || query.Has(queryParamSort) ||
+		query.Has(queryParamOrder) || query.Has(queryParamSearch) {
+		h.getPage(w, r)
+		return
+	}
 	users, err := h.userService.GetAllUsers()
 	if err != nil {
-		slog.Error("Failed to fetch all users", "error", err)
+		slog.ErrorContext(r.Context(), "Failed to fetch all users", "error", err)
 		http.Error(w, errFailedToFetchUsers, http.StatusInternalServerError)
 		return
 	}
+	if err := writeJSON(w, http.StatusOK, toUserResponses(users)); err != nil {
+		slog.ErrorContext(r.Context(), "Failed to write JSON response", "error", err)
+		http.Error(w, errFailedToWriteResponse, http.StatusInternalServerError)
+	}
+}
+
+// getPage writes one page of users whose email or name starts with the search parameter.
+func (h *UserHandler) getPage(w http.ResponseWriter, r *http.Request) {
+	params, err := parseListParams(r, userSort)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	limit, offset := params.limit, params.offset
+	search := strings.TrimSpace(r.URL.Query().Get(queryParamSearch))
+	if len(search) > maxSearchLength {
+		http.Error(w, errSearchTooLong, http.StatusBadRequest)
+		return
+	}
+	sort := userservice.UserSort{Field: userSort.fields[params.sort], Desc: params.desc}
+	users, total, err := h.userService.GetUsersPaginated(limit, offset, search, sort)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to fetch users", "error", err, "limit", limit, "offset", offset)
+		http.Error(w, errFailedToFetchUsers, http.StatusInternalServerError)
+		return
+	}
+	response := dto.UserListResponse{
+		Users:  toUserResponses(users),
+		Total:  total,
+		Limit:  limit,
+		Offset: offset,
+	}
+	if err := writeJSON(w, http.StatusOK, response); err != nil {
+		slog.ErrorContext(r.Context(), "Failed to write JSON response", "error", err)
+		http.Error(w, errFailedToWriteResponse, http.StatusInternalServerError)
+	}
+}
+
+func toUserResponses(users []*models.User) []dto.UserResponse {
 	response := make([]dto.UserResponse, 0, len(users))
 	for _, user := range users {
 		response = append(response, dto.UserResponse{
@@ -93,10 +153,7 @@ func (h *UserHandler) GetAll(w http.ResponseWriter, r *http.Request) {
 			Location:      user.Location,
 		})
 	}
-	if err := writeJSON(w, http.StatusOK, response); err != nil {
-		slog.Error("Failed to write JSON response", "error", err)
-		http.Error(w, errFailedToWriteResponse, http.StatusInternalServerError)
-	}
+	return response
 }
 
 // Upsert creates a new user(s) or updates an existing one.
@@ -107,12 +164,16 @@ func (h *UserHandler) Upsert(w http.ResponseWriter, r *http.Request) {
 	limitRequestBody(w, r, userRequestBodyLimit)
 	requests, isBulk, err := parseUpsertPayload(r.Body)
 	if err != nil {
-		slog.Error("Invalid request body for upsert", "error", err)
+		slog.ErrorContext(r.Context(), "Invalid request body for upsert", "error", err)
 		var maxBytesErr *http.MaxBytesError
 		if errors.As(err, &maxBytesErr) {
 			http.Error(w, errRequestBodyTooLarge, http.StatusRequestEntityTooLarge)
 			return
 		}
+		if errors.Is(err, io.EOF) {
+			http.Error(w, errEmptyRequestBody, http.StatusBadRequest)
+			return
+		}
 		http.Error(w, errInvalidRequestBody, http.StatusBadRequest)
 		return
 	}
@@ -127,26 +188,90 @@ func (h *UserHandler) Upsert(w http.ResponseWriter, r *http.Request) {
 	// Bulk user upsert
 	if isBulk {
 		if err := h.userService.UpsertUsers(users); err != nil {
-			slog.Error("Failed to upsert bulk users", "error", err, "count", len(users))
+			slog.ErrorContext(r.Context(), "Failed to upsert bulk users", "error", err, "count", len(users))
 			http.Error(w, errFailedToUpsertBulkUsers, http.StatusInternalServerError)
 			return
 		}
 		if err := writeJSON(w, http.StatusCreated, map[string]string{"message": msgUsersBulkSuccess}); err != nil {
-			slog.Error("Failed to write JSON response", "error", err)
+			slog.ErrorContext(r.Context(), "Failed to write JSON response", "error", err)
 		}
 		return
 	}
 	// Single user upsert
 	if err := h.userService.UpsertUser(users[0]); err != nil {
-		slog.Error("Failed to upsert user", "error", err, "email", users[0].Email)
+		slog.ErrorContext(r.Context(), "Failed to upsert user", "error", err, "email", users[0].Email)
 		http.Error(w, errFailedToUpsertUser, http.StatusInternalServerError)
 		return
 	}
 	if err := writeJSON(w, http.StatusCreated, map[string]string{
 		"message": msgUserUpsertSuccess,
 	}); err != nil {
-		slog.Error("Failed to write JSON response", "error", err)
+		slog.ErrorContext(r.Context(), "Failed to write JSON response", "error", err)
+	}
+}
+
+// BulkUpsert creates or updates a JSON array of users in one transaction, reporting each user
+// by its index in the array. By default invalid users are skipped and the rest are saved;
+// ?atomic=true saves nothing unless every user is valid.
+func (h *UserHandler) BulkUpsert(w http.ResponseWriter, r *http.Request) {
+	atomic := false
+	if v := r.URL.Query().Get(queryParamAtomic); v != "" {
+		var err error
+		atomic, err = strconv.ParseBool(v)
+		if err != nil {
+			http.Error(w, errInvalidAtomic, http.StatusBadRequest)
+			return
+		}
+	}
+	if !validateContentType(w, r) {
+		return
+	}
+	limitRequestBody(w, r, userRequestBodyLimit)
+	var requests []dto.UpsertUserRequest
+	if !decodeJSONBody(w, r, &requests) {
+		return
+	}
+	if len(requests) == 0 {
+		http.Error(w, errEmptyRequestBody, http.StatusBadRequest)
+		return
+	}
+
+	rows, rowErrors := validateUserRequests(requests)
+	response := dto.BulkUpsertUsersResponse{Failed: len(rowErrors), Results: make([]dto.BatchItemResult, len(requests))}
+	for i, req := range requests {
+		response.Results[i] = dto.BatchItemResult{Index: i, Key: req.Email, Status: dto.BatchItemSucceeded}
+	}
+	for _, rowErr := range rowErrors {
+		response.Results[rowErr.Row-1].Status = dto.BatchItemFailed
+		response.Results[rowErr.Row-1].Error = rowErr.Error
+	}
+	if len(rowErrors) > 0 && (atomic || len(rows) == 0) {
+		for _, row := range rows {
+			response.Results[row.Row-1].Status = dto.BatchItemSkipped
+		}
+		response.Message = errBulkUsersNotUpserted
+		writeJSON(w, http.StatusBadRequest, response)
+		return
+	}
+
+	users := make([]*models.User, len(rows))
+	for i, row := range rows {
+		users[i] = row.User
+	}
+	if err := h.userService.UpsertUsers(users); err != nil {
+		slog.ErrorContext(r.Context(), "Failed to upsert bulk users", "error", err, "count", len(users))
+		http.Error(w, errFailedToUpsertBulkUsers, http.StatusInternalServerError)
+		return
+	}
+	response.Upserted = len(users)
+	status, partial := batchStatus(response.Results, http.StatusCreated)
+	response.Partial = partial
+	response.Message = msgUsersBulkSuccess
+	if partial {
+		slog.InfoContext(r.Context(), "Bulk user upsert partially succeeded", "upserted", response.Upserted, "failed", response.Failed)
+		response.Message = msgUsersBulkPartial
 	}
+	writeJSON(w, status, response)
 }
 
 // Delete removes a user by their email address.
@@ -161,13 +286,13 @@ func (h *UserHandler) Delete(w http.ResponseWriter, r *http.Request) {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			http.Error(w, errUserNotFound, http.StatusNotFound)
 		} else {
-			slog.Error("Failed to delete user", "error", err, "email", email)
+			slog.ErrorContext(r.Context(), "Failed to delete user", "error", err, "email", email)
 			http.Error(w, errFailedToDeleteUser, http.StatusInternalServerError)
 		}
 		return
 	}
 	if err := writeJSON(w, http.StatusOK, map[string]string{"message": msgUserDeleteSuccess}); err != nil {
-		slog.Error("Failed to write JSON response", "error", err)
+		slog.ErrorContext(r.Context(), "Failed to write JSON response", "error", err)
 		http.Error(w, errFailedToWriteResponse, http.StatusInternalServerError)
 	}
 }
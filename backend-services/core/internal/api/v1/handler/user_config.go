@@ -16,7 +16,6 @@
 package handler
 
 import (
-	"encoding/json"
 	"log/slog"
 	"net/http"
 
@@ -44,7 +43,7 @@ func (h *UserConfigHandler) GetAppConfigs(w http.ResponseWriter, r *http.Request
 	}
 	var configs []models.UserConfig
 	if err := h.db.Where("email = ? AND active = ?", userInfo.Email, 1).Find(&configs).Error; err != nil {
-		slog.Error("Failed to fetch user configs", "error", err, "email", userInfo.Email)
+		slog.ErrorContext(r.Context(), "Failed to fetch user configs", "error", err, "email", userInfo.Email)
 		http.Error(w, errFailedToFetchUserConfigs, http.StatusInternalServerError)
 		return
 	}
@@ -58,7 +57,7 @@ func (h *UserConfigHandler) GetAppConfigs(w http.ResponseWriter, r *http.Request
 		})
 	}
 	if err := writeJSON(w, http.StatusOK, response); err != nil {
-		slog.Error("Failed to write JSON response", "error", err)
+		slog.ErrorContext(r.Context(), "Failed to write JSON response", "error", err)
 		http.Error(w, errFailedToWriteResponse, http.StatusInternalServerError)
 	}
 }
@@ -75,8 +74,7 @@ func (h *UserConfigHandler) UpsertAppConfig(w http.ResponseWriter, r *http.Reque
 	}
 	limitRequestBody(w, r, 0) // 1MB default limit
 	var req dto.UpsertUserConfigRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, errInvalidRequestBody, http.StatusBadRequest)
+	if !decodeJSONBody(w, r, &req) {
 		return
 	}
 
@@ -100,12 +98,12 @@ func (h *UserConfigHandler) UpsertAppConfig(w http.ResponseWriter, r *http.Reque
 			CreatedBy:   userInfo.Email,
 		}).FirstOrCreate(&config)
 	if result.Error != nil {
-		slog.Error("Failed to upsert user config", "error", result.Error, "email", userInfo.Email, "configKey", req.ConfigKey)
+		slog.ErrorContext(r.Context(), "Failed to upsert user config", "error", result.Error, "email", userInfo.Email, "configKey", req.ConfigKey)
 		http.Error(w, errFailedToUpsertUserConfig, http.StatusInternalServerError)
 		return
 	}
 	if err := writeJSON(w, http.StatusCreated, map[string]string{"message": msgConfigurationUpdatedSuccessfully}); err != nil {
-		slog.Error("Failed to write JSON response", "error", err)
+		slog.ErrorContext(r.Context(), "Failed to write JSON response", "error", err)
 		http.Error(w, errFailedToWriteResponse, http.StatusInternalServerError)
 	}
 }
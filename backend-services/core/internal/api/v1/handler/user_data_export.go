@@ -0,0 +1,171 @@
+// Copyright (c) 2025 WSO2 LLC. (https://www.wso2.com).
+//
+// WSO2 LLC. licenses this file to you under the Apache License,
+// Version 2.0 (the "License"); you may not use this file except
+// in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/opensuperapp/opensuperapp/backend-services/core/internal/api/v1/dto"
+	"github.com/opensuperapp/opensuperapp/backend-services/core/internal/auth"
+	"github.com/opensuperapp/opensuperapp/backend-services/core/internal/auth/rbac"
+	"github.com/opensuperapp/opensuperapp/backend-services/core/internal/models"
+	"github.com/opensuperapp/opensuperapp/backend-services/core/internal/services"
+
+	"github.com/go-chi/chi/v5"
+	"gorm.io/gorm"
+)
+
+// userDataExportDownloadPath is where a completed export is downloaded from
+const userDataExportDownloadPath = "/api/v1/users/data-exports/%d/download"
+
+// UserDataExportHandler serves data-subject access exports. Users can export their own data and
+// admins anyone's. Every request and download is logged and recorded on the export.
+type UserDataExportHandler struct {
+	db       *gorm.DB
+	exporter *services.UserDataExporter
+}
+
+func NewUserDataExportHandler(db *gorm.DB, exporter *services.UserDataExporter) *UserDataExportHandler {
+	return &UserDataExportHandler{db: db, exporter: exporter}
+}
+
+// RequestExport starts assembling the data of the user in the path and responds with the export to poll
+func (h *UserDataExportHandler) RequestExport(w http.ResponseWriter, r *http.Request) {
+	userInfo, ok := auth.GetUserInfo(r.Context())
+	if !ok {
+		http.Error(w, errUserInfoNotFound, http.StatusUnauthorized)
+		return
+	}
+	email := chi.URLParam(r, paramEmail)
+	if err := validate.Var(email, "required,email"); err != nil {
+		http.Error(w, errInvalidEmailParameter, http.StatusBadRequest)
+		return
+	}
+	if !strings.EqualFold(email, userInfo.Email) && !rbac.HasRole(userInfo.Groups, rbac.GroupAdmin) {
+		slog.WarnContext(r.Context(), "User data export denied", "requested_by", userInfo.Email, "user_email", email)
+		http.Error(w, errDataExportForbidden, http.StatusForbidden)
+		return
+	}
+
+	export := models.UserDataExport{
+		UserEmail:   email,
+		RequestedBy: userInfo.Email,
+		Status:      models.UserDataExportStatusProcessing,
+	}
+	if err := h.db.Create(&export).Error; err != nil {
+		slog.ErrorContext(r.Context(), "Failed to create user data export", "error", err, "user_email", email)
+		http.Error(w, errFailedToCreateExport, http.StatusInternalServerError)
+		return
+	}
+	// The response is built before the exporter starts updating the export
+	response := toUserDataExportResponse(export)
+	go h.exporter.Run(&export)
+
+	slog.InfoContext(r.Context(), "User data export requested", "export_id", export.ID, "user_email", email, "requested_by", userInfo.Email)
+	writeJSON(w, http.StatusAccepted, response)
+}
+
+// GetExport returns the status of an export, and its download URL once completed
+func (h *UserDataExportHandler) GetExport(w http.ResponseWriter, r *http.Request) {
+	export, _, ok := h.accessibleExport(w, r)
+	if !ok {
+		return
+	}
+	writeJSON(w, http.StatusOK, toUserDataExportResponse(*export))
+}
+
+// DownloadExport serves a completed export as a JSON attachment and records the download
+func (h *UserDataExportHandler) DownloadExport(w http.ResponseWriter, r *http.Request) {
+	export, userInfo, ok := h.accessibleExport(w, r)
+	if !ok {
+		return
+	}
+	if export.Status != models.UserDataExportStatusCompleted {
+		http.Error(w, errDataExportNotReady, http.StatusConflict)
+		return
+	}
+
+	now := time.Now()
+	if err := h.db.Model(export).Updates(map[string]any{
+		"download_count":     gorm.Expr("download_count + 1"),
+		"last_downloaded_by": userInfo.Email,
+		"last_downloaded_at": now,
+	}).Error; err != nil {
+		// Refuse the download rather than hand out data without a record of it
+		slog.ErrorContext(r.Context(), "Failed to record user data export download", "error", err, "export_id", export.ID)
+		http.Error(w, errFailedToFetchDataExport, http.StatusInternalServerError)
+		return
+	}
+	slog.InfoContext(r.Context(), "User data export downloaded", "export_id", export.ID, "user_email", export.UserEmail, "downloaded_by", userInfo.Email)
+
+	w.Header().Set(headerContentType, contentTypeJSON)
+	w.Header().Set(contentDisposition, fmt.Sprintf("attachment; filename=\"user-data-%d.json\"", export.ID))
+	w.Write(export.Data)
+}
+
+// accessibleExport loads the export in the path if the caller is its subject, its requester or an
+// admin. Exports of other users are reported as not found, so their IDs reveal nothing.
+func (h *UserDataExportHandler) accessibleExport(w http.ResponseWriter, r *http.Request) (*models.UserDataExport, *auth.CustomJwtPayload, bool) {
+	userInfo, ok := auth.GetUserInfo(r.Context())
+	if !ok {
+		http.Error(w, errUserInfoNotFound, http.StatusUnauthorized)
+		return nil, nil, false
+	}
+	exportID, err := strconv.ParseInt(chi.URLParam(r, urlParamExportID), 10, 64)
+	if err != nil || exportID <= 0 {
+		http.Error(w, errInvalidDataExportID, http.StatusBadRequest)
+		return nil, nil, false
+	}
+	var export models.UserDataExport
+	if err := h.db.First(&export, exportID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			http.Error(w, errDataExportNotFound, http.StatusNotFound)
+			return nil, nil, false
+		}
+		slog.ErrorContext(r.Context(), "Failed to fetch user data export", "error", err, "export_id", exportID)
+		http.Error(w, errFailedToFetchDataExport, http.StatusInternalServerError)
+		return nil, nil, false
+	}
+	if !strings.EqualFold(export.UserEmail, userInfo.Email) && !strings.EqualFold(export.RequestedBy, userInfo.Email) &&
+		!rbac.HasRole(userInfo.Groups, rbac.GroupAdmin) {
+		http.Error(w, errDataExportNotFound, http.StatusNotFound)
+		return nil, nil, false
+	}
+	return &export, userInfo, true
+}
+
+func toUserDataExportResponse(export models.UserDataExport) dto.UserDataExportResponse {
+	response := dto.UserDataExportResponse{
+		ExportID:    export.ID,
+		UserEmail:   export.UserEmail,
+		Status:      export.Status,
+		Error:       export.Error,
+		SizeBytes:   export.SizeBytes,
+		RequestedBy: export.RequestedBy,
+		CreatedAt:   export.CreatedAt,
+		CompletedAt: export.CompletedAt,
+	}
+	if export.Status == models.UserDataExportStatusCompleted {
+		response.DownloadURL = fmt.Sprintf(userDataExportDownloadPath, export.ID)
+	}
+	return response
+}
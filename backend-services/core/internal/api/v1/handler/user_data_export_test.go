@@ -0,0 +1,191 @@
+// Copyright (c) 2025 WSO2 LLC. (https://www.wso2.com).
+//
+// WSO2 LLC. licenses this file to you under the Apache License,
+// Version 2.0 (the "License"); you may not use this file except
+// in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/opensuperapp/opensuperapp/backend-services/core/internal/api/v1/dto"
+	"github.com/opensuperapp/opensuperapp/backend-services/core/internal/auth"
+	"github.com/opensuperapp/opensuperapp/backend-services/core/internal/models"
+	"github.com/opensuperapp/opensuperapp/backend-services/core/internal/services"
+	"gorm.io/gorm"
+)
+
+// setupDataExport returns a router serving the export endpoints over a database holding data for
+// alice@example.com, and a little for bob@example.com that must stay out of her export
+func setupDataExport(t *testing.T) (*gorm.DB, http.Handler) {
+	t.Helper()
+	location := "Colombo"
+	db, userService := setupUserService(t, &models.User{Email: "alice@example.com", FirstName: "Alice", LastName: "Perera", Location: &location})
+	if err := db.AutoMigrate(&models.UserDataExport{}, &models.UserConfig{},
+		&models.NotificationLog{}, &models.NotificationPreference{}); err != nil {
+		t.Fatalf("Failed to migrate database: %v", err)
+	}
+	createDeviceTokensTable(t, db)
+	title := "Payslip ready"
+	payroll := "payroll"
+	seed := []any{
+		&models.UserConfig{Email: "alice@example.com", ConfigKey: "theme", ConfigValue: json.RawMessage(`{"dark":true}`), CreatedBy: "alice@example.com", UpdatedBy: "alice@example.com"},
+		&models.DeviceToken{UserEmail: "alice@example.com", DeviceToken: "fcm-token-secret-1234", Platform: "android", IsActive: true},
+		&models.DeviceToken{UserEmail: "bob@example.com", DeviceToken: "fcm-token-bob-5678", Platform: "ios", IsActive: true},
+		&models.NotificationLog{UserEmail: "alice@example.com", Title: &title, MicroappID: &payroll, DeliveryStatus: "delivered"},
+		&models.NotificationPreference{UserEmail: "alice@example.com", MicroappID: "news", OptedOut: true},
+	}
+	for _, row := range seed {
+		if err := db.Create(row).Error; err != nil {
+			t.Fatalf("Failed to seed %T: %v", row, err)
+		}
+	}
+
+	h := NewUserDataExportHandler(db, services.NewUserDataExporter(db, userService))
+	r := chi.NewRouter()
+	r.Post("/users/{email}/data-export", h.RequestExport)
+	r.Get("/users/data-exports/{exportID}", h.GetExport)
+	r.Get("/users/data-exports/{exportID}/download", h.DownloadExport)
+	return db, r
+}
+
+func serveAs(r http.Handler, method, target, email string, groups ...string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, target, nil)
+	req = auth.SetUserInfo(req, &auth.CustomJwtPayload{Email: email, Groups: groups})
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+// waitForExport polls an export until it leaves the processing state
+func waitForExport(t *testing.T, r http.Handler, exportID int64, email string) dto.UserDataExportResponse {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		w := serveAs(r, http.MethodGet, "/users/data-exports/"+strconv.FormatInt(exportID, 10), email)
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+		}
+		var export dto.UserDataExportResponse
+		if err := json.Unmarshal(w.Body.Bytes(), &export); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+		if export.Status != models.UserDataExportStatusProcessing {
+			return export
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Export did not complete in time: %+v", export)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// TestUserDataExport_IncludesUserData tests that a self-service export holds every data category
+// for the user, masks device tokens and records the download
+func TestUserDataExport_IncludesUserData(t *testing.T) {
+	db, r := setupDataExport(t)
+
+	w := serveAs(r, http.MethodPost, "/users/alice@example.com/data-export", "alice@example.com")
+	if w.Code != http.StatusAccepted {
+		t.Fatalf("Expected status 202, got %d: %s", w.Code, w.Body.String())
+	}
+	var started dto.UserDataExportResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &started); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if started.ExportID == 0 || started.Status != models.UserDataExportStatusProcessing || started.DownloadURL != "" {
+		t.Fatalf("Expected a processing export without a download URL, got %+v", started)
+	}
+
+	export := waitForExport(t, r, started.ExportID, "alice@example.com")
+	wantURL := "/api/v1/users/data-exports/" + strconv.FormatInt(started.ExportID, 10) + "/download"
+	if export.Status != models.UserDataExportStatusCompleted || export.DownloadURL != wantURL || export.SizeBytes == 0 {
+		t.Fatalf("Expected a completed export at %s, got %+v", wantURL, export)
+	}
+
+	w = serveAs(r, http.MethodGet, "/users/data-exports/"+strconv.FormatInt(started.ExportID, 10)+"/download", "alice@example.com")
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if got := w.Header().Get("Content-Disposition"); got != `attachment; filename="user-data-`+strconv.FormatInt(started.ExportID, 10)+`.json"` {
+		t.Errorf("Expected an attachment, got %q", got)
+	}
+	var data services.UserData
+	if err := json.Unmarshal(w.Body.Bytes(), &data); err != nil {
+		t.Fatalf("Failed to decode export: %v", err)
+	}
+	if data.Email != "alice@example.com" || data.Profile == nil || data.Profile.FirstName != "Alice" {
+		t.Errorf("Expected Alice's profile, got %+v", data.Profile)
+	}
+	if len(data.Configs) != 1 || data.Configs[0].ConfigKey != "theme" || string(data.Configs[0].ConfigValue) != `{"dark":true}` {
+		t.Errorf("Expected the theme config, got %+v", data.Configs)
+	}
+	if len(data.Devices) != 1 || data.Devices[0].Token != "********1234" || data.Devices[0].Platform != "android" {
+		t.Errorf("Expected one masked android device, got %+v", data.Devices)
+	}
+	if len(data.Notifications) != 1 || *data.Notifications[0].Title != "Payslip ready" {
+		t.Errorf("Expected the payslip notification, got %+v", data.Notifications)
+	}
+	if len(data.NotificationPreferences) != 1 || !data.NotificationPreferences[0].OptedOut {
+		t.Errorf("Expected the news opt-out, got %+v", data.NotificationPreferences)
+	}
+
+	var stored models.UserDataExport
+	db.First(&stored, started.ExportID)
+	if stored.RequestedBy != "alice@example.com" || stored.DownloadCount != 1 ||
+		stored.LastDownloadedBy == nil || *stored.LastDownloadedBy != "alice@example.com" || stored.LastDownloadedAt == nil {
+		t.Errorf("Expected the request and download to be recorded, got %+v", stored)
+	}
+}
+
+// TestUserDataExport_Access tests that only the user and admins can export or read a user's data
+func TestUserDataExport_Access(t *testing.T) {
+	_, r := setupDataExport(t)
+
+	if w := serveAs(r, http.MethodPost, "/users/alice@example.com/data-export", "bob@example.com"); w.Code != http.StatusForbidden {
+		t.Errorf("Expected status 403 for another user, got %d", w.Code)
+	}
+	if w := serveAs(r, http.MethodPost, "/users/not-an-email/data-export", "admin@example.com", "admin"); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for an invalid email, got %d", w.Code)
+	}
+
+	w := serveAs(r, http.MethodPost, "/users/alice@example.com/data-export", "admin@example.com", "admin")
+	if w.Code != http.StatusAccepted {
+		t.Fatalf("Expected status 202 for an admin, got %d: %s", w.Code, w.Body.String())
+	}
+	var started dto.UserDataExportResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &started); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	waitForExport(t, r, started.ExportID, "admin@example.com")
+
+	path := "/users/data-exports/" + strconv.FormatInt(started.ExportID, 10)
+	for _, target := range []string{path, path + "/download"} {
+		if w := serveAs(r, http.MethodGet, target, "bob@example.com"); w.Code != http.StatusNotFound {
+			t.Errorf("%s: expected status 404 for another user, got %d", target, w.Code)
+		}
+		// The subject can read an export an admin requested for them
+		if w := serveAs(r, http.MethodGet, target, "alice@example.com"); w.Code != http.StatusOK {
+			t.Errorf("%s: expected status 200 for the subject, got %d", target, w.Code)
+		}
+	}
+	if w := serveAs(r, http.MethodGet, "/users/data-exports/999", "admin@example.com", "admin"); w.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404 for an unknown export, got %d", w.Code)
+	}
+}
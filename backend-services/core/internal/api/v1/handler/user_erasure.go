@@ -0,0 +1,68 @@
+// Copyright (c) 2025 WSO2 LLC. (https://www.wso2.com).
+//
+// WSO2 LLC. licenses this file to you under the Apache License,
+// Version 2.0 (the "License"); you may not use this file except
+// in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+package handler
+
+import (
+	"log/slog"
+	"net/http"
+
+	"github.com/opensuperapp/opensuperapp/backend-services/core/internal/api/v1/dto"
+	"github.com/opensuperapp/opensuperapp/backend-services/core/internal/auth"
+	"github.com/opensuperapp/opensuperapp/backend-services/core/internal/services"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// UserErasureHandler serves right-to-erasure requests (admin only)
+type UserErasureHandler struct {
+	eraser *services.UserEraser
+}
+
+func NewUserErasureHandler(eraser *services.UserEraser) *UserErasureHandler {
+	return &UserErasureHandler{eraser: eraser}
+}
+
+// EraseUser deletes or anonymizes the personal data of the user in the path, deactivates them and
+// responds with the audit record of the erasure
+func (h *UserErasureHandler) EraseUser(w http.ResponseWriter, r *http.Request) {
+	userInfo, ok := auth.GetUserInfo(r.Context())
+	if !ok {
+		http.Error(w, errUserInfoNotFound, http.StatusUnauthorized)
+		return
+	}
+	email := chi.URLParam(r, paramEmail)
+	if err := validate.Var(email, "required,email"); err != nil {
+		http.Error(w, errInvalidEmailParameter, http.StatusBadRequest)
+		return
+	}
+
+	erasure, err := h.eraser.Erase(email, userInfo.Email)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to erase user data", "error", err, "requested_by", userInfo.Email)
+		http.Error(w, errFailedToEraseUser, http.StatusInternalServerError)
+		return
+	}
+	slog.InfoContext(r.Context(), "User data erased", "erasure_id", erasure.ID, "requested_by", userInfo.Email)
+
+	writeJSON(w, http.StatusOK, dto.UserErasureResponse{
+		ErasureID:      erasure.ID,
+		Pseudonym:      erasure.Pseudonym,
+		Categories:     erasure.Categories,
+		ProfileDeleted: erasure.ProfileDeleted,
+		RequestedBy:    erasure.RequestedBy,
+		ErasedAt:       erasure.CreatedAt,
+	})
+}
@@ -0,0 +1,275 @@
+// Copyright (c) 2025 WSO2 LLC. (https://www.wso2.com).
+//
+// WSO2 LLC. licenses this file to you under the Apache License,
+// Version 2.0 (the "License"); you may not use this file except
+// in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/opensuperapp/opensuperapp/backend-services/core/internal/api/v1/dto"
+	"github.com/opensuperapp/opensuperapp/backend-services/core/internal/models"
+	"github.com/opensuperapp/opensuperapp/backend-services/core/internal/services"
+	userservice "github.com/opensuperapp/opensuperapp/backend-services/core/plugins/user-service"
+	"gorm.io/gorm"
+)
+
+const erasedEmail = "alice@example.com"
+
+// setupUserErasure returns a router serving the erasure endpoint with the given policy, over a
+// database holding data in every category for alice@example.com and some for bob@example.com
+func setupUserErasure(t *testing.T, policy services.ErasurePolicy) (*gorm.DB, userservice.UserService, http.Handler) {
+	t.Helper()
+	db, userService := setupUserService(t,
+		&models.User{Email: erasedEmail, FirstName: "Alice", LastName: "Perera"},
+		&models.User{Email: "bob@example.com", FirstName: "Bob", LastName: "Silva"})
+	if err := db.AutoMigrate(&models.UserErasure{}, &models.UserDataExport{}, &models.UserConfig{},
+		&models.NotificationLog{}, &models.NotificationDelivery{}, &models.NotificationPreference{},
+		&models.UserGroup{}, &models.MicroAppAdmin{}, &models.ScheduledNotification{}, &models.MicroAppConfigConflict{}); err != nil {
+		t.Fatalf("Failed to migrate database: %v", err)
+	}
+	createDeviceTokensTable(t, db)
+
+	title := "Payslip ready"
+	body := "Your salary of 250,000 LKR was credited"
+	payroll := "payroll"
+	log := models.NotificationLog{UserEmail: erasedEmail, Title: &title, Body: &body, MicroappID: &payroll,
+		Data: models.JSONMap{"amount": "250000"}, DeliveryStatus: models.DeliveryStatusDelivered}
+	if err := db.Create(&log).Error; err != nil {
+		t.Fatalf("Failed to seed notification log: %v", err)
+	}
+	seed := []any{
+		&models.NotificationDelivery{NotificationLogID: log.ID, DeviceTokenHash: models.HashDeviceToken("fcm-alice"), Status: models.DeliveryStatusDelivered},
+		&models.NotificationLog{UserEmail: "bob@example.com", Title: &title, MicroappID: &payroll, DeliveryStatus: models.DeliveryStatusDelivered},
+		&models.UserConfig{Email: erasedEmail, ConfigKey: "theme", ConfigValue: json.RawMessage(`{"dark":true}`), CreatedBy: erasedEmail, UpdatedBy: erasedEmail},
+		&models.UserConfig{Email: "bob@example.com", ConfigKey: "theme", ConfigValue: json.RawMessage(`{"dark":false}`), CreatedBy: "bob@example.com", UpdatedBy: "bob@example.com"},
+		&models.DeviceToken{UserEmail: erasedEmail, DeviceToken: "fcm-alice", Platform: "android", IsActive: true},
+		&models.DeviceToken{UserEmail: "bob@example.com", DeviceToken: "fcm-bob", Platform: "ios", IsActive: true},
+		&models.NotificationPreference{UserEmail: erasedEmail, MicroappID: "news", OptedOut: true},
+		&models.UserGroup{UserEmail: erasedEmail, GroupName: "finance"},
+		&models.MicroAppAdmin{MicroAppID: "payroll", UserEmail: erasedEmail, GrantedBy: "admin@example.com"},
+		&models.UserDataExport{UserEmail: erasedEmail, RequestedBy: erasedEmail, Status: models.UserDataExportStatusCompleted, Data: []byte(`{"email":"alice@example.com"}`)},
+		&models.ScheduledNotification{MicroappID: "payroll", UserEmails: models.JSONStringSlice{erasedEmail}, Title: title, Body: body, SendAt: time.Now().Add(time.Hour)},
+		&models.ScheduledNotification{MicroappID: "payroll", UserEmails: models.JSONStringSlice{"bob@example.com", erasedEmail}, Title: title, Body: body, SendAt: time.Now().Add(time.Hour)},
+		&models.MicroAppConfigConflict{MicroAppID: "payroll", ConfigKey: "theme", OldValue: json.RawMessage(`"light"`), NewValue: json.RawMessage(`"dark"`),
+			OldUpdatedBy: "bob@example.com", OldUpdatedAt: time.Now(), NewUpdatedBy: erasedEmail, DetectedAt: time.Now()},
+	}
+	for _, row := range seed {
+		if err := db.Create(row).Error; err != nil {
+			t.Fatalf("Failed to seed %T: %v", row, err)
+		}
+	}
+
+	h := NewUserErasureHandler(services.NewUserEraser(db, userService, policy))
+	r := chi.NewRouter()
+	r.Delete("/users/{email}/data", h.EraseUser)
+	return db, userService, r
+}
+
+// countRows counts the rows of a model matching a condition
+func countRows(t *testing.T, db *gorm.DB, model any, query string, args ...any) int64 {
+	t.Helper()
+	var count int64
+	if err := db.Model(model).Where(query, args...).Count(&count).Error; err != nil {
+		t.Fatalf("Failed to count %T: %v", model, err)
+	}
+	return count
+}
+
+// assertNoUserRows checks that no table still references the erased email
+func assertNoUserRows(t *testing.T, db *gorm.DB) {
+	t.Helper()
+	for model, query := range map[any]string{
+		&models.DeviceToken{}:            "user_email = ?",
+		&models.UserConfig{}:             "email = ? OR created_by = ? OR updated_by = ?",
+		&models.NotificationPreference{}: "user_email = ?",
+		&models.NotificationLog{}:        "user_email = ?",
+		&models.UserGroup{}:              "user_email = ?",
+		&models.MicroAppAdmin{}:          "user_email = ?",
+		&models.UserDataExport{}:         "user_email = ? OR requested_by = ?",
+		&models.ScheduledNotification{}:  "INSTR(user_emails, ?) > 0",
+		&models.MicroAppConfigConflict{}: "old_updated_by = ? OR new_updated_by = ?",
+	} {
+		args := make([]any, strings.Count(query, "?"))
+		for i := range args {
+			args[i] = erasedEmail
+		}
+		if n := countRows(t, db, model, query, args...); n != 0 {
+			t.Errorf("Expected no %T rows for the erased user, found %d", model, n)
+		}
+	}
+}
+
+// eraseUser erases alice@example.com as an admin and decodes the audit record
+func eraseUser(t *testing.T, r http.Handler) dto.UserErasureResponse {
+	t.Helper()
+	w := serveAs(r, http.MethodDelete, "/users/"+erasedEmail+"/data", "admin@example.com", "admin")
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var erasure dto.UserErasureResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &erasure); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	return erasure
+}
+
+// TestEraseUser_AnonymizesAggregates tests that an erasure removes the user's PII from every table,
+// while anonymized notification history and preferences remain for aggregates
+func TestEraseUser_AnonymizesAggregates(t *testing.T) {
+	db, userService, r := setupUserErasure(t, services.ErasurePolicy{
+		DeviceTokens:            services.ErasureDelete,
+		Configs:                 services.ErasureDelete,
+		NotificationPreferences: services.ErasureAnonymize,
+		NotificationLogs:        services.ErasureAnonymize,
+	})
+
+	erasure := eraseUser(t, r)
+	if erasure.ErasureID == 0 || !erasure.ProfileDeleted || erasure.RequestedBy != "admin@example.com" {
+		t.Fatalf("Unexpected erasure record: %+v", erasure)
+	}
+	assertNoUserRows(t, db)
+
+	// The notification is still counted for payroll's delivery statistics, without its content
+	var log models.NotificationLog
+	if err := db.Where("user_email = ?", erasure.Pseudonym).First(&log).Error; err != nil {
+		t.Fatalf("Expected the anonymized notification log to remain: %v", err)
+	}
+	if log.Title != nil || log.Body != nil || log.Data != nil {
+		t.Errorf("Expected the notification content to be cleared, got %+v", log)
+	}
+	if log.MicroappID == nil || *log.MicroappID != "payroll" || log.DeliveryStatus != models.DeliveryStatusDelivered {
+		t.Errorf("Expected the notification's microapp and delivery status to remain, got %+v", log)
+	}
+	if n := countRows(t, db, &models.NotificationDelivery{}, "notification_log_id = ?", log.ID); n != 1 {
+		t.Errorf("Expected the notification's delivery to remain, found %d", n)
+	}
+	if n := countRows(t, db, &models.NotificationPreference{}, "user_email = ? AND opted_out = ?", erasure.Pseudonym, true); n != 1 {
+		t.Errorf("Expected the anonymized opt-out to remain, found %d", n)
+	}
+	if n := countRows(t, db, &models.DeviceToken{}, "1 = 1"); n != 1 {
+		t.Errorf("Expected only the other user's device token to remain, found %d", n)
+	}
+	if n := countRows(t, db, &models.UserConfig{}, "1 = 1"); n != 1 {
+		t.Errorf("Expected only the other user's config to remain, found %d", n)
+	}
+	var export models.UserDataExport
+	if err := db.First(&export).Error; err != nil {
+		t.Fatalf("Failed to fetch data export: %v", err)
+	}
+	if export.Data != nil || export.UserEmail != erasure.Pseudonym {
+		t.Errorf("Expected the export document to be purged, got %+v", export)
+	}
+	var scheduled []models.ScheduledNotification
+	if err := db.Find(&scheduled).Error; err != nil {
+		t.Fatalf("Failed to fetch scheduled notifications: %v", err)
+	}
+	if len(scheduled) != 1 || !reflect.DeepEqual([]string(scheduled[0].UserEmails), []string{"bob@example.com"}) {
+		t.Errorf("Expected only the other user to remain scheduled, got %+v", scheduled)
+	}
+	var conflict models.MicroAppConfigConflict
+	if err := db.First(&conflict).Error; err != nil {
+		t.Fatalf("Failed to fetch config conflict: %v", err)
+	}
+	if conflict.OldUpdatedBy != "bob@example.com" || conflict.NewUpdatedBy != erasure.Pseudonym {
+		t.Errorf("Expected the erased user's change to be moved to the pseudonym, got %+v", conflict)
+	}
+
+	// The other user is untouched and the erased user is gone from the user service
+	for table, n := range map[string]int64{
+		"device tokens":     countRows(t, db, &models.DeviceToken{}, "user_email = ? AND is_active = ?", "bob@example.com", true),
+		"configs":           countRows(t, db, &models.UserConfig{}, "email = ?", "bob@example.com"),
+		"notification logs": countRows(t, db, &models.NotificationLog{}, "user_email = ? AND title IS NOT NULL", "bob@example.com"),
+	} {
+		if n != 1 {
+			t.Errorf("Expected the other user's %s to remain, found %d", table, n)
+		}
+	}
+	if user, err := userService.GetUserByEmail(erasedEmail); err != nil || user != nil {
+		t.Errorf("Expected the erased user's profile to be deleted, got %+v, %v", user, err)
+	}
+	if user, err := userService.GetUserByEmail("bob@example.com"); err != nil || user == nil {
+		t.Errorf("Expected the other user's profile to remain, got %v", err)
+	}
+
+	// The audit record identifies the user only by hash
+	var record models.UserErasure
+	if err := db.First(&record, erasure.ErasureID).Error; err != nil {
+		t.Fatalf("Failed to fetch erasure record: %v", err)
+	}
+	if record.UserEmailHash != services.HashErasedEmail(erasedEmail) || !record.ProfileDeleted {
+		t.Errorf("Unexpected erasure record: %+v", record)
+	}
+	categories, _ := json.Marshal(record.Categories)
+	if strings.Contains(string(categories), erasedEmail) {
+		t.Errorf("Expected the erasure record to hold no email, got %s", categories)
+	}
+	logs, ok := erasure.Categories[services.ErasureCategoryNotificationLogs].(map[string]any)
+	if !ok || logs["action"] != string(services.ErasureAnonymize) || logs["rows"] != float64(1) {
+		t.Errorf("Expected the notification logs to be reported anonymized, got %v", erasure.Categories)
+	}
+}
+
+// TestEraseUser_DeletePolicy tests that categories configured for deletion lose their rows,
+// including the per-device deliveries of deleted notifications
+func TestEraseUser_DeletePolicy(t *testing.T) {
+	db, _, r := setupUserErasure(t, services.ErasurePolicy{
+		DeviceTokens:            services.ErasureAnonymize,
+		Configs:                 services.ErasureAnonymize,
+		NotificationPreferences: services.ErasureDelete,
+		NotificationLogs:        services.ErasureDelete,
+	})
+
+	erasure := eraseUser(t, r)
+	assertNoUserRows(t, db)
+
+	if n := countRows(t, db, &models.NotificationLog{}, "1 = 1"); n != 1 {
+		t.Errorf("Expected only the other user's notification log to remain, found %d", n)
+	}
+	if n := countRows(t, db, &models.NotificationDelivery{}, "1 = 1"); n != 0 {
+		t.Errorf("Expected the deleted notification's deliveries to be deleted, found %d", n)
+	}
+	if n := countRows(t, db, &models.NotificationPreference{}, "1 = 1"); n != 0 {
+		t.Errorf("Expected the preference to be deleted, found %d", n)
+	}
+	var token models.DeviceToken
+	if err := db.Where("user_email = ?", erasure.Pseudonym).First(&token).Error; err != nil {
+		t.Fatalf("Expected the anonymized device token to remain: %v", err)
+	}
+	if token.DeviceToken != "" || token.IsActive || token.Platform != "android" {
+		t.Errorf("Expected an inactive android device without its token, got %+v", token)
+	}
+	var config models.UserConfig
+	if err := db.Where("email = ?", erasure.Pseudonym).First(&config).Error; err != nil {
+		t.Fatalf("Expected the anonymized config to remain: %v", err)
+	}
+	if config.ConfigKey != "theme" || string(config.ConfigValue) != "null" {
+		t.Errorf("Expected the config key to remain without its value, got %+v", config)
+	}
+}
+
+// TestEraseUser_InvalidEmail tests that the email in the path is validated
+func TestEraseUser_InvalidEmail(t *testing.T) {
+	_, _, r := setupUserErasure(t, services.ErasurePolicy{})
+	w := serveAs(r, http.MethodDelete, "/users/not-an-email/data", "admin@example.com", "admin")
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("Expected status 400, got %d: %s", w.Code, w.Body.String())
+	}
+}
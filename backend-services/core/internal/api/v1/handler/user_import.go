@@ -0,0 +1,255 @@
+// Copyright (c) 2025 WSO2 LLC. (https://www.wso2.com).
+//
+// WSO2 LLC. licenses this file to you under the Apache License,
+// Version 2.0 (the "License"); you may not use this file except
+// in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+package handler
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/opensuperapp/opensuperapp/backend-services/core/internal/api/v1/dto"
+	"github.com/opensuperapp/opensuperapp/backend-services/core/internal/auth"
+	"github.com/opensuperapp/opensuperapp/backend-services/core/internal/models"
+	"github.com/opensuperapp/opensuperapp/backend-services/core/internal/services"
+
+	"github.com/go-chi/chi/v5"
+	"gorm.io/gorm"
+)
+
+const (
+	importFormatCSV  = "csv"
+	importFormatJSON = "json"
+)
+
+// csvImportColumns maps the accepted CSV header names to setters on a user request.
+var csvImportColumns = map[string]func(*dto.UpsertUserRequest, string){
+	"workEmail":     func(u *dto.UpsertUserRequest, v string) { u.Email = v },
+	"firstName":     func(u *dto.UpsertUserRequest, v string) { u.FirstName = v },
+	"lastName":      func(u *dto.UpsertUserRequest, v string) { u.LastName = v },
+	"userThumbnail": func(u *dto.UpsertUserRequest, v string) { u.UserThumbnail = optionalString(v) },
+	"location":      func(u *dto.UpsertUserRequest, v string) { u.Location = optionalString(v) },
+}
+
+type UserImportHandler struct {
+	db       *gorm.DB
+	importer *services.UserImporter
+}
+
+func NewUserImportHandler(db *gorm.DB, importer *services.UserImporter) *UserImportHandler {
+	return &UserImportHandler{db: db, importer: importer}
+}
+
+// Import accepts a CSV or JSON file of users and imports it in the background, responding with
+// the job to poll. A file that cannot be parsed is rejected up front, while users that fail
+// validation are skipped and reported in the job.
+func (h *UserImportHandler) Import(w http.ResponseWriter, r *http.Request) {
+	userInfo, ok := auth.GetUserInfo(r.Context())
+	if !ok {
+		http.Error(w, errUserInfoNotFound, http.StatusUnauthorized)
+		return
+	}
+	format, ok := importFormat(r.Header.Get(headerContentType))
+	if !ok {
+		http.Error(w, errUnsupportedImportFormat, http.StatusUnsupportedMediaType)
+		return
+	}
+	limitRequestBody(w, r, userImportBodyLimit)
+	requests, err := parseUserImport(format, r.Body)
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			http.Error(w, errRequestBodyTooLarge, http.StatusRequestEntityTooLarge)
+			return
+		}
+		http.Error(w, errInvalidImportFile+": "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if len(requests) == 0 {
+		http.Error(w, errEmptyImportFile, http.StatusBadRequest)
+		return
+	}
+
+	rows, rowErrors := validateUserRequests(requests)
+	job := models.UserImportJob{
+		Status:        models.UserImportStatusProcessing,
+		Format:        format,
+		TotalRows:     len(requests),
+		ProcessedRows: len(rowErrors),
+		FailedRows:    len(rowErrors),
+		RowErrors:     rowErrors,
+		CreatedBy:     userInfo.Email,
+	}
+	if err := h.db.Create(&job).Error; err != nil {
+		slog.ErrorContext(r.Context(), "Failed to create user import job", "error", err, "created_by", userInfo.Email)
+		http.Error(w, errFailedToCreateImportJob, http.StatusInternalServerError)
+		return
+	}
+	// The response is built before the importer starts updating the job
+	response := toUserImportJobResponse(job)
+	go h.importer.Run(&job, rows)
+
+	slog.InfoContext(r.Context(), "User import started", "job_id", job.ID, "format", format, "rows", job.TotalRows, "invalid", job.FailedRows, "created_by", userInfo.Email)
+	writeJSON(w, http.StatusAccepted, response)
+}
+
+// GetImportJob returns the progress of an import job, and the users that were not imported.
+func (h *UserImportHandler) GetImportJob(w http.ResponseWriter, r *http.Request) {
+	jobID, err := strconv.ParseInt(chi.URLParam(r, urlParamJobID), 10, 64)
+	if err != nil || jobID <= 0 {
+		http.Error(w, errInvalidImportJobID, http.StatusBadRequest)
+		return
+	}
+	var job models.UserImportJob
+	if err := h.db.First(&job, jobID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			http.Error(w, errImportJobNotFound, http.StatusNotFound)
+			return
+		}
+		slog.ErrorContext(r.Context(), "Failed to fetch user import job", "error", err, "job_id", jobID)
+		http.Error(w, errFailedToFetchImportJob, http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusOK, toUserImportJobResponse(job))
+}
+
+// importFormat returns the import format for a Content-Type header.
+func importFormat(contentType string) (string, bool) {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return "", false
+	}
+	switch mediaType {
+	case contentTypeCSV:
+		return importFormatCSV, true
+	case contentTypeJSON:
+		return importFormatJSON, true
+	}
+	return "", false
+}
+
+// parseUserImport reads every user from an import file. A JSON file is an array of user objects
+// as accepted by the upsert endpoint; a CSV file has a header row naming its columns.
+func parseUserImport(format string, body io.Reader) ([]dto.UpsertUserRequest, error) {
+	if format == importFormatJSON {
+		var requests []dto.UpsertUserRequest
+		if err := json.NewDecoder(body).Decode(&requests); err != nil {
+			return nil, fmt.Errorf("expected a JSON array of users: %w", err)
+		}
+		return requests, nil
+	}
+
+	reader := csv.NewReader(body)
+	reader.TrimLeadingSpace = true
+	header, err := reader.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	setters := make([]func(*dto.UpsertUserRequest, string), len(header))
+	seen := make(map[string]bool, len(header))
+	for i, name := range header {
+		// Spreadsheet exports often start with a byte order mark
+		name = strings.TrimSpace(strings.TrimPrefix(name, "\ufeff"))
+		setter, ok := csvImportColumns[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown column %q", name)
+		}
+		if seen[name] {
+			return nil, fmt.Errorf("duplicate column %q", name)
+		}
+		seen[name] = true
+		setters[i] = setter
+	}
+	for _, required := range []string{"workEmail", "firstName", "lastName"} {
+		if !seen[required] {
+			return nil, fmt.Errorf("missing column %q", required)
+		}
+	}
+
+	var requests []dto.UpsertUserRequest
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return requests, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		var req dto.UpsertUserRequest
+		for i, value := range record {
+			setters[i](&req, strings.TrimSpace(value))
+		}
+		requests = append(requests, req)
+	}
+}
+
+// validateUserRequests splits users into valid rows and row errors, numbering rows from 1.
+func validateUserRequests(requests []dto.UpsertUserRequest) ([]services.UserImportRow, models.UserImportRowErrors) {
+	rows := make([]services.UserImportRow, 0, len(requests))
+	var rowErrors models.UserImportRowErrors
+	for i, req := range requests {
+		if err := validate.Struct(&req); err != nil {
+			rowErrors = append(rowErrors, models.UserImportRowError{Row: i + 1, Email: req.Email, Error: err.Error()})
+			continue
+		}
+		rows = append(rows, services.UserImportRow{
+			Row: i + 1,
+			User: &models.User{
+				Email:         req.Email,
+				FirstName:     req.FirstName,
+				LastName:      req.LastName,
+				UserThumbnail: req.UserThumbnail,
+				Location:      req.Location,
+			},
+		})
+	}
+	return rows, rowErrors
+}
+
+func optionalString(v string) *string {
+	if v == "" {
+		return nil
+	}
+	return &v
+}
+
+func toUserImportJobResponse(job models.UserImportJob) dto.UserImportJobResponse {
+	response := dto.UserImportJobResponse{
+		JobID:         job.ID,
+		Status:        job.Status,
+		Format:        job.Format,
+		TotalRows:     job.TotalRows,
+		ProcessedRows: job.ProcessedRows,
+		ImportedRows:  job.ImportedRows,
+		FailedRows:    job.FailedRows,
+		CreatedBy:     job.CreatedBy,
+		CreatedAt:     job.CreatedAt,
+		CompletedAt:   job.CompletedAt,
+	}
+	for _, rowErr := range job.RowErrors {
+		response.Errors = append(response.Errors, dto.UserImportRowError{Row: rowErr.Row, Email: rowErr.Email, Error: rowErr.Error})
+	}
+	return response
+}
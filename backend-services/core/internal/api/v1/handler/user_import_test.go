@@ -0,0 +1,183 @@
+// Copyright (c) 2025 WSO2 LLC. (https://www.wso2.com).
+//
+// WSO2 LLC. licenses this file to you under the Apache License,
+// Version 2.0 (the "License"); you may not use this file except
+// in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/opensuperapp/opensuperapp/backend-services/core/internal/api/v1/dto"
+	"github.com/opensuperapp/opensuperapp/backend-services/core/internal/auth"
+	"github.com/opensuperapp/opensuperapp/backend-services/core/internal/models"
+	"github.com/opensuperapp/opensuperapp/backend-services/core/internal/services"
+	userservice "github.com/opensuperapp/opensuperapp/backend-services/core/plugins/user-service"
+)
+
+// rejectingUserService fails to save one email, like a row the database refuses
+type rejectingUserService struct {
+	userservice.UserService
+	rejectEmail string
+}
+
+func (s *rejectingUserService) UpsertUser(user *models.User) error {
+	if user.Email == s.rejectEmail {
+		return errors.New("rejected by the database")
+	}
+	return s.UserService.UpsertUser(user)
+}
+
+func (s *rejectingUserService) UpsertUsers(users []*models.User) error {
+	for _, user := range users {
+		if user.Email == s.rejectEmail {
+			return errors.New("rejected by the database")
+		}
+	}
+	return s.UserService.UpsertUsers(users)
+}
+
+func newImportRequest(contentType, body string) *http.Request {
+	req := httptest.NewRequest(http.MethodPost, "/users/import", strings.NewReader(body))
+	req.Header.Set(headerContentType, contentType)
+	return auth.SetUserInfo(req, &auth.CustomJwtPayload{Email: "admin@example.com"})
+}
+
+// waitForImport polls an import job until it completes
+func waitForImport(t *testing.T, h *UserImportHandler, jobID int64) dto.UserImportJobResponse {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		req := httptest.NewRequest(http.MethodGet, "/users/import/"+strconv.FormatInt(jobID, 10), nil)
+		rctx := chi.NewRouteContext()
+		rctx.URLParams.Add(urlParamJobID, strconv.FormatInt(jobID, 10))
+		req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+		w := httptest.NewRecorder()
+		h.GetImportJob(w, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+		}
+		var job dto.UserImportJobResponse
+		if err := json.Unmarshal(w.Body.Bytes(), &job); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+		if job.Status == models.UserImportStatusCompleted {
+			return job
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Import did not complete in time: %+v", job)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// TestUserImport_MultiBatchWithInvalidRows tests that valid users are imported across batches
+// and that rows failing validation or the save are reported by row number
+func TestUserImport_MultiBatchWithInvalidRows(t *testing.T) {
+	db, userService := setupUserService(t, &models.User{Email: "alice@example.com", FirstName: "Old", LastName: "Name"})
+	if err := db.AutoMigrate(&models.UserImportJob{}); err != nil {
+		t.Fatalf("Failed to migrate import jobs: %v", err)
+	}
+	rejecting := &rejectingUserService{UserService: userService, rejectEmail: "erin@example.com"}
+	h := NewUserImportHandler(db, services.NewUserImporter(db, rejecting, 2))
+
+	csvFile := "\ufeffworkEmail,firstName,lastName,location\n" +
+		"alice@example.com,Alice,Perera,Colombo\n" +
+		"not-an-email,Bob,Silva,\n" +
+		"carol@example.com,Carol,Perez,\n" +
+		"dave@example.com,Dave,,\n" +
+		"erin@example.com,Erin,Fernando,\n" +
+		"frank@example.com,Frank,Jayasuriya,Kandy\n"
+	w := httptest.NewRecorder()
+	h.Import(w, newImportRequest("text/csv; charset=utf-8", csvFile))
+	if w.Code != http.StatusAccepted {
+		t.Fatalf("Expected status 202, got %d: %s", w.Code, w.Body.String())
+	}
+	var started dto.UserImportJobResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &started); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if started.JobID == 0 || started.TotalRows != 6 || started.Format != "csv" {
+		t.Fatalf("Expected a csv job with 6 rows, got %+v", started)
+	}
+
+	job := waitForImport(t, h, started.JobID)
+	if job.ProcessedRows != 6 || job.ImportedRows != 3 || job.FailedRows != 3 || job.CompletedAt == nil {
+		t.Errorf("Expected 3 imported and 3 failed rows, got %+v", job)
+	}
+	var failedRows []int
+	for _, rowErr := range job.Errors {
+		failedRows = append(failedRows, rowErr.Row)
+	}
+	if len(failedRows) != 3 || failedRows[0] != 2 || failedRows[1] != 4 || failedRows[2] != 5 {
+		t.Errorf("Expected rows 2, 4 and 5 to fail, got %+v", job.Errors)
+	}
+
+	alice, err := userService.GetUserByEmail("alice@example.com")
+	if err != nil || alice == nil || alice.FirstName != "Alice" || alice.Location == nil || *alice.Location != "Colombo" {
+		t.Errorf("Expected alice to be updated, got %+v (%v)", alice, err)
+	}
+	// carol shares a batch with the rejected erin, so she is saved by the per-user retry
+	for _, email := range []string{"carol@example.com", "frank@example.com"} {
+		if user, _ := userService.GetUserByEmail(email); user == nil {
+			t.Errorf("Expected %s to be imported", email)
+		}
+	}
+}
+
+// TestUserImport_RejectsMalformedFiles tests that files with the wrong format fail before a job is created
+func TestUserImport_RejectsMalformedFiles(t *testing.T) {
+	db, userService := setupUserService(t)
+	if err := db.AutoMigrate(&models.UserImportJob{}); err != nil {
+		t.Fatalf("Failed to migrate import jobs: %v", err)
+	}
+	h := NewUserImportHandler(db, services.NewUserImporter(db, userService, 0))
+
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		wantStatus  int
+	}{
+		{"unsupported type", "text/plain", "workEmail\n", http.StatusUnsupportedMediaType},
+		{"unknown column", "text/csv", "workEmail,firstName,lastName,phone\na@example.com,A,B,1\n", http.StatusBadRequest},
+		{"missing column", "text/csv", "workEmail,firstName\na@example.com,A\n", http.StatusBadRequest},
+		{"ragged row", "text/csv", "workEmail,firstName,lastName\na@example.com,A\n", http.StatusBadRequest},
+		{"header only", "text/csv", "workEmail,firstName,lastName\n", http.StatusBadRequest},
+		{"json object", "application/json", `{"workEmail":"a@example.com"}`, http.StatusBadRequest},
+		{"empty json array", "application/json", `[]`, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			h.Import(w, newImportRequest(tt.contentType, tt.body))
+			if w.Code != tt.wantStatus {
+				t.Errorf("Expected status %d, got %d: %s", tt.wantStatus, w.Code, w.Body.String())
+			}
+		})
+	}
+	var jobs int64
+	db.Model(&models.UserImportJob{}).Count(&jobs)
+	if jobs != 0 {
+		t.Errorf("Expected no jobs for rejected files, got %d", jobs)
+	}
+}
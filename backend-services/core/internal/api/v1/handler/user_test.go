@@ -0,0 +1,204 @@
+// Copyright (c) 2025 WSO2 LLC. (https://www.wso2.com).
+//
+// WSO2 LLC. licenses this file to you under the Apache License,
+// Version 2.0 (the "License"); you may not use this file except
+// in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/opensuperapp/opensuperapp/backend-services/core/internal/api/v1/dto"
+	"github.com/opensuperapp/opensuperapp/backend-services/core/internal/models"
+	userservice "github.com/opensuperapp/opensuperapp/backend-services/core/plugins/user-service"
+	userdb "github.com/opensuperapp/opensuperapp/backend-services/core/plugins/user-service/default-db"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// setupUserService returns the default DB user service on a test database with the given users
+func setupUserService(t *testing.T, users ...*models.User) (*gorm.DB, userservice.UserService) {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("Failed to open test database: %v", err)
+	}
+	// Every connection to :memory: is a separate database, so background work must share this one
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatalf("Failed to get database handle: %v", err)
+	}
+	sqlDB.SetMaxOpenConns(1)
+	if err := db.Exec("CREATE TABLE users (email TEXT PRIMARY KEY, firstName TEXT, lastName TEXT, userThumbnail TEXT, location TEXT)").Error; err != nil {
+		t.Fatalf("Failed to create users table: %v", err)
+	}
+	userService, err := userdb.New(map[string]any{"DB": db})
+	if err != nil {
+		t.Fatalf("Failed to create user service: %v", err)
+	}
+	if len(users) > 0 {
+		if err := userService.UpsertUsers(users); err != nil {
+			t.Fatalf("Failed to seed users: %v", err)
+		}
+	}
+	return db, userService
+}
+
+// setupUserHandler returns a user handler backed by the default DB user service with the given users
+func setupUserHandler(t *testing.T, users ...*models.User) *UserHandler {
+	t.Helper()
+	_, userService := setupUserService(t, users...)
+	return NewUserHandler(userService)
+}
+
+// TestGetAllUsers_Paginated tests paging, prefix search and the unpaged legacy response
+func TestGetAllUsers_Paginated(t *testing.T) {
+	h := setupUserHandler(t,
+		&models.User{Email: "alice@example.com", FirstName: "Alice", LastName: "Perera"},
+		&models.User{Email: "bob@example.com", FirstName: "Bob", LastName: "Silva"},
+		&models.User{Email: "carol@example.com", FirstName: "Carol", LastName: "Perez"},
+		&models.User{Email: "per_cent@example.com", FirstName: "Dan", LastName: "Fernando"},
+	)
+
+	get := func(target string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		h.GetAll(w, httptest.NewRequest(http.MethodGet, target, nil))
+		return w
+	}
+	decode := func(w *httptest.ResponseRecorder) dto.UserListResponse {
+		t.Helper()
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+		}
+		var resp dto.UserListResponse
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+		return resp
+	}
+
+	page := decode(get("/users?limit=2&offset=1"))
+	if page.Total != 4 || page.Limit != 2 || page.Offset != 1 || len(page.Users) != 2 || page.Users[0].FirstName != "Bob" {
+		t.Errorf("Expected the second and third of 4 users, got %+v", page)
+	}
+
+	page = decode(get("/users?search=Per"))
+	if page.Total != 3 || page.Limit != defaultPageLimit {
+		t.Errorf("Expected 3 users with an email or name starting with Per, got %+v", page)
+	}
+	page = decode(get("/users?search=per_"))
+	if page.Total != 1 || page.Users[0].Email != "per_cent@example.com" {
+		t.Errorf("Expected _ in the search to match literally, got %+v", page)
+	}
+
+	page = decode(get("/users?limit=1000"))
+	if page.Limit != maxPageLimit {
+		t.Errorf("Expected the limit to be capped at %d, got %d", maxPageLimit, page.Limit)
+	}
+	if w := get("/users?limit=0"); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for limit=0, got %d", w.Code)
+	}
+
+	page = decode(get("/users?sort=email&order=desc&limit=2"))
+	if page.Total != 4 || len(page.Users) != 2 || page.Users[0].Email != "per_cent@example.com" || page.Users[1].Email != "carol@example.com" {
+		t.Errorf("Expected users sorted by email descending, got %+v", page)
+	}
+	page = decode(get("/users?sort=lastName"))
+	if page.Users[0].LastName != "Fernando" {
+		t.Errorf("Expected users sorted by last name, got %+v", page)
+	}
+	if w := get("/users?sort=password"); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for an unknown sort field, got %d", w.Code)
+	}
+
+	w := get("/users")
+	var all []dto.UserResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &all); err != nil || len(all) != 4 {
+		t.Errorf("Expected an unpaged request to list all 4 users, got %s", w.Body.String())
+	}
+}
+
+// TestBulkUpsertUsers tests best-effort and atomic bulk upserts with some invalid users
+func TestBulkUpsertUsers(t *testing.T) {
+	_, userService := setupUserService(t)
+	h := NewUserHandler(userService)
+	payload := []dto.UpsertUserRequest{
+		{Email: "alice@example.com", FirstName: "Alice", LastName: "Perera"},
+		{Email: "not-an-email", FirstName: "Bob", LastName: "Silva"},
+		{Email: "carol@example.com", FirstName: "Carol"},
+		{Email: "dave@example.com", FirstName: "Dave", LastName: "Fernando"},
+	}
+	post := func(target string, users []dto.UpsertUserRequest) (*httptest.ResponseRecorder, dto.BulkUpsertUsersResponse) {
+		t.Helper()
+		body, _ := json.Marshal(users)
+		req := httptest.NewRequest(http.MethodPost, target, bytes.NewReader(body))
+		req.Header.Set(headerContentType, contentTypeJSON)
+		w := httptest.NewRecorder()
+		h.BulkUpsert(w, req)
+		var resp dto.BulkUpsertUsersResponse
+		json.Unmarshal(w.Body.Bytes(), &resp)
+		return w, resp
+	}
+
+	w, resp := post("/users/bulk?atomic=true", payload)
+	if w.Code != http.StatusBadRequest || resp.Upserted != 0 || resp.Failed != 2 || resp.Partial {
+		t.Fatalf("Expected an atomic upsert to be rejected, got %d %+v", w.Code, resp)
+	}
+	if len(resp.Results) != 4 || resp.Results[0].Status != dto.BatchItemSkipped || resp.Results[1].Status != dto.BatchItemFailed {
+		t.Errorf("Expected valid users to be skipped and invalid ones failed, got %+v", resp.Results)
+	}
+	if user, _ := userService.GetUserByEmail("alice@example.com"); user != nil {
+		t.Error("Expected no user to be saved by a rejected atomic upsert")
+	}
+
+	w, resp = post("/users/bulk", payload)
+	if w.Code != http.StatusMultiStatus || resp.Upserted != 2 || resp.Failed != 2 || !resp.Partial {
+		t.Fatalf("Expected a partial 207 with 2 upserted and 2 failed, got %d %+v", w.Code, resp)
+	}
+	wantStatuses := []string{dto.BatchItemSucceeded, dto.BatchItemFailed, dto.BatchItemFailed, dto.BatchItemSucceeded}
+	if len(resp.Results) != len(wantStatuses) {
+		t.Fatalf("Expected a result per user, got %+v", resp.Results)
+	}
+	for i, want := range wantStatuses {
+		if result := resp.Results[i]; result.Index != i || result.Status != want || result.Key != payload[i].Email {
+			t.Errorf("Result %d: expected %s for %s, got %+v", i, want, payload[i].Email, result)
+		}
+	}
+	if resp.Results[2].Error == "" {
+		t.Error("Expected the failed result to carry the validation error")
+	}
+	for _, email := range []string{"alice@example.com", "dave@example.com"} {
+		if user, _ := userService.GetUserByEmail(email); user == nil {
+			t.Errorf("Expected %s to be saved", email)
+		}
+	}
+
+	w, resp = post("/users/bulk?atomic=true", []dto.UpsertUserRequest{payload[0], payload[3]})
+	if w.Code != http.StatusCreated || resp.Upserted != 2 || resp.Failed != 0 || resp.Partial || len(resp.Results) != 2 {
+		t.Errorf("Expected a 201 when every user is valid, got %d %+v", w.Code, resp)
+	}
+	w, resp = post("/users/bulk", []dto.UpsertUserRequest{payload[1], payload[2]})
+	if w.Code != http.StatusBadRequest || resp.Upserted != 0 || resp.Partial || len(resp.Results) != 2 {
+		t.Errorf("Expected a 400 when every user is invalid, got %d %+v", w.Code, resp)
+	}
+	if w, _ := post("/users/bulk?atomic=maybe", payload); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for an invalid atomic flag, got %d", w.Code)
+	}
+	if w, _ := post("/users/bulk", []dto.UpsertUserRequest{}); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for an empty array, got %d", w.Code)
+	}
+}
@@ -16,11 +16,17 @@
 package handler
 
 import (
+	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"mime"
 	"net/http"
+	"slices"
+	"strconv"
 	"strings"
+	"time"
 	"unicode"
 
 	"github.com/go-playground/validator/v10"
@@ -38,6 +44,25 @@ func writeJSON(w http.ResponseWriter, status int, data any) error {
 	return nil
 }
 
+// Decodes the JSON request body into v, writing an error response when it fails. An empty body is
+// reported as errEmptyRequestBody and a body over the limitRequestBody limit as errRequestBodyTooLarge.
+func decodeJSONBody(w http.ResponseWriter, r *http.Request, v any) bool {
+	err := json.NewDecoder(r.Body).Decode(v)
+	if err == nil {
+		return true
+	}
+	var maxBytesErr *http.MaxBytesError
+	switch {
+	case errors.Is(err, io.EOF):
+		http.Error(w, errEmptyRequestBody, http.StatusBadRequest)
+	case errors.As(err, &maxBytesErr):
+		http.Error(w, errRequestBodyTooLarge, http.StatusRequestEntityTooLarge)
+	default:
+		http.Error(w, errInvalidRequestBody, http.StatusBadRequest)
+	}
+	return false
+}
+
 // Validates a struct using the validator package and writes validation errors to the response.
 func validateStruct(w http.ResponseWriter, s any) bool {
 	if err := validate.Struct(s); err != nil {
@@ -74,3 +99,141 @@ func sanitizeForHeader(s string) string {
 		return r
 	}, s)
 }
+
+// Returns the value of a string pointer, or an empty string when nil.
+func derefString(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+// Parses the limit and offset query parameters. Limit defaults to defaultPageLimit and is capped at maxPageLimit.
+func parsePagination(r *http.Request) (limit, offset int, err error) {
+	limit, offset = defaultPageLimit, 0
+	if v := r.URL.Query().Get(queryParamLimit); v != "" {
+		limit, err = strconv.Atoi(v)
+		if err != nil || limit <= 0 {
+			return 0, 0, errors.New(errInvalidLimit)
+		}
+		if limit > maxPageLimit {
+			limit = maxPageLimit
+		}
+	}
+	if v := r.URL.Query().Get(queryParamOffset); v != "" {
+		offset, err = strconv.Atoi(v)
+		if err != nil || offset < 0 {
+			return 0, 0, errors.New(errInvalidOffset)
+		}
+	}
+	return limit, offset, nil
+}
+
+// listSort is the sort contract of a list endpoint: the fields clients may pass as the sort
+// query parameter, each mapped to the comma-separated columns it orders by, and the default.
+// Only these columns ever reach an ORDER BY clause.
+type listSort struct {
+	fields       map[string]string
+	defaultField string
+	defaultDesc  bool
+	tieBreaker   string // unique column ordered by last, so pages do not overlap; may be empty
+}
+
+// listParams are the parsed limit, offset, sort and order query parameters of a list request.
+type listParams struct {
+	limit  int
+	offset int
+	paged  bool   // the request set a limit or an offset
+	sort   string // one of the endpoint's sort fields
+	desc   bool
+	order  string // ORDER BY clause for sort and desc
+}
+
+// Parses the list query parameters shared by every list endpoint: limit and offset as in
+// parsePagination, sort (one of the endpoint's fields) and order (asc or desc). An unknown sort
+// field or order is an error listing what is accepted, so it can be returned as a 400.
+func parseListParams(r *http.Request, sort listSort) (listParams, error) {
+	limit, offset, err := parsePagination(r)
+	if err != nil {
+		return listParams{}, err
+	}
+	query := r.URL.Query()
+	params := listParams{
+		limit:  limit,
+		offset: offset,
+		paged:  query.Has(queryParamLimit) || query.Has(queryParamOffset),
+		sort:   sort.defaultField,
+		desc:   sort.defaultDesc,
+	}
+	if field := query.Get(queryParamSort); field != "" {
+		if _, ok := sort.fields[field]; !ok {
+			names := make([]string, 0, len(sort.fields))
+			for name := range sort.fields {
+				names = append(names, name)
+			}
+			slices.Sort(names)
+			return listParams{}, fmt.Errorf(errInvalidSort, strings.Join(names, ", "))
+		}
+		params.sort = field
+	}
+	switch query.Get(queryParamOrder) {
+	case "":
+	case sortOrderAsc:
+		params.desc = false
+	case sortOrderDesc:
+		params.desc = true
+	default:
+		return listParams{}, errors.New(errInvalidOrder)
+	}
+
+	direction := " ASC"
+	if params.desc {
+		direction = " DESC"
+	}
+	columns := strings.Split(sort.fields[params.sort], ",")
+	for i, column := range columns {
+		columns[i] = strings.TrimSpace(column)
+	}
+	if sort.tieBreaker != "" && !slices.Contains(columns, sort.tieBreaker) {
+		columns = append(columns, sort.tieBreaker)
+	}
+	params.order = strings.Join(columns, direction+", ") + direction
+	return params, nil
+}
+
+// isDefaultSort reports whether the params use the endpoint's default sort field and order.
+func (p listParams) isDefaultSort(sort listSort) bool {
+	return p.sort == sort.defaultField && p.desc == sort.defaultDesc
+}
+
+// pageCursor marks a row in a list ordered by time and then ID, both descending.
+type pageCursor struct {
+	ID int64
+	At time.Time
+}
+
+// Encodes a cursor as opaque URL-safe base64 of "<id>:<unix nanoseconds>".
+func encodePageCursor(id int64, at time.Time) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(fmt.Sprintf("%d:%d", id, at.UnixNano())))
+}
+
+// Decodes a cursor produced by encodePageCursor.
+func decodePageCursor(s string) (pageCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return pageCursor{}, errors.New(errInvalidCursor)
+	}
+	idPart, atPart, ok := strings.Cut(string(raw), ":")
+	if !ok {
+		return pageCursor{}, errors.New(errInvalidCursor)
+	}
+	id, err := strconv.ParseInt(idPart, 10, 64)
+	if err != nil {
+		return pageCursor{}, errors.New(errInvalidCursor)
+	}
+	nanos, err := strconv.ParseInt(atPart, 10, 64)
+	if err != nil {
+		return pageCursor{}, errors.New(errInvalidCursor)
+	}
+	return pageCursor{ID: id, At: time.Unix(0, nanos)}, nil
+}
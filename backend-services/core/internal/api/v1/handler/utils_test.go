@@ -0,0 +1,132 @@
+// Copyright (c) 2025 WSO2 LLC. (https://www.wso2.com).
+//
+// WSO2 LLC. licenses this file to you under the Apache License,
+// Version 2.0 (the "License"); you may not use this file except
+// in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/opensuperapp/opensuperapp/backend-services/core/internal/auth"
+	"github.com/opensuperapp/opensuperapp/backend-services/core/internal/services"
+)
+
+// TestDecodeJSONBody_EmptyBody tests that JSON endpoints tell an empty body apart from malformed JSON
+func TestDecodeJSONBody_EmptyBody(t *testing.T) {
+	db := setupTestDB(t)
+	notifications := NewNotificationHandler(db, &fakeNotificationService{}, nil, services.SenderIdentity{})
+	userConfigs := NewUserConfigHandler(db)
+	asService := func(r *http.Request) *http.Request {
+		return auth.SetServiceInfo(r, &auth.ServiceInfo{ClientID: "app-1"})
+	}
+	asUser := func(r *http.Request) *http.Request {
+		return auth.SetUserInfo(r, &auth.CustomJwtPayload{Email: "alice@example.com"})
+	}
+
+	endpoints := []struct {
+		name    string
+		handle  http.HandlerFunc
+		context func(*http.Request) *http.Request
+	}{
+		{"send notification", notifications.SendNotification, asService},
+		{"schedule notification", notifications.ScheduleNotification, asService},
+		{"upsert notification template", notifications.UpsertNotificationTemplate, asService},
+		{"register device token", notifications.RegisterDeviceToken, asUser},
+		{"upsert user config", userConfigs.UpsertAppConfig, asUser},
+	}
+	bodies := []struct {
+		name     string
+		body     string
+		wantBody string
+	}{
+		{"empty body", "", errEmptyRequestBody},
+		{"malformed JSON", "{", errInvalidRequestBody},
+	}
+	for _, endpoint := range endpoints {
+		for _, body := range bodies {
+			t.Run(endpoint.name+"/"+body.name, func(t *testing.T) {
+				req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body.body))
+				req.Header.Set(headerContentType, contentTypeJSON)
+				w := httptest.NewRecorder()
+
+				endpoint.handle(w, endpoint.context(req))
+
+				if w.Code != http.StatusBadRequest {
+					t.Fatalf("Expected status 400, got %d: %s", w.Code, w.Body.String())
+				}
+				if got := strings.TrimSpace(w.Body.String()); got != body.wantBody {
+					t.Errorf("Expected %q, got %q", body.wantBody, got)
+				}
+			})
+		}
+	}
+}
+
+// TestDecodeJSONBody_TooLarge tests that a body over the request limit is reported as too large
+func TestDecodeJSONBody_TooLarge(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"title":"`+strings.Repeat("a", 64)+`"}`))
+	w := httptest.NewRecorder()
+	limitRequestBody(w, req, 16)
+
+	var v map[string]string
+	if decodeJSONBody(w, req, &v) {
+		t.Fatal("Expected decoding to fail")
+	}
+	if w.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("Expected status 413, got %d", w.Code)
+	}
+}
+
+// TestParseListParams tests the shared limit, offset, sort and order contract of list endpoints
+func TestParseListParams(t *testing.T) {
+	sort := listSort{
+		fields:       map[string]string{"sentAt": "sent_at", "name": "last_name, first_name"},
+		defaultField: "sentAt",
+		defaultDesc:  true,
+		tieBreaker:   "id",
+	}
+	parse := func(query string) (listParams, error) {
+		return parseListParams(httptest.NewRequest(http.MethodGet, "/items?"+query, nil), sort)
+	}
+
+	params, err := parse("")
+	if err != nil || params.limit != defaultPageLimit || params.offset != 0 || params.paged || params.order != "sent_at DESC, id DESC" {
+		t.Errorf("Expected the default page and order, got %+v %v", params, err)
+	}
+	params, err = parse("sort=name&order=asc&offset=5")
+	if err != nil || !params.paged || params.offset != 5 || params.order != "last_name ASC, first_name ASC, id ASC" || params.isDefaultSort(sort) {
+		t.Errorf("Expected every sort column ascending, got %+v %v", params, err)
+	}
+	params, err = parse("limit=" + strconv.Itoa(maxPageLimit))
+	if err != nil || params.limit != maxPageLimit {
+		t.Errorf("Expected the maximum limit to be accepted, got %+v %v", params, err)
+	}
+	params, err = parse("limit=" + strconv.Itoa(maxPageLimit+1))
+	if err != nil || params.limit != maxPageLimit {
+		t.Errorf("Expected a limit over the maximum to be capped, got %+v %v", params, err)
+	}
+
+	for _, query := range []string{"limit=0", "limit=-1", "limit=ten", "offset=-1", "sort=id", "sort=sent_at%3BDROP%20TABLE%20users", "order=up", "order=DESC"} {
+		if _, err := parse(query); err == nil {
+			t.Errorf("Expected %q to be rejected", query)
+		}
+	}
+	if _, err := parse("sort=password"); err == nil || !strings.Contains(err.Error(), "name, sentAt") {
+		t.Errorf("Expected the error to list the sortable fields, got %v", err)
+	}
+}
@@ -17,10 +17,12 @@ package router
 
 import (
 	"net/http"
+	"time"
 
 	"github.com/opensuperapp/opensuperapp/backend-services/core/internal/api/v1/handler"
 	"github.com/opensuperapp/opensuperapp/backend-services/core/internal/auth/rbac"
 	"github.com/opensuperapp/opensuperapp/backend-services/core/internal/config"
+	"github.com/opensuperapp/opensuperapp/backend-services/core/internal/metrics"
 	"github.com/opensuperapp/opensuperapp/backend-services/core/internal/services"
 
 	fileservice "github.com/opensuperapp/opensuperapp/backend-services/core/plugins/file-service"
@@ -31,24 +33,29 @@ import (
 )
 
 // NewUserRouter returns the http.Handler for user-authenticated routes (Asgardeo).
-func NewUserRouter(db *gorm.DB, fcmService services.NotificationService, fileService fileservice.FileService, userService userservice.UserService, cfg *config.Config) http.Handler {
+func NewUserRouter(db *gorm.DB, fcmService services.NotificationService, fileService fileservice.FileService, userService userservice.UserService, erasurePolicy services.ErasurePolicy, cfg *config.Config) http.Handler {
 	r := chi.NewRouter()
 
-	r.Mount("/micro-apps", MicroAppRoutes(db))
+	r.Mount("/micro-apps", MicroAppRoutes(db, time.Duration(cfg.ConfigConflictWindowSec)*time.Second))
+	r.Mount("/categories", categoryRoutes(db))
 	r.Mount("/device-tokens", deviceTokenRoutes(db, fcmService))
+	r.Mount("/notifications", userNotificationRoutes(db, fcmService))
 	r.Mount("/token", TokenRoutes(db, cfg))
-	r.Mount("/files", fileRoutes(fileService, cfg))
-	r.Mount("/users", userRoutes(db, userService))
+	r.Mount("/files", fileRoutes(db, fileService, cfg))
+	r.Mount("/users", userRoutes(db, userService, erasurePolicy))
 	r.Mount("/user-info", userInfoRoutes(userService))
+	r.Mount("/admin", adminRoutes(db, fcmService))
 
 	return r
 }
 
 // NewServiceRouter returns the http.Handler for service-authenticated routes (Internal IDP).
-func NewServiceRouter(db *gorm.DB, fcmService services.NotificationService) http.Handler {
+// With scopesRequired, callers need the scope of each route in their token or API key.
+// maxTokensPerSend is the token limit the FCM service was configured with.
+func NewServiceRouter(db *gorm.DB, fcmService services.NotificationService, receiptSigner *services.ReceiptSigner, defaultSender services.SenderIdentity, quota *services.QuotaService, imagePolicy *services.NotificationImagePolicy, notificationMetrics *metrics.NotificationMetrics, maxTokensPerSend int, scopesRequired bool) http.Handler {
 	r := chi.NewRouter()
 
-	r.Mount("/notifications", NotificationRoutes(db, fcmService))
+	r.Mount("/notifications", NotificationRoutes(db, fcmService, receiptSigner, defaultSender, quota, imagePolicy, notificationMetrics, maxTokensPerSend, scopesRequired))
 
 	return r
 }
@@ -77,18 +84,23 @@ func PublicRoutes(fileService fileservice.FileService, cfg *config.Config) http.
 	r := chi.NewRouter()
 
 	// GET /public/micro-app-files/download/{fileName}
-	r.Get("/micro-app-files/download/{fileName}", handler.NewFileHandler(fileService, cfg.UploadFileMaxSizeMB).DownloadMicroAppFile)
+	// Only the db file service serves downloads; other backends return URLs that are downloaded from directly
+	if cfg.FileServiceType == "db" {
+		r.Get("/micro-app-files/download/{fileName}", handler.NewFileHandler(fileService, cfg.UploadFileMaxSizeMB).DownloadMicroAppFile)
+	}
 
 	return r
 }
 
 // MicroAppRoutes sets up a sub-router for all endpoints prefixed with /micro-apps.
-func MicroAppRoutes(db *gorm.DB) http.Handler {
+// Config upserts that overwrite another user's change made within conflictWindow are logged.
+func MicroAppRoutes(db *gorm.DB, conflictWindow time.Duration) http.Handler {
 	r := chi.NewRouter()
 
 	// Initialize Microapp Handlers
-	microappHandler := handler.NewMicroAppHandler(db)
+	microappHandler := handler.NewMicroAppHandler(db, conflictWindow)
 	microappVersionHandler := handler.NewMicroAppVersionHandler(db)
+	categoryHandler := handler.NewCategoryHandler(db)
 
 	// GET /micro-apps
 	r.Get("/", microappHandler.GetAll)
@@ -98,19 +110,107 @@ func MicroAppRoutes(db *gorm.DB) http.Handler {
 
 	// POST /micro-apps (admin only)
 	r.
-		With(rbac.RequireGroups(rbac.GroupAdmin)).
+		With(rbac.RequireRole(rbac.GroupAdmin)).
 		Post("/", microappHandler.Upsert)
 
-	// PUT /micro-apps/deactivate/{appID} (admin only)
+	// PUT /micro-apps/deactivate/{appID} (micro app admin only)
 	r.
-		With(rbac.RequireGroups(rbac.GroupAdmin)).
+		With(rbac.MicroAppAdminMiddleware(db)).
 		Put("/deactivate/{appID}", microappHandler.Deactivate)
 
-	// POST /micro-apps/{appID}/versions (admin only)
+	// DELETE /micro-apps/{appID} (micro app admin only)
 	r.
-		With(rbac.RequireGroups(rbac.GroupAdmin)).
+		With(rbac.MicroAppAdminMiddleware(db)).
+		Delete("/{appID}", microappHandler.Deactivate)
+
+	// POST /micro-apps/{appID}/versions (micro app admin only)
+	r.
+		With(rbac.MicroAppAdminMiddleware(db), handler.LoadMicroApp(db)).
 		Post("/{appID}/versions", microappVersionHandler.UpsertVersion)
 
+	// PUT /micro-apps/{appID}/categories (micro app admin only)
+	r.
+		With(rbac.MicroAppAdminMiddleware(db), handler.LoadMicroApp(db)).
+		Put("/{appID}/categories", categoryHandler.SetMicroAppCategories)
+
+	// GET /micro-apps/{appID}/config-conflicts (micro app admin only)
+	r.
+		With(rbac.MicroAppAdminMiddleware(db), handler.LoadMicroApp(db)).
+		Get("/{appID}/config-conflicts", microappHandler.ListConfigConflicts)
+
+	// /micro-apps/{appID}/api-keys (micro app admin only)
+	r.
+		With(rbac.MicroAppAdminMiddleware(db)).
+		Mount("/{appID}/api-keys", apiKeyRoutes(db))
+
+	// /micro-apps/{appID}/notification-templates (micro app admin only)
+	r.
+		With(rbac.MicroAppAdminMiddleware(db)).
+		Mount("/{appID}/notification-templates", notificationTemplateRoutes(db))
+
+	return r
+}
+
+// categoryRoutes sets up a sub-router for the categories micro apps are browsed by.
+func categoryRoutes(db *gorm.DB) http.Handler {
+	r := chi.NewRouter()
+	categoryHandler := handler.NewCategoryHandler(db)
+
+	// GET /categories
+	r.Get("/", categoryHandler.List)
+
+	// POST /categories (admin only)
+	r.
+		With(rbac.RequireRole(rbac.GroupAdmin)).
+		Post("/", categoryHandler.Create)
+
+	// DELETE /categories/{slug} (admin only)
+	r.
+		With(rbac.RequireRole(rbac.GroupAdmin)).
+		Delete("/{slug}", categoryHandler.Delete)
+
+	return r
+}
+
+// apiKeyRoutes sets up a sub-router for managing the API keys of a micro app
+func apiKeyRoutes(db *gorm.DB) http.Handler {
+	r := chi.NewRouter()
+
+	apiKeyHandler := handler.NewAPIKeyHandler(db)
+
+	// GET /micro-apps/{appID}/api-keys
+	r.Get("/", apiKeyHandler.List)
+
+	// POST /micro-apps/{appID}/api-keys
+	r.Post("/", apiKeyHandler.Create)
+
+	// DELETE /micro-apps/{appID}/api-keys/{keyID}
+	r.Delete("/{keyID}", apiKeyHandler.Revoke)
+
+	// POST /micro-apps/{appID}/api-keys/{keyID}/rotate
+	r.Post("/{keyID}/rotate", apiKeyHandler.Rotate)
+
+	return r
+}
+
+// notificationTemplateRoutes sets up a sub-router for managing the notification templates of a micro app
+func notificationTemplateRoutes(db *gorm.DB) http.Handler {
+	r := chi.NewRouter()
+
+	notificationHandler := handler.NewNotificationHandler(db, nil, nil, services.SenderIdentity{})
+
+	// GET /micro-apps/{appID}/notification-templates
+	r.Get("/", notificationHandler.ListNotificationTemplates)
+
+	// GET /micro-apps/{appID}/notification-templates/{templateKey}?locale=xx
+	r.Get("/{templateKey}", notificationHandler.GetNotificationTemplate)
+
+	// PUT /micro-apps/{appID}/notification-templates/{templateKey}
+	r.Put("/{templateKey}", notificationHandler.PutNotificationTemplate)
+
+	// DELETE /micro-apps/{appID}/notification-templates/{templateKey}?locale=xx
+	r.Delete("/{templateKey}", notificationHandler.DeleteNotificationTemplate)
+
 	return r
 }
 
@@ -118,7 +218,7 @@ func MicroAppRoutes(db *gorm.DB) http.Handler {
 func deviceTokenRoutes(db *gorm.DB, fcmService services.NotificationService) http.Handler {
 	r := chi.NewRouter()
 
-	notificationHandler := handler.NewNotificationHandler(db, fcmService)
+	notificationHandler := handler.NewNotificationHandler(db, fcmService, nil, services.SenderIdentity{})
 
 	// POST /device-tokens
 	r.Post("/", notificationHandler.RegisterDeviceToken)
@@ -126,17 +226,99 @@ func deviceTokenRoutes(db *gorm.DB, fcmService services.NotificationService) htt
 	// DELETE /device-tokens
 	r.Delete("/", notificationHandler.DeactivateDeviceToken)
 
+	// POST /device-tokens/revoke (admin only)
+	r.
+		With(rbac.RequireRole(rbac.GroupAdmin)).
+		Post("/revoke", notificationHandler.RevokeUserDevices)
+
 	return r
 }
 
-// NotificationRoutes sets up a sub-router for notification endpoints
-func NotificationRoutes(db *gorm.DB, fcmService services.NotificationService) http.Handler {
+// userNotificationRoutes sets up a sub-router for notification endpoints of the authenticated user
+func userNotificationRoutes(db *gorm.DB, fcmService services.NotificationService) http.Handler {
 	r := chi.NewRouter()
 
-	notificationHandler := handler.NewNotificationHandler(db, fcmService)
+	notificationHandler := handler.NewNotificationHandler(db, fcmService, nil, services.SenderIdentity{})
+
+	// GET /notifications
+	r.Get("/", notificationHandler.GetNotificationHistory)
+
+	// GET /notifications/preferences
+	r.Get("/preferences", notificationHandler.GetNotificationPreferences)
+
+	// PUT /notifications/preferences
+	r.Put("/preferences", notificationHandler.UpdateNotificationPreferences)
+
+	// GET /notifications/devices
+	r.Get("/devices", notificationHandler.ListDevices)
+
+	// DELETE /notifications/devices
+	r.Delete("/devices", notificationHandler.DeactivateAllDevices)
+
+	// GET /notifications/unread-count
+	r.Get("/unread-count", notificationHandler.GetUnreadCount)
+
+	// POST /notifications/{notificationID}/read
+	r.Post("/{notificationID}/read", notificationHandler.MarkNotificationRead)
+
+	return r
+}
+
+// NotificationRoutes sets up a sub-router for notification endpoints.
+// With scopesRequired, the routes that send or schedule notifications require notifications:send.
+func NotificationRoutes(db *gorm.DB, fcmService services.NotificationService, receiptSigner *services.ReceiptSigner, defaultSender services.SenderIdentity, quota *services.QuotaService, imagePolicy *services.NotificationImagePolicy, notificationMetrics *metrics.NotificationMetrics, maxTokensPerSend int, scopesRequired bool) http.Handler {
+	r := chi.NewRouter()
+
+	notificationHandler := handler.NewNotificationHandler(db, fcmService, receiptSigner, defaultSender).
+		WithQuotaService(quota).
+		WithImagePolicy(imagePolicy).
+		WithCoalescer(services.NewNotificationCoalescer(db, services.SystemClock)).
+		WithMetrics(notificationMetrics).
+		WithMaxTokensPerSend(maxTokensPerSend)
+
+	send := chi.Chain()
+	if scopesRequired {
+		send = chi.Chain(rbac.RequireScopes(rbac.ScopeNotificationsSend))
+	}
 
 	// POST /notifications/send
-	r.Post("/send", notificationHandler.SendNotification)
+	r.With(send...).Post("/send", notificationHandler.SendNotification)
+
+	// POST /notifications/groups/send
+	r.With(send...).Post("/groups/send", notificationHandler.SendToGroups)
+
+	// POST /notifications/topics/subscribe
+	r.Post("/topics/subscribe", notificationHandler.SubscribeToTopic)
+
+	// POST /notifications/topics/unsubscribe
+	r.Post("/topics/unsubscribe", notificationHandler.UnsubscribeFromTopic)
+
+	// POST /notifications/topics/send
+	r.With(send...).Post("/topics/send", notificationHandler.SendToTopic)
+
+	// POST /notifications/groups/preview
+	r.Post("/groups/preview", notificationHandler.PreviewSendToGroups)
+
+	// POST /notifications/send-template
+	r.With(send...).Post("/send-template", notificationHandler.SendTemplateNotification)
+
+	// PUT /notifications/templates
+	r.Put("/templates", notificationHandler.UpsertNotificationTemplate)
+
+	// POST /notifications/schedule
+	r.With(send...).Post("/schedule", notificationHandler.ScheduleNotification)
+
+	// DELETE /notifications/schedule/{scheduleID}
+	r.With(send...).Delete("/schedule/{scheduleID}", notificationHandler.CancelScheduledNotification)
+
+	// GET /notifications/receipts/{receiptID}
+	r.Get("/receipts/{receiptID}", notificationHandler.GetNotificationReceipt)
+
+	// POST /notifications/delivery-webhook
+	r.Post("/delivery-webhook", notificationHandler.DeliveryWebhook)
+
+	// GET /notifications/{notificationID}/status
+	r.Get("/{notificationID}/status", notificationHandler.GetNotificationDeliveryStatus)
 
 	return r
 }
@@ -150,25 +332,36 @@ func TokenRoutes(db *gorm.DB, cfg *config.Config) http.Handler {
 	// POST /token/exchange - Exchange user token for microapp token (requires user auth)
 	r.Post("/exchange", tokenHandler.ExchangeToken)
 
+	// POST /token/preview - Preview the claims of a user's microapp token (admin only)
+	r.
+		With(rbac.RequireRole(rbac.GroupAdmin)).
+		Post("/preview", tokenHandler.PreviewToken)
+
 	return r
 }
 
 // fileRoutes sets up a sub-router for file operations.
-func fileRoutes(fileService fileservice.FileService, cfg *config.Config) http.Handler {
+func fileRoutes(db *gorm.DB, fileService fileservice.FileService, cfg *config.Config) http.Handler {
 	r := chi.NewRouter()
 
-	fileHandler := handler.NewFileHandler(fileService, cfg.UploadFileMaxSizeMB)
+	fileHandler := handler.NewFileHandler(fileService, cfg.UploadFileMaxSizeMB).
+		WithAssetOwners(db)
 
-	// POST /files?fileName=xxx
+	// POST /files?fileName=xxx[&microappId=xxx]
 	r.
-		With(rbac.RequireGroups(rbac.GroupAdmin)).
+		With(rbac.RequireRole(rbac.GroupAdmin)).
 		Post("/", fileHandler.UploadFile)
 
 	// DELETE /files?fileName=xxx
 	r.
-		With(rbac.RequireGroups(rbac.GroupAdmin)).
+		With(rbac.RequireRole(rbac.GroupAdmin)).
 		Delete("/", fileHandler.DeleteFile)
 
+	// GET /files/presign?fileName=xxx&op=upload|download
+	r.
+		With(rbac.RequireRole(rbac.GroupAdmin)).
+		Get("/presign", fileHandler.PresignFile)
+
 	return r
 }
 
@@ -185,26 +378,58 @@ func userInfoRoutes(userService userservice.UserService) http.Handler {
 }
 
 // userRoutes sets up a sub-router for all endpoints prefixed with /users.
-func userRoutes(db *gorm.DB, userService userservice.UserService) http.Handler {
+func userRoutes(db *gorm.DB, userService userservice.UserService, erasurePolicy services.ErasurePolicy) http.Handler {
 	r := chi.NewRouter()
 
 	// Initialize User Config Handler
 	userConfigHandler := handler.NewUserConfigHandler(db)
 	userHandler := handler.NewUserHandler(userService)
+	userImportHandler := handler.NewUserImportHandler(db, services.NewUserImporter(db, userService, services.DefaultUserImportBatchSize))
+	userDataExportHandler := handler.NewUserDataExportHandler(db, services.NewUserDataExporter(db, userService))
+	userErasureHandler := handler.NewUserErasureHandler(services.NewUserEraser(db, userService, erasurePolicy))
 
 	// GET /users
 	r.
-		With(rbac.RequireGroups(rbac.GroupAdmin)).
+		With(rbac.RequireRole(rbac.GroupAdmin)).
 		Get("/", userHandler.GetAll)
 
 	// POST /users
 	r.
-		With(rbac.RequireGroups(rbac.GroupAdmin)).
+		With(rbac.RequireRole(rbac.GroupAdmin)).
 		Post("/", userHandler.Upsert)
 
+	// POST /users/bulk
+	r.
+		With(rbac.RequireRole(rbac.GroupAdmin)).
+		Post("/bulk", userHandler.BulkUpsert)
+
+	// POST /users/import
+	r.
+		With(rbac.RequireRole(rbac.GroupAdmin)).
+		Post("/import", userImportHandler.Import)
+
+	// GET /users/import/{jobID}
+	r.
+		With(rbac.RequireRole(rbac.GroupAdmin)).
+		Get("/import/{jobID}", userImportHandler.GetImportJob)
+
+	// POST /users/{email}/data-export (the user themselves or an admin)
+	r.Post("/{email}/data-export", userDataExportHandler.RequestExport)
+
+	// GET /users/data-exports/{exportID} (the user, the requester or an admin)
+	r.Get("/data-exports/{exportID}", userDataExportHandler.GetExport)
+
+	// GET /users/data-exports/{exportID}/download (the user, the requester or an admin)
+	r.Get("/data-exports/{exportID}/download", userDataExportHandler.DownloadExport)
+
+	// DELETE /users/{email}/data - Erase the user's personal data and deactivate them
+	r.
+		With(rbac.RequireRole(rbac.GroupAdmin)).
+		Delete("/{email}/data", userErasureHandler.EraseUser)
+
 	// DELETE /users/{email}
 	r.
-		With(rbac.RequireGroups(rbac.GroupAdmin)).
+		With(rbac.RequireRole(rbac.GroupAdmin)).
 		Delete("/{email}", userHandler.Delete)
 
 	// GET /users/app-configs
@@ -215,3 +440,36 @@ func userRoutes(db *gorm.DB, userService userservice.UserService) http.Handler {
 
 	return r
 }
+
+// adminRoutes sets up a sub-router for platform administration endpoints (admin only)
+func adminRoutes(db *gorm.DB, fcmService services.NotificationService) http.Handler {
+	r := chi.NewRouter()
+	r.Use(rbac.RequireRole(rbac.GroupAdmin))
+
+	rbacHandler := handler.NewRBACHandler()
+	notificationHandler := handler.NewNotificationHandler(db, fcmService, nil, services.SenderIdentity{})
+
+	// POST /admin/rbac/reload
+	r.Post("/rbac/reload", rbacHandler.ReloadPermissions)
+
+	// DELETE /admin/users/{email}/devices
+	r.Delete("/users/{email}/devices", notificationHandler.RevokeUserDevicesByEmail)
+
+	return r
+}
+
+// DebugRoutes sets up a sub-router for diagnostic endpoints (admin only)
+func DebugRoutes(validators map[string]services.JWKSCacheInspector) http.Handler {
+	r := chi.NewRouter()
+	r.Use(rbac.RequireRole(rbac.GroupAdmin))
+
+	debugHandler := handler.NewDebugHandler(validators)
+
+	// GET /debug/jwks-cache
+	r.Get("/jwks-cache", debugHandler.GetJWKSCacheStats)
+
+	// POST /debug/jwks-cache/refresh
+	r.Post("/jwks-cache/refresh", debugHandler.RefreshJWKSCache)
+
+	return r
+}
@@ -0,0 +1,146 @@
+// Copyright (c) 2025 WSO2 LLC. (https://www.wso2.com).
+//
+// WSO2 LLC. licenses this file to you under the Apache License,
+// Version 2.0 (the "License"); you may not use this file except
+// in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+package auth
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+)
+
+const (
+	headerForwardedFor = "X-Forwarded-For"
+	headerRealIP       = "X-Real-IP"
+
+	clientIPKey = contextKey("clientIP")
+)
+
+// TrustedProxies holds the networks of the load balancers and proxies whose forwarding
+// headers are trusted when resolving a request's client IP.
+type TrustedProxies struct {
+	networks []*net.IPNet
+}
+
+// ParseTrustedProxies parses a list of CIDRs, or single IP addresses, into TrustedProxies.
+// An empty list trusts no proxy, so the client IP is always taken from RemoteAddr.
+func ParseTrustedProxies(cidrs []string) (*TrustedProxies, error) {
+	proxies := &TrustedProxies{}
+	for _, cidr := range cidrs {
+		cidr = strings.TrimSpace(cidr)
+		if cidr == "" {
+			continue
+		}
+		if !strings.Contains(cidr, "/") {
+			ip := net.ParseIP(cidr)
+			if ip == nil {
+				return nil, fmt.Errorf("invalid trusted proxy address %q", cidr)
+			}
+			bits := 8 * net.IPv6len
+			if ip.To4() != nil {
+				ip, bits = ip.To4(), 8*net.IPv4len
+			}
+			proxies.networks = append(proxies.networks, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted proxy CIDR %q: %w", cidr, err)
+		}
+		proxies.networks = append(proxies.networks, network)
+	}
+	return proxies, nil
+}
+
+// trusts reports whether ip belongs to one of the trusted proxy networks
+func (p *TrustedProxies) trusts(ip net.IP) bool {
+	if p == nil || ip == nil {
+		return false
+	}
+	for _, network := range p.networks {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// Resolve returns the IP of the client that sent the request. Forwarding headers are only
+// read when the direct peer is a trusted proxy. X-Forwarded-For is walked from the right,
+// skipping trusted proxies, so that addresses a client prepends to the header are ignored.
+// X-Real-IP is used when X-Forwarded-For is absent.
+func (p *TrustedProxies) Resolve(r *http.Request) string {
+	remoteIP := remoteAddrIP(r)
+	if !p.trusts(net.ParseIP(remoteIP)) {
+		return remoteIP
+	}
+
+	if header := r.Header.Values(headerForwardedFor); len(header) > 0 {
+		hops := strings.Split(strings.Join(header, ","), ",")
+		clientIP := ""
+		for i := len(hops) - 1; i >= 0; i-- {
+			ip := net.ParseIP(strings.TrimSpace(hops[i]))
+			if ip == nil {
+				// A malformed hop cannot be attributed, so stop at the last good address
+				break
+			}
+			clientIP = ip.String()
+			if !p.trusts(ip) {
+				return clientIP
+			}
+		}
+		if clientIP != "" {
+			return clientIP
+		}
+		return remoteIP
+	}
+	if ip := net.ParseIP(strings.TrimSpace(r.Header.Get(headerRealIP))); ip != nil {
+		return ip.String()
+	}
+	return remoteIP
+}
+
+// remoteAddrIP returns the host part of the request's RemoteAddr
+func remoteAddrIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// ClientIPMiddleware resolves the client IP of each request once and stores it in the
+// request context for ClientIP.
+func ClientIPMiddleware(proxies *TrustedProxies) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := context.WithValue(r.Context(), clientIPKey, proxies.Resolve(r))
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// ClientIP returns the client IP resolved by ClientIPMiddleware. Features that depend on the
+// caller's address (allowlists, rate limits, audit logs) must use this rather than RemoteAddr,
+// which is the load balancer when running behind one. Without the middleware it falls back
+// to RemoteAddr.
+func ClientIP(r *http.Request) string {
+	if ip, ok := r.Context().Value(clientIPKey).(string); ok {
+		return ip
+	}
+	return remoteAddrIP(r)
+}
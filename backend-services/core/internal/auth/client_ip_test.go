@@ -0,0 +1,96 @@
+// Copyright (c) 2025 WSO2 LLC. (https://www.wso2.com).
+//
+// WSO2 LLC. licenses this file to you under the Apache License,
+// Version 2.0 (the "License"); you may not use this file except
+// in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+package auth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestTrustedProxiesResolve(t *testing.T) {
+	proxies, err := ParseTrustedProxies([]string{"10.0.0.0/8", "192.168.1.5"})
+	if err != nil {
+		t.Fatalf("Failed to parse trusted proxies: %v", err)
+	}
+
+	tests := []struct {
+		name       string
+		proxies    *TrustedProxies
+		remoteAddr string
+		forwarded  []string
+		realIP     string
+		want       string
+	}{
+		{name: "no proxies configured ignores headers", proxies: &TrustedProxies{}, remoteAddr: "10.0.0.1:1234", forwarded: []string{"1.2.3.4"}, want: "10.0.0.1"},
+		{name: "spoofed header from untrusted peer", proxies: proxies, remoteAddr: "203.0.113.9:1234", forwarded: []string{"1.2.3.4"}, realIP: "5.6.7.8", want: "203.0.113.9"},
+		{name: "trusted proxy forwards client", proxies: proxies, remoteAddr: "10.0.0.1:1234", forwarded: []string{"198.51.100.7"}, want: "198.51.100.7"},
+		{name: "client-prepended hop is ignored", proxies: proxies, remoteAddr: "10.0.0.1:1234", forwarded: []string{"1.2.3.4, 198.51.100.7"}, want: "198.51.100.7"},
+		{name: "chain of trusted proxies", proxies: proxies, remoteAddr: "10.0.0.1:1234", forwarded: []string{"198.51.100.7, 192.168.1.5", "10.1.1.1"}, want: "198.51.100.7"},
+		{name: "all hops trusted uses leftmost", proxies: proxies, remoteAddr: "10.0.0.1:1234", forwarded: []string{"10.2.2.2, 10.3.3.3"}, want: "10.2.2.2"},
+		{name: "malformed rightmost hop falls back to peer", proxies: proxies, remoteAddr: "10.0.0.1:1234", forwarded: []string{"198.51.100.7, not-an-ip"}, want: "10.0.0.1"},
+		{name: "real ip from trusted proxy", proxies: proxies, remoteAddr: "192.168.1.5:80", realIP: "198.51.100.8", want: "198.51.100.8"},
+		{name: "invalid real ip falls back to peer", proxies: proxies, remoteAddr: "192.168.1.5:80", realIP: "garbage", want: "192.168.1.5"},
+		{name: "ipv6 peer", proxies: proxies, remoteAddr: "[2001:db8::1]:443", forwarded: []string{"1.2.3.4"}, want: "2001:db8::1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.RemoteAddr = tt.remoteAddr
+			for _, value := range tt.forwarded {
+				req.Header.Add(headerForwardedFor, value)
+			}
+			if tt.realIP != "" {
+				req.Header.Set(headerRealIP, tt.realIP)
+			}
+			if got := tt.proxies.Resolve(req); got != tt.want {
+				t.Errorf("Expected client IP %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestParseTrustedProxies_Invalid(t *testing.T) {
+	for _, cidr := range []string{"10.0.0.0/33", "not-an-ip"} {
+		if _, err := ParseTrustedProxies([]string{cidr}); err == nil {
+			t.Errorf("Expected an error for %q", cidr)
+		}
+	}
+}
+
+func TestClientIPMiddleware(t *testing.T) {
+	proxies, err := ParseTrustedProxies([]string{"10.0.0.0/8"})
+	if err != nil {
+		t.Fatalf("Failed to parse trusted proxies: %v", err)
+	}
+	var got string
+	handler := ClientIPMiddleware(proxies)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = ClientIP(r)
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.RemoteAddr = "10.0.0.1:1234"
+	req.Header.Set(headerForwardedFor, "198.51.100.7")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+	if got != "198.51.100.7" {
+		t.Errorf("Expected the forwarded client IP, got %q", got)
+	}
+
+	// Without the middleware the peer address is used
+	if ip := ClientIP(req); ip != "10.0.0.1" {
+		t.Errorf("Expected RemoteAddr fallback, got %q", ip)
+	}
+}
@@ -17,6 +17,7 @@ package auth
 
 import (
 	"encoding/json"
+	"errors"
 	"log/slog"
 	"net/http"
 	"strings"
@@ -25,16 +26,23 @@ import (
 )
 
 const (
-	authHeader  = "Authorization"
-	bearerToken = "bearer"
+	authHeader   = "Authorization"
+	bearerToken  = "bearer"
+	apiKeyHeader = "X-API-Key"
 )
 
 // AuthMiddleware is the middleware that validates JWT tokens for users.
-func AuthMiddleware(tokenValidator services.TokenValidator) func(http.Handler) http.Handler {
+// Only the first maxGroups groups of a token are kept; 0 keeps them all.
+func AuthMiddleware(tokenValidator services.TokenValidator, maxGroups int) func(http.Handler) http.Handler {
 	return validateTokenMiddleware(tokenValidator, func(r *http.Request, claims *services.TokenClaims) *http.Request {
+		groups := claims.Groups
+		if maxGroups > 0 && len(groups) > maxGroups {
+			slog.WarnContext(r.Context(), "Token carries too many groups, truncating", "email", claims.Email, "groups", len(groups), "limit", maxGroups)
+			groups = groups[:maxGroups]
+		}
 		userInfo := &CustomJwtPayload{
 			Email:  claims.Email,
-			Groups: claims.Groups,
+			Groups: groups,
 		}
 		return SetUserInfo(r, userInfo)
 	})
@@ -45,25 +53,71 @@ func ServiceOAuthMiddleware(tokenValidator services.TokenValidator) func(http.Ha
 	return validateTokenMiddleware(tokenValidator, func(r *http.Request, claims *services.TokenClaims) *http.Request {
 		serviceInfo := &ServiceInfo{
 			ClientID: claims.Subject,
+			Scopes:   strings.Fields(claims.Scopes),
 		}
 		return SetServiceInfo(r, serviceInfo)
 	})
 }
 
+// ServiceAuthMiddleware authenticates services with an X-API-Key header when one is sent,
+// and with an OAuth Bearer token otherwise. Both populate the same ServiceInfo.
+func ServiceAuthMiddleware(tokenValidator services.TokenValidator, apiKeys *services.APIKeyAuthenticator) func(http.Handler) http.Handler {
+	oauth := ServiceOAuthMiddleware(tokenValidator)
+	return func(next http.Handler) http.Handler {
+		oauthNext := oauth(next)
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := r.Header.Get(apiKeyHeader)
+			if key == "" || apiKeys == nil {
+				oauthNext.ServeHTTP(w, r)
+				return
+			}
+
+			apiKey, err := apiKeys.Authenticate(r.Context(), key)
+			if err != nil {
+				switch {
+				case errors.Is(err, services.ErrAPIKeyRateLimited):
+					slog.WarnContext(r.Context(), "API key rate limited", "path", r.URL.Path, "method", r.Method)
+					writeError(w, http.StatusTooManyRequests, "API key rate limit exceeded")
+				case errors.Is(err, services.ErrInvalidAPIKey):
+					slog.WarnContext(r.Context(), "API key authentication failed", "path", r.URL.Path, "method", r.Method)
+					writeError(w, http.StatusUnauthorized, "Invalid API key")
+				default:
+					slog.ErrorContext(r.Context(), "API key authentication error", "error", err, "path", r.URL.Path, "method", r.Method)
+					writeError(w, http.StatusInternalServerError, "Failed to authenticate API key")
+				}
+				return
+			}
+
+			// Audit trail of API key use; keys are identified by their non-secret prefix
+			slog.InfoContext(r.Context(), "API key authenticated",
+				"key_id", apiKey.ID,
+				"key_prefix", apiKey.KeyPrefix,
+				"microapp_id", apiKey.MicroappID,
+				"path", r.URL.Path,
+				"method", r.Method)
+			r = SetServiceInfo(r, &ServiceInfo{
+				ClientID: apiKey.MicroappID,
+				Scopes:   strings.Fields(apiKey.Scopes),
+			})
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
 // validateTokenMiddleware is a generic middleware for token validation.
 func validateTokenMiddleware(tokenValidator services.TokenValidator, onSuccess func(r *http.Request, claims *services.TokenClaims) *http.Request) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			tokenString, ok := extractBearerToken(r)
 			if !ok {
-				slog.Warn("Missing or invalid Authorization header", "path", r.URL.Path, "method", r.Method)
+				slog.WarnContext(r.Context(), "Missing or invalid Authorization header", "path", r.URL.Path, "method", r.Method)
 				writeError(w, http.StatusUnauthorized, "Missing or invalid Authorization header")
 				return
 			}
 
 			claims, err := tokenValidator.ValidateToken(tokenString)
 			if err != nil {
-				slog.Error("Token validation failed", "error", err, "path", r.URL.Path, "method", r.Method)
+				slog.ErrorContext(r.Context(), "Token validation failed", "error", err, "path", r.URL.Path, "method", r.Method)
 				writeError(w, http.StatusUnauthorized, "Invalid or expired token")
 				return
 			}
@@ -0,0 +1,196 @@
+// Copyright (c) 2025 WSO2 LLC. (https://www.wso2.com).
+//
+// WSO2 LLC. licenses this file to you under the Apache License,
+// Version 2.0 (the "License"); you may not use this file except
+// in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+package auth
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/opensuperapp/opensuperapp/backend-services/core/internal/models"
+	"github.com/opensuperapp/opensuperapp/backend-services/core/internal/services"
+
+	"github.com/golang-jwt/jwt/v4"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// fakeTokenValidator accepts only the token "valid-token", issued to "oauth-app"
+type fakeTokenValidator struct{}
+
+func (fakeTokenValidator) ValidateToken(tokenString string) (*services.TokenClaims, error) {
+	if tokenString != "valid-token" {
+		return nil, errors.New("invalid token")
+	}
+	return &services.TokenClaims{RegisteredClaims: jwt.RegisteredClaims{Subject: "oauth-app"}, Scopes: "notifications:send"}, nil
+}
+
+func (fakeTokenValidator) GetJWKS() (json.RawMessage, error) {
+	return nil, nil
+}
+
+// setupAPIKeyDB creates an in-memory database with one active and one revoked key for "payroll"
+func setupAPIKeyDB(t *testing.T) (db *gorm.DB, activeKey, revokedKey string) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("Failed to open test database: %v", err)
+	}
+	if err := db.AutoMigrate(&models.MicroAppAPIKey{}); err != nil {
+		t.Fatalf("Failed to migrate database: %v", err)
+	}
+
+	now := time.Now()
+	for _, revoked := range []bool{false, true} {
+		key, prefix, hash, err := services.GenerateAPIKey()
+		if err != nil {
+			t.Fatalf("GenerateAPIKey failed: %v", err)
+		}
+		record := models.MicroAppAPIKey{MicroappID: "payroll", Name: "backend", KeyPrefix: prefix, KeyHash: hash, Scopes: "notifications:send", CreatedBy: "admin@example.com"}
+		if revoked {
+			record.RevokedAt = &now
+			revokedKey = key
+		} else {
+			activeKey = key
+		}
+		if err := db.Create(&record).Error; err != nil {
+			t.Fatalf("Failed to seed API key: %v", err)
+		}
+	}
+	return db, activeKey, revokedKey
+}
+
+// serviceInfoHandler echoes the authenticated client ID
+var serviceInfoHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	info, ok := GetServiceInfo(r.Context())
+	if !ok {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	w.Write([]byte(info.ClientID))
+})
+
+// TestServiceAuthMiddleware_APIKeys tests valid, revoked, expired and unknown API keys and the OAuth fallback
+func TestServiceAuthMiddleware_APIKeys(t *testing.T) {
+	db, activeKey, revokedKey := setupAPIKeyDB(t)
+	expiredKey, prefix, hash, err := services.GenerateAPIKey()
+	if err != nil {
+		t.Fatalf("GenerateAPIKey failed: %v", err)
+	}
+	expired := time.Now().Add(-time.Minute)
+	if err := db.Create(&models.MicroAppAPIKey{MicroappID: "payroll", Name: "old", KeyPrefix: prefix, KeyHash: hash, CreatedBy: "admin@example.com", ExpiresAt: &expired}).Error; err != nil {
+		t.Fatalf("Failed to seed API key: %v", err)
+	}
+	handler := ServiceAuthMiddleware(fakeTokenValidator{}, services.NewAPIKeyAuthenticator(db, 100, 100))(serviceInfoHandler)
+
+	tests := []struct {
+		name       string
+		apiKey     string
+		bearer     string
+		wantStatus int
+		wantClient string
+	}{
+		{name: "valid key", apiKey: activeKey, wantStatus: http.StatusOK, wantClient: "payroll"},
+		{name: "revoked key", apiKey: revokedKey, wantStatus: http.StatusUnauthorized},
+		{name: "expired key", apiKey: expiredKey, wantStatus: http.StatusUnauthorized},
+		{name: "unknown key", apiKey: "sak_doesnotexist", wantStatus: http.StatusUnauthorized},
+		{name: "malformed key", apiKey: "not-an-api-key", wantStatus: http.StatusUnauthorized},
+		{name: "oauth token", bearer: "valid-token", wantStatus: http.StatusOK, wantClient: "oauth-app"},
+		{name: "no credentials", wantStatus: http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/notifications/send", nil)
+			if tt.apiKey != "" {
+				req.Header.Set(apiKeyHeader, tt.apiKey)
+			}
+			if tt.bearer != "" {
+				req.Header.Set(authHeader, "Bearer "+tt.bearer)
+			}
+			w := httptest.NewRecorder()
+
+			handler.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("Expected status %d, got %d: %s", tt.wantStatus, w.Code, w.Body.String())
+			}
+			if tt.wantClient != "" && w.Body.String() != tt.wantClient {
+				t.Errorf("Expected client %q, got %q", tt.wantClient, w.Body.String())
+			}
+		})
+	}
+
+	var stored models.MicroAppAPIKey
+	db.Where("key_hash = ?", services.HashAPIKey(activeKey)).First(&stored)
+	if stored.LastUsedAt == nil {
+		t.Error("Expected last_used_at to be recorded for the valid key")
+	}
+}
+
+// TestServiceAuthMiddleware_APIKeyRateLimit tests that a key over its rate limit is rejected
+func TestServiceAuthMiddleware_APIKeyRateLimit(t *testing.T) {
+	db, activeKey, _ := setupAPIKeyDB(t)
+	handler := ServiceAuthMiddleware(fakeTokenValidator{}, services.NewAPIKeyAuthenticator(db, 0.001, 2))(serviceInfoHandler)
+
+	codes := make([]int, 3)
+	for i := range codes {
+		req := httptest.NewRequest(http.MethodPost, "/notifications/send", nil)
+		req.Header.Set(apiKeyHeader, activeKey)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		codes[i] = w.Code
+	}
+
+	if codes[0] != http.StatusOK || codes[1] != http.StatusOK || codes[2] != http.StatusTooManyRequests {
+		t.Errorf("Expected 200, 200, 429 within the burst, got %v", codes)
+	}
+}
+
+// groupsTokenValidator accepts any token and issues it to alice in the given groups
+type groupsTokenValidator struct{ groups []string }
+
+func (v groupsTokenValidator) ValidateToken(string) (*services.TokenClaims, error) {
+	return &services.TokenClaims{Email: "alice@example.com", Groups: v.groups}, nil
+}
+
+func (groupsTokenValidator) GetJWKS() (json.RawMessage, error) {
+	return nil, nil
+}
+
+// TestAuthMiddleware_MaxGroups tests that groups beyond the cap are dropped from the user info
+func TestAuthMiddleware_MaxGroups(t *testing.T) {
+	groups := make([]string, 50)
+	for i := range groups {
+		groups[i] = "group"
+	}
+	var gotGroups []string
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		userInfo, _ := GetUserInfo(r.Context())
+		gotGroups = userInfo.Groups
+	})
+
+	for _, tt := range []struct{ maxGroups, want int }{{10, 10}, {100, 50}, {0, 50}} {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("Authorization", "Bearer token")
+		AuthMiddleware(groupsTokenValidator{groups: groups}, tt.maxGroups)(next).ServeHTTP(httptest.NewRecorder(), req)
+		if len(gotGroups) != tt.want {
+			t.Errorf("maxGroups %d: expected %d groups, got %d", tt.maxGroups, tt.want, len(gotGroups))
+		}
+	}
+}
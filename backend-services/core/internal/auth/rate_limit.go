@@ -0,0 +1,140 @@
+// Copyright (c) 2025 WSO2 LLC. (https://www.wso2.com).
+//
+// WSO2 LLC. licenses this file to you under the Apache License,
+// Version 2.0 (the "License"); you may not use this file except
+// in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+package auth
+
+import (
+	"context"
+	"log/slog"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/opensuperapp/opensuperapp/backend-services/core/internal/services"
+
+	"golang.org/x/time/rate"
+)
+
+// rateLimitSweepInterval is how often idle buckets are dropped from a MemoryRateLimiter
+const rateLimitSweepInterval = time.Minute
+
+// RateLimiter decides whether a caller, identified by key, may make another request. The in-memory
+// limiter is used by default; a shared store such as Redis can implement it to limit across replicas.
+type RateLimiter interface {
+	// Allow consumes one request for key. When it is refused, retryAfter is how long until one would be allowed.
+	Allow(ctx context.Context, key string) (allowed bool, retryAfter time.Duration, err error)
+}
+
+// MemoryRateLimiter is a token bucket per key held in process memory, so each replica limits on its own.
+type MemoryRateLimiter struct {
+	limit rate.Limit
+	burst int
+	clock services.Clock
+
+	mu        sync.Mutex
+	buckets   map[string]*rate.Limiter
+	lastSweep time.Time
+}
+
+// NewMemoryRateLimiter allows each key requestsPerSecond sustained, and burst (at least 1) requests at once.
+func NewMemoryRateLimiter(requestsPerSecond float64, burst int) *MemoryRateLimiter {
+	return &MemoryRateLimiter{
+		limit:   rate.Limit(requestsPerSecond),
+		burst:   max(burst, 1),
+		clock:   services.SystemClock,
+		buckets: make(map[string]*rate.Limiter),
+	}
+}
+
+func (l *MemoryRateLimiter) Allow(_ context.Context, key string) (bool, time.Duration, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.clock.Now()
+	l.sweep(now)
+	bucket, ok := l.buckets[key]
+	if !ok {
+		bucket = rate.NewLimiter(l.limit, l.burst)
+		l.buckets[key] = bucket
+	}
+	if bucket.AllowN(now, 1) {
+		return true, 0, nil
+	}
+	// Reserve to learn when the next token is due, then hand it back
+	reservation := bucket.ReserveN(now, 1)
+	retryAfter := reservation.DelayFrom(now)
+	reservation.CancelAt(now)
+	return false, retryAfter, nil
+}
+
+// sweep drops buckets that have refilled completely, since they behave like new ones. It keeps
+// memory bounded by the callers active within the last sweep interval.
+func (l *MemoryRateLimiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < rateLimitSweepInterval {
+		return
+	}
+	l.lastSweep = now
+	for key, bucket := range l.buckets {
+		if bucket.TokensAt(now) >= float64(l.burst) {
+			delete(l.buckets, key)
+		}
+	}
+}
+
+// UserRateLimitKey keys user routes by the authenticated user's email
+func UserRateLimitKey(r *http.Request) string {
+	if userInfo, ok := GetUserInfo(r.Context()); ok && userInfo.Email != "" {
+		return "user:" + userInfo.Email
+	}
+	return ""
+}
+
+// ServiceRateLimitKey keys service routes by the calling client, which is the microapp ID
+func ServiceRateLimitKey(r *http.Request) string {
+	if serviceInfo, ok := GetServiceInfo(r.Context()); ok && serviceInfo.ClientID != "" {
+		return "client:" + serviceInfo.ClientID
+	}
+	return ""
+}
+
+// RateLimitMiddleware rejects a caller's requests with 429 and Retry-After once limiter refuses them.
+// key names the caller and runs after authentication; requests it cannot name pass unthrottled.
+// When the limiter fails, requests are let through rather than failing the API with it.
+func RateLimitMiddleware(limiter RateLimiter, key func(*http.Request) string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			caller := key(r)
+			if caller == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+			allowed, retryAfter, err := limiter.Allow(r.Context(), caller)
+			if err != nil {
+				slog.ErrorContext(r.Context(), "Rate limiter failed, allowing request", "error", err, "caller", caller)
+				next.ServeHTTP(w, r)
+				return
+			}
+			if !allowed {
+				slog.WarnContext(r.Context(), "Rate limit exceeded", "caller", caller, "path", r.URL.Path, "method", r.Method)
+				w.Header().Set("Retry-After", strconv.Itoa(max(1, int(math.Ceil(retryAfter.Seconds())))))
+				writeError(w, http.StatusTooManyRequests, "Rate limit exceeded")
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
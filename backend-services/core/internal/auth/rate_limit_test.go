@@ -0,0 +1,116 @@
+// Copyright (c) 2025 WSO2 LLC. (https://www.wso2.com).
+//
+// WSO2 LLC. licenses this file to you under the Apache License,
+// Version 2.0 (the "License"); you may not use this file except
+// in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+package auth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/opensuperapp/opensuperapp/backend-services/core/internal/clocktest"
+)
+
+// TestMemoryRateLimiter tests that each key gets its own bucket, refilled at the configured rate
+func TestMemoryRateLimiter(t *testing.T) {
+	clock := clocktest.NewFakeClock(time.Unix(1_700_000_000, 0))
+	limiter := NewMemoryRateLimiter(2, 3)
+	limiter.clock = clock
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		if allowed, _, _ := limiter.Allow(ctx, "user:alice@example.com"); !allowed {
+			t.Fatalf("Expected request %d within the burst to be allowed", i+1)
+		}
+	}
+	allowed, retryAfter, err := limiter.Allow(ctx, "user:alice@example.com")
+	if err != nil || allowed {
+		t.Fatalf("Expected the request after the burst to be refused, got %v, %v", allowed, err)
+	}
+	if retryAfter != 500*time.Millisecond {
+		t.Errorf("Expected to retry after 500ms at 2 requests per second, got %s", retryAfter)
+	}
+	if allowed, _, _ := limiter.Allow(ctx, "user:bob@example.com"); !allowed {
+		t.Error("Expected another user to have a separate bucket")
+	}
+
+	clock.Advance(500 * time.Millisecond)
+	if allowed, _, _ := limiter.Allow(ctx, "user:alice@example.com"); !allowed {
+		t.Error("Expected a request to be allowed once a token refilled")
+	}
+
+	// Buckets that have refilled are dropped on the next sweep
+	clock.Advance(rateLimitSweepInterval)
+	limiter.Allow(ctx, "user:carol@example.com")
+	if len(limiter.buckets) != 1 {
+		t.Errorf("Expected only the active bucket to be kept, got %d", len(limiter.buckets))
+	}
+}
+
+// failingRateLimiter fails every check
+type failingRateLimiter struct{}
+
+func (failingRateLimiter) Allow(context.Context, string) (bool, time.Duration, error) {
+	return false, 0, errors.New("store unavailable")
+}
+
+// TestRateLimitMiddleware tests 429 with Retry-After once a caller is over its limit, and that
+// requests without a caller or with a failing limiter are let through
+func TestRateLimitMiddleware(t *testing.T) {
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) })
+	serve := func(h http.Handler, r *http.Request) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		return w
+	}
+	asClient := func(clientID string) *http.Request {
+		return SetServiceInfo(httptest.NewRequest(http.MethodPost, "/api/v1/services/notifications/send", nil), &ServiceInfo{ClientID: clientID})
+	}
+
+	limiter := NewMemoryRateLimiter(0.5, 1)
+	h := RateLimitMiddleware(limiter, ServiceRateLimitKey)(ok)
+	if w := serve(h, asClient("payroll")); w.Code != http.StatusOK {
+		t.Fatalf("Expected the first request to pass, got %d", w.Code)
+	}
+	w := serve(h, asClient("payroll"))
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("Expected status 429, got %d", w.Code)
+	}
+	if got := w.Header().Get("Retry-After"); got != "2" {
+		t.Errorf("Expected Retry-After 2, got %q", got)
+	}
+	if w := serve(h, asClient("leave")); w.Code != http.StatusOK {
+		t.Errorf("Expected another client to pass, got %d", w.Code)
+	}
+	// Requests that name no caller are not throttled
+	for i := 0; i < 3; i++ {
+		if w := serve(h, httptest.NewRequest(http.MethodGet, "/", nil)); w.Code != http.StatusOK {
+			t.Errorf("Expected a request without a caller to pass, got %d", w.Code)
+		}
+	}
+
+	user := SetUserInfo(httptest.NewRequest(http.MethodGet, "/api/v1/micro-apps", nil), &CustomJwtPayload{Email: "alice@example.com"})
+	if got := UserRateLimitKey(user); got != "user:alice@example.com" {
+		t.Errorf("Expected the user key to be the email, got %q", got)
+	}
+
+	failing := RateLimitMiddleware(failingRateLimiter{}, ServiceRateLimitKey)(ok)
+	if w := serve(failing, asClient("payroll")); w.Code != http.StatusOK {
+		t.Errorf("Expected requests to pass when the limiter fails, got %d", w.Code)
+	}
+}
@@ -19,3 +19,8 @@ const (
 	GroupAdmin = "admin"
 	GroupUser  = "user"
 )
+
+// Scopes required of service callers
+const (
+	ScopeNotificationsSend = "notifications:send"
+)
@@ -0,0 +1,153 @@
+// Copyright (c) 2025 WSO2 LLC. (https://www.wso2.com).
+//
+// WSO2 LLC. licenses this file to you under the Apache License,
+// Version 2.0 (the "License"); you may not use this file except
+// in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+package rbac
+
+import (
+	"log/slog"
+	"slices"
+	"sort"
+	"sync/atomic"
+
+	"gorm.io/gorm"
+
+	"github.com/opensuperapp/opensuperapp/backend-services/core/internal/models"
+)
+
+// MaxHierarchyDepth is how many levels of nested groups are expanded below a user's own groups.
+// Deeper groups are ignored with a warning, which bounds the work per request.
+const MaxHierarchyDepth = 10
+
+// GroupHierarchy maps a group to the groups its members implicitly belong to.
+// Implications are transitive: superadmin -> admin -> user gives superadmin members all three.
+type GroupHierarchy map[string][]string
+
+// roleHierarchy is the hierarchy HasRole and RequireRole expand through, already normalized
+var roleHierarchy atomic.Pointer[GroupHierarchy]
+
+// SetHierarchy sets the hierarchy used by HasRole and RequireRole, typically once at startup.
+// A nil or empty hierarchy makes them behave like HasAnyGroup and RequireGroups.
+func SetHierarchy(h GroupHierarchy) {
+	normalized := normalizeHierarchy(h)
+	roleHierarchy.Store(&normalized)
+}
+
+// currentHierarchy returns the hierarchy set by SetHierarchy, or nil
+func currentHierarchy() GroupHierarchy {
+	if h := roleHierarchy.Load(); h != nil {
+		return *h
+	}
+	return nil
+}
+
+// HasRole checks if user belongs to any of the roles, directly or through a group that
+// implies it under the hierarchy set by SetHierarchy.
+func HasRole(userGroups []string, roles ...string) bool {
+	return HasAnyGroupSet(NewHierarchicalGroupSet(userGroups, currentHierarchy()), roles...)
+}
+
+// ExpandGroups returns the groups and every group they imply, normalized and sorted
+func ExpandGroups(groups []string, hierarchy GroupHierarchy) []string {
+	set := NewHierarchicalGroupSet(groups, hierarchy)
+	expanded := make([]string, 0, len(set))
+	for g := range set {
+		expanded = append(expanded, g)
+	}
+	sort.Strings(expanded)
+	return expanded
+}
+
+// NewHierarchicalGroupSet builds a normalized GroupSet of the groups and every group they imply.
+// Groups are expanded depth first in sorted order, so the same input always takes the same
+// paths; a cycle is reported and broken, and a group reachable by several paths (a diamond)
+// is expanded once.
+func NewHierarchicalGroupSet(groups []string, hierarchy GroupHierarchy) GroupSet {
+	set := makeGroupSet(groups)
+	if len(hierarchy) == 0 {
+		return set
+	}
+	normalized := normalizeHierarchy(hierarchy)
+
+	roots := make([]string, 0, len(set))
+	for g := range set {
+		roots = append(roots, g)
+	}
+	sort.Strings(roots)
+	onPath := make(map[string]bool)
+	expandedAt := make(map[string]int)
+	for _, g := range roots {
+		expandGroup(g, normalized, set, onPath, expandedAt, 0)
+	}
+	return set
+}
+
+// expandGroup adds the groups implied by g, found depth levels below a user's own group, to set.
+// expandedAt records the shallowest depth each group was expanded from, so a group is expanded
+// again only when a shorter path leaves room for more of its nested groups.
+func expandGroup(g string, hierarchy GroupHierarchy, set GroupSet, onPath map[string]bool, expandedAt map[string]int, depth int) {
+	if at, ok := expandedAt[g]; ok && at <= depth {
+		return
+	}
+	if depth >= MaxHierarchyDepth {
+		slog.Warn("rbac: group hierarchy too deep, ignoring nested groups", "group", g, "maxDepth", MaxHierarchyDepth)
+		return
+	}
+	onPath[g] = true
+	for _, child := range hierarchy[g] {
+		if onPath[child] {
+			slog.Warn("rbac: cycle in group hierarchy", "group", g, "child", child)
+			continue
+		}
+		set[child] = struct{}{}
+		expandGroup(child, hierarchy, set, onPath, expandedAt, depth+1)
+	}
+	onPath[g] = false
+	expandedAt[g] = depth
+}
+
+// normalizeHierarchy normalizes group names the same way as GroupSet, dropping empty ones.
+// Each group's children are sorted and deduplicated.
+func normalizeHierarchy(hierarchy GroupHierarchy) GroupHierarchy {
+	normalized := make(GroupHierarchy, len(hierarchy))
+	for parent, children := range hierarchy {
+		p := normalizeGroup(parent)
+		if p == "" {
+			continue
+		}
+		for _, child := range children {
+			if c := normalizeGroup(child); c != "" {
+				normalized[p] = append(normalized[p], c)
+			}
+		}
+	}
+	for p, children := range normalized {
+		sort.Strings(children)
+		normalized[p] = slices.Compact(children)
+	}
+	return normalized
+}
+
+// LoadHierarchyFromDB reads the group hierarchy from the group_hierarchy table
+func LoadHierarchyFromDB(db *gorm.DB) (GroupHierarchy, error) {
+	var rows []models.GroupHierarchy
+	if err := db.Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	hierarchy := make(GroupHierarchy)
+	for _, row := range rows {
+		hierarchy[row.ParentGroup] = append(hierarchy[row.ParentGroup], row.ChildGroup)
+	}
+	return hierarchy, nil
+}
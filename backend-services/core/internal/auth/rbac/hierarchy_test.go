@@ -0,0 +1,206 @@
+// Copyright (c) 2025 WSO2 LLC. (https://www.wso2.com).
+//
+// WSO2 LLC. licenses this file to you under the Apache License,
+// Version 2.0 (the "License"); you may not use this file except
+// in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+package rbac
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+
+	"github.com/opensuperapp/opensuperapp/backend-services/core/internal/auth"
+	"github.com/opensuperapp/opensuperapp/backend-services/core/internal/models"
+)
+
+// assertGroupSet fails unless set holds exactly the groups
+func assertGroupSet(t *testing.T, set GroupSet, groups ...string) {
+	t.Helper()
+	if len(set) != len(groups) {
+		t.Errorf("Expected groups %v, got %v", groups, set)
+		return
+	}
+	for _, g := range groups {
+		if _, ok := set[g]; !ok {
+			t.Errorf("Expected group %q in %v", g, set)
+		}
+	}
+}
+
+func TestNewHierarchicalGroupSet(t *testing.T) {
+	hierarchy := GroupHierarchy{
+		"SuperAdmin": {"admin"},
+		"admin":      {"user"},
+	}
+	assertGroupSet(t, NewHierarchicalGroupSet([]string{"superadmin"}, hierarchy), "superadmin", "admin", "user")
+	assertGroupSet(t, NewHierarchicalGroupSet([]string{"admin", "sales"}, hierarchy), "admin", "sales", "user")
+	assertGroupSet(t, NewHierarchicalGroupSet([]string{"user"}, hierarchy), "user")
+	assertGroupSet(t, NewHierarchicalGroupSet([]string{"admin"}, nil), "admin")
+}
+
+func TestNewHierarchicalGroupSet_Diamond(t *testing.T) {
+	// lead implies both engineering and management, which both imply staff
+	hierarchy := GroupHierarchy{
+		"lead":        {"engineering", "management"},
+		"engineering": {"staff"},
+		"management":  {"staff"},
+		"staff":       {"user"},
+	}
+	assertGroupSet(t, NewHierarchicalGroupSet([]string{"lead"}, hierarchy), "lead", "engineering", "management", "staff", "user")
+}
+
+func TestNewHierarchicalGroupSet_Cycle(t *testing.T) {
+	hierarchy := GroupHierarchy{
+		"a":    {"b"},
+		"b":    {"c"},
+		"c":    {"a", "d"},
+		"self": {"self"},
+	}
+	assertGroupSet(t, NewHierarchicalGroupSet([]string{"b"}, hierarchy), "a", "b", "c", "d")
+	assertGroupSet(t, NewHierarchicalGroupSet([]string{"self"}, hierarchy), "self")
+}
+
+func TestNewHierarchicalGroupSet_DepthLimit(t *testing.T) {
+	// level-0 -> level-1 -> ... -> level-15
+	hierarchy := GroupHierarchy{}
+	for i := 0; i < 15; i++ {
+		hierarchy[fmt.Sprintf("level-%d", i)] = []string{fmt.Sprintf("level-%d", i+1)}
+	}
+
+	set := NewHierarchicalGroupSet([]string{"level-0"}, hierarchy)
+	if len(set) != MaxHierarchyDepth+1 {
+		t.Errorf("Expected %d groups, got %d: %v", MaxHierarchyDepth+1, len(set), set)
+	}
+	if _, ok := set[fmt.Sprintf("level-%d", MaxHierarchyDepth)]; !ok {
+		t.Errorf("Expected level-%d to be expanded", MaxHierarchyDepth)
+	}
+	if _, ok := set[fmt.Sprintf("level-%d", MaxHierarchyDepth+1)]; ok {
+		t.Errorf("Expected level-%d to be beyond the depth limit", MaxHierarchyDepth+1)
+	}
+
+	// A group first reached near the limit is expanded further when also reached by a shorter path
+	hierarchy["level-0"] = append(hierarchy["level-0"], "level-9")
+	set = NewHierarchicalGroupSet([]string{"level-0"}, hierarchy)
+	if len(set) != 16 {
+		t.Errorf("Expected all 16 groups through the shortcut, got %d: %v", len(set), set)
+	}
+}
+
+func TestHasRole(t *testing.T) {
+	SetHierarchy(GroupHierarchy{
+		"Admin":  {"editor"},
+		"editor": {"viewer", "viewer"},
+		"viewer": {"admin"}, // a cycle back to the top must not loop
+	})
+	t.Cleanup(func() { SetHierarchy(nil) })
+
+	tests := []struct {
+		name   string
+		groups []string
+		role   string
+		want   bool
+	}{
+		{"two levels down", []string{"admin"}, "viewer", true},
+		{"one level down", []string{"admin"}, "editor", true},
+		{"direct role", []string{"viewer"}, "viewer", true},
+		{"unrelated group", []string{"sales"}, "viewer", false},
+		{"no groups", nil, "viewer", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := HasRole(tt.groups, tt.role); got != tt.want {
+				t.Errorf("HasRole(%v, %q) = %v, want %v", tt.groups, tt.role, got, tt.want)
+			}
+		})
+	}
+
+	// HasAnyGroup stays an exact match
+	if HasAnyGroup([]string{"admin"}, "viewer") {
+		t.Error("Expected HasAnyGroup not to expand the hierarchy")
+	}
+}
+
+func TestExpandGroups(t *testing.T) {
+	hierarchy := GroupHierarchy{
+		"admin":  {"viewer", "editor"},
+		"editor": {"viewer"},
+		"viewer": {"admin"},
+	}
+	want := []string{"admin", "editor", "sales", "viewer"}
+	for i := 0; i < 5; i++ {
+		got := ExpandGroups([]string{"Sales", "admin"}, hierarchy)
+		if fmt.Sprint(got) != fmt.Sprint(want) {
+			t.Fatalf("Expected %v, got %v", want, got)
+		}
+	}
+}
+
+func TestRequireRole(t *testing.T) {
+	SetHierarchy(GroupHierarchy{"admin": {"editor"}, "editor": {"viewer"}})
+	t.Cleanup(func() { SetHierarchy(nil) })
+	handler := RequireRole("viewer")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	tests := []struct {
+		name       string
+		user       *auth.CustomJwtPayload
+		wantStatus int
+	}{
+		{"no user", nil, http.StatusUnauthorized},
+		{"implied role", &auth.CustomJwtPayload{Email: "a@example.com", Groups: []string{"admin"}}, http.StatusOK},
+		{"unrelated group", &auth.CustomJwtPayload{Email: "a@example.com", Groups: []string{"sales"}}, http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.user != nil {
+				req = auth.SetUserInfo(req, tt.user)
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+			if w.Code != tt.wantStatus {
+				t.Errorf("Expected status %d, got %d", tt.wantStatus, w.Code)
+			}
+			if tt.user != nil && len(tt.user.Groups) != 1 {
+				t.Errorf("Expected the token's groups to be left unchanged, got %v", tt.user.Groups)
+			}
+		})
+	}
+}
+
+func TestLoadHierarchyFromDB(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("Failed to open test database: %v", err)
+	}
+	if err := db.AutoMigrate(&models.GroupHierarchy{}); err != nil {
+		t.Fatalf("Failed to migrate database: %v", err)
+	}
+	db.Create(&[]models.GroupHierarchy{
+		{ParentGroup: "superadmin", ChildGroup: "admin"},
+		{ParentGroup: "admin", ChildGroup: "user"},
+		{ParentGroup: "admin", ChildGroup: "reports"},
+	})
+
+	hierarchy, err := LoadHierarchyFromDB(db)
+	if err != nil {
+		t.Fatalf("LoadHierarchyFromDB failed: %v", err)
+	}
+	assertGroupSet(t, NewHierarchicalGroupSet([]string{"superadmin"}, hierarchy), "superadmin", "admin", "user", "reports")
+}
@@ -20,7 +20,11 @@ import (
 	"log/slog"
 	"net/http"
 
+	"github.com/go-chi/chi/v5"
+	"gorm.io/gorm"
+
 	"github.com/opensuperapp/opensuperapp/backend-services/core/internal/auth"
+	"github.com/opensuperapp/opensuperapp/backend-services/core/internal/models"
 )
 
 type errorResponse struct {
@@ -33,14 +37,14 @@ func RequireGroups(groups ...string) func(http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			user, ok := auth.GetUserInfo(r.Context())
 			if !ok {
-				slog.Warn("rbac: no user in context", "path", r.URL.Path)
+				slog.WarnContext(r.Context(), "rbac: no user in context", "path", r.URL.Path)
 				writeJSON(w, http.StatusUnauthorized, errorResponse{Error: "unauthorized"})
 				return
 			}
 
 			userSet := makeGroupSet(user.Groups)
 			if !HasAnyGroupSet(userSet, groups...) {
-				slog.Warn("rbac: access denied",
+				slog.WarnContext(r.Context(), "rbac: access denied",
 					"user", user.Email,
 					"userGroups", user.Groups,
 					"requiredGroups", groups,
@@ -55,6 +59,133 @@ func RequireGroups(groups ...string) func(http.Handler) http.Handler {
 	}
 }
 
+// RequireRole is middleware that checks if user holds any of the roles, directly or through
+// a group that implies it under the hierarchy set by SetHierarchy.
+func RequireRole(roles ...string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			user, ok := auth.GetUserInfo(r.Context())
+			if !ok {
+				slog.WarnContext(r.Context(), "rbac: no user in context", "path", r.URL.Path)
+				writeJSON(w, http.StatusUnauthorized, errorResponse{Error: "unauthorized"})
+				return
+			}
+
+			if !HasRole(user.Groups, roles...) {
+				slog.WarnContext(r.Context(), "rbac: access denied",
+					"user", user.Email,
+					"userGroups", user.Groups,
+					"requiredRoles", roles,
+					"path", r.URL.Path,
+				)
+				writeJSON(w, http.StatusForbidden, errorResponse{Error: "forbidden"})
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// RequireAllGroups is middleware that checks if user belongs to every one of the groups.
+func RequireAllGroups(groups ...string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			user, ok := auth.GetUserInfo(r.Context())
+			if !ok {
+				slog.WarnContext(r.Context(), "rbac: no user in context", "path", r.URL.Path)
+				writeJSON(w, http.StatusUnauthorized, errorResponse{Error: "unauthorized"})
+				return
+			}
+
+			userSet := makeGroupSet(user.Groups)
+			if !HasAllGroupSet(userSet, groups...) {
+				slog.WarnContext(r.Context(), "rbac: access denied",
+					"user", user.Email,
+					"userGroups", user.Groups,
+					"requiredAllGroups", groups,
+					"path", r.URL.Path,
+				)
+				writeJSON(w, http.StatusForbidden, errorResponse{Error: "forbidden"})
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// MicroAppAdminMiddleware is middleware that checks if user administers the micro app in the appID URL parameter.
+// Holders of the global admin role, directly or through the hierarchy, administer every micro app; other users
+// need a micro_app_admin grant for it.
+func MicroAppAdminMiddleware(db *gorm.DB) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			user, ok := auth.GetUserInfo(r.Context())
+			if !ok {
+				slog.WarnContext(r.Context(), "rbac: no user in context", "path", r.URL.Path)
+				writeJSON(w, http.StatusUnauthorized, errorResponse{Error: "unauthorized"})
+				return
+			}
+			if HasRole(user.Groups, GroupAdmin) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			appID := chi.URLParam(r, "appID")
+			var count int64
+			if appID != "" && user.Email != "" {
+				if err := db.WithContext(r.Context()).Model(&models.MicroAppAdmin{}).
+					Where("micro_app_id = ? AND user_email = ?", appID, user.Email).
+					Count(&count).Error; err != nil {
+					slog.ErrorContext(r.Context(), "rbac: failed to look up micro app admin", "error", err, "appID", appID)
+					writeJSON(w, http.StatusInternalServerError, errorResponse{Error: "internal server error"})
+					return
+				}
+			}
+			if count == 0 {
+				slog.WarnContext(r.Context(), "rbac: access denied",
+					"user", user.Email,
+					"userGroups", user.Groups,
+					"microAppID", appID,
+					"path", r.URL.Path,
+				)
+				writeJSON(w, http.StatusForbidden, errorResponse{Error: "forbidden"})
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// RequireScopes is middleware that checks if the calling service holds every scope.
+func RequireScopes(scopes ...string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			service, ok := auth.GetServiceInfo(r.Context())
+			if !ok {
+				slog.WarnContext(r.Context(), "rbac: no service in context", "path", r.URL.Path)
+				writeJSON(w, http.StatusUnauthorized, errorResponse{Error: "unauthorized"})
+				return
+			}
+
+			if !HasAllScopes(service.Scopes, scopes...) {
+				slog.WarnContext(r.Context(), "rbac: missing scope",
+					"clientID", service.ClientID,
+					"scopes", service.Scopes,
+					"requiredScopes", scopes,
+					"path", r.URL.Path,
+				)
+				writeJSON(w, http.StatusForbidden, errorResponse{Error: "forbidden"})
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
 func writeJSON(w http.ResponseWriter, status int, data any) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(status)
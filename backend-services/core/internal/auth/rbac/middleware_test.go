@@ -0,0 +1,84 @@
+// Copyright (c) 2025 WSO2 LLC. (https://www.wso2.com).
+//
+// WSO2 LLC. licenses this file to you under the Apache License,
+// Version 2.0 (the "License"); you may not use this file except
+// in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+package rbac
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+
+	"github.com/opensuperapp/opensuperapp/backend-services/core/internal/auth"
+	"github.com/opensuperapp/opensuperapp/backend-services/core/internal/models"
+)
+
+// setupMicroAppAdminRouter routes /micro-apps/{appID} behind MicroAppAdminMiddleware,
+// with alice@example.com granted admin of payroll only
+func setupMicroAppAdminRouter(t *testing.T) http.Handler {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("Failed to open test database: %v", err)
+	}
+	if err := db.AutoMigrate(&models.MicroAppAdmin{}); err != nil {
+		t.Fatalf("Failed to migrate database: %v", err)
+	}
+	if err := db.Create(&models.MicroAppAdmin{MicroAppID: "payroll", UserEmail: "alice@example.com", GrantedBy: "admin@example.com"}).Error; err != nil {
+		t.Fatalf("Failed to grant micro app admin: %v", err)
+	}
+
+	r := chi.NewRouter()
+	r.With(MicroAppAdminMiddleware(db)).Delete("/micro-apps/{appID}", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+	return r
+}
+
+func TestMicroAppAdminMiddleware(t *testing.T) {
+	router := setupMicroAppAdminRouter(t)
+	SetHierarchy(GroupHierarchy{"superadmin": {GroupAdmin}})
+	t.Cleanup(func() { SetHierarchy(nil) })
+
+	tests := []struct {
+		name       string
+		appID      string
+		user       *auth.CustomJwtPayload
+		wantStatus int
+	}{
+		{"no user", "payroll", nil, http.StatusUnauthorized},
+		{"granted app", "payroll", &auth.CustomJwtPayload{Email: "alice@example.com", Groups: []string{"user"}}, http.StatusNoContent},
+		{"other app", "leave", &auth.CustomJwtPayload{Email: "alice@example.com", Groups: []string{"user"}}, http.StatusForbidden},
+		{"no grant", "payroll", &auth.CustomJwtPayload{Email: "bob@example.com", Groups: []string{"user"}}, http.StatusForbidden},
+		{"global admin", "leave", &auth.CustomJwtPayload{Email: "root@example.com", Groups: []string{GroupAdmin}}, http.StatusNoContent},
+		{"implied global admin", "leave", &auth.CustomJwtPayload{Email: "root@example.com", Groups: []string{"superadmin"}}, http.StatusNoContent},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodDelete, "/micro-apps/"+tt.appID, nil)
+			if tt.user != nil {
+				req = auth.SetUserInfo(req, tt.user)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			if w.Code != tt.wantStatus {
+				t.Errorf("Expected status %d, got %d", tt.wantStatus, w.Code)
+			}
+		})
+	}
+}
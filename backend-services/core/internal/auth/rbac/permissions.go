@@ -0,0 +1,132 @@
+// Copyright (c) 2025 WSO2 LLC. (https://www.wso2.com).
+//
+// WSO2 LLC. licenses this file to you under the Apache License,
+// Version 2.0 (the "License"); you may not use this file except
+// in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+package rbac
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/opensuperapp/opensuperapp/backend-services/core/internal/auth"
+	"github.com/opensuperapp/opensuperapp/backend-services/core/internal/models"
+)
+
+// PermissionCacheTTL is how long the permissions of a group are served from memory
+// before role_permissions is queried again.
+const PermissionCacheTTL = 60 * time.Second
+
+// permissionCache maps a normalized group name to its cachedPermissions
+var permissionCache sync.Map
+
+// timeNow is replaced in tests to expire cache entries
+var timeNow = time.Now
+
+type cachedPermissions struct {
+	permissions map[string]struct{}
+	expiresAt   time.Time
+}
+
+// ReloadPermissions drops every cached group permission, so the next check reads role_permissions.
+func ReloadPermissions() {
+	permissionCache.Clear()
+}
+
+// RequirePermission is middleware that checks if any of the user's groups is granted the permission
+// in role_permissions. Grants are cached per group for PermissionCacheTTL.
+func RequirePermission(db *gorm.DB, permission string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			user, ok := auth.GetUserInfo(r.Context())
+			if !ok {
+				slog.WarnContext(r.Context(), "rbac: no user in context", "path", r.URL.Path)
+				writeJSON(w, http.StatusUnauthorized, errorResponse{Error: "unauthorized"})
+				return
+			}
+
+			allowed, err := HasPermission(r.Context(), db, user.Groups, permission)
+			if err != nil {
+				slog.ErrorContext(r.Context(), "rbac: failed to load permissions", "error", err, "user", user.Email)
+				writeJSON(w, http.StatusInternalServerError, errorResponse{Error: "internal server error"})
+				return
+			}
+			if !allowed {
+				slog.WarnContext(r.Context(), "rbac: access denied",
+					"user", user.Email,
+					"userGroups", user.Groups,
+					"requiredPermission", permission,
+					"path", r.URL.Path,
+				)
+				writeJSON(w, http.StatusForbidden, errorResponse{Error: "forbidden"})
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// HasPermission checks if any of the groups is granted the permission.
+// Groups whose permissions are not cached, or have expired, are loaded in one query.
+func HasPermission(ctx context.Context, db *gorm.DB, userGroups []string, permission string) (bool, error) {
+	if permission == "" {
+		return false, nil
+	}
+
+	now := timeNow()
+	var missing []string
+	for group := range makeGroupSet(userGroups) {
+		entry, ok := permissionCache.Load(group)
+		if !ok || !now.Before(entry.(cachedPermissions).expiresAt) {
+			missing = append(missing, group)
+			continue
+		}
+		if _, ok := entry.(cachedPermissions).permissions[permission]; ok {
+			return true, nil
+		}
+	}
+	if len(missing) == 0 {
+		return false, nil
+	}
+
+	var grants []models.RolePermission
+	if err := db.WithContext(ctx).Where("group_name IN ?", missing).Find(&grants).Error; err != nil {
+		return false, err
+	}
+	loaded := make(map[string]map[string]struct{}, len(missing))
+	for _, group := range missing {
+		loaded[group] = make(map[string]struct{})
+	}
+	for _, grant := range grants {
+		if permissions, ok := loaded[normalizeGroup(grant.GroupName)]; ok {
+			permissions[grant.PermissionName] = struct{}{}
+		}
+	}
+
+	// Groups without grants are cached too, so they do not query on every request
+	allowed := false
+	expiresAt := now.Add(PermissionCacheTTL)
+	for group, permissions := range loaded {
+		permissionCache.Store(group, cachedPermissions{permissions: permissions, expiresAt: expiresAt})
+		if _, ok := permissions[permission]; ok {
+			allowed = true
+		}
+	}
+	return allowed, nil
+}
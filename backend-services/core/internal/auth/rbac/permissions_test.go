@@ -0,0 +1,138 @@
+// Copyright (c) 2025 WSO2 LLC. (https://www.wso2.com).
+//
+// WSO2 LLC. licenses this file to you under the Apache License,
+// Version 2.0 (the "License"); you may not use this file except
+// in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+package rbac
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+
+	"github.com/opensuperapp/opensuperapp/backend-services/core/internal/auth"
+	"github.com/opensuperapp/opensuperapp/backend-services/core/internal/models"
+)
+
+// setupPermissionDB creates an in-memory database granting notifications:send to the
+// marketing group and starts every test with an empty permission cache
+func setupPermissionDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("Failed to open test database: %v", err)
+	}
+	if err := db.AutoMigrate(&models.Permission{}, &models.RolePermission{}); err != nil {
+		t.Fatalf("Failed to migrate database: %v", err)
+	}
+	db.Create(&models.Permission{PermissionName: "notifications:send", Description: "Send notifications"})
+	db.Create(&models.RolePermission{GroupName: "marketing", PermissionName: "notifications:send"})
+
+	ReloadPermissions()
+	t.Cleanup(func() {
+		ReloadPermissions()
+		timeNow = time.Now
+	})
+	return db
+}
+
+func TestRequirePermission(t *testing.T) {
+	db := setupPermissionDB(t)
+	handler := RequirePermission(db, "notifications:send")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	tests := []struct {
+		name       string
+		user       *auth.CustomJwtPayload
+		wantStatus int
+	}{
+		{"no user", nil, http.StatusUnauthorized},
+		{"granted group", &auth.CustomJwtPayload{Email: "a@example.com", Groups: []string{"staff", "Marketing"}}, http.StatusOK},
+		{"other group", &auth.CustomJwtPayload{Email: "b@example.com", Groups: []string{"sales"}}, http.StatusForbidden},
+		{"no groups", &auth.CustomJwtPayload{Email: "c@example.com"}, http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/notifications/send", nil)
+			if tt.user != nil {
+				req = auth.SetUserInfo(req, tt.user)
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+			if w.Code != tt.wantStatus {
+				t.Errorf("Expected status %d, got %d", tt.wantStatus, w.Code)
+			}
+		})
+	}
+}
+
+// TestHasPermission_Cache tests that grants are cached for the TTL and dropped by ReloadPermissions
+func TestHasPermission_Cache(t *testing.T) {
+	db := setupPermissionDB(t)
+	ctx := context.Background()
+	now := time.Date(2025, 1, 15, 10, 0, 0, 0, time.UTC)
+	timeNow = func() time.Time { return now }
+
+	if ok, err := HasPermission(ctx, db, []string{"marketing"}, "notifications:send"); err != nil || !ok {
+		t.Fatalf("Expected marketing to be granted, got %v (%v)", ok, err)
+	}
+
+	// A revoked grant is still served from the cache until it expires
+	db.Where("group_name = ?", "marketing").Delete(&models.RolePermission{})
+	db.Create(&models.RolePermission{GroupName: "sales", PermissionName: "notifications:send"})
+	if ok, _ := HasPermission(ctx, db, []string{"marketing"}, "notifications:send"); !ok {
+		t.Error("Expected the cached grant before the TTL passes")
+	}
+
+	now = now.Add(PermissionCacheTTL)
+	if ok, _ := HasPermission(ctx, db, []string{"marketing"}, "notifications:send"); ok {
+		t.Error("Expected the revoked grant to be reloaded after the TTL")
+	}
+
+	// A group without grants is cached as denied until ReloadPermissions
+	db.Where("group_name = ?", "sales").Delete(&models.RolePermission{})
+	if ok, _ := HasPermission(ctx, db, []string{"sales"}, "notifications:send"); ok {
+		t.Fatal("Expected sales to be denied")
+	}
+	db.Create(&models.RolePermission{GroupName: "sales", PermissionName: "notifications:send"})
+	if ok, _ := HasPermission(ctx, db, []string{"sales"}, "notifications:send"); ok {
+		t.Error("Expected the cached denial before a reload")
+	}
+	ReloadPermissions()
+	if ok, _ := HasPermission(ctx, db, []string{"sales"}, "notifications:send"); !ok {
+		t.Error("Expected the new grant after ReloadPermissions")
+	}
+}
+
+// TestRequirePermission_DatabaseError tests that a failed lookup is a 500 rather than a denial
+func TestRequirePermission_DatabaseError(t *testing.T) {
+	db := setupPermissionDB(t)
+	sqlDB, _ := db.DB()
+	sqlDB.Close()
+
+	handler := RequirePermission(db, "notifications:send")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	req := auth.SetUserInfo(httptest.NewRequest(http.MethodPost, "/", nil), &auth.CustomJwtPayload{Groups: []string{"marketing"}})
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	if w.Code != http.StatusInternalServerError {
+		t.Errorf("Expected status 500, got %d", w.Code)
+	}
+}
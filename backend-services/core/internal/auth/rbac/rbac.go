@@ -30,6 +30,7 @@ func normalizeGroup(g string) string {
 }
 
 // makeGroupSet builds a normalized GroupSet, skipping empties.
+// The number of groups is already capped by auth.AuthMiddleware.
 func makeGroupSet(groups []string) GroupSet {
 	set := make(GroupSet, len(groups))
 	for _, g := range groups {
@@ -64,3 +65,23 @@ func HasAnyGroupSet(userSet GroupSet, groups ...string) bool {
 	}
 	return false
 }
+
+// HasAllGroups checks if user belongs to every one of the groups.
+func HasAllGroups(userGroups []string, groups ...string) bool {
+	set := makeGroupSet(userGroups)
+	return HasAllGroupSet(set, groups...)
+}
+
+// HasAllGroupSet checks membership of every group using an existing set.
+// It fails closed: an empty list of groups, or a blank group, is never satisfied.
+func HasAllGroupSet(userSet GroupSet, groups ...string) bool {
+	if len(groups) == 0 {
+		return false
+	}
+	for _, g := range groups {
+		if _, ok := userSet[normalizeGroup(g)]; !ok {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,107 @@
+// Copyright (c) 2025 WSO2 LLC. (https://www.wso2.com).
+//
+// WSO2 LLC. licenses this file to you under the Apache License,
+// Version 2.0 (the "License"); you may not use this file except
+// in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+package rbac
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/opensuperapp/opensuperapp/backend-services/core/internal/auth"
+)
+
+func TestHasAllGroups(t *testing.T) {
+	tests := []struct {
+		name       string
+		userGroups []string
+		groups     []string
+		want       bool
+	}{
+		{"exact match", []string{"admin", "billing"}, []string{"admin", "billing"}, true},
+		{"superset", []string{"admin", "billing", "staff"}, []string{"admin", "billing"}, true},
+		{"subset", []string{"admin"}, []string{"admin", "billing"}, false},
+		{"normalized", []string{" Admin ", "BILLING"}, []string{"admin", " billing"}, true},
+		{"no required groups", []string{"admin"}, nil, false},
+		{"empty required group", []string{"admin"}, []string{"admin", " "}, false},
+		{"no user groups", nil, []string{"admin"}, false},
+		{"both empty", []string{}, []string{}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := HasAllGroups(tt.userGroups, tt.groups...); got != tt.want {
+				t.Errorf("HasAllGroups(%v, %v) = %v, want %v", tt.userGroups, tt.groups, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHasAnyGroup_EmptyGroups(t *testing.T) {
+	if HasAnyGroup([]string{"admin"}) {
+		t.Error("Expected no required groups to deny access")
+	}
+	if HasAnyGroup(nil, "admin") || HasAnyGroup([]string{}, "admin") {
+		t.Error("Expected a user without groups to be denied")
+	}
+	if HasAnyGroup([]string{"", "  "}, "") {
+		t.Error("Expected blank groups never to match")
+	}
+}
+
+func TestRequireAllGroups(t *testing.T) {
+	handler := RequireAllGroups(GroupAdmin, "billing")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	tests := []struct {
+		name       string
+		user       *auth.CustomJwtPayload
+		wantStatus int
+	}{
+		{"no user", nil, http.StatusUnauthorized},
+		{"exact match", &auth.CustomJwtPayload{Email: "a@example.com", Groups: []string{"admin", "billing"}}, http.StatusOK},
+		{"superset", &auth.CustomJwtPayload{Email: "a@example.com", Groups: []string{"staff", "billing", "admin"}}, http.StatusOK},
+		{"subset", &auth.CustomJwtPayload{Email: "a@example.com", Groups: []string{"admin"}}, http.StatusForbidden},
+		{"no groups", &auth.CustomJwtPayload{Email: "a@example.com"}, http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodDelete, "/micro-apps/payroll", nil)
+			if tt.user != nil {
+				req = auth.SetUserInfo(req, tt.user)
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+			if w.Code != tt.wantStatus {
+				t.Fatalf("Expected status %d, got %d", tt.wantStatus, w.Code)
+			}
+			if tt.wantStatus == http.StatusForbidden {
+				var body errorResponse
+				if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil || body.Error != "forbidden" {
+					t.Errorf(`Expected {"error":"forbidden"}, got %s`, w.Body.String())
+				}
+			}
+		})
+	}
+
+	// An empty group list fails closed rather than admitting every user
+	w := httptest.NewRecorder()
+	req := auth.SetUserInfo(httptest.NewRequest(http.MethodGet, "/", nil), &auth.CustomJwtPayload{Groups: []string{"admin"}})
+	RequireAllGroups()(handler).ServeHTTP(w, req)
+	if w.Code != http.StatusForbidden {
+		t.Errorf("Expected status 403 when no groups are required, got %d", w.Code)
+	}
+}
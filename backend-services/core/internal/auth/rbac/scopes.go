@@ -0,0 +1,78 @@
+// Copyright (c) 2025 WSO2 LLC. (https://www.wso2.com).
+//
+// WSO2 LLC. licenses this file to you under the Apache License,
+// Version 2.0 (the "License"); you may not use this file except
+// in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+package rbac
+
+import (
+	"regexp"
+	"strings"
+)
+
+// ScopeWildcard is the action that grants every action on a resource, as in "notifications:*".
+const ScopeWildcard = "*"
+
+// scopeNamePattern matches a resource or action name, and a legacy flat scope.
+// It mirrors the validation the token service applies when issuing scopes.
+var scopeNamePattern = regexp.MustCompile(`^[a-z][a-z0-9_.-]*$`)
+
+// Scope is a structured resource:action scope.
+type Scope struct {
+	Resource string
+	Action   string
+}
+
+// ParseScope parses a structured scope such as "notifications:send" or "notifications:*".
+func ParseScope(scope string) (Scope, bool) {
+	resource, action, ok := strings.Cut(scope, ":")
+	if !ok || !scopeNamePattern.MatchString(resource) ||
+		(action != ScopeWildcard && !scopeNamePattern.MatchString(action)) {
+		return Scope{}, false
+	}
+	return Scope{Resource: resource, Action: action}, true
+}
+
+// ScopeCovers checks if a granted scope permits a required one.
+// "resource:*" covers every action on the resource; legacy flat scopes only match themselves.
+func ScopeCovers(granted, required string) bool {
+	if granted == required {
+		return true
+	}
+	g, ok := ParseScope(granted)
+	if !ok {
+		return false
+	}
+	req, ok := ParseScope(required)
+	if !ok {
+		return false
+	}
+	return g.Resource == req.Resource && (g.Action == ScopeWildcard || g.Action == req.Action)
+}
+
+// HasAllScopes checks if the granted scopes cover every required scope.
+func HasAllScopes(granted []string, required ...string) bool {
+	for _, req := range required {
+		covered := false
+		for _, g := range granted {
+			if ScopeCovers(g, req) {
+				covered = true
+				break
+			}
+		}
+		if !covered {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,91 @@
+// Copyright (c) 2025 WSO2 LLC. (https://www.wso2.com).
+//
+// WSO2 LLC. licenses this file to you under the Apache License,
+// Version 2.0 (the "License"); you may not use this file except
+// in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+package rbac
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/opensuperapp/opensuperapp/backend-services/core/internal/auth"
+)
+
+func TestParseScope(t *testing.T) {
+	got, ok := ParseScope("notifications:*")
+	if !ok || got != (Scope{Resource: "notifications", Action: ScopeWildcard}) {
+		t.Errorf("ParseScope(notifications:*) = %+v, %v", got, ok)
+	}
+
+	for _, scope := range []string{"", "read", ":send", "notifications:", "a:b:c", "Notifications:send", "*:send"} {
+		if _, ok := ParseScope(scope); ok {
+			t.Errorf("ParseScope(%q): expected malformed", scope)
+		}
+	}
+}
+
+func TestHasAllScopes(t *testing.T) {
+	tests := []struct {
+		name     string
+		granted  []string
+		required []string
+		want     bool
+	}{
+		{"exact", []string{"notifications:send"}, []string{"notifications:send"}, true},
+		{"wildcard", []string{"notifications:*"}, []string{"notifications:send", "notifications:read"}, true},
+		{"other resource", []string{"notifications:*"}, []string{"users:read"}, false},
+		{"missing one", []string{"notifications:send"}, []string{"notifications:send", "users:read"}, false},
+		{"legacy exact", []string{"read"}, []string{"read"}, true},
+		{"malformed granted", []string{"notifications:"}, []string{"notifications:send"}, false},
+		{"none required", nil, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := HasAllScopes(tt.granted, tt.required...); got != tt.want {
+				t.Errorf("HasAllScopes(%v, %v) = %v, want %v", tt.granted, tt.required, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRequireScopes(t *testing.T) {
+	handler := RequireScopes("notifications:send")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	tests := []struct {
+		name       string
+		service    *auth.ServiceInfo
+		wantStatus int
+	}{
+		{"no service", nil, http.StatusUnauthorized},
+		{"missing scope", &auth.ServiceInfo{ClientID: "app", Scopes: []string{"users:read"}}, http.StatusForbidden},
+		{"exact scope", &auth.ServiceInfo{ClientID: "app", Scopes: []string{"notifications:send"}}, http.StatusOK},
+		{"wildcard scope", &auth.ServiceInfo{ClientID: "app", Scopes: []string{"notifications:*"}}, http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/notifications/send", nil)
+			if tt.service != nil {
+				req = auth.SetServiceInfo(req, tt.service)
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+			if w.Code != tt.wantStatus {
+				t.Errorf("Expected status %d, got %d", tt.wantStatus, w.Code)
+			}
+		})
+	}
+}
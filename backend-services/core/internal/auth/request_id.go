@@ -0,0 +1,100 @@
+// Copyright (c) 2025 WSO2 LLC. (https://www.wso2.com).
+//
+// WSO2 LLC. licenses this file to you under the Apache License,
+// Version 2.0 (the "License"); you may not use this file except
+// in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+package auth
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/google/uuid"
+)
+
+const (
+	// HeaderRequestID carries the request ID between clients, core and the token service
+	HeaderRequestID = "X-Request-ID"
+
+	// maxRequestIDLength bounds a caller-supplied request ID; longer IDs are replaced
+	maxRequestIDLength = 128
+
+	requestIDKey = contextKey("requestID")
+)
+
+// RequestIDMiddleware tags each request with an ID used to correlate its logs across services.
+// A valid X-Request-ID header from the caller is kept, otherwise a UUID v4 is generated.
+// The ID is stored in the request context for RequestID and echoed in the response header.
+func RequestIDMiddleware() func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requestID := r.Header.Get(HeaderRequestID)
+			if !validRequestID(requestID) {
+				requestID = uuid.NewString()
+			}
+			w.Header().Set(HeaderRequestID, requestID)
+			ctx := context.WithValue(r.Context(), requestIDKey, requestID)
+			// Also set chi's key so the access log line carries the same ID
+			ctx = context.WithValue(ctx, middleware.RequestIDKey, requestID)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// RequestID returns the request ID stored by RequestIDMiddleware, or "" outside a request.
+func RequestID(ctx context.Context) string {
+	requestID, _ := ctx.Value(requestIDKey).(string)
+	return requestID
+}
+
+// validRequestID accepts non-empty IDs of printable ASCII without spaces, so a caller
+// cannot inject log fields or headers through the ID
+func validRequestID(requestID string) bool {
+	if requestID == "" || len(requestID) > maxRequestIDLength {
+		return false
+	}
+	for i := 0; i < len(requestID); i++ {
+		if c := requestID[i]; c <= ' ' || c > '~' {
+			return false
+		}
+	}
+	return true
+}
+
+// RequestIDLogHandler adds a request_id attribute to records logged with a request context
+// (slog.InfoContext(r.Context(), ...) and friends).
+type RequestIDLogHandler struct {
+	slog.Handler
+}
+
+// NewRequestIDLogHandler wraps next so that records logged with a request context carry its request ID.
+func NewRequestIDLogHandler(next slog.Handler) *RequestIDLogHandler {
+	return &RequestIDLogHandler{Handler: next}
+}
+
+func (h *RequestIDLogHandler) Handle(ctx context.Context, record slog.Record) error {
+	if requestID := RequestID(ctx); requestID != "" {
+		record.AddAttrs(slog.String("request_id", requestID))
+	}
+	return h.Handler.Handle(ctx, record)
+}
+
+func (h *RequestIDLogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &RequestIDLogHandler{Handler: h.Handler.WithAttrs(attrs)}
+}
+
+func (h *RequestIDLogHandler) WithGroup(name string) slog.Handler {
+	return &RequestIDLogHandler{Handler: h.Handler.WithGroup(name)}
+}
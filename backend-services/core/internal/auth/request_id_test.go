@@ -0,0 +1,111 @@
+// Copyright (c) 2025 WSO2 LLC. (https://www.wso2.com).
+//
+// WSO2 LLC. licenses this file to you under the Apache License,
+// Version 2.0 (the "License"); you may not use this file except
+// in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+package auth
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+// serveWithRequestID runs a request through RequestIDMiddleware and returns the response and the ID seen by the handler
+func serveWithRequestID(t *testing.T, incoming string) (*httptest.ResponseRecorder, string) {
+	t.Helper()
+	var seen string
+	handler := RequestIDMiddleware()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = RequestID(r.Context())
+	}))
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	if incoming != "" {
+		r.Header.Set(HeaderRequestID, incoming)
+	}
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	return w, seen
+}
+
+func TestRequestIDMiddleware(t *testing.T) {
+	t.Run("generates a UUID when absent", func(t *testing.T) {
+		w, seen := serveWithRequestID(t, "")
+		parsed, err := uuid.Parse(seen)
+		if err != nil || parsed.Version() != 4 {
+			t.Fatalf("Expected a UUID v4 request ID, got %q", seen)
+		}
+		if got := w.Header().Get(HeaderRequestID); got != seen {
+			t.Errorf("Expected response header %q, got %q", seen, got)
+		}
+	})
+
+	t.Run("keeps the caller's ID", func(t *testing.T) {
+		w, seen := serveWithRequestID(t, "trace-abc-123")
+		if seen != "trace-abc-123" {
+			t.Errorf("Expected request ID trace-abc-123 in context, got %q", seen)
+		}
+		if got := w.Header().Get(HeaderRequestID); got != "trace-abc-123" {
+			t.Errorf("Expected response header trace-abc-123, got %q", got)
+		}
+	})
+
+	for name, incoming := range map[string]string{
+		"replaces an ID with spaces": "id with spaces",
+		"replaces an oversized ID":   strings.Repeat("a", maxRequestIDLength+1),
+		"replaces non-ASCII IDs":     "id-é",
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, seen := serveWithRequestID(t, incoming)
+			if seen == incoming {
+				t.Fatalf("Expected %q to be replaced", incoming)
+			}
+			if _, err := uuid.Parse(seen); err != nil {
+				t.Errorf("Expected a generated UUID, got %q", seen)
+			}
+		})
+	}
+
+	if got := RequestID(context.Background()); got != "" {
+		t.Errorf("Expected no request ID outside a request, got %q", got)
+	}
+}
+
+func TestRequestIDLogHandler(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(NewRequestIDLogHandler(slog.NewTextHandler(&buf, nil))).With("component", "test")
+
+	handler := RequestIDMiddleware()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		logger.InfoContext(r.Context(), "handled")
+	}))
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set(HeaderRequestID, "req-42")
+	handler.ServeHTTP(httptest.NewRecorder(), r)
+	logger.Info("background")
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("Expected 2 log lines, got %d: %s", len(lines), buf.String())
+	}
+	if !strings.Contains(lines[0], "request_id=req-42") || !strings.Contains(lines[0], "component=test") {
+		t.Errorf("Expected the request ID on records logged with the request context, got %s", lines[0])
+	}
+	if strings.Contains(lines[1], "request_id") {
+		t.Errorf("Expected no request ID without a request context, got %s", lines[1])
+	}
+}
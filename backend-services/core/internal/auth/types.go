@@ -21,5 +21,6 @@ type CustomJwtPayload struct {
 }
 
 type ServiceInfo struct {
-	ClientID string `json:"client_id"` // This is the microapp ID
+	ClientID string   `json:"client_id"` // This is the microapp ID
+	Scopes   []string `json:"scopes,omitempty"`
 }
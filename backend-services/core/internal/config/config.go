@@ -19,6 +19,7 @@ import (
 	"fmt"
 	"log/slog"
 	"os"
+	"strconv"
 	"strings"
 
 	"github.com/joho/godotenv"
@@ -41,8 +42,26 @@ type Config struct {
 	DBConnMaxIdleTime int // in minutes
 	DBConnectRetries  int
 	ServerPort        string
+	DrainTimeoutSec   int // How long in-flight requests may run after SIGTERM or SIGINT
 
 	FirebaseCredentialsPath string
+	FCMDryRun               bool // Validate notifications with FCM without delivering them
+
+	// FCM circuit breaker: consecutive failed multicast calls that open it (0 disables it) and
+	// how long it stays open before a probe is let through
+	FCMCircuitFailureThreshold int
+	FCMCircuitResetTimeoutSec  int
+
+	// FCM retry and limit settings
+	FCMMaxRetries          int    // Send attempts per token, including the first
+	FCMInitialRetryDelayMs int    // Delay before the first retry; doubles on each later retry
+	FCMMaxRetryDelayMs     int    // Cap on the delay between retries
+	FCMMaxTokensPerSend    int    // Tokens beyond this many in a single send are dropped
+	FCMRetryJitter         string // Retry delay randomization: none, full or equal
+
+	// Notification provider per device platform ("fcm" or "apns"); provider settings use the provider name as prefix, e.g. APNS_
+	NotificationProviderIOS     string
+	NotificationProviderAndroid string
 
 	// External IDP (Asgardeo) - for user authentication
 	ExternalIdPJWKSURL  string
@@ -54,6 +73,36 @@ type Config struct {
 	InternalIdPIssuer   string
 	InternalIdPAudience string
 
+	// Decode numeric custom token claims as json.Number to preserve 64-bit integers
+	JWTClaimsUseJSONNumber bool
+
+	// Tolerated difference between this server's clock and token issuers' when checking exp, nbf and iat
+	TokenClockSkewSeconds int
+
+	// How long JWKS keys fetched from the IdPs are cached before they are fetched again
+	JWKSCacheTTLSec int
+
+	// Enables admin-only diagnostic endpoints (never enable in production)
+	DebugEndpointsEnabled bool
+
+	// Serves Prometheus metrics at /metrics without authentication; restrict it at the ingress
+	MetricsEnabled bool
+
+	// OTLP gRPC collector that traces are exported to; tracing is off when empty
+	OTelExporterEndpoint string
+	OTelServiceName      string
+
+	// Micro app config upserts that overwrite a change another user made within this many
+	// seconds are recorded in the conflict log; 0 disables the log
+	ConfigConflictWindowSec int
+
+	// Proxies and load balancers (CIDRs or IPs) whose X-Forwarded-For and X-Real-IP headers are
+	// trusted when resolving the client IP; when empty the client IP is always RemoteAddr
+	TrustedProxyCIDRs []string
+
+	// Groups of a user token beyond this many are ignored in authorization checks; 0 disables the cap
+	MaxUserGroups int
+
 	// File Service
 	FileServiceType string
 
@@ -63,6 +112,52 @@ type Config struct {
 	// File Upload
 	UploadFileMaxSizeMB int // Maximum file upload size in megabytes
 
+	// Scheduled Notifications
+	ScheduledNotificationPollIntervalSec int // How often the worker polls for due notifications
+	ScheduledNotificationBatchSize       int // Maximum rows claimed per poll
+	ScheduledNotificationLeaseSec        int // How long a claim holds before another instance may reclaim the row
+
+	// Stale Device Token Cleanup
+	DeviceTokenStaleDays          int // Tokens not registered for this many days are deactivated; 0 disables the cleanup
+	DeviceTokenCleanupIntervalSec int // How often the cleanup runs
+
+	// Microapp API Keys
+	APIKeyRateLimitPerSec int // Sustained requests per second allowed for each API key
+	APIKeyRateLimitBurst  int // Requests an API key may make in a burst
+
+	// Per-caller rate limits: user routes are limited per user email, service routes per client ID.
+	// A rate of 0 disables the limit.
+	UserRateLimitPerSec    int
+	UserRateLimitBurst     int
+	ServiceRateLimitPerSec int
+	ServiceRateLimitBurst  int
+
+	// Service scopes: notification sends require the notifications:send scope in the caller's
+	// service token or API key. Turning this off is a legacy escape hatch for old clients.
+	ServiceScopesRequired bool
+
+	// User erasure: "delete" or "anonymize" per data category
+	ErasureDeviceTokens            string
+	ErasureConfigs                 string
+	ErasureNotificationPreferences string
+	ErasureNotificationLogs        string
+
+	// Notification Quotas
+	NotificationQuotaPeriodSec    int // Length of a quota period
+	NotificationQuotaDefaultLimit int // Sends per period for microapps without a notificationQuota config; 0 is unlimited
+
+	// Notification Receipts
+	NotificationReceiptKeyPath string // Ed25519 PKCS#8 PEM key used to sign receipts; receipts are disabled when empty
+
+	// Notification Sender Identity (used when the sending microapp has no name or icon of its own)
+	NotificationDefaultSenderName    string
+	NotificationDefaultSenderIconURL string
+
+	// Notification Images: require imageUrl to be an asset uploaded for the sending microapp,
+	// or an https URL on one of the allowed hosts
+	NotificationImageRequireOwnedAsset bool
+	NotificationImageAllowedHosts      []string
+
 	// rawEnv stores all environment variables for plugin configuration.
 	// This field is unexported to prevent direct access to sensitive data.
 	// Use GetPluginConfig() to access filtered configuration by prefix.
@@ -96,8 +191,22 @@ func Load() *Config {
 		DBConnMaxIdleTime: getEnvInt("DB_CONN_MAX_IDLE_TIME_MIN", 5),
 		DBConnectRetries:  getEnvInt("DB_CONNECT_RETRIES", 5),
 		ServerPort:        getEnv("SERVER_PORT", "9090"),
+		DrainTimeoutSec:   getEnvInt("SHUTDOWN_TIMEOUT_SECONDS", 30),
 
 		FirebaseCredentialsPath: getEnv("FIREBASE_CREDENTIALS_PATH", ""),
+		FCMDryRun:               getEnvBool("FCM_DRY_RUN", false),
+
+		FCMCircuitFailureThreshold: getEnvInt("FCM_CIRCUIT_FAILURE_THRESHOLD", 5),
+		FCMCircuitResetTimeoutSec:  getEnvInt("FCM_CIRCUIT_RESET_TIMEOUT_SEC", 30),
+
+		FCMMaxRetries:          getEnvInt("FCM_MAX_RETRIES", 3),
+		FCMInitialRetryDelayMs: getEnvInt("FCM_INITIAL_RETRY_DELAY_MS", 1000),
+		FCMMaxRetryDelayMs:     getEnvInt("FCM_MAX_RETRY_DELAY_MS", 30000),
+		FCMMaxTokensPerSend:    getEnvInt("FCM_MAX_TOKENS_PER_SEND", 50000),
+		FCMRetryJitter:         getEnv("FCM_RETRY_JITTER", "equal"),
+
+		NotificationProviderIOS:     getEnv("NOTIFICATION_PROVIDER_IOS", "fcm"),
+		NotificationProviderAndroid: getEnv("NOTIFICATION_PROVIDER_ANDROID", "fcm"),
 
 		// External IDP (Asgardeo)
 		ExternalIdPJWKSURL:  getEnvRequired("EXTERNAL_IDP_JWKS_URL"),
@@ -109,6 +218,23 @@ func Load() *Config {
 		InternalIdPIssuer:   getEnvRequired("INTERNAL_IDP_ISSUER"),
 		InternalIdPAudience: getEnvRequired("INTERNAL_IDP_AUDIENCE"),
 
+		JWTClaimsUseJSONNumber: getEnvBool("JWT_CLAIMS_USE_JSON_NUMBER", true),
+		TokenClockSkewSeconds:  getEnvInt("TOKEN_CLOCK_SKEW_SECONDS", 0),
+		JWKSCacheTTLSec:        getEnvInt("JWKS_CACHE_TTL_SEC", 3600),
+
+		DebugEndpointsEnabled: getEnvBool("DEBUG_ENDPOINTS_ENABLED", false),
+
+		MetricsEnabled: getEnvBool("METRICS_ENABLED", true),
+
+		OTelExporterEndpoint: getEnv("OTEL_EXPORTER_OTLP_ENDPOINT", ""),
+		OTelServiceName:      getEnv("OTEL_SERVICE_NAME", "superapp-core"),
+
+		ConfigConflictWindowSec: getEnvInt("CONFIG_CONFLICT_WINDOW_SEC", 0),
+
+		TrustedProxyCIDRs: getEnvList("TRUSTED_PROXY_CIDRS"),
+
+		MaxUserGroups: getEnvInt("MAX_USER_GROUPS", 1000),
+
 		// File Service
 		FileServiceType: getEnv("FILE_SERVICE_TYPE", "db"),
 
@@ -118,6 +244,49 @@ func Load() *Config {
 		// File Upload
 		UploadFileMaxSizeMB: getEnvInt("UPLOAD_FILE_MAX_SIZE_MB", 20),
 
+		// Scheduled Notifications
+		ScheduledNotificationPollIntervalSec: getEnvInt("SCHEDULED_NOTIFICATION_POLL_INTERVAL_SEC", 30),
+		ScheduledNotificationBatchSize:       getEnvInt("SCHEDULED_NOTIFICATION_BATCH_SIZE", 50),
+		ScheduledNotificationLeaseSec:        getEnvInt("SCHEDULED_NOTIFICATION_LEASE_SEC", 600),
+
+		// Stale Device Token Cleanup
+		DeviceTokenStaleDays:          getEnvInt("DEVICE_TOKEN_STALE_DAYS", 270),
+		DeviceTokenCleanupIntervalSec: getEnvInt("DEVICE_TOKEN_CLEANUP_INTERVAL_SEC", 86400),
+
+		// Microapp API Keys
+		APIKeyRateLimitPerSec: getEnvInt("API_KEY_RATE_LIMIT_PER_SEC", 10),
+		APIKeyRateLimitBurst:  getEnvInt("API_KEY_RATE_LIMIT_BURST", 20),
+
+		// Per-caller rate limits
+		UserRateLimitPerSec:    getEnvInt("USER_RATE_LIMIT_PER_SEC", 20),
+		UserRateLimitBurst:     getEnvInt("USER_RATE_LIMIT_BURST", 40),
+		ServiceRateLimitPerSec: getEnvInt("SERVICE_RATE_LIMIT_PER_SEC", 50),
+		ServiceRateLimitBurst:  getEnvInt("SERVICE_RATE_LIMIT_BURST", 100),
+
+		// Service Scopes
+		ServiceScopesRequired: getEnvBool("SERVICE_SCOPES_REQUIRED", true),
+
+		// User Erasure
+		ErasureDeviceTokens:            getEnv("ERASURE_DEVICE_TOKENS", "delete"),
+		ErasureConfigs:                 getEnv("ERASURE_USER_CONFIGS", "delete"),
+		ErasureNotificationPreferences: getEnv("ERASURE_NOTIFICATION_PREFERENCES", "anonymize"),
+		ErasureNotificationLogs:        getEnv("ERASURE_NOTIFICATION_LOGS", "anonymize"),
+
+		// Notification Quotas
+		NotificationQuotaPeriodSec:    getEnvInt("NOTIFICATION_QUOTA_PERIOD_SEC", 3600),
+		NotificationQuotaDefaultLimit: getEnvInt("NOTIFICATION_QUOTA_DEFAULT_LIMIT", 0),
+
+		// Notification Receipts
+		NotificationReceiptKeyPath: getEnv("NOTIFICATION_RECEIPT_KEY_PATH", ""),
+
+		// Notification Sender Identity
+		NotificationDefaultSenderName:    getEnv("NOTIFICATION_DEFAULT_SENDER_NAME", "SuperApp"),
+		NotificationDefaultSenderIconURL: getEnv("NOTIFICATION_DEFAULT_SENDER_ICON_URL", ""),
+
+		// Notification Images
+		NotificationImageRequireOwnedAsset: getEnvBool("NOTIFICATION_IMAGE_REQUIRE_OWNED_ASSET", false),
+		NotificationImageAllowedHosts:      getEnvList("NOTIFICATION_IMAGE_ALLOWED_HOSTS"),
+
 		rawEnv: rawEnv,
 	}
 
@@ -144,6 +313,17 @@ func getEnvRequired(key string) string {
 	return value
 }
 
+func getEnvBool(key string, fallback bool) bool {
+	if value := os.Getenv(key); value != "" {
+		boolValue, err := strconv.ParseBool(value)
+		if err == nil {
+			return boolValue
+		}
+		slog.Warn("Invalid boolean value for environment variable, using default", "key", key, "value", value, "default", fallback)
+	}
+	return fallback
+}
+
 func getEnvInt(key string, fallback int) int {
 	if value := os.Getenv(key); value != "" {
 		var intValue int
@@ -155,6 +335,17 @@ func getEnvInt(key string, fallback int) int {
 	return fallback
 }
 
+// getEnvList returns the comma-separated values of an environment variable, skipping empty entries
+func getEnvList(key string) []string {
+	var values []string
+	for _, value := range strings.Split(os.Getenv(key), ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
 // get file service config
 func (c *Config) GetFileServiceConfig() map[string]any {
 	return c.GetPluginConfig(fileServiceConfigPrefix)
@@ -0,0 +1,126 @@
+// Copyright (c) 2025 WSO2 LLC. (https://www.wso2.com).
+//
+// WSO2 LLC. licenses this file to you under the Apache License,
+// Version 2.0 (the "License"); you may not use this file except
+// in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+// Package metrics exposes Prometheus metrics for the core service.
+package metrics
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// unmatchedRoute is the path label of requests that matched no route, so that probes for
+// arbitrary URLs do not each create a new series
+const unmatchedRoute = "unmatched"
+
+// NewRegistry returns a registry with the Go runtime and process collectors registered.
+func NewRegistry() *prometheus.Registry {
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+	return registry
+}
+
+// Handler serves the registry's metrics in the Prometheus text format.
+func Handler(registry *prometheus.Registry) http.Handler {
+	return promhttp.HandlerFor(registry, promhttp.HandlerOpts{Registry: registry})
+}
+
+// MetricsMiddleware records http_requests_total{method, path, status} and
+// http_request_duration_seconds{method, path} for every request. The path label is the chi
+// route pattern (e.g. /api/v1/micro-apps/{appID}) rather than the URL, to bound cardinality,
+// so the middleware must be installed on the top-level router.
+func MetricsMiddleware(registry *prometheus.Registry) func(http.Handler) http.Handler {
+	requests := register(registry, prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "http_requests_total",
+		Help: "HTTP requests by method, route pattern and status code.",
+	}, []string{"method", "path", "status"}))
+	duration := register(registry, prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "http_request_duration_seconds",
+		Help:    "HTTP request latency by method and route pattern.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"method", "path"}))
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+			next.ServeHTTP(ww, r)
+
+			// The route pattern is only complete once routing has finished
+			path := unmatchedRoute
+			if rctx := chi.RouteContext(r.Context()); rctx != nil && rctx.RoutePattern() != "" {
+				path = rctx.RoutePattern()
+			}
+			status := ww.Status()
+			if status == 0 {
+				status = http.StatusOK
+			}
+			requests.WithLabelValues(r.Method, path, strconv.Itoa(status)).Inc()
+			duration.WithLabelValues(r.Method, path).Observe(time.Since(start).Seconds())
+		})
+	}
+}
+
+// NotificationMetrics counts push notifications sent through the API. A nil
+// *NotificationMetrics records nothing, so handlers work without metrics.
+type NotificationMetrics struct {
+	sent *prometheus.CounterVec
+}
+
+// NewNotificationMetrics registers fcm_notifications_sent_total{microapp_id, status}.
+func NewNotificationMetrics(registry *prometheus.Registry) *NotificationMetrics {
+	return &NotificationMetrics{
+		sent: register(registry, prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "fcm_notifications_sent_total",
+			Help: "Push notifications sent to devices by micro app and delivery status.",
+		}, []string{"microapp_id", "status"})),
+	}
+}
+
+// RecordSent counts the successful and failed device deliveries of a send.
+func (m *NotificationMetrics) RecordSent(microappID string, success, failure int) {
+	if m == nil {
+		return
+	}
+	m.sent.WithLabelValues(microappID, "success").Add(float64(success))
+	m.sent.WithLabelValues(microappID, "failure").Add(float64(failure))
+}
+
+// register registers a collector, or returns the one already registered under the same
+// name, so building the router twice against one registry does not panic
+func register[C prometheus.Collector](registry *prometheus.Registry, collector C) C {
+	if err := registry.Register(collector); err != nil {
+		var already prometheus.AlreadyRegisteredError
+		if errors.As(err, &already) {
+			if existing, ok := already.ExistingCollector.(C); ok {
+				return existing
+			}
+		}
+		panic(err)
+	}
+	return collector
+}
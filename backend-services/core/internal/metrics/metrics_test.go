@@ -0,0 +1,120 @@
+// Copyright (c) 2025 WSO2 LLC. (https://www.wso2.com).
+//
+// WSO2 LLC. licenses this file to you under the Apache License,
+// Version 2.0 (the "License"); you may not use this file except
+// in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package metrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func newTestRouter(registry *prometheus.Registry) http.Handler {
+	r := chi.NewRouter()
+	r.Use(MetricsMiddleware(registry))
+	r.Route("/api/v1", func(r chi.Router) {
+		r.Get("/micro-apps/{appID}", func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte("ok"))
+		})
+		r.Post("/micro-apps", func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusCreated)
+		})
+	})
+	return r
+}
+
+// TestMetricsMiddleware_LabelsByRoutePattern tests that requests are counted under the route
+// pattern rather than the URL, with their method and status
+func TestMetricsMiddleware_LabelsByRoutePattern(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	router := newTestRouter(registry)
+
+	for _, req := range []*http.Request{
+		httptest.NewRequest(http.MethodGet, "/api/v1/micro-apps/app-1", nil),
+		httptest.NewRequest(http.MethodGet, "/api/v1/micro-apps/app-2", nil),
+		httptest.NewRequest(http.MethodPost, "/api/v1/micro-apps", nil),
+		httptest.NewRequest(http.MethodGet, "/wp-login.php", nil),
+	} {
+		router.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	expected := `
+# HELP http_requests_total HTTP requests by method, route pattern and status code.
+# TYPE http_requests_total counter
+http_requests_total{method="GET",path="/api/v1/micro-apps/{appID}",status="200"} 2
+http_requests_total{method="GET",path="unmatched",status="404"} 1
+http_requests_total{method="POST",path="/api/v1/micro-apps",status="201"} 1
+`
+	if err := testutil.GatherAndCompare(registry, strings.NewReader(expected), "http_requests_total"); err != nil {
+		t.Error(err)
+	}
+	if got := testutil.CollectAndCount(registry, "http_request_duration_seconds"); got != 3 {
+		t.Errorf("Expected 3 duration series, got %d", got)
+	}
+}
+
+// TestMetricsMiddleware_SharedRegistry tests that building the middleware twice against one
+// registry reuses the registered collectors instead of panicking
+func TestMetricsMiddleware_SharedRegistry(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	first := newTestRouter(registry)
+	second := newTestRouter(registry)
+
+	first.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/api/v1/micro-apps/app-1", nil))
+	second.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/api/v1/micro-apps/app-1", nil))
+
+	expected := `
+# HELP http_requests_total HTTP requests by method, route pattern and status code.
+# TYPE http_requests_total counter
+http_requests_total{method="GET",path="/api/v1/micro-apps/{appID}",status="200"} 2
+`
+	if err := testutil.GatherAndCompare(registry, strings.NewReader(expected), "http_requests_total"); err != nil {
+		t.Error(err)
+	}
+}
+
+// TestNotificationMetrics_Nil tests that a nil NotificationMetrics records nothing
+func TestNotificationMetrics_Nil(t *testing.T) {
+	var m *NotificationMetrics
+	m.RecordSent("app-1", 1, 1)
+}
+
+// TestHandler tests that the registry is served in the Prometheus text format
+func TestHandler(t *testing.T) {
+	registry := NewRegistry()
+	NewNotificationMetrics(registry).RecordSent("app-1", 3, 0)
+
+	w := httptest.NewRecorder()
+	Handler(registry).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", w.Code)
+	}
+	for _, want := range []string{
+		`fcm_notifications_sent_total{microapp_id="app-1",status="success"} 3`,
+		"go_goroutines",
+		"process_cpu_seconds_total",
+	} {
+		if !strings.Contains(w.Body.String(), want) {
+			t.Errorf("Expected the metrics to contain %q", want)
+		}
+	}
+}
@@ -0,0 +1,42 @@
+// Copyright (c) 2025 WSO2 LLC. (https://www.wso2.com).
+//
+// WSO2 LLC. licenses this file to you under the Apache License,
+// Version 2.0 (the "License"); you may not use this file except
+// in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+package models
+
+import "time"
+
+// Category groups micro apps by function (productivity, communication, ...) for users to browse.
+type Category struct {
+	ID          int       `gorm:"column:id;primaryKey;autoIncrement"`
+	Name        string    `gorm:"column:name;type:varchar(255);not null"`
+	Slug        string    `gorm:"column:slug;type:varchar(64);not null;uniqueIndex:uq_categories_slug"`
+	Description *string   `gorm:"column:description;type:text"`
+	CreatedBy   string    `gorm:"column:created_by;type:varchar(319);not null"`
+	CreatedAt   time.Time `gorm:"column:created_at;not null;autoCreateTime"`
+}
+
+func (Category) TableName() string {
+	return "categories"
+}
+
+// MicroAppCategory assigns a micro app to a category. Rows go with either side when it is deleted.
+type MicroAppCategory struct {
+	MicroAppID string `gorm:"column:micro_app_id;type:varchar(255);primaryKey"`
+	CategoryID int    `gorm:"column:category_id;primaryKey;index:idx_mac_category"`
+}
+
+func (MicroAppCategory) TableName() string {
+	return "micro_app_category"
+}
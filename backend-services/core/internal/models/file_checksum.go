@@ -0,0 +1,31 @@
+// Copyright (c) 2025 WSO2 LLC. (https://www.wso2.com).
+//
+// WSO2 LLC. licenses this file to you under the Apache License,
+// Version 2.0 (the "License"); you may not use this file except
+// in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+package models
+
+import "time"
+
+// FileChecksum records the SHA-256 of a file's content as uploaded through the file service, so
+// microapp versions can publish it for clients to verify their download against.
+type FileChecksum struct {
+	FileName      string    `gorm:"column:file_name;type:varchar(255);primaryKey"`
+	URL           string    `gorm:"column:url;type:varchar(2083);not null;index:idx_file_checksums_url,length:255"` // download URL returned by the file service
+	ContentSHA256 string    `gorm:"column:content_sha256;type:char(64);not null"`                                   // lowercase hex
+	UploadedAt    time.Time `gorm:"column:uploaded_at;not null;autoUpdateTime"`
+}
+
+func (FileChecksum) TableName() string {
+	return "file_checksums"
+}
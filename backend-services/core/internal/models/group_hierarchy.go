@@ -0,0 +1,27 @@
+// Copyright (c) 2025 WSO2 LLC. (https://www.wso2.com).
+//
+// WSO2 LLC. licenses this file to you under the Apache License,
+// Version 2.0 (the "License"); you may not use this file except
+// in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+package models
+
+// GroupHierarchy records that members of ParentGroup are implicitly members of ChildGroup,
+// e.g. superadmin implies admin.
+type GroupHierarchy struct {
+	ParentGroup string `gorm:"column:parent_group;type:varchar(255);primaryKey"`
+	ChildGroup  string `gorm:"column:child_group;type:varchar(255);primaryKey"`
+}
+
+func (GroupHierarchy) TableName() string {
+	return "group_hierarchy"
+}
@@ -15,7 +15,12 @@
 // under the License.
 package models
 
-import "time"
+import (
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
 
 type MicroApp struct {
 	ID             int               `gorm:"column:id;primaryKey;autoIncrement"`
@@ -34,8 +39,61 @@ type MicroApp struct {
 	Versions       []MicroAppVersion `gorm:"foreignKey:MicroAppID;references:MicroAppID"`
 	Roles          []MicroAppRole    `gorm:"foreignKey:MicroAppID;references:MicroAppID"`
 	Configs        []MicroAppConfig  `gorm:"foreignKey:MicroAppID;references:MicroAppID"`
+	Categories     []Category        `gorm:"many2many:micro_app_category;foreignKey:MicroAppID;joinForeignKey:MicroAppID;references:ID;joinReferences:CategoryID"`
 }
 
 func (MicroApp) TableName() string {
 	return "micro_app"
 }
+
+// likeEscaper escapes the LIKE wildcards in a search term, using ! as the escape character.
+var likeEscaper = strings.NewReplacer("!", "!!", "%", "!%", "_", "!_")
+
+// WithSearch matches micro apps whose name contains q. An empty q matches every micro app.
+func WithSearch(q string) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if q == "" {
+			return db
+		}
+		return db.Where("micro_app.name LIKE ? ESCAPE '!'", "%"+likeEscaper.Replace(q)+"%")
+	}
+}
+
+// WithActive matches micro apps whose active flag is *v. A nil v matches every micro app.
+func WithActive(v *int) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if v == nil {
+			return db
+		}
+		return db.Where("micro_app.active = ?", *v)
+	}
+}
+
+// WithMandatory matches micro apps whose mandatory flag is *v. A nil v matches every micro app.
+func WithMandatory(v *int) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if v == nil {
+			return db
+		}
+		return db.Where("micro_app.mandatory = ?", *v)
+	}
+}
+
+// WithCategory matches micro apps assigned to the category with the given slug. An empty slug
+// matches every micro app.
+func WithCategory(slug string) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if slug == "" {
+			return db
+		}
+		return db.Where("micro_app.micro_app_id IN (SELECT mac.micro_app_id FROM micro_app_category mac "+
+			"JOIN categories c ON c.id = mac.category_id WHERE c.slug = ?)", slug)
+	}
+}
+
+// WithPagination selects the 1-based page of limit micro apps.
+func WithPagination(page, limit int) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Limit(limit).Offset((page - 1) * limit)
+	}
+}
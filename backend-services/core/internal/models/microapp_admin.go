@@ -0,0 +1,30 @@
+// Copyright (c) 2025 WSO2 LLC. (https://www.wso2.com).
+//
+// WSO2 LLC. licenses this file to you under the Apache License,
+// Version 2.0 (the "License"); you may not use this file except
+// in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+package models
+
+import "time"
+
+// MicroAppAdmin grants a user admin rights over a single micro app, without the global admin group.
+type MicroAppAdmin struct {
+	MicroAppID string    `gorm:"column:micro_app_id;type:varchar(255);primaryKey"`
+	UserEmail  string    `gorm:"column:user_email;type:varchar(319);primaryKey;index:idx_micro_app_admin_user_email"`
+	GrantedBy  string    `gorm:"column:granted_by;type:varchar(319);not null"`
+	GrantedAt  time.Time `gorm:"column:granted_at;not null;autoCreateTime"`
+}
+
+func (MicroAppAdmin) TableName() string {
+	return "micro_app_admin"
+}
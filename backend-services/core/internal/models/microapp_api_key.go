@@ -0,0 +1,39 @@
+// Copyright (c) 2025 WSO2 LLC. (https://www.wso2.com).
+//
+// WSO2 LLC. licenses this file to you under the Apache License,
+// Version 2.0 (the "License"); you may not use this file except
+// in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+package models
+
+import "time"
+
+// MicroAppAPIKey is a long-lived credential a microapp backend can use instead of OAuth
+// client credentials. Only the SHA-256 hash of the key is stored; the prefix identifies
+// the key in listings and logs without revealing it.
+type MicroAppAPIKey struct {
+	ID         int64      `gorm:"column:id;primaryKey;autoIncrement"`
+	MicroappID string     `gorm:"column:microapp_id;type:varchar(100);not null;index:idx_api_keys_microapp_id"`
+	Name       string     `gorm:"column:name;type:varchar(255);not null"`
+	KeyPrefix  string     `gorm:"column:key_prefix;type:varchar(32);not null"`
+	KeyHash    string     `gorm:"column:key_hash;type:char(64);not null;uniqueIndex:uq_api_keys_key_hash"`
+	Scopes     string     `gorm:"column:scopes;type:varchar(1024)"`
+	CreatedBy  string     `gorm:"column:created_by;type:varchar(319);not null"`
+	CreatedAt  time.Time  `gorm:"column:created_at;not null;autoCreateTime"`
+	LastUsedAt *time.Time `gorm:"column:last_used_at"`
+	ExpiresAt  *time.Time `gorm:"column:expires_at"` // nil if the key never expires
+	RevokedAt  *time.Time `gorm:"column:revoked_at"`
+}
+
+func (MicroAppAPIKey) TableName() string {
+	return "microapp_api_keys"
+}
@@ -0,0 +1,30 @@
+// Copyright (c) 2025 WSO2 LLC. (https://www.wso2.com).
+//
+// WSO2 LLC. licenses this file to you under the Apache License,
+// Version 2.0 (the "License"); you may not use this file except
+// in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+package models
+
+import "time"
+
+// MicroAppAsset records the micro app that owns a file uploaded through the file service.
+type MicroAppAsset struct {
+	FileName   string    `gorm:"column:file_name;type:varchar(255);primaryKey"`
+	MicroAppID string    `gorm:"column:micro_app_id;type:varchar(255);not null;index:idx_micro_app_assets_micro_app_id"`
+	URL        string    `gorm:"column:url;type:varchar(2083);not null"` // download URL returned by the file service
+	UploadedAt time.Time `gorm:"column:uploaded_at;not null;autoUpdateTime"`
+}
+
+func (MicroAppAsset) TableName() string {
+	return "micro_app_assets"
+}
@@ -17,6 +17,7 @@ package models
 
 import (
 	"encoding/json"
+	"time"
 )
 
 type MicroAppConfig struct {
@@ -26,6 +27,7 @@ type MicroAppConfig struct {
 	Active      int             `gorm:"column:active;type:tinyint(1);not null;default:1"`
 	CreatedBy   string          `gorm:"column:created_by;type:varchar(319);not null"`
 	UpdatedBy   *string         `gorm:"column:updated_by;type:varchar(319)"`
+	UpdatedAt   time.Time       `gorm:"column:updated_at;not null;autoUpdateTime"`
 }
 
 func (MicroAppConfig) TableName() string {
@@ -0,0 +1,39 @@
+// Copyright (c) 2025 WSO2 LLC. (https://www.wso2.com).
+//
+// WSO2 LLC. licenses this file to you under the Apache License,
+// Version 2.0 (the "License"); you may not use this file except
+// in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// MicroAppConfigConflict records a config upsert that overwrote a value another user had
+// changed shortly before, so accidental clobbering can be reviewed after the fact.
+type MicroAppConfigConflict struct {
+	ID           int64           `gorm:"column:id;primaryKey;autoIncrement"`
+	MicroAppID   string          `gorm:"column:micro_app_id;type:varchar(255);not null;index:idx_macc_app"`
+	ConfigKey    string          `gorm:"column:config_key;type:varchar(191);not null"`
+	OldValue     json.RawMessage `gorm:"column:old_value;type:json;not null"`
+	NewValue     json.RawMessage `gorm:"column:new_value;type:json;not null"`
+	OldUpdatedBy string          `gorm:"column:old_updated_by;type:varchar(319);not null"`
+	OldUpdatedAt time.Time       `gorm:"column:old_updated_at;not null"`
+	NewUpdatedBy string          `gorm:"column:new_updated_by;type:varchar(319);not null"`
+	DetectedAt   time.Time       `gorm:"column:detected_at;not null"`
+}
+
+func (MicroAppConfigConflict) TableName() string {
+	return "micro_app_config_conflicts"
+}
@@ -0,0 +1,72 @@
+// Copyright (c) 2025 WSO2 LLC. (https://www.wso2.com).
+//
+// WSO2 LLC. licenses this file to you under the Apache License,
+// Version 2.0 (the "License"); you may not use this file except
+// in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+package models
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"time"
+)
+
+// Delivery statuses of a notification, in the order a delivery progresses through them
+const (
+	DeliveryStatusPending   = "pending"
+	DeliveryStatusFailed    = "failed"
+	DeliveryStatusDelivered = "delivered"
+	DeliveryStatusRead      = "read"
+)
+
+// deliveryStatusRank orders statuses so that a delivery never moves back to an earlier one
+var deliveryStatusRank = map[string]int{
+	DeliveryStatusPending:   0,
+	DeliveryStatusFailed:    1,
+	DeliveryStatusDelivered: 2,
+	DeliveryStatusRead:      3,
+}
+
+// IsDeliveryStatus reports whether status is a known delivery status.
+func IsDeliveryStatus(status string) bool {
+	_, ok := deliveryStatusRank[status]
+	return ok
+}
+
+// DeliveryStatusAdvances reports whether moving from one status to another is progress.
+// A delivery reported as read stays read even if a late delivered report arrives.
+func DeliveryStatusAdvances(from, to string) bool {
+	return deliveryStatusRank[to] > deliveryStatusRank[from]
+}
+
+// NotificationDelivery is the delivery state of a notification on a single device.
+// The device token itself is not stored; it is identified by its hash.
+type NotificationDelivery struct {
+	ID                int64     `gorm:"column:id;primaryKey;autoIncrement"`
+	NotificationLogID int64     `gorm:"column:notification_log_id;not null;index:idx_nd_notification_log_id"`
+	DeviceTokenHash   string    `gorm:"column:device_token_hash;type:char(64);not null"`
+	FCMMessageID      *string   `gorm:"column:fcm_message_id;type:varchar(255);index:idx_nd_fcm_message_id"`
+	Status            string    `gorm:"column:status;type:varchar(20);not null"`
+	CreatedAt         time.Time `gorm:"column:created_at;autoCreateTime"`
+	UpdatedAt         time.Time `gorm:"column:updated_at;autoUpdateTime"`
+}
+
+func (NotificationDelivery) TableName() string {
+	return "notification_delivery"
+}
+
+// HashDeviceToken returns the hex SHA-256 hash under which a device token is recorded.
+func HashDeviceToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
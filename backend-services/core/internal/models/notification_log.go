@@ -46,14 +46,21 @@ func (j JSONMap) Value() (driver.Value, error) {
 }
 
 type NotificationLog struct {
-	ID         int64     `gorm:"column:id;primaryKey;autoIncrement"`
-	UserEmail  string    `gorm:"column:user_email;type:varchar(255);not null;index:idx_user_email"`
-	Title      *string   `gorm:"column:title;type:varchar(255)"`
-	Body       *string   `gorm:"column:body;type:text"`
-	Data       JSONMap   `gorm:"column:data;type:json"`
-	SentAt     time.Time `gorm:"column:sent_at;not null;autoCreateTime;index:idx_sent_at"`
-	Status     *string   `gorm:"column:status;type:varchar(50)"`
-	MicroappID *string   `gorm:"column:microapp_id;type:varchar(100);index:idx_microapp_id"`
+	ID         int64      `gorm:"column:id;primaryKey;autoIncrement;index:idx_user_sent_at,priority:3,sort:desc"`
+	UserEmail  string     `gorm:"column:user_email;type:varchar(255);not null;index:idx_user_email;index:idx_user_read_at,priority:1;index:idx_user_sent_at,priority:1;index:idx_notification_logs_dedup,priority:3"`
+	Title      *string    `gorm:"column:title;type:varchar(255)"`
+	Body       *string    `gorm:"column:body;type:text"`
+	Data       JSONMap    `gorm:"column:data;type:json"`
+	SentAt     time.Time  `gorm:"column:sent_at;not null;autoCreateTime;index:idx_sent_at;index:idx_user_sent_at,priority:2,sort:desc;index:idx_notification_logs_dedup,priority:4"`
+	Status     *string    `gorm:"column:status;type:varchar(50)"`
+	MicroappID *string    `gorm:"column:microapp_id;type:varchar(100);index:idx_microapp_id;index:idx_notification_logs_dedup,priority:2"`
+	DedupKey   *string    `gorm:"column:dedup_key;type:varchar(255);index:idx_notification_logs_dedup,priority:1"`
+	ReadAt     *time.Time `gorm:"column:read_at;index:idx_user_read_at,priority:2"`
+	// FailureReason is set when none of the recipient's devices received the notification
+	FailureReason *string `gorm:"column:failure_reason;type:varchar(500)"`
+	// DeliveryStatus is the furthest any of the recipient's devices has progressed
+	DeliveryStatus string  `gorm:"column:delivery_status;type:varchar(20);not null;default:pending"`
+	FCMMessageID   *string `gorm:"column:fcm_message_id;type:varchar(255);index:idx_fcm_message_id"`
 }
 
 func (NotificationLog) TableName() string {
@@ -0,0 +1,32 @@
+// Copyright (c) 2025 WSO2 LLC. (https://www.wso2.com).
+//
+// WSO2 LLC. licenses this file to you under the Apache License,
+// Version 2.0 (the "License"); you may not use this file except
+// in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+package models
+
+import "time"
+
+// NotificationPreference records whether a user has opted out of a microapp's notifications.
+// Users without a row for a microapp receive its notifications.
+type NotificationPreference struct {
+	ID         int64     `gorm:"column:id;primaryKey;autoIncrement"`
+	UserEmail  string    `gorm:"column:user_email;type:varchar(255);not null;uniqueIndex:uq_notification_preferences_user_app"`
+	MicroappID string    `gorm:"column:microapp_id;type:varchar(100);not null;uniqueIndex:uq_notification_preferences_user_app;index:idx_np_microapp_opted_out,priority:1"`
+	OptedOut   bool      `gorm:"column:opted_out;not null;default:false;index:idx_np_microapp_opted_out,priority:2"`
+	UpdatedAt  time.Time `gorm:"column:updated_at;not null;autoUpdateTime"`
+}
+
+func (NotificationPreference) TableName() string {
+	return "notification_preferences"
+}
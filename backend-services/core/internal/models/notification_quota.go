@@ -0,0 +1,31 @@
+// Copyright (c) 2025 WSO2 LLC. (https://www.wso2.com).
+//
+// WSO2 LLC. licenses this file to you under the Apache License,
+// Version 2.0 (the "License"); you may not use this file except
+// in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+package models
+
+import "time"
+
+// NotificationQuota counts the notifications a microapp sent in one quota period
+type NotificationQuota struct {
+	ID          int64     `gorm:"column:id;primaryKey;autoIncrement"`
+	MicroappID  string    `gorm:"column:microapp_id;type:varchar(255);not null;uniqueIndex:uk_nq_microapp_period"`
+	PeriodStart time.Time `gorm:"column:period_start;not null;uniqueIndex:uk_nq_microapp_period"`
+	SendCount   int       `gorm:"column:send_count;not null;default:0"`
+	QuotaLimit  int       `gorm:"column:quota_limit;not null"` // limit in force when the period was last charged
+}
+
+func (NotificationQuota) TableName() string {
+	return "notification_quota"
+}
@@ -0,0 +1,35 @@
+// Copyright (c) 2025 WSO2 LLC. (https://www.wso2.com).
+//
+// WSO2 LLC. licenses this file to you under the Apache License,
+// Version 2.0 (the "License"); you may not use this file except
+// in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+package models
+
+import "time"
+
+// NotificationReceipt is a signed record proving that a notification with a given
+// content hash was sent to a recipient at a point in time.
+type NotificationReceipt struct {
+	ID          int64     `gorm:"column:id;primaryKey;autoIncrement"`
+	MicroappID  string    `gorm:"column:microapp_id;type:varchar(100);not null;index:idx_nr_microapp_id"`
+	Recipient   string    `gorm:"column:recipient;type:varchar(255);not null;index:idx_nr_recipient"`
+	ContentHash string    `gorm:"column:content_hash;type:char(64);not null"`
+	Status      string    `gorm:"column:status;type:varchar(50);not null"`
+	IssuedAt    time.Time `gorm:"column:issued_at;not null"`
+	KeyID       string    `gorm:"column:key_id;type:varchar(64);not null"`
+	Signature   string    `gorm:"column:signature;type:varchar(255);not null"`
+}
+
+func (NotificationReceipt) TableName() string {
+	return "notification_receipts"
+}
@@ -0,0 +1,75 @@
+// Copyright (c) 2025 WSO2 LLC. (https://www.wso2.com).
+//
+// WSO2 LLC. licenses this file to you under the Apache License,
+// Version 2.0 (the "License"); you may not use this file except
+// in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"time"
+)
+
+// NotificationTemplate is a reusable notification whose title and body are text/template
+// sources rendered with per-recipient variables. A template is identified by its key
+// within the owning microapp; Locale is empty for the default translation.
+type NotificationTemplate struct {
+	ID            int64   `gorm:"column:id;primaryKey;autoIncrement"`
+	MicroappID    string  `gorm:"column:microapp_id;type:varchar(100);not null;uniqueIndex:uq_notification_templates_key"`
+	TemplateKey   string  `gorm:"column:template_key;type:varchar(100);not null;uniqueIndex:uq_notification_templates_key"`
+	Locale        string  `gorm:"column:locale;type:varchar(35);not null;default:'';uniqueIndex:uq_notification_templates_key"`
+	TitleTemplate string  `gorm:"column:title_template;type:varchar(1024);not null"`
+	BodyTemplate  string  `gorm:"column:body_template;type:text;not null"`
+	DefaultData   JSONMap `gorm:"column:default_data;type:json"` // Sent with the notification under data keys the sender leaves unset
+	// Per-platform replacements, used for recipient devices on that platform
+	PlatformOverrides PlatformOverrides `gorm:"column:platform_overrides;type:json"`
+	CreatedAt         time.Time         `gorm:"column:created_at;not null;autoCreateTime"`
+	UpdatedAt         time.Time         `gorm:"column:updated_at;not null;autoUpdateTime"`
+}
+
+func (NotificationTemplate) TableName() string {
+	return "notification_templates"
+}
+
+// NotificationTemplateOverride replaces parts of a template on one device platform. An empty
+// title or body keeps the base template's; Data is added over the base template's default data.
+type NotificationTemplateOverride struct {
+	Title string  `json:"title,omitempty"`
+	Body  string  `json:"body,omitempty"`
+	Data  JSONMap `json:"data,omitempty"`
+}
+
+// PlatformOverrides maps a device platform ("ios", "android" or "web") to its template override.
+type PlatformOverrides map[string]NotificationTemplateOverride
+
+// Scan implements the sql.Scanner interface
+func (p *PlatformOverrides) Scan(value interface{}) error {
+	if value == nil {
+		*p = nil
+		return nil
+	}
+	bytes, ok := value.([]byte)
+	if !ok {
+		return nil
+	}
+	return json.Unmarshal(bytes, p)
+}
+
+// Value implements the driver.Valuer interface
+func (p PlatformOverrides) Value() (driver.Value, error) {
+	if p == nil {
+		return nil, nil
+	}
+	return json.Marshal(p)
+}
@@ -0,0 +1,36 @@
+// Copyright (c) 2025 WSO2 LLC. (https://www.wso2.com).
+//
+// WSO2 LLC. licenses this file to you under the Apache License,
+// Version 2.0 (the "License"); you may not use this file except
+// in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+package models
+
+// Permission is a named capability that routes can require instead of a group
+type Permission struct {
+	PermissionName string `gorm:"column:permission_name;type:varchar(255);primaryKey"`
+	Description    string `gorm:"column:description;type:varchar(1024)"`
+}
+
+func (Permission) TableName() string {
+	return "permissions"
+}
+
+// RolePermission grants a permission to every member of a group
+type RolePermission struct {
+	GroupName      string `gorm:"column:group_name;type:varchar(255);primaryKey"`
+	PermissionName string `gorm:"column:permission_name;type:varchar(255);primaryKey;index:idx_role_permissions_permission"`
+}
+
+func (RolePermission) TableName() string {
+	return "role_permissions"
+}
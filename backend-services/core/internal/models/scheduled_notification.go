@@ -0,0 +1,87 @@
+// Copyright (c) 2025 WSO2 LLC. (https://www.wso2.com).
+//
+// WSO2 LLC. licenses this file to you under the Apache License,
+// Version 2.0 (the "License"); you may not use this file except
+// in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"time"
+)
+
+const (
+	ScheduledStatusPending    = "pending"
+	ScheduledStatusProcessing = "processing"
+	ScheduledStatusSent       = "sent"
+	ScheduledStatusFailed     = "failed"
+)
+
+// JSONStringSlice is a custom type for storing a list of strings as JSON
+type JSONStringSlice []string
+
+// Scan implements the sql.Scanner interface
+func (s *JSONStringSlice) Scan(value interface{}) error {
+	if value == nil {
+		*s = nil
+		return nil
+	}
+	bytes, ok := value.([]byte)
+	if !ok {
+		return nil
+	}
+	return json.Unmarshal(bytes, s)
+}
+
+// Value implements the driver.Valuer interface
+func (s JSONStringSlice) Value() (driver.Value, error) {
+	if s == nil {
+		return nil, nil
+	}
+	return json.Marshal(s)
+}
+
+// ScheduledNotification is a notification queued to be sent at a future time.
+// Rows are claimed by a worker (ClaimedBy/ClaimedAt) before dispatch so that
+// multiple replicas never send the same notification concurrently. A claim is a lease:
+// once LeaseExpiresAt passes, another worker may reclaim the row, and ClaimToken
+// identifies the current claim so a worker that lost its lease cannot update the row.
+// Notifications created by coalescing sends carry a CoalesceKey; while pending, later sends
+// to the same recipients are merged into them.
+type ScheduledNotification struct {
+	ID             int64           `gorm:"column:id;primaryKey;autoIncrement"`
+	MicroappID     string          `gorm:"column:microapp_id;type:varchar(100);not null;index:idx_sn_microapp_id"`
+	UserEmails     JSONStringSlice `gorm:"column:user_emails;type:json;not null"`
+	Title          string          `gorm:"column:title;type:varchar(255);not null"`
+	Body           string          `gorm:"column:body;type:text;not null"`
+	Data           JSONMap         `gorm:"column:data;type:json"`
+	SendAt         time.Time       `gorm:"column:send_at;not null;index:idx_sn_status_send_at,priority:2"`
+	Status         string          `gorm:"column:status;type:varchar(20);not null;default:pending;index:idx_sn_status_send_at,priority:1;index:idx_sn_status_lease,priority:1;index:idx_sn_coalesce_key_status,priority:2"`
+	ClaimedBy      *string         `gorm:"column:claimed_by;type:varchar(255)"`
+	ClaimedAt      *time.Time      `gorm:"column:claimed_at"`
+	ClaimToken     *string         `gorm:"column:claim_token;type:varchar(36)"`
+	LeaseExpiresAt *time.Time      `gorm:"column:lease_expires_at;index:idx_sn_status_lease,priority:2"`
+	SentAt         *time.Time      `gorm:"column:sent_at"`
+	SuccessCount   int             `gorm:"column:success_count;not null;default:0"`
+	FailureCount   int             `gorm:"column:failure_count;not null;default:0"`
+	LastError      *string         `gorm:"column:last_error;type:text"`
+	CoalesceKey    *string         `gorm:"column:coalesce_key;type:char(64);index:idx_sn_coalesce_key_status,priority:1"` // Set on notifications later sends may be coalesced into
+	CoalescedCount int             `gorm:"column:coalesced_count;not null;default:1"`                                     // Sends merged into the notification
+	CreatedAt      time.Time       `gorm:"column:created_at;not null;autoCreateTime"`
+	UpdatedAt      time.Time       `gorm:"column:updated_at;not null;autoUpdateTime"`
+}
+
+func (ScheduledNotification) TableName() string {
+	return "scheduled_notifications"
+}
@@ -0,0 +1,46 @@
+// Copyright (c) 2025 WSO2 LLC. (https://www.wso2.com).
+//
+// WSO2 LLC. licenses this file to you under the Apache License,
+// Version 2.0 (the "License"); you may not use this file except
+// in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+package models
+
+import "time"
+
+const (
+	UserDataExportStatusProcessing = "processing"
+	UserDataExportStatusCompleted  = "completed"
+	UserDataExportStatusFailed     = "failed"
+)
+
+// UserDataExport is a data-subject access request: everything stored about one user, assembled in
+// the background into a JSON document. Rows are kept after download as the audit trail of who
+// exported whose data.
+type UserDataExport struct {
+	ID               int64      `gorm:"column:id;primaryKey;autoIncrement"`
+	UserEmail        string     `gorm:"column:user_email;type:varchar(255);not null;index:idx_user_data_exports_user_email"`
+	RequestedBy      string     `gorm:"column:requested_by;type:varchar(255);not null"`
+	Status           string     `gorm:"column:status;type:varchar(20);not null;default:processing"`
+	Error            *string    `gorm:"column:error;type:varchar(500)"`
+	Data             []byte     `gorm:"column:data;type:longblob"`
+	SizeBytes        int64      `gorm:"column:size_bytes;not null;default:0"`
+	DownloadCount    int        `gorm:"column:download_count;not null;default:0"`
+	LastDownloadedBy *string    `gorm:"column:last_downloaded_by;type:varchar(255)"`
+	LastDownloadedAt *time.Time `gorm:"column:last_downloaded_at"`
+	CreatedAt        time.Time  `gorm:"column:created_at;not null;autoCreateTime"`
+	CompletedAt      *time.Time `gorm:"column:completed_at"`
+}
+
+func (UserDataExport) TableName() string {
+	return "user_data_exports"
+}
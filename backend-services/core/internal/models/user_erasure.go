@@ -0,0 +1,35 @@
+// Copyright (c) 2025 WSO2 LLC. (https://www.wso2.com).
+//
+// WSO2 LLC. licenses this file to you under the Apache License,
+// Version 2.0 (the "License"); you may not use this file except
+// in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+package models
+
+import "time"
+
+// UserErasure is the audit record of a right-to-erasure request. It holds a hash of the erased
+// email rather than the email itself, so the record can answer "was this user erased?" without
+// keeping their PII. Rows the erasure anonymized rather than deleted carry Pseudonym as their email.
+type UserErasure struct {
+	ID             int64     `gorm:"column:id;primaryKey;autoIncrement"`
+	UserEmailHash  string    `gorm:"column:user_email_hash;type:char(64);not null;index:idx_user_erasures_email_hash"`
+	Pseudonym      string    `gorm:"column:pseudonym;type:varchar(64);not null"`
+	RequestedBy    string    `gorm:"column:requested_by;type:varchar(255);not null"`
+	Categories     JSONMap   `gorm:"column:categories;type:json"` // Action taken and rows affected per data category
+	ProfileDeleted bool      `gorm:"column:profile_deleted;not null;default:false"`
+	CreatedAt      time.Time `gorm:"column:created_at;not null;autoCreateTime"`
+}
+
+func (UserErasure) TableName() string {
+	return "user_erasures"
+}
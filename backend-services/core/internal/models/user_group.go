@@ -0,0 +1,31 @@
+// Copyright (c) 2025 WSO2 LLC. (https://www.wso2.com).
+//
+// WSO2 LLC. licenses this file to you under the Apache License,
+// Version 2.0 (the "License"); you may not use this file except
+// in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+package models
+
+import "time"
+
+// UserGroup records a user's IdP group membership so notifications can be addressed by group.
+// Rows are refreshed from the user's token claims whenever they register a device token.
+type UserGroup struct {
+	ID        int64     `gorm:"column:id;primaryKey;autoIncrement"`
+	UserEmail string    `gorm:"column:user_email;type:varchar(255);not null;uniqueIndex:uq_user_group"`
+	GroupName string    `gorm:"column:group_name;type:varchar(255);not null;uniqueIndex:uq_user_group;index:idx_ug_group_name"`
+	UpdatedAt time.Time `gorm:"column:updated_at;not null;autoUpdateTime"`
+}
+
+func (UserGroup) TableName() string {
+	return "user_groups"
+}
@@ -0,0 +1,80 @@
+// Copyright (c) 2025 WSO2 LLC. (https://www.wso2.com).
+//
+// WSO2 LLC. licenses this file to you under the Apache License,
+// Version 2.0 (the "License"); you may not use this file except
+// in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"time"
+)
+
+const (
+	UserImportStatusProcessing = "processing"
+	UserImportStatusCompleted  = "completed"
+)
+
+// UserImportRowError is a row of an import file that was not imported, and why.
+// Row is the 1-based position of the user in the file, not counting a CSV header.
+type UserImportRowError struct {
+	Row   int    `json:"row"`
+	Email string `json:"workEmail,omitempty"`
+	Error string `json:"error"`
+}
+
+// UserImportRowErrors is a custom type for storing row errors as JSON
+type UserImportRowErrors []UserImportRowError
+
+// Scan implements the sql.Scanner interface
+func (e *UserImportRowErrors) Scan(value interface{}) error {
+	if value == nil {
+		*e = nil
+		return nil
+	}
+	bytes, ok := value.([]byte)
+	if !ok {
+		return nil
+	}
+	return json.Unmarshal(bytes, e)
+}
+
+// Value implements the driver.Valuer interface
+func (e UserImportRowErrors) Value() (driver.Value, error) {
+	if e == nil {
+		return nil, nil
+	}
+	return json.Marshal(e)
+}
+
+// UserImportJob tracks an uploaded user file that is upserted in batches in the background.
+// Rows that fail validation are counted as failed before processing starts.
+type UserImportJob struct {
+	ID            int64               `gorm:"column:id;primaryKey;autoIncrement"`
+	Status        string              `gorm:"column:status;type:varchar(20);not null;default:processing"`
+	Format        string              `gorm:"column:format;type:varchar(10);not null"`
+	TotalRows     int                 `gorm:"column:total_rows;not null;default:0"`
+	ProcessedRows int                 `gorm:"column:processed_rows;not null;default:0"`
+	ImportedRows  int                 `gorm:"column:imported_rows;not null;default:0"`
+	FailedRows    int                 `gorm:"column:failed_rows;not null;default:0"`
+	RowErrors     UserImportRowErrors `gorm:"column:row_errors;type:json"`
+	CreatedBy     string              `gorm:"column:created_by;type:varchar(255);not null"`
+	CreatedAt     time.Time           `gorm:"column:created_at;not null;autoCreateTime"`
+	UpdatedAt     time.Time           `gorm:"column:updated_at;not null;autoUpdateTime"`
+	CompletedAt   *time.Time          `gorm:"column:completed_at"`
+}
+
+func (UserImportJob) TableName() string {
+	return "user_import_jobs"
+}
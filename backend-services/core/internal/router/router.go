@@ -18,11 +18,17 @@ package router
 import (
 	"log/slog"
 	"net/http"
+	"strings"
+	"time"
 
+	"github.com/opensuperapp/opensuperapp/backend-services/core/internal/api/v1/handler"
 	v1 "github.com/opensuperapp/opensuperapp/backend-services/core/internal/api/v1/router"
 	"github.com/opensuperapp/opensuperapp/backend-services/core/internal/auth"
+	"github.com/opensuperapp/opensuperapp/backend-services/core/internal/auth/rbac"
 	"github.com/opensuperapp/opensuperapp/backend-services/core/internal/config"
+	"github.com/opensuperapp/opensuperapp/backend-services/core/internal/metrics"
 	"github.com/opensuperapp/opensuperapp/backend-services/core/internal/services"
+	"github.com/opensuperapp/opensuperapp/backend-services/core/internal/tracing"
 
 	// pluggable services
 	fileservice "github.com/opensuperapp/opensuperapp/backend-services/core/plugins/file-service"
@@ -39,9 +45,28 @@ const (
 	serviceRoutesPrefix = apiV1Prefix + "/services"
 )
 
-func NewRouter(db *gorm.DB, cfg *config.Config) http.Handler {
+// NewRouter builds the HTTP handler and starts the background workers it depends on.
+// The returned shutdown function stops those workers and should be called once the
+// HTTP server has stopped accepting requests.
+func NewRouter(db *gorm.DB, cfg *config.Config) (http.Handler, func()) {
 	r := chi.NewRouter()
+	var workers []interface{ Stop() }
 
+	// Prometheus metrics go to a private registry rather than the global default
+	registry := metrics.NewRegistry()
+	notificationMetrics := metrics.NewNotificationMetrics(registry)
+
+	// Tag the request before anything logs, then resolve the client IP so every later
+	// middleware and handler sees the same address
+	trustedProxies, err := auth.ParseTrustedProxies(cfg.TrustedProxyCIDRs)
+	if err != nil {
+		slog.Error("Invalid trusted proxy configuration", "error", err)
+		panic(err)
+	}
+	r.Use(tracing.RouteSpanName)
+	r.Use(auth.RequestIDMiddleware())
+	r.Use(auth.ClientIPMiddleware(trustedProxies))
+	r.Use(metrics.MetricsMiddleware(registry))
 	r.Use(middleware.Logger)
 	r.Use(middleware.Recoverer)
 
@@ -52,6 +77,9 @@ func NewRouter(db *gorm.DB, cfg *config.Config) http.Handler {
 		cfg.ExternalIdPJWKSURL,
 		cfg.ExternalIdPIssuer,
 		cfg.ExternalIdPAudience,
+		services.WithJSONNumberClaims(cfg.JWTClaimsUseJSONNumber),
+		services.WithClockSkew(time.Duration(cfg.TokenClockSkewSeconds)*time.Second),
+		services.WithJWKSCacheTTL(time.Duration(cfg.JWKSCacheTTLSec)*time.Second),
 	)
 	if err != nil {
 		slog.Error("Failed to initialize External IDP Validator", "error", err)
@@ -61,7 +89,10 @@ func NewRouter(db *gorm.DB, cfg *config.Config) http.Handler {
 	}
 
 	// Initialize Service Token Validator (Internal IDP)
-	internalIDPValidator, err := services.NewTokenValidator(cfg.InternalIdPBaseURL, cfg.InternalIdPIssuer, cfg.InternalIdPAudience)
+	internalIDPValidator, err := services.NewTokenValidator(cfg.InternalIdPBaseURL, cfg.InternalIdPIssuer, cfg.InternalIdPAudience,
+		services.WithJSONNumberClaims(cfg.JWTClaimsUseJSONNumber),
+		services.WithClockSkew(time.Duration(cfg.TokenClockSkewSeconds)*time.Second),
+		services.WithJWKSCacheTTL(time.Duration(cfg.JWKSCacheTTLSec)*time.Second))
 	if err != nil {
 		slog.Error("Failed to initialize Internal IDP Validator", "error", err)
 		panic("Internal IDP Validator is required but failed to initialize")
@@ -72,16 +103,101 @@ func NewRouter(db *gorm.DB, cfg *config.Config) http.Handler {
 	// Initialize FCM service
 	var fcmService services.NotificationService
 	if cfg.FirebaseCredentialsPath != "" {
-		fcmService, err = services.NewFCMService(cfg.FirebaseCredentialsPath)
+		jitter, err := services.ParseJitterStrategy(cfg.FCMRetryJitter)
+		if err != nil {
+			slog.Error("Invalid FCM retry jitter", "error", err)
+			panic(err)
+		}
+		fcm, err := services.NewFCMServiceWithOptions(cfg.FirebaseCredentialsPath, cfg.FCMDryRun, services.FCMConfig{
+			MaxRetries:        cfg.FCMMaxRetries,
+			InitialRetryDelay: time.Duration(cfg.FCMInitialRetryDelayMs) * time.Millisecond,
+			MaxRetryDelay:     time.Duration(cfg.FCMMaxRetryDelayMs) * time.Millisecond,
+			AbsoluteLimit:     cfg.FCMMaxTokensPerSend,
+			Jitter:            jitter,
+		})
 		if err != nil {
 			slog.Error("Failed to initialize FCM service", "error", err)
 		} else {
+			var breaker *services.CircuitBreaker
+			if cfg.FCMCircuitFailureThreshold > 0 {
+				breaker = services.NewCircuitBreaker(cfg.FCMCircuitFailureThreshold,
+					time.Duration(cfg.FCMCircuitResetTimeoutSec)*time.Second, services.SystemClock)
+			}
+			fcmService = fcm.WithCircuitBreaker(breaker)
 			slog.Info("FCM service initialized successfully")
 		}
 	} else {
 		slog.Warn("Firebase credentials path not configured, notification features will be unavailable")
 	}
 
+	// Route device notifications through a different provider per platform when configured
+	platformProviders := map[string]string{
+		"ios":     cfg.NotificationProviderIOS,
+		"android": cfg.NotificationProviderAndroid,
+	}
+	providers := make(map[string]services.NotificationService)
+	providersByName := make(map[string]services.NotificationService)
+	for platform, name := range platformProviders {
+		if name == services.ProviderFCM {
+			continue
+		}
+		provider, ok := providersByName[name]
+		if !ok {
+			provider, err = services.NewNotificationProvider(name, cfg.GetPluginConfig(strings.ToUpper(name)+"_"))
+			if err != nil {
+				slog.Error("Failed to initialize notification provider", "provider", name, "error", err)
+				panic(err)
+			}
+			providersByName[name] = provider
+		}
+		providers[platform] = provider
+		slog.Info("Notification provider initialized", "platform", platform, "provider", name)
+	}
+	if len(providers) > 0 {
+		fcmService = services.NewPlatformDispatcher(fcmService, providers)
+	}
+
+	// Start the scheduled notification worker (requires a notification service to dispatch through)
+	if fcmService != nil {
+		scheduler := services.NewScheduledNotificationWorker(
+			db,
+			fcmService,
+			time.Duration(cfg.ScheduledNotificationPollIntervalSec)*time.Second,
+			cfg.ScheduledNotificationBatchSize,
+			time.Duration(cfg.ScheduledNotificationLeaseSec)*time.Second,
+		)
+		scheduler.Start()
+		workers = append(workers, scheduler)
+	}
+
+	// Start the stale device token cleaner
+	if cfg.DeviceTokenStaleDays > 0 && cfg.DeviceTokenCleanupIntervalSec > 0 {
+		cleaner := services.NewStaleDeviceTokenCleaner(
+			db,
+			time.Duration(cfg.DeviceTokenStaleDays)*24*time.Hour,
+			time.Duration(cfg.DeviceTokenCleanupIntervalSec)*time.Second,
+		)
+		cleaner.Start()
+		workers = append(workers, cleaner)
+	}
+
+	// Initialize notification receipt signer (optional)
+	var receiptSigner *services.ReceiptSigner
+	if cfg.NotificationReceiptKeyPath != "" {
+		receiptSigner, err = services.NewReceiptSignerFromFile(cfg.NotificationReceiptKeyPath)
+		if err != nil {
+			slog.Error("Failed to initialize notification receipt signer", "error", err)
+			panic(err)
+		}
+		slog.Info("Notification receipt signer initialized successfully", "key_id", receiptSigner.KeyID())
+	}
+
+	// Sender identity attached to notifications from microapps without a name or icon of their own
+	defaultSender := services.SenderIdentity{
+		Name:    cfg.NotificationDefaultSenderName,
+		IconURL: cfg.NotificationDefaultSenderIconURL,
+	}
+
 	// Initialize File Service
 	fileServiceConfig := cfg.GetFileServiceConfig()
 	fileServiceConfig["DB"] = db // Add the database connection access for default db file service (and db user service)
@@ -104,24 +220,108 @@ func NewRouter(db *gorm.DB, cfg *config.Config) http.Handler {
 		slog.Info("User Service initialized successfully", "type", cfg.UserServiceType)
 	}
 
+	// Per-microapp notification quotas; a microapp's notificationQuota config overrides the default limit
+	quota := services.NewQuotaService(db, time.Duration(cfg.NotificationQuotaPeriodSec)*time.Second,
+		cfg.NotificationQuotaDefaultLimit, services.SystemClock)
+
+	// Notification images must be assets uploaded for the sending microapp, or on an allowlisted host
+	var imagePolicy *services.NotificationImagePolicy
+	if cfg.NotificationImageRequireOwnedAsset {
+		imagePolicy = services.NewNotificationImagePolicy(db, cfg.NotificationImageAllowedHosts)
+	}
+
+	// Microapp API keys are accepted on service routes alongside OAuth client credentials
+	apiKeyAuthenticator := services.NewAPIKeyAuthenticator(db, float64(cfg.APIKeyRateLimitPerSec), cfg.APIKeyRateLimitBurst)
+
+	// What erasing a user does to each category of their data
+	erasurePolicy, err := parseErasurePolicy(cfg)
+	if err != nil {
+		slog.Error("Invalid user erasure policy", "error", err)
+		panic(err)
+	}
+
+	// Nested groups (superadmin implies admin) apply to the admin checks made with rbac.RequireRole
+	// and rbac.HasRole; the token's groups are left as the IdP sent them. The hierarchy is read once at startup.
+	groupHierarchy, err := rbac.LoadHierarchyFromDB(db)
+	if err != nil {
+		slog.Warn("Failed to load group hierarchy, nested groups are disabled", "error", err)
+	}
+	rbac.SetHierarchy(groupHierarchy)
+
 	// set up routes
 	// v1
 
 	// Public Routes (no authentication required)
+	healthHandler := handler.NewHealthHandler(db)
+	r.Get("/health/live", healthHandler.Live)
+	r.Get("/health/ready", healthHandler.Ready)
+	if cfg.MetricsEnabled {
+		r.Handle("/metrics", metrics.Handler(registry))
+	}
+
 	// Auth Router (Gateway/Public - OAuth, JWKS)
 	r.Mount("/", v1.NewNoAuthRouter(db, cfg, internalIDPValidator, fileService))
 
 	// User Authenticated Routes (validates against External IDP)
 	r.Route(userRoutesPrefix, func(r chi.Router) {
-		r.Use(auth.AuthMiddleware(externalIDPValidator))
-		r.Mount("/", v1.NewUserRouter(db, fcmService, fileService, userService, cfg))
+		r.Use(auth.AuthMiddleware(externalIDPValidator, cfg.MaxUserGroups))
+		if cfg.UserRateLimitPerSec > 0 {
+			r.Use(auth.RateLimitMiddleware(auth.NewMemoryRateLimiter(float64(cfg.UserRateLimitPerSec), cfg.UserRateLimitBurst), auth.UserRateLimitKey))
+		}
+		r.Mount("/", v1.NewUserRouter(db, fcmService, fileService, userService, erasurePolicy, cfg))
+
+		// Diagnostic endpoints (non-production only)
+		if cfg.DebugEndpointsEnabled {
+			slog.Warn("Debug endpoints enabled, do not use in production")
+			validators := make(map[string]services.JWKSCacheInspector)
+			if inspector, ok := externalIDPValidator.(services.JWKSCacheInspector); ok {
+				validators["external"] = inspector
+			}
+			if inspector, ok := internalIDPValidator.(services.JWKSCacheInspector); ok {
+				validators["internal"] = inspector
+			}
+			r.Mount("/debug", v1.DebugRoutes(validators))
+		}
 	})
 
 	// Service Routes (validates against Internal IDP)
+	if !cfg.ServiceScopesRequired {
+		slog.Warn("Service scopes are not required, any service client can send notifications")
+	}
 	r.Route(serviceRoutesPrefix, func(r chi.Router) {
-		r.Use(auth.ServiceOAuthMiddleware(internalIDPValidator))
-		r.Mount("/", v1.NewServiceRouter(db, fcmService))
+		r.Use(auth.ServiceAuthMiddleware(internalIDPValidator, apiKeyAuthenticator))
+		if cfg.ServiceRateLimitPerSec > 0 {
+			r.Use(auth.RateLimitMiddleware(auth.NewMemoryRateLimiter(float64(cfg.ServiceRateLimitPerSec), cfg.ServiceRateLimitBurst), auth.ServiceRateLimitKey))
+		}
+		r.Mount("/", v1.NewServiceRouter(db, fcmService, receiptSigner, defaultSender, quota, imagePolicy, notificationMetrics, cfg.FCMMaxTokensPerSend, cfg.ServiceScopesRequired))
 	})
 
-	return r
+	shutdown := func() {
+		for _, worker := range workers {
+			worker.Stop()
+		}
+	}
+	// The server span wraps the whole router so it covers every middleware
+	return tracing.Handler(r), shutdown
+}
+
+// parseErasurePolicy reads the delete or anonymize action configured for each data category
+func parseErasurePolicy(cfg *config.Config) (services.ErasurePolicy, error) {
+	var policy services.ErasurePolicy
+	for _, setting := range []struct {
+		value  string
+		action *services.ErasureAction
+	}{
+		{cfg.ErasureDeviceTokens, &policy.DeviceTokens},
+		{cfg.ErasureConfigs, &policy.Configs},
+		{cfg.ErasureNotificationPreferences, &policy.NotificationPreferences},
+		{cfg.ErasureNotificationLogs, &policy.NotificationLogs},
+	} {
+		action, err := services.ParseErasureAction(setting.value)
+		if err != nil {
+			return services.ErasurePolicy{}, err
+		}
+		*setting.action = action
+	}
+	return policy, nil
 }
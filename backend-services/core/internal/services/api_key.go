@@ -0,0 +1,150 @@
+// Copyright (c) 2025 WSO2 LLC. (https://www.wso2.com).
+//
+// WSO2 LLC. licenses this file to you under the Apache License,
+// Version 2.0 (the "License"); you may not use this file except
+// in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/opensuperapp/opensuperapp/backend-services/core/internal/models"
+
+	"golang.org/x/time/rate"
+	"gorm.io/gorm"
+)
+
+const (
+	// apiKeyPrefix marks a string as a SuperApp API key so leaked keys are easy to spot in scans.
+	apiKeyPrefix = "sak_"
+	// apiKeyIDLength is the number of characters after apiKeyPrefix kept as the non-secret key prefix.
+	apiKeyIDLength = 8
+	// apiKeySecretBytes is the amount of randomness in a key.
+	apiKeySecretBytes = 32
+
+	// apiKeyLastUsedInterval limits how often last_used_at is written for a busy key.
+	apiKeyLastUsedInterval = time.Minute
+)
+
+var (
+	// ErrInvalidAPIKey is returned for unknown, malformed, revoked or expired keys.
+	ErrInvalidAPIKey = errors.New("invalid API key")
+	// ErrAPIKeyRateLimited is returned when a key exceeds its request rate.
+	ErrAPIKeyRateLimited = errors.New("API key rate limit exceeded")
+)
+
+// GenerateAPIKey returns a new random key, its non-secret prefix and the hash to store.
+func GenerateAPIKey() (key, prefix, hash string, err error) {
+	secret := make([]byte, apiKeySecretBytes)
+	if _, err := rand.Read(secret); err != nil {
+		return "", "", "", fmt.Errorf("failed to generate API key: %w", err)
+	}
+	key = apiKeyPrefix + base64.RawURLEncoding.EncodeToString(secret)
+	return key, key[:len(apiKeyPrefix)+apiKeyIDLength], HashAPIKey(key), nil
+}
+
+// HashAPIKey returns the hex SHA-256 hash under which a key is stored.
+// Keys carry 256 bits of randomness, so a fast hash is sufficient.
+func HashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// APIKeyAuthenticator validates microapp API keys and applies a per-key rate limit.
+type APIKeyAuthenticator struct {
+	db    *gorm.DB
+	limit rate.Limit
+	burst int
+	clock Clock
+
+	mu       sync.Mutex
+	limiters map[int64]*rate.Limiter
+	lastUsed map[int64]time.Time
+}
+
+// NewAPIKeyAuthenticator creates an authenticator allowing requestsPerSecond per key with the given burst.
+func NewAPIKeyAuthenticator(db *gorm.DB, requestsPerSecond float64, burst int) *APIKeyAuthenticator {
+	return &APIKeyAuthenticator{
+		db:       db,
+		limit:    rate.Limit(requestsPerSecond),
+		burst:    burst,
+		clock:    SystemClock,
+		limiters: make(map[int64]*rate.Limiter),
+		lastUsed: make(map[int64]time.Time),
+	}
+}
+
+// Authenticate resolves an API key to its stored record. Revoked and expired keys are
+// rejected immediately because every request is checked against the database.
+func (a *APIKeyAuthenticator) Authenticate(ctx context.Context, key string) (*models.MicroAppAPIKey, error) {
+	if !strings.HasPrefix(key, apiKeyPrefix) {
+		return nil, ErrInvalidAPIKey
+	}
+
+	var apiKey models.MicroAppAPIKey
+	if err := a.db.WithContext(ctx).
+		Where("key_hash = ? AND revoked_at IS NULL", HashAPIKey(key)).
+		First(&apiKey).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrInvalidAPIKey
+		}
+		return nil, fmt.Errorf("failed to look up API key: %w", err)
+	}
+
+	now := a.clock.Now()
+	if apiKey.ExpiresAt != nil && !now.Before(*apiKey.ExpiresAt) {
+		return nil, ErrInvalidAPIKey
+	}
+	allowed, touch := a.admit(apiKey.ID, now)
+	if !allowed {
+		return nil, ErrAPIKeyRateLimited
+	}
+	if touch {
+		if err := a.db.WithContext(ctx).Model(&models.MicroAppAPIKey{}).
+			Where("id = ?", apiKey.ID).
+			Update("last_used_at", now).Error; err != nil {
+			slog.Warn("Failed to record API key use", "error", err, "key_prefix", apiKey.KeyPrefix)
+		}
+	}
+	return &apiKey, nil
+}
+
+// admit applies the rate limit for a key and reports whether last_used_at is due to be written.
+func (a *APIKeyAuthenticator) admit(keyID int64, now time.Time) (allowed bool, touch bool) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	limiter, ok := a.limiters[keyID]
+	if !ok {
+		limiter = rate.NewLimiter(a.limit, a.burst)
+		a.limiters[keyID] = limiter
+	}
+	if !limiter.AllowN(now, 1) {
+		return false, false
+	}
+	if now.Sub(a.lastUsed[keyID]) < apiKeyLastUsedInterval {
+		return true, false
+	}
+	a.lastUsed[keyID] = now
+	return true, true
+}
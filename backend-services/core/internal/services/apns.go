@@ -0,0 +1,316 @@
+// Copyright (c) 2025 WSO2 LLC. (https://www.wso2.com).
+//
+// WSO2 LLC. licenses this file to you under the Apache License,
+// Version 2.0 (the "License"); you may not use this file except
+// in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+const (
+	// ProviderAPNS is the registry name of the direct Apple Push Notification service provider.
+	ProviderAPNS = "apns"
+
+	apnsProductionURL = "https://api.push.apple.com"
+	apnsSandboxURL    = "https://api.sandbox.push.apple.com"
+
+	// apnsTokenRefreshInterval is how long a provider token is reused. Apple rejects tokens
+	// older than one hour and throttles providers that refresh more than every 20 minutes.
+	apnsTokenRefreshInterval = 50 * time.Minute
+
+	// apnsConcurrency bounds the number of in-flight requests; APNs accepts one token per request.
+	apnsConcurrency = 10
+
+	apnsRequestTimeout = 10 * time.Second
+)
+
+// ErrTopicsNotSupported is returned by providers that have no topic messaging.
+var ErrTopicsNotSupported = errors.New("topic messaging is not supported by this notification provider")
+
+// apnsDeadTokenReasons are the APNs rejection reasons that mean the token will never be valid again.
+var apnsDeadTokenReasons = map[string]bool{
+	"BadDeviceToken":         true,
+	"Unregistered":           true,
+	"DeviceTokenNotForTopic": true,
+}
+
+// APNSService delivers notifications directly to Apple devices over the APNs HTTP/2 API
+// using token-based (.p8 key) authentication.
+type APNSService struct {
+	httpClient *http.Client
+	baseURL    string
+	topic      string // App bundle ID
+	keyID      string
+	teamID     string
+	signingKey *ecdsa.PrivateKey
+	clock      Clock
+
+	mu          sync.Mutex
+	authToken   string
+	authTokenAt time.Time
+}
+
+func init() {
+	providerRegistry.Register(ProviderAPNS, NewAPNSServiceFromConfig)
+}
+
+// NewAPNSServiceFromConfig creates an APNSService from APNS_* configuration values.
+func NewAPNSServiceFromConfig(config map[string]any) (NotificationService, error) {
+	keyPath, _ := config["APNS_KEY_PATH"].(string)
+	keyID, _ := config["APNS_KEY_ID"].(string)
+	teamID, _ := config["APNS_TEAM_ID"].(string)
+	topic, _ := config["APNS_TOPIC"].(string)
+	if keyPath == "" || keyID == "" || teamID == "" || topic == "" {
+		return nil, fmt.Errorf("APNSService: APNS_KEY_PATH, APNS_KEY_ID, APNS_TEAM_ID and APNS_TOPIC are required")
+	}
+	production, _ := strconv.ParseBool(fmt.Sprint(config["APNS_PRODUCTION"]))
+
+	keyBytes, err := os.ReadFile(keyPath)
+	if err != nil {
+		return nil, fmt.Errorf("APNSService: failed to read signing key: %w", err)
+	}
+	signingKey, err := jwt.ParseECPrivateKeyFromPEM(keyBytes)
+	if err != nil {
+		return nil, fmt.Errorf("APNSService: failed to parse signing key: %w", err)
+	}
+
+	baseURL := apnsSandboxURL
+	if production {
+		baseURL = apnsProductionURL
+	}
+	slog.Info("Initializing APNSService", "topic", topic, "production", production)
+	return NewAPNSService(baseURL, topic, keyID, teamID, signingKey), nil
+}
+
+// NewAPNSService creates an APNSService that sends to the given APNs endpoint.
+func NewAPNSService(baseURL, topic, keyID, teamID string, signingKey *ecdsa.PrivateKey) *APNSService {
+	return &APNSService{
+		// net/http negotiates HTTP/2 over TLS, which APNs requires
+		httpClient: &http.Client{Timeout: apnsRequestTimeout},
+		baseURL:    baseURL,
+		topic:      topic,
+		keyID:      keyID,
+		teamID:     teamID,
+		signingKey: signingKey,
+		clock:      SystemClock,
+	}
+}
+
+// apnsResult is the outcome of sending to a single device token.
+type apnsResult struct {
+	token string
+	err   error
+	dead  bool
+}
+
+// SendMulticastNotification sends the notification to each token and reduces the per-token
+// results of SendMulticastDetailed to counts.
+func (s *APNSService) SendMulticastNotification(ctx context.Context, tokens []string, title string, body string, data map[string]string) (int, int, []string, error) {
+	results, err := s.SendMulticastDetailed(ctx, tokens, title, body, data)
+	successCount, failureCount, deadTokens := SummarizeDeliveryResults(results)
+	return successCount, failureCount, deadTokens, err
+}
+
+// SendMulticastDetailed sends the notification to each token with bounded concurrency and
+// returns one result per unique token, in the order the tokens were given.
+// APNs has no multicast endpoint, so every token is a separate HTTP/2 request.
+func (s *APNSService) SendMulticastDetailed(ctx context.Context, tokens []string, title string, body string, data map[string]string) ([]DeliveryResult, error) {
+	tokens = uniqueTokens(tokens)
+	if len(tokens) == 0 {
+		return nil, nil
+	}
+
+	payload, err := buildAPNSPayload(title, body, data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build APNs payload: %w", err)
+	}
+	authToken, err := s.providerToken()
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign APNs provider token: %w", err)
+	}
+
+	headers := apnsHeaders(data, s.clock.Now())
+
+	jobs := make(chan string)
+	results := make(chan apnsResult, len(tokens))
+	var wg sync.WaitGroup
+	for i := 0; i < min(apnsConcurrency, len(tokens)); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for token := range jobs {
+				dead, err := s.send(ctx, authToken, token, payload, headers)
+				results <- apnsResult{token: token, err: err, dead: dead}
+			}
+		}()
+	}
+	for _, token := range tokens {
+		jobs <- token
+	}
+	close(jobs)
+	wg.Wait()
+	close(results)
+
+	byToken := make(map[string]DeliveryResult, len(tokens))
+	delivered := false
+	for result := range results {
+		if result.err == nil {
+			byToken[result.token] = DeliveryResult{Token: result.token, Success: true}
+			delivered = true
+			continue
+		}
+		byToken[result.token] = DeliveryResult{
+			Token:        result.token,
+			Error:        result.err.Error(),
+			Retryable:    !result.dead && isRetryableAPNSError(result.err),
+			Unregistered: result.dead,
+		}
+		slog.Warn("APNs delivery failed", "error", result.err, "token_prefix", tokenPrefix(result.token))
+	}
+
+	ordered := make([]DeliveryResult, len(tokens))
+	for i, token := range tokens {
+		ordered[i] = byToken[token]
+	}
+	if ctx.Err() != nil && !delivered {
+		return ordered, ctx.Err()
+	}
+	return ordered, nil
+}
+
+// send posts the payload to a single device and reports whether the token is permanently invalid.
+// headers carries the per-notification APNs headers, such as apns-collapse-id and apns-expiration.
+func (s *APNSService) send(ctx context.Context, authToken, token string, payload []byte, headers map[string]string) (bool, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.baseURL+"/3/device/"+token, bytes.NewReader(payload))
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("authorization", "bearer "+authToken)
+	req.Header.Set("apns-topic", s.topic)
+	req.Header.Set("apns-push-type", "alert")
+	req.Header.Set("apns-priority", "10")
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+	req.Header.Set("content-type", "application/json")
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusOK {
+		return false, nil
+	}
+	var apnsErr struct {
+		Reason string `json:"reason"`
+	}
+	_ = json.NewDecoder(io.LimitReader(resp.Body, 4096)).Decode(&apnsErr)
+	dead := resp.StatusCode == http.StatusGone || apnsDeadTokenReasons[apnsErr.Reason]
+	return dead, &apnsStatusError{status: resp.StatusCode, reason: apnsErr.Reason}
+}
+
+// apnsStatusError is a non-200 response from APNs.
+type apnsStatusError struct {
+	status int
+	reason string
+}
+
+func (e *apnsStatusError) Error() string {
+	return fmt.Sprintf("APNs returned status %d: %s", e.status, e.reason)
+}
+
+// isRetryableAPNSError reports whether a failed send may succeed later: transport errors,
+// throttling and server errors are transient, other rejections are not.
+func isRetryableAPNSError(err error) bool {
+	var statusErr *apnsStatusError
+	if !errors.As(err, &statusErr) {
+		return true
+	}
+	return statusErr.status == http.StatusTooManyRequests || statusErr.status >= http.StatusInternalServerError
+}
+
+// providerToken returns the cached ES256 provider token, signing a new one when it is due for refresh.
+func (s *APNSService) providerToken() (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.clock.Now()
+	if s.authToken != "" && now.Sub(s.authTokenAt) < apnsTokenRefreshInterval {
+		return s.authToken, nil
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodES256, jwt.RegisteredClaims{
+		Issuer:   s.teamID,
+		IssuedAt: jwt.NewNumericDate(now),
+	})
+	token.Header["kid"] = s.keyID
+	signed, err := token.SignedString(s.signingKey)
+	if err != nil {
+		return "", err
+	}
+	s.authToken = signed
+	s.authTokenAt = now
+	return signed, nil
+}
+
+// buildAPNSPayload builds the APNs JSON body. Custom data keys are placed next to the aps dictionary.
+func buildAPNSPayload(title, body string, data map[string]string) ([]byte, error) {
+	aps := map[string]any{
+		"alert": map[string]string{"title": title, "body": body},
+		"sound": "default",
+		"badge": notificationBadge(data),
+	}
+	if notificationImage(data) != "" {
+		aps["mutable-content"] = 1
+	}
+
+	payload := make(map[string]any, len(data)+1)
+	for k, v := range data {
+		payload[k] = v
+	}
+	payload["aps"] = aps
+	return json.Marshal(payload)
+}
+
+// SendToTopic is not supported by APNs.
+func (s *APNSService) SendToTopic(ctx context.Context, topic string, title string, body string, data map[string]string) (string, error) {
+	return "", ErrTopicsNotSupported
+}
+
+// SubscribeToTopic is not supported by APNs.
+func (s *APNSService) SubscribeToTopic(ctx context.Context, tokens []string, topic string) (int, int, error) {
+	return 0, 0, ErrTopicsNotSupported
+}
+
+// UnsubscribeFromTopic is not supported by APNs.
+func (s *APNSService) UnsubscribeFromTopic(ctx context.Context, tokens []string, topic string) (int, int, error) {
+	return 0, 0, ErrTopicsNotSupported
+}
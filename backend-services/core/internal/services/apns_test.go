@@ -0,0 +1,155 @@
+// Copyright (c) 2025 WSO2 LLC. (https://www.wso2.com).
+//
+// WSO2 LLC. licenses this file to you under the Apache License,
+// Version 2.0 (the "License"); you may not use this file except
+// in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+package services
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/opensuperapp/opensuperapp/backend-services/core/internal/clocktest"
+)
+
+// TestAPNSService_SendMulticastNotification tests per-token outcomes, dead token detection and request headers
+func TestAPNSService_SendMulticastNotification(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+
+	var mu sync.Mutex
+	var payloads []map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("apns-topic") != "com.example.superapp" || r.Header.Get("apns-push-type") != "alert" {
+			t.Errorf("Unexpected APNs headers: %v", r.Header)
+		}
+		if r.Header.Get("apns-collapse-id") != "payday" || r.Header.Get("apns-expiration") != "0" {
+			t.Errorf("Expected collapse and expiration headers, got %v", r.Header)
+		}
+		authToken, err := jwt.Parse(strings.TrimPrefix(r.Header.Get("authorization"), "bearer "), func(token *jwt.Token) (interface{}, error) {
+			return &key.PublicKey, nil
+		})
+		if err != nil || authToken.Header["kid"] != "KEY123" {
+			t.Errorf("Invalid provider token: %v", err)
+		}
+
+		var payload map[string]any
+		json.NewDecoder(r.Body).Decode(&payload)
+		mu.Lock()
+		payloads = append(payloads, payload)
+		mu.Unlock()
+
+		switch strings.TrimPrefix(r.URL.Path, "/3/device/") {
+		case "gone":
+			w.WriteHeader(http.StatusGone)
+			w.Write([]byte(`{"reason":"Unregistered"}`))
+		case "bad":
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"reason":"BadDeviceToken"}`))
+		case "busy":
+			w.WriteHeader(http.StatusTooManyRequests)
+			w.Write([]byte(`{"reason":"TooManyRequests"}`))
+		}
+	}))
+	defer server.Close()
+
+	s := NewAPNSService(server.URL, "com.example.superapp", "KEY123", "TEAM123", key)
+	success, failed, dead, err := s.SendMulticastNotification(context.Background(),
+		[]string{"good", "gone", "bad", "busy"}, "Title", "Body", map[string]string{"microappId": "payroll", DataKeyCollapseKey: "payday", DataKeyTTL: "0"})
+	if err != nil {
+		t.Fatalf("SendMulticastNotification failed: %v", err)
+	}
+
+	if success != 1 || failed != 3 {
+		t.Errorf("Expected 1 success and 3 failed, got %d and %d", success, failed)
+	}
+	deadSet := map[string]bool{}
+	for _, token := range dead {
+		deadSet[token] = true
+	}
+	if len(dead) != 2 || !deadSet["gone"] || !deadSet["bad"] {
+		t.Errorf("Expected gone and bad to be dead, got %v", dead)
+	}
+
+	payload := payloads[0]
+	if payload["microappId"] != "payroll" {
+		t.Errorf("Expected custom data next to aps, got %v", payload)
+	}
+	alert := payload["aps"].(map[string]any)["alert"].(map[string]any)
+	if alert["title"] != "Title" || alert["body"] != "Body" {
+		t.Errorf("Unexpected alert: %v", alert)
+	}
+}
+
+// TestAPNSService_ProviderTokenRefresh tests that the provider token is reused until the refresh interval passes
+func TestAPNSService_ProviderTokenRefresh(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+	clock := clocktest.NewFakeClock(time.Date(2025, 1, 15, 10, 0, 0, 0, time.UTC))
+	s := NewAPNSService("https://apns.invalid", "com.example.superapp", "KEY123", "TEAM123", key)
+	s.clock = clock
+
+	first, err := s.providerToken()
+	if err != nil {
+		t.Fatalf("providerToken failed: %v", err)
+	}
+	clock.Advance(apnsTokenRefreshInterval - time.Minute)
+	if second, _ := s.providerToken(); second != first {
+		t.Error("Expected the provider token to be reused within the refresh interval")
+	}
+	clock.Advance(time.Minute)
+	if third, _ := s.providerToken(); third == first {
+		t.Error("Expected a new provider token after the refresh interval")
+	}
+}
+
+// TestAPNSService_TopicsNotSupported tests that topic operations report they are unsupported
+func TestAPNSService_TopicsNotSupported(t *testing.T) {
+	s := &APNSService{}
+	if _, err := s.SendToTopic(context.Background(), "news", "Title", "Body", nil); err != ErrTopicsNotSupported {
+		t.Errorf("Expected ErrTopicsNotSupported, got %v", err)
+	}
+}
+
+// TestBuildAPNSPayload_Badge tests that the direct APNs payload carries the caller's badge count
+func TestBuildAPNSPayload_Badge(t *testing.T) {
+	raw, err := buildAPNSPayload("Title", "Body", map[string]string{DataKeyBadge: "5"})
+	if err != nil {
+		t.Fatalf("Failed to build payload: %v", err)
+	}
+	var payload struct {
+		Aps struct {
+			Badge int `json:"badge"`
+		} `json:"aps"`
+	}
+	if err := json.Unmarshal(raw, &payload); err != nil {
+		t.Fatalf("Failed to decode payload: %v", err)
+	}
+	if payload.Aps.Badge != 5 {
+		t.Errorf("Expected badge 5, got %d", payload.Aps.Badge)
+	}
+}
@@ -0,0 +1,145 @@
+// Copyright (c) 2025 WSO2 LLC. (https://www.wso2.com).
+//
+// WSO2 LLC. licenses this file to you under the Apache License,
+// Version 2.0 (the "License"); you may not use this file except
+// in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+package services
+
+import (
+	"errors"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen is returned instead of calling FCM while the circuit breaker is open
+var ErrCircuitOpen = errors.New("fcm circuit breaker is open")
+
+// CircuitState is the state of a CircuitBreaker
+type CircuitState int
+
+const (
+	// CircuitClosed lets every call through
+	CircuitClosed CircuitState = iota
+	// CircuitOpen rejects every call until the reset timeout has passed
+	CircuitOpen
+	// CircuitHalfOpen lets a single probe call through to test whether FCM has recovered
+	CircuitHalfOpen
+)
+
+func (s CircuitState) String() string {
+	switch s {
+	case CircuitOpen:
+		return "open"
+	case CircuitHalfOpen:
+		return "half-open"
+	default:
+		return "closed"
+	}
+}
+
+// CircuitBreaker fails calls fast during a sustained outage. It opens after threshold
+// consecutive failures, and once resetTimeout has passed lets one probe through: a
+// successful probe closes it again and a failed one reopens it.
+//
+// A nil *CircuitBreaker is valid and never opens.
+type CircuitBreaker struct {
+	threshold    int
+	resetTimeout time.Duration
+	clock        Clock
+
+	mu       sync.Mutex
+	state    CircuitState
+	failures int       // consecutive failures while closed
+	openedAt time.Time // when the breaker last opened
+	probing  bool      // a half-open probe is in flight
+}
+
+// NewCircuitBreaker creates a closed CircuitBreaker. The clock decides when the reset timeout has passed.
+func NewCircuitBreaker(threshold int, resetTimeout time.Duration, clock Clock) *CircuitBreaker {
+	if threshold < 1 {
+		threshold = 1
+	}
+	return &CircuitBreaker{threshold: threshold, resetTimeout: resetTimeout, clock: clock}
+}
+
+// Allow reports whether a call may go ahead, returning ErrCircuitOpen when it may not.
+// Every allowed call must be followed by RecordSuccess or RecordFailure.
+func (b *CircuitBreaker) Allow() error {
+	if b == nil {
+		return nil
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	switch b.state {
+	case CircuitOpen:
+		if b.clock.Now().Sub(b.openedAt) < b.resetTimeout {
+			return ErrCircuitOpen
+		}
+		b.state = CircuitHalfOpen
+		b.probing = true
+		slog.Info("FCM circuit breaker half-open, sending probe")
+		return nil
+	case CircuitHalfOpen:
+		if b.probing {
+			return ErrCircuitOpen
+		}
+		b.probing = true
+		return nil
+	default:
+		return nil
+	}
+}
+
+// RecordSuccess closes the breaker and clears the failure count
+func (b *CircuitBreaker) RecordSuccess() {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.state != CircuitClosed {
+		slog.Info("FCM circuit breaker closed")
+	}
+	b.state = CircuitClosed
+	b.failures = 0
+	b.probing = false
+}
+
+// RecordFailure counts a failed call, opening the breaker at the threshold or when a probe fails
+func (b *CircuitBreaker) RecordFailure() {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.failures++
+	if b.state == CircuitHalfOpen || b.failures >= b.threshold {
+		if b.state != CircuitOpen {
+			slog.Warn("FCM circuit breaker opened", "consecutive_failures", b.failures, "reset_timeout", b.resetTimeout)
+		}
+		b.state = CircuitOpen
+		b.openedAt = b.clock.Now()
+		b.probing = false
+	}
+}
+
+// State returns the current state of the breaker
+func (b *CircuitBreaker) State() CircuitState {
+	if b == nil {
+		return CircuitClosed
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.state
+}
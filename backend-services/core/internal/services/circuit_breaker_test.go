@@ -0,0 +1,54 @@
+// Copyright (c) 2025 WSO2 LLC. (https://www.wso2.com).
+//
+// WSO2 LLC. licenses this file to you under the Apache License,
+// Version 2.0 (the "License"); you may not use this file except
+// in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/opensuperapp/opensuperapp/backend-services/core/internal/clocktest"
+)
+
+// TestCircuitBreaker_FailedProbeReopens tests that a failed half-open probe reopens the breaker for another reset timeout
+func TestCircuitBreaker_FailedProbeReopens(t *testing.T) {
+	clock := clocktest.NewFakeClock(time.Date(2025, 1, 15, 10, 0, 0, 0, time.UTC))
+	b := NewCircuitBreaker(1, time.Minute, clock)
+
+	b.RecordFailure()
+	if b.State() != CircuitOpen {
+		t.Fatalf("Expected the breaker to open at the threshold, got %s", b.State())
+	}
+
+	clock.Advance(time.Minute)
+	if err := b.Allow(); err != nil {
+		t.Fatalf("Expected a probe to be allowed after the reset timeout, got %v", err)
+	}
+	if err := b.Allow(); err != ErrCircuitOpen {
+		t.Errorf("Expected only one probe while half-open, got %v", err)
+	}
+
+	b.RecordFailure()
+	clock.Advance(30 * time.Second)
+	if err := b.Allow(); err != ErrCircuitOpen {
+		t.Errorf("Expected the breaker to stay open after a failed probe, got %v", err)
+	}
+
+	var disabled *CircuitBreaker
+	disabled.RecordFailure()
+	if err := disabled.Allow(); err != nil || disabled.State() != CircuitClosed {
+		t.Error("Expected a nil breaker to never open")
+	}
+}
@@ -0,0 +1,76 @@
+// Copyright (c) 2025 WSO2 LLC. (https://www.wso2.com).
+//
+// WSO2 LLC. licenses this file to you under the Apache License,
+// Version 2.0 (the "License"); you may not use this file except
+// in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+package services
+
+import (
+	"bytes"
+	"encoding/json"
+	"time"
+
+	"github.com/opensuperapp/opensuperapp/backend-services/core/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// RecordConfigConflict is called before a micro app config upsert. When the upsert would replace
+// a value that a different user set less than window ago, the old and new values are written to
+// the conflict log. The upsert itself still goes ahead (last write wins); the log only surfaces
+// the overwrite for review. It reports whether a conflict was recorded.
+func RecordConfigConflict(db *gorm.DB, appID, configKey string, newValue json.RawMessage, updater string, window time.Duration, now time.Time) (bool, error) {
+	if window <= 0 {
+		return false, nil
+	}
+	var existing []models.MicroAppConfig
+	if err := db.Where("micro_app_id = ? AND config_key = ?", appID, configKey).Limit(1).Find(&existing).Error; err != nil {
+		return false, err
+	}
+	if len(existing) == 0 {
+		return false, nil
+	}
+	current := existing[0]
+
+	previousUpdater := current.CreatedBy
+	if current.UpdatedBy != nil {
+		previousUpdater = *current.UpdatedBy
+	}
+	if previousUpdater == updater || now.Sub(current.UpdatedAt) >= window || sameJSON(current.ConfigValue, newValue) {
+		return false, nil
+	}
+
+	conflict := models.MicroAppConfigConflict{
+		MicroAppID:   appID,
+		ConfigKey:    configKey,
+		OldValue:     current.ConfigValue,
+		NewValue:     newValue,
+		OldUpdatedBy: previousUpdater,
+		OldUpdatedAt: current.UpdatedAt,
+		NewUpdatedBy: updater,
+		DetectedAt:   now,
+	}
+	if err := db.Create(&conflict).Error; err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// sameJSON reports whether two JSON documents are identical apart from insignificant whitespace.
+func sameJSON(a, b json.RawMessage) bool {
+	var compactA, compactB bytes.Buffer
+	if json.Compact(&compactA, a) != nil || json.Compact(&compactB, b) != nil {
+		return bytes.Equal(a, b)
+	}
+	return bytes.Equal(compactA.Bytes(), compactB.Bytes())
+}
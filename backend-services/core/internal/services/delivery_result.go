@@ -0,0 +1,91 @@
+// Copyright (c) 2025 WSO2 LLC. (https://www.wso2.com).
+//
+// WSO2 LLC. licenses this file to you under the Apache License,
+// Version 2.0 (the "License"); you may not use this file except
+// in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+package services
+
+import (
+	"context"
+	"errors"
+
+	"github.com/opensuperapp/opensuperapp/backend-services/core/internal/models"
+)
+
+// errTokenNotAttempted is the error reported for a token the send stopped before reaching.
+const errTokenNotAttempted = "not attempted"
+
+// ErrDetailedResultsNotSupported is returned when a notification service only reports
+// aggregate counts and cannot describe the outcome of each token.
+var ErrDetailedResultsNotSupported = errors.New("notification service does not report per-token results")
+
+// DeliveryResult is the outcome of sending a notification to a single device token.
+type DeliveryResult struct {
+	Token        string
+	Success      bool
+	Error        string // Last error reported for the token; empty on success
+	Retryable    bool   // The failure was transient and a later send may succeed
+	Unregistered bool   // The provider reported the token as no longer valid
+	MessageID    string // Provider message ID of a successful send, when the provider reports one
+	Dropped      bool   // The token was beyond the per-send token limit and was not sent to
+}
+
+// SummarizeDeliveryResults reduces per-token results to success and failure counts and
+// the tokens that are no longer registered. Dropped tokens count as failures.
+func SummarizeDeliveryResults(results []DeliveryResult) (int, int, []string) {
+	successCount, failureCount := 0, 0
+	var deadTokens []string
+	for _, result := range results {
+		if result.Success {
+			successCount++
+			continue
+		}
+		failureCount++
+		if result.Unregistered {
+			deadTokens = append(deadTokens, result.Token)
+		}
+	}
+	return successCount, failureCount, deadTokens
+}
+
+// SendToDevicesDetailed is the per-token counterpart of SendToDevices. It returns
+// ErrDetailedResultsNotSupported, without sending anything, when the service or one of
+// the platform providers it would use only reports aggregate counts.
+func SendToDevicesDetailed(ctx context.Context, service NotificationService, devices []models.DeviceToken, title string, body string, data map[string]string) ([]DeliveryResult, error) {
+	if dispatcher, ok := service.(PlatformNotificationService); ok {
+		tokensByPlatform := make(map[string][]string)
+		for _, device := range devices {
+			tokensByPlatform[device.Platform] = append(tokensByPlatform[device.Platform], device.DeviceToken)
+		}
+		return dispatcher.SendDetailedByPlatform(ctx, tokensByPlatform, title, body, data)
+	}
+
+	detailed, ok := service.(DetailedNotificationService)
+	if !ok {
+		return nil, ErrDetailedResultsNotSupported
+	}
+	tokens := make([]string, len(devices))
+	for i, device := range devices {
+		tokens[i] = device.DeviceToken
+	}
+	return detailed.SendMulticastDetailed(ctx, tokens, title, body, data)
+}
+
+// failedResults reports every token as failed with the given error.
+func failedResults(tokens []string, err error) []DeliveryResult {
+	results := make([]DeliveryResult, len(tokens))
+	for i, token := range tokens {
+		results[i] = DeliveryResult{Token: token, Error: err.Error()}
+	}
+	return results
+}
@@ -0,0 +1,104 @@
+// Copyright (c) 2025 WSO2 LLC. (https://www.wso2.com).
+//
+// WSO2 LLC. licenses this file to you under the Apache License,
+// Version 2.0 (the "License"); you may not use this file except
+// in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+package services
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/opensuperapp/opensuperapp/backend-services/core/internal/models"
+	"gorm.io/gorm"
+)
+
+// staleTokenCleanupTimeout bounds the time spent on a single cleanup run.
+const staleTokenCleanupTimeout = 5 * time.Minute
+
+// DeactivateStaleDeviceTokens sets is_active = false on active tokens that have not been
+// registered since the cutoff. It is a single UPDATE, so concurrent runs on several
+// replicas only deactivate each row once.
+func DeactivateStaleDeviceTokens(ctx context.Context, db *gorm.DB, cutoff time.Time) (int64, error) {
+	result := db.WithContext(ctx).Model(&models.DeviceToken{}).
+		Where("is_active = ? AND last_seen_at < ?", true, cutoff).
+		Update("is_active", false)
+	return result.RowsAffected, result.Error
+}
+
+// StaleDeviceTokenCleaner periodically deactivates device tokens whose last_seen_at is older
+// than maxAge. Apps re-register their token on start, so a token that has not been seen for
+// months almost certainly belongs to an uninstalled app.
+type StaleDeviceTokenCleaner struct {
+	db        *gorm.DB
+	maxAge    time.Duration
+	interval  time.Duration
+	done      chan struct{}
+	closeOnce sync.Once
+	wg        sync.WaitGroup
+}
+
+// NewStaleDeviceTokenCleaner creates a cleaner that runs every interval and deactivates tokens
+// not seen within maxAge.
+func NewStaleDeviceTokenCleaner(db *gorm.DB, maxAge, interval time.Duration) *StaleDeviceTokenCleaner {
+	return &StaleDeviceTokenCleaner{
+		db:       db,
+		maxAge:   maxAge,
+		interval: interval,
+		done:     make(chan struct{}),
+	}
+}
+
+// Start runs a cleanup immediately and then every interval in a background goroutine.
+func (c *StaleDeviceTokenCleaner) Start() {
+	slog.Info("Starting stale device token cleaner", "max_age", c.maxAge, "interval", c.interval)
+	c.wg.Add(1)
+	go c.run()
+}
+
+// Stop stops the cleaner and waits for a running cleanup to finish.
+func (c *StaleDeviceTokenCleaner) Stop() {
+	c.closeOnce.Do(func() { close(c.done) })
+	c.wg.Wait()
+}
+
+func (c *StaleDeviceTokenCleaner) run() {
+	defer c.wg.Done()
+	ticker := time.NewTicker(c.interval)
+	defer ticker.Stop()
+
+	c.cleanup()
+	for {
+		select {
+		case <-c.done:
+			return
+		case <-ticker.C:
+			c.cleanup()
+		}
+	}
+}
+
+// cleanup deactivates the tokens that are stale as of now and logs how many there were.
+func (c *StaleDeviceTokenCleaner) cleanup() {
+	ctx, cancel := context.WithTimeout(context.Background(), staleTokenCleanupTimeout)
+	defer cancel()
+
+	deactivated, err := DeactivateStaleDeviceTokens(ctx, c.db, time.Now().Add(-c.maxAge))
+	if err != nil {
+		slog.Error("Failed to deactivate stale device tokens", "error", err)
+		return
+	}
+	slog.Info("Deactivated stale device tokens", "count", deactivated, "max_age", c.maxAge)
+}
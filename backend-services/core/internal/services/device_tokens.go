@@ -0,0 +1,70 @@
+// Copyright (c) 2025 WSO2 LLC. (https://www.wso2.com).
+//
+// WSO2 LLC. licenses this file to you under the Apache License,
+// Version 2.0 (the "License"); you may not use this file except
+// in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+package services
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/opensuperapp/opensuperapp/backend-services/core/internal/models"
+	"gorm.io/gorm"
+)
+
+// DeactivateDeviceTokens sets is_active = false on the device_tokens rows holding exactly these tokens.
+// It is used to prune tokens FCM reported as unregistered, so they are not sent to again.
+func DeactivateDeviceTokens(ctx context.Context, db *gorm.DB, tokens []string) (int64, error) {
+	if len(tokens) == 0 {
+		return 0, nil
+	}
+	result := db.WithContext(ctx).Model(&models.DeviceToken{}).
+		Where("device_token IN ? AND is_active = ?", tokens, true).
+		Update("is_active", false)
+	return result.RowsAffected, result.Error
+}
+
+// DeviceTokenDeactivator is the default InvalidTokenHandler. It deactivates the device_tokens
+// rows of the invalid tokens so they are not sent to again.
+type DeviceTokenDeactivator struct {
+	db *gorm.DB
+}
+
+// NewDeviceTokenDeactivator creates an InvalidTokenHandler backed by the device_tokens table.
+func NewDeviceTokenDeactivator(db *gorm.DB) *DeviceTokenDeactivator {
+	return &DeviceTokenDeactivator{db: db}
+}
+
+// HandleInvalidTokens deactivates the given tokens in one bulk update.
+func (d *DeviceTokenDeactivator) HandleInvalidTokens(ctx context.Context, tokens []string) error {
+	deactivated, err := DeactivateDeviceTokens(ctx, d.db, tokens)
+	if err != nil {
+		return err
+	}
+	if deactivated > 0 {
+		slog.Info("Deactivated dead device tokens", "count", deactivated)
+	}
+	return nil
+}
+
+// PruneInvalidTokens passes device tokens a provider reported as no longer registered to handler.
+// Failures are logged and do not fail the send, since the notifications were already delivered.
+func PruneInvalidTokens(ctx context.Context, handler InvalidTokenHandler, tokens []string) {
+	if len(tokens) == 0 {
+		return
+	}
+	if err := handler.HandleInvalidTokens(ctx, tokens); err != nil {
+		slog.ErrorContext(ctx, "Failed to handle dead device tokens", "error", err, "count", len(tokens))
+	}
+}
@@ -0,0 +1,124 @@
+// Copyright (c) 2025 WSO2 LLC. (https://www.wso2.com).
+//
+// WSO2 LLC. licenses this file to you under the Apache License,
+// Version 2.0 (the "License"); you may not use this file except
+// in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/opensuperapp/opensuperapp/backend-services/core/internal/models"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// TestDeviceTokenDeactivator_AfterFCMSend tests that tokens FCM rejects as unregistered are deactivated
+// while tokens that failed for other reasons stay active
+func TestDeviceTokenDeactivator_AfterFCMSend(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("Failed to open test database: %v", err)
+	}
+	// device_tokens uses a MySQL enum column, which SQLite cannot parse, so it is created by hand
+	if err := db.Exec(`CREATE TABLE device_tokens (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		user_email VARCHAR(255) NOT NULL,
+		device_token TEXT NOT NULL,
+		platform VARCHAR(10) NOT NULL,
+		app_build INTEGER,
+		last_seen_at DATETIME,
+		created_at DATETIME,
+		updated_at DATETIME,
+		is_active BOOLEAN NOT NULL DEFAULT 1
+	)`).Error; err != nil {
+		t.Fatalf("Failed to create device_tokens table: %v", err)
+	}
+	devices := []models.DeviceToken{
+		{UserEmail: "alice@example.com", DeviceToken: "live", Platform: "android", IsActive: true},
+		{UserEmail: "alice@example.com", DeviceToken: "unregistered", Platform: "android", IsActive: true},
+		{UserEmail: "bob@example.com", DeviceToken: "invalid", Platform: "ios", IsActive: true},
+		{UserEmail: "bob@example.com", DeviceToken: "mismatch", Platform: "ios", IsActive: true},
+	}
+	if err := db.Create(&devices).Error; err != nil {
+		t.Fatalf("Failed to seed device tokens: %v", err)
+	}
+
+	client := &scriptedMessagingClient{calls: []map[string]error{{
+		"unregistered": errors.New("registration-token-not-registered"),
+		"invalid":      errors.New("invalid-registration-token"),
+		"mismatch":     errors.New("sender-id-mismatch"),
+	}}}
+	fcm := &FCMService{client: client, clock: SystemClock}
+	_, _, deadTokens, err := SendToDevices(context.Background(), fcm, devices, "Title", "Body", nil)
+	if err != nil {
+		t.Fatalf("SendToDevices failed: %v", err)
+	}
+	if err := NewDeviceTokenDeactivator(db).HandleInvalidTokens(context.Background(), deadTokens); err != nil {
+		t.Fatalf("HandleInvalidTokens failed: %v", err)
+	}
+
+	var active []string
+	db.Model(&models.DeviceToken{}).Where("is_active = ?", true).Order("id").Pluck("device_token", &active)
+	if len(active) != 2 || active[0] != "live" || active[1] != "mismatch" {
+		t.Errorf("Expected only live and mismatch to remain active, got %v", active)
+	}
+}
+
+// TestDeactivateStaleDeviceTokens tests that only active tokens last seen before the cutoff are deactivated
+func TestDeactivateStaleDeviceTokens(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("Failed to open test database: %v", err)
+	}
+	if err := db.Exec(`CREATE TABLE device_tokens (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		user_email VARCHAR(255) NOT NULL,
+		device_token TEXT NOT NULL,
+		platform VARCHAR(10) NOT NULL,
+		app_build INTEGER,
+		last_seen_at DATETIME,
+		created_at DATETIME,
+		updated_at DATETIME,
+		is_active BOOLEAN NOT NULL DEFAULT 1
+	)`).Error; err != nil {
+		t.Fatalf("Failed to create device_tokens table: %v", err)
+	}
+	now := time.Now()
+	devices := []models.DeviceToken{
+		{UserEmail: "alice@example.com", DeviceToken: "recent", Platform: "android", LastSeenAt: now.Add(-24 * time.Hour), IsActive: true},
+		{UserEmail: "alice@example.com", DeviceToken: "stale", Platform: "android", LastSeenAt: now.Add(-300 * 24 * time.Hour), IsActive: true},
+		{UserEmail: "bob@example.com", DeviceToken: "already-inactive", Platform: "ios", LastSeenAt: now.Add(-300 * 24 * time.Hour), IsActive: true},
+	}
+	if err := db.Create(&devices).Error; err != nil {
+		t.Fatalf("Failed to seed device tokens: %v", err)
+	}
+	// is_active defaults to true, so the inactive token is deactivated after it is created
+	db.Model(&devices[2]).Update("is_active", false)
+
+	deactivated, err := DeactivateStaleDeviceTokens(context.Background(), db, now.Add(-270*24*time.Hour))
+	if err != nil {
+		t.Fatalf("DeactivateStaleDeviceTokens failed: %v", err)
+	}
+	if deactivated != 1 {
+		t.Errorf("Expected 1 token to be deactivated, got %d", deactivated)
+	}
+	var active []string
+	db.Model(&models.DeviceToken{}).Where("is_active = ?", true).Pluck("device_token", &active)
+	if len(active) != 1 || active[0] != "recent" {
+		t.Errorf("Expected only the recent token to remain active, got %v", active)
+	}
+}
@@ -20,12 +20,20 @@ import (
 	"encoding/json"
 	"fmt"
 	"log/slog"
+	"math/rand"
 	"os"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 
+	"github.com/opensuperapp/opensuperapp/backend-services/core/internal/tracing"
+
 	firebase "firebase.google.com/go/v4"
 	"firebase.google.com/go/v4/messaging"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 	"google.golang.org/api/option"
 )
 
@@ -33,19 +41,115 @@ import (
 // It wraps the Firebase Admin SDK messaging client and provides methods for sending
 // notifications to multiple devices with automatic batching and retry logic.
 type FCMService struct {
-	client *messaging.Client
+	client messagingClient
+	clock  Clock // Drives retry backoff waits
+
+	jitterMu sync.Mutex // *rand.Rand is not safe for concurrent use
+	jitter   *rand.Rand // Randomizes retry delays; nil disables jitter
+
+	breaker *CircuitBreaker // Fails multicast sends fast during an FCM outage; nil disables it
+
+	config FCMConfig // Retry and limit settings; zero fields fall back to the defaults
+
+	tracer trace.Tracer // Traces batch sends; nil uses the global tracer provider
+
+	// DryRun makes FCM validate messages without delivering them to devices
+	DryRun bool
+}
+
+// messagingClient is the subset of the Firebase messaging client used by FCMService.
+type messagingClient interface {
+	Send(ctx context.Context, message *messaging.Message) (string, error)
+	SendDryRun(ctx context.Context, message *messaging.Message) (string, error)
+	SendEachForMulticast(ctx context.Context, message *messaging.MulticastMessage) (*messaging.BatchResponse, error)
+	SendEachForMulticastDryRun(ctx context.Context, message *messaging.MulticastMessage) (*messaging.BatchResponse, error)
+	SubscribeToTopic(ctx context.Context, tokens []string, topic string) (*messaging.TopicManagementResponse, error)
+	UnsubscribeFromTopic(ctx context.Context, tokens []string, topic string) (*messaging.TopicManagementResponse, error)
 }
 
+// DataKeyCollapseKey is the data payload key carrying a notification's collapse key. Devices keep
+// only the latest notification with a given collapse key, replacing earlier ones still on screen.
+const DataKeyCollapseKey = "collapseKey"
+
+// DataKeyImageURL is the data payload key carrying the https URL of a notification's image.
+// It is shown as the rich notification image in place of the sender icon.
+const DataKeyImageURL = "imageUrl"
+
+// DataKeyBadge is the data payload key carrying the iOS app icon badge count. Messages without
+// it set the badge to defaultBadge.
+const DataKeyBadge = "badge"
+
+const defaultBadge = 1
+
+// DataKeyTTL is the data payload key carrying how many seconds a notification may wait for an
+// offline device before it is dropped. 0 means deliver immediately or not at all.
+const DataKeyTTL = "ttl"
+
 type Notification struct {
 	Title string
 	Body  string
 	Data  map[string]string
 }
 
+// notificationImage returns the image to show with a notification. The sender icon is never shown
+// as the image; clients read it from the data payload.
+func notificationImage(data map[string]string) string {
+	return data[DataKeyImageURL]
+}
+
+// notificationBadge returns the iOS badge count carried in the data payload, or defaultBadge
+// when it is missing or not a non-negative integer
+func notificationBadge(data map[string]string) int {
+	if value, ok := data[DataKeyBadge]; ok {
+		if badge, err := strconv.Atoi(value); err == nil && badge >= 0 {
+			return badge
+		}
+	}
+	return defaultBadge
+}
+
+// notificationTTL returns the time to live carried in the data payload. ok is false when it is
+// missing or not a non-negative number of seconds, in which case the provider default applies.
+func notificationTTL(data map[string]string) (ttl time.Duration, ok bool) {
+	value, present := data[DataKeyTTL]
+	if !present {
+		return 0, false
+	}
+	seconds, err := strconv.Atoi(value)
+	if err != nil || seconds < 0 {
+		return 0, false
+	}
+	return time.Duration(seconds) * time.Second, true
+}
+
+// apnsHeaders returns the APNs request headers derived from the data payload: apns-collapse-id
+// for a collapse key and apns-expiration for a TTL. It returns nil when neither is set.
+func apnsHeaders(data map[string]string, now time.Time) map[string]string {
+	var headers map[string]string
+	if collapseKey := data[DataKeyCollapseKey]; collapseKey != "" {
+		headers = map[string]string{"apns-collapse-id": collapseKey}
+	}
+	if ttl, ok := notificationTTL(data); ok {
+		if headers == nil {
+			headers = make(map[string]string, 1)
+		}
+		// An expiration of 0 tells APNs to try once and not store the notification
+		expiration := "0"
+		if ttl > 0 {
+			expiration = strconv.FormatInt(now.Add(ttl).Unix(), 10)
+		}
+		headers["apns-expiration"] = expiration
+	}
+	return headers
+}
+
 // retryState tracks the state of retry attempts across iterations.
 type retryState struct {
 	totalSuccess      int
 	finalFailedTokens map[string]struct{}
+	deadTokens        []string                   // tokens FCM reported as unregistered or invalid
+	results           map[string]*DeliveryResult // latest outcome per token
+	circuitOpen       bool                       // a batch was rejected by the open circuit breaker
 }
 
 // attemptResult holds the results of processing all batches in a single attempt.
@@ -65,20 +169,97 @@ const (
 	// FCM has a limit of 500 tokens per multicast request.
 	maxTokensPerBatch = 500
 
-	maxRetries = 3
+	// maxTokensPerTopicRequest is the maximum number of device tokens that can be
+	// subscribed to or unsubscribed from a topic in a single request.
+	maxTokensPerTopicRequest = 1000
+
+	// defaultMaxRetries is the number of send attempts made for each token.
+	defaultMaxRetries = 3
+
+	// defaultCircuitFailureThreshold is the number of consecutive failed multicast calls
+	// that opens the circuit breaker.
+	defaultCircuitFailureThreshold = 5
 
-	// initialRetryDelay is the initial delay before the first retry attempt.
+	// defaultCircuitResetTimeout is how long the circuit breaker stays open before probing FCM again.
+	defaultCircuitResetTimeout = 30 * time.Second
+
+	// defaultInitialRetryDelay is the initial delay before the first retry attempt.
 	// Subsequent retries use exponential backoff.
-	initialRetryDelay = 1 * time.Second
+	defaultInitialRetryDelay = 1 * time.Second
 
-	// maxRetryDelay is the maximum delay between retry attempts.
+	// defaultMaxRetryDelay is the maximum delay between retry attempts.
 	// This caps the exponential backoff to prevent excessively long waits.
-	maxRetryDelay = 30 * time.Second
+	defaultMaxRetryDelay = 30 * time.Second
 
-	// FCMAbsoluteLimit is the absolute maximum number of tokens to process
+	// FCMAbsoluteLimit is the default absolute maximum number of tokens to process
 	FCMAbsoluteLimit = 50000
 )
 
+// JitterStrategy selects how retry delays are randomized so that senders which failed
+// together do not all retry at the same instant.
+type JitterStrategy string
+
+const (
+	// JitterNone waits exactly the exponential backoff delay
+	JitterNone JitterStrategy = "none"
+	// JitterFull waits a random delay between zero and the backoff delay
+	JitterFull JitterStrategy = "full"
+	// JitterEqual waits half the backoff delay plus a random delay up to the other half
+	JitterEqual JitterStrategy = "equal"
+)
+
+// ParseJitterStrategy returns the JitterStrategy named by s
+func ParseJitterStrategy(s string) (JitterStrategy, error) {
+	switch strategy := JitterStrategy(strings.ToLower(strings.TrimSpace(s))); strategy {
+	case JitterNone, JitterFull, JitterEqual:
+		return strategy, nil
+	default:
+		return "", fmt.Errorf("unknown jitter strategy %q (expected none, full or equal)", s)
+	}
+}
+
+// FCMConfig holds the tunable retry and limit settings of FCMService. Zero fields use the
+// defaults returned by DefaultFCMConfig.
+type FCMConfig struct {
+	MaxRetries        int            // Send attempts per token, including the first
+	InitialRetryDelay time.Duration  // Delay before the first retry; doubles on each later retry
+	MaxRetryDelay     time.Duration  // Cap on the delay between retries
+	AbsoluteLimit     int            // Tokens beyond this many in a single send are dropped
+	Jitter            JitterStrategy // How retry delays are randomized
+}
+
+// DefaultFCMConfig returns the settings FCMService uses unless configured otherwise
+func DefaultFCMConfig() FCMConfig {
+	return FCMConfig{
+		MaxRetries:        defaultMaxRetries,
+		InitialRetryDelay: defaultInitialRetryDelay,
+		MaxRetryDelay:     defaultMaxRetryDelay,
+		AbsoluteLimit:     FCMAbsoluteLimit,
+		Jitter:            JitterEqual,
+	}
+}
+
+// withDefaults returns the config with every unset field replaced by its default
+func (c FCMConfig) withDefaults() FCMConfig {
+	defaults := DefaultFCMConfig()
+	if c.MaxRetries <= 0 {
+		c.MaxRetries = defaults.MaxRetries
+	}
+	if c.InitialRetryDelay <= 0 {
+		c.InitialRetryDelay = defaults.InitialRetryDelay
+	}
+	if c.MaxRetryDelay <= 0 {
+		c.MaxRetryDelay = defaults.MaxRetryDelay
+	}
+	if c.AbsoluteLimit <= 0 {
+		c.AbsoluteLimit = defaults.AbsoluteLimit
+	}
+	if c.Jitter == "" {
+		c.Jitter = defaults.Jitter
+	}
+	return c
+}
+
 // Error pattern sets for classifying retry behavior.
 var (
 	// batchRetryablePatterns are substrings indicating a request-level issue
@@ -112,6 +293,13 @@ var (
 		"invalid-apns-credentials",
 	}
 
+	// deadTokenErrors indicate the registration token itself is no longer valid,
+	// so the device token should stop being used for future sends.
+	deadTokenErrors = []string{
+		"invalid-registration-token",
+		"registration-token-not-registered",
+	}
+
 	// tokenRetryableErrors indicate transient issues for a specific token
 	// (e.g., service unavailable). Retry with backoff.
 	tokenRetryableErrors = []string{
@@ -130,11 +318,26 @@ var (
 //
 // Parameters:
 //   - credentialsPath: Path to the Firebase service account credentials JSON file.
+//   - config: Retry and limit settings; pass DefaultFCMConfig() for the standard behaviour.
 //
 // Returns:
 //   - *FCMService: An initialized FCM service instance
 //   - error: An error if initialization fails (e.g., invalid credentials, missing project ID)
-func NewFCMService(credentialsPath string) (*FCMService, error) {
+func NewFCMService(credentialsPath string, config FCMConfig) (*FCMService, error) {
+	return NewFCMServiceWithOptions(credentialsPath, false, config)
+}
+
+// NewFCMServiceWithOptions initializes a new FCM service with Firebase Admin SDK.
+//
+// Parameters:
+//   - credentialsPath: Path to the Firebase service account credentials JSON file.
+//   - dryRun: When true, messages are validated by FCM but never delivered to devices.
+//   - config: Retry and limit settings; zero fields use the defaults.
+//
+// Returns:
+//   - *FCMService: An initialized FCM service instance
+//   - error: An error if initialization fails (e.g., invalid credentials, missing project ID)
+func NewFCMServiceWithOptions(credentialsPath string, dryRun bool, config FCMConfig) (*FCMService, error) {
 	ctx := context.Background()
 
 	var app *firebase.App
@@ -167,17 +370,66 @@ func NewFCMService(credentialsPath string) (*FCMService, error) {
 		return nil, fmt.Errorf("error getting messaging client: %w", err)
 	}
 
-	slog.Info("FCM service initialized successfully")
-	return &FCMService{client: client}, nil
+	if dryRun {
+		slog.Warn("FCM service initialized in dry-run mode; notifications will not be delivered")
+	} else {
+		slog.Info("FCM service initialized successfully")
+	}
+	return &FCMService{
+		client:  client,
+		clock:   SystemClock,
+		jitter:  rand.New(rand.NewSource(time.Now().UnixNano())),
+		breaker: NewCircuitBreaker(defaultCircuitFailureThreshold, defaultCircuitResetTimeout, SystemClock),
+		config:  config.withDefaults(),
+		DryRun:  dryRun,
+	}, nil
+}
+
+// WithRetryJitter replaces the random source used to jitter retry delays and returns the service.
+// Passing a seeded source makes the delays reproducible; passing nil disables jitter.
+func (s *FCMService) WithRetryJitter(r *rand.Rand) *FCMService {
+	s.jitterMu.Lock()
+	defer s.jitterMu.Unlock()
+	s.jitter = r
+	return s
+}
+
+// WithClock replaces the clock that drives retry backoff waits and APNs expirations and returns
+// the service. The circuit breaker keeps its own clock.
+func (s *FCMService) WithClock(clock Clock) *FCMService {
+	s.clock = clock
+	return s
+}
+
+// WithCircuitBreaker replaces the circuit breaker guarding multicast sends and returns the
+// service. Passing nil disables it.
+func (s *FCMService) WithCircuitBreaker(b *CircuitBreaker) *FCMService {
+	s.breaker = b
+	return s
+}
+
+// WithTracer replaces the tracer used for batch send spans and returns the service.
+func (s *FCMService) WithTracer(tracer trace.Tracer) *FCMService {
+	s.tracer = tracer
+	return s
+}
+
+// CircuitBreakerState reports the state of the multicast circuit breaker ("closed", "open"
+// or "half-open") for health checks.
+func (s *FCMService) CircuitBreakerState() string {
+	return s.breaker.State().String()
 }
 
 // SendMulticastNotification sends a push notification to multiple devices.
 //
+// It delegates to SendMulticastDetailed and reduces the per-token results to counts.
 // This method automatically handles:
 //   - Token deduplication to avoid sending duplicates
 //   - Batching tokens into groups of maxTokensPerBatch (500) tokens
 //   - Per-token retry logic with exponential backoff for transient failures
-//   - Platform-specific configuration (APNS for iOS, Android config)
+//   - Platform-specific configuration (APNS for iOS, Android config, web push)
+//   - Truncation to AbsoluteLimit unique tokens, or rejection with ErrTokenLimitExceeded
+//     when ctx carries TokenLimitReject
 //
 // Parameters:
 //   - ctx: Context for request cancellation and timeout control
@@ -188,7 +440,8 @@ func NewFCMService(credentialsPath string) (*FCMService, error) {
 //
 // Returns:
 //   - int: Total number of successfully delivered notifications
-//   - int: Total number of failed deliveries
+//   - int: Total number of failed deliveries, including tokens dropped by the limit
+//   - []string: Tokens FCM reported as unregistered or invalid; callers should deactivate them
 //   - error: An error if the entire batch processing fails (partial failures are reported in failure count)
 //
 // The notification includes default sound and badge settings for both iOS and Android.
@@ -198,25 +451,175 @@ func (s *FCMService) SendMulticastNotification(
 	title string,
 	body string,
 	data map[string]string,
-) (int, int, error) {
+) (int, int, []string, error) {
+	results, err := s.SendMulticastDetailed(ctx, tokens, title, body, data)
+	successCount, failureCount, deadTokens := SummarizeDeliveryResults(results)
+	return successCount, failureCount, deadTokens, err
+}
+
+// SendMulticastDetailed sends a push notification to multiple devices and reports the
+// outcome for every unique token, in the order the tokens were first given.
+//
+// It applies the same deduplication, batching, truncation and retry behaviour as
+// SendMulticastNotification. A failed result carries the last error FCM returned for the
+// token; Retryable is set when the token was still failing with a transient error after
+// the retries ran out, and Unregistered when FCM reported the token as no longer valid.
+//
+// Unique tokens beyond the configured AbsoluteLimit are reported as Dropped failures, or,
+// when ctx carries TokenLimitReject (see WithTokenLimitPolicy), nothing is sent and the
+// error wraps ErrTokenLimitExceeded.
+//
+// The error is non-nil only when the send was cancelled, or when the circuit breaker is open
+// and nothing was delivered (ErrCircuitOpen); the results then describe the tokens still
+// pending as retryable failures.
+func (s *FCMService) SendMulticastDetailed(
+	ctx context.Context,
+	tokens []string,
+	title string,
+	body string,
+	data map[string]string,
+) ([]DeliveryResult, error) {
 
 	if len(tokens) == 0 {
-		return 0, 0, nil
+		return nil, nil
 	}
+	s.warnIfDryRun("multicast")
 
 	// Deduplicate tokens to avoid sending duplicate notifications
 	tokens = uniqueTokens(tokens)
 	slog.Info("Starting notification send", "unique_tokens", len(tokens))
 
-	// Enforce absolute limit - TRUNCATE if exceeded
-	if len(tokens) > FCMAbsoluteLimit {
+	// Enforce absolute limit - TRUNCATE unless the caller asked to reject
+	var dropped []string
+	if limit := s.config.withDefaults().AbsoluteLimit; len(tokens) > limit {
+		if TokenLimitPolicyFromContext(ctx) == TokenLimitReject {
+			return nil, fmt.Errorf("%w: %d unique tokens, limit is %d", ErrTokenLimitExceeded, len(tokens), limit)
+		}
 		slog.Warn("Token count exceeds absolute limit, truncating",
 			"original_count", len(tokens),
-			"limit", FCMAbsoluteLimit)
-		tokens = tokens[:FCMAbsoluteLimit]
+			"limit", limit,
+			"dropped", len(tokens)-limit)
+		tokens, dropped = tokens[:limit], tokens[limit:]
+	}
+
+	results, err := s.sendWithRetry(ctx, tokens, title, body, data)
+	return append(results, DroppedResults(dropped)...), err
+}
+
+// SendToTopic sends a single push notification to every device subscribed to the given topic.
+//
+// Unlike SendMulticastNotification, FCM fans the message out server side, so the cost of a
+// topic send does not grow with the number of subscribed devices.
+//
+// Parameters:
+//   - ctx: Context for request cancellation and timeout control
+//   - topic: Name of the FCM topic (without the "/topics/" prefix)
+//   - title: Notification title
+//   - body: Notification body text
+//   - data: Additional key-value data to include in the notification payload
+//
+// Returns:
+//   - string: The message ID assigned by FCM
+//   - error: An error if the message could not be sent
+func (s *FCMService) SendToTopic(
+	ctx context.Context,
+	topic string,
+	title string,
+	body string,
+	data map[string]string,
+) (string, error) {
+	s.warnIfDryRun("topic")
+	message := s.buildTopicMessage(topic, title, body, data)
+
+	send := s.client.Send
+	if s.DryRun {
+		send = s.client.SendDryRun
+	}
+	messageID, err := send(ctx, message)
+	if err != nil {
+		slog.Error("Failed to send topic notification", "topic", topic, "error", err)
+		return "", fmt.Errorf("failed to send topic notification: %w", err)
 	}
 
-	return s.sendWithRetry(ctx, tokens, title, body, data)
+	slog.Info("Topic notification sent", "topic", topic, "message_id", messageID)
+	return messageID, nil
+}
+
+// SubscribeToTopic subscribes the given device tokens to a topic.
+//
+// Tokens are deduplicated and split into batches of maxTokensPerTopicRequest (1000)
+// to stay within the FCM topic management limit.
+//
+// Returns:
+//   - int: Number of tokens successfully subscribed
+//   - int: Number of tokens that failed to subscribe
+//   - error: An error if a batch request fails entirely
+func (s *FCMService) SubscribeToTopic(ctx context.Context, tokens []string, topic string) (int, int, error) {
+	return s.manageTopic(ctx, tokens, topic, s.client.SubscribeToTopic, "subscribe")
+}
+
+// UnsubscribeFromTopic unsubscribes the given device tokens from a topic.
+//
+// Tokens are batched in the same way as SubscribeToTopic.
+//
+// Returns:
+//   - int: Number of tokens successfully unsubscribed
+//   - int: Number of tokens that failed to unsubscribe
+//   - error: An error if a batch request fails entirely
+func (s *FCMService) UnsubscribeFromTopic(ctx context.Context, tokens []string, topic string) (int, int, error) {
+	return s.manageTopic(ctx, tokens, topic, s.client.UnsubscribeFromTopic, "unsubscribe")
+}
+
+// topicManagementFunc matches the Admin SDK subscribe/unsubscribe signatures.
+type topicManagementFunc func(ctx context.Context, tokens []string, topic string) (*messaging.TopicManagementResponse, error)
+
+// manageTopic applies a topic management operation to the tokens in batches.
+func (s *FCMService) manageTopic(
+	ctx context.Context,
+	tokens []string,
+	topic string,
+	operation topicManagementFunc,
+	operationName string,
+) (int, int, error) {
+	tokens = uniqueTokens(tokens)
+	if len(tokens) == 0 {
+		return 0, 0, nil
+	}
+
+	var successCount, failureCount int
+	for i := 0; i < len(tokens); i += maxTokensPerTopicRequest {
+		end := min(i+maxTokensPerTopicRequest, len(tokens))
+		batch := tokens[i:end]
+
+		response, err := operation(ctx, batch, topic)
+		if err != nil {
+			slog.Error("Topic batch failed",
+				"operation", operationName,
+				"topic", topic,
+				"batch_start", i,
+				"batch_end", end,
+				"error", err)
+			return successCount, failureCount + len(tokens) - i, fmt.Errorf("failed to %s topic %s: %w", operationName, topic, err)
+		}
+
+		successCount += response.SuccessCount
+		failureCount += response.FailureCount
+		for _, e := range response.Errors {
+			slog.Warn("Topic operation failed for token",
+				"operation", operationName,
+				"topic", topic,
+				"reason", e.Reason,
+				"token_prefix", tokenPrefix(batch[e.Index]))
+		}
+	}
+
+	slog.Info("Topic operation complete",
+		"operation", operationName,
+		"topic", topic,
+		"success", successCount,
+		"failed", failureCount)
+
+	return successCount, failureCount, nil
 }
 
 // sendWithRetry sends notifications with per-token retry logic.
@@ -226,10 +629,11 @@ func (s *FCMService) sendWithRetry(
 	title string,
 	body string,
 	data map[string]string,
-) (int, int, error) {
+) ([]DeliveryResult, error) {
 
 	retryState := newRetryState()
 	currentTokens := allTokens
+	maxRetries := s.config.withDefaults().MaxRetries
 
 	// Retry loop for failed tokens
 	for attempt := 1; attempt <= maxRetries; attempt++ {
@@ -252,6 +656,10 @@ func (s *FCMService) sendWithRetry(
 			"failed_non_retryable", retryState.failedCount())
 
 		// Check if we should continue retrying
+		if retryState.circuitOpen {
+			slog.Warn("FCM circuit breaker is open, not retrying")
+			break
+		}
 		if len(attemptResult.retryableTokens) == 0 {
 			slog.Info("No tokens to retry, operation complete")
 			// Clear currentTokens since we're done (don't mark successful tokens as failed)
@@ -265,7 +673,7 @@ func (s *FCMService) sendWithRetry(
 		// Wait before retrying (unless this is the last attempt)
 		if attempt < maxRetries {
 			if err := s.waitForRetry(ctx, attempt); err != nil {
-				return retryState.totalSuccess, retryState.failedCount() + len(currentTokens), err
+				return retryState.deliveryResults(allTokens), err
 			}
 		}
 	}
@@ -279,16 +687,49 @@ func (s *FCMService) sendWithRetry(
 	slog.Info("Notification send complete",
 		"total_success", retryState.totalSuccess,
 		"total_failure", retryState.failedCount(),
+		"dead_tokens", len(retryState.deadTokens),
 		"original_tokens", len(allTokens))
 
-	return retryState.totalSuccess, retryState.failedCount(), nil
+	if retryState.circuitOpen && retryState.totalSuccess == 0 {
+		return retryState.deliveryResults(allTokens), ErrCircuitOpen
+	}
+	return retryState.deliveryResults(allTokens), nil
 }
 
 // newRetryState creates a new retry state tracker.
 func newRetryState() *retryState {
 	return &retryState{
 		finalFailedTokens: make(map[string]struct{}),
+		results:           make(map[string]*DeliveryResult),
+	}
+}
+
+// recordSuccess records that a token was delivered under the given FCM message ID.
+func (rs *retryState) recordSuccess(token, messageID string) {
+	rs.results[token] = &DeliveryResult{Token: token, Success: true, MessageID: messageID}
+}
+
+// recordFailure records the latest error for a token. Retryable is cleared again if the
+// token is later marked as permanently failed.
+func (rs *retryState) recordFailure(token string, err error, retryable bool) {
+	result := &DeliveryResult{Token: token, Retryable: retryable}
+	if err != nil {
+		result.Error = err.Error()
 	}
+	rs.results[token] = result
+}
+
+// deliveryResults returns the outcome of every token in the given order.
+func (rs *retryState) deliveryResults(tokens []string) []DeliveryResult {
+	results := make([]DeliveryResult, 0, len(tokens))
+	for _, token := range tokens {
+		if result, ok := rs.results[token]; ok {
+			results = append(results, *result)
+			continue
+		}
+		results = append(results, DeliveryResult{Token: token, Error: errTokenNotAttempted})
+	}
+	return results
 }
 
 // addSuccessCount increments the total success counter.
@@ -304,6 +745,20 @@ func (rs *retryState) failedCount() int {
 // markAsFailed marks a token as permanently failed.
 func (rs *retryState) markAsFailed(token string) {
 	rs.finalFailedTokens[token] = struct{}{}
+	if result, ok := rs.results[token]; ok {
+		result.Retryable = false
+	}
+}
+
+// markAsDead marks a token as permanently failed because it is no longer registered.
+func (rs *retryState) markAsDead(token string) {
+	if !rs.isAlreadyFailed(token) {
+		rs.deadTokens = append(rs.deadTokens, token)
+	}
+	rs.markAsFailed(token)
+	if result, ok := rs.results[token]; ok {
+		result.Unregistered = true
+	}
 }
 
 // isAlreadyFailed checks if a token has already been marked as failed.
@@ -317,7 +772,8 @@ func (rs *retryState) markRemainingAsFailed(tokens []string) {
 	if len(tokens) > 0 {
 		slog.Warn("Max retries exceeded for tokens", "count", len(tokens))
 		for _, token := range tokens {
-			rs.markAsFailed(token)
+			// Still failing with a transient error, so the result stays retryable
+			rs.finalFailedTokens[token] = struct{}{}
 		}
 	}
 }
@@ -369,12 +825,51 @@ func (s *FCMService) sendBatch(
 	batchStartIndex int,
 ) batchResult {
 
+	tracer := s.tracer
+	if tracer == nil {
+		tracer = tracing.Tracer()
+	}
+	ctx, span := tracer.Start(ctx, "FCMService.sendBatch", trace.WithAttributes(
+		attribute.Int("fcm.batch.size", len(batch)),
+		attribute.Int("fcm.batch.start_index", batchStartIndex),
+		attribute.Bool("fcm.dry_run", s.DryRun),
+	))
+	defer span.End()
+
+	if err := s.breaker.Allow(); err != nil {
+		// FCM is not called; the tokens stay retryable so callers can try again later
+		span.SetStatus(codes.Error, err.Error())
+		retryState.circuitOpen = true
+		for _, token := range s.filterRetryableTokens(batch, retryState) {
+			retryState.recordFailure(token, err, true)
+			retryState.finalFailedTokens[token] = struct{}{}
+		}
+		return batchResult{}
+	}
+
 	message := s.buildMulticastMessage(batch, title, body, data)
 
-	response, err := s.client.SendEachForMulticast(ctx, message)
+	send := s.client.SendEachForMulticast
+	if s.DryRun {
+		send = s.client.SendEachForMulticastDryRun
+	}
+	response, err := send(ctx, message)
 	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		// Only transient errors suggest FCM is degraded; other errors are still answers from it
+		if isRetryableBatchError(err) {
+			s.breaker.RecordFailure()
+		} else {
+			s.breaker.RecordSuccess()
+		}
 		return s.handleBatchError(err, batch, retryState, batchStartIndex)
 	}
+	s.breaker.RecordSuccess()
+	span.SetAttributes(
+		attribute.Int("fcm.batch.success_count", response.SuccessCount),
+		attribute.Int("fcm.batch.failure_count", response.FailureCount),
+	)
 
 	// Process individual token responses
 	retryableTokens := s.processTokenResponses(batch, response, retryState)
@@ -387,6 +882,13 @@ func (s *FCMService) sendBatch(
 	}
 }
 
+// warnIfDryRun logs a warning on every send while dry-run mode is active.
+func (s *FCMService) warnIfDryRun(kind string) {
+	if s.DryRun {
+		slog.Warn("FCM dry-run mode is active; message is validated but not delivered", "send", kind)
+	}
+}
+
 // buildMulticastMessage constructs the FCM multicast message with platform-specific config.
 func (s *FCMService) buildMulticastMessage(
 	tokens []string,
@@ -397,27 +899,98 @@ func (s *FCMService) buildMulticastMessage(
 	return &messaging.MulticastMessage{
 		Tokens: tokens,
 		Notification: &messaging.Notification{
-			Title: title,
-			Body:  body,
+			Title:    title,
+			Body:     body,
+			ImageURL: notificationImage(data),
 		},
-		Data: data,
-		APNS: &messaging.APNSConfig{
-			Payload: &messaging.APNSPayload{
-				Aps: &messaging.Aps{
-					Sound: "default",
-					Badge: ptrInt(1),
-				},
-			},
+		Data:    data,
+		APNS:    buildAPNSConfig(data, s.clock.Now()),
+		Android: buildAndroidConfig(data),
+		Webpush: buildWebpushConfig(data),
+	}
+}
+
+// buildTopicMessage constructs a single FCM message addressed to a topic.
+func (s *FCMService) buildTopicMessage(
+	topic string,
+	title string,
+	body string,
+	data map[string]string,
+) *messaging.Message {
+	return &messaging.Message{
+		Topic: topic,
+		Notification: &messaging.Notification{
+			Title:    title,
+			Body:     body,
+			ImageURL: notificationImage(data),
 		},
-		Android: &messaging.AndroidConfig{
-			Priority: "high",
-			Notification: &messaging.AndroidNotification{
-				Sound:        "default",
-				ChannelID:    "default",
-				DefaultSound: true,
+		Data:    data,
+		APNS:    buildAPNSConfig(data, s.clock.Now()),
+		Android: buildAndroidConfig(data),
+		Webpush: buildWebpushConfig(data),
+	}
+}
+
+// buildAPNSConfig returns the iOS specific configuration shared by all outgoing messages.
+// When the data payload carries an image it is attached as the notification image, and a
+// collapse key or TTL is sent in the headers built by apnsHeaders.
+func buildAPNSConfig(data map[string]string, now time.Time) *messaging.APNSConfig {
+	config := &messaging.APNSConfig{
+		Headers: apnsHeaders(data, now),
+		Payload: &messaging.APNSPayload{
+			Aps: &messaging.Aps{
+				Sound: "default",
+				Badge: ptrInt(notificationBadge(data)),
 			},
 		},
 	}
+	if image := notificationImage(data); image != "" {
+		config.Payload.Aps.MutableContent = true
+		config.FCMOptions = &messaging.APNSFCMOptions{ImageURL: image}
+	}
+	return config
+}
+
+// buildAndroidConfig returns the Android specific configuration shared by all outgoing messages.
+// When the data payload carries an image it is attached as the notification image, a collapse
+// key is used as the Android collapse key and a TTL as the message TTL.
+func buildAndroidConfig(data map[string]string) *messaging.AndroidConfig {
+	config := &messaging.AndroidConfig{
+		Priority:    "high",
+		CollapseKey: data[DataKeyCollapseKey],
+		Notification: &messaging.AndroidNotification{
+			Sound:        "default",
+			ChannelID:    "default",
+			DefaultSound: true,
+			ImageURL:     notificationImage(data),
+		},
+	}
+	if ttl, ok := notificationTTL(data); ok {
+		config.TTL = &ttl
+	}
+	return config
+}
+
+// buildWebpushConfig returns the web push configuration shared by all outgoing messages. Browsers
+// show the sender icon as the notification icon and the image as its large image, replace a shown
+// notification that has the same collapse key as its tag, and drop it after the TTL.
+func buildWebpushConfig(data map[string]string) *messaging.WebpushConfig {
+	config := &messaging.WebpushConfig{
+		Headers: map[string]string{"Urgency": "high"},
+		Notification: &messaging.WebpushNotification{
+			Icon:  data[DataKeySenderIcon],
+			Image: data[DataKeyImageURL],
+			Tag:   data[DataKeyCollapseKey],
+		},
+	}
+	if collapseKey := data[DataKeyCollapseKey]; collapseKey != "" {
+		// Without renotify a replacement with the same tag is shown silently
+		config.Notification.Renotify = true
+	}
+	if ttl, ok := notificationTTL(data); ok {
+		config.Headers["TTL"] = strconv.Itoa(int(ttl.Seconds()))
+	}
+	return config
 }
 
 // handleBatchError handles errors that affect an entire batch.
@@ -434,9 +1007,13 @@ func (s *FCMService) handleBatchError(
 			"batch_start", batchStartIndex,
 			"batch_end", batchEnd,
 			"error", err)
+		retryable := s.filterRetryableTokens(batch, retryState)
+		for _, token := range retryable {
+			retryState.recordFailure(token, err, true)
+		}
 		return batchResult{
 			successCount:    0,
-			retryableTokens: s.filterRetryableTokens(batch, retryState),
+			retryableTokens: retryable,
 		}
 	}
 
@@ -447,6 +1024,7 @@ func (s *FCMService) handleBatchError(
 		"error", err)
 
 	for _, token := range batch {
+		retryState.recordFailure(token, err, false)
 		retryState.markAsFailed(token)
 	}
 
@@ -476,20 +1054,29 @@ func (s *FCMService) processTokenResponses(
 	var retryableTokens []string
 
 	for idx, resp := range response.Responses {
-		if !resp.Success {
-			token := batch[idx]
-
-			if s.shouldRetryToken(resp.Error, token, retryState) {
-				retryableTokens = append(retryableTokens, token)
-				slog.Warn("Token failed with retryable error",
-					"error", resp.Error,
-					"token_prefix", tokenPrefix(token))
-			} else {
-				retryState.markAsFailed(token)
-				slog.Warn("Token failed with non-retryable error",
-					"error", resp.Error,
-					"token_prefix", tokenPrefix(token))
-			}
+		token := batch[idx]
+		if resp.Success {
+			retryState.recordSuccess(token, resp.MessageID)
+			continue
+		}
+
+		retryable := s.shouldRetryToken(resp.Error, token, retryState)
+		retryState.recordFailure(token, resp.Error, retryable)
+		if retryable {
+			retryableTokens = append(retryableTokens, token)
+			slog.Warn("Token failed with retryable error",
+				"error", resp.Error,
+				"token_prefix", tokenPrefix(token))
+		} else if isDeadTokenError(resp.Error) {
+			retryState.markAsDead(token)
+			slog.Warn("Token is no longer registered",
+				"error", resp.Error,
+				"token_prefix", tokenPrefix(token))
+		} else {
+			retryState.markAsFailed(token)
+			slog.Warn("Token failed with non-retryable error",
+				"error", resp.Error,
+				"token_prefix", tokenPrefix(token))
 		}
 	}
 
@@ -516,13 +1103,15 @@ func (s *FCMService) logBatchResults(startIndex, batchSize int, response *messag
 
 // waitForRetry implements exponential backoff delay before retry attempts.
 func (s *FCMService) waitForRetry(ctx context.Context, attempt int) error {
-	delay := backoffDelay(attempt)
+	s.jitterMu.Lock()
+	delay := backoffDelay(attempt, s.config.withDefaults(), s.jitter)
+	s.jitterMu.Unlock()
 	slog.Info("Waiting before retry",
 		"delay_ms", delay.Milliseconds(),
 		"next_attempt", attempt+1)
 
 	select {
-	case <-time.After(delay):
+	case <-s.clock.After(delay):
 		return nil
 	case <-ctx.Done():
 		return ctx.Err()
@@ -574,14 +1163,46 @@ func isRetryablePerTokenError(err error) bool {
 	return false
 }
 
+// isDeadTokenError determines if a per-token FCM error means the token is permanently invalid.
+func isDeadTokenError(err error) bool {
+	if err == nil {
+		return false
+	}
+	if messaging.IsUnregistered(err) {
+		return true
+	}
+
+	errMsg := err.Error()
+	for _, deadErr := range deadTokenErrors {
+		if containsInsensitive(errMsg, deadErr) {
+			return true
+		}
+	}
+	return false
+}
+
 // backoffDelay implements exponential backoff with a maximum cap.
-// The delay doubles with each attempt but is capped at maxRetryDelay.
-func backoffDelay(attempt int) time.Duration {
-	delay := initialRetryDelay * time.Duration(1<<uint(attempt-1))
+// The delay starts at config.InitialRetryDelay, doubles with each attempt
+// and is capped at config.MaxRetryDelay.
+// When r is non-nil the capped delay is randomized according to config.Jitter,
+// so the result never exceeds the cap.
+func backoffDelay(attempt int, config FCMConfig, r *rand.Rand) time.Duration {
+	delay := config.InitialRetryDelay * time.Duration(1<<uint(attempt-1))
+
+	// Cap the maximum delay (the shift overflows to zero or below for large attempts)
+	if delay > config.MaxRetryDelay || delay <= 0 {
+		delay = config.MaxRetryDelay
+	}
 
-	// Cap the maximum delay
-	if delay > maxRetryDelay {
-		delay = maxRetryDelay
+	if r == nil {
+		return delay
+	}
+	switch config.Jitter {
+	case JitterFull:
+		delay = time.Duration(r.Int63n(int64(delay) + 1))
+	case JitterEqual:
+		half := delay / 2
+		delay = half + time.Duration(r.Int63n(int64(delay-half)+1))
 	}
 
 	return delay
@@ -0,0 +1,736 @@
+// Copyright (c) 2025 WSO2 LLC. (https://www.wso2.com).
+//
+// WSO2 LLC. licenses this file to you under the Apache License,
+// Version 2.0 (the "License"); you may not use this file except
+// in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+package services
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"reflect"
+	"strconv"
+	"testing"
+	"time"
+
+	"firebase.google.com/go/v4/messaging"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"github.com/opensuperapp/opensuperapp/backend-services/core/internal/clocktest"
+)
+
+// TestProcessTokenResponses_DeadTokens tests that unregistered and invalid tokens are reported as dead
+func TestProcessTokenResponses_DeadTokens(t *testing.T) {
+	s := &FCMService{}
+	rs := newRetryState()
+	batch := []string{"ok-token", "unregistered-token", "retry-token", "invalid-token", "mismatch-token"}
+	response := &messaging.BatchResponse{
+		SuccessCount: 1,
+		FailureCount: 4,
+		Responses: []*messaging.SendResponse{
+			{Success: true},
+			{Error: errors.New("registration-token-not-registered")},
+			{Error: errors.New("unavailable")},
+			{Error: errors.New("invalid-registration-token")},
+			{Error: errors.New("sender-id-mismatch")},
+		},
+	}
+
+	retryable := s.processTokenResponses(batch, response, rs)
+
+	if !reflect.DeepEqual(retryable, []string{"retry-token"}) {
+		t.Errorf("Expected only retry-token to be retryable, got %v", retryable)
+	}
+	if !reflect.DeepEqual(rs.deadTokens, []string{"unregistered-token", "invalid-token"}) {
+		t.Errorf("Expected unregistered-token and invalid-token to be dead, got %v", rs.deadTokens)
+	}
+	if rs.failedCount() != 3 {
+		t.Errorf("Expected 3 permanently failed tokens, got %d", rs.failedCount())
+	}
+}
+
+// TestProcessTokenResponses_MessageID tests that delivered tokens keep the message ID FCM assigned
+func TestProcessTokenResponses_MessageID(t *testing.T) {
+	s := &FCMService{}
+	rs := newRetryState()
+	batch := []string{"ok-token", "failed-token"}
+	response := &messaging.BatchResponse{
+		SuccessCount: 1,
+		FailureCount: 1,
+		Responses: []*messaging.SendResponse{
+			{Success: true, MessageID: "projects/demo/messages/1"},
+			{Error: errors.New("sender-id-mismatch")},
+		},
+	}
+
+	s.processTokenResponses(batch, response, rs)
+
+	results := rs.deliveryResults(batch)
+	if results[0].MessageID != "projects/demo/messages/1" {
+		t.Errorf("Expected the message ID of ok-token to be recorded, got %q", results[0].MessageID)
+	}
+	if results[1].MessageID != "" {
+		t.Errorf("Expected no message ID for failed-token, got %q", results[1].MessageID)
+	}
+}
+
+// TestIsDeadTokenError tests classification of per-token errors
+func TestIsDeadTokenError(t *testing.T) {
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{nil, false},
+		{errors.New("registration-token-not-registered"), true},
+		{errors.New("Invalid-Registration-Token"), true},
+		{errors.New("sender-id-mismatch"), false},
+		{errors.New("unavailable"), false},
+	}
+
+	for _, tt := range tests {
+		if got := isDeadTokenError(tt.err); got != tt.want {
+			t.Errorf("isDeadTokenError(%v) = %v, want %v", tt.err, got, tt.want)
+		}
+	}
+}
+
+// waitForWaiter blocks until the fake clock has a pending timer
+func waitForWaiter(t *testing.T, clock *clocktest.FakeClock) {
+	deadline := time.Now().Add(time.Second)
+	for clock.Waiters() == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("Timed out waiting for a timer to be registered")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// TestWaitForRetry_FakeClock tests that the retry wait lasts exactly the backoff delay
+func TestWaitForRetry_FakeClock(t *testing.T) {
+	clock := clocktest.NewFakeClock(time.Date(2025, 1, 15, 10, 0, 0, 0, time.UTC))
+	s := (&FCMService{clock: clock}).WithRetryJitter(rand.New(rand.NewSource(42)))
+
+	// An identically seeded source yields the jittered delay attempt 2 will wait
+	delay := backoffDelay(2, DefaultFCMConfig(), rand.New(rand.NewSource(42)))
+
+	done := make(chan error, 1)
+	go func() { done <- s.waitForRetry(context.Background(), 2) }()
+	waitForWaiter(t, clock)
+
+	clock.Advance(delay - time.Millisecond)
+	select {
+	case <-done:
+		t.Fatal("waitForRetry returned before the backoff delay elapsed")
+	case <-time.After(10 * time.Millisecond):
+	}
+
+	clock.Advance(time.Millisecond)
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("Expected nil error, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("waitForRetry did not return after the backoff delay")
+	}
+}
+
+// TestWaitForRetry_ContextCancelled tests that cancellation interrupts the retry wait
+func TestWaitForRetry_ContextCancelled(t *testing.T) {
+	clock := clocktest.NewFakeClock(time.Now())
+	s := (&FCMService{clock: clock}).WithRetryJitter(rand.New(rand.NewSource(42)))
+	ctx, cancel := context.WithCancel(context.Background())
+
+	done := make(chan error, 1)
+	go func() { done <- s.waitForRetry(ctx, 1) }()
+	waitForWaiter(t, clock)
+	cancel()
+
+	select {
+	case err := <-done:
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("Expected context.Canceled, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("waitForRetry did not return after cancellation")
+	}
+}
+
+// TestBackoffDelay_NoJitter tests that a nil source yields the plain exponential delay
+func TestBackoffDelay_NoJitter(t *testing.T) {
+	tests := []struct {
+		attempt int
+		want    time.Duration
+	}{
+		{1, defaultInitialRetryDelay},
+		{2, 2 * defaultInitialRetryDelay},
+		{3, 4 * defaultInitialRetryDelay},
+		{10, defaultMaxRetryDelay},
+	}
+	for _, tt := range tests {
+		if got := backoffDelay(tt.attempt, DefaultFCMConfig(), nil); got != tt.want {
+			t.Errorf("backoffDelay(%d) = %v, want %v", tt.attempt, got, tt.want)
+		}
+	}
+}
+
+// TestBackoffDelay_JitterStrategies tests that each strategy stays within its window and under the cap
+func TestBackoffDelay_JitterStrategies(t *testing.T) {
+	tests := []struct {
+		strategy JitterStrategy
+		lower    func(base time.Duration) time.Duration
+	}{
+		{JitterNone, func(base time.Duration) time.Duration { return base }},
+		{JitterFull, func(base time.Duration) time.Duration { return 0 }},
+		{JitterEqual, func(base time.Duration) time.Duration { return base / 2 }},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.strategy), func(t *testing.T) {
+			config := DefaultFCMConfig()
+			config.Jitter = tt.strategy
+			r := rand.New(rand.NewSource(1))
+			varied := false
+			for attempt := 1; attempt <= 10; attempt++ {
+				base := backoffDelay(attempt, config, nil)
+				for n := 0; n < 100; n++ {
+					delay := backoffDelay(attempt, config, r)
+					if delay < tt.lower(base) || delay > base || delay > config.MaxRetryDelay {
+						t.Fatalf("attempt %d: delay %v outside [%v, %v]", attempt, delay, tt.lower(base), base)
+					}
+					varied = varied || delay != base
+				}
+			}
+			if varied != (tt.strategy != JitterNone) {
+				t.Errorf("Expected randomized delays only with jitter, got varied=%v", varied)
+			}
+		})
+	}
+
+	if _, err := ParseJitterStrategy("sometimes"); err == nil {
+		t.Error("Expected an error for an unknown jitter strategy")
+	}
+}
+
+// BenchmarkBackoffDelay_Jitter checks that equal-jitter delays stay between half and all of the base delay
+func BenchmarkBackoffDelay_Jitter(b *testing.B) {
+	const attempts = 10000
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < b.N; i++ {
+		for n := 0; n < attempts; n++ {
+			attempt := n%defaultMaxRetries + 1
+			base := backoffDelay(attempt, DefaultFCMConfig(), nil)
+			delay := backoffDelay(attempt, DefaultFCMConfig(), r)
+			if delay < base/2 || delay > base {
+				b.Fatalf("attempt %d: delay %v outside [%v, %v]", attempt, delay, base/2, base)
+			}
+		}
+	}
+}
+
+// recordingMessagingClient records which FCM send methods were called and reports every token as delivered
+type recordingMessagingClient struct {
+	sendCalls, sendDryRunCalls           int
+	multicastCalls, multicastDryRunCalls int
+	lastMulticast                        *messaging.MulticastMessage
+}
+
+func (c *recordingMessagingClient) Send(ctx context.Context, message *messaging.Message) (string, error) {
+	c.sendCalls++
+	return "message-id", nil
+}
+
+func (c *recordingMessagingClient) SendDryRun(ctx context.Context, message *messaging.Message) (string, error) {
+	c.sendDryRunCalls++
+	return "message-id", nil
+}
+
+func (c *recordingMessagingClient) SendEachForMulticast(ctx context.Context, message *messaging.MulticastMessage) (*messaging.BatchResponse, error) {
+	c.multicastCalls++
+	c.lastMulticast = message
+	return successResponse(len(message.Tokens)), nil
+}
+
+func (c *recordingMessagingClient) SendEachForMulticastDryRun(ctx context.Context, message *messaging.MulticastMessage) (*messaging.BatchResponse, error) {
+	c.multicastDryRunCalls++
+	c.lastMulticast = message
+	return successResponse(len(message.Tokens)), nil
+}
+
+func (c *recordingMessagingClient) SubscribeToTopic(ctx context.Context, tokens []string, topic string) (*messaging.TopicManagementResponse, error) {
+	return &messaging.TopicManagementResponse{SuccessCount: len(tokens)}, nil
+}
+
+func (c *recordingMessagingClient) UnsubscribeFromTopic(ctx context.Context, tokens []string, topic string) (*messaging.TopicManagementResponse, error) {
+	return &messaging.TopicManagementResponse{SuccessCount: len(tokens)}, nil
+}
+
+// successResponse builds a batch response where every token was delivered
+func successResponse(n int) *messaging.BatchResponse {
+	responses := make([]*messaging.SendResponse, n)
+	for i := range responses {
+		responses[i] = &messaging.SendResponse{Success: true}
+	}
+	return &messaging.BatchResponse{SuccessCount: n, Responses: responses}
+}
+
+// TestSendMulticastNotification_DryRun tests that dry-run mode sends through the FCM dry-run API
+func TestSendMulticastNotification_DryRun(t *testing.T) {
+	client := &recordingMessagingClient{}
+	s := &FCMService{client: client, clock: SystemClock, DryRun: true}
+
+	success, failed, _, err := s.SendMulticastNotification(context.Background(), []string{"token-1", "token-2"}, "Title", "Body", nil)
+	if err != nil {
+		t.Fatalf("SendMulticastNotification failed: %v", err)
+	}
+	if success != 2 || failed != 0 {
+		t.Errorf("Expected 2 success and 0 failed, got %d and %d", success, failed)
+	}
+	if client.multicastDryRunCalls != 1 || client.multicastCalls != 0 {
+		t.Errorf("Expected one dry-run multicast and no live multicast, got %d dry-run and %d live",
+			client.multicastDryRunCalls, client.multicastCalls)
+	}
+	if client.lastMulticast == nil || client.lastMulticast.Notification.Title != "Title" {
+		t.Error("Expected the built message to be passed to the dry-run send")
+	}
+
+	if _, err := s.SendToTopic(context.Background(), "news", "Title", "Body", nil); err != nil {
+		t.Fatalf("SendToTopic failed: %v", err)
+	}
+	if client.sendDryRunCalls != 1 || client.sendCalls != 0 {
+		t.Errorf("Expected one dry-run topic send and no live send, got %d dry-run and %d live",
+			client.sendDryRunCalls, client.sendCalls)
+	}
+}
+
+// TestSendMulticastNotification_Live tests that messages are delivered when dry-run mode is off
+func TestSendMulticastNotification_Live(t *testing.T) {
+	client := &recordingMessagingClient{}
+	s := &FCMService{client: client, clock: SystemClock}
+
+	if _, _, _, err := s.SendMulticastNotification(context.Background(), []string{"token-1"}, "Title", "Body", nil); err != nil {
+		t.Fatalf("SendMulticastNotification failed: %v", err)
+	}
+	if client.multicastCalls != 1 || client.multicastDryRunCalls != 0 {
+		t.Errorf("Expected one live multicast and no dry-run, got %d live and %d dry-run",
+			client.multicastCalls, client.multicastDryRunCalls)
+	}
+}
+
+// TestSendMulticastNotification_BatchSpans tests that each batch gets a span under the caller's span
+func TestSendMulticastNotification_BatchSpans(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	tracer := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)).Tracer("test")
+	s := (&FCMService{client: &recordingMessagingClient{}, clock: SystemClock}).WithTracer(tracer)
+
+	tokens := make([]string, maxTokensPerBatch+1)
+	for i := range tokens {
+		tokens[i] = "token-" + strconv.Itoa(i)
+	}
+	ctx, parent := tracer.Start(context.Background(), "send")
+	if _, _, _, err := s.SendMulticastNotification(ctx, tokens, "Title", "Body", nil); err != nil {
+		t.Fatalf("SendMulticastNotification failed: %v", err)
+	}
+	parent.End()
+
+	var batches []sdktrace.ReadOnlySpan
+	for _, span := range recorder.Ended() {
+		if span.Name() == "FCMService.sendBatch" {
+			batches = append(batches, span)
+		}
+	}
+	if len(batches) != 2 {
+		t.Fatalf("Expected 2 sendBatch spans, got %d", len(batches))
+	}
+	wantSizes := []int64{maxTokensPerBatch, 1}
+	for i, span := range batches {
+		if span.Parent().SpanID() != parent.SpanContext().SpanID() {
+			t.Errorf("Expected batch %d to be a child of the caller's span", i)
+		}
+		for _, attr := range span.Attributes() {
+			if attr.Key == attribute.Key("fcm.batch.size") && attr.Value.AsInt64() != wantSizes[i] {
+				t.Errorf("Expected batch %d to have size %d, got %d", i, wantSizes[i], attr.Value.AsInt64())
+			}
+		}
+	}
+}
+
+// TestSendMulticastDetailed_TokenLimit tests that tokens beyond the limit are reported as dropped by default
+// and that the reject policy sends nothing
+func TestSendMulticastDetailed_TokenLimit(t *testing.T) {
+	tokens := []string{"token-1", "token-2", "token-2", "token-3"}
+
+	client := &recordingMessagingClient{}
+	s := &FCMService{client: client, clock: SystemClock, config: FCMConfig{AbsoluteLimit: 2}}
+	results, err := s.SendMulticastDetailed(context.Background(), tokens, "Title", "Body", nil)
+	if err != nil {
+		t.Fatalf("SendMulticastDetailed failed: %v", err)
+	}
+	want := []DeliveryResult{
+		{Token: "token-1", Success: true},
+		{Token: "token-2", Success: true},
+		{Token: "token-3", Error: errTokenDropped, Dropped: true},
+	}
+	if !reflect.DeepEqual(results, want) {
+		t.Errorf("Unexpected results:\n got %+v\nwant %+v", results, want)
+	}
+	if dropped := CountDroppedResults(results); dropped != 1 {
+		t.Errorf("Expected 1 dropped token, got %d", dropped)
+	}
+	if success, failed, _ := SummarizeDeliveryResults(results); success != 2 || failed != 1 {
+		t.Errorf("Expected 2 success and 1 failed, got %d and %d", success, failed)
+	}
+
+	client = &recordingMessagingClient{}
+	s = &FCMService{client: client, clock: SystemClock, config: FCMConfig{AbsoluteLimit: 2}}
+	ctx := WithTokenLimitPolicy(context.Background(), TokenLimitReject)
+	if _, err := s.SendMulticastDetailed(ctx, tokens, "Title", "Body", nil); !errors.Is(err, ErrTokenLimitExceeded) {
+		t.Errorf("Expected ErrTokenLimitExceeded, got %v", err)
+	}
+	if client.multicastCalls != 0 {
+		t.Errorf("Expected nothing to be sent, got %d multicasts", client.multicastCalls)
+	}
+	if _, err := s.SendMulticastDetailed(ctx, tokens[:3], "Title", "Body", nil); err != nil {
+		t.Errorf("Expected tokens within the limit to be sent under the reject policy, got %v", err)
+	}
+}
+
+// TestBuildMulticastMessage_SenderIcon tests that a sender icon stays in the data payload and is never
+// shown as the notification image
+func TestBuildMulticastMessage_SenderIcon(t *testing.T) {
+	s := &FCMService{clock: SystemClock}
+	icon := "https://cdn.example.com/payroll.png"
+
+	msg := s.buildMulticastMessage([]string{"token-1"}, "Title", "Body", map[string]string{DataKeySenderIcon: icon})
+	if msg.Data[DataKeySenderIcon] != icon {
+		t.Errorf("Expected the sender icon in the data payload, got %v", msg.Data)
+	}
+	if msg.Notification.ImageURL != "" || msg.Android.Notification.ImageURL != "" {
+		t.Errorf("Expected no image, got %q and %q", msg.Notification.ImageURL, msg.Android.Notification.ImageURL)
+	}
+	if msg.APNS.FCMOptions != nil || msg.APNS.Payload.Aps.MutableContent {
+		t.Errorf("Expected no APNS image, got %+v", msg.APNS.FCMOptions)
+	}
+}
+
+// scriptedMessagingClient fails tokens with the errors scripted for each multicast call;
+// tokens without a scripted error are delivered
+type scriptedMessagingClient struct {
+	recordingMessagingClient
+	calls []map[string]error
+}
+
+func (c *scriptedMessagingClient) SendEachForMulticast(ctx context.Context, message *messaging.MulticastMessage) (*messaging.BatchResponse, error) {
+	var errs map[string]error
+	if c.multicastCalls < len(c.calls) {
+		errs = c.calls[c.multicastCalls]
+	}
+	c.multicastCalls++
+	response := &messaging.BatchResponse{}
+	for _, token := range message.Tokens {
+		if err := errs[token]; err != nil {
+			response.FailureCount++
+			response.Responses = append(response.Responses, &messaging.SendResponse{Error: err})
+			continue
+		}
+		response.SuccessCount++
+		response.Responses = append(response.Responses, &messaging.SendResponse{Success: true})
+	}
+	return response, nil
+}
+
+// TestSendMulticastDetailed tests that every unique token gets a result describing its final outcome
+func TestSendMulticastDetailed(t *testing.T) {
+	unavailable := errors.New("unavailable")
+	client := &scriptedMessagingClient{calls: []map[string]error{
+		{
+			"dead":  errors.New("registration-token-not-registered"),
+			"bad":   errors.New("sender-id-mismatch"),
+			"flaky": unavailable,
+			"stuck": unavailable,
+		},
+		{"stuck": unavailable},
+		{"stuck": unavailable},
+	}}
+	clock := clocktest.NewFakeClock(time.Now())
+	s := &FCMService{client: client, clock: clock}
+
+	type outcome struct {
+		results []DeliveryResult
+		err     error
+	}
+	done := make(chan outcome, 1)
+	go func() {
+		results, err := s.SendMulticastDetailed(context.Background(),
+			[]string{"ok", "dead", "bad", "ok", "flaky", "stuck"}, "Title", "Body", nil)
+		done <- outcome{results, err}
+	}()
+	for i := 1; i < defaultMaxRetries; i++ {
+		waitForWaiter(t, clock)
+		clock.Advance(time.Hour)
+	}
+
+	var got outcome
+	select {
+	case got = <-done:
+	case <-time.After(time.Second):
+		t.Fatal("SendMulticastDetailed did not return")
+	}
+	if got.err != nil {
+		t.Fatalf("SendMulticastDetailed failed: %v", got.err)
+	}
+
+	want := []DeliveryResult{
+		{Token: "ok", Success: true},
+		{Token: "dead", Error: "registration-token-not-registered", Unregistered: true},
+		{Token: "bad", Error: "sender-id-mismatch"},
+		{Token: "flaky", Success: true},
+		{Token: "stuck", Error: "unavailable", Retryable: true},
+	}
+	if !reflect.DeepEqual(got.results, want) {
+		t.Errorf("Unexpected results:\n got %+v\nwant %+v", got.results, want)
+	}
+
+	success, failed, dead := SummarizeDeliveryResults(got.results)
+	if success != 2 || failed != 3 || !reflect.DeepEqual(dead, []string{"dead"}) {
+		t.Errorf("Expected 2 success, 3 failed and dead [dead], got %d, %d and %v", success, failed, dead)
+	}
+}
+
+// TestBuildMulticastMessage_CollapseKey tests that a collapse key is applied to both platform configs
+func TestBuildMulticastMessage_CollapseKey(t *testing.T) {
+	s := &FCMService{clock: SystemClock}
+
+	msg := s.buildMulticastMessage([]string{"token-1"}, "Title", "Body", map[string]string{DataKeyCollapseKey: "score-123"})
+	if msg.Android.CollapseKey != "score-123" {
+		t.Errorf("Expected Android collapse key score-123, got %q", msg.Android.CollapseKey)
+	}
+	if got := msg.APNS.Headers["apns-collapse-id"]; got != "score-123" {
+		t.Errorf("Expected apns-collapse-id score-123, got %q", got)
+	}
+
+	plain := s.buildMulticastMessage([]string{"token-1"}, "Title", "Body", map[string]string{"k": "v"})
+	if plain.Android.CollapseKey != "" {
+		t.Errorf("Expected no Android collapse key, got %q", plain.Android.CollapseKey)
+	}
+	if _, ok := plain.APNS.Headers["apns-collapse-id"]; ok {
+		t.Error("Expected no apns-collapse-id header without a collapse key")
+	}
+}
+
+// TestBuildMulticastMessage_Image tests that a notification image is shown on every platform
+func TestBuildMulticastMessage_Image(t *testing.T) {
+	s := &FCMService{clock: SystemClock}
+
+	msg := s.buildMulticastMessage([]string{"token-1"}, "Title", "Body", map[string]string{
+		DataKeyImageURL:   "https://cdn.example.com/promo.png",
+		DataKeySenderIcon: "https://cdn.example.com/icon.png",
+	})
+	if msg.Notification.ImageURL != "https://cdn.example.com/promo.png" {
+		t.Errorf("Expected notification image, got %q", msg.Notification.ImageURL)
+	}
+	if msg.Android.Notification.ImageURL != "https://cdn.example.com/promo.png" {
+		t.Errorf("Expected Android image, got %q", msg.Android.Notification.ImageURL)
+	}
+	if !msg.APNS.Payload.Aps.MutableContent || msg.APNS.FCMOptions == nil || msg.APNS.FCMOptions.ImageURL != "https://cdn.example.com/promo.png" {
+		t.Errorf("Expected mutable APNS payload with the image, got %+v and %+v", msg.APNS.Payload.Aps, msg.APNS.FCMOptions)
+	}
+
+	plain := s.buildMulticastMessage([]string{"token-1"}, "Title", "Body", map[string]string{"k": "v"})
+	if plain.Notification.ImageURL != "" || plain.APNS.Payload.Aps.MutableContent || plain.APNS.FCMOptions != nil {
+		t.Error("Expected no image without an image")
+	}
+}
+
+// TestBuildAPNSConfig_Badge tests that the caller's badge count reaches the APNS payload and defaults to 1
+func TestBuildAPNSConfig_Badge(t *testing.T) {
+	tests := []struct {
+		name string
+		data map[string]string
+		want int
+	}{
+		{"caller badge", map[string]string{DataKeyBadge: "5"}, 5},
+		{"cleared badge", map[string]string{DataKeyBadge: "0"}, 0},
+		{"no badge", map[string]string{}, 1},
+		{"malformed badge", map[string]string{DataKeyBadge: "many"}, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := buildAPNSConfig(tt.data, time.Now())
+			if config.Payload.Aps.Badge == nil || *config.Payload.Aps.Badge != tt.want {
+				t.Errorf("Expected badge %d, got %v", tt.want, config.Payload.Aps.Badge)
+			}
+		})
+	}
+}
+
+// TestBuildMulticastMessage_TTL tests that a TTL maps to the Android TTL and the apns-expiration header
+func TestBuildMulticastMessage_TTL(t *testing.T) {
+	now := time.Date(2025, 1, 15, 10, 0, 0, 0, time.UTC)
+	s := &FCMService{clock: clocktest.NewFakeClock(now)}
+
+	tests := []struct {
+		name           string
+		data           map[string]string
+		wantTTL        *time.Duration
+		wantExpiration string
+	}{
+		{"ttl", map[string]string{DataKeyTTL: "600"}, ptrDuration(10 * time.Minute), strconv.FormatInt(now.Add(10*time.Minute).Unix(), 10)},
+		{"zero ttl", map[string]string{DataKeyTTL: "0"}, ptrDuration(0), "0"},
+		{"no ttl", map[string]string{"k": "v"}, nil, ""},
+		{"negative ttl", map[string]string{DataKeyTTL: "-5"}, nil, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			msg := s.buildMulticastMessage([]string{"token-1"}, "Title", "Body", tt.data)
+			if !reflect.DeepEqual(msg.Android.TTL, tt.wantTTL) {
+				t.Errorf("Expected Android TTL %v, got %v", tt.wantTTL, msg.Android.TTL)
+			}
+			if got := msg.APNS.Headers["apns-expiration"]; got != tt.wantExpiration {
+				t.Errorf("Expected apns-expiration %q, got %q", tt.wantExpiration, got)
+			}
+		})
+	}
+}
+
+func ptrDuration(d time.Duration) *time.Duration {
+	return &d
+}
+
+// outageMessagingClient fails every multicast call with a transient error while down is set
+type outageMessagingClient struct {
+	recordingMessagingClient
+	down bool
+}
+
+func (c *outageMessagingClient) SendEachForMulticast(ctx context.Context, message *messaging.MulticastMessage) (*messaging.BatchResponse, error) {
+	if c.down {
+		c.multicastCalls++
+		return nil, errors.New("server unavailable")
+	}
+	return c.recordingMessagingClient.SendEachForMulticast(ctx, message)
+}
+
+// TestFCMService_CircuitBreaker tests that a sustained outage trips the breaker and that a probe closes it again
+func TestFCMService_CircuitBreaker(t *testing.T) {
+	clock := clocktest.NewFakeClock(time.Date(2025, 1, 15, 10, 0, 0, 0, time.UTC))
+	client := &outageMessagingClient{down: true}
+	s := (&FCMService{client: client, clock: clock}).
+		WithCircuitBreaker(NewCircuitBreaker(2, time.Minute, clock))
+
+	// The first two attempts fail and open the breaker, so the third is not sent
+	done := make(chan error, 1)
+	go func() {
+		_, err := s.SendMulticastDetailed(context.Background(), []string{"token-1"}, "Title", "Body", nil)
+		done <- err
+	}()
+	for i := 1; i < defaultMaxRetries; i++ {
+		waitForWaiter(t, clock)
+		clock.Advance(defaultMaxRetryDelay)
+	}
+	select {
+	case err := <-done:
+		if !errors.Is(err, ErrCircuitOpen) {
+			t.Fatalf("Expected ErrCircuitOpen, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("SendMulticastDetailed did not return")
+	}
+	if client.multicastCalls != 2 || s.CircuitBreakerState() != "open" {
+		t.Fatalf("Expected 2 calls and an open breaker, got %d calls and %q", client.multicastCalls, s.CircuitBreakerState())
+	}
+
+	// While open, sends fail fast without calling FCM
+	results, err := s.SendMulticastDetailed(context.Background(), []string{"token-1"}, "Title", "Body", nil)
+	if !errors.Is(err, ErrCircuitOpen) || client.multicastCalls != 2 {
+		t.Fatalf("Expected a fast failure without calling FCM, got %v after %d calls", err, client.multicastCalls)
+	}
+	if len(results) != 1 || results[0].Success || !results[0].Retryable {
+		t.Errorf("Expected a retryable failure result, got %+v", results)
+	}
+
+	// After the reset timeout a successful probe closes the breaker
+	client.down = false
+	clock.Advance(time.Minute)
+	success, failed, _, err := s.SendMulticastNotification(context.Background(), []string{"token-1"}, "Title", "Body", nil)
+	if err != nil || success != 1 || failed != 0 {
+		t.Fatalf("Expected the probe to be delivered, got %d success, %d failed, err %v", success, failed, err)
+	}
+	if s.CircuitBreakerState() != "closed" {
+		t.Errorf("Expected the breaker to close after a successful probe, got %q", s.CircuitBreakerState())
+	}
+}
+
+// TestSendMulticastDetailed_TinyRetryDelays tests that a configured retry policy is used with the real clock
+func TestSendMulticastDetailed_TinyRetryDelays(t *testing.T) {
+	unavailable := errors.New("unavailable")
+	client := &scriptedMessagingClient{calls: []map[string]error{
+		{"flaky": unavailable},
+		{"flaky": unavailable},
+		{"flaky": unavailable},
+		{"flaky": unavailable},
+	}}
+	s := &FCMService{client: client, clock: SystemClock, config: FCMConfig{
+		MaxRetries:        5,
+		InitialRetryDelay: time.Millisecond,
+		MaxRetryDelay:     2 * time.Millisecond,
+	}}
+
+	start := time.Now()
+	results, err := s.SendMulticastDetailed(context.Background(), []string{"flaky"}, "Title", "Body", nil)
+	if err != nil {
+		t.Fatalf("SendMulticastDetailed failed: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Expected tiny retry delays, took %v", elapsed)
+	}
+	if client.multicastCalls != 5 || len(results) != 1 || !results[0].Success {
+		t.Errorf("Expected delivery on the fifth attempt, got %d calls and %+v", client.multicastCalls, results)
+	}
+}
+
+func TestBuildMulticastMessage_Webpush(t *testing.T) {
+	s := &FCMService{clock: SystemClock}
+	icon := "https://cdn.example.com/payroll.png"
+	image := "https://cdn.example.com/banner.png"
+
+	msg := s.buildMulticastMessage([]string{"token-1"}, "Title", "Body", map[string]string{
+		DataKeySenderIcon:  icon,
+		DataKeyImageURL:    image,
+		DataKeyCollapseKey: "payslip",
+		DataKeyTTL:         "600",
+	})
+	if msg.Webpush == nil || msg.Webpush.Notification == nil {
+		t.Fatal("Expected a web push configuration")
+	}
+	n := msg.Webpush.Notification
+	if n.Icon != icon || n.Image != image {
+		t.Errorf("Expected icon %q and image %q, got %q and %q", icon, image, n.Icon, n.Image)
+	}
+	if n.Tag != "payslip" || !n.Renotify {
+		t.Errorf("Expected the collapse key as a renotifying tag, got tag %q renotify %v", n.Tag, n.Renotify)
+	}
+	if msg.Webpush.Headers["TTL"] != "600" || msg.Webpush.Headers["Urgency"] != "high" {
+		t.Errorf("Expected TTL and Urgency headers, got %v", msg.Webpush.Headers)
+	}
+
+	plain := s.buildMulticastMessage([]string{"token-1"}, "Title", "Body", nil)
+	if plain.Webpush.Notification.Tag != "" || plain.Webpush.Notification.Renotify || plain.Webpush.Headers["TTL"] != "" {
+		t.Errorf("Expected no tag or TTL without a collapse key or TTL, got %+v", plain.Webpush)
+	}
+	if topic := s.buildTopicMessage("news", "Title", "Body", nil); topic.Webpush == nil {
+		t.Error("Expected topic messages to carry the web push configuration")
+	}
+}
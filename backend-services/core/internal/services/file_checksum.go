@@ -0,0 +1,53 @@
+// Copyright (c) 2025 WSO2 LLC. (https://www.wso2.com).
+//
+// WSO2 LLC. licenses this file to you under the Apache License,
+// Version 2.0 (the "License"); you may not use this file except
+// in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+package services
+
+import (
+	"errors"
+
+	"github.com/opensuperapp/opensuperapp/backend-services/core/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// RecordFileChecksum records the SHA-256 of the file uploaded as fileName and served at fileURL,
+// replacing the checksum of any earlier upload under the same name.
+func RecordFileChecksum(db *gorm.DB, fileName, fileURL, sha256Hex string) error {
+	return db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "file_name"}},
+		DoUpdates: clause.AssignmentColumns([]string{"url", "content_sha256", "uploaded_at"}),
+	}).Create(&models.FileChecksum{FileName: fileName, URL: fileURL, ContentSHA256: sha256Hex}).Error
+}
+
+// ForgetFileChecksum drops the checksum of a deleted file.
+func ForgetFileChecksum(db *gorm.DB, fileName string) error {
+	return db.Where("file_name = ?", fileName).Delete(&models.FileChecksum{}).Error
+}
+
+// FileChecksumForURL returns the SHA-256 recorded for the file served at fileURL, or "" when the
+// file was not uploaded through the core service (e.g. with a presigned URL).
+func FileChecksumForURL(db *gorm.DB, fileURL string) (string, error) {
+	var checksum models.FileChecksum
+	err := db.Where("url = ?", fileURL).Order("uploaded_at DESC").First(&checksum).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	return checksum.ContentSHA256, nil
+}
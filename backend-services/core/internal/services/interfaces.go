@@ -26,7 +26,41 @@ type TokenValidator interface {
 	GetJWKS() (json.RawMessage, error)
 }
 
+// JWKSCacheInspector exposes JWKS cache diagnostics for token validators that cache keys
+type JWKSCacheInspector interface {
+	CacheStats() JWKSCacheStats
+	ForceRefresh() error
+}
+
 // NotificationService defines the interface for sending notifications
 type NotificationService interface {
-	SendMulticastNotification(ctx context.Context, tokens []string, title string, body string, data map[string]string) (int, int, error)
+	// SendMulticastNotification returns success and failure counts and the tokens that are no longer registered
+	SendMulticastNotification(ctx context.Context, tokens []string, title string, body string, data map[string]string) (int, int, []string, error)
+	SendToTopic(ctx context.Context, topic string, title string, body string, data map[string]string) (string, error)
+	SubscribeToTopic(ctx context.Context, tokens []string, topic string) (int, int, error)
+	UnsubscribeFromTopic(ctx context.Context, tokens []string, topic string) (int, int, error)
+}
+
+// InvalidTokenHandler is notified of device tokens a notification provider reported as
+// permanently invalid (e.g. FCM registration-token-not-registered) after a send
+type InvalidTokenHandler interface {
+	HandleInvalidTokens(ctx context.Context, tokens []string) error
+}
+
+// PlatformNotificationService is implemented by notification services that deliver
+// through a different provider for each device platform
+type PlatformNotificationService interface {
+	NotificationService
+	// SendMulticastByPlatform sends to tokens grouped by platform and merges the per-provider results
+	SendMulticastByPlatform(ctx context.Context, tokensByPlatform map[string][]string, title string, body string, data map[string]string) (int, int, []string, error)
+	// SendDetailedByPlatform is the per-token counterpart of SendMulticastByPlatform
+	SendDetailedByPlatform(ctx context.Context, tokensByPlatform map[string][]string, title string, body string, data map[string]string) ([]DeliveryResult, error)
+}
+
+// DetailedNotificationService is implemented by notification services that can report
+// the outcome of each token rather than only aggregate counts
+type DetailedNotificationService interface {
+	NotificationService
+	// SendMulticastDetailed returns one result per unique token
+	SendMulticastDetailed(ctx context.Context, tokens []string, title string, body string, data map[string]string) ([]DeliveryResult, error)
 }
@@ -0,0 +1,77 @@
+// Copyright (c) 2025 WSO2 LLC. (https://www.wso2.com).
+//
+// WSO2 LLC. licenses this file to you under the Apache License,
+// Version 2.0 (the "License"); you may not use this file except
+// in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+package services
+
+import (
+	"net/url"
+	"strings"
+
+	"github.com/opensuperapp/opensuperapp/backend-services/core/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// RecordMicroAppAsset records microappID as the owner of the uploaded file served at assetURL,
+// replacing any earlier owner of the file.
+func RecordMicroAppAsset(db *gorm.DB, fileName, microappID, assetURL string) error {
+	return db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "file_name"}},
+		DoUpdates: clause.AssignmentColumns([]string{"micro_app_id", "url", "uploaded_at"}),
+	}).Create(&models.MicroAppAsset{FileName: fileName, MicroAppID: microappID, URL: assetURL}).Error
+}
+
+// ForgetMicroAppAsset drops the owner of a file, once it is deleted or replaced without one.
+func ForgetMicroAppAsset(db *gorm.DB, fileName string) error {
+	return db.Where("file_name = ?", fileName).Delete(&models.MicroAppAsset{}).Error
+}
+
+// NotificationImagePolicy limits notification images to assets owned by the sending microapp,
+// and to images on allowlisted hosts.
+type NotificationImagePolicy struct {
+	db           *gorm.DB
+	allowedHosts map[string]struct{}
+}
+
+// NewNotificationImagePolicy returns a policy that also accepts https images on allowedHosts.
+// Hosts are matched exactly, ignoring case.
+func NewNotificationImagePolicy(db *gorm.DB, allowedHosts []string) *NotificationImagePolicy {
+	hosts := make(map[string]struct{}, len(allowedHosts))
+	for _, host := range allowedHosts {
+		hosts[strings.ToLower(host)] = struct{}{}
+	}
+	return &NotificationImagePolicy{db: db, allowedHosts: hosts}
+}
+
+// Allowed reports whether microappID may attach the image at imageURL: the URL must be the
+// download URL of an asset the microapp owns, or an https URL on an allowlisted host.
+func (p *NotificationImagePolicy) Allowed(microappID, imageURL string) (bool, error) {
+	var count int64
+	if err := p.db.Model(&models.MicroAppAsset{}).
+		Where("micro_app_id = ? AND url = ?", microappID, imageURL).
+		Count(&count).Error; err != nil {
+		return false, err
+	}
+	if count > 0 {
+		return true, nil
+	}
+	u, err := url.Parse(imageURL)
+	if err != nil || u.Scheme != "https" {
+		return false, nil
+	}
+	_, ok := p.allowedHosts[strings.ToLower(u.Hostname())]
+	return ok, nil
+}
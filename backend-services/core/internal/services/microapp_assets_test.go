@@ -0,0 +1,99 @@
+// Copyright (c) 2025 WSO2 LLC. (https://www.wso2.com).
+//
+// WSO2 LLC. licenses this file to you under the Apache License,
+// Version 2.0 (the "License"); you may not use this file except
+// in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+package services
+
+import (
+	"testing"
+
+	"github.com/opensuperapp/opensuperapp/backend-services/core/internal/models"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func setupAssetTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("Failed to open test database: %v", err)
+	}
+	if err := db.AutoMigrate(&models.MicroAppAsset{}); err != nil {
+		t.Fatalf("Failed to migrate micro_app_assets: %v", err)
+	}
+	return db
+}
+
+// TestNotificationImagePolicy tests that images are allowed when owned by the sender or on an allowlisted host
+func TestNotificationImagePolicy(t *testing.T) {
+	db := setupAssetTestDB(t)
+	if err := RecordMicroAppAsset(db, "promo.png", "app-1", "https://files.example.com/promo.png"); err != nil {
+		t.Fatalf("Failed to record asset: %v", err)
+	}
+	if err := RecordMicroAppAsset(db, "other.png", "app-2", "https://files.example.com/other.png"); err != nil {
+		t.Fatalf("Failed to record asset: %v", err)
+	}
+	policy := NewNotificationImagePolicy(db, []string{"CDN.example.com"})
+
+	tests := []struct {
+		name     string
+		imageURL string
+		want     bool
+	}{
+		{"owned asset", "https://files.example.com/promo.png", true},
+		{"asset of another microapp", "https://files.example.com/other.png", false},
+		{"allowlisted host", "https://cdn.example.com/banner.png", true},
+		{"allowlisted host over http", "http://cdn.example.com/banner.png", false},
+		{"allowlisted host as subdomain", "https://cdn.example.com.evil.example/banner.png", false},
+		{"arbitrary host", "https://evil.example/banner.png", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := policy.Allowed("app-1", tt.imageURL)
+			if err != nil {
+				t.Fatalf("Allowed returned error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Expected Allowed=%v for %s, got %v", tt.want, tt.imageURL, got)
+			}
+		})
+	}
+}
+
+// TestRecordMicroAppAsset_ReplacesOwner tests that re-uploading a file moves it to the new owner and forgetting it removes it
+func TestRecordMicroAppAsset_ReplacesOwner(t *testing.T) {
+	db := setupAssetTestDB(t)
+	const assetURL = "https://files.example.com/promo.png"
+	policy := NewNotificationImagePolicy(db, nil)
+
+	if err := RecordMicroAppAsset(db, "promo.png", "app-1", assetURL); err != nil {
+		t.Fatalf("Failed to record asset: %v", err)
+	}
+	if err := RecordMicroAppAsset(db, "promo.png", "app-2", assetURL); err != nil {
+		t.Fatalf("Failed to re-record asset: %v", err)
+	}
+	if ok, _ := policy.Allowed("app-1", assetURL); ok {
+		t.Error("Expected the earlier owner to lose the asset")
+	}
+	if ok, _ := policy.Allowed("app-2", assetURL); !ok {
+		t.Error("Expected the new owner to have the asset")
+	}
+
+	if err := ForgetMicroAppAsset(db, "promo.png"); err != nil {
+		t.Fatalf("Failed to forget asset: %v", err)
+	}
+	if ok, _ := policy.Allowed("app-2", assetURL); ok {
+		t.Error("Expected a forgotten asset to be rejected")
+	}
+}
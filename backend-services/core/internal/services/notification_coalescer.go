@@ -0,0 +1,206 @@
+// Copyright (c) 2025 WSO2 LLC. (https://www.wso2.com).
+//
+// WSO2 LLC. licenses this file to you under the Apache License,
+// Version 2.0 (the "License"); you may not use this file except
+// in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/opensuperapp/opensuperapp/backend-services/core/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// MicroAppConfigKeyNotificationCoalescing is the micro app config key enabling notification
+// coalescing, as a JSON object such as {"windowSeconds": 30, "strategy": "latest"}
+const MicroAppConfigKeyNotificationCoalescing = "notificationCoalescing"
+
+// maxCoalescingWindow bounds how long a coalesced notification may be held back
+const maxCoalescingWindow = time.Hour
+
+// CoalescingStrategy selects how sends merged into a pending notification combine their content
+type CoalescingStrategy string
+
+const (
+	// CoalesceLatest sends the content of the latest send; data keys of earlier sends are kept
+	// unless the latest send overrides them
+	CoalesceLatest CoalescingStrategy = "latest"
+	// CoalesceConcatenate sends the latest title with the bodies of every send, one per line
+	CoalesceConcatenate CoalescingStrategy = "concatenate"
+)
+
+// CoalescingConfig is a microapp's coalescing window and strategy
+type CoalescingConfig struct {
+	Window   time.Duration
+	Strategy CoalescingStrategy
+}
+
+// UnmarshalJSON reads the config from its micro app config form, defaulting the strategy to latest
+func (c *CoalescingConfig) UnmarshalJSON(b []byte) error {
+	var raw struct {
+		WindowSeconds int                `json:"windowSeconds"`
+		Strategy      CoalescingStrategy `json:"strategy"`
+	}
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return err
+	}
+	c.Window = time.Duration(raw.WindowSeconds) * time.Second
+	switch c.Strategy = CoalescingStrategy(strings.ToLower(string(raw.Strategy))); c.Strategy {
+	case "":
+		c.Strategy = CoalesceLatest
+	case CoalesceLatest, CoalesceConcatenate:
+	default:
+		return fmt.Errorf("unknown coalescing strategy %q (expected latest or concatenate)", raw.Strategy)
+	}
+	if c.Window < 0 || c.Window > maxCoalescingWindow {
+		return fmt.Errorf("coalescing window must be between 0 and %d seconds", int(maxCoalescingWindow.Seconds()))
+	}
+	return nil
+}
+
+// CoalescedNotification is the pending notification a send was coalesced into
+type CoalescedNotification struct {
+	ID             int64
+	SendAt         time.Time
+	CoalescedCount int // Sends merged into the notification, including this one
+}
+
+// NotificationCoalescer merges a microapp's sends to the same recipients within its coalescing
+// window into one pending scheduled notification, which the scheduled notification worker sends
+// when the window closes. The window opens with the first send and does not extend with later
+// ones, so no send is delayed by more than the window. Sends to different recipient lists are
+// never merged, even when the lists overlap.
+type NotificationCoalescer struct {
+	db    *gorm.DB
+	clock Clock
+}
+
+func NewNotificationCoalescer(db *gorm.DB, clock Clock) *NotificationCoalescer {
+	return &NotificationCoalescer{db: db, clock: clock}
+}
+
+// ConfigFor returns the microapp's coalescing config, or nil when it does not coalesce sends.
+// An invalid config is logged and treated as absent, so sends go out immediately.
+func (c *NotificationCoalescer) ConfigFor(ctx context.Context, microappID string) (*CoalescingConfig, error) {
+	var configs []models.MicroAppConfig
+	if err := c.db.WithContext(ctx).
+		Where("micro_app_id = ? AND config_key = ? AND active = ?", microappID, MicroAppConfigKeyNotificationCoalescing, 1).
+		Limit(1).Find(&configs).Error; err != nil {
+		return nil, err
+	}
+	if len(configs) == 0 {
+		return nil, nil
+	}
+	var config CoalescingConfig
+	if err := json.Unmarshal(configs[0].ConfigValue, &config); err != nil {
+		slog.WarnContext(ctx, "Invalid notification coalescing config, sending without coalescing", "microapp_id", microappID, "error", err)
+		return nil, nil
+	}
+	if config.Window == 0 {
+		return nil, nil
+	}
+	return &config, nil
+}
+
+// Coalesce merges the send into the microapp's pending notification to the same recipients, or
+// creates one due when the window closes.
+func (c *NotificationCoalescer) Coalesce(ctx context.Context, microappID string, userEmails []string, title, body string, data map[string]string, config CoalescingConfig) (*CoalescedNotification, error) {
+	key := coalesceKey(microappID, userEmails)
+	now := c.clock.Now().UTC()
+	var coalesced *CoalescedNotification
+	err := c.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var pending []models.ScheduledNotification
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("coalesce_key = ? AND status = ? AND send_at > ?", key, models.ScheduledStatusPending, now).
+			Order("send_at DESC").Limit(1).
+			Find(&pending).Error; err != nil {
+			return err
+		}
+
+		if len(pending) == 0 {
+			n := models.ScheduledNotification{
+				MicroappID:     microappID,
+				UserEmails:     userEmails,
+				Title:          title,
+				Body:           body,
+				Data:           toJSONMap(data),
+				SendAt:         now.Add(config.Window),
+				Status:         models.ScheduledStatusPending,
+				CoalesceKey:    &key,
+				CoalescedCount: 1,
+			}
+			if err := tx.Create(&n).Error; err != nil {
+				return err
+			}
+			coalesced = &CoalescedNotification{ID: n.ID, SendAt: n.SendAt, CoalescedCount: 1}
+			return nil
+		}
+
+		n := pending[0]
+		merged := toJSONMap(data)
+		for k, v := range n.Data {
+			if _, ok := merged[k]; !ok {
+				merged[k] = v
+			}
+		}
+		if config.Strategy == CoalesceConcatenate {
+			body = n.Body + "\n" + body
+		}
+		if err := tx.Model(&n).Updates(map[string]any{
+			"title":           title,
+			"body":            body,
+			"data":            merged,
+			"coalesced_count": gorm.Expr("coalesced_count + 1"),
+		}).Error; err != nil {
+			return err
+		}
+		coalesced = &CoalescedNotification{ID: n.ID, SendAt: n.SendAt, CoalescedCount: n.CoalescedCount + 1}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return coalesced, nil
+}
+
+// coalesceKey identifies a microapp's sends to one set of recipients, in any order
+func coalesceKey(microappID string, userEmails []string) string {
+	emails := make([]string, len(userEmails))
+	for i, email := range userEmails {
+		emails[i] = strings.ToLower(email)
+	}
+	slices.Sort(emails)
+	emails = slices.Compact(emails)
+	sum := sha256.Sum256([]byte(microappID + "\n" + strings.Join(emails, "\n")))
+	return hex.EncodeToString(sum[:])
+}
+
+// toJSONMap converts an FCM data payload into its stored form
+func toJSONMap(data map[string]string) models.JSONMap {
+	result := make(models.JSONMap, len(data))
+	for k, v := range data {
+		result[k] = v
+	}
+	return result
+}
@@ -0,0 +1,184 @@
+// Copyright (c) 2025 WSO2 LLC. (https://www.wso2.com).
+//
+// WSO2 LLC. licenses this file to you under the Apache License,
+// Version 2.0 (the "License"); you may not use this file except
+// in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/opensuperapp/opensuperapp/backend-services/core/internal/clocktest"
+	"github.com/opensuperapp/opensuperapp/backend-services/core/internal/models"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func setupCoalescerTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("Failed to open test database: %v", err)
+	}
+	if err := db.AutoMigrate(&models.ScheduledNotification{}, &models.MicroAppConfig{}); err != nil {
+		t.Fatalf("Failed to migrate coalescing tables: %v", err)
+	}
+	return db
+}
+
+// TestNotificationCoalescer_MergesWithinWindow tests that sends to the same recipients within the
+// window become one notification due when the window closes, and later sends start a new one
+func TestNotificationCoalescer_MergesWithinWindow(t *testing.T) {
+	db := setupCoalescerTestDB(t)
+	start := time.Date(2025, 1, 15, 10, 0, 0, 0, time.UTC)
+	clock := clocktest.NewFakeClock(start)
+	coalescer := NewNotificationCoalescer(db, clock)
+	config := CoalescingConfig{Window: 30 * time.Second, Strategy: CoalesceLatest}
+	ctx := context.Background()
+
+	first, err := coalescer.Coalesce(ctx, "chat", []string{"alice@example.com", "bob@example.com"}, "1 new message", "Hi", map[string]string{"thread": "1", "sender": "carol"}, config)
+	if err != nil {
+		t.Fatalf("Coalesce failed: %v", err)
+	}
+	if !first.SendAt.Equal(start.Add(30*time.Second)) || first.CoalescedCount != 1 {
+		t.Fatalf("Expected a notification due when the window closes, got %+v", first)
+	}
+
+	// The same recipients in another order and case are merged; the window does not extend
+	clock.Advance(20 * time.Second)
+	second, err := coalescer.Coalesce(ctx, "chat", []string{"Bob@example.com", "alice@example.com"}, "2 new messages", "Are you there?", map[string]string{"thread": "2"}, config)
+	if err != nil {
+		t.Fatalf("Coalesce failed: %v", err)
+	}
+	if second.ID != first.ID || !second.SendAt.Equal(first.SendAt) || second.CoalescedCount != 2 {
+		t.Fatalf("Expected the send to be merged into %+v, got %+v", first, second)
+	}
+	var merged models.ScheduledNotification
+	if err := db.First(&merged, first.ID).Error; err != nil {
+		t.Fatalf("Failed to fetch notification: %v", err)
+	}
+	wantData := models.JSONMap{"thread": "2", "sender": "carol"}
+	if merged.Title != "2 new messages" || merged.Body != "Are you there?" || !reflect.DeepEqual(merged.Data, wantData) || merged.CoalescedCount != 2 {
+		t.Errorf("Expected the latest content with earlier data keys kept, got %+v", merged)
+	}
+
+	// Other recipients and other microapps are coalesced separately
+	other, err := coalescer.Coalesce(ctx, "chat", []string{"alice@example.com"}, "1 new message", "Hi", nil, config)
+	if err != nil {
+		t.Fatalf("Coalesce failed: %v", err)
+	}
+	otherApp, err := coalescer.Coalesce(ctx, "news", []string{"alice@example.com", "bob@example.com"}, "Headline", "News", nil, config)
+	if err != nil {
+		t.Fatalf("Coalesce failed: %v", err)
+	}
+	if other.ID == first.ID || otherApp.ID == first.ID || other.ID == otherApp.ID {
+		t.Errorf("Expected separate notifications for other recipients and microapps, got %d, %d and %d", first.ID, other.ID, otherApp.ID)
+	}
+
+	// Once the window has closed the next send starts a new notification
+	clock.Advance(10 * time.Second)
+	third, err := coalescer.Coalesce(ctx, "chat", []string{"alice@example.com", "bob@example.com"}, "1 new message", "Later", nil, config)
+	if err != nil {
+		t.Fatalf("Coalesce failed: %v", err)
+	}
+	if third.ID == first.ID || third.CoalescedCount != 1 || !third.SendAt.Equal(start.Add(60*time.Second)) {
+		t.Errorf("Expected a new notification after the window, got %+v", third)
+	}
+}
+
+// TestNotificationCoalescer_Concatenate tests that the concatenate strategy keeps every body
+func TestNotificationCoalescer_Concatenate(t *testing.T) {
+	db := setupCoalescerTestDB(t)
+	coalescer := NewNotificationCoalescer(db, clocktest.NewFakeClock(time.Date(2025, 1, 15, 10, 0, 0, 0, time.UTC)))
+	config := CoalescingConfig{Window: time.Minute, Strategy: CoalesceConcatenate}
+	ctx := context.Background()
+
+	var id int64
+	for _, body := range []string{"Build 41 passed", "Build 42 failed", "Build 43 passed"} {
+		n, err := coalescer.Coalesce(ctx, "ci", []string{"alice@example.com"}, "CI", body, nil, config)
+		if err != nil {
+			t.Fatalf("Coalesce failed: %v", err)
+		}
+		id = n.ID
+	}
+	var n models.ScheduledNotification
+	if err := db.First(&n, id).Error; err != nil {
+		t.Fatalf("Failed to fetch notification: %v", err)
+	}
+	if n.Body != "Build 41 passed\nBuild 42 failed\nBuild 43 passed" || n.CoalescedCount != 3 {
+		t.Errorf("Expected the bodies one per line, got %q from %d sends", n.Body, n.CoalescedCount)
+	}
+}
+
+// TestNotificationCoalescer_SkipsClaimedNotification tests that a notification the worker has
+// claimed is not changed, so the send starts a new one
+func TestNotificationCoalescer_SkipsClaimedNotification(t *testing.T) {
+	db := setupCoalescerTestDB(t)
+	coalescer := NewNotificationCoalescer(db, clocktest.NewFakeClock(time.Date(2025, 1, 15, 10, 0, 0, 0, time.UTC)))
+	config := CoalescingConfig{Window: time.Minute, Strategy: CoalesceLatest}
+	ctx := context.Background()
+
+	first, err := coalescer.Coalesce(ctx, "chat", []string{"alice@example.com"}, "Hi", "First", nil, config)
+	if err != nil {
+		t.Fatalf("Coalesce failed: %v", err)
+	}
+	if err := db.Model(&models.ScheduledNotification{}).Where("id = ?", first.ID).Update("status", models.ScheduledStatusProcessing).Error; err != nil {
+		t.Fatalf("Failed to claim notification: %v", err)
+	}
+	second, err := coalescer.Coalesce(ctx, "chat", []string{"alice@example.com"}, "Hi", "Second", nil, config)
+	if err != nil {
+		t.Fatalf("Coalesce failed: %v", err)
+	}
+	if second.ID == first.ID {
+		t.Errorf("Expected a new notification once the first was claimed")
+	}
+}
+
+// TestNotificationCoalescer_ConfigFor tests reading the coalescing config of a microapp
+func TestNotificationCoalescer_ConfigFor(t *testing.T) {
+	db := setupCoalescerTestDB(t)
+	coalescer := NewNotificationCoalescer(db, SystemClock)
+	for appID, value := range map[string]string{
+		"latest":      `{"windowSeconds": 30}`,
+		"concatenate": `{"windowSeconds": 60, "strategy": "concatenate"}`,
+		"disabled":    `{"windowSeconds": 0}`,
+		"unknown":     `{"windowSeconds": 30, "strategy": "sum"}`,
+		"too-long":    `{"windowSeconds": 7200}`,
+	} {
+		if err := db.Create(&models.MicroAppConfig{MicroAppID: appID, ConfigKey: MicroAppConfigKeyNotificationCoalescing, ConfigValue: json.RawMessage(value), CreatedBy: "admin@example.com"}).Error; err != nil {
+			t.Fatalf("Failed to seed config: %v", err)
+		}
+	}
+
+	tests := map[string]*CoalescingConfig{
+		"latest":      {Window: 30 * time.Second, Strategy: CoalesceLatest},
+		"concatenate": {Window: time.Minute, Strategy: CoalesceConcatenate},
+		"disabled":    nil,
+		"unknown":     nil,
+		"too-long":    nil,
+		"unset":       nil,
+	}
+	for appID, want := range tests {
+		got, err := coalescer.ConfigFor(context.Background(), appID)
+		if err != nil {
+			t.Fatalf("%s: ConfigFor failed: %v", appID, err)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("%s: expected %+v, got %+v", appID, want, got)
+		}
+	}
+}
@@ -0,0 +1,53 @@
+// Copyright (c) 2025 WSO2 LLC. (https://www.wso2.com).
+//
+// WSO2 LLC. licenses this file to you under the Apache License,
+// Version 2.0 (the "License"); you may not use this file except
+// in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+package services
+
+import (
+	"github.com/opensuperapp/opensuperapp/backend-services/core/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// FilterOptedOutRecipients drops the recipients who opted out of the microapp's notifications.
+// It returns the remaining recipients, in order, and how many were skipped.
+func FilterOptedOutRecipients(db *gorm.DB, microappID string, userEmails []string) ([]string, int, error) {
+	if len(userEmails) == 0 {
+		return userEmails, 0, nil
+	}
+	var optedOut []string
+	if err := db.Model(&models.NotificationPreference{}).
+		Where("microapp_id = ? AND opted_out = ? AND user_email IN ?", microappID, true, userEmails).
+		Pluck("user_email", &optedOut).Error; err != nil {
+		return nil, 0, err
+	}
+	if len(optedOut) == 0 {
+		return userEmails, 0, nil
+	}
+	skip := make(map[string]struct{}, len(optedOut))
+	for _, email := range optedOut {
+		skip[email] = struct{}{}
+	}
+	remaining := make([]string, 0, len(userEmails))
+	skipped := 0
+	for _, email := range userEmails {
+		if _, ok := skip[email]; ok {
+			skipped++
+			continue
+		}
+		remaining = append(remaining, email)
+	}
+	return remaining, skipped, nil
+}
@@ -0,0 +1,222 @@
+// Copyright (c) 2025 WSO2 LLC. (https://www.wso2.com).
+//
+// WSO2 LLC. licenses this file to you under the Apache License,
+// Version 2.0 (the "License"); you may not use this file except
+// in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+package services
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sort"
+
+	"github.com/opensuperapp/opensuperapp/backend-services/core/internal/models"
+	"github.com/opensuperapp/opensuperapp/backend-services/core/internal/registry"
+)
+
+// ProviderFCM is the name of the built-in Firebase Cloud Messaging provider.
+const ProviderFCM = "fcm"
+
+// ErrNoNotificationProvider is returned when no provider is configured for a request.
+var ErrNoNotificationProvider = errors.New("no notification provider configured")
+
+// providerRegistry holds the notification providers that can be selected per platform.
+// Providers register themselves in their init() functions. FCM is not registered here
+// because it is created once at startup and shared with the topic endpoints.
+var providerRegistry = registry.New[NotificationService]()
+
+// NewNotificationProvider creates the named provider from the registry.
+func NewNotificationProvider(name string, config map[string]any) (NotificationService, error) {
+	return providerRegistry.Get(name, config)
+}
+
+// PlatformDispatcher routes device notifications to a provider per platform.
+// Platforms without a dedicated provider, and all topic operations, use the default provider.
+type PlatformDispatcher struct {
+	defaultProvider NotificationService
+	providers       map[string]NotificationService
+}
+
+// NewPlatformDispatcher creates a dispatcher. defaultProvider may be nil when every
+// platform that has devices is covered by providers.
+func NewPlatformDispatcher(defaultProvider NotificationService, providers map[string]NotificationService) *PlatformDispatcher {
+	return &PlatformDispatcher{defaultProvider: defaultProvider, providers: providers}
+}
+
+// providerFor returns the provider for the given platform, or nil if there is none.
+func (d *PlatformDispatcher) providerFor(platform string) NotificationService {
+	if provider, ok := d.providers[platform]; ok {
+		return provider
+	}
+	return d.defaultProvider
+}
+
+// SendMulticastNotification sends through the default provider because the platform of the tokens is unknown.
+func (d *PlatformDispatcher) SendMulticastNotification(ctx context.Context, tokens []string, title string, body string, data map[string]string) (int, int, []string, error) {
+	if d.defaultProvider == nil {
+		return 0, 0, nil, ErrNoNotificationProvider
+	}
+	return d.defaultProvider.SendMulticastNotification(ctx, tokens, title, body, data)
+}
+
+// SendMulticastByPlatform sends each platform's tokens through its provider and merges the results.
+// A provider that fails counts all of its tokens as failed; an error is returned only when no provider succeeded.
+func (d *PlatformDispatcher) SendMulticastByPlatform(ctx context.Context, tokensByPlatform map[string][]string, title string, body string, data map[string]string) (int, int, []string, error) {
+	platforms := make([]string, 0, len(tokensByPlatform))
+	for platform := range tokensByPlatform {
+		platforms = append(platforms, platform)
+	}
+	sort.Strings(platforms)
+
+	successCount, failureCount := 0, 0
+	var deadTokens []string
+	var errs []error
+	delivered := false
+	for _, platform := range platforms {
+		tokens := tokensByPlatform[platform]
+		if len(tokens) == 0 {
+			continue
+		}
+		provider := d.providerFor(platform)
+		if provider == nil {
+			slog.Warn("No notification provider for platform", "platform", platform, "tokens", len(tokens))
+			failureCount += len(tokens)
+			errs = append(errs, ErrNoNotificationProvider)
+			continue
+		}
+		success, failure, dead, err := provider.SendMulticastNotification(ctx, tokens, title, body, data)
+		if err != nil {
+			slog.Error("Notification provider failed", "platform", platform, "error", err)
+			failureCount += len(tokens)
+			errs = append(errs, err)
+			continue
+		}
+		delivered = true
+		successCount += success
+		failureCount += failure
+		deadTokens = append(deadTokens, dead...)
+	}
+
+	if !delivered && len(errs) > 0 {
+		return successCount, failureCount, deadTokens, errors.Join(errs...)
+	}
+	return successCount, failureCount, deadTokens, nil
+}
+
+// SendMulticastDetailed sends through the default provider, which must report per-token results.
+func (d *PlatformDispatcher) SendMulticastDetailed(ctx context.Context, tokens []string, title string, body string, data map[string]string) ([]DeliveryResult, error) {
+	if d.defaultProvider == nil {
+		return nil, ErrNoNotificationProvider
+	}
+	detailed, ok := d.defaultProvider.(DetailedNotificationService)
+	if !ok {
+		return nil, ErrDetailedResultsNotSupported
+	}
+	return detailed.SendMulticastDetailed(ctx, tokens, title, body, data)
+}
+
+// SendDetailedByPlatform sends each platform's tokens through its provider and concatenates the
+// per-token results. Every provider that would be used must report per-token results, otherwise
+// ErrDetailedResultsNotSupported is returned before anything is sent. As with
+// SendMulticastByPlatform, a provider that fails reports all of its tokens as failed and an
+// error is returned only when no provider succeeded.
+func (d *PlatformDispatcher) SendDetailedByPlatform(ctx context.Context, tokensByPlatform map[string][]string, title string, body string, data map[string]string) ([]DeliveryResult, error) {
+	platforms := make([]string, 0, len(tokensByPlatform))
+	for platform, tokens := range tokensByPlatform {
+		if len(tokens) == 0 {
+			continue
+		}
+		if provider := d.providerFor(platform); provider != nil {
+			if _, ok := provider.(DetailedNotificationService); !ok {
+				return nil, ErrDetailedResultsNotSupported
+			}
+		}
+		platforms = append(platforms, platform)
+	}
+	sort.Strings(platforms)
+
+	var results []DeliveryResult
+	var errs []error
+	delivered := false
+	for _, platform := range platforms {
+		tokens := tokensByPlatform[platform]
+		provider := d.providerFor(platform)
+		if provider == nil {
+			slog.Warn("No notification provider for platform", "platform", platform, "tokens", len(tokens))
+			results = append(results, failedResults(tokens, ErrNoNotificationProvider)...)
+			errs = append(errs, ErrNoNotificationProvider)
+			continue
+		}
+		platformResults, err := provider.(DetailedNotificationService).SendMulticastDetailed(ctx, tokens, title, body, data)
+		if errors.Is(err, ErrTokenLimitExceeded) {
+			// The caller asked for nothing to be sent; stop before any later platform sends
+			return nil, err
+		}
+		if err != nil {
+			slog.Error("Notification provider failed", "platform", platform, "error", err)
+			results = append(results, failedResults(uniqueTokens(tokens), err)...)
+			errs = append(errs, err)
+			continue
+		}
+		delivered = true
+		results = append(results, platformResults...)
+	}
+
+	if !delivered && len(errs) > 0 {
+		return results, errors.Join(errs...)
+	}
+	return results, nil
+}
+
+// SendToTopic sends through the default provider.
+func (d *PlatformDispatcher) SendToTopic(ctx context.Context, topic string, title string, body string, data map[string]string) (string, error) {
+	if d.defaultProvider == nil {
+		return "", ErrNoNotificationProvider
+	}
+	return d.defaultProvider.SendToTopic(ctx, topic, title, body, data)
+}
+
+// SubscribeToTopic subscribes through the default provider.
+func (d *PlatformDispatcher) SubscribeToTopic(ctx context.Context, tokens []string, topic string) (int, int, error) {
+	if d.defaultProvider == nil {
+		return 0, 0, ErrNoNotificationProvider
+	}
+	return d.defaultProvider.SubscribeToTopic(ctx, tokens, topic)
+}
+
+// UnsubscribeFromTopic unsubscribes through the default provider.
+func (d *PlatformDispatcher) UnsubscribeFromTopic(ctx context.Context, tokens []string, topic string) (int, int, error) {
+	if d.defaultProvider == nil {
+		return 0, 0, ErrNoNotificationProvider
+	}
+	return d.defaultProvider.UnsubscribeFromTopic(ctx, tokens, topic)
+}
+
+// SendToDevices sends a notification to the given devices. When the service routes by
+// platform the devices are grouped by platform first; otherwise all tokens are sent together.
+func SendToDevices(ctx context.Context, service NotificationService, devices []models.DeviceToken, title string, body string, data map[string]string) (int, int, []string, error) {
+	if dispatcher, ok := service.(PlatformNotificationService); ok {
+		tokensByPlatform := make(map[string][]string)
+		for _, device := range devices {
+			tokensByPlatform[device.Platform] = append(tokensByPlatform[device.Platform], device.DeviceToken)
+		}
+		return dispatcher.SendMulticastByPlatform(ctx, tokensByPlatform, title, body, data)
+	}
+
+	tokens := make([]string, len(devices))
+	for i, device := range devices {
+		tokens[i] = device.DeviceToken
+	}
+	return service.SendMulticastNotification(ctx, tokens, title, body, data)
+}
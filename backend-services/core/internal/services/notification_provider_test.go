@@ -0,0 +1,213 @@
+// Copyright (c) 2025 WSO2 LLC. (https://www.wso2.com).
+//
+// WSO2 LLC. licenses this file to you under the Apache License,
+// Version 2.0 (the "License"); you may not use this file except
+// in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+package services
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/opensuperapp/opensuperapp/backend-services/core/internal/models"
+)
+
+// fakeProvider records the tokens it was asked to send to and reports the configured outcome
+type fakeProvider struct {
+	tokens     []string
+	deadTokens []string
+	err        error
+}
+
+func (p *fakeProvider) SendMulticastNotification(ctx context.Context, tokens []string, title string, body string, data map[string]string) (int, int, []string, error) {
+	p.tokens = append(p.tokens, tokens...)
+	if p.err != nil {
+		return 0, 0, nil, p.err
+	}
+	return len(tokens) - len(p.deadTokens), len(p.deadTokens), p.deadTokens, nil
+}
+
+func (p *fakeProvider) SendToTopic(ctx context.Context, topic string, title string, body string, data map[string]string) (string, error) {
+	return "message-id", nil
+}
+
+func (p *fakeProvider) SubscribeToTopic(ctx context.Context, tokens []string, topic string) (int, int, error) {
+	return len(tokens), 0, nil
+}
+
+func (p *fakeProvider) UnsubscribeFromTopic(ctx context.Context, tokens []string, topic string) (int, int, error) {
+	return len(tokens), 0, nil
+}
+
+// TestSendToDevices_GroupsByPlatform tests that devices are routed to their platform's provider and results merged
+func TestSendToDevices_GroupsByPlatform(t *testing.T) {
+	fcm := &fakeProvider{}
+	apns := &fakeProvider{deadTokens: []string{"ios-2"}}
+	dispatcher := NewPlatformDispatcher(fcm, map[string]NotificationService{"ios": apns})
+
+	devices := []models.DeviceToken{
+		{DeviceToken: "android-1", Platform: "android"},
+		{DeviceToken: "ios-1", Platform: "ios"},
+		{DeviceToken: "ios-2", Platform: "ios"},
+	}
+	success, failed, dead, err := SendToDevices(context.Background(), dispatcher, devices, "Title", "Body", nil)
+	if err != nil {
+		t.Fatalf("SendToDevices failed: %v", err)
+	}
+
+	if success != 2 || failed != 1 {
+		t.Errorf("Expected 2 success and 1 failed, got %d and %d", success, failed)
+	}
+	if !reflect.DeepEqual(dead, []string{"ios-2"}) {
+		t.Errorf("Expected dead tokens [ios-2], got %v", dead)
+	}
+	if !reflect.DeepEqual(fcm.tokens, []string{"android-1"}) {
+		t.Errorf("Expected FCM to receive [android-1], got %v", fcm.tokens)
+	}
+	sort.Strings(apns.tokens)
+	if !reflect.DeepEqual(apns.tokens, []string{"ios-1", "ios-2"}) {
+		t.Errorf("Expected APNs to receive [ios-1 ios-2], got %v", apns.tokens)
+	}
+}
+
+// TestSendToDevices_PlainService tests that a service without platform routing receives every token
+func TestSendToDevices_PlainService(t *testing.T) {
+	fcm := &fakeProvider{}
+	devices := []models.DeviceToken{
+		{DeviceToken: "android-1", Platform: "android"},
+		{DeviceToken: "ios-1", Platform: "ios"},
+	}
+	if _, _, _, err := SendToDevices(context.Background(), fcm, devices, "Title", "Body", nil); err != nil {
+		t.Fatalf("SendToDevices failed: %v", err)
+	}
+	if !reflect.DeepEqual(fcm.tokens, []string{"android-1", "ios-1"}) {
+		t.Errorf("Expected all tokens to be sent together, got %v", fcm.tokens)
+	}
+}
+
+// TestSendMulticastByPlatform_ProviderFailure tests that one failing provider does not fail the whole send
+func TestSendMulticastByPlatform_ProviderFailure(t *testing.T) {
+	fcm := &fakeProvider{}
+	apns := &fakeProvider{err: errors.New("apns unavailable")}
+	dispatcher := NewPlatformDispatcher(fcm, map[string]NotificationService{"ios": apns})
+
+	success, failed, _, err := dispatcher.SendMulticastByPlatform(context.Background(), map[string][]string{
+		"android": {"android-1"},
+		"ios":     {"ios-1", "ios-2"},
+	}, "Title", "Body", nil)
+	if err != nil {
+		t.Fatalf("Expected no error when another provider succeeded, got %v", err)
+	}
+	if success != 1 || failed != 2 {
+		t.Errorf("Expected 1 success and 2 failed, got %d and %d", success, failed)
+	}
+
+	_, _, _, err = dispatcher.SendMulticastByPlatform(context.Background(), map[string][]string{
+		"ios": {"ios-1"},
+	}, "Title", "Body", nil)
+	if err == nil {
+		t.Error("Expected an error when every provider failed")
+	}
+}
+
+// TestSendMulticastByPlatform_NoDefaultProvider tests that platforms without a provider are counted as failed
+func TestSendMulticastByPlatform_NoDefaultProvider(t *testing.T) {
+	apns := &fakeProvider{}
+	dispatcher := NewPlatformDispatcher(nil, map[string]NotificationService{"ios": apns})
+
+	success, failed, _, err := dispatcher.SendMulticastByPlatform(context.Background(), map[string][]string{
+		"android": {"android-1"},
+		"ios":     {"ios-1"},
+	}, "Title", "Body", nil)
+	if err != nil {
+		t.Fatalf("SendMulticastByPlatform failed: %v", err)
+	}
+	if success != 1 || failed != 1 {
+		t.Errorf("Expected 1 success and 1 failed, got %d and %d", success, failed)
+	}
+	if _, err := dispatcher.SendToTopic(context.Background(), "news", "Title", "Body", nil); !errors.Is(err, ErrNoNotificationProvider) {
+		t.Errorf("Expected ErrNoNotificationProvider for topics, got %v", err)
+	}
+}
+
+// detailedFakeProvider is a fakeProvider that also reports per-token results
+type detailedFakeProvider struct {
+	fakeProvider
+}
+
+func (p *detailedFakeProvider) SendMulticastDetailed(ctx context.Context, tokens []string, title string, body string, data map[string]string) ([]DeliveryResult, error) {
+	p.tokens = append(p.tokens, tokens...)
+	if p.err != nil {
+		return nil, p.err
+	}
+	results := make([]DeliveryResult, len(tokens))
+	for i, token := range tokens {
+		results[i] = DeliveryResult{Token: token, Success: true}
+		for _, dead := range p.deadTokens {
+			if token == dead {
+				results[i] = DeliveryResult{Token: token, Error: "unregistered", Unregistered: true}
+			}
+		}
+	}
+	return results, nil
+}
+
+// TestSendToDevicesDetailed tests that per-token results are collected from each platform's provider
+func TestSendToDevicesDetailed(t *testing.T) {
+	fcm := &detailedFakeProvider{}
+	apns := &detailedFakeProvider{fakeProvider{deadTokens: []string{"ios-2"}}}
+	dispatcher := NewPlatformDispatcher(fcm, map[string]NotificationService{"ios": apns})
+
+	devices := []models.DeviceToken{
+		{DeviceToken: "android-1", Platform: "android"},
+		{DeviceToken: "ios-1", Platform: "ios"},
+		{DeviceToken: "ios-2", Platform: "ios"},
+	}
+	results, err := SendToDevicesDetailed(context.Background(), dispatcher, devices, "Title", "Body", nil)
+	if err != nil {
+		t.Fatalf("SendToDevicesDetailed failed: %v", err)
+	}
+	want := []DeliveryResult{
+		{Token: "android-1", Success: true},
+		{Token: "ios-1", Success: true},
+		{Token: "ios-2", Error: "unregistered", Unregistered: true},
+	}
+	if !reflect.DeepEqual(results, want) {
+		t.Errorf("Unexpected results:\n got %+v\nwant %+v", results, want)
+	}
+}
+
+// TestSendToDevicesDetailed_NotSupported tests that nothing is sent when a provider only reports counts
+func TestSendToDevicesDetailed_NotSupported(t *testing.T) {
+	fcm := &detailedFakeProvider{}
+	apns := &fakeProvider{}
+	dispatcher := NewPlatformDispatcher(fcm, map[string]NotificationService{"ios": apns})
+
+	devices := []models.DeviceToken{
+		{DeviceToken: "android-1", Platform: "android"},
+		{DeviceToken: "ios-1", Platform: "ios"},
+	}
+	if _, err := SendToDevicesDetailed(context.Background(), dispatcher, devices, "Title", "Body", nil); !errors.Is(err, ErrDetailedResultsNotSupported) {
+		t.Fatalf("Expected ErrDetailedResultsNotSupported, got %v", err)
+	}
+	if len(fcm.tokens) != 0 || len(apns.tokens) != 0 {
+		t.Errorf("Expected nothing to be sent, got %v and %v", fcm.tokens, apns.tokens)
+	}
+
+	if _, err := SendToDevicesDetailed(context.Background(), apns, devices, "Title", "Body", nil); !errors.Is(err, ErrDetailedResultsNotSupported) {
+		t.Errorf("Expected ErrDetailedResultsNotSupported for a plain service, got %v", err)
+	}
+}
@@ -0,0 +1,131 @@
+// Copyright (c) 2025 WSO2 LLC. (https://www.wso2.com).
+//
+// WSO2 LLC. licenses this file to you under the Apache License,
+// Version 2.0 (the "License"); you may not use this file except
+// in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/opensuperapp/opensuperapp/backend-services/core/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// MicroAppConfigKeyNotificationQuota is the micro app config key holding the number of
+// notifications the microapp may send per quota period, as a JSON number. 0 means unlimited.
+const MicroAppConfigKeyNotificationQuota = "notificationQuota"
+
+// ErrQuotaExceeded is returned when a send would take a microapp over its notification quota
+var ErrQuotaExceeded = errors.New("notification quota exceeded")
+
+// QuotaExceededError reports when the exhausted quota period ends. It matches ErrQuotaExceeded.
+type QuotaExceededError struct {
+	Limit      int
+	ResetAt    time.Time     // start of the next quota period
+	RetryAfter time.Duration // time left until ResetAt
+}
+
+func (e *QuotaExceededError) Error() string {
+	return fmt.Sprintf("%s: limit %d, resets at %s", ErrQuotaExceeded, e.Limit, e.ResetAt.Format(time.RFC3339))
+}
+
+func (e *QuotaExceededError) Unwrap() error {
+	return ErrQuotaExceeded
+}
+
+// QuotaService enforces per-microapp notification quotas over fixed periods. Each microapp's
+// limit comes from its notificationQuota config, falling back to the default limit.
+type QuotaService struct {
+	db           *gorm.DB
+	period       time.Duration
+	defaultLimit int // 0 leaves microapps without a configured quota unlimited
+	clock        Clock
+}
+
+// NewQuotaService creates a QuotaService with the given period length (an hour if not positive) and default limit
+func NewQuotaService(db *gorm.DB, period time.Duration, defaultLimit int, clock Clock) *QuotaService {
+	if period <= 0 {
+		period = time.Hour
+	}
+	return &QuotaService{db: db, period: period, defaultLimit: defaultLimit, clock: clock}
+}
+
+// CheckAndIncrement charges count sends to the microapp's current period. The counter is only
+// incremented when the whole count fits within the limit; otherwise a *QuotaExceededError is
+// returned and nothing is charged. The check and increment are a single conditional update,
+// so concurrent sends cannot overshoot the limit.
+func (q *QuotaService) CheckAndIncrement(ctx context.Context, microappID string, count int) error {
+	if count <= 0 {
+		return nil
+	}
+	limit, err := q.limitFor(ctx, microappID)
+	if err != nil {
+		return err
+	}
+	if limit <= 0 {
+		return nil
+	}
+	now := q.clock.Now().UTC()
+	periodStart := now.Truncate(q.period)
+	exceeded := &QuotaExceededError{Limit: limit, ResetAt: periodStart.Add(q.period)}
+	exceeded.RetryAfter = exceeded.ResetAt.Sub(now)
+	if count > limit {
+		return exceeded
+	}
+
+	db := q.db.WithContext(ctx)
+	row := models.NotificationQuota{MicroappID: microappID, PeriodStart: periodStart, QuotaLimit: limit}
+	if err := db.Clauses(clause.OnConflict{DoNothing: true}).Create(&row).Error; err != nil {
+		return err
+	}
+	result := db.Model(&models.NotificationQuota{}).
+		Where("microapp_id = ? AND period_start = ? AND send_count + ? <= ?", microappID, periodStart, count, limit).
+		Updates(map[string]any{
+			"send_count":  gorm.Expr("send_count + ?", count),
+			"quota_limit": limit,
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return exceeded
+	}
+	return nil
+}
+
+// limitFor returns the microapp's configured quota, or the default limit when it has none
+func (q *QuotaService) limitFor(ctx context.Context, microappID string) (int, error) {
+	var configs []models.MicroAppConfig
+	if err := q.db.WithContext(ctx).
+		Where("micro_app_id = ? AND config_key = ? AND active = ?", microappID, MicroAppConfigKeyNotificationQuota, 1).
+		Limit(1).Find(&configs).Error; err != nil {
+		return 0, err
+	}
+	if len(configs) == 0 {
+		return q.defaultLimit, nil
+	}
+	var limit int
+	if err := json.Unmarshal(configs[0].ConfigValue, &limit); err != nil {
+		slog.Warn("Invalid notification quota config, using the default limit", "microapp_id", microappID, "error", err)
+		return q.defaultLimit, nil
+	}
+	return limit, nil
+}
@@ -0,0 +1,98 @@
+// Copyright (c) 2025 WSO2 LLC. (https://www.wso2.com).
+//
+// WSO2 LLC. licenses this file to you under the Apache License,
+// Version 2.0 (the "License"); you may not use this file except
+// in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/opensuperapp/opensuperapp/backend-services/core/internal/clocktest"
+	"github.com/opensuperapp/opensuperapp/backend-services/core/internal/models"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func setupQuotaTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("Failed to open test database: %v", err)
+	}
+	if err := db.AutoMigrate(&models.NotificationQuota{}, &models.MicroAppConfig{}); err != nil {
+		t.Fatalf("Failed to migrate quota tables: %v", err)
+	}
+	return db
+}
+
+// TestQuotaService_EnforcesAndResets tests that sends past the limit are refused until the next period
+func TestQuotaService_EnforcesAndResets(t *testing.T) {
+	db := setupQuotaTestDB(t)
+	clock := clocktest.NewFakeClock(time.Date(2025, 1, 15, 10, 15, 0, 0, time.UTC))
+	quota := NewQuotaService(db, time.Hour, 10, clock)
+	ctx := context.Background()
+
+	if err := quota.CheckAndIncrement(ctx, "app-1", 6); err != nil {
+		t.Fatalf("Expected the first send to fit, got %v", err)
+	}
+	err := quota.CheckAndIncrement(ctx, "app-1", 5)
+	var exceeded *QuotaExceededError
+	if !errors.As(err, &exceeded) || !errors.Is(err, ErrQuotaExceeded) {
+		t.Fatalf("Expected a quota exceeded error, got %v", err)
+	}
+	if exceeded.RetryAfter != 45*time.Minute || !exceeded.ResetAt.Equal(time.Date(2025, 1, 15, 11, 0, 0, 0, time.UTC)) {
+		t.Errorf("Expected a reset at the next hour in 45 minutes, got %v in %v", exceeded.ResetAt, exceeded.RetryAfter)
+	}
+	// A refused send is not charged, so the remaining quota can still be used
+	if err := quota.CheckAndIncrement(ctx, "app-1", 4); err != nil {
+		t.Errorf("Expected the remaining quota to be usable, got %v", err)
+	}
+	// Other microapps have their own counters
+	if err := quota.CheckAndIncrement(ctx, "app-2", 10); err != nil {
+		t.Errorf("Expected another microapp to have its own quota, got %v", err)
+	}
+
+	clock.Advance(45 * time.Minute)
+	if err := quota.CheckAndIncrement(ctx, "app-1", 10); err != nil {
+		t.Errorf("Expected the quota to reset in the next period, got %v", err)
+	}
+}
+
+// TestQuotaService_MicroAppConfigOverride tests that a microapp's notificationQuota config replaces the default limit
+func TestQuotaService_MicroAppConfigOverride(t *testing.T) {
+	db := setupQuotaTestDB(t)
+	configs := []models.MicroAppConfig{
+		{MicroAppID: "limited", ConfigKey: MicroAppConfigKeyNotificationQuota, ConfigValue: json.RawMessage(`2`), Active: 1, CreatedBy: "admin@example.com"},
+		{MicroAppID: "unlimited", ConfigKey: MicroAppConfigKeyNotificationQuota, ConfigValue: json.RawMessage(`0`), Active: 1, CreatedBy: "admin@example.com"},
+	}
+	if err := db.Create(&configs).Error; err != nil {
+		t.Fatalf("Failed to seed micro app configs: %v", err)
+	}
+	quota := NewQuotaService(db, time.Hour, 100, clocktest.NewFakeClock(time.Now()))
+	ctx := context.Background()
+
+	if err := quota.CheckAndIncrement(ctx, "limited", 3); !errors.Is(err, ErrQuotaExceeded) {
+		t.Errorf("Expected the configured limit of 2 to apply, got %v", err)
+	}
+	if err := quota.CheckAndIncrement(ctx, "unlimited", 1000); err != nil {
+		t.Errorf("Expected a configured limit of 0 to be unlimited, got %v", err)
+	}
+	if err := quota.CheckAndIncrement(ctx, "default", 100); err != nil {
+		t.Errorf("Expected the default limit for an unconfigured microapp, got %v", err)
+	}
+}
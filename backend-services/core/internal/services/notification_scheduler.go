@@ -0,0 +1,371 @@
+// Copyright (c) 2025 WSO2 LLC. (https://www.wso2.com).
+//
+// WSO2 LLC. licenses this file to you under the Apache License,
+// Version 2.0 (the "License"); you may not use this file except
+// in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+package services
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/opensuperapp/opensuperapp/backend-services/core/internal/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	// scheduledDispatchTimeout bounds the time spent sending a single scheduled notification.
+	scheduledDispatchTimeout = 2 * time.Minute
+
+	// minScheduledLease is the shortest claim lease accepted. A lease renewed just before a
+	// dispatch must outlast the dispatch, or another worker could reclaim the row mid-send.
+	minScheduledLease = 2 * scheduledDispatchTimeout
+
+	// defaultScheduledPollInterval and defaultScheduledBatchSize replace a non-positive poll
+	// interval or batch size, which would panic the ticker or claim no rows.
+	defaultScheduledPollInterval = 30 * time.Second
+	defaultScheduledBatchSize    = 50
+
+	notificationLogStatusSent           = "sent"
+	notificationLogStatusPartialFailure = "partial_failure"
+)
+
+// ScheduledNotificationWorker polls the scheduled_notifications table and dispatches
+// due notifications through the NotificationService.
+//
+// Rows are claimed with SELECT ... FOR UPDATE SKIP LOCKED and marked as processing
+// inside a transaction, so it is safe to run the worker on multiple replicas. Each claim
+// is a lease: a replica that dies or is scaled down mid-batch leaves rows whose lease
+// expires, and another replica reclaims them. The lease is renewed just before each
+// dispatch and every later update is fenced on the claim token, so a worker that lost
+// its lease skips the row instead of sending it a second time.
+type ScheduledNotificationWorker struct {
+	db                  *gorm.DB
+	notificationService NotificationService
+	invalidTokens       InvalidTokenHandler
+	interval            time.Duration
+	batchSize           int
+	lease               time.Duration
+	clock               Clock
+	workerID            string
+	done                chan struct{}
+	closeOnce           sync.Once
+	wg                  sync.WaitGroup
+}
+
+// NewScheduledNotificationWorker creates a worker that polls for due notifications every interval
+// and claims at most batchSize rows per poll, each for lease before another worker may reclaim it.
+func NewScheduledNotificationWorker(db *gorm.DB, notificationService NotificationService, interval time.Duration, batchSize int, lease time.Duration) *ScheduledNotificationWorker {
+	hostname, err := os.Hostname()
+	if err != nil {
+		hostname = "unknown"
+	}
+	if interval <= 0 {
+		slog.Warn("Scheduled notification poll interval not positive, using default", "interval", interval, "default", defaultScheduledPollInterval)
+		interval = defaultScheduledPollInterval
+	}
+	if batchSize <= 0 {
+		slog.Warn("Scheduled notification batch size not positive, using default", "batch_size", batchSize, "default", defaultScheduledBatchSize)
+		batchSize = defaultScheduledBatchSize
+	}
+	if lease < minScheduledLease {
+		slog.Warn("Scheduled notification lease too short, using minimum", "lease", lease, "minimum", minScheduledLease)
+		lease = minScheduledLease
+	}
+	return &ScheduledNotificationWorker{
+		db:                  db,
+		notificationService: notificationService,
+		invalidTokens:       NewDeviceTokenDeactivator(db),
+		interval:            interval,
+		batchSize:           batchSize,
+		lease:               lease,
+		clock:               SystemClock,
+		workerID:            fmt.Sprintf("%s-%d", hostname, os.Getpid()),
+		done:                make(chan struct{}),
+	}
+}
+
+// WithClock replaces the clock that decides which notifications are due and when leases expire,
+// and returns the worker.
+func (w *ScheduledNotificationWorker) WithClock(clock Clock) *ScheduledNotificationWorker {
+	w.clock = clock
+	return w
+}
+
+// Start launches the polling loop in a background goroutine.
+func (w *ScheduledNotificationWorker) Start() {
+	slog.Info("Starting scheduled notification worker", "worker_id", w.workerID, "interval", w.interval, "batch_size", w.batchSize, "lease", w.lease)
+	w.wg.Add(1)
+	go w.run()
+}
+
+// Stop stops the polling loop and waits for it to exit. The notification being dispatched
+// finishes and is marked sent or failed; the rest of the claimed batch is released so
+// another replica can send it without waiting for the lease to expire.
+func (w *ScheduledNotificationWorker) Stop() {
+	w.closeOnce.Do(func() { close(w.done) })
+	w.wg.Wait()
+}
+
+func (w *ScheduledNotificationWorker) run() {
+	defer w.wg.Done()
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-w.done:
+			return
+		case <-ticker.C:
+			w.processDue()
+		}
+	}
+}
+
+// processDue claims and dispatches all due notifications for a single poll.
+func (w *ScheduledNotificationWorker) processDue() {
+	claimed, err := w.claimDue()
+	if err != nil {
+		slog.Error("Failed to claim scheduled notifications", "error", err)
+		return
+	}
+	for i, n := range claimed {
+		select {
+		case <-w.done:
+			w.release(claimed[i:])
+			return
+		default:
+		}
+		if !w.renewLease(n) {
+			slog.Warn("Lost lease on scheduled notification, skipping", "id", n.ID, "worker_id", w.workerID)
+			continue
+		}
+		w.dispatch(n)
+	}
+}
+
+// claimDue locks due rows and rows whose lease has expired, claims them for this worker
+// and returns the rows it claimed.
+func (w *ScheduledNotificationWorker) claimDue() ([]models.ScheduledNotification, error) {
+	var claimed []models.ScheduledNotification
+	now := w.clock.Now()
+
+	err := w.db.Transaction(func(tx *gorm.DB) error {
+		var candidates []models.ScheduledNotification
+		if err := claimableScope(tx, now).
+			Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Order("send_at").
+			Limit(w.batchSize).
+			Find(&candidates).Error; err != nil {
+			return err
+		}
+		if len(candidates) == 0 {
+			return nil
+		}
+		ids := make([]int64, len(candidates))
+		reclaimed := 0
+		for i, n := range candidates {
+			ids[i] = n.ID
+			if n.Status == models.ScheduledStatusProcessing {
+				reclaimed++
+			}
+		}
+		if reclaimed > 0 {
+			slog.Warn("Reclaiming scheduled notifications with expired leases", "count", reclaimed, "worker_id", w.workerID)
+		}
+		var err error
+		claimed, err = w.claim(tx, ids, now)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return claimed, nil
+}
+
+// claim takes a lease on the given rows under a new claim token and returns the rows it won.
+// The update repeats the claimable condition, so when two workers race for a row that the
+// database did not lock (e.g. both saw the same expired lease), only the first update
+// matches and the other worker gets nothing back for that row.
+func (w *ScheduledNotificationWorker) claim(tx *gorm.DB, ids []int64, now time.Time) ([]models.ScheduledNotification, error) {
+	token := uuid.NewString()
+	if err := claimableScope(tx.Model(&models.ScheduledNotification{}), now).
+		Where("id IN ?", ids).
+		Updates(map[string]any{
+			"status":           models.ScheduledStatusProcessing,
+			"claimed_by":       w.workerID,
+			"claimed_at":       now,
+			"claim_token":      token,
+			"lease_expires_at": now.Add(w.lease),
+		}).Error; err != nil {
+		return nil, err
+	}
+	var claimed []models.ScheduledNotification
+	if err := tx.Where("claim_token = ?", token).Order("send_at").Find(&claimed).Error; err != nil {
+		return nil, err
+	}
+	return claimed, nil
+}
+
+// claimableScope matches pending rows that are due and processing rows whose lease has expired.
+func claimableScope(db *gorm.DB, now time.Time) *gorm.DB {
+	return db.Where("(status = ? AND send_at <= ?) OR (status = ? AND lease_expires_at < ?)",
+		models.ScheduledStatusPending, now,
+		models.ScheduledStatusProcessing, now)
+}
+
+// leaseScope matches a row only while this worker's claim on it stands.
+func leaseScope(db *gorm.DB, n models.ScheduledNotification) *gorm.DB {
+	return db.Model(&models.ScheduledNotification{}).
+		Where("id = ? AND claim_token = ? AND status = ?", n.ID, n.ClaimToken, models.ScheduledStatusProcessing)
+}
+
+// renewLease extends the lease on a claimed row before it is dispatched. It returns false
+// if the lease expired and another worker has reclaimed the row.
+func (w *ScheduledNotificationWorker) renewLease(n models.ScheduledNotification) bool {
+	result := leaseScope(w.db, n).Update("lease_expires_at", w.clock.Now().Add(w.lease))
+	if result.Error != nil {
+		slog.Error("Failed to renew scheduled notification lease", "error", result.Error, "id", n.ID)
+		return false
+	}
+	return result.RowsAffected == 1
+}
+
+// release hands claimed rows that were not dispatched back to the queue.
+func (w *ScheduledNotificationWorker) release(ns []models.ScheduledNotification) {
+	released := 0
+	for _, n := range ns {
+		result := leaseScope(w.db, n).Updates(map[string]any{
+			"status":           models.ScheduledStatusPending,
+			"claimed_by":       nil,
+			"claimed_at":       nil,
+			"claim_token":      nil,
+			"lease_expires_at": nil,
+		})
+		if result.Error != nil {
+			slog.Error("Failed to release scheduled notification", "error", result.Error, "id", n.ID)
+			continue
+		}
+		released += int(result.RowsAffected)
+	}
+	slog.Info("Released claimed scheduled notifications on shutdown", "count", released, "worker_id", w.workerID)
+}
+
+// dispatch sends a claimed notification and records the outcome.
+func (w *ScheduledNotificationWorker) dispatch(n models.ScheduledNotification) {
+	ctx, cancel := context.WithTimeout(context.Background(), scheduledDispatchTimeout)
+	defer cancel()
+
+	// Users may have opted out of the microapp since the notification was scheduled
+	recipients, skipped, err := FilterOptedOutRecipients(w.db.WithContext(ctx), n.MicroappID, n.UserEmails)
+	if err != nil {
+		w.markFailed(n, fmt.Errorf("failed to check notification preferences: %w", err))
+		return
+	}
+	if skipped > 0 {
+		slog.Info("Skipping opted-out recipients of scheduled notification", "id", n.ID, "skipped", skipped, "microapp_id", n.MicroappID)
+		n.UserEmails = recipients
+	}
+
+	var deviceTokens []models.DeviceToken
+	if err := w.db.WithContext(ctx).
+		Where("user_email IN ? AND is_active = ?", []string(n.UserEmails), true).
+		Find(&deviceTokens).Error; err != nil {
+		w.markFailed(n, fmt.Errorf("failed to fetch device tokens: %w", err))
+		return
+	}
+
+	successCount, failureCount := 0, 0
+	if len(deviceTokens) > 0 {
+		var deadTokens []string
+		var err error
+		successCount, failureCount, deadTokens, err = SendToDevices(ctx, w.notificationService, deviceTokens, n.Title, n.Body, toStringMap(n.Data))
+		if err != nil {
+			w.markFailed(n, err)
+			return
+		}
+		PruneInvalidTokens(ctx, w.invalidTokens, deadTokens)
+	} else {
+		slog.Warn("No active device tokens found for scheduled notification", "id", n.ID, "microapp_id", n.MicroappID)
+	}
+
+	now := w.clock.Now()
+	result := leaseScope(w.db, n).Updates(map[string]any{
+		"status":        models.ScheduledStatusSent,
+		"sent_at":       now,
+		"success_count": successCount,
+		"failure_count": failureCount,
+	})
+	if result.Error != nil {
+		slog.Error("Failed to mark scheduled notification as sent", "error", result.Error, "id", n.ID)
+	} else if result.RowsAffected == 0 {
+		slog.Warn("Lease on scheduled notification expired during dispatch", "id", n.ID, "worker_id", w.workerID)
+	}
+
+	status := notificationLogStatusSent
+	if failureCount > 0 {
+		status = notificationLogStatusPartialFailure
+	}
+	w.logNotifications(n, status)
+	slog.Info("Scheduled notification sent", "id", n.ID, "microapp_id", n.MicroappID, "success", successCount, "failed", failureCount)
+}
+
+// markFailed records a dispatch failure for a claimed notification.
+func (w *ScheduledNotificationWorker) markFailed(n models.ScheduledNotification, dispatchErr error) {
+	slog.Error("Failed to dispatch scheduled notification", "error", dispatchErr, "id", n.ID, "microapp_id", n.MicroappID)
+	errMsg := dispatchErr.Error()
+	if err := leaseScope(w.db, n).
+		Updates(map[string]any{
+			"status":     models.ScheduledStatusFailed,
+			"last_error": errMsg,
+		}).Error; err != nil {
+		slog.Error("Failed to mark scheduled notification as failed", "error", err, "id", n.ID)
+	}
+}
+
+// logNotifications writes a notification log entry per recipient.
+func (w *ScheduledNotificationWorker) logNotifications(n models.ScheduledNotification, status string) {
+	for _, email := range n.UserEmails {
+		log := models.NotificationLog{
+			UserEmail:  email,
+			Title:      &n.Title,
+			Body:       &n.Body,
+			Data:       n.Data,
+			Status:     &status,
+			MicroappID: &n.MicroappID,
+		}
+		if err := w.db.Create(&log).Error; err != nil {
+			slog.Error("Failed to log notification", "error", err, "email", email)
+		}
+	}
+}
+
+// toStringMap converts stored notification data back into the FCM data payload format.
+func toStringMap(data models.JSONMap) map[string]string {
+	result := make(map[string]string, len(data))
+	for k, v := range data {
+		if str, ok := v.(string); ok {
+			result[k] = str
+		} else {
+			result[k] = fmt.Sprint(v)
+		}
+	}
+	return result
+}
@@ -0,0 +1,363 @@
+// Copyright (c) 2025 WSO2 LLC. (https://www.wso2.com).
+//
+// WSO2 LLC. licenses this file to you under the Apache License,
+// Version 2.0 (the "License"); you may not use this file except
+// in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+package services
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/opensuperapp/opensuperapp/backend-services/core/internal/clocktest"
+	"github.com/opensuperapp/opensuperapp/backend-services/core/internal/models"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// setupSchedulerDB creates an in-memory SQLite database with the tables the scheduled notification worker uses
+func setupSchedulerDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("Failed to open test database: %v", err)
+	}
+	if err := db.AutoMigrate(&models.ScheduledNotification{}, &models.NotificationLog{}, &models.NotificationPreference{}); err != nil {
+		t.Fatalf("Failed to migrate database: %v", err)
+	}
+	// device_tokens uses a MySQL enum column, which SQLite cannot parse, so it is created by hand
+	if err := db.Exec(`CREATE TABLE device_tokens (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		user_email VARCHAR(255) NOT NULL,
+		device_token TEXT NOT NULL,
+		platform VARCHAR(10) NOT NULL,
+		app_build INTEGER,
+		last_seen_at DATETIME,
+		created_at DATETIME,
+		updated_at DATETIME,
+		is_active BOOLEAN NOT NULL DEFAULT 1
+	)`).Error; err != nil {
+		t.Fatalf("Failed to create device_tokens table: %v", err)
+	}
+	return db
+}
+
+// seedScheduled inserts a pending scheduled notification for the given users
+func seedScheduled(t *testing.T, db *gorm.DB, sendAt time.Time, emails ...string) models.ScheduledNotification {
+	t.Helper()
+	n := models.ScheduledNotification{
+		MicroappID: "app-1",
+		UserEmails: emails,
+		Title:      "Reminder",
+		Body:       "Meeting in 10 minutes",
+		Data:       models.JSONMap{"meetingId": "42"},
+		SendAt:     sendAt,
+		Status:     models.ScheduledStatusPending,
+	}
+	if err := db.Create(&n).Error; err != nil {
+		t.Fatalf("Failed to seed scheduled notification: %v", err)
+	}
+	return n
+}
+
+// TestScheduledNotificationWorker_DispatchesDue tests that due notifications are sent, marked sent and logged,
+// that dead tokens are deactivated and that notifications not yet due are left pending
+func TestScheduledNotificationWorker_DispatchesDue(t *testing.T) {
+	db := setupSchedulerDB(t)
+	devices := []models.DeviceToken{
+		{UserEmail: "alice@example.com", DeviceToken: "alice-live", Platform: "android", IsActive: true},
+		{UserEmail: "alice@example.com", DeviceToken: "alice-dead", Platform: "android", IsActive: true},
+		{UserEmail: "bob@example.com", DeviceToken: "bob-live", Platform: "android", IsActive: true},
+	}
+	if err := db.Create(&devices).Error; err != nil {
+		t.Fatalf("Failed to seed device tokens: %v", err)
+	}
+	due := seedScheduled(t, db, time.Now().Add(-time.Minute), "alice@example.com", "bob@example.com")
+	later := seedScheduled(t, db, time.Now().Add(time.Hour), "alice@example.com")
+	provider := &fakeProvider{deadTokens: []string{"alice-dead"}}
+	w := NewScheduledNotificationWorker(db, provider, time.Minute, 10, 10*time.Minute)
+
+	w.processDue()
+
+	if len(provider.tokens) != 3 {
+		t.Errorf("Expected the due notification to be sent to 3 devices, got %v", provider.tokens)
+	}
+	var sent models.ScheduledNotification
+	db.First(&sent, due.ID)
+	if sent.Status != models.ScheduledStatusSent || sent.SentAt == nil {
+		t.Errorf("Expected the due notification to be marked sent, got status %q", sent.Status)
+	}
+	if sent.SuccessCount != 2 || sent.FailureCount != 1 {
+		t.Errorf("Expected 2 successes and 1 failure, got %d and %d", sent.SuccessCount, sent.FailureCount)
+	}
+	var pending models.ScheduledNotification
+	db.First(&pending, later.ID)
+	if pending.Status != models.ScheduledStatusPending {
+		t.Errorf("Expected the later notification to stay pending, got %q", pending.Status)
+	}
+
+	var logs []models.NotificationLog
+	db.Order("user_email").Find(&logs)
+	if len(logs) != 2 || logs[0].UserEmail != "alice@example.com" || *logs[0].Status != notificationLogStatusPartialFailure {
+		t.Errorf("Expected a partial failure log entry per recipient, got %+v", logs)
+	}
+	var active []string
+	db.Model(&models.DeviceToken{}).Where("is_active = ?", true).Order("id").Pluck("device_token", &active)
+	if len(active) != 2 || active[0] != "alice-live" || active[1] != "bob-live" {
+		t.Errorf("Expected the dead token to be deactivated, got active %v", active)
+	}
+
+	// A second poll finds nothing due
+	provider.tokens = nil
+	w.processDue()
+	if provider.tokens != nil {
+		t.Errorf("Expected nothing to be sent twice, got %v", provider.tokens)
+	}
+}
+
+// TestScheduledNotificationWorker_Clock tests that the worker's clock decides when a notification is due
+// and when its lease expires
+func TestScheduledNotificationWorker_Clock(t *testing.T) {
+	db := setupSchedulerDB(t)
+	if err := db.Create(&models.DeviceToken{UserEmail: "alice@example.com", DeviceToken: "alice-live", Platform: "android", IsActive: true}).Error; err != nil {
+		t.Fatalf("Failed to seed device token: %v", err)
+	}
+	start := time.Date(2025, 1, 15, 10, 0, 0, 0, time.UTC)
+	n := seedScheduled(t, db, start.Add(time.Hour), "alice@example.com")
+	clock := clocktest.NewFakeClock(start)
+	provider := &fakeProvider{}
+	w := NewScheduledNotificationWorker(db, provider, time.Minute, 10, 10*time.Minute).WithClock(clock)
+
+	w.processDue()
+	if provider.tokens != nil {
+		t.Fatalf("Expected nothing to be sent before the send time, got %v", provider.tokens)
+	}
+
+	clock.Advance(time.Hour)
+	w.processDue()
+	if len(provider.tokens) != 1 {
+		t.Fatalf("Expected the notification to be sent once due, got %v", provider.tokens)
+	}
+	var sent models.ScheduledNotification
+	db.First(&sent, n.ID)
+	if sent.SentAt == nil || !sent.SentAt.Equal(start.Add(time.Hour)) {
+		t.Errorf("Expected sent_at %v, got %v", start.Add(time.Hour), sent.SentAt)
+	}
+	if sent.LeaseExpiresAt == nil || !sent.LeaseExpiresAt.Equal(start.Add(time.Hour+10*time.Minute)) {
+		t.Errorf("Expected the lease to expire at %v, got %v", start.Add(time.Hour+10*time.Minute), sent.LeaseExpiresAt)
+	}
+}
+
+// TestScheduledNotificationWorker_SendError tests that a failed send marks the notification failed with the error
+func TestScheduledNotificationWorker_SendError(t *testing.T) {
+	db := setupSchedulerDB(t)
+	if err := db.Create(&models.DeviceToken{UserEmail: "alice@example.com", DeviceToken: "alice-live", Platform: "android", IsActive: true}).Error; err != nil {
+		t.Fatalf("Failed to seed device token: %v", err)
+	}
+	n := seedScheduled(t, db, time.Now().Add(-time.Minute), "alice@example.com")
+	w := NewScheduledNotificationWorker(db, &fakeProvider{err: errors.New("fcm unavailable")}, time.Minute, 10, 10*time.Minute)
+
+	w.processDue()
+
+	var failed models.ScheduledNotification
+	db.First(&failed, n.ID)
+	if failed.Status != models.ScheduledStatusFailed {
+		t.Errorf("Expected status failed, got %q", failed.Status)
+	}
+	if failed.LastError == nil || *failed.LastError != "fcm unavailable" {
+		t.Errorf("Expected the send error to be recorded, got %v", failed.LastError)
+	}
+	var logs int64
+	db.Model(&models.NotificationLog{}).Count(&logs)
+	if logs != 0 {
+		t.Errorf("Expected no notification log entries for a failed send, got %d", logs)
+	}
+}
+
+// TestScheduledNotificationWorker_NoDevices tests that a notification whose users have no active device is still marked sent
+func TestScheduledNotificationWorker_NoDevices(t *testing.T) {
+	db := setupSchedulerDB(t)
+	n := seedScheduled(t, db, time.Now().Add(-time.Minute), "nobody@example.com")
+	provider := &fakeProvider{}
+	w := NewScheduledNotificationWorker(db, provider, time.Minute, 10, 10*time.Minute)
+
+	w.processDue()
+
+	var sent models.ScheduledNotification
+	db.First(&sent, n.ID)
+	if sent.Status != models.ScheduledStatusSent || sent.SuccessCount != 0 {
+		t.Errorf("Expected status sent with no successes, got %q and %d", sent.Status, sent.SuccessCount)
+	}
+	if provider.tokens != nil {
+		t.Errorf("Expected nothing to be sent, got %v", provider.tokens)
+	}
+}
+
+// TestScheduledNotificationWorker_Stop tests that Stop waits for the polling loop to exit and may be called twice
+func TestScheduledNotificationWorker_Stop(t *testing.T) {
+	db := setupSchedulerDB(t)
+	w := NewScheduledNotificationWorker(db, &fakeProvider{}, time.Millisecond, 10, 10*time.Minute)
+	w.Start()
+
+	stopped := make(chan struct{})
+	go func() {
+		w.Stop()
+		w.Stop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Stop did not return")
+	}
+}
+
+// newLeaseTestWorker creates a worker with a fixed ID so tests can tell competing workers apart
+func newLeaseTestWorker(db *gorm.DB, provider *fakeProvider, workerID string) *ScheduledNotificationWorker {
+	w := NewScheduledNotificationWorker(db, provider, time.Minute, 10, 10*time.Minute)
+	w.workerID = workerID
+	return w
+}
+
+// TestScheduledNotificationWorker_ReclaimsExpiredLease tests that a notification claimed by a worker that stalled
+// past its lease is reclaimed and sent by another worker, and that the stalled worker can no longer update it
+func TestScheduledNotificationWorker_ReclaimsExpiredLease(t *testing.T) {
+	db := setupSchedulerDB(t)
+	if err := db.Create(&models.DeviceToken{UserEmail: "alice@example.com", DeviceToken: "alice-live", Platform: "android", IsActive: true}).Error; err != nil {
+		t.Fatalf("Failed to seed device token: %v", err)
+	}
+	n := seedScheduled(t, db, time.Now().Add(-time.Minute), "alice@example.com")
+	stalled := newLeaseTestWorker(db, &fakeProvider{}, "worker-a")
+	provider := &fakeProvider{}
+	other := newLeaseTestWorker(db, provider, "worker-b")
+
+	claimed, err := stalled.claimDue()
+	if err != nil || len(claimed) != 1 {
+		t.Fatalf("Expected worker-a to claim 1 notification, got %d (%v)", len(claimed), err)
+	}
+
+	// worker-b leaves a live lease alone
+	other.processDue()
+	if provider.tokens != nil {
+		t.Fatalf("Expected a leased notification not to be reclaimed, got %v", provider.tokens)
+	}
+
+	// worker-a stalls until its lease expires
+	db.Model(&models.ScheduledNotification{}).Where("id = ?", n.ID).Update("lease_expires_at", time.Now().Add(-time.Second))
+	other.processDue()
+	if len(provider.tokens) != 1 {
+		t.Fatalf("Expected worker-b to send the reclaimed notification, got %v", provider.tokens)
+	}
+	var sent models.ScheduledNotification
+	db.First(&sent, n.ID)
+	if sent.Status != models.ScheduledStatusSent || sent.ClaimedBy == nil || *sent.ClaimedBy != "worker-b" {
+		t.Fatalf("Expected the notification to be sent by worker-b, got status %q claimed by %v", sent.Status, sent.ClaimedBy)
+	}
+
+	// worker-a resumes: its lease is gone, so it neither sends nor overwrites the outcome
+	if stalled.renewLease(claimed[0]) {
+		t.Error("Expected worker-a to have lost its lease")
+	}
+	stalled.markFailed(claimed[0], errors.New("late failure"))
+	db.First(&sent, n.ID)
+	if sent.Status != models.ScheduledStatusSent || sent.LastError != nil {
+		t.Errorf("Expected worker-a's late update to be ignored, got status %q error %v", sent.Status, sent.LastError)
+	}
+}
+
+// TestScheduledNotificationWorker_ClaimRace tests that when two workers try to claim the same row,
+// for example after both saw its lease expire, only one of them wins it
+func TestScheduledNotificationWorker_ClaimRace(t *testing.T) {
+	db := setupSchedulerDB(t)
+	n := seedScheduled(t, db, time.Now().Add(-time.Minute), "alice@example.com")
+	a := newLeaseTestWorker(db, &fakeProvider{}, "worker-a")
+	b := newLeaseTestWorker(db, &fakeProvider{}, "worker-b")
+	ids := []int64{n.ID}
+
+	now := time.Now()
+	wonA, err := a.claim(db, ids, now)
+	if err != nil || len(wonA) != 1 {
+		t.Fatalf("Expected worker-a to win the pending row, got %d (%v)", len(wonA), err)
+	}
+	if wonB, _ := b.claim(db, ids, now); len(wonB) != 0 {
+		t.Errorf("Expected worker-b to lose the race, got %d rows", len(wonB))
+	}
+
+	// Both see the lease expire; the first to update wins and the other gets nothing
+	expired := now.Add(a.lease + time.Second)
+	wonB, err := b.claim(db, ids, expired)
+	if err != nil || len(wonB) != 1 {
+		t.Fatalf("Expected worker-b to reclaim the expired row, got %d (%v)", len(wonB), err)
+	}
+	if again, _ := a.claim(db, ids, expired); len(again) != 0 {
+		t.Errorf("Expected worker-a to lose the reclaim race, got %d rows", len(again))
+	}
+	if *wonA[0].ClaimToken == *wonB[0].ClaimToken {
+		t.Error("Expected each claim to get its own token")
+	}
+	if a.renewLease(wonA[0]) {
+		t.Error("Expected worker-a's original claim to be fenced off")
+	}
+	if !b.renewLease(wonB[0]) {
+		t.Error("Expected worker-b to hold the lease")
+	}
+}
+
+// TestScheduledNotificationWorker_StopReleasesClaims tests that a stopping worker hands back notifications it
+// claimed but has not sent, so another worker sends them without waiting for the lease to expire
+func TestScheduledNotificationWorker_StopReleasesClaims(t *testing.T) {
+	db := setupSchedulerDB(t)
+	if err := db.Create(&models.DeviceToken{UserEmail: "alice@example.com", DeviceToken: "alice-live", Platform: "android", IsActive: true}).Error; err != nil {
+		t.Fatalf("Failed to seed device token: %v", err)
+	}
+	seedScheduled(t, db, time.Now().Add(-2*time.Minute), "alice@example.com")
+	seedScheduled(t, db, time.Now().Add(-time.Minute), "alice@example.com")
+	stopping := newLeaseTestWorker(db, &fakeProvider{}, "worker-a")
+	stopping.closeOnce.Do(func() { close(stopping.done) })
+
+	stopping.processDue()
+
+	var rows []models.ScheduledNotification
+	db.Order("id").Find(&rows)
+	for _, n := range rows {
+		if n.Status != models.ScheduledStatusPending || n.ClaimToken != nil || n.LeaseExpiresAt != nil {
+			t.Errorf("Expected notification %d to be released, got status %q token %v", n.ID, n.Status, n.ClaimToken)
+		}
+	}
+
+	provider := &fakeProvider{}
+	newLeaseTestWorker(db, provider, "worker-b").processDue()
+	if len(provider.tokens) != 2 {
+		t.Errorf("Expected the released notifications to be sent by another worker, got %v", provider.tokens)
+	}
+}
+
+// TestNewScheduledNotificationWorker_MinimumLease tests that a lease shorter than a dispatch is raised to the minimum
+func TestNewScheduledNotificationWorker_MinimumLease(t *testing.T) {
+	w := NewScheduledNotificationWorker(setupSchedulerDB(t), &fakeProvider{}, time.Minute, 10, time.Second)
+	if w.lease != minScheduledLease {
+		t.Errorf("Expected lease %s, got %s", minScheduledLease, w.lease)
+	}
+}
+
+// TestNewScheduledNotificationWorker_NonPositiveSettings tests that a non-positive interval or batch size falls back to the default
+func TestNewScheduledNotificationWorker_NonPositiveSettings(t *testing.T) {
+	w := NewScheduledNotificationWorker(setupSchedulerDB(t), &fakeProvider{}, 0, -1, 10*time.Minute)
+	if w.interval != defaultScheduledPollInterval {
+		t.Errorf("Expected interval %s, got %s", defaultScheduledPollInterval, w.interval)
+	}
+	if w.batchSize != defaultScheduledBatchSize {
+		t.Errorf("Expected batch size %d, got %d", defaultScheduledBatchSize, w.batchSize)
+	}
+}
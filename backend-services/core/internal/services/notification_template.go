@@ -0,0 +1,111 @@
+// Copyright (c) 2025 WSO2 LLC. (https://www.wso2.com).
+//
+// WSO2 LLC. licenses this file to you under the Apache License,
+// Version 2.0 (the "License"); you may not use this file except
+// in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"text/template"
+
+	"github.com/opensuperapp/opensuperapp/backend-services/core/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// ErrNotificationTemplateNotFound is returned when a microapp has no template with the requested key.
+var ErrNotificationTemplateNotFound = errors.New("notification template not found")
+
+// CompiledNotificationTemplate is a parsed notification template ready to render.
+type CompiledNotificationTemplate struct {
+	title *template.Template
+	body  *template.Template
+}
+
+// CompileNotificationTemplate parses the title and body sources of a template.
+// Placeholders use text/template syntax, e.g. "Hi {{.firstName}}". Rendering fails
+// when a placeholder has no variable rather than producing "<no value>".
+func CompileNotificationTemplate(titleSource, bodySource string) (*CompiledNotificationTemplate, error) {
+	title, err := template.New("title").Option("missingkey=error").Parse(titleSource)
+	if err != nil {
+		return nil, fmt.Errorf("invalid title template: %w", err)
+	}
+	body, err := template.New("body").Option("missingkey=error").Parse(bodySource)
+	if err != nil {
+		return nil, fmt.Errorf("invalid body template: %w", err)
+	}
+	return &CompiledNotificationTemplate{title: title, body: body}, nil
+}
+
+// CompilePlatformVariants compiles a template and its platform overrides. The base template is
+// returned under the empty platform; an override without a title or body uses the base's source.
+func CompilePlatformVariants(titleSource, bodySource string, overrides models.PlatformOverrides) (map[string]*CompiledNotificationTemplate, error) {
+	base, err := CompileNotificationTemplate(titleSource, bodySource)
+	if err != nil {
+		return nil, err
+	}
+	variants := map[string]*CompiledNotificationTemplate{"": base}
+	for platform, override := range overrides {
+		title, body := override.Title, override.Body
+		if title == "" {
+			title = titleSource
+		}
+		if body == "" {
+			body = bodySource
+		}
+		variant, err := CompileNotificationTemplate(title, body)
+		if err != nil {
+			return nil, fmt.Errorf("%s override: %w", platform, err)
+		}
+		variants[platform] = variant
+	}
+	return variants, nil
+}
+
+// Render returns the title and body with the placeholders replaced by the given variables.
+func (t *CompiledNotificationTemplate) Render(vars map[string]string) (string, string, error) {
+	if vars == nil {
+		vars = map[string]string{}
+	}
+	var title, body strings.Builder
+	if err := t.title.Execute(&title, vars); err != nil {
+		return "", "", fmt.Errorf("failed to render title: %w", err)
+	}
+	if err := t.body.Execute(&body, vars); err != nil {
+		return "", "", fmt.Errorf("failed to render body: %w", err)
+	}
+	return title.String(), body.String(), nil
+}
+
+// FindNotificationTemplate returns the microapp's template for the key in the closest
+// available locale: the exact locale, then its base language ("en-US" falls back to "en"),
+// then the default translation with an empty locale.
+func FindNotificationTemplate(db *gorm.DB, microappID, key, locale string) (models.NotificationTemplate, error) {
+	candidates := localeCandidates(locale)
+	var templates []models.NotificationTemplate
+	if err := db.Where("microapp_id = ? AND template_key = ? AND locale IN ?", microappID, key, candidates).
+		Find(&templates).Error; err != nil {
+		return models.NotificationTemplate{}, err
+	}
+	for _, candidate := range candidates {
+		for _, tmpl := range templates {
+			if strings.EqualFold(tmpl.Locale, candidate) {
+				return tmpl, nil
+			}
+		}
+	}
+	return models.NotificationTemplate{}, ErrNotificationTemplateNotFound
+}
@@ -0,0 +1,92 @@
+// Copyright (c) 2025 WSO2 LLC. (https://www.wso2.com).
+//
+// WSO2 LLC. licenses this file to you under the Apache License,
+// Version 2.0 (the "License"); you may not use this file except
+// in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+package services
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/opensuperapp/opensuperapp/backend-services/core/internal/models"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// TestFindNotificationTemplate_Render tests rendering the default translation of a stored template, including missing variables and keys
+func TestFindNotificationTemplate_Render(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("Failed to open test database: %v", err)
+	}
+	if err := db.AutoMigrate(&models.NotificationTemplate{}); err != nil {
+		t.Fatalf("Failed to migrate notification templates: %v", err)
+	}
+	templates := []models.NotificationTemplate{
+		{MicroappID: "app-1", TemplateKey: "leave", TitleTemplate: "Leave {{.status}}", BodyTemplate: "Your leave on {{.date}} was {{.status}}"},
+		{MicroappID: "app-1", TemplateKey: "leave", Locale: "fr", TitleTemplate: "Congé {{.status}}", BodyTemplate: "Votre congé"},
+	}
+	if err := db.Create(&templates).Error; err != nil {
+		t.Fatalf("Failed to seed templates: %v", err)
+	}
+
+	stored, err := FindNotificationTemplate(db, "app-1", "leave", "")
+	if err != nil {
+		t.Fatalf("Expected the default translation to be found, got %v", err)
+	}
+	tmpl, err := CompileNotificationTemplate(stored.TitleTemplate, stored.BodyTemplate)
+	if err != nil {
+		t.Fatalf("Expected the template to compile, got %v", err)
+	}
+	title, body, err := tmpl.Render(map[string]string{"status": "approved", "date": "2 May"})
+	if err != nil {
+		t.Fatalf("Expected the template to render, got %v", err)
+	}
+	if title != "Leave approved" || body != "Your leave on 2 May was approved" {
+		t.Errorf("Unexpected rendering: %q / %q", title, body)
+	}
+
+	if _, _, err := tmpl.Render(map[string]string{"status": "approved"}); err == nil {
+		t.Error("Expected a render error for a missing variable")
+	}
+	if _, _, err := tmpl.Render(nil); err == nil {
+		t.Error("Expected a render error without variables")
+	}
+	if _, err := FindNotificationTemplate(db, "app-2", "leave", ""); !errors.Is(err, ErrNotificationTemplateNotFound) {
+		t.Errorf("Expected another microapp's template not to be found, got %v", err)
+	}
+}
+
+// TestCompilePlatformVariants tests that overrides replace only the parts they set
+func TestCompilePlatformVariants(t *testing.T) {
+	variants, err := CompilePlatformVariants("Leave {{.status}}", "Your leave was {{.status}}", models.PlatformOverrides{
+		"ios": {Body: "Leave {{.status}}"},
+	})
+	if err != nil {
+		t.Fatalf("Expected the variants to compile, got %v", err)
+	}
+	vars := map[string]string{"status": "approved"}
+	title, body, _ := variants["ios"].Render(vars)
+	if title != "Leave approved" || body != "Leave approved" {
+		t.Errorf("Unexpected ios rendering: %q / %q", title, body)
+	}
+	title, body, _ = variants[""].Render(vars)
+	if title != "Leave approved" || body != "Your leave was approved" {
+		t.Errorf("Unexpected base rendering: %q / %q", title, body)
+	}
+
+	if _, err := CompilePlatformVariants("Title", "Body", models.PlatformOverrides{"web": {Title: "{{.status"}}); err == nil {
+		t.Error("Expected an override that does not parse to fail")
+	}
+}
@@ -0,0 +1,134 @@
+// Copyright (c) 2025 WSO2 LLC. (https://www.wso2.com).
+//
+// WSO2 LLC. licenses this file to you under the Apache License,
+// Version 2.0 (the "License"); you may not use this file except
+// in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+package services
+
+import (
+	"crypto/ed25519"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"os"
+	"time"
+)
+
+// ReceiptSigner signs and verifies notification delivery receipts with an Ed25519 key.
+// Receipts let operators prove later that a notification was attempted or delivered
+// to a recipient with a specific content, which is required for regulated notices.
+type ReceiptSigner struct {
+	privateKey ed25519.PrivateKey
+	publicKey  ed25519.PublicKey
+	keyID      string
+}
+
+// ReceiptContent holds the fields covered by a receipt signature.
+type ReceiptContent struct {
+	MicroappID  string    `json:"microappId"`
+	Recipient   string    `json:"recipient"`
+	ContentHash string    `json:"contentHash"`
+	Status      string    `json:"status"`
+	IssuedAt    time.Time `json:"issuedAt"`
+}
+
+// NewReceiptSigner creates a ReceiptSigner from an Ed25519 private key.
+func NewReceiptSigner(privateKey ed25519.PrivateKey) *ReceiptSigner {
+	publicKey := privateKey.Public().(ed25519.PublicKey)
+	sum := sha256.Sum256(publicKey)
+	return &ReceiptSigner{
+		privateKey: privateKey,
+		publicKey:  publicKey,
+		keyID:      hex.EncodeToString(sum[:8]),
+	}
+}
+
+// NewReceiptSignerFromFile loads a PKCS#8 PEM encoded Ed25519 private key from disk.
+func NewReceiptSignerFromFile(keyPath string) (*ReceiptSigner, error) {
+	data, err := os.ReadFile(keyPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read receipt signing key: %w", err)
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("receipt signing key is not PEM encoded")
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse receipt signing key: %w", err)
+	}
+	privateKey, ok := key.(ed25519.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("receipt signing key must be an Ed25519 key")
+	}
+	return NewReceiptSigner(privateKey), nil
+}
+
+// KeyID returns a short identifier derived from the public key.
+func (s *ReceiptSigner) KeyID() string {
+	return s.keyID
+}
+
+// Sign returns the base64url encoded signature over the canonical receipt content.
+func (s *ReceiptSigner) Sign(content ReceiptContent) (string, error) {
+	payload, err := canonicalReceipt(content)
+	if err != nil {
+		return "", err
+	}
+	signature := ed25519.Sign(s.privateKey, payload)
+	return base64.RawURLEncoding.EncodeToString(signature), nil
+}
+
+// Verify reports whether the signature matches the receipt content.
+// Any change to the content or signature makes verification fail.
+func (s *ReceiptSigner) Verify(content ReceiptContent, signature string) bool {
+	sig, err := base64.RawURLEncoding.DecodeString(signature)
+	if err != nil {
+		return false
+	}
+	payload, err := canonicalReceipt(content)
+	if err != nil {
+		return false
+	}
+	return ed25519.Verify(s.publicKey, payload, sig)
+}
+
+// HashNotificationContent returns the hex encoded SHA-256 hash of the notification content.
+// The data map is marshalled with sorted keys, so the hash is deterministic.
+func HashNotificationContent(title, body string, data map[string]string) (string, error) {
+	payload, err := json.Marshal(struct {
+		Title string            `json:"title"`
+		Body  string            `json:"body"`
+		Data  map[string]string `json:"data,omitempty"`
+	}{Title: title, Body: body, Data: data})
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal notification content: %w", err)
+	}
+	sum := sha256.Sum256(payload)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// canonicalReceipt serializes the receipt content in a stable form for signing.
+// IssuedAt is normalized to UTC with second precision so the value survives a database round-trip.
+func canonicalReceipt(content ReceiptContent) ([]byte, error) {
+	content.IssuedAt = content.IssuedAt.UTC().Truncate(time.Second)
+	payload, err := json.Marshal(content)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal receipt: %w", err)
+	}
+	return payload, nil
+}
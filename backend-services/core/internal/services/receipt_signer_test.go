@@ -0,0 +1,184 @@
+// Copyright (c) 2025 WSO2 LLC. (https://www.wso2.com).
+//
+// WSO2 LLC. licenses this file to you under the Apache License,
+// Version 2.0 (the "License"); you may not use this file except
+// in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+package services
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// newTestReceiptSigner creates a ReceiptSigner with a freshly generated key
+func newTestReceiptSigner(t *testing.T) *ReceiptSigner {
+	_, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+	return NewReceiptSigner(privateKey)
+}
+
+// newTestReceipt returns receipt content for a sample notification
+func newTestReceipt(t *testing.T) ReceiptContent {
+	hash, err := HashNotificationContent("Legal notice", "Terms updated", map[string]string{"microappId": "legal"})
+	if err != nil {
+		t.Fatalf("Failed to hash content: %v", err)
+	}
+	return ReceiptContent{
+		MicroappID:  "legal",
+		Recipient:   "user@example.com",
+		ContentHash: hash,
+		Status:      "sent",
+		IssuedAt:    time.Now(),
+	}
+}
+
+// TestReceiptSignAndVerify tests that a signed receipt verifies
+func TestReceiptSignAndVerify(t *testing.T) {
+	signer := newTestReceiptSigner(t)
+	receipt := newTestReceipt(t)
+
+	signature, err := signer.Sign(receipt)
+	if err != nil {
+		t.Fatalf("Failed to sign receipt: %v", err)
+	}
+	if signature == "" {
+		t.Fatal("Signature is empty")
+	}
+	if !signer.Verify(receipt, signature) {
+		t.Error("Expected receipt to verify")
+	}
+}
+
+// TestReceiptVerifyAfterPrecisionLoss tests that a receipt still verifies after
+// IssuedAt loses sub-second precision (as it does when stored in the database)
+func TestReceiptVerifyAfterPrecisionLoss(t *testing.T) {
+	signer := newTestReceiptSigner(t)
+	receipt := newTestReceipt(t)
+	receipt.IssuedAt = time.Date(2025, 1, 2, 3, 4, 5, 987654321, time.FixedZone("IST", 19800))
+
+	signature, err := signer.Sign(receipt)
+	if err != nil {
+		t.Fatalf("Failed to sign receipt: %v", err)
+	}
+
+	stored := receipt
+	stored.IssuedAt = receipt.IssuedAt.UTC().Truncate(time.Second)
+	if !signer.Verify(stored, signature) {
+		t.Error("Expected receipt to verify after truncating IssuedAt")
+	}
+}
+
+// TestReceiptTamperDetection tests that any change to the receipt is detected
+func TestReceiptTamperDetection(t *testing.T) {
+	signer := newTestReceiptSigner(t)
+	receipt := newTestReceipt(t)
+
+	signature, err := signer.Sign(receipt)
+	if err != nil {
+		t.Fatalf("Failed to sign receipt: %v", err)
+	}
+
+	tamperedHash, err := HashNotificationContent("Legal notice", "Terms NOT updated", map[string]string{"microappId": "legal"})
+	if err != nil {
+		t.Fatalf("Failed to hash content: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		modify func(r *ReceiptContent)
+	}{
+		{"recipient", func(r *ReceiptContent) { r.Recipient = "attacker@example.com" }},
+		{"microapp", func(r *ReceiptContent) { r.MicroappID = "other" }},
+		{"content hash", func(r *ReceiptContent) { r.ContentHash = tamperedHash }},
+		{"status", func(r *ReceiptContent) { r.Status = "failed" }},
+		{"issued at", func(r *ReceiptContent) { r.IssuedAt = r.IssuedAt.Add(time.Hour) }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tampered := receipt
+			tt.modify(&tampered)
+			if signer.Verify(tampered, signature) {
+				t.Errorf("Expected verification to fail after tampering with %s", tt.name)
+			}
+		})
+	}
+
+	// Tampered signature
+	tamperedSig := []byte(signature)
+	if tamperedSig[0] == 'A' {
+		tamperedSig[0] = 'B'
+	} else {
+		tamperedSig[0] = 'A'
+	}
+	if signer.Verify(receipt, string(tamperedSig)) {
+		t.Error("Expected verification to fail with tampered signature")
+	}
+
+	// Signature from a different key
+	otherSigner := newTestReceiptSigner(t)
+	if otherSigner.Verify(receipt, signature) {
+		t.Error("Expected verification to fail with a different key")
+	}
+}
+
+// TestHashNotificationContentDeterministic tests that data ordering does not change the hash
+func TestHashNotificationContentDeterministic(t *testing.T) {
+	a, err := HashNotificationContent("t", "b", map[string]string{"a": "1", "b": "2", "c": "3"})
+	if err != nil {
+		t.Fatalf("Failed to hash content: %v", err)
+	}
+	b, err := HashNotificationContent("t", "b", map[string]string{"c": "3", "a": "1", "b": "2"})
+	if err != nil {
+		t.Fatalf("Failed to hash content: %v", err)
+	}
+	if a != b {
+		t.Errorf("Expected identical hashes, got %s and %s", a, b)
+	}
+}
+
+// TestNewReceiptSignerFromFile tests loading a PEM encoded key from disk
+func TestNewReceiptSignerFromFile(t *testing.T) {
+	_, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(privateKey)
+	if err != nil {
+		t.Fatalf("Failed to marshal key: %v", err)
+	}
+	keyPath := filepath.Join(t.TempDir(), "receipt_private.pem")
+	if err := os.WriteFile(keyPath, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), 0600); err != nil {
+		t.Fatalf("Failed to write key: %v", err)
+	}
+
+	signer, err := NewReceiptSignerFromFile(keyPath)
+	if err != nil {
+		t.Fatalf("Failed to load signer: %v", err)
+	}
+	if signer.KeyID() != NewReceiptSigner(privateKey).KeyID() {
+		t.Error("Expected key ID to match the generated key")
+	}
+
+	if _, err := NewReceiptSignerFromFile(filepath.Join(t.TempDir(), "missing.pem")); err == nil {
+		t.Error("Expected error for missing key file")
+	}
+}
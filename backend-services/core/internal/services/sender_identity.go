@@ -0,0 +1,79 @@
+// Copyright (c) 2025 WSO2 LLC. (https://www.wso2.com).
+//
+// WSO2 LLC. licenses this file to you under the Apache License,
+// Version 2.0 (the "License"); you may not use this file except
+// in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+package services
+
+import (
+	"errors"
+	"log/slog"
+
+	"github.com/opensuperapp/opensuperapp/backend-services/core/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// Data payload keys used to attribute a notification to the microapp that sent it.
+const (
+	DataKeySenderName = "senderName"
+	DataKeySenderIcon = "senderIcon"
+)
+
+// SenderIdentity is the display name and icon a client shows for the sender of a notification.
+type SenderIdentity struct {
+	Name    string
+	IconURL string
+}
+
+// ResolveSenderIdentity returns the identity of the given active microapp, or fallback
+// when the microapp is unknown, inactive or cannot be looked up. An empty name or icon
+// on the microapp is filled from fallback so clients always receive a complete identity.
+func ResolveSenderIdentity(db *gorm.DB, microappID string, fallback SenderIdentity) SenderIdentity {
+	if microappID == "" {
+		return fallback
+	}
+
+	var app models.MicroApp
+	err := db.Select("name", "icon_url").
+		Where("micro_app_id = ? AND active = ?", microappID, 1).
+		First(&app).Error
+	if err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			slog.Warn("Failed to resolve sender identity, using default", "microapp_id", microappID, "error", err)
+		}
+		return fallback
+	}
+
+	identity := SenderIdentity{Name: app.Name, IconURL: fallback.IconURL}
+	if identity.Name == "" {
+		identity.Name = fallback.Name
+	}
+	if app.IconURL != nil && *app.IconURL != "" {
+		identity.IconURL = *app.IconURL
+	}
+	return identity
+}
+
+// Apply writes the identity into an FCM data payload, overwriting any sender keys
+// supplied by the caller so a microapp cannot impersonate another sender.
+func (s SenderIdentity) Apply(data map[string]string) {
+	delete(data, DataKeySenderName)
+	delete(data, DataKeySenderIcon)
+	if s.Name != "" {
+		data[DataKeySenderName] = s.Name
+	}
+	if s.IconURL != "" {
+		data[DataKeySenderIcon] = s.IconURL
+	}
+}
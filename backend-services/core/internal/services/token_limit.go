@@ -0,0 +1,75 @@
+// Copyright (c) 2025 WSO2 LLC. (https://www.wso2.com).
+//
+// WSO2 LLC. licenses this file to you under the Apache License,
+// Version 2.0 (the "License"); you may not use this file except
+// in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+package services
+
+import (
+	"context"
+	"errors"
+)
+
+// TokenLimitPolicy selects what a send does with tokens beyond the provider's per-send limit.
+type TokenLimitPolicy string
+
+const (
+	// TokenLimitTruncate sends to the first tokens up to the limit and reports the rest as dropped
+	TokenLimitTruncate TokenLimitPolicy = "truncate"
+	// TokenLimitReject sends nothing and returns ErrTokenLimitExceeded
+	TokenLimitReject TokenLimitPolicy = "reject"
+)
+
+// errTokenDropped is the error reported for a token beyond the per-send limit.
+const errTokenDropped = "dropped: token limit exceeded"
+
+// ErrTokenLimitExceeded is returned, without sending anything, when a send has more unique
+// tokens than the provider allows and the caller chose TokenLimitReject.
+var ErrTokenLimitExceeded = errors.New("token count exceeds the per-send limit")
+
+type tokenLimitPolicyKey struct{}
+
+// WithTokenLimitPolicy returns a context that makes sends started with it apply the given
+// policy. Sends without one truncate.
+func WithTokenLimitPolicy(ctx context.Context, policy TokenLimitPolicy) context.Context {
+	return context.WithValue(ctx, tokenLimitPolicyKey{}, policy)
+}
+
+// TokenLimitPolicyFromContext returns the policy set with WithTokenLimitPolicy, or TokenLimitTruncate.
+// Providers with a per-send limit use it to decide between dropping tokens and rejecting the send.
+func TokenLimitPolicyFromContext(ctx context.Context) TokenLimitPolicy {
+	if policy, ok := ctx.Value(tokenLimitPolicyKey{}).(TokenLimitPolicy); ok && policy != "" {
+		return policy
+	}
+	return TokenLimitTruncate
+}
+
+// DroppedResults reports every token as dropped by the per-send limit.
+func DroppedResults(tokens []string) []DeliveryResult {
+	results := make([]DeliveryResult, len(tokens))
+	for i, token := range tokens {
+		results[i] = DeliveryResult{Token: token, Error: errTokenDropped, Dropped: true}
+	}
+	return results
+}
+
+// CountDroppedResults returns how many tokens were dropped by the per-send limit.
+func CountDroppedResults(results []DeliveryResult) int {
+	dropped := 0
+	for _, result := range results {
+		if result.Dropped {
+			dropped++
+		}
+	}
+	return dropped
+}
@@ -16,6 +16,7 @@
 package services
 
 import (
+	"bytes"
 	"crypto/rsa"
 	"encoding/base64"
 	"encoding/json"
@@ -24,7 +25,10 @@ import (
 	"log/slog"
 	"math/big"
 	"net/http"
+	"sort"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/golang-jwt/jwt/v4"
@@ -34,8 +38,11 @@ const (
 
 	// Timeouts and Intervals
 	defaultHTTPTimeout      = 10 * time.Second
-	jwksRefreshInterval     = 1 * time.Hour
+	defaultJWKSCacheTTL     = 1 * time.Hour
 	jwksLazyRefreshCooldown = 10 * time.Second
+
+	// A failed background refresh is retried after this delay, doubling up to the cache TTL
+	jwksRetryInitialDelay = 1 * time.Second
 )
 
 type RSATokenValidator struct {
@@ -48,8 +55,74 @@ type RSATokenValidator struct {
 	lastRefreshAttempt time.Time
 	httpClient         *http.Client
 	cachedJWKS         json.RawMessage
+	useJSONNumber      bool
+	clockSkew          time.Duration
+	cacheTTL           time.Duration
 	done               chan struct{}
 	closeOnce          sync.Once
+
+	// Diagnostic counters (see CacheStats)
+	cacheHits        atomic.Int64
+	cacheMisses      atomic.Int64
+	refreshCount     atomic.Int64
+	validations      atomic.Int64
+	validationNanos  atomic.Int64
+	lastRefreshError atomic.Value // string
+}
+
+// JWKSCacheStats is a snapshot of a validator's JWKS cache. It never contains key material.
+type JWKSCacheStats struct {
+	JWKSURL             string    `json:"jwksUrl"`
+	KeyIDs              []string  `json:"kids"`
+	LastRefresh         time.Time `json:"lastRefresh"`
+	AgeSeconds          float64   `json:"ageSeconds"`
+	CacheHits           int64     `json:"cacheHits"`
+	CacheMisses         int64     `json:"cacheMisses"`
+	Refreshes           int64     `json:"refreshes"`
+	LastRefreshError    string    `json:"lastRefreshError,omitempty"`
+	Validations         int64     `json:"validations"`
+	AvgValidationMicros float64   `json:"avgValidationMicros"`
+}
+
+// TokenValidatorOption configures optional RSATokenValidator behaviour.
+type TokenValidatorOption func(*RSATokenValidator)
+
+// WithJSONNumberClaims controls how numeric custom claims are decoded.
+// When enabled, numbers in Extra are json.Number so 64-bit IDs keep full precision.
+// When disabled, they are float64 and integers above 2^53 lose precision.
+func WithJSONNumberClaims(enabled bool) TokenValidatorOption {
+	return func(tv *RSATokenValidator) {
+		tv.useJSONNumber = enabled
+	}
+}
+
+// WithClockSkew tolerates up to skew of difference between this server's clock and the
+// issuer's: a token is accepted until skew after its exp, and from skew before its nbf and iat.
+func WithClockSkew(skew time.Duration) TokenValidatorOption {
+	return func(tv *RSATokenValidator) {
+		if skew > 0 {
+			tv.clockSkew = skew
+		}
+	}
+}
+
+// WithJWKSCacheTTL sets how long fetched keys are used before the JWKS is fetched again.
+// Keys are also refetched when a token has an unknown kid. The default is one hour.
+func WithJWKSCacheTTL(ttl time.Duration) TokenValidatorOption {
+	return func(tv *RSATokenValidator) {
+		if ttl > 0 {
+			tv.cacheTTL = ttl
+		}
+	}
+}
+
+// WithHTTPClient sets the client used to fetch the JWKS, e.g. one with a custom transport.
+func WithHTTPClient(client *http.Client) TokenValidatorOption {
+	return func(tv *RSATokenValidator) {
+		if client != nil {
+			tv.httpClient = client
+		}
+	}
 }
 
 type TokenClaims struct {
@@ -57,6 +130,15 @@ type TokenClaims struct {
 	Scopes string   `json:"scope,omitempty"`
 	Email  string   `json:"email,omitempty"`
 	Groups []string `json:"groups,omitempty"`
+
+	// Extra holds any claims not mapped to the fields above.
+	Extra map[string]interface{} `json:"-"`
+}
+
+// knownClaims are decoded into TokenClaims fields and excluded from Extra.
+var knownClaims = map[string]struct{}{
+	"iss": {}, "sub": {}, "aud": {}, "exp": {}, "nbf": {}, "iat": {}, "jti": {},
+	"scope": {}, "email": {}, "groups": {},
 }
 
 type JWKS struct {
@@ -72,13 +154,13 @@ type JWK struct {
 }
 
 // NewTokenValidator creates a TokenValidator from an IDP base URL (for internal IDP)
-func NewTokenValidator(idpBaseURL, issuer, audience string) (TokenValidator, error) {
+func NewTokenValidator(idpBaseURL, issuer, audience string, opts ...TokenValidatorOption) (TokenValidator, error) {
 	jwksURL := fmt.Sprintf("%s/.well-known/jwks.json", idpBaseURL)
-	return NewTokenValidatorWithJWKSURL(jwksURL, issuer, audience)
+	return NewTokenValidatorWithJWKSURL(jwksURL, issuer, audience, opts...)
 }
 
 // NewTokenValidatorWithJWKSURL creates a TokenValidator with explicit JWKS URL and validation (for external IDP)
-func NewTokenValidatorWithJWKSURL(jwksURL, issuer, audience string) (TokenValidator, error) {
+func NewTokenValidatorWithJWKSURL(jwksURL, issuer, audience string, opts ...TokenValidatorOption) (TokenValidator, error) {
 	tv := &RSATokenValidator{
 		jwksURL:  jwksURL,
 		issuer:   issuer,
@@ -87,7 +169,12 @@ func NewTokenValidatorWithJWKSURL(jwksURL, issuer, audience string) (TokenValida
 		httpClient: &http.Client{
 			Timeout: defaultHTTPTimeout,
 		},
-		done: make(chan struct{}),
+		useJSONNumber: true,
+		cacheTTL:      defaultJWKSCacheTTL,
+		done:          make(chan struct{}),
+	}
+	for _, opt := range opts {
+		opt(tv)
 	}
 
 	// Fetch keys on initialization
@@ -102,7 +189,15 @@ func NewTokenValidatorWithJWKSURL(jwksURL, issuer, audience string) (TokenValida
 }
 
 func (tv *RSATokenValidator) ValidateToken(tokenString string) (*TokenClaims, error) {
-	token, err := jwt.ParseWithClaims(tokenString, &TokenClaims{}, func(token *jwt.Token) (interface{}, error) {
+	start := time.Now()
+	defer func() {
+		tv.validations.Add(1)
+		tv.validationNanos.Add(int64(time.Since(start)))
+	}()
+
+	// exp, nbf and iat are checked below, allowing for clock skew
+	parser := &jwt.Parser{UseJSONNumber: tv.useJSONNumber, SkipClaimsValidation: true}
+	token, err := parser.ParseWithClaims(tokenString, &TokenClaims{}, func(token *jwt.Token) (interface{}, error) {
 		// Verify signing method
 		if _, ok := token.Method.(*jwt.SigningMethodRSA); !ok {
 			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
@@ -126,6 +221,9 @@ func (tv *RSATokenValidator) ValidateToken(tokenString string) (*TokenClaims, er
 	if !ok || !token.Valid {
 		return nil, fmt.Errorf("invalid token")
 	}
+	if err := tv.verifyTimes(claims, time.Now()); err != nil {
+		return nil, err
+	}
 
 	// Validate issuer if configured
 	if tv.issuer != "" && claims.Issuer != tv.issuer {
@@ -137,9 +235,64 @@ func (tv *RSATokenValidator) ValidateToken(tokenString string) (*TokenClaims, er
 		return nil, fmt.Errorf("invalid audience: expected %s", tv.audience)
 	}
 
+	extra, err := decodeExtraClaims(token.Raw, tv.useJSONNumber)
+	if err != nil {
+		return nil, err
+	}
+	claims.Extra = extra
+
 	return claims, nil
 }
 
+// verifyTimes checks the exp, nbf and iat claims against now, allowing for the configured clock
+// skew. The errors match the jwt package's own, so errors.Is(err, jwt.ErrTokenExpired) holds.
+func (tv *RSATokenValidator) verifyTimes(claims *TokenClaims, now time.Time) error {
+	if !claims.VerifyExpiresAt(now.Add(-tv.clockSkew), false) {
+		return jwt.NewValidationError("token is expired", jwt.ValidationErrorExpired)
+	}
+	if !claims.VerifyNotBefore(now.Add(tv.clockSkew), false) {
+		return jwt.NewValidationError("token is not valid yet", jwt.ValidationErrorNotValidYet)
+	}
+	if !claims.VerifyIssuedAt(now.Add(tv.clockSkew), false) {
+		return jwt.NewValidationError("token used before issued", jwt.ValidationErrorIssuedAt)
+	}
+	return nil
+}
+
+// decodeExtraClaims decodes the claims that TokenClaims does not map to a field.
+// The payload is decoded separately because encoding/json cannot collect unknown fields into a map.
+func decodeExtraClaims(rawToken string, useJSONNumber bool) (map[string]interface{}, error) {
+	parts := strings.Split(rawToken, ".")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("invalid token format")
+	}
+	payload, err := jwt.DecodeSegment(parts[1])
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode token payload: %w", err)
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(payload))
+	if useJSONNumber {
+		decoder.UseNumber()
+	}
+	var all map[string]interface{}
+	if err := decoder.Decode(&all); err != nil {
+		return nil, fmt.Errorf("failed to decode token claims: %w", err)
+	}
+
+	var extra map[string]interface{}
+	for name, value := range all {
+		if _, ok := knownClaims[name]; ok {
+			continue
+		}
+		if extra == nil {
+			extra = make(map[string]interface{})
+		}
+		extra[name] = value
+	}
+	return extra, nil
+}
+
 // Close stops the background refresh goroutine and releases resources
 func (tv *RSATokenValidator) Close() {
 	tv.closeOnce.Do(func() { close(tv.done) })
@@ -153,8 +306,10 @@ func (tv *RSATokenValidator) getKey(kid string) (*rsa.PublicKey, error) {
 	tv.keysMutex.RUnlock()
 
 	if exists {
+		tv.cacheHits.Add(1)
 		return key, nil
 	}
+	tv.cacheMisses.Add(1)
 
 	// Key not found, check if we can refresh
 	tv.keysMutex.Lock()
@@ -191,6 +346,17 @@ func (tv *RSATokenValidator) getKey(kid string) (*rsa.PublicKey, error) {
 }
 
 func (tv *RSATokenValidator) refreshKeys() error {
+	err := tv.fetchKeys()
+	tv.refreshCount.Add(1)
+	if err != nil {
+		tv.lastRefreshError.Store(err.Error())
+	} else {
+		tv.lastRefreshError.Store("")
+	}
+	return err
+}
+
+func (tv *RSATokenValidator) fetchKeys() error {
 	resp, err := tv.httpClient.Get(tv.jwksURL)
 	if err != nil {
 		return fmt.Errorf("failed to fetch JWKS: %w", err)
@@ -258,22 +424,72 @@ func (tv *RSATokenValidator) parseRSAPublicKey(key JWK) (*rsa.PublicKey, error)
 	}, nil
 }
 
+// backgroundRefresh refetches the JWKS every cache TTL. A failed fetch keeps the cached keys
+// and is retried with exponential backoff, from jwksRetryInitialDelay up to the TTL.
 func (tv *RSATokenValidator) backgroundRefresh() {
-	ticker := time.NewTicker(jwksRefreshInterval)
-	defer ticker.Stop()
+	retryDelay := min(jwksRetryInitialDelay, tv.cacheTTL)
+	timer := time.NewTimer(tv.cacheTTL)
+	defer timer.Stop()
 
 	for {
 		select {
 		case <-tv.done:
 			return
-		case <-ticker.C:
+		case <-timer.C:
 			if err := tv.refreshKeys(); err != nil {
-				slog.Warn("Background JWKS refresh failed", "error", err)
+				slog.Warn("Background JWKS refresh failed", "error", err, "retry_in", retryDelay)
+				timer.Reset(retryDelay)
+				retryDelay = min(retryDelay*2, tv.cacheTTL)
+				continue
 			}
+			retryDelay = min(jwksRetryInitialDelay, tv.cacheTTL)
+			timer.Reset(tv.cacheTTL)
 		}
 	}
 }
 
+// CacheStats returns a snapshot of the JWKS cache state and validation counters.
+func (tv *RSATokenValidator) CacheStats() JWKSCacheStats {
+	tv.keysMutex.RLock()
+	kids := make([]string, 0, len(tv.keys))
+	for kid := range tv.keys {
+		kids = append(kids, kid)
+	}
+	lastFetch := tv.lastFetch
+	tv.keysMutex.RUnlock()
+	sort.Strings(kids)
+
+	stats := JWKSCacheStats{
+		JWKSURL:     tv.jwksURL,
+		KeyIDs:      kids,
+		LastRefresh: lastFetch,
+		CacheHits:   tv.cacheHits.Load(),
+		CacheMisses: tv.cacheMisses.Load(),
+		Refreshes:   tv.refreshCount.Load(),
+		Validations: tv.validations.Load(),
+	}
+	if !lastFetch.IsZero() {
+		stats.AgeSeconds = time.Since(lastFetch).Seconds()
+	}
+	if lastErr, ok := tv.lastRefreshError.Load().(string); ok {
+		stats.LastRefreshError = lastErr
+	}
+	if stats.Validations > 0 {
+		stats.AvgValidationMicros = float64(tv.validationNanos.Load()) / float64(stats.Validations) / float64(time.Microsecond)
+	}
+	return stats
+}
+
+// ForceRefresh refetches the JWKS immediately, bypassing the lazy refresh cooldown.
+func (tv *RSATokenValidator) ForceRefresh() error {
+	tv.keysMutex.Lock()
+	tv.lastRefreshAttempt = time.Now()
+	tv.keysMutex.Unlock()
+
+	slog.Info("Forced JWKS refresh", "jwks_url", tv.jwksURL)
+	return tv.refreshKeys()
+}
+
 // GetJWKS returns the cached JWKS JSON from the last refresh without refetching.
 func (tv *RSATokenValidator) GetJWKS() (json.RawMessage, error) {
 	tv.keysMutex.RLock()
@@ -0,0 +1,339 @@
+// Copyright (c) 2025 WSO2 LLC. (https://www.wso2.com).
+//
+// WSO2 LLC. licenses this file to you under the Apache License,
+// Version 2.0 (the "License"); you may not use this file except
+// in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+package services
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+const (
+	testKeyID    = "test-key"
+	testIssuer   = "superapp"
+	testAudience = "superapp-api"
+
+	// largeClaimID is 2^53 + 1, the smallest positive integer float64 cannot represent
+	largeClaimID = "9007199254740993"
+)
+
+// newTestJWKSServer serves a JWKS containing the public half of a freshly generated key
+func newTestJWKSServer(t *testing.T) (*rsa.PrivateKey, *httptest.Server) {
+	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+	jwks := JWKS{Keys: []JWK{{
+		Kid: testKeyID,
+		Kty: "RSA",
+		Use: "sig",
+		N:   base64.RawURLEncoding.EncodeToString(privateKey.N.Bytes()),
+		E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(privateKey.E)).Bytes()),
+	}}}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(jwks)
+	}))
+	t.Cleanup(server.Close)
+	return privateKey, server
+}
+
+// signTestToken signs a token whose payload contains a large integer custom claim
+func signTestToken(t *testing.T, privateKey *rsa.PrivateKey) string {
+	now := time.Now()
+	claims := jwt.MapClaims{
+		"iss":         testIssuer,
+		"aud":         testAudience,
+		"sub":         "test-client",
+		"iat":         now.Unix(),
+		"exp":         now.Add(time.Hour).Unix(),
+		"scope":       "read",
+		"employee_id": json.Number(largeClaimID),
+		"tenant":      "acme",
+	}
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = testKeyID
+	tokenString, err := token.SignedString(privateKey)
+	if err != nil {
+		t.Fatalf("Failed to sign token: %v", err)
+	}
+	return tokenString
+}
+
+// newTestValidator creates a validator against the test JWKS server
+func newTestValidator(t *testing.T, jwksURL string, opts ...TokenValidatorOption) *RSATokenValidator {
+	validator, err := NewTokenValidatorWithJWKSURL(jwksURL, testIssuer, testAudience, opts...)
+	if err != nil {
+		t.Fatalf("Failed to create validator: %v", err)
+	}
+	tv := validator.(*RSATokenValidator)
+	t.Cleanup(tv.Close)
+	return tv
+}
+
+// TestValidateTokenPreservesLargeIntegerClaims tests that 64-bit custom claims survive with JSON number decoding
+func TestValidateTokenPreservesLargeIntegerClaims(t *testing.T) {
+	privateKey, server := newTestJWKSServer(t)
+	tv := newTestValidator(t, server.URL)
+
+	claims, err := tv.ValidateToken(signTestToken(t, privateKey))
+	if err != nil {
+		t.Fatalf("Failed to validate token: %v", err)
+	}
+
+	id, ok := claims.Extra["employee_id"].(json.Number)
+	if !ok {
+		t.Fatalf("Expected employee_id to be json.Number, got %T", claims.Extra["employee_id"])
+	}
+	if id.String() != largeClaimID {
+		t.Errorf("Expected employee_id %s, got %s", largeClaimID, id.String())
+	}
+	if n, err := id.Int64(); err != nil || n != 9007199254740993 {
+		t.Errorf("Expected employee_id to convert to int64 exactly, got %d (%v)", n, err)
+	}
+
+	if claims.Extra["tenant"] != "acme" {
+		t.Errorf("Expected tenant acme, got %v", claims.Extra["tenant"])
+	}
+	if _, ok := claims.Extra["scope"]; ok {
+		t.Error("Expected known claims to be excluded from Extra")
+	}
+	if claims.Scopes != "read" || claims.Subject != "test-client" {
+		t.Errorf("Expected standard claims to be decoded, got scope=%q sub=%q", claims.Scopes, claims.Subject)
+	}
+}
+
+// TestValidateTokenFloatClaims tests that numeric claims are float64 when JSON number decoding is disabled
+func TestValidateTokenFloatClaims(t *testing.T) {
+	privateKey, server := newTestJWKSServer(t)
+	tv := newTestValidator(t, server.URL, WithJSONNumberClaims(false))
+
+	claims, err := tv.ValidateToken(signTestToken(t, privateKey))
+	if err != nil {
+		t.Fatalf("Failed to validate token: %v", err)
+	}
+
+	id, ok := claims.Extra["employee_id"].(float64)
+	if !ok {
+		t.Fatalf("Expected employee_id to be float64, got %T", claims.Extra["employee_id"])
+	}
+	// Documents the precision loss that JSON number decoding avoids
+	if int64(id) == 9007199254740993 {
+		t.Error("Expected float64 decoding to lose precision for 2^53 + 1")
+	}
+}
+
+// TestCacheStatsCounters tests that cache hits, misses and validations are counted
+func TestCacheStatsCounters(t *testing.T) {
+	privateKey, server := newTestJWKSServer(t)
+	tv := newTestValidator(t, server.URL)
+
+	initial := tv.CacheStats()
+	if initial.Refreshes != 1 {
+		t.Errorf("Expected 1 refresh after initialization, got %d", initial.Refreshes)
+	}
+	if len(initial.KeyIDs) != 1 || initial.KeyIDs[0] != testKeyID {
+		t.Errorf("Expected kids [%s], got %v", testKeyID, initial.KeyIDs)
+	}
+
+	tokenString := signTestToken(t, privateKey)
+	for i := 0; i < 3; i++ {
+		if _, err := tv.ValidateToken(tokenString); err != nil {
+			t.Fatalf("Failed to validate token: %v", err)
+		}
+	}
+
+	// Unknown kid: a miss that is served by a lazy refresh (not rate limited yet)
+	tv.lastRefreshAttempt = time.Time{}
+	if _, err := tv.getKey("unknown-kid"); err == nil {
+		t.Error("Expected error for unknown kid")
+	}
+
+	stats := tv.CacheStats()
+	if stats.CacheHits != 3 {
+		t.Errorf("Expected 3 cache hits, got %d", stats.CacheHits)
+	}
+	if stats.CacheMisses != 1 {
+		t.Errorf("Expected 1 cache miss, got %d", stats.CacheMisses)
+	}
+	if stats.Validations != 3 {
+		t.Errorf("Expected 3 validations, got %d", stats.Validations)
+	}
+	if stats.Refreshes != 2 {
+		t.Errorf("Expected 2 refreshes, got %d", stats.Refreshes)
+	}
+	if stats.AvgValidationMicros <= 0 {
+		t.Errorf("Expected positive average validation latency, got %f", stats.AvgValidationMicros)
+	}
+}
+
+// TestForceRefresh tests that a forced refresh picks up rotated keys and records failures
+func TestForceRefresh(t *testing.T) {
+	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+	kid := "key-1"
+	fail := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if fail {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		json.NewEncoder(w).Encode(JWKS{Keys: []JWK{{
+			Kid: kid,
+			Kty: "RSA",
+			N:   base64.RawURLEncoding.EncodeToString(privateKey.N.Bytes()),
+			E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(privateKey.E)).Bytes()),
+		}}})
+	}))
+	defer server.Close()
+
+	tv := newTestValidator(t, server.URL)
+	before := tv.CacheStats()
+
+	// Rotate the key and refresh inside the lazy refresh cooldown
+	kid = "key-2"
+	if err := tv.ForceRefresh(); err != nil {
+		t.Fatalf("Forced refresh failed: %v", err)
+	}
+
+	stats := tv.CacheStats()
+	if len(stats.KeyIDs) != 1 || stats.KeyIDs[0] != "key-2" {
+		t.Errorf("Expected kids [key-2] after forced refresh, got %v", stats.KeyIDs)
+	}
+	if stats.Refreshes != before.Refreshes+1 {
+		t.Errorf("Expected refresh count %d, got %d", before.Refreshes+1, stats.Refreshes)
+	}
+	if stats.LastRefresh.Before(before.LastRefresh) {
+		t.Error("Expected last refresh time to advance")
+	}
+
+	// A failed refresh keeps the cached keys and reports the error
+	fail = true
+	if err := tv.ForceRefresh(); err == nil {
+		t.Fatal("Expected forced refresh to fail")
+	}
+	stats = tv.CacheStats()
+	if stats.LastRefreshError == "" {
+		t.Error("Expected last refresh error to be recorded")
+	}
+	if len(stats.KeyIDs) != 1 || stats.KeyIDs[0] != "key-2" {
+		t.Errorf("Expected cached kids to be kept after failed refresh, got %v", stats.KeyIDs)
+	}
+}
+
+// signTimedToken signs a token issued at iat that expires at exp
+func signTimedToken(t *testing.T, privateKey *rsa.PrivateKey, iat, exp time.Time) string {
+	claims := jwt.MapClaims{
+		"iss": testIssuer,
+		"aud": testAudience,
+		"sub": "test-client",
+		"iat": iat.Unix(),
+		"nbf": iat.Unix(),
+		"exp": exp.Unix(),
+	}
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = testKeyID
+	tokenString, err := token.SignedString(privateKey)
+	if err != nil {
+		t.Fatalf("Failed to sign token: %v", err)
+	}
+	return tokenString
+}
+
+// TestValidateTokenClockSkew tests that tokens from an issuer whose clock is a few seconds off
+// are accepted only within the configured skew
+func TestValidateTokenClockSkew(t *testing.T) {
+	privateKey, server := newTestJWKSServer(t)
+	strict := newTestValidator(t, server.URL)
+	tolerant := newTestValidator(t, server.URL, WithClockSkew(5*time.Second))
+	now := time.Now()
+
+	future := signTimedToken(t, privateKey, now.Add(3*time.Second), now.Add(time.Hour))
+	if _, err := strict.ValidateToken(future); err == nil {
+		t.Error("Expected a token issued in the future to fail without a skew tolerance")
+	}
+	if _, err := tolerant.ValidateToken(future); err != nil {
+		t.Errorf("Expected a token issued 3s in the future to pass with a 5s skew, got %v", err)
+	}
+
+	expired := signTimedToken(t, privateKey, now.Add(-time.Hour), now.Add(-3*time.Second))
+	if _, err := strict.ValidateToken(expired); !errors.Is(err, jwt.ErrTokenExpired) {
+		t.Errorf("Expected a token expired 3s ago to fail without a skew tolerance, got %v", err)
+	}
+	if _, err := tolerant.ValidateToken(expired); err != nil {
+		t.Errorf("Expected a token expired 3s ago to pass with a 5s skew, got %v", err)
+	}
+
+	tooFarAhead := signTimedToken(t, privateKey, now.Add(time.Minute), now.Add(time.Hour))
+	if _, err := tolerant.ValidateToken(tooFarAhead); err == nil {
+		t.Error("Expected a token issued a minute in the future to fail with a 5s skew")
+	}
+}
+
+// TestBackgroundRefreshRecovers tests that the cache is refetched every TTL and failed fetches are retried
+func TestBackgroundRefreshRecovers(t *testing.T) {
+	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+	var hits int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Serve the initial fetch, fail the next two background fetches, then recover
+		if n := atomic.AddInt32(&hits, 1); n == 2 || n == 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		json.NewEncoder(w).Encode(JWKS{Keys: []JWK{{
+			Kid: "key-1",
+			Kty: "RSA",
+			N:   base64.RawURLEncoding.EncodeToString(privateKey.N.Bytes()),
+			E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(privateKey.E)).Bytes()),
+		}}})
+	}))
+	defer server.Close()
+
+	tv := newTestValidator(t, server.URL, WithJWKSCacheTTL(20*time.Millisecond), WithHTTPClient(server.Client()))
+
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		if atomic.LoadInt32(&hits) >= 4 && tv.CacheStats().LastRefreshError == "" {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	stats := tv.CacheStats()
+	if got := atomic.LoadInt32(&hits); got < 4 {
+		t.Fatalf("Expected at least 4 JWKS fetches, got %d", got)
+	}
+	if stats.LastRefreshError != "" {
+		t.Errorf("Expected refresh error to clear after recovery, got %q", stats.LastRefreshError)
+	}
+	if len(stats.KeyIDs) != 1 || stats.KeyIDs[0] != "key-1" {
+		t.Errorf("Expected kids [key-1] to stay cached, got %v", stats.KeyIDs)
+	}
+}
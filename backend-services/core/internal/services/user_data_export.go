@@ -0,0 +1,213 @@
+// Copyright (c) 2025 WSO2 LLC. (https://www.wso2.com).
+//
+// WSO2 LLC. licenses this file to you under the Apache License,
+// Version 2.0 (the "License"); you may not use this file except
+// in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/opensuperapp/opensuperapp/backend-services/core/internal/models"
+
+	userservice "github.com/opensuperapp/opensuperapp/backend-services/core/plugins/user-service"
+
+	"gorm.io/gorm"
+)
+
+// maskedTokenSuffix is the number of trailing device token characters left visible in an export
+const maskedTokenSuffix = 4
+
+// UserData is the document a user data export produces
+type UserData struct {
+	Email                   string                           `json:"email"`
+	ExportedAt              time.Time                        `json:"exportedAt"`
+	Profile                 *UserDataProfile                 `json:"profile"` // nil when the user is not in the user service
+	Configs                 []UserDataConfig                 `json:"configs"`
+	Devices                 []UserDataDevice                 `json:"devices"`
+	Notifications           []UserDataNotification           `json:"notifications"`
+	NotificationPreferences []UserDataNotificationPreference `json:"notificationPreferences"`
+}
+
+type UserDataProfile struct {
+	FirstName     string  `json:"firstName"`
+	LastName      string  `json:"lastName"`
+	UserThumbnail *string `json:"userThumbnail,omitempty"`
+	Location      *string `json:"location,omitempty"`
+}
+
+type UserDataConfig struct {
+	ConfigKey   string          `json:"configKey"`
+	ConfigValue json.RawMessage `json:"configValue"`
+	Active      bool            `json:"active"`
+	UpdatedAt   time.Time       `json:"updatedAt"`
+}
+
+// UserDataDevice is a registered device. The token is masked, since it would let the holder
+// push notifications to the device.
+type UserDataDevice struct {
+	Token      string    `json:"token"`
+	Platform   string    `json:"platform"`
+	AppBuild   *int      `json:"appBuild,omitempty"`
+	Active     bool      `json:"active"`
+	LastSeenAt time.Time `json:"lastSeenAt"`
+	CreatedAt  time.Time `json:"createdAt"`
+}
+
+type UserDataNotification struct {
+	ID             int64          `json:"id"`
+	MicroappID     *string        `json:"microappId,omitempty"`
+	Title          *string        `json:"title,omitempty"`
+	Body           *string        `json:"body,omitempty"`
+	Data           models.JSONMap `json:"data,omitempty"`
+	SentAt         time.Time      `json:"sentAt"`
+	DeliveryStatus string         `json:"deliveryStatus"`
+	ReadAt         *time.Time     `json:"readAt,omitempty"`
+}
+
+type UserDataNotificationPreference struct {
+	MicroappID string    `json:"microappId"`
+	OptedOut   bool      `json:"optedOut"`
+	UpdatedAt  time.Time `json:"updatedAt"`
+}
+
+// UserDataExporter assembles everything stored about a user for data-subject access requests
+type UserDataExporter struct {
+	db    *gorm.DB
+	users userservice.UserService
+}
+
+func NewUserDataExporter(db *gorm.DB, users userservice.UserService) *UserDataExporter {
+	return &UserDataExporter{db: db, users: users}
+}
+
+// Run builds the export's document and stores it, marking the export completed, or failed with the reason
+func (e *UserDataExporter) Run(export *models.UserDataExport) {
+	data, err := e.Export(export.UserEmail)
+	var doc []byte
+	if err == nil {
+		doc, err = json.Marshal(data)
+	}
+	now := time.Now()
+	export.CompletedAt = &now
+	if err != nil {
+		slog.Error("User data export failed", "error", err, "export_id", export.ID, "user_email", export.UserEmail)
+		reason := err.Error()
+		export.Status = models.UserDataExportStatusFailed
+		export.Error = &reason
+	} else {
+		export.Status = models.UserDataExportStatusCompleted
+		export.Data = doc
+		export.SizeBytes = int64(len(doc))
+	}
+	if err := e.db.Model(export).Select("status", "error", "data", "size_bytes", "completed_at").Updates(export).Error; err != nil {
+		slog.Error("Failed to save user data export", "error", err, "export_id", export.ID)
+		return
+	}
+	slog.Info("User data export finished", "export_id", export.ID, "status", export.Status, "size_bytes", export.SizeBytes)
+}
+
+// Export reads the profile, configs, devices, notifications and notification preferences of a user
+func (e *UserDataExporter) Export(email string) (*UserData, error) {
+	data := &UserData{
+		Email:                   email,
+		ExportedAt:              time.Now().UTC(),
+		Configs:                 []UserDataConfig{},
+		Devices:                 []UserDataDevice{},
+		Notifications:           []UserDataNotification{},
+		NotificationPreferences: []UserDataNotificationPreference{},
+	}
+
+	user, err := e.users.GetUserByEmail(email)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch profile: %w", err)
+	}
+	if user != nil {
+		data.Profile = &UserDataProfile{
+			FirstName:     user.FirstName,
+			LastName:      user.LastName,
+			UserThumbnail: user.UserThumbnail,
+			Location:      user.Location,
+		}
+	}
+
+	var configs []models.UserConfig
+	if err := e.db.Where("email = ?", email).Order("config_key").Find(&configs).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch configs: %w", err)
+	}
+	for _, c := range configs {
+		data.Configs = append(data.Configs, UserDataConfig{
+			ConfigKey:   c.ConfigKey,
+			ConfigValue: c.ConfigValue,
+			Active:      c.Active == models.StatusActive,
+			UpdatedAt:   c.UpdatedAt,
+		})
+	}
+
+	var devices []models.DeviceToken
+	if err := e.db.Where("user_email = ?", email).Order("id").Find(&devices).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch devices: %w", err)
+	}
+	for _, d := range devices {
+		data.Devices = append(data.Devices, UserDataDevice{
+			Token:      maskDeviceToken(d.DeviceToken),
+			Platform:   d.Platform,
+			AppBuild:   d.AppBuild,
+			Active:     d.IsActive,
+			LastSeenAt: d.LastSeenAt,
+			CreatedAt:  d.CreatedAt,
+		})
+	}
+
+	var logs []models.NotificationLog
+	if err := e.db.Where("user_email = ?", email).Order("id").Find(&logs).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch notifications: %w", err)
+	}
+	for _, l := range logs {
+		data.Notifications = append(data.Notifications, UserDataNotification{
+			ID:             l.ID,
+			MicroappID:     l.MicroappID,
+			Title:          l.Title,
+			Body:           l.Body,
+			Data:           l.Data,
+			SentAt:         l.SentAt,
+			DeliveryStatus: l.DeliveryStatus,
+			ReadAt:         l.ReadAt,
+		})
+	}
+
+	var preferences []models.NotificationPreference
+	if err := e.db.Where("user_email = ?", email).Order("microapp_id").Find(&preferences).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch notification preferences: %w", err)
+	}
+	for _, p := range preferences {
+		data.NotificationPreferences = append(data.NotificationPreferences, UserDataNotificationPreference{
+			MicroappID: p.MicroappID,
+			OptedOut:   p.OptedOut,
+			UpdatedAt:  p.UpdatedAt,
+		})
+	}
+	return data, nil
+}
+
+// maskDeviceToken hides all but the last few characters of a device token
+func maskDeviceToken(token string) string {
+	if len(token) <= maskedTokenSuffix {
+		return strings.Repeat("*", len(token))
+	}
+	return strings.Repeat("*", 8) + token[len(token)-maskedTokenSuffix:]
+}
@@ -0,0 +1,283 @@
+// Copyright (c) 2025 WSO2 LLC. (https://www.wso2.com).
+//
+// WSO2 LLC. licenses this file to you under the Apache License,
+// Version 2.0 (the "License"); you may not use this file except
+// in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+package services
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/opensuperapp/opensuperapp/backend-services/core/internal/models"
+
+	userservice "github.com/opensuperapp/opensuperapp/backend-services/core/plugins/user-service"
+
+	"gorm.io/gorm"
+)
+
+// ErasureAction is what a user erasure does to the rows of one data category
+type ErasureAction string
+
+const (
+	// ErasureDelete removes the rows
+	ErasureDelete ErasureAction = "delete"
+	// ErasureAnonymize keeps the rows for aggregates, with the user's email replaced by the
+	// erasure's pseudonym and any free-form content cleared
+	ErasureAnonymize ErasureAction = "anonymize"
+)
+
+// ParseErasureAction returns the ErasureAction named by s
+func ParseErasureAction(s string) (ErasureAction, error) {
+	switch action := ErasureAction(strings.ToLower(strings.TrimSpace(s))); action {
+	case ErasureDelete, ErasureAnonymize:
+		return action, nil
+	default:
+		return "", fmt.Errorf("unknown erasure action %q (expected delete or anonymize)", s)
+	}
+}
+
+// Data categories of a user erasure, as recorded on its audit record
+const (
+	ErasureCategoryDeviceTokens            = "deviceTokens"
+	ErasureCategoryConfigs                 = "configs"
+	ErasureCategoryNotificationPreferences = "notificationPreferences"
+	ErasureCategoryNotificationLogs        = "notificationLogs"
+	ErasureCategoryGroups                  = "groups"
+	ErasureCategoryMicroAppAdmin           = "microAppAdmin"
+	ErasureCategoryDataExports             = "dataExports"
+	ErasureCategoryScheduledNotifications  = "scheduledNotifications"
+	ErasureCategoryConfigConflicts         = "configConflicts"
+)
+
+// ErasurePolicy selects, per data category, whether erasing a user deletes or anonymizes their rows.
+// Group memberships and micro app admin grants are always deleted, the user is always removed from
+// the recipients of scheduled notifications, and the documents of the user's data exports always
+// purged, since they serve no purpose once the user is gone. Config conflicts the user took part in
+// are always kept under the pseudonym.
+type ErasurePolicy struct {
+	DeviceTokens            ErasureAction
+	Configs                 ErasureAction
+	NotificationPreferences ErasureAction
+	NotificationLogs        ErasureAction
+}
+
+// UserEraser removes a user's personal data for right-to-erasure requests
+type UserEraser struct {
+	db     *gorm.DB
+	users  userservice.UserService
+	policy ErasurePolicy
+}
+
+func NewUserEraser(db *gorm.DB, users userservice.UserService, policy ErasurePolicy) *UserEraser {
+	return &UserEraser{db: db, users: users, policy: policy}
+}
+
+// Erase deletes or anonymizes the user's rows per the policy and records the erasure, all in one
+// transaction, then removes the user from the user service. The user service may not share the
+// database, so a failure there is returned with the audit record left showing the profile in place;
+// erasing the user again is safe.
+func (e *UserEraser) Erase(email, requestedBy string) (*models.UserErasure, error) {
+	erasure := &models.UserErasure{
+		UserEmailHash: HashErasedEmail(email),
+		RequestedBy:   requestedBy,
+	}
+	err := e.db.Transaction(func(tx *gorm.DB) error {
+		// The pseudonym is derived from the ID, so the record is created before anything is anonymized
+		if err := tx.Create(erasure).Error; err != nil {
+			return fmt.Errorf("failed to create erasure record: %w", err)
+		}
+		erasure.Pseudonym = fmt.Sprintf("erased-%d", erasure.ID)
+
+		erasure.Categories = models.JSONMap{}
+		steps := []struct {
+			category string
+			action   ErasureAction
+			erase    func(tx *gorm.DB, email, pseudonym string, action ErasureAction) (int64, error)
+		}{
+			{ErasureCategoryDeviceTokens, e.policy.DeviceTokens, eraseDeviceTokens},
+			{ErasureCategoryConfigs, e.policy.Configs, eraseConfigs},
+			{ErasureCategoryNotificationPreferences, e.policy.NotificationPreferences, eraseNotificationPreferences},
+			{ErasureCategoryNotificationLogs, e.policy.NotificationLogs, eraseNotificationLogs},
+			{ErasureCategoryGroups, ErasureDelete, eraseGroups},
+			{ErasureCategoryMicroAppAdmin, ErasureDelete, eraseMicroAppAdmin},
+			{ErasureCategoryDataExports, ErasureAnonymize, eraseDataExports},
+			{ErasureCategoryScheduledNotifications, ErasureDelete, eraseScheduledNotifications},
+			{ErasureCategoryConfigConflicts, ErasureAnonymize, eraseConfigConflicts},
+		}
+		for _, step := range steps {
+			rows, err := step.erase(tx, email, erasure.Pseudonym, step.action)
+			if err != nil {
+				return fmt.Errorf("failed to erase %s: %w", step.category, err)
+			}
+			erasure.Categories[step.category] = map[string]any{"action": string(step.action), "rows": rows}
+		}
+		return tx.Model(erasure).Select("pseudonym", "categories").Updates(erasure).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := e.users.DeleteUser(email); err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return erasure, fmt.Errorf("failed to delete profile: %w", err)
+	}
+	erasure.ProfileDeleted = true
+	if err := e.db.Model(erasure).Update("profile_deleted", true).Error; err != nil {
+		return erasure, fmt.Errorf("failed to update erasure record: %w", err)
+	}
+	return erasure, nil
+}
+
+// HashErasedEmail returns the hex SHA-256 hash under which an erased email is recorded
+func HashErasedEmail(email string) string {
+	sum := sha256.Sum256([]byte(strings.ToLower(strings.TrimSpace(email))))
+	return hex.EncodeToString(sum[:])
+}
+
+// eraseDeviceTokens deletes the user's device tokens, or keeps their platform and build with the
+// token itself cleared and the device deactivated
+func eraseDeviceTokens(tx *gorm.DB, email, pseudonym string, action ErasureAction) (int64, error) {
+	query := tx.Where("user_email = ?", email)
+	if action == ErasureDelete {
+		result := query.Delete(&models.DeviceToken{})
+		return result.RowsAffected, result.Error
+	}
+	result := query.Model(&models.DeviceToken{}).Updates(map[string]any{
+		"user_email":   pseudonym,
+		"device_token": "",
+		"is_active":    false,
+	})
+	return result.RowsAffected, result.Error
+}
+
+// eraseConfigs deletes the user's app configs, or keeps which keys they had set with the values cleared
+func eraseConfigs(tx *gorm.DB, email, pseudonym string, action ErasureAction) (int64, error) {
+	query := tx.Where("email = ?", email)
+	if action == ErasureDelete {
+		result := query.Delete(&models.UserConfig{})
+		return result.RowsAffected, result.Error
+	}
+	result := query.Model(&models.UserConfig{}).Updates(map[string]any{
+		"email":        pseudonym,
+		"config_value": json.RawMessage("null"),
+		"created_by":   pseudonym,
+		"updated_by":   pseudonym,
+	})
+	return result.RowsAffected, result.Error
+}
+
+// eraseNotificationPreferences deletes the user's opt-outs, or keeps them under the pseudonym
+func eraseNotificationPreferences(tx *gorm.DB, email, pseudonym string, action ErasureAction) (int64, error) {
+	query := tx.Where("user_email = ?", email)
+	if action == ErasureDelete {
+		result := query.Delete(&models.NotificationPreference{})
+		return result.RowsAffected, result.Error
+	}
+	result := query.Model(&models.NotificationPreference{}).Update("user_email", pseudonym)
+	return result.RowsAffected, result.Error
+}
+
+// eraseNotificationLogs deletes the user's notifications with their per-device deliveries, or keeps
+// when, from which microapp and how far each was delivered, with the content cleared
+func eraseNotificationLogs(tx *gorm.DB, email, pseudonym string, action ErasureAction) (int64, error) {
+	if action == ErasureDelete {
+		logIDs := tx.Model(&models.NotificationLog{}).Select("id").Where("user_email = ?", email)
+		if err := tx.Where("notification_log_id IN (?)", logIDs).Delete(&models.NotificationDelivery{}).Error; err != nil {
+			return 0, err
+		}
+		result := tx.Where("user_email = ?", email).Delete(&models.NotificationLog{})
+		return result.RowsAffected, result.Error
+	}
+	result := tx.Model(&models.NotificationLog{}).Where("user_email = ?", email).Updates(map[string]any{
+		"user_email": pseudonym,
+		"title":      nil,
+		"body":       nil,
+		"data":       nil,
+		"dedup_key":  nil,
+	})
+	return result.RowsAffected, result.Error
+}
+
+// eraseGroups deletes the user's recorded group memberships
+func eraseGroups(tx *gorm.DB, email, _ string, _ ErasureAction) (int64, error) {
+	result := tx.Where("user_email = ?", email).Delete(&models.UserGroup{})
+	return result.RowsAffected, result.Error
+}
+
+// eraseMicroAppAdmin revokes the user's admin rights over individual micro apps
+func eraseMicroAppAdmin(tx *gorm.DB, email, _ string, _ ErasureAction) (int64, error) {
+	result := tx.Where("user_email = ?", email).Delete(&models.MicroAppAdmin{})
+	return result.RowsAffected, result.Error
+}
+
+// eraseDataExports purges the documents of the user's data exports, keeping the rows as the record
+// that exports were made. Where the user requested or downloaded the export, the pseudonym replaces them.
+func eraseDataExports(tx *gorm.DB, email, pseudonym string, _ ErasureAction) (int64, error) {
+	result := tx.Model(&models.UserDataExport{}).Where("user_email = ?", email).Updates(map[string]any{
+		"user_email":         pseudonym,
+		"data":               nil,
+		"requested_by":       gorm.Expr("CASE WHEN requested_by = ? THEN ? ELSE requested_by END", email, pseudonym),
+		"last_downloaded_by": gorm.Expr("CASE WHEN last_downloaded_by = ? THEN ? ELSE last_downloaded_by END", email, pseudonym),
+	})
+	return result.RowsAffected, result.Error
+}
+
+// eraseScheduledNotifications removes the user from the recipients of scheduled notifications, and
+// deletes the notifications left without recipients
+func eraseScheduledNotifications(tx *gorm.DB, email, _ string, _ ErasureAction) (int64, error) {
+	encoded, err := json.Marshal(email)
+	if err != nil {
+		return 0, err
+	}
+	// Recipients are a JSON array, so the match is narrowed down to the exact email below
+	var scheduled []models.ScheduledNotification
+	if err := tx.Where("user_emails LIKE ?", "%"+string(encoded)+"%").Find(&scheduled).Error; err != nil {
+		return 0, err
+	}
+	var rows int64
+	for _, notification := range scheduled {
+		recipients := make(models.JSONStringSlice, 0, len(notification.UserEmails))
+		for _, recipient := range notification.UserEmails {
+			if !strings.EqualFold(recipient, email) {
+				recipients = append(recipients, recipient)
+			}
+		}
+		if len(recipients) == len(notification.UserEmails) {
+			continue
+		}
+		if len(recipients) == 0 {
+			err = tx.Delete(&models.ScheduledNotification{}, notification.ID).Error
+		} else {
+			err = tx.Model(&models.ScheduledNotification{}).Where("id = ?", notification.ID).Update("user_emails", recipients).Error
+		}
+		if err != nil {
+			return rows, err
+		}
+		rows++
+	}
+	return rows, nil
+}
+
+// eraseConfigConflicts replaces the user with the pseudonym on the config conflicts where they made
+// either of the conflicting changes
+func eraseConfigConflicts(tx *gorm.DB, email, pseudonym string, _ ErasureAction) (int64, error) {
+	result := tx.Model(&models.MicroAppConfigConflict{}).Where("old_updated_by = ? OR new_updated_by = ?", email, email).Updates(map[string]any{
+		"old_updated_by": gorm.Expr("CASE WHEN old_updated_by = ? THEN ? ELSE old_updated_by END", email, pseudonym),
+		"new_updated_by": gorm.Expr("CASE WHEN new_updated_by = ? THEN ? ELSE new_updated_by END", email, pseudonym),
+	})
+	return result.RowsAffected, result.Error
+}
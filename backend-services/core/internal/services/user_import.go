@@ -0,0 +1,101 @@
+// Copyright (c) 2025 WSO2 LLC. (https://www.wso2.com).
+//
+// WSO2 LLC. licenses this file to you under the Apache License,
+// Version 2.0 (the "License"); you may not use this file except
+// in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+package services
+
+import (
+	"log/slog"
+	"time"
+
+	"github.com/opensuperapp/opensuperapp/backend-services/core/internal/models"
+
+	userservice "github.com/opensuperapp/opensuperapp/backend-services/core/plugins/user-service"
+
+	"gorm.io/gorm"
+)
+
+// DefaultUserImportBatchSize is the number of users a UserImporter upserts per transaction.
+const DefaultUserImportBatchSize = 500
+
+// UserImportRow is a validated user from an import file and its 1-based position in the file.
+type UserImportRow struct {
+	Row  int
+	User *models.User
+}
+
+// UserImporter upserts the users of an import job in batches and records progress on the job.
+type UserImporter struct {
+	db        *gorm.DB
+	users     userservice.UserService
+	batchSize int
+}
+
+func NewUserImporter(db *gorm.DB, users userservice.UserService, batchSize int) *UserImporter {
+	if batchSize <= 0 {
+		batchSize = DefaultUserImportBatchSize
+	}
+	return &UserImporter{db: db, users: users, batchSize: batchSize}
+}
+
+// Run imports the rows of a stored job and marks it completed. The job's counters start from the
+// rows that already failed validation. Each batch is upserted in one transaction; when that fails,
+// its users are retried one at a time so that only the users that cannot be saved are reported.
+func (i *UserImporter) Run(job *models.UserImportJob, rows []UserImportRow) {
+	for start := 0; start < len(rows); start += i.batchSize {
+		batch := rows[start:min(start+i.batchSize, len(rows))]
+		imported, rowErrors := i.importBatch(batch)
+		job.ProcessedRows += len(batch)
+		job.ImportedRows += imported
+		job.FailedRows += len(rowErrors)
+		job.RowErrors = append(job.RowErrors, rowErrors...)
+		i.saveProgress(job)
+	}
+	now := time.Now()
+	job.Status = models.UserImportStatusCompleted
+	job.CompletedAt = &now
+	i.saveProgress(job)
+	slog.Info("User import completed", "job_id", job.ID, "imported", job.ImportedRows, "failed", job.FailedRows)
+}
+
+// importBatch upserts a batch and returns the number of users saved and the rows that were not.
+func (i *UserImporter) importBatch(batch []UserImportRow) (int, []models.UserImportRowError) {
+	users := make([]*models.User, len(batch))
+	for j, row := range batch {
+		users[j] = row.User
+	}
+	if err := i.users.UpsertUsers(users); err == nil {
+		return len(batch), nil
+	}
+
+	imported := 0
+	var rowErrors []models.UserImportRowError
+	for _, row := range batch {
+		if err := i.users.UpsertUser(row.User); err != nil {
+			rowErrors = append(rowErrors, models.UserImportRowError{Row: row.Row, Email: row.User.Email, Error: err.Error()})
+			continue
+		}
+		imported++
+	}
+	return imported, rowErrors
+}
+
+// saveProgress writes the job's counters, errors and status.
+func (i *UserImporter) saveProgress(job *models.UserImportJob) {
+	err := i.db.Model(job).Select("status", "processed_rows", "imported_rows", "failed_rows", "row_errors", "completed_at").
+		Updates(job).Error
+	if err != nil {
+		slog.Error("Failed to save user import progress", "error", err, "job_id", job.ID)
+	}
+}
@@ -0,0 +1,79 @@
+// Copyright (c) 2025 WSO2 LLC. (https://www.wso2.com).
+//
+// WSO2 LLC. licenses this file to you under the Apache License,
+// Version 2.0 (the "License"); you may not use this file except
+// in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+package services
+
+import (
+	"encoding/json"
+	"strings"
+
+	"gorm.io/gorm"
+
+	"github.com/opensuperapp/opensuperapp/backend-services/core/internal/models"
+)
+
+// UserConfigKeyLocale is the user config key holding a user's preferred locale as a JSON string, e.g. "fr-CA"
+const UserConfigKeyLocale = "locale"
+
+// localeCandidates lists the locales to try for a preferred locale, closest first: the exact
+// locale, then its base language ("en-US" falls back to "en"), then the default empty locale.
+func localeCandidates(locale string) []string {
+	candidates := []string{locale}
+	if base, _, found := strings.Cut(locale, "-"); found {
+		candidates = append(candidates, base)
+	}
+	if locale != "" {
+		candidates = append(candidates, "")
+	}
+	return candidates
+}
+
+// MatchLocale returns the key of available that best matches the preferred locale, compared
+// case-insensitively, or "" when none does and the default copy should be used.
+func MatchLocale(preferred string, available []string) string {
+	for _, candidate := range localeCandidates(preferred) {
+		if candidate == "" {
+			break
+		}
+		for _, locale := range available {
+			if strings.EqualFold(locale, candidate) {
+				return locale
+			}
+		}
+	}
+	return ""
+}
+
+// UserLocales returns the preferred locale of each of the given users that has one set in
+// their active user config. Values that are not a JSON string are ignored.
+func UserLocales(db *gorm.DB, emails []string) (map[string]string, error) {
+	locales := make(map[string]string)
+	if len(emails) == 0 {
+		return locales, nil
+	}
+	var configs []models.UserConfig
+	if err := db.Where("email IN ? AND config_key = ? AND active = ?", emails, UserConfigKeyLocale, 1).
+		Find(&configs).Error; err != nil {
+		return nil, err
+	}
+	for _, config := range configs {
+		var locale string
+		if err := json.Unmarshal(config.ConfigValue, &locale); err != nil || locale == "" {
+			continue
+		}
+		locales[config.Email] = locale
+	}
+	return locales, nil
+}
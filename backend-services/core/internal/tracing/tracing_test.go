@@ -0,0 +1,97 @@
+// Copyright (c) 2025 WSO2 LLC. (https://www.wso2.com).
+//
+// WSO2 LLC. licenses this file to you under the Apache License,
+// Version 2.0 (the "License"); you may not use this file except
+// in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package tracing
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	semconv "go.opentelemetry.io/otel/semconv/v1.34.0"
+)
+
+// useSpanRecorder installs a global tracer provider recording every span until the test ends
+func useSpanRecorder(t *testing.T) *tracetest.SpanRecorder {
+	t.Helper()
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(provider)
+	t.Cleanup(func() {
+		otel.SetTracerProvider(previous)
+		provider.Shutdown(context.Background())
+	})
+	return recorder
+}
+
+// TestHandler_ContinuesTraceAndNamesRoute tests that the server span continues the caller's trace
+// and is named after the route pattern
+func TestHandler_ContinuesTraceAndNamesRoute(t *testing.T) {
+	recorder := useSpanRecorder(t)
+	if _, err := Setup(context.Background(), "", "test"); err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+
+	r := chi.NewRouter()
+	r.Use(RouteSpanName)
+	r.Get("/micro-apps/{appID}", func(w http.ResponseWriter, r *http.Request) {})
+	handler := Handler(r)
+
+	req := httptest.NewRequest(http.MethodGet, "/micro-apps/app-1", nil)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	spans := recorder.Ended()
+	if len(spans) != 1 {
+		t.Fatalf("Expected 1 span, got %d", len(spans))
+	}
+	span := spans[0]
+	if span.Name() != "GET /micro-apps/{appID}" {
+		t.Errorf("Expected span name GET /micro-apps/{appID}, got %q", span.Name())
+	}
+	if got := span.SpanContext().TraceID().String(); got != "4bf92f3577b34da6a3ce929d0e0e4736" {
+		t.Errorf("Expected the caller's trace ID, got %s", got)
+	}
+	if got := span.Parent().SpanID().String(); got != "00f067aa0ba902b7" {
+		t.Errorf("Expected the caller's span as parent, got %s", got)
+	}
+	found := false
+	for _, attr := range span.Attributes() {
+		if attr.Key == semconv.HTTPRouteKey && attr.Value.AsString() == "/micro-apps/{appID}" {
+			found = true
+		}
+	}
+	if !found {
+		t.Error("Expected the http.route attribute to hold the route pattern")
+	}
+}
+
+// TestSetup_NoEndpoint tests that without an endpoint no exporter is started
+func TestSetup_NoEndpoint(t *testing.T) {
+	shutdown, err := Setup(context.Background(), "", "test")
+	if err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+	if err := shutdown(context.Background()); err != nil {
+		t.Errorf("Expected a no-op shutdown, got %v", err)
+	}
+}
@@ -0,0 +1,48 @@
+-- Copyright (c) 2025 WSO2 LLC. (https://www.wso2.com).
+
+-- WSO2 LLC. licenses this file to you under the Apache License,
+-- Version 2.0 (the "License"); you may not use this file except
+-- in compliance with the License.
+-- You may obtain a copy of the License at
+
+-- http://www.apache.org/licenses/LICENSE-2.0
+
+-- Unless required by applicable law or agreed to in writing,
+-- software distributed under the License is distributed on an
+-- "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+-- KIND, either express or implied.  See the License for the
+-- specific language governing permissions and limitations
+-- under the License.
+
+-- ========================================
+-- TABLE: scheduled_notifications
+-- Description: Queue of push notifications to be sent at a future time
+-- ========================================
+
+CREATE TABLE IF NOT EXISTS `scheduled_notifications` (
+  `id` BIGINT NOT NULL AUTO_INCREMENT COMMENT 'Internal auto-increment ID',
+  `microapp_id` VARCHAR(100) NOT NULL COMMENT 'Micro app that scheduled the notification',
+  `user_emails` JSON NOT NULL COMMENT 'Target user email addresses (JSON array)',
+  `title` VARCHAR(255) NOT NULL COMMENT 'Notification title',
+  `body` TEXT NOT NULL COMMENT 'Notification body',
+  `data` JSON DEFAULT NULL COMMENT 'Additional notification data (JSON)',
+  `send_at` TIMESTAMP NOT NULL COMMENT 'Time at which the notification is due',
+  `status` VARCHAR(20) NOT NULL DEFAULT 'pending' COMMENT 'pending, processing, sent or failed',
+  `claimed_by` VARCHAR(255) DEFAULT NULL COMMENT 'Worker instance that claimed the row',
+  `claimed_at` TIMESTAMP NULL DEFAULT NULL COMMENT 'Time the row was claimed',
+  `sent_at` TIMESTAMP NULL DEFAULT NULL COMMENT 'Time the notification was dispatched',
+  `success_count` INT NOT NULL DEFAULT 0 COMMENT 'Number of successful deliveries',
+  `failure_count` INT NOT NULL DEFAULT 0 COMMENT 'Number of failed deliveries',
+  `last_error` TEXT DEFAULT NULL COMMENT 'Last dispatch error, if any',
+  `created_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT 'Creation timestamp',
+  `updated_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT 'Last update timestamp',
+
+  PRIMARY KEY (`id`),
+
+  INDEX `idx_sn_status_send_at` (`status`, `send_at`),
+  INDEX `idx_sn_microapp_id` (`microapp_id`)
+) ENGINE=InnoDB
+  AUTO_INCREMENT=1
+  DEFAULT CHARSET=utf8mb4
+  COLLATE=utf8mb4_0900_ai_ci
+  COMMENT='Scheduled push notifications';
@@ -0,0 +1,40 @@
+-- Copyright (c) 2025 WSO2 LLC. (https://www.wso2.com).
+
+-- WSO2 LLC. licenses this file to you under the Apache License,
+-- Version 2.0 (the "License"); you may not use this file except
+-- in compliance with the License.
+-- You may obtain a copy of the License at
+
+-- http://www.apache.org/licenses/LICENSE-2.0
+
+-- Unless required by applicable law or agreed to in writing,
+-- software distributed under the License is distributed on an
+-- "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+-- KIND, either express or implied.  See the License for the
+-- specific language governing permissions and limitations
+-- under the License.
+
+-- ========================================
+-- TABLE: notification_receipts
+-- Description: Signed receipts for notifications sent with receipts enabled
+-- ========================================
+
+CREATE TABLE IF NOT EXISTS `notification_receipts` (
+  `id` BIGINT NOT NULL AUTO_INCREMENT COMMENT 'Internal auto-increment ID',
+  `microapp_id` VARCHAR(100) NOT NULL COMMENT 'Micro app that sent the notification',
+  `recipient` VARCHAR(255) NOT NULL COMMENT 'Recipient user email',
+  `content_hash` CHAR(64) NOT NULL COMMENT 'SHA-256 hash of the notification title, body and data',
+  `status` VARCHAR(50) NOT NULL COMMENT 'Send status at the time the receipt was issued',
+  `issued_at` TIMESTAMP NOT NULL COMMENT 'Time the receipt was issued',
+  `key_id` VARCHAR(64) NOT NULL COMMENT 'Identifier of the key that signed the receipt',
+  `signature` VARCHAR(255) NOT NULL COMMENT 'Ed25519 signature over the receipt fields (base64url)',
+
+  PRIMARY KEY (`id`),
+
+  INDEX `idx_nr_microapp_id` (`microapp_id`),
+  INDEX `idx_nr_recipient` (`recipient`)
+) ENGINE=InnoDB
+  AUTO_INCREMENT=1
+  DEFAULT CHARSET=utf8mb4
+  COLLATE=utf8mb4_0900_ai_ci
+  COMMENT='Signed notification receipts';
@@ -0,0 +1,23 @@
+-- Copyright (c) 2025 WSO2 LLC. (https://www.wso2.com).
+
+-- WSO2 LLC. licenses this file to you under the Apache License,
+-- Version 2.0 (the "License"); you may not use this file except
+-- in compliance with the License.
+-- You may obtain a copy of the License at
+
+-- http://www.apache.org/licenses/LICENSE-2.0
+
+-- Unless required by applicable law or agreed to in writing,
+-- software distributed under the License is distributed on an
+-- "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+-- KIND, either express or implied.  See the License for the
+-- specific language governing permissions and limitations
+-- under the License.
+
+-- ========================================
+-- TABLE: o_auth2_clients
+-- Description: Per-client token expiry override (NULL uses TOKEN_EXPIRY_SECONDS)
+-- ========================================
+
+ALTER TABLE `o_auth2_clients`
+  ADD COLUMN `expiry_seconds` INT DEFAULT NULL COMMENT 'Token lifetime override in seconds (NULL uses the global expiry)' AFTER `scopes`;
@@ -0,0 +1,36 @@
+-- Copyright (c) 2025 WSO2 LLC. (https://www.wso2.com).
+
+-- WSO2 LLC. licenses this file to you under the Apache License,
+-- Version 2.0 (the "License"); you may not use this file except
+-- in compliance with the License.
+-- You may obtain a copy of the License at
+
+-- http://www.apache.org/licenses/LICENSE-2.0
+
+-- Unless required by applicable law or agreed to in writing,
+-- software distributed under the License is distributed on an
+-- "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+-- KIND, either express or implied.  See the License for the
+-- specific language governing permissions and limitations
+-- under the License.
+
+-- ========================================
+-- TABLE: user_groups
+-- Description: User group memberships synced from IdP token claims, used to address notifications by group
+-- ========================================
+
+CREATE TABLE IF NOT EXISTS `user_groups` (
+  `id` BIGINT NOT NULL AUTO_INCREMENT COMMENT 'Internal auto-increment ID',
+  `user_email` VARCHAR(255) NOT NULL COMMENT 'User email address',
+  `group_name` VARCHAR(255) NOT NULL COMMENT 'Group name from the IdP groups claim',
+  `updated_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT 'Last sync timestamp',
+
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `uq_user_group` (`user_email`, `group_name`),
+
+  INDEX `idx_ug_group_name` (`group_name`)
+) ENGINE=InnoDB
+  AUTO_INCREMENT=1
+  DEFAULT CHARSET=utf8mb4
+  COLLATE=utf8mb4_0900_ai_ci
+  COMMENT='User group memberships for group-addressed notifications';
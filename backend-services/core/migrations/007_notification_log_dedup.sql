@@ -0,0 +1,24 @@
+-- Copyright (c) 2025 WSO2 LLC. (https://www.wso2.com).
+
+-- WSO2 LLC. licenses this file to you under the Apache License,
+-- Version 2.0 (the "License"); you may not use this file except
+-- in compliance with the License.
+-- You may obtain a copy of the License at
+
+-- http://www.apache.org/licenses/LICENSE-2.0
+
+-- Unless required by applicable law or agreed to in writing,
+-- software distributed under the License is distributed on an
+-- "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+-- KIND, either express or implied.  See the License for the
+-- specific language governing permissions and limitations
+-- under the License.
+
+-- ========================================
+-- TABLE: notification_logs
+-- Description: Optional dedup key used to skip recipients already notified within a max-age window
+-- ========================================
+
+ALTER TABLE `notification_logs`
+  ADD COLUMN `dedup_key` VARCHAR(255) DEFAULT NULL COMMENT 'Caller-supplied deduplication key' AFTER `microapp_id`,
+  ADD INDEX `idx_notification_logs_dedup` (`dedup_key`, `microapp_id`, `user_email`, `sent_at`);
@@ -0,0 +1,44 @@
+-- Copyright (c) 2025 WSO2 LLC. (https://www.wso2.com).
+
+-- WSO2 LLC. licenses this file to you under the Apache License,
+-- Version 2.0 (the "License"); you may not use this file except
+-- in compliance with the License.
+-- You may obtain a copy of the License at
+
+-- http://www.apache.org/licenses/LICENSE-2.0
+
+-- Unless required by applicable law or agreed to in writing,
+-- software distributed under the License is distributed on an
+-- "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+-- KIND, either express or implied.  See the License for the
+-- specific language governing permissions and limitations
+-- under the License.
+
+-- ========================================
+-- TABLE: authorization_codes
+-- Description: Single-use OAuth2 authorization codes with their PKCE challenge (S256 only)
+-- ========================================
+
+CREATE TABLE IF NOT EXISTS `authorization_codes` (
+  `id` BIGINT UNSIGNED NOT NULL AUTO_INCREMENT COMMENT 'Internal auto-increment ID',
+  `code` VARCHAR(255) NOT NULL COMMENT 'Authorization code',
+  `client_id` VARCHAR(255) NOT NULL COMMENT 'OAuth2 client the code was issued to',
+  `user_email` VARCHAR(255) NOT NULL COMMENT 'User who authorized the client',
+  `redirect_uri` VARCHAR(2048) DEFAULT NULL COMMENT 'Redirect URI from the authorization request',
+  `scopes` VARCHAR(1024) DEFAULT NULL COMMENT 'Granted scopes',
+  `code_challenge` VARCHAR(128) NOT NULL COMMENT 'PKCE code challenge (BASE64URL(SHA256(verifier)))',
+  `code_challenge_method` VARCHAR(10) NOT NULL COMMENT 'PKCE method (S256)',
+  `expires_at` TIMESTAMP NOT NULL COMMENT 'Code expiry',
+  `used_at` TIMESTAMP NULL DEFAULT NULL COMMENT 'When the code was redeemed (NULL if unused)',
+  `created_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT 'Creation timestamp',
+
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `uq_authorization_code` (`code`),
+
+  INDEX `idx_authorization_codes_client_id` (`client_id`),
+  INDEX `idx_authorization_codes_expires_at` (`expires_at`)
+) ENGINE=InnoDB
+  AUTO_INCREMENT=1
+  DEFAULT CHARSET=utf8mb4
+  COLLATE=utf8mb4_0900_ai_ci
+  COMMENT='OAuth2 authorization codes with PKCE challenges';
@@ -0,0 +1,42 @@
+-- Copyright (c) 2025 WSO2 LLC. (https://www.wso2.com).
+
+-- WSO2 LLC. licenses this file to you under the Apache License,
+-- Version 2.0 (the "License"); you may not use this file except
+-- in compliance with the License.
+-- You may obtain a copy of the License at
+
+-- http://www.apache.org/licenses/LICENSE-2.0
+
+-- Unless required by applicable law or agreed to in writing,
+-- software distributed under the License is distributed on an
+-- "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+-- KIND, either express or implied.  See the License for the
+-- specific language governing permissions and limitations
+-- under the License.
+
+-- ========================================
+-- TABLE: microapp_api_keys
+-- Description: Hashed API keys that microapp backends can use instead of OAuth client credentials
+-- ========================================
+
+CREATE TABLE IF NOT EXISTS `microapp_api_keys` (
+  `id` BIGINT NOT NULL AUTO_INCREMENT COMMENT 'Internal auto-increment ID',
+  `microapp_id` VARCHAR(100) NOT NULL COMMENT 'Micro app the key authenticates as',
+  `name` VARCHAR(255) NOT NULL COMMENT 'Human readable label',
+  `key_prefix` VARCHAR(32) NOT NULL COMMENT 'Non-secret leading part of the key, for identification',
+  `key_hash` CHAR(64) NOT NULL COMMENT 'SHA-256 hash of the full key (hex)',
+  `scopes` VARCHAR(1024) DEFAULT NULL COMMENT 'Space separated scopes granted to the key',
+  `created_by` VARCHAR(319) NOT NULL COMMENT 'Admin who issued the key',
+  `created_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT 'Creation timestamp',
+  `last_used_at` TIMESTAMP NULL DEFAULT NULL COMMENT 'Last successful authentication (updated at most once a minute)',
+  `revoked_at` TIMESTAMP NULL DEFAULT NULL COMMENT 'When the key was revoked (NULL if active)',
+
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `uq_api_keys_key_hash` (`key_hash`),
+
+  INDEX `idx_api_keys_microapp_id` (`microapp_id`)
+) ENGINE=InnoDB
+  AUTO_INCREMENT=1
+  DEFAULT CHARSET=utf8mb4
+  COLLATE=utf8mb4_0900_ai_ci
+  COMMENT='Microapp API keys';
@@ -0,0 +1,38 @@
+-- Copyright (c) 2025 WSO2 LLC. (https://www.wso2.com).
+
+-- WSO2 LLC. licenses this file to you under the Apache License,
+-- Version 2.0 (the "License"); you may not use this file except
+-- in compliance with the License.
+-- You may obtain a copy of the License at
+
+-- http://www.apache.org/licenses/LICENSE-2.0
+
+-- Unless required by applicable law or agreed to in writing,
+-- software distributed under the License is distributed on an
+-- "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+-- KIND, either express or implied.  See the License for the
+-- specific language governing permissions and limitations
+-- under the License.
+
+-- ========================================
+-- TABLE: notification_templates
+-- Description: Reusable notification title/body templates rendered with per-recipient variables
+-- ========================================
+
+CREATE TABLE IF NOT EXISTS `notification_templates` (
+  `id` BIGINT NOT NULL AUTO_INCREMENT COMMENT 'Internal auto-increment ID',
+  `microapp_id` VARCHAR(100) NOT NULL COMMENT 'Micro app that owns the template',
+  `template_key` VARCHAR(100) NOT NULL COMMENT 'Key the micro app sends the template by',
+  `locale` VARCHAR(35) NOT NULL DEFAULT '' COMMENT 'BCP 47 locale of this translation (empty for the default)',
+  `title_template` VARCHAR(1024) NOT NULL COMMENT 'Go text/template source for the title',
+  `body_template` TEXT NOT NULL COMMENT 'Go text/template source for the body',
+  `created_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT 'Creation timestamp',
+  `updated_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT 'Last update timestamp',
+
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `uq_notification_templates_key` (`microapp_id`, `template_key`, `locale`)
+) ENGINE=InnoDB
+  AUTO_INCREMENT=1
+  DEFAULT CHARSET=utf8mb4
+  COLLATE=utf8mb4_0900_ai_ci
+  COMMENT='Notification templates with placeholder substitution';
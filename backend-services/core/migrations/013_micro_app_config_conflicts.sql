@@ -0,0 +1,40 @@
+-- Copyright (c) 2025 WSO2 LLC. (https://www.wso2.com).
+
+-- WSO2 LLC. licenses this file to you under the Apache License,
+-- Version 2.0 (the "License"); you may not use this file except
+-- in compliance with the License.
+-- You may obtain a copy of the License at
+
+-- http://www.apache.org/licenses/LICENSE-2.0
+
+-- Unless required by applicable law or agreed to in writing,
+-- software distributed under the License is distributed on an
+-- "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+-- KIND, either express or implied.  See the License for the
+-- specific language governing permissions and limitations
+-- under the License.
+
+-- ========================================
+-- TABLE: micro_app_config_conflicts
+-- Description: Config upserts that overwrote a value another user changed shortly before
+-- ========================================
+
+CREATE TABLE IF NOT EXISTS `micro_app_config_conflicts` (
+  `id` BIGINT NOT NULL AUTO_INCREMENT COMMENT 'Internal auto-increment ID',
+  `micro_app_id` VARCHAR(255) NOT NULL COMMENT 'Reference to micro_app.micro_app_id',
+  `config_key` VARCHAR(191) NOT NULL COMMENT 'Configuration key that was overwritten',
+  `old_value` JSON NOT NULL COMMENT 'Value that was replaced',
+  `new_value` JSON NOT NULL COMMENT 'Value that replaced it',
+  `old_updated_by` VARCHAR(319) NOT NULL COMMENT 'Email of the user who set the replaced value',
+  `old_updated_at` DATETIME NOT NULL COMMENT 'When the replaced value was set',
+  `new_updated_by` VARCHAR(319) NOT NULL COMMENT 'Email of the user who overwrote it',
+  `detected_at` DATETIME NOT NULL COMMENT 'When the overwrite happened',
+
+  PRIMARY KEY (`id`),
+
+  INDEX `idx_macc_app` (`micro_app_id`)
+) ENGINE=InnoDB
+  AUTO_INCREMENT=1
+  DEFAULT CHARSET=utf8mb4
+  COLLATE=utf8mb4_0900_ai_ci
+  COMMENT='Last-write-wins conflicts on micro app config upserts';
@@ -0,0 +1,36 @@
+-- Copyright (c) 2025 WSO2 LLC. (https://www.wso2.com).
+
+-- WSO2 LLC. licenses this file to you under the Apache License,
+-- Version 2.0 (the "License"); you may not use this file except
+-- in compliance with the License.
+-- You may obtain a copy of the License at
+
+-- http://www.apache.org/licenses/LICENSE-2.0
+
+-- Unless required by applicable law or agreed to in writing,
+-- software distributed under the License is distributed on an
+-- "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+-- KIND, either express or implied.  See the License for the
+-- specific language governing permissions and limitations
+-- under the License.
+
+-- ========================================
+-- TABLE: notification_quota
+-- Description: Notifications sent by each microapp per quota period
+-- ========================================
+
+CREATE TABLE IF NOT EXISTS `notification_quota` (
+  `id` BIGINT NOT NULL AUTO_INCREMENT COMMENT 'Internal auto-increment ID',
+  `microapp_id` VARCHAR(255) NOT NULL COMMENT 'Microapp (client ID) the sends are charged to',
+  `period_start` DATETIME NOT NULL COMMENT 'Start of the quota period (UTC)',
+  `send_count` INT NOT NULL DEFAULT 0 COMMENT 'Notifications sent in the period',
+  `quota_limit` INT NOT NULL COMMENT 'Limit in force when the period was last charged',
+
+  PRIMARY KEY (`id`),
+
+  UNIQUE KEY `uk_nq_microapp_period` (`microapp_id`, `period_start`)
+) ENGINE=InnoDB
+  AUTO_INCREMENT=1
+  DEFAULT CHARSET=utf8mb4
+  COLLATE=utf8mb4_0900_ai_ci
+  COMMENT='Per-microapp notification send quotas';
@@ -0,0 +1,49 @@
+-- Copyright (c) 2025 WSO2 LLC. (https://www.wso2.com).
+
+-- WSO2 LLC. licenses this file to you under the Apache License,
+-- Version 2.0 (the "License"); you may not use this file except
+-- in compliance with the License.
+-- You may obtain a copy of the License at
+
+-- http://www.apache.org/licenses/LICENSE-2.0
+
+-- Unless required by applicable law or agreed to in writing,
+-- software distributed under the License is distributed on an
+-- "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+-- KIND, either express or implied.  See the License for the
+-- specific language governing permissions and limitations
+-- under the License.
+
+-- ========================================
+-- TABLE: notification_logs
+-- Description: Delivery state of a notification as reported by the push provider
+-- ========================================
+
+ALTER TABLE `notification_logs`
+  ADD COLUMN `delivery_status` VARCHAR(20) NOT NULL DEFAULT 'pending' COMMENT 'Furthest state reached on any recipient device (pending, delivered, failed, read)' AFTER `failure_reason`,
+  ADD COLUMN `fcm_message_id` VARCHAR(255) NULL DEFAULT NULL COMMENT 'FCM message ID of a device the notification was delivered to' AFTER `delivery_status`,
+  ADD INDEX `idx_fcm_message_id` (`fcm_message_id`);
+
+-- ========================================
+-- TABLE: notification_delivery
+-- Description: Per-device delivery state of a logged notification
+-- ========================================
+
+CREATE TABLE IF NOT EXISTS `notification_delivery` (
+  `id` BIGINT NOT NULL AUTO_INCREMENT COMMENT 'Internal auto-increment ID',
+  `notification_log_id` BIGINT NOT NULL COMMENT 'Notification log entry of the recipient',
+  `device_token_hash` CHAR(64) NOT NULL COMMENT 'Hex SHA-256 hash of the device token',
+  `fcm_message_id` VARCHAR(255) NULL DEFAULT NULL COMMENT 'Message ID assigned by FCM (NULL if not accepted)',
+  `status` VARCHAR(20) NOT NULL COMMENT 'Delivery state on this device (pending, delivered, failed, read)',
+  `created_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT 'Creation timestamp',
+  `updated_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT 'Last update timestamp',
+
+  PRIMARY KEY (`id`),
+  KEY `idx_nd_notification_log_id` (`notification_log_id`),
+  KEY `idx_nd_fcm_message_id` (`fcm_message_id`),
+  CONSTRAINT `fk_nd_notification_log` FOREIGN KEY (`notification_log_id`) REFERENCES `notification_logs` (`id`) ON DELETE CASCADE
+) ENGINE=InnoDB
+  AUTO_INCREMENT=1
+  DEFAULT CHARSET=utf8mb4
+  COLLATE=utf8mb4_0900_ai_ci
+  COMMENT='Per-device notification delivery tracking';
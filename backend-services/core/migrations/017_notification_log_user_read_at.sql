@@ -0,0 +1,28 @@
+-- Copyright (c) 2025 WSO2 LLC. (https://www.wso2.com).
+
+-- WSO2 LLC. licenses this file to you under the Apache License,
+-- Version 2.0 (the "License"); you may not use this file except
+-- in compliance with the License.
+-- You may obtain a copy of the License at
+
+-- http://www.apache.org/licenses/LICENSE-2.0
+
+-- Unless required by applicable law or agreed to in writing,
+-- software distributed under the License is distributed on an
+-- "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+-- KIND, either express or implied.  See the License for the
+-- specific language governing permissions and limitations
+-- under the License.
+
+-- ========================================
+-- TABLE: notification_logs
+-- Description: Read state of a recipient's notifications, indexed for the unread count
+-- ========================================
+
+-- Notifications read before delivery tracking was added still report pending
+UPDATE `notification_logs`
+  SET `delivery_status` = 'read'
+  WHERE `read_at` IS NOT NULL AND `delivery_status` <> 'read';
+
+ALTER TABLE `notification_logs`
+  ADD INDEX `idx_user_read_at` (`user_email`, `read_at`);
@@ -0,0 +1,36 @@
+-- Copyright (c) 2025 WSO2 LLC. (https://www.wso2.com).
+
+-- WSO2 LLC. licenses this file to you under the Apache License,
+-- Version 2.0 (the "License"); you may not use this file except
+-- in compliance with the License.
+-- You may obtain a copy of the License at
+
+-- http://www.apache.org/licenses/LICENSE-2.0
+
+-- Unless required by applicable law or agreed to in writing,
+-- software distributed under the License is distributed on an
+-- "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+-- KIND, either express or implied.  See the License for the
+-- specific language governing permissions and limitations
+-- under the License.
+
+-- ========================================
+-- TABLE: notification_preferences
+-- Description: Per-user opt-out of a microapp's notifications
+-- ========================================
+
+CREATE TABLE IF NOT EXISTS `notification_preferences` (
+  `id` BIGINT NOT NULL AUTO_INCREMENT COMMENT 'Internal auto-increment ID',
+  `user_email` VARCHAR(255) NOT NULL COMMENT 'User the preference belongs to',
+  `microapp_id` VARCHAR(100) NOT NULL COMMENT 'Micro app whose notifications the preference applies to',
+  `opted_out` TINYINT(1) NOT NULL DEFAULT 0 COMMENT 'Whether the user receives no notifications from the micro app',
+  `updated_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT 'Last update timestamp',
+
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `uq_notification_preferences_user_app` (`user_email`, `microapp_id`),
+  KEY `idx_np_microapp_opted_out` (`microapp_id`, `opted_out`)
+) ENGINE=InnoDB
+  AUTO_INCREMENT=1
+  DEFAULT CHARSET=utf8mb4
+  COLLATE=utf8mb4_0900_ai_ci
+  COMMENT='Per-user notification preferences for each micro app';
@@ -0,0 +1,41 @@
+-- Copyright (c) 2025 WSO2 LLC. (https://www.wso2.com).
+
+-- WSO2 LLC. licenses this file to you under the Apache License,
+-- Version 2.0 (the "License"); you may not use this file except
+-- in compliance with the License.
+-- You may obtain a copy of the License at
+
+-- http://www.apache.org/licenses/LICENSE-2.0
+
+-- Unless required by applicable law or agreed to in writing,
+-- software distributed under the License is distributed on an
+-- "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+-- KIND, either express or implied.  See the License for the
+-- specific language governing permissions and limitations
+-- under the License.
+
+-- ========================================
+-- TABLE: user_import_jobs
+-- Description: Background imports of uploaded user files
+-- ========================================
+
+CREATE TABLE IF NOT EXISTS `user_import_jobs` (
+  `id` BIGINT NOT NULL AUTO_INCREMENT COMMENT 'Internal auto-increment ID, used as the job ID',
+  `status` VARCHAR(20) NOT NULL DEFAULT 'processing' COMMENT 'processing or completed',
+  `format` VARCHAR(10) NOT NULL COMMENT 'Format of the uploaded file (csv or json)',
+  `total_rows` INT NOT NULL DEFAULT 0 COMMENT 'Users in the file',
+  `processed_rows` INT NOT NULL DEFAULT 0 COMMENT 'Users imported or rejected so far',
+  `imported_rows` INT NOT NULL DEFAULT 0 COMMENT 'Users created or updated',
+  `failed_rows` INT NOT NULL DEFAULT 0 COMMENT 'Users that failed validation or could not be saved',
+  `row_errors` JSON NULL COMMENT 'Row number, email and error of each failed user',
+  `created_by` VARCHAR(255) NOT NULL COMMENT 'Admin who uploaded the file',
+  `created_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT 'Upload timestamp',
+  `updated_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT 'Last progress update',
+  `completed_at` TIMESTAMP NULL DEFAULT NULL COMMENT 'When the last batch finished',
+
+  PRIMARY KEY (`id`)
+) ENGINE=InnoDB
+  AUTO_INCREMENT=1
+  DEFAULT CHARSET=utf8mb4
+  COLLATE=utf8mb4_0900_ai_ci
+  COMMENT='Asynchronous bulk user imports';
@@ -0,0 +1,34 @@
+-- Copyright (c) 2025 WSO2 LLC. (https://www.wso2.com).
+
+-- WSO2 LLC. licenses this file to you under the Apache License,
+-- Version 2.0 (the "License"); you may not use this file except
+-- in compliance with the License.
+-- You may obtain a copy of the License at
+
+-- http://www.apache.org/licenses/LICENSE-2.0
+
+-- Unless required by applicable law or agreed to in writing,
+-- software distributed under the License is distributed on an
+-- "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+-- KIND, either express or implied.  See the License for the
+-- specific language governing permissions and limitations
+-- under the License.
+
+-- ========================================
+-- TABLE: micro_app_admin
+-- Description: Users who can administer a single micro app without the global admin group
+-- ========================================
+
+CREATE TABLE IF NOT EXISTS `micro_app_admin` (
+  `micro_app_id` VARCHAR(255) NOT NULL COMMENT 'Micro app the user administers',
+  `user_email` VARCHAR(319) NOT NULL COMMENT 'Email of the micro app admin',
+  `granted_by` VARCHAR(319) NOT NULL COMMENT 'Admin who granted the access',
+  `granted_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT 'When the access was granted',
+
+  PRIMARY KEY (`micro_app_id`, `user_email`),
+
+  INDEX `idx_micro_app_admin_user_email` (`user_email`)
+) ENGINE=InnoDB
+  DEFAULT CHARSET=utf8mb4
+  COLLATE=utf8mb4_0900_ai_ci
+  COMMENT='Per micro app admins';
@@ -0,0 +1,50 @@
+-- Copyright (c) 2025 WSO2 LLC. (https://www.wso2.com).
+
+-- WSO2 LLC. licenses this file to you under the Apache License,
+-- Version 2.0 (the "License"); you may not use this file except
+-- in compliance with the License.
+-- You may obtain a copy of the License at
+
+-- http://www.apache.org/licenses/LICENSE-2.0
+
+-- Unless required by applicable law or agreed to in writing,
+-- software distributed under the License is distributed on an
+-- "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+-- KIND, either express or implied.  See the License for the
+-- specific language governing permissions and limitations
+-- under the License.
+
+-- ========================================
+-- TABLE: permissions
+-- Description: Named capabilities that routes can require instead of a group
+-- ========================================
+
+CREATE TABLE IF NOT EXISTS `permissions` (
+  `permission_name` VARCHAR(255) NOT NULL COMMENT 'Permission identifier, e.g. notifications:send',
+  `description` VARCHAR(1024) DEFAULT NULL COMMENT 'What the permission allows',
+
+  PRIMARY KEY (`permission_name`)
+) ENGINE=InnoDB
+  DEFAULT CHARSET=utf8mb4
+  COLLATE=utf8mb4_0900_ai_ci
+  COMMENT='RBAC permissions';
+
+-- ========================================
+-- TABLE: role_permissions
+-- Description: Grants permissions to the members of a group
+-- ========================================
+
+CREATE TABLE IF NOT EXISTS `role_permissions` (
+  `group_name` VARCHAR(255) NOT NULL COMMENT 'Group from the user token (lowercase)',
+  `permission_name` VARCHAR(255) NOT NULL COMMENT 'Permission granted to the group',
+
+  PRIMARY KEY (`group_name`, `permission_name`),
+
+  INDEX `idx_role_permissions_permission` (`permission_name`),
+
+  CONSTRAINT `fk_role_permissions_permission` FOREIGN KEY (`permission_name`)
+    REFERENCES `permissions` (`permission_name`) ON DELETE CASCADE
+) ENGINE=InnoDB
+  DEFAULT CHARSET=utf8mb4
+  COLLATE=utf8mb4_0900_ai_ci
+  COMMENT='RBAC group to permission mappings';
@@ -0,0 +1,30 @@
+-- Copyright (c) 2025 WSO2 LLC. (https://www.wso2.com).
+
+-- WSO2 LLC. licenses this file to you under the Apache License,
+-- Version 2.0 (the "License"); you may not use this file except
+-- in compliance with the License.
+-- You may obtain a copy of the License at
+
+-- http://www.apache.org/licenses/LICENSE-2.0
+
+-- Unless required by applicable law or agreed to in writing,
+-- software distributed under the License is distributed on an
+-- "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+-- KIND, either express or implied.  See the License for the
+-- specific language governing permissions and limitations
+-- under the License.
+
+-- ========================================
+-- TABLE: group_hierarchy
+-- Description: Nested groups; members of the parent group are implicitly members of the child group
+-- ========================================
+
+CREATE TABLE IF NOT EXISTS `group_hierarchy` (
+  `parent_group` VARCHAR(255) NOT NULL COMMENT 'Group that implies the child, e.g. superadmin',
+  `child_group` VARCHAR(255) NOT NULL COMMENT 'Group implied by the parent, e.g. admin',
+
+  PRIMARY KEY (`parent_group`, `child_group`)
+) ENGINE=InnoDB
+  DEFAULT CHARSET=utf8mb4
+  COLLATE=utf8mb4_0900_ai_ci
+  COMMENT='RBAC group inheritance';
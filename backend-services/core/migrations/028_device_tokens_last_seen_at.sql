@@ -0,0 +1,27 @@
+-- Copyright (c) 2025 WSO2 LLC. (https://www.wso2.com).
+
+-- WSO2 LLC. licenses this file to you under the Apache License,
+-- Version 2.0 (the "License"); you may not use this file except
+-- in compliance with the License.
+-- You may obtain a copy of the License at
+
+-- http://www.apache.org/licenses/LICENSE-2.0
+
+-- Unless required by applicable law or agreed to in writing,
+-- software distributed under the License is distributed on an
+-- "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+-- KIND, either express or implied.  See the License for the
+-- specific language governing permissions and limitations
+-- under the License.
+
+-- ========================================
+-- TABLE: device_tokens
+-- Description: When each token was last registered, so stale tokens can be deactivated
+-- ========================================
+
+ALTER TABLE `device_tokens`
+  ADD COLUMN `last_seen_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT 'When the token was last registered' AFTER `app_build`,
+  ADD INDEX `idx_device_tokens_last_seen_at` (`last_seen_at`);
+
+-- Existing tokens were last seen when their row last changed
+UPDATE `device_tokens` SET `last_seen_at` = `updated_at`;
@@ -0,0 +1,30 @@
+-- Copyright (c) 2025 WSO2 LLC. (https://www.wso2.com).
+
+-- WSO2 LLC. licenses this file to you under the Apache License,
+-- Version 2.0 (the "License"); you may not use this file except
+-- in compliance with the License.
+-- You may obtain a copy of the License at
+
+-- http://www.apache.org/licenses/LICENSE-2.0
+
+-- Unless required by applicable law or agreed to in writing,
+-- software distributed under the License is distributed on an
+-- "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+-- KIND, either express or implied.  See the License for the
+-- specific language governing permissions and limitations
+-- under the License.
+
+-- ========================================
+-- TABLE: scheduled_notifications
+-- Description: Lease on claimed rows, so another instance can reclaim rows whose claim expired
+-- ========================================
+
+ALTER TABLE `scheduled_notifications`
+  ADD COLUMN `claim_token` VARCHAR(36) DEFAULT NULL COMMENT 'Identifies the current claim; updates by an earlier claim are ignored' AFTER `claimed_at`,
+  ADD COLUMN `lease_expires_at` TIMESTAMP NULL DEFAULT NULL COMMENT 'Time after which another worker may reclaim the row' AFTER `claim_token`,
+  ADD INDEX `idx_sn_status_lease` (`status`, `lease_expires_at`);
+
+-- Rows claimed before leases existed keep the previous 10 minute claim timeout
+UPDATE `scheduled_notifications`
+SET `lease_expires_at` = `claimed_at` + INTERVAL 10 MINUTE
+WHERE `status` = 'processing' AND `claimed_at` IS NOT NULL;
@@ -0,0 +1,34 @@
+-- Copyright (c) 2025 WSO2 LLC. (https://www.wso2.com).
+
+-- WSO2 LLC. licenses this file to you under the Apache License,
+-- Version 2.0 (the "License"); you may not use this file except
+-- in compliance with the License.
+-- You may obtain a copy of the License at
+
+-- http://www.apache.org/licenses/LICENSE-2.0
+
+-- Unless required by applicable law or agreed to in writing,
+-- software distributed under the License is distributed on an
+-- "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+-- KIND, either express or implied.  See the License for the
+-- specific language governing permissions and limitations
+-- under the License.
+
+-- ========================================
+-- TABLE: micro_app_assets
+-- Description: Micro app that owns each file uploaded with an appId, used to vet notification images
+-- ========================================
+
+CREATE TABLE IF NOT EXISTS `micro_app_assets` (
+  `file_name` VARCHAR(255) NOT NULL COMMENT 'File name in the file service',
+  `micro_app_id` VARCHAR(255) NOT NULL COMMENT 'Micro app that owns the file',
+  `url` VARCHAR(2083) NOT NULL COMMENT 'Download URL returned by the file service',
+  `uploaded_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT 'When the file was last uploaded',
+
+  PRIMARY KEY (`file_name`),
+
+  INDEX `idx_micro_app_assets_micro_app_id` (`micro_app_id`)
+) ENGINE=InnoDB
+  DEFAULT CHARSET=utf8mb4
+  COLLATE=utf8mb4_0900_ai_ci
+  COMMENT='Owners of uploaded micro app files';
@@ -0,0 +1,43 @@
+-- Copyright (c) 2025 WSO2 LLC. (https://www.wso2.com).
+
+-- WSO2 LLC. licenses this file to you under the Apache License,
+-- Version 2.0 (the "License"); you may not use this file except
+-- in compliance with the License.
+-- You may obtain a copy of the License at
+
+-- http://www.apache.org/licenses/LICENSE-2.0
+
+-- Unless required by applicable law or agreed to in writing,
+-- software distributed under the License is distributed on an
+-- "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+-- KIND, either express or implied.  See the License for the
+-- specific language governing permissions and limitations
+-- under the License.
+
+-- ========================================
+-- TABLE: user_data_exports
+-- Description: Data-subject access exports of everything stored about a user, and who requested and downloaded them
+-- ========================================
+
+CREATE TABLE IF NOT EXISTS `user_data_exports` (
+  `id` BIGINT NOT NULL AUTO_INCREMENT COMMENT 'Internal auto-increment ID, used as the export ID',
+  `user_email` VARCHAR(255) NOT NULL COMMENT 'User whose data is exported',
+  `requested_by` VARCHAR(255) NOT NULL COMMENT 'User or admin who requested the export',
+  `status` VARCHAR(20) NOT NULL DEFAULT 'processing' COMMENT 'processing, completed or failed',
+  `error` VARCHAR(500) NULL COMMENT 'Why a failed export failed',
+  `data` LONGBLOB NULL COMMENT 'The JSON export document',
+  `size_bytes` BIGINT NOT NULL DEFAULT 0 COMMENT 'Size of the export document',
+  `download_count` INT NOT NULL DEFAULT 0 COMMENT 'Times the export was downloaded',
+  `last_downloaded_by` VARCHAR(255) NULL COMMENT 'Who downloaded the export last',
+  `last_downloaded_at` TIMESTAMP NULL DEFAULT NULL COMMENT 'When the export was downloaded last',
+  `created_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT 'Request timestamp',
+  `completed_at` TIMESTAMP NULL DEFAULT NULL COMMENT 'When the export finished or failed',
+
+  PRIMARY KEY (`id`),
+
+  INDEX `idx_user_data_exports_user_email` (`user_email`)
+) ENGINE=InnoDB
+  AUTO_INCREMENT=1
+  DEFAULT CHARSET=utf8mb4
+  COLLATE=utf8mb4_0900_ai_ci
+  COMMENT='Asynchronous per-user data exports';
@@ -0,0 +1,38 @@
+-- Copyright (c) 2025 WSO2 LLC. (https://www.wso2.com).
+
+-- WSO2 LLC. licenses this file to you under the Apache License,
+-- Version 2.0 (the "License"); you may not use this file except
+-- in compliance with the License.
+-- You may obtain a copy of the License at
+
+-- http://www.apache.org/licenses/LICENSE-2.0
+
+-- Unless required by applicable law or agreed to in writing,
+-- software distributed under the License is distributed on an
+-- "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+-- KIND, either express or implied.  See the License for the
+-- specific language governing permissions and limitations
+-- under the License.
+
+-- ========================================
+-- TABLE: user_erasures
+-- Description: Audit trail of right-to-erasure requests. The erased email is only stored hashed.
+-- ========================================
+
+CREATE TABLE IF NOT EXISTS `user_erasures` (
+  `id` BIGINT NOT NULL AUTO_INCREMENT COMMENT 'Internal auto-increment ID, used as the erasure ID',
+  `user_email_hash` CHAR(64) NOT NULL COMMENT 'Hex SHA-256 of the lower-cased email of the erased user',
+  `pseudonym` VARCHAR(64) NOT NULL COMMENT 'Replaces the email on rows that were anonymized rather than deleted',
+  `requested_by` VARCHAR(255) NOT NULL COMMENT 'Admin who requested the erasure',
+  `categories` JSON NULL COMMENT 'Action taken and rows affected per data category',
+  `profile_deleted` TINYINT(1) NOT NULL DEFAULT 0 COMMENT 'Whether the user was removed from the user service',
+  `created_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT 'Erasure timestamp',
+
+  PRIMARY KEY (`id`),
+
+  INDEX `idx_user_erasures_email_hash` (`user_email_hash`)
+) ENGINE=InnoDB
+  AUTO_INCREMENT=1
+  DEFAULT CHARSET=utf8mb4
+  COLLATE=utf8mb4_0900_ai_ci
+  COMMENT='Right-to-erasure audit records';
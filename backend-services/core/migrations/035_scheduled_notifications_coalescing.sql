@@ -0,0 +1,25 @@
+-- Copyright (c) 2025 WSO2 LLC. (https://www.wso2.com).
+
+-- WSO2 LLC. licenses this file to you under the Apache License,
+-- Version 2.0 (the "License"); you may not use this file except
+-- in compliance with the License.
+-- You may obtain a copy of the License at
+
+-- http://www.apache.org/licenses/LICENSE-2.0
+
+-- Unless required by applicable law or agreed to in writing,
+-- software distributed under the License is distributed on an
+-- "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+-- KIND, either express or implied.  See the License for the
+-- specific language governing permissions and limitations
+-- under the License.
+
+-- ========================================
+-- TABLE: scheduled_notifications
+-- Description: Pending notifications that sends within a microapp's coalescing window are merged into
+-- ========================================
+
+ALTER TABLE `scheduled_notifications`
+  ADD COLUMN `coalesce_key` CHAR(64) DEFAULT NULL COMMENT 'Hash of the microapp and recipients; set on notifications later sends may be merged into' AFTER `last_error`,
+  ADD COLUMN `coalesced_count` INT NOT NULL DEFAULT 1 COMMENT 'Sends merged into the notification' AFTER `coalesce_key`,
+  ADD INDEX `idx_sn_coalesce_key_status` (`coalesce_key`, `status`);
@@ -0,0 +1,43 @@
+-- Copyright (c) 2025 WSO2 LLC. (https://www.wso2.com).
+
+-- WSO2 LLC. licenses this file to you under the Apache License,
+-- Version 2.0 (the "License"); you may not use this file except
+-- in compliance with the License.
+-- You may obtain a copy of the License at
+
+-- http://www.apache.org/licenses/LICENSE-2.0
+
+-- Unless required by applicable law or agreed to in writing,
+-- software distributed under the License is distributed on an
+-- "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+-- KIND, either express or implied.  See the License for the
+-- specific language governing permissions and limitations
+-- under the License.
+
+-- ========================================
+-- TABLE: file_checksums
+-- Description: SHA-256 of each file uploaded through the core service, published with microapp versions
+-- ========================================
+
+CREATE TABLE IF NOT EXISTS `file_checksums` (
+  `file_name` VARCHAR(255) NOT NULL COMMENT 'File name in the file service',
+  `url` VARCHAR(2083) NOT NULL COMMENT 'Download URL returned by the file service',
+  `content_sha256` CHAR(64) NOT NULL COMMENT 'Lowercase hex SHA-256 of the uploaded content',
+  `uploaded_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT 'When the file was last uploaded',
+
+  PRIMARY KEY (`file_name`),
+
+  INDEX `idx_file_checksums_url` (`url`(255))
+) ENGINE=InnoDB
+  DEFAULT CHARSET=utf8mb4
+  COLLATE=utf8mb4_0900_ai_ci
+  COMMENT='Checksums of uploaded files';
+
+-- ========================================
+-- TABLE: micro_app_version
+-- Description: Bundle checksum and optional publisher signature for clients to verify downloads
+-- ========================================
+
+ALTER TABLE `micro_app_version`
+  ADD COLUMN `content_sha256` CHAR(64) NULL COMMENT 'Lowercase hex SHA-256 of the bundle' AFTER `download_url`,
+  ADD COLUMN `signature` TEXT NULL COMMENT 'Publisher signature over the bundle' AFTER `content_sha256`;
@@ -0,0 +1,62 @@
+-- Copyright (c) 2025 WSO2 LLC. (https://www.wso2.com).
+
+-- WSO2 LLC. licenses this file to you under the Apache License,
+-- Version 2.0 (the "License"); you may not use this file except
+-- in compliance with the License.
+-- You may obtain a copy of the License at
+
+-- http://www.apache.org/licenses/LICENSE-2.0
+
+-- Unless required by applicable law or agreed to in writing,
+-- software distributed under the License is distributed on an
+-- "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+-- KIND, either express or implied.  See the License for the
+-- specific language governing permissions and limitations
+-- under the License.
+
+-- ========================================
+-- TABLE: categories
+-- Description: Functional groupings of micro apps (productivity, communication, ...) for browsing
+-- ========================================
+
+CREATE TABLE IF NOT EXISTS `categories` (
+  `id` INT NOT NULL AUTO_INCREMENT COMMENT 'Internal auto-increment ID',
+  `name` VARCHAR(255) NOT NULL COMMENT 'Display name',
+  `slug` VARCHAR(64) NOT NULL COMMENT 'URL-safe identifier used in filters and assignments',
+  `description` TEXT DEFAULT NULL COMMENT 'Description shown when browsing the category',
+  `created_by` VARCHAR(319) NOT NULL COMMENT 'Email of creator',
+  `created_at` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT 'Creation timestamp',
+
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `uq_categories_slug` (`slug`)
+) ENGINE=InnoDB
+  DEFAULT CHARSET=utf8mb4
+  COLLATE=utf8mb4_0900_ai_ci
+  COMMENT='Micro app categories';
+
+-- ========================================
+-- TABLE: micro_app_category
+-- Description: Categories each micro app is assigned to
+-- ========================================
+
+CREATE TABLE IF NOT EXISTS `micro_app_category` (
+  `micro_app_id` VARCHAR(255) NOT NULL COMMENT 'Reference to micro_app.micro_app_id',
+  `category_id` INT NOT NULL COMMENT 'Reference to categories.id',
+
+  PRIMARY KEY (`micro_app_id`, `category_id`),
+
+  INDEX `idx_mac_category` (`category_id`),
+
+  CONSTRAINT `fk_mac_micro_app`
+    FOREIGN KEY (`micro_app_id`)
+    REFERENCES `micro_app` (`micro_app_id`)
+    ON DELETE CASCADE
+    ON UPDATE CASCADE,
+  CONSTRAINT `fk_mac_category`
+    FOREIGN KEY (`category_id`)
+    REFERENCES `categories` (`id`)
+    ON DELETE CASCADE
+) ENGINE=InnoDB
+  DEFAULT CHARSET=utf8mb4
+  COLLATE=utf8mb4_0900_ai_ci
+  COMMENT='Micro app category assignments';
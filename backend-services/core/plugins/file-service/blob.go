@@ -0,0 +1,67 @@
+// Copyright (c) 2025 WSO2 LLC. (https://www.wso2.com).
+//
+// WSO2 LLC. licenses this file to you under the Apache License,
+// Version 2.0 (the "License"); you may not use this file except
+// in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+package fileservice
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+)
+
+// Blob is a stored file opened for download. Its size, content type and ETag are known before any
+// content is read, so a backend that can seek (e.g. ranged object reads) fetches only the bytes
+// a download asks for.
+type Blob interface {
+	Size() int64
+	// ContentType is empty for files stored before content types were recorded
+	ContentType() string
+	// ETag is a quoted strong validator that changes whenever the content does
+	ETag() string
+	// SHA256 is the lowercase hex SHA-256 of the whole content
+	SHA256() string
+	// ReadRange returns length bytes of the content starting at offset. The caller closes it.
+	ReadRange(offset, length int64) (io.ReadCloser, error)
+}
+
+// NewMemoryBlob returns a Blob over content already held in memory. Its ETag is the content's SHA-256.
+func NewMemoryBlob(content []byte, contentType string) Blob {
+	sum := sha256.Sum256(content)
+	return &memoryBlob{
+		content:     content,
+		contentType: contentType,
+		sha256:      hex.EncodeToString(sum[:]),
+	}
+}
+
+type memoryBlob struct {
+	content     []byte
+	contentType string
+	sha256      string
+}
+
+func (b *memoryBlob) Size() int64         { return int64(len(b.content)) }
+func (b *memoryBlob) ContentType() string { return b.contentType }
+func (b *memoryBlob) ETag() string        { return `"` + b.sha256 + `"` }
+func (b *memoryBlob) SHA256() string      { return b.sha256 }
+
+func (b *memoryBlob) ReadRange(offset, length int64) (io.ReadCloser, error) {
+	if offset < 0 || length < 0 || offset+length > b.Size() {
+		return nil, fmt.Errorf("range %d+%d is outside the %d byte file", offset, length, b.Size())
+	}
+	return io.NopCloser(bytes.NewReader(b.content[offset : offset+length])), nil
+}
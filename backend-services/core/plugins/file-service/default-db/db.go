@@ -18,7 +18,9 @@ package db
 import (
 	"errors"
 	"fmt"
+	"io"
 	"log/slog"
+	"net/http"
 	"net/url"
 
 	fileservice "github.com/opensuperapp/opensuperapp/backend-services/core/plugins/file-service"
@@ -27,10 +29,12 @@ import (
 )
 
 // MicroAppFile represents a file stored in the database.
-// Files are stored with their name as the primary key and content as a BLOB.
+// Files are stored with their name as the primary key and content as a BLOB,
+// along with the content type detected from the content.
 type MicroAppFile struct {
 	FileName    string `gorm:"column:file_name;primaryKey;type:varchar(255)"`
 	BlobContent []byte `gorm:"column:blob_content;type:mediumblob;not null"`
+	ContentType string `gorm:"column:content_type;type:varchar(100)"`
 }
 
 func (MicroAppFile) TableName() string {
@@ -87,6 +91,7 @@ func (s *DBFileService) UploadFile(fileName string, content []byte) (string, err
 	file := MicroAppFile{
 		FileName:    fileName,
 		BlobContent: content,
+		ContentType: http.DetectContentType(content),
 	}
 
 	result := s.db.Save(&file)
@@ -104,6 +109,17 @@ func (s *DBFileService) UploadFile(fileName string, content []byte) (string, err
 	return downloadUrl, nil
 }
 
+// UploadFileStream reads the content into memory and stores it as UploadFile does, since the
+// file is saved as a single BLOB. Callers should cap how much r can return.
+func (s *DBFileService) UploadFileStream(fileName string, r io.Reader, size int64) (string, error) {
+	content, err := io.ReadAll(r)
+	if err != nil {
+		slog.Error("Failed to read file content", "error", err, "fileName", fileName)
+		return "", err
+	}
+	return s.UploadFile(fileName, content)
+}
+
 // DeleteFile removes a file from the database by fileName.
 // Returns gorm.ErrRecordNotFound if the file doesn't exist.
 //
@@ -130,6 +146,16 @@ func (s *DBFileService) DeleteFile(fileName string) error {
 	return nil
 }
 
+// PresignUpload is not supported: files stored in the database are uploaded through the core service.
+func (s *DBFileService) PresignUpload(fileName string) (string, error) {
+	return "", fileservice.ErrPresignUnsupported
+}
+
+// PresignDownload is not supported: files stored in the database are downloaded through the core service.
+func (s *DBFileService) PresignDownload(fileName string) (string, error) {
+	return "", fileservice.ErrPresignUnsupported
+}
+
 // GetDownloadURL generates the download URL for a file.
 // The URL is constructed using the base URL configured during service initialization.
 //
@@ -147,14 +173,25 @@ func (s *DBFileService) GetDownloadURL(fileName string) (string, error) {
 	return fmt.Sprintf("%s/public/micro-app-files/download/%s", s.baseURL, url.PathEscape(fileName)), nil
 }
 
+// OpenBlob loads a file for download. The whole content is read from the database, and ranges
+// are sliced from it in memory. Returns gorm.ErrRecordNotFound if the file doesn't exist.
+func (s *DBFileService) OpenBlob(fileName string) (fileservice.Blob, error) {
+	content, contentType, err := s.GetBlobContent(fileName)
+	if err != nil {
+		return nil, err
+	}
+	return fileservice.NewMemoryBlob(content, contentType), nil
+}
+
 // GetBlobContent retrieves the blob content of a file by fileName.
 // Returns gorm.ErrRecordNotFound if the file doesn't exist.
 //
 // Parameters:
 //   - fileName: The name of the file to retrieve
 //
-// Returns the file content as bytes or an error if retrieval fails.
-func (s *DBFileService) GetBlobContent(fileName string) ([]byte, error) {
+// Returns the file content as bytes and its content type (empty for files stored before
+// content types were recorded), or an error if retrieval fails.
+func (s *DBFileService) GetBlobContent(fileName string) ([]byte, string, error) {
 	slog.Info("Retrieving blob content", "fileName", fileName)
 
 	var file MicroAppFile
@@ -166,9 +203,9 @@ func (s *DBFileService) GetBlobContent(fileName string) ([]byte, error) {
 		} else {
 			slog.Error("Failed to retrieve blob content", "error", result.Error, "fileName", fileName)
 		}
-		return nil, result.Error
+		return nil, "", result.Error
 	}
 
 	slog.Info("Blob content retrieved successfully", "fileName", fileName, "size", len(file.BlobContent))
-	return file.BlobContent, nil
+	return file.BlobContent, file.ContentType, nil
 }
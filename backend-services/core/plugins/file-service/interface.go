@@ -16,13 +16,30 @@
 package fileservice
 
 import (
+	"errors"
+	"io"
+	"time"
+
 	"github.com/opensuperapp/opensuperapp/backend-services/core/internal/registry"
 )
 
+// PresignExpiry is how long presigned upload and download URLs stay valid.
+const PresignExpiry = 15 * time.Minute
+
+// ErrPresignUnsupported is returned by file services that cannot issue presigned URLs.
+var ErrPresignUnsupported = errors.New("file service does not support presigned URLs")
+
 // FileService defines the interface for file management operations.
 type FileService interface {
 	UploadFile(fileName string, content []byte) (string, error)
+	// UploadFileStream stores the content read from r, which is size bytes long or -1 when the size
+	// is unknown. Backends that can stream do not hold the whole file in memory.
+	UploadFileStream(fileName string, r io.Reader, size int64) (string, error)
 	DeleteFile(fileName string) error
+	// PresignUpload returns a URL the client can PUT the file's content to directly, valid for PresignExpiry.
+	PresignUpload(fileName string) (string, error)
+	// PresignDownload returns a URL the client can GET the file from directly, valid for PresignExpiry.
+	PresignDownload(fileName string) (string, error)
 }
 
 // Registry is the global registry for FileService implementations.
@@ -0,0 +1,301 @@
+// Copyright (c) 2025 WSO2 LLC. (https://www.wso2.com).
+//
+// WSO2 LLC. licenses this file to you under the Apache License,
+// Version 2.0 (the "License"); you may not use this file except
+// in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+package s3
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/feature/s3/manager"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+
+	fileservice "github.com/opensuperapp/opensuperapp/backend-services/core/plugins/file-service"
+)
+
+const (
+	// requestTimeout bounds a single upload or delete request to the object store.
+	requestTimeout = 2 * time.Minute
+	// streamTimeout bounds a streamed upload, which lasts as long as the client takes to send the file.
+	streamTimeout = 30 * time.Minute
+	// sniffLen is how many leading bytes http.DetectContentType considers.
+	sniffLen = 512
+)
+
+// S3FileService implements the FileService interface using an S3-compatible object store.
+// Files are stored as objects in one bucket and are downloaded from the store directly,
+// so the bucket (or the CDN in front of it) must allow public reads of the objects.
+type S3FileService struct {
+	client    *s3.Client
+	presigner *s3.PresignClient
+	uploader  *manager.Uploader
+	bucket    string
+	keyPrefix string
+	publicURL string
+}
+
+func init() {
+	fileservice.Registry.Register("s3", New)
+}
+
+// New creates an S3FileService from the FILE_SERVICE_S3_ configuration:
+//   - FILE_SERVICE_S3_BUCKET: the bucket files are stored in (required)
+//   - FILE_SERVICE_S3_REGION: the bucket's region (uses the AWS default chain when empty)
+//   - FILE_SERVICE_S3_ENDPOINT: the endpoint of an S3-compatible store such as MinIO (AWS when empty)
+//   - FILE_SERVICE_S3_USE_PATH_STYLE: "true" to address the bucket in the path, as most S3-compatible stores need
+//   - FILE_SERVICE_S3_ACCESS_KEY_ID / FILE_SERVICE_S3_SECRET_ACCESS_KEY: static credentials (uses the AWS default chain when empty)
+//   - FILE_SERVICE_S3_KEY_PREFIX: prefix added to every object key, e.g. "micro-app-files/"
+//   - FILE_SERVICE_S3_PUBLIC_BASE_URL: base of the returned download URLs, e.g. a CDN (the bucket URL when empty)
+func New(config map[string]any) (fileservice.FileService, error) {
+	bucket := configString(config, "FILE_SERVICE_S3_BUCKET")
+	if bucket == "" {
+		return nil, fmt.Errorf("S3FileService: FILE_SERVICE_S3_BUCKET is required")
+	}
+	region := configString(config, "FILE_SERVICE_S3_REGION")
+	endpoint := strings.TrimSuffix(configString(config, "FILE_SERVICE_S3_ENDPOINT"), "/")
+	usePathStyle := configString(config, "FILE_SERVICE_S3_USE_PATH_STYLE") == "true"
+
+	var opts []func(*awsconfig.LoadOptions) error
+	if region != "" {
+		opts = append(opts, awsconfig.WithRegion(region))
+	}
+	accessKeyID := configString(config, "FILE_SERVICE_S3_ACCESS_KEY_ID")
+	secretAccessKey := configString(config, "FILE_SERVICE_S3_SECRET_ACCESS_KEY")
+	if accessKeyID != "" || secretAccessKey != "" {
+		if accessKeyID == "" || secretAccessKey == "" {
+			return nil, fmt.Errorf("S3FileService: FILE_SERVICE_S3_ACCESS_KEY_ID and FILE_SERVICE_S3_SECRET_ACCESS_KEY must be set together")
+		}
+		opts = append(opts, awsconfig.WithCredentialsProvider(credentials.NewStaticCredentialsProvider(accessKeyID, secretAccessKey, "")))
+	}
+	awsCfg, err := awsconfig.LoadDefaultConfig(context.Background(), opts...)
+	if err != nil {
+		return nil, fmt.Errorf("S3FileService: failed to load AWS configuration: %w", err)
+	}
+	if awsCfg.Region == "" {
+		return nil, fmt.Errorf("S3FileService: FILE_SERVICE_S3_REGION is required when no AWS region is configured")
+	}
+
+	client := s3.NewFromConfig(awsCfg, func(o *s3.Options) {
+		if endpoint != "" {
+			o.BaseEndpoint = aws.String(endpoint)
+		}
+		o.UsePathStyle = usePathStyle
+	})
+
+	publicURL := strings.TrimSuffix(configString(config, "FILE_SERVICE_S3_PUBLIC_BASE_URL"), "/")
+	if publicURL == "" {
+		publicURL = bucketURL(bucket, awsCfg.Region, endpoint, usePathStyle)
+	}
+
+	slog.Info("Initializing S3FileService", "bucket", bucket, "region", awsCfg.Region, "endpoint", endpoint, "public_url", publicURL)
+	return &S3FileService{
+		client:    client,
+		presigner: s3.NewPresignClient(client),
+		uploader:  manager.NewUploader(client),
+		bucket:    bucket,
+		keyPrefix: configString(config, "FILE_SERVICE_S3_KEY_PREFIX"),
+		publicURL: publicURL,
+	}, nil
+}
+
+// UploadFile stores or replaces an object named after the file.
+//
+// Parameters:
+//   - fileName: The name of the file (max 255 characters, required)
+//   - content: The file content as bytes (nil is treated as empty content)
+//
+// Returns the object's public URL or an error if the operation fails.
+func (s *S3FileService) UploadFile(fileName string, content []byte) (string, error) {
+	slog.Info("Uploading file", "fileName", fileName, "size", len(content))
+
+	if err := checkFileName(fileName); err != nil {
+		return "", err
+	}
+	if content == nil {
+		content = []byte{}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), requestTimeout)
+	defer cancel()
+	_, err := s.client.PutObject(ctx, &s3.PutObjectInput{
+		Bucket:        aws.String(s.bucket),
+		Key:           aws.String(s.objectKey(fileName)),
+		Body:          bytes.NewReader(content),
+		ContentLength: aws.Int64(int64(len(content))),
+		ContentType:   aws.String(http.DetectContentType(content)),
+	})
+	if err != nil {
+		slog.Error("Failed to upload file", "error", err, "fileName", fileName)
+		return "", err
+	}
+
+	slog.Info("File uploaded successfully", "fileName", fileName)
+	return s.GetDownloadURL(fileName), nil
+}
+
+// UploadFileStream streams the content to the object store. Content that fits in one part
+// (5 MiB) is sent in a single request and larger content as a multipart upload, so only a few
+// parts are held in memory whatever the size of the file.
+//
+// Parameters:
+//   - fileName: The name of the file (max 255 characters, required)
+//   - r: The file content
+//   - size: The content length, or -1 when unknown
+//
+// Returns the object's public URL or an error if the operation fails.
+func (s *S3FileService) UploadFileStream(fileName string, r io.Reader, size int64) (string, error) {
+	slog.Info("Streaming file upload", "fileName", fileName, "size", size)
+
+	if err := checkFileName(fileName); err != nil {
+		return "", err
+	}
+	// Detect the content type from the leading bytes without consuming them
+	br := bufio.NewReaderSize(r, sniffLen)
+	head, err := br.Peek(sniffLen)
+	if err != nil && err != io.EOF {
+		slog.Error("Failed to read file content", "error", err, "fileName", fileName)
+		return "", err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), streamTimeout)
+	defer cancel()
+	_, err = s.uploader.Upload(ctx, &s3.PutObjectInput{
+		Bucket:      aws.String(s.bucket),
+		Key:         aws.String(s.objectKey(fileName)),
+		Body:        br,
+		ContentType: aws.String(http.DetectContentType(head)),
+	})
+	if err != nil {
+		slog.Error("Failed to upload file", "error", err, "fileName", fileName)
+		return "", err
+	}
+
+	slog.Info("File uploaded successfully", "fileName", fileName)
+	return s.GetDownloadURL(fileName), nil
+}
+
+// DeleteFile removes the file's object. Deleting a file that does not exist succeeds,
+// because S3 does not report whether the object existed.
+//
+// Parameters:
+//   - fileName: The name of the file to delete
+//
+// Returns an error if the deletion fails.
+func (s *S3FileService) DeleteFile(fileName string) error {
+	slog.Info("Deleting file", "fileName", fileName)
+
+	ctx, cancel := context.WithTimeout(context.Background(), requestTimeout)
+	defer cancel()
+	_, err := s.client.DeleteObject(ctx, &s3.DeleteObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(s.objectKey(fileName)),
+	})
+	if err != nil {
+		slog.Error("Failed to delete file", "error", err, "fileName", fileName)
+		return err
+	}
+
+	slog.Info("File deleted successfully", "fileName", fileName)
+	return nil
+}
+
+// PresignUpload returns a presigned PUT URL for the file's object, so large files can be
+// uploaded to the store without passing through the core service.
+func (s *S3FileService) PresignUpload(fileName string) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), requestTimeout)
+	defer cancel()
+	req, err := s.presigner.PresignPutObject(ctx, &s3.PutObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(s.objectKey(fileName)),
+	}, s3.WithPresignExpires(fileservice.PresignExpiry))
+	if err != nil {
+		slog.Error("Failed to presign upload", "error", err, "fileName", fileName)
+		return "", err
+	}
+	return req.URL, nil
+}
+
+// PresignDownload returns a presigned GET URL for the file's object, which works even when
+// the bucket does not allow public reads.
+func (s *S3FileService) PresignDownload(fileName string) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), requestTimeout)
+	defer cancel()
+	req, err := s.presigner.PresignGetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(s.objectKey(fileName)),
+	}, s3.WithPresignExpires(fileservice.PresignExpiry))
+	if err != nil {
+		slog.Error("Failed to presign download", "error", err, "fileName", fileName)
+		return "", err
+	}
+	return req.URL, nil
+}
+
+// GetDownloadURL returns the public URL of the file's object. Each segment of the object key
+// is escaped for use in a URL path, so a key prefix may contain slashes.
+func (s *S3FileService) GetDownloadURL(fileName string) string {
+	segments := strings.Split(s.objectKey(fileName), "/")
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
+	}
+	return fmt.Sprintf("%s/%s", s.publicURL, strings.Join(segments, "/"))
+}
+
+func (s *S3FileService) objectKey(fileName string) string {
+	return s.keyPrefix + fileName
+}
+
+// bucketURL returns the URL objects in the bucket are served from.
+func bucketURL(bucket, region, endpoint string, usePathStyle bool) string {
+	if endpoint == "" {
+		if usePathStyle {
+			return fmt.Sprintf("https://s3.%s.amazonaws.com/%s", region, bucket)
+		}
+		return fmt.Sprintf("https://%s.s3.%s.amazonaws.com", bucket, region)
+	}
+	if usePathStyle {
+		return fmt.Sprintf("%s/%s", endpoint, bucket)
+	}
+	if scheme, host, ok := strings.Cut(endpoint, "://"); ok {
+		return fmt.Sprintf("%s://%s.%s", scheme, bucket, host)
+	}
+	return fmt.Sprintf("%s.%s", bucket, endpoint)
+}
+
+func checkFileName(fileName string) error {
+	if fileName == "" {
+		return fmt.Errorf("S3FileService: fileName is required")
+	}
+	if len(fileName) > 255 {
+		return fmt.Errorf("S3FileService: fileName is too long (max 255)")
+	}
+	return nil
+}
+
+func configString(config map[string]any, key string) string {
+	value, _ := config[key].(string)
+	return strings.TrimSpace(value)
+}
@@ -24,4 +24,7 @@ import (
 	// Default Implementations
 	_ "github.com/opensuperapp/opensuperapp/backend-services/core/plugins/file-service/default-db"
 	_ "github.com/opensuperapp/opensuperapp/backend-services/core/plugins/user-service/default-db"
+
+	// Object Storage
+	_ "github.com/opensuperapp/opensuperapp/backend-services/core/plugins/file-service/s3"
 )
@@ -18,6 +18,7 @@ package db
 import (
 	"fmt"
 	"log/slog"
+	"strings"
 
 	"github.com/opensuperapp/opensuperapp/backend-services/core/internal/models"
 
@@ -94,6 +95,59 @@ func (s *DBUserService) GetAllUsers() ([]*models.User, error) {
 	return modelUsers, nil
 }
 
+// likeEscaper escapes the LIKE wildcards in a search term, using ! as the escape character.
+var likeEscaper = strings.NewReplacer("!", "!!", "%", "!%", "_", "!_")
+
+// sortColumns are the columns each sort field orders by, ending with the unique email.
+var sortColumns = map[string][]string{
+	userservice.SortByFirstName: {"firstName", "lastName", "email"},
+	userservice.SortByLastName:  {"lastName", "firstName", "email"},
+	userservice.SortByEmail:     {"email"},
+}
+
+// GetUsersPaginated retrieves a page of users in the requested order (by default the same as
+// GetAllUsers), with the total number of users matching the search prefix.
+func (s *DBUserService) GetUsersPaginated(limit, offset int, search string, sort userservice.UserSort) ([]*models.User, int64, error) {
+	columns, ok := sortColumns[sort.Field]
+	if !ok && sort.Field != "" {
+		return nil, 0, fmt.Errorf("DBUserService: unknown sort field %q", sort.Field)
+	}
+	if !ok {
+		columns = sortColumns[userservice.SortByFirstName]
+	}
+	direction := " ASC"
+	if sort.Desc {
+		direction = " DESC"
+	}
+	order := strings.Join(columns, direction+", ") + direction
+
+	matchesSearch := func(tx *gorm.DB) *gorm.DB {
+		if search == "" {
+			return tx
+		}
+		prefix := likeEscaper.Replace(search) + "%"
+		return tx.Where("email LIKE ? ESCAPE '!' OR firstName LIKE ? ESCAPE '!' OR lastName LIKE ? ESCAPE '!'",
+			prefix, prefix, prefix)
+	}
+
+	var total int64
+	if err := s.db.Model(&userModel{}).Scopes(matchesSearch).Count(&total).Error; err != nil {
+		slog.Error("Failed to count users", "error", err, "search", search)
+		return nil, 0, err
+	}
+	var users []userModel
+	if err := s.db.Scopes(matchesSearch).Order(order).Limit(limit).Offset(offset).Find(&users).Error; err != nil {
+		slog.Error("Failed to fetch users", "error", err, "search", search)
+		return nil, 0, err
+	}
+
+	modelUsers := make([]*models.User, len(users))
+	for i, u := range users {
+		modelUsers[i] = u.toUser()
+	}
+	return modelUsers, total, nil
+}
+
 // UpsertUser creates a new user or updates an existing one.
 func (s *DBUserService) UpsertUser(user *models.User) error {
 	dbUser := fromUser(user)
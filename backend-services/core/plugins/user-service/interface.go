@@ -24,11 +24,27 @@ import (
 type UserService interface {
 	GetUserByEmail(email string) (*models.User, error)
 	GetAllUsers() ([]*models.User, error)
+	// GetUsersPaginated returns a page of users in the given order, optionally only those whose
+	// email, first name or last name starts with search, and the total number of matching users.
+	GetUsersPaginated(limit, offset int, search string, sort UserSort) ([]*models.User, int64, error)
 	UpsertUser(user *models.User) error
 	UpsertUsers(users []*models.User) error
 	DeleteUser(email string) error
 }
 
+// Fields a page of users can be sorted by.
+const (
+	SortByFirstName = "firstName"
+	SortByLastName  = "lastName"
+	SortByEmail     = "email"
+)
+
+// UserSort orders a page of users by one of the sort fields. An empty Field sorts by first name.
+type UserSort struct {
+	Field string
+	Desc  bool
+}
+
 // Registry is the global registry for UserService implementations.
 // Implementations should register themselves in their init() functions.
 var Registry = registry.New[UserService]()
@@ -16,9 +16,16 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"log/slog"
+	"net"
 	"net/http"
 	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"github.com/opensuperapp/opensuperapp/backend-services/token-service/internal/api/v1/router"
 	"github.com/opensuperapp/opensuperapp/backend-services/token-service/internal/config"
@@ -35,12 +42,25 @@ func main() {
 	logger := slog.New(slog.NewJSONHandler(os.Stdout, nil))
 	slog.SetDefault(logger)
 
+	if err := run(cfg); err != nil {
+		slog.Error("Server failed", "error", err)
+		os.Exit(1)
+	}
+}
+
+// run serves HTTP until the process is interrupted or the server fails, then lets in-flight
+// requests drain before the key watcher stops and the database connection is closed.
+func run(cfg *config.Config) error {
 	// Connect to Database
 	db, err := gorm.Open(mysql.Open(cfg.DBDSN), &gorm.Config{})
 	if err != nil {
-		slog.Error("Failed to connect to database", "error", err)
-		os.Exit(1)
+		return fmt.Errorf("failed to connect to database: %w", err)
 	}
+	defer func() {
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	}()
 
 	// Initialize Token Service
 	// Choose between directory mode (zero-downtime rotation) or single-key mode (backward compatible)
@@ -50,16 +70,18 @@ func main() {
 		slog.Info("Initializing token service in directory mode", "keys_dir", cfg.KeysDir, "active_key", cfg.ActiveKeyID)
 		tokenService, err = services.NewTokenServiceFromDirectory(cfg.KeysDir, cfg.ActiveKeyID, cfg.TokenExpiry)
 		if err != nil {
-			slog.Error("Failed to initialize token service from directory", "error", err)
-			os.Exit(1)
+			return fmt.Errorf("failed to initialize token service from directory: %w", err)
 		}
+
+		// Pick up rotated keys without calling the admin endpoints
+		stopKeyWatcher := tokenService.StartKeyRotationWatcher(time.Duration(cfg.KeyRotationPollIntervalSec) * time.Second)
+		defer stopKeyWatcher()
 	} else {
 		// Single-key mode: Load single key pair (backward compatible)
 		slog.Info("Initializing token service in single-key mode", "key_id", cfg.ActiveKeyID)
 		tokenService, err = services.NewTokenService(cfg.PrivateKeyPath, cfg.PublicKeyPath, cfg.JWKSPath, cfg.TokenExpiry)
 		if err != nil {
-			slog.Error("Failed to initialize token service", "error", err)
-			os.Exit(1)
+			return fmt.Errorf("failed to initialize token service: %w", err)
 		}
 
 		// Set active key from config (allows override via environment variable)
@@ -70,13 +92,50 @@ func main() {
 		}
 	}
 
+	// Sign the JWKS with a root key so consumers can verify the key set itself
+	if cfg.JWKSSigningKeyPath != "" {
+		signer, err := services.NewJWKSSigner(cfg.JWKSSigningKeyPath, cfg.JWKSSigningKeyID)
+		if err != nil {
+			return fmt.Errorf("failed to initialize JWKS signer: %w", err)
+		}
+		tokenService.SetJWKSSigner(signer)
+		slog.Info("JWKS signing enabled", "key_id", cfg.JWKSSigningKeyID)
+	}
+
 	// Initialize Router
-	r := router.NewRouter(db, tokenService)
+	r := router.NewRouter(db, tokenService, cfg.PublicBaseURL, cfg.StrictScopes)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
 
 	// Start Server
+	listener, err := net.Listen("tcp", ":"+cfg.Port)
+	if err != nil {
+		return err
+	}
 	slog.Info("Starting IdP Service", "port", cfg.Port)
-	if err := http.ListenAndServe(":"+cfg.Port, r); err != nil {
-		slog.Error("Server failed", "error", err)
-		os.Exit(1)
+	return serve(ctx, &http.Server{Handler: r}, listener, time.Duration(cfg.DrainTimeoutSec)*time.Second)
+}
+
+// serve runs server on listener until it fails or ctx is done. It then stops accepting connections
+// and waits up to drainTimeout for in-flight requests to complete.
+func serve(ctx context.Context, server *http.Server, listener net.Listener, drainTimeout time.Duration) error {
+	serverErr := make(chan error, 1)
+	go func() {
+		serverErr <- server.Serve(listener)
+	}()
+
+	select {
+	case err := <-serverErr:
+		if errors.Is(err, http.ErrServerClosed) {
+			return nil
+		}
+		return err
+	case <-ctx.Done():
 	}
+
+	slog.Info("Shutting down IdP Service", "drain_timeout", drainTimeout)
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), drainTimeout)
+	defer cancel()
+	return server.Shutdown(shutdownCtx)
 }
@@ -0,0 +1,124 @@
+// Copyright (c) 2025 WSO2 LLC. (https://www.wso2.com).
+//
+// WSO2 LLC. licenses this file to you under the Apache License,
+// Version 2.0 (the "License"); you may not use this file except
+// in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+package handler
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/opensuperapp/opensuperapp/backend-services/token-service/internal/store"
+)
+
+// authorizationCodeGrant holds the token request parameters for grant_type=authorization_code
+type authorizationCodeGrant struct {
+	ClientID     string
+	ClientSecret string
+	Code         string
+	CodeVerifier string
+	RedirectURI  string
+}
+
+// exchangeAuthorizationCode redeems a PKCE-protected authorization code for a user-context token.
+// Clients marked public may omit client_secret; the code_verifier proves the caller started the flow.
+// Every other client must authenticate with its secret.
+func (h *OAuthHandler) exchangeAuthorizationCode(w http.ResponseWriter, r *http.Request, grant authorizationCodeGrant) {
+	if grant.ClientID == "" || grant.Code == "" || grant.CodeVerifier == "" {
+		writeError(w, http.StatusBadRequest, errInvalidRequest, "client_id, code and code_verifier are required")
+		return
+	}
+
+	client, err := h.clients.GetActiveClient(r.Context(), grant.ClientID)
+	if err != nil {
+		if !errors.Is(err, store.ErrNotFound) {
+			slog.Error("Failed to look up client", "error", err, "client_id", grant.ClientID)
+			writeError(w, http.StatusInternalServerError, errServerError, "")
+			return
+		}
+		slog.Warn("Client not found or inactive", "client_id", grant.ClientID)
+		writeError(w, http.StatusUnauthorized, errInvalidClient, "")
+		return
+	}
+	if !client.IsPublic {
+		if grant.ClientSecret == "" {
+			slog.Warn("Missing client secret for confidential client", "client_id", grant.ClientID)
+			writeError(w, http.StatusUnauthorized, errInvalidClient, "client_secret is required")
+			return
+		}
+		if err := checkSecret(grant.ClientSecret, client.ClientSecret); err != nil {
+			slog.Warn("Invalid client secret", "client_id", grant.ClientID)
+			writeError(w, http.StatusUnauthorized, errInvalidClient, "")
+			return
+		}
+	}
+
+	authCode, err := h.codes.GetAuthorizationCode(r.Context(), grant.Code, grant.ClientID)
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			writeError(w, http.StatusBadRequest, errInvalidGrant, "invalid authorization code")
+			return
+		}
+		slog.Error("Failed to look up authorization code", "error", err, "client_id", grant.ClientID)
+		writeError(w, http.StatusInternalServerError, errServerError, "")
+		return
+	}
+
+	now := time.Now()
+	if authCode.UsedAt != nil || now.After(authCode.ExpiresAt) {
+		writeError(w, http.StatusBadRequest, errInvalidGrant, "authorization code expired or already used")
+		return
+	}
+	if authCode.RedirectURI != "" && authCode.RedirectURI != grant.RedirectURI {
+		writeError(w, http.StatusBadRequest, errInvalidGrant, "redirect_uri does not match")
+		return
+	}
+	if !h.pkce.VerifyChallenge(grant.CodeVerifier, authCode.CodeChallenge, authCode.CodeChallengeMethod) {
+		slog.Warn("PKCE verification failed", "client_id", grant.ClientID)
+		writeError(w, http.StatusBadRequest, errInvalidGrant, "code_verifier does not match code_challenge")
+		return
+	}
+
+	// Mark the code used; only one of several concurrent redemptions of the same code succeeds
+	redeemed, err := h.codes.RedeemAuthorizationCode(r.Context(), authCode.ID, now)
+	if err != nil {
+		slog.Error("Failed to redeem authorization code", "error", err, "client_id", grant.ClientID)
+		writeError(w, http.StatusInternalServerError, errServerError, "")
+		return
+	}
+	if !redeemed {
+		writeError(w, http.StatusBadRequest, errInvalidGrant, "authorization code expired or already used")
+		return
+	}
+
+	expiry := client.TokenExpiry(h.tokenService.GetExpiryDuration())
+	token, err := h.tokenService.GenerateUserTokenWithExpiry(authCode.UserEmail, client.ClientID, authCode.Scopes, expiry)
+	if err != nil {
+		slog.Error("Failed to issue token", "error", err)
+		writeError(w, http.StatusInternalServerError, errServerError, "")
+		return
+	}
+
+	slog.Info("Authorization code redeemed", "client_id", client.ClientID)
+
+	resp := TokenResponse{
+		AccessToken: token,
+		TokenType:   tokenTypeBearer,
+		ExpiresIn:   int(expiry.Seconds()),
+		Scope:       authCode.Scopes,
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
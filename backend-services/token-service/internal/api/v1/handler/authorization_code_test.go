@@ -0,0 +1,234 @@
+// Copyright (c) 2025 WSO2 LLC. (https://www.wso2.com).
+//
+// WSO2 LLC. licenses this file to you under the Apache License,
+// Version 2.0 (the "License"); you may not use this file except
+// in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/opensuperapp/opensuperapp/backend-services/token-service/internal/models"
+	"github.com/opensuperapp/opensuperapp/backend-services/token-service/internal/services"
+	"github.com/opensuperapp/opensuperapp/backend-services/token-service/internal/store"
+
+	"gorm.io/gorm"
+)
+
+// RFC 7636 Appendix B example values
+const (
+	testCodeVerifier  = "dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk"
+	testCodeChallenge = "E9Melhoa2OwvFrEMTJguCHaoeK1t8URWbuGJSstw-cM"
+)
+
+// seedAuthorizationCode stores an authorization code for test-client
+func seedAuthorizationCode(t *testing.T, db *gorm.DB, code, method string, expiresAt time.Time) {
+	authCode := &models.AuthorizationCode{
+		Code:                code,
+		ClientID:            "test-client",
+		UserEmail:           "user@example.com",
+		RedirectURI:         "https://app.example.com/callback",
+		Scopes:              "read",
+		CodeChallenge:       testCodeChallenge,
+		CodeChallengeMethod: method,
+		ExpiresAt:           expiresAt,
+	}
+	if err := db.Create(authCode).Error; err != nil {
+		t.Fatalf("Failed to seed authorization code: %v", err)
+	}
+}
+
+// seedPublicTestClient stores test-client marked as a public client
+func seedPublicTestClient(t *testing.T, db *gorm.DB) {
+	t.Helper()
+	client := seedTestClient(t, db)
+	if err := db.Model(client).Update("is_public", true).Error; err != nil {
+		t.Fatalf("Failed to mark client public: %v", err)
+	}
+}
+
+// authorizationCodeRequest builds a form-encoded authorization_code token request from a public client
+func authorizationCodeRequest(code, verifier string) *http.Request {
+	form := url.Values{}
+	form.Set("grant_type", "authorization_code")
+	form.Set("client_id", "test-client")
+	form.Set("code", code)
+	form.Set("code_verifier", verifier)
+	form.Set("redirect_uri", "https://app.example.com/callback")
+
+	req := httptest.NewRequest(http.MethodPost, "/oauth/token", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	return req
+}
+
+// TestOAuthHandler_Token_AuthorizationCode tests redeeming a code with a valid PKCE verifier
+func TestOAuthHandler_Token_AuthorizationCode(t *testing.T) {
+	db := setupTestDB(t)
+	seedPublicTestClient(t, db)
+	seedAuthorizationCode(t, db, "code-1", services.PKCEMethodS256, time.Now().Add(time.Minute))
+	tokenService := setupTestTokenService(t)
+	handler := NewOAuthHandler(db, tokenService)
+
+	w := httptest.NewRecorder()
+	handler.Token(w, authorizationCodeRequest("code-1", testCodeVerifier))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d. Body: %s", w.Code, w.Body.String())
+	}
+
+	var resp TokenResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("Failed to parse response: %v", err)
+	}
+	if resp.AccessToken == "" {
+		t.Error("Access token is empty")
+	}
+	if resp.Scope != "read" {
+		t.Errorf("Expected scope 'read', got %q", resp.Scope)
+	}
+
+	if claims := parseTestToken(t, resp.AccessToken); claims.Subject != "user@example.com" {
+		t.Errorf("Expected subject user@example.com, got %s", claims.Subject)
+	}
+}
+
+// TestOAuthHandler_Token_AuthorizationCode_ConfidentialClient tests that a client not marked public
+// must send its secret to redeem a code
+func TestOAuthHandler_Token_AuthorizationCode_ConfidentialClient(t *testing.T) {
+	db := setupTestDB(t)
+	seedTestClient(t, db)
+	seedAuthorizationCode(t, db, "code-1", services.PKCEMethodS256, time.Now().Add(time.Minute))
+	handler := NewOAuthHandler(db, setupTestTokenService(t))
+
+	w := httptest.NewRecorder()
+	handler.Token(w, authorizationCodeRequest("code-1", testCodeVerifier))
+	if w.Code != http.StatusUnauthorized {
+		t.Fatalf("Expected status 401 without a secret, got %d. Body: %s", w.Code, w.Body.String())
+	}
+	var errResp map[string]string
+	json.Unmarshal(w.Body.Bytes(), &errResp)
+	if errResp["error"] != errInvalidClient {
+		t.Errorf("Expected error %s, got %s", errInvalidClient, errResp["error"])
+	}
+
+	req := authorizationCodeRequest("code-1", testCodeVerifier)
+	req.SetBasicAuth("test-client", "test-secret")
+	w = httptest.NewRecorder()
+	handler.Token(w, req)
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected status 200 with the secret, got %d. Body: %s", w.Code, w.Body.String())
+	}
+}
+
+// TestOAuthHandler_Token_AuthorizationCode_Rejected tests that invalid redemptions return invalid_grant
+func TestOAuthHandler_Token_AuthorizationCode_Rejected(t *testing.T) {
+	tests := []struct {
+		name      string
+		method    string
+		expiresAt time.Time
+		verifier  string
+	}{
+		{"wrong verifier", services.PKCEMethodS256, time.Now().Add(time.Minute), strings.Repeat("a", 43)},
+		{"plain method", "plain", time.Now().Add(time.Minute), testCodeChallenge},
+		{"expired code", services.PKCEMethodS256, time.Now().Add(-time.Minute), testCodeVerifier},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := setupTestDB(t)
+			seedPublicTestClient(t, db)
+			seedAuthorizationCode(t, db, "code-1", tt.method, tt.expiresAt)
+			handler := NewOAuthHandler(db, setupTestTokenService(t))
+
+			w := httptest.NewRecorder()
+			handler.Token(w, authorizationCodeRequest("code-1", tt.verifier))
+
+			if w.Code != http.StatusBadRequest {
+				t.Fatalf("Expected status 400, got %d. Body: %s", w.Code, w.Body.String())
+			}
+			var errResp map[string]string
+			json.Unmarshal(w.Body.Bytes(), &errResp)
+			if errResp["error"] != errInvalidGrant {
+				t.Errorf("Expected error %s, got %s", errInvalidGrant, errResp["error"])
+			}
+		})
+	}
+}
+
+// TestOAuthHandler_Token_AuthorizationCode_Replay tests that a code cannot be redeemed twice
+func TestOAuthHandler_Token_AuthorizationCode_Replay(t *testing.T) {
+	db := setupTestDB(t)
+	seedPublicTestClient(t, db)
+	seedAuthorizationCode(t, db, "code-1", services.PKCEMethodS256, time.Now().Add(time.Minute))
+	handler := NewOAuthHandler(db, setupTestTokenService(t))
+
+	w := httptest.NewRecorder()
+	handler.Token(w, authorizationCodeRequest("code-1", testCodeVerifier))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected first redemption to succeed, got %d", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	handler.Token(w, authorizationCodeRequest("code-1", testCodeVerifier))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected replay to fail with 400, got %d", w.Code)
+	}
+}
+
+// TestOAuthHandler_Token_AuthorizationCode_MemoryStore tests the authorization code flow on the in-memory store backend
+func TestOAuthHandler_Token_AuthorizationCode_MemoryStore(t *testing.T) {
+	ctx := context.Background()
+	memory := store.NewMemoryStore()
+	if err := memory.CreateClients(ctx, []models.OAuth2Client{{ClientID: "test-client", ClientSecret: "unused", Name: "Test Client", IsPublic: true, IsActive: true}}); err != nil {
+		t.Fatalf("Failed to seed client: %v", err)
+	}
+	if err := memory.CreateAuthorizationCode(ctx, &models.AuthorizationCode{
+		Code:                "code-1",
+		ClientID:            "test-client",
+		UserEmail:           "user@example.com",
+		RedirectURI:         "https://app.example.com/callback",
+		Scopes:              "read",
+		CodeChallenge:       testCodeChallenge,
+		CodeChallengeMethod: services.PKCEMethodS256,
+		ExpiresAt:           time.Now().Add(time.Minute),
+	}); err != nil {
+		t.Fatalf("Failed to seed authorization code: %v", err)
+	}
+	handler := NewOAuthHandlerWithStores(memory, memory, setupTestTokenService(t))
+
+	w := httptest.NewRecorder()
+	handler.Token(w, authorizationCodeRequest("code-1", testCodeVerifier))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d. Body: %s", w.Code, w.Body.String())
+	}
+	var resp TokenResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("Failed to parse response: %v", err)
+	}
+	if claims := parseTestToken(t, resp.AccessToken); claims.Subject != "user@example.com" {
+		t.Errorf("Expected subject user@example.com, got %s", claims.Subject)
+	}
+
+	w = httptest.NewRecorder()
+	handler.Token(w, authorizationCodeRequest("code-1", testCodeVerifier))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected replay to fail with 400, got %d", w.Code)
+	}
+}
@@ -0,0 +1,190 @@
+// Copyright (c) 2025 WSO2 LLC. (https://www.wso2.com).
+//
+// WSO2 LLC. licenses this file to you under the Apache License,
+// Version 2.0 (the "License"); you may not use this file except
+// in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+
+	"github.com/opensuperapp/opensuperapp/backend-services/token-service/internal/models"
+	"github.com/opensuperapp/opensuperapp/backend-services/token-service/internal/store"
+)
+
+// maxImportBodySize bounds the import payload; exports of many clients exceed the default limit
+const maxImportBodySize = 10 << 20 // 10MB
+
+// ClientConfig is the non-secret configuration of an OAuth2 client, used for export and import.
+// It deliberately has no secret field so secrets can never be exported.
+type ClientConfig struct {
+	ClientID      string  `json:"client_id"`
+	Name          string  `json:"name"`
+	Scopes        string  `json:"scopes"`
+	AllowedScopes *string `json:"allowed_scopes,omitempty"`
+	ExpirySeconds *int    `json:"expiry_seconds,omitempty"`
+	IsPublic      bool    `json:"is_public,omitempty"`
+	IsActive      bool    `json:"is_active"`
+}
+
+// ClientExport is the document returned by the export endpoint and accepted by the import endpoint
+type ClientExport struct {
+	Clients []ClientConfig `json:"clients"`
+}
+
+// ImportedClient carries the freshly generated secret of an imported client (only returned once)
+type ImportedClient struct {
+	ClientID     string `json:"client_id"`
+	ClientSecret string `json:"client_secret"`
+}
+
+// ClientImportIssue describes a client that was rejected or skipped during import
+type ClientImportIssue struct {
+	ClientID string `json:"client_id"`
+	Reason   string `json:"reason"`
+}
+
+type ClientImportResponse struct {
+	Created   []ImportedClient    `json:"created"`
+	Conflicts []ClientImportIssue `json:"conflicts"`
+}
+
+type ClientImportErrorResponse struct {
+	Error  string              `json:"error"`
+	Errors []ClientImportIssue `json:"errors"`
+}
+
+// ExportClients returns the non-secret configuration of every OAuth2 client
+func (h *OAuthHandler) ExportClients(w http.ResponseWriter, r *http.Request) {
+	clients, err := h.clients.ListClients(r.Context())
+	if err != nil {
+		slog.Error("Failed to fetch OAuth2 clients", "error", err)
+		writeError(w, http.StatusInternalServerError, errServerError, "failed to fetch clients")
+		return
+	}
+
+	export := ClientExport{Clients: make([]ClientConfig, len(clients))}
+	for i, client := range clients {
+		export.Clients[i] = ClientConfig{
+			ClientID:      client.ClientID,
+			Name:          client.Name,
+			Scopes:        client.Scopes,
+			AllowedScopes: client.AllowedScopes,
+			ExpirySeconds: client.ExpirySeconds,
+			IsPublic:      client.IsPublic,
+			IsActive:      client.IsActive,
+		}
+	}
+
+	slog.Info("OAuth2 clients exported", "count", len(clients))
+	writeJSON(w, http.StatusOK, export)
+}
+
+// ImportClients recreates clients from an export document with freshly generated secrets.
+// The whole payload is validated first and nothing is created if any entry is invalid.
+// Clients whose client_id already exists are skipped and reported as conflicts.
+func (h *OAuthHandler) ImportClients(w http.ResponseWriter, r *http.Request) {
+	limitRequestBody(w, r, maxImportBodySize)
+
+	var req ClientExport
+	if !decodeJSONBody(w, r, &req) {
+		return
+	}
+	if len(req.Clients) == 0 {
+		writeError(w, http.StatusBadRequest, errInvalidRequest, "clients is required")
+		return
+	}
+
+	var invalid []ClientImportIssue
+	seen := make(map[string]struct{}, len(req.Clients))
+	for i, client := range req.Clients {
+		if msg := validateClientConfig(client.ClientID, client.Name, client.ExpirySeconds); msg != "" {
+			invalid = append(invalid, ClientImportIssue{ClientID: client.ClientID, Reason: fmt.Sprintf("clients[%d]: %s", i, msg)})
+			continue
+		}
+		if msg := h.validateClientScopes(client.Scopes, client.AllowedScopes); msg != "" {
+			invalid = append(invalid, ClientImportIssue{ClientID: client.ClientID, Reason: fmt.Sprintf("clients[%d]: %s", i, msg)})
+			continue
+		}
+		if _, ok := seen[client.ClientID]; ok {
+			invalid = append(invalid, ClientImportIssue{ClientID: client.ClientID, Reason: fmt.Sprintf("clients[%d]: duplicate client_id in import", i)})
+			continue
+		}
+		seen[client.ClientID] = struct{}{}
+	}
+	if len(invalid) > 0 {
+		writeJSON(w, http.StatusBadRequest, ClientImportErrorResponse{Error: errInvalidRequest, Errors: invalid})
+		return
+	}
+
+	clientIDs := make([]string, len(req.Clients))
+	for i, client := range req.Clients {
+		clientIDs[i] = client.ClientID
+	}
+	// Deleted clients still hold their client_id, so they count as conflicts too
+	existing, err := h.clients.ExistingClientIDs(r.Context(), clientIDs)
+	if err != nil {
+		slog.Error("Failed to check for existing OAuth2 clients", "error", err)
+		writeError(w, http.StatusInternalServerError, errServerError, "failed to import clients")
+		return
+	}
+
+	resp := ClientImportResponse{Created: []ImportedClient{}, Conflicts: []ClientImportIssue{}}
+	var newClients []models.OAuth2Client
+	for _, client := range req.Clients {
+		if existing[client.ClientID] {
+			resp.Conflicts = append(resp.Conflicts, ClientImportIssue{ClientID: client.ClientID, Reason: "client_id already exists"})
+			continue
+		}
+		clientSecret, err := generateSecureSecret(32)
+		if err != nil {
+			slog.Error("Failed to generate client secret", "error", err)
+			writeError(w, http.StatusInternalServerError, errServerError, "failed to import clients")
+			return
+		}
+		hashedSecret, err := hashSecret(clientSecret)
+		if err != nil {
+			slog.Error("Failed to hash client secret", "error", err)
+			writeError(w, http.StatusInternalServerError, errServerError, "failed to import clients")
+			return
+		}
+		newClients = append(newClients, models.OAuth2Client{
+			ClientID:      client.ClientID,
+			ClientSecret:  hashedSecret,
+			Name:          client.Name,
+			Scopes:        client.Scopes,
+			AllowedScopes: client.AllowedScopes,
+			ExpirySeconds: client.ExpirySeconds,
+			IsPublic:      client.IsPublic,
+			IsActive:      client.IsActive,
+		})
+		resp.Created = append(resp.Created, ImportedClient{ClientID: client.ClientID, ClientSecret: clientSecret})
+	}
+
+	// The clients are created together, so a client_id taken in the meantime fails the whole import
+	if err := h.clients.CreateClients(r.Context(), newClients); err != nil {
+		if errors.Is(err, store.ErrClientExists) {
+			writeError(w, http.StatusConflict, errInvalidRequest, "client_id already exists")
+			return
+		}
+		slog.Error("Failed to import OAuth2 clients", "error", err)
+		writeError(w, http.StatusInternalServerError, errServerError, "failed to import clients")
+		return
+	}
+
+	slog.Info("OAuth2 clients imported", "created", len(resp.Created), "conflicts", len(resp.Conflicts))
+	writeJSON(w, http.StatusOK, resp)
+}
@@ -0,0 +1,174 @@
+// Copyright (c) 2025 WSO2 LLC. (https://www.wso2.com).
+//
+// WSO2 LLC. licenses this file to you under the Apache License,
+// Version 2.0 (the "License"); you may not use this file except
+// in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/opensuperapp/opensuperapp/backend-services/token-service/internal/models"
+)
+
+// importClients posts an export document to the import endpoint
+func importClients(t *testing.T, handler *OAuthHandler, body []byte) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/admin/clients/import", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	handler.ImportClients(w, req)
+	return w
+}
+
+// TestOAuthHandler_ExportImportClients_RoundTrip tests exporting clients and importing them into another environment
+func TestOAuthHandler_ExportImportClients_RoundTrip(t *testing.T) {
+	sourceDB := setupTestDB(t)
+	client := seedTestClient(t, sourceDB)
+	allowedScopes := "read,write"
+	expirySeconds := 600
+	if err := sourceDB.Model(client).Updates(map[string]interface{}{"allowed_scopes": allowedScopes, "expiry_seconds": expirySeconds}).Error; err != nil {
+		t.Fatalf("Failed to update test client: %v", err)
+	}
+	inactive := &models.OAuth2Client{ClientID: "inactive-client", ClientSecret: "hash", Name: "Inactive", Scopes: "read", IsActive: true}
+	if err := sourceDB.Create(inactive).Error; err != nil {
+		t.Fatalf("Failed to seed inactive client: %v", err)
+	}
+	sourceDB.Model(inactive).Update("is_active", false)
+
+	tokenService := setupTestTokenService(t)
+	source := NewOAuthHandler(sourceDB, tokenService)
+
+	w := httptest.NewRecorder()
+	source.ExportClients(w, httptest.NewRequest(http.MethodGet, "/admin/clients/export", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", w.Code)
+	}
+	exported := w.Body.Bytes()
+
+	if strings.Contains(string(exported), "client_secret") || strings.Contains(string(exported), client.ClientSecret) {
+		t.Fatalf("Export must not contain secrets: %s", exported)
+	}
+
+	var export ClientExport
+	if err := json.Unmarshal(exported, &export); err != nil {
+		t.Fatalf("Failed to parse export: %v", err)
+	}
+	if len(export.Clients) != 2 {
+		t.Fatalf("Expected 2 exported clients, got %d", len(export.Clients))
+	}
+
+	targetDB := setupTestDB(t)
+	target := NewOAuthHandler(targetDB, tokenService)
+
+	w = importClients(t, target, exported)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d. Body: %s", w.Code, w.Body.String())
+	}
+	var resp ClientImportResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("Failed to parse import response: %v", err)
+	}
+	if len(resp.Created) != 2 || len(resp.Conflicts) != 0 {
+		t.Fatalf("Expected 2 created and 0 conflicts, got %+v", resp)
+	}
+
+	// The target export must match the source export
+	w = httptest.NewRecorder()
+	target.ExportClients(w, httptest.NewRequest(http.MethodGet, "/admin/clients/export", nil))
+	var reexport ClientExport
+	if err := json.Unmarshal(w.Body.Bytes(), &reexport); err != nil {
+		t.Fatalf("Failed to parse re-export: %v", err)
+	}
+	if !reflect.DeepEqual(export, reexport) {
+		t.Errorf("Expected re-export %+v to equal export %+v", reexport, export)
+	}
+
+	// The fresh secrets must authenticate the imported clients
+	for _, created := range resp.Created {
+		var stored models.OAuth2Client
+		if err := targetDB.Where("client_id = ?", created.ClientID).First(&stored).Error; err != nil {
+			t.Fatalf("Imported client %s not found: %v", created.ClientID, err)
+		}
+		if err := checkSecret(created.ClientSecret, stored.ClientSecret); err != nil {
+			t.Errorf("Returned secret for %s does not match stored hash", created.ClientID)
+		}
+	}
+}
+
+// TestOAuthHandler_ImportClients_Conflict tests that existing client IDs are reported and not overwritten
+func TestOAuthHandler_ImportClients_Conflict(t *testing.T) {
+	db := setupTestDB(t)
+	existing := seedTestClient(t, db)
+	handler := NewOAuthHandler(db, setupTestTokenService(t))
+
+	body, _ := json.Marshal(ClientExport{Clients: []ClientConfig{
+		{ClientID: "test-client", Name: "Replacement", Scopes: "admin", IsActive: true},
+		{ClientID: "new-client", Name: "New Client", Scopes: "read", IsActive: true},
+	}})
+
+	w := importClients(t, handler, body)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d. Body: %s", w.Code, w.Body.String())
+	}
+	var resp ClientImportResponse
+	json.Unmarshal(w.Body.Bytes(), &resp)
+
+	if len(resp.Created) != 1 || resp.Created[0].ClientID != "new-client" {
+		t.Errorf("Expected only new-client to be created, got %+v", resp.Created)
+	}
+	if len(resp.Conflicts) != 1 || resp.Conflicts[0].ClientID != "test-client" {
+		t.Errorf("Expected a conflict for test-client, got %+v", resp.Conflicts)
+	}
+
+	var stored models.OAuth2Client
+	db.Where("client_id = ?", "test-client").First(&stored)
+	if stored.Name != existing.Name || stored.ClientSecret != existing.ClientSecret {
+		t.Error("Existing client must not be modified by import")
+	}
+}
+
+// TestOAuthHandler_ImportClients_Invalid tests that an invalid entry rejects the whole import
+func TestOAuthHandler_ImportClients_Invalid(t *testing.T) {
+	db := setupTestDB(t)
+	handler := NewOAuthHandler(db, setupTestTokenService(t))
+
+	tooShort := 10
+	body, _ := json.Marshal(ClientExport{Clients: []ClientConfig{
+		{ClientID: "valid-client", Name: "Valid", IsActive: true},
+		{ClientID: "", Name: "Missing ID"},
+		{ClientID: "short-expiry", Name: "Short", ExpirySeconds: &tooShort},
+		{ClientID: "valid-client", Name: "Duplicate"},
+	}})
+
+	w := importClients(t, handler, body)
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("Expected status 400, got %d", w.Code)
+	}
+	var resp ClientImportErrorResponse
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	if len(resp.Errors) != 3 {
+		t.Errorf("Expected 3 validation errors, got %+v", resp.Errors)
+	}
+
+	var count int64
+	db.Model(&models.OAuth2Client{}).Count(&count)
+	if count != 0 {
+		t.Errorf("Expected no clients to be created, got %d", count)
+	}
+}
@@ -0,0 +1,67 @@
+// Copyright (c) 2025 WSO2 LLC. (https://www.wso2.com).
+//
+// WSO2 LLC. licenses this file to you under the Apache License,
+// Version 2.0 (the "License"); you may not use this file except
+// in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+package handler
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/opensuperapp/opensuperapp/backend-services/token-service/internal/services"
+)
+
+// DiscoveryDocument is the authorization server metadata (RFC 8414 / OpenID Connect Discovery)
+type DiscoveryDocument struct {
+	Issuer                            string   `json:"issuer"`
+	JWKSURI                           string   `json:"jwks_uri"`
+	TokenEndpoint                     string   `json:"token_endpoint"`
+	IntrospectionEndpoint             string   `json:"introspection_endpoint,omitempty"`
+	RevocationEndpoint                string   `json:"revocation_endpoint,omitempty"`
+	GrantTypesSupported               []string `json:"grant_types_supported"`
+	ResponseTypesSupported            []string `json:"response_types_supported"`
+	SubjectTypesSupported             []string `json:"subject_types_supported"`
+	IDTokenSigningAlgValuesSupported  []string `json:"id_token_signing_alg_values_supported"`
+	TokenEndpointAuthMethodsSupported []string `json:"token_endpoint_auth_methods_supported"`
+	CodeChallengeMethodsSupported     []string `json:"code_challenge_methods_supported"`
+}
+
+type DiscoveryHandler struct {
+	document DiscoveryDocument
+}
+
+// NewDiscoveryHandler builds the discovery document once, using publicBaseURL as the
+// externally reachable base of every advertised endpoint.
+// Introspection and revocation are not implemented, so they are not advertised.
+func NewDiscoveryHandler(publicBaseURL string) *DiscoveryHandler {
+	baseURL := strings.TrimRight(publicBaseURL, "/")
+	return &DiscoveryHandler{
+		document: DiscoveryDocument{
+			Issuer:                            services.Issuer, // must match the iss claim of issued tokens
+			JWKSURI:                           baseURL + "/.well-known/jwks.json",
+			TokenEndpoint:                     baseURL + "/oauth/token",
+			GrantTypesSupported:               []string{grantTypeClientCredentials, grantTypeAuthorizationCode},
+			ResponseTypesSupported:            []string{"token"},
+			SubjectTypesSupported:             []string{"public"},
+			IDTokenSigningAlgValuesSupported:  []string{"RS256"},
+			TokenEndpointAuthMethodsSupported: []string{"client_secret_basic", "client_secret_post", "none"},
+			CodeChallengeMethodsSupported:     []string{services.PKCEMethodS256},
+		},
+	}
+}
+
+// GetOpenIDConfiguration serves the discovery document
+func (h *DiscoveryHandler) GetOpenIDConfiguration(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, h.document)
+}
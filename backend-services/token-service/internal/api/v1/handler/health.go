@@ -0,0 +1,86 @@
+// Copyright (c) 2025 WSO2 LLC. (https://www.wso2.com).
+//
+// WSO2 LLC. licenses this file to you under the Apache License,
+// Version 2.0 (the "License"); you may not use this file except
+// in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+package handler
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/opensuperapp/opensuperapp/backend-services/token-service/internal/services"
+
+	"gorm.io/gorm"
+)
+
+// readinessTimeout bounds the database check so a hung connection fails the probe instead of stalling it
+const readinessTimeout = 2 * time.Second
+
+const (
+	healthOK    = "ok"
+	healthError = "error"
+)
+
+// HealthStatus is the readiness probe response
+type HealthStatus struct {
+	DB    string `json:"db"`
+	Keys  string `json:"keys,omitempty"`
+	Error string `json:"error,omitempty"`
+}
+
+// HealthHandler serves the liveness and readiness probes
+type HealthHandler struct {
+	db           *gorm.DB
+	tokenService *services.TokenService
+}
+
+func NewHealthHandler(db *gorm.DB, tokenService *services.TokenService) *HealthHandler {
+	return &HealthHandler{
+		db:           db,
+		tokenService: tokenService,
+	}
+}
+
+// Live reports that the process is up. It checks nothing else, so a slow dependency never gets the pod restarted.
+func (h *HealthHandler) Live(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]string{"status": healthOK})
+}
+
+// Ready reports whether the service can issue tokens: the database answers a ping and a signing key is active
+func (h *HealthHandler) Ready(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), readinessTimeout)
+	defer cancel()
+
+	if err := pingDB(ctx, h.db); err != nil {
+		slog.Warn("Readiness check failed: database unavailable", "error", err)
+		writeJSON(w, http.StatusServiceUnavailable, HealthStatus{DB: healthError, Error: err.Error()})
+		return
+	}
+	if h.tokenService.GetActiveKeyID() == "" {
+		slog.Warn("Readiness check failed: no active signing key")
+		writeJSON(w, http.StatusServiceUnavailable, HealthStatus{DB: healthOK, Keys: healthError, Error: "no active signing key"})
+		return
+	}
+	writeJSON(w, http.StatusOK, HealthStatus{DB: healthOK, Keys: healthOK})
+}
+
+func pingDB(ctx context.Context, db *gorm.DB) error {
+	sqlDB, err := db.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.PingContext(ctx)
+}
@@ -0,0 +1,91 @@
+// Copyright (c) 2025 WSO2 LLC. (https://www.wso2.com).
+//
+// WSO2 LLC. licenses this file to you under the Apache License,
+// Version 2.0 (the "License"); you may not use this file except
+// in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/opensuperapp/opensuperapp/backend-services/token-service/internal/services"
+)
+
+// TestHealthHandler_Live tests that the liveness probe always succeeds
+func TestHealthHandler_Live(t *testing.T) {
+	handler := NewHealthHandler(nil, nil)
+
+	w := httptest.NewRecorder()
+	handler.Live(w, httptest.NewRequest(http.MethodGet, "/health/live", nil))
+
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected status 200, got %d", w.Code)
+	}
+}
+
+// TestHealthHandler_Ready tests the readiness probe against a working database, a closed one and a missing key
+func TestHealthHandler_Ready(t *testing.T) {
+	brokenDB := setupTestDB(t)
+	sqlDB, err := brokenDB.DB()
+	if err != nil {
+		t.Fatalf("Failed to get sql.DB: %v", err)
+	}
+	sqlDB.Close()
+
+	tests := []struct {
+		name         string
+		handler      *HealthHandler
+		expectedCode int
+		expected     HealthStatus
+	}{
+		{
+			name:         "ready",
+			handler:      NewHealthHandler(setupTestDB(t), setupTestTokenService(t)),
+			expectedCode: http.StatusOK,
+			expected:     HealthStatus{DB: "ok", Keys: "ok"},
+		},
+		{
+			name:         "broken database",
+			handler:      NewHealthHandler(brokenDB, setupTestTokenService(t)),
+			expectedCode: http.StatusServiceUnavailable,
+			expected:     HealthStatus{DB: "error", Error: "sql: database is closed"},
+		},
+		{
+			name:         "no active key",
+			handler:      NewHealthHandler(setupTestDB(t), &services.TokenService{}),
+			expectedCode: http.StatusServiceUnavailable,
+			expected:     HealthStatus{DB: "ok", Keys: "error", Error: "no active signing key"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			tt.handler.Ready(w, httptest.NewRequest(http.MethodGet, "/health/ready", nil))
+
+			if w.Code != tt.expectedCode {
+				t.Errorf("Expected status %d, got %d. Body: %s", tt.expectedCode, w.Code, w.Body.String())
+			}
+			var status HealthStatus
+			if err := json.Unmarshal(w.Body.Bytes(), &status); err != nil {
+				t.Fatalf("Failed to parse response: %v", err)
+			}
+			if status != tt.expected {
+				t.Errorf("Expected %+v, got %+v", tt.expected, status)
+			}
+		})
+	}
+}
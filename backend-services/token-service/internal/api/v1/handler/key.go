@@ -40,9 +40,27 @@ func (h *KeyHandler) GetJWKS(w http.ResponseWriter, r *http.Request) {
 	}
 
 	w.Header().Set("Content-Type", "application/json")
+	// Tag the response so a key reload can purge it from the CDN
+	w.Header().Set("Surrogate-Key", services.JWKSSurrogateKey)
+	w.Header().Set("Cache-Tag", services.JWKSSurrogateKey)
 	w.Write(jwksBytes)
 }
 
+// GetJWKSSignature serves the detached JWS signature of the JWKS, when JWKS signing is configured
+func (h *KeyHandler) GetJWKSSignature(w http.ResponseWriter, r *http.Request) {
+	signature, err := h.tokenService.GetJWKSSignature()
+	if err != nil {
+		http.Error(w, "JWKS signature not available", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/jose")
+	// Purged together with the JWKS so a cached signature never outlives the key set it covers
+	w.Header().Set("Surrogate-Key", services.JWKSSurrogateKey)
+	w.Header().Set("Cache-Tag", services.JWKSSurrogateKey)
+	w.Write([]byte(signature))
+}
+
 // ReloadKeys triggers a reload of the keys from the directory
 func (h *KeyHandler) ReloadKeys(w http.ResponseWriter, r *http.Request) {
 	if err := h.tokenService.ReloadKeys(); err != nil {
@@ -19,7 +19,12 @@ import (
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"os"
 	"testing"
+
+	"github.com/opensuperapp/opensuperapp/backend-services/token-service/internal/services"
+
+	"github.com/golang-jwt/jwt/v4"
 )
 
 // TestKeyHandler_GetJWKS tests JWKS endpoint
@@ -41,6 +46,13 @@ func TestKeyHandler_GetJWKS(t *testing.T) {
 		t.Errorf("Expected Content-Type application/json, got %s", contentType)
 	}
 
+	// The surrogate key lets a key reload purge the JWKS from the CDN
+	for _, header := range []string{"Surrogate-Key", "Cache-Tag"} {
+		if got := w.Header().Get(header); got != "jwks" {
+			t.Errorf("Expected %s jwks, got %q", header, got)
+		}
+	}
+
 	// Parse JWKS
 	var jwks map[string]interface{}
 	err := json.Unmarshal(w.Body.Bytes(), &jwks)
@@ -73,3 +85,47 @@ func TestKeyHandler_GetJWKS(t *testing.T) {
 		}
 	}
 }
+
+// TestKeyHandler_GetJWKSSignature tests the detached JWKS signature endpoint
+func TestKeyHandler_GetJWKSSignature(t *testing.T) {
+	tokenService := setupTestTokenService(t)
+	handler := NewKeyHandler(tokenService)
+
+	// Not configured: the endpoint is unavailable
+	w := httptest.NewRecorder()
+	handler.GetJWKSSignature(w, httptest.NewRequest(http.MethodGet, "/.well-known/jwks.json.sig", nil))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404 without a signer, got %d", w.Code)
+	}
+
+	signer, err := services.NewJWKSSigner("../../../services/testdata/test-key-2_private.pem", "jwks-root")
+	if err != nil {
+		t.Fatalf("Failed to create JWKS signer: %v", err)
+	}
+	tokenService.SetJWKSSigner(signer)
+
+	w = httptest.NewRecorder()
+	handler.GetJWKSSignature(w, httptest.NewRequest(http.MethodGet, "/.well-known/jwks.json.sig", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", w.Code)
+	}
+	if got := w.Header().Get("Content-Type"); got != "application/jose" {
+		t.Errorf("Expected Content-Type application/jose, got %s", got)
+	}
+	if got := w.Header().Get("Surrogate-Key"); got != services.JWKSSurrogateKey {
+		t.Errorf("Expected Surrogate-Key %s, got %q", services.JWKSSurrogateKey, got)
+	}
+
+	jwks, _ := tokenService.GetJWKS()
+	pubBytes, err := os.ReadFile("../../../services/testdata/test-key-2_public.pem")
+	if err != nil {
+		t.Fatalf("Failed to read public key: %v", err)
+	}
+	rootKey, err := jwt.ParseRSAPublicKeyFromPEM(pubBytes)
+	if err != nil {
+		t.Fatalf("Failed to parse public key: %v", err)
+	}
+	if err := services.VerifyJWKSSignature(jwks, w.Body.String(), rootKey); err != nil {
+		t.Errorf("Expected served signature to verify: %v", err)
+	}
+}
@@ -16,13 +16,15 @@
 package handler
 
 import (
-	"encoding/json"
+	"errors"
+	"fmt"
 	"log/slog"
 	"net/http"
 	"strings"
 
 	"github.com/opensuperapp/opensuperapp/backend-services/token-service/internal/models"
 	"github.com/opensuperapp/opensuperapp/backend-services/token-service/internal/services"
+	"github.com/opensuperapp/opensuperapp/backend-services/token-service/internal/store"
 
 	"gorm.io/gorm"
 )
@@ -30,51 +32,90 @@ import (
 const (
 	grantTypeClientCredentials = "client_credentials"
 	grantTypeUserContext       = "user_context"
+	grantTypeAuthorizationCode = "authorization_code"
 	tokenTypeBearer            = "Bearer"
 
 	// OAuth2 error codes (RFC 6749)
 	errInvalidRequest   = "invalid_request"
 	errInvalidClient    = "invalid_client"
+	errInvalidGrant     = "invalid_grant"
+	errInvalidScope     = "invalid_scope"
 	errUnsupportedGrant = "unsupported_grant_type"
 	errServerError      = "server_error"
+
+	// Bounds for per-client token expiry overrides
+	minClientExpirySeconds = 60
+	maxClientExpirySeconds = 86400
 )
 
 type OAuthHandler struct {
-	db           *gorm.DB
+	clients      store.ClientStore
+	codes        store.AuthorizationCodeStore
 	tokenService *services.TokenService
+	pkce         *services.PKCEVerifier
+	strictScopes bool // Legacy flat scopes are rejected; otherwise they are accepted alongside resource:action scopes
 }
 
+// NewOAuthHandler creates a handler that keeps clients and authorization codes in db
 func NewOAuthHandler(db *gorm.DB, tokenService *services.TokenService) *OAuthHandler {
+	gormStore := store.NewGormStore(db)
+	return NewOAuthHandlerWithStores(gormStore, gormStore, tokenService)
+}
+
+// NewOAuthHandlerWithStores creates a handler on the given storage backends
+func NewOAuthHandlerWithStores(clients store.ClientStore, codes store.AuthorizationCodeStore, tokenService *services.TokenService) *OAuthHandler {
 	return &OAuthHandler{
-		db:           db,
+		clients:      clients,
+		codes:        codes,
 		tokenService: tokenService,
+		pkce:         services.NewPKCEVerifier(),
 	}
 }
 
+// WithStrictScopes makes the handler reject legacy flat scopes
+func (h *OAuthHandler) WithStrictScopes(strict bool) *OAuthHandler {
+	h.strictScopes = strict
+	return h
+}
+
 type TokenRequest struct {
 	GrantType    string `json:"grant_type"`
 	ClientID     string `json:"client_id"`
 	ClientSecret string `json:"client_secret"`
+	Scope        string `json:"scope,omitempty"`
+
+	// authorization_code grant
+	Code         string `json:"code,omitempty"`
+	CodeVerifier string `json:"code_verifier,omitempty"`
+	RedirectURI  string `json:"redirect_uri,omitempty"`
 }
 
 type TokenResponse struct {
 	AccessToken string `json:"access_token"`
 	TokenType   string `json:"token_type"`
 	ExpiresIn   int    `json:"expires_in"`
+	Scope       string `json:"scope,omitempty"`
 }
 
 type CreateClientRequest struct {
-	ClientID string `json:"client_id"`
-	Name     string `json:"name"`
-	Scopes   string `json:"scopes"` // Comma-separated scopes
+	ClientID           string `json:"client_id"`
+	Name               string `json:"name"`
+	Scopes             string `json:"scopes"`                        // Comma-separated scopes
+	AllowedScopes      string `json:"allowed_scopes,omitempty"`      // Comma-separated scopes the client may request; defaults to scopes
+	UnrestrictedScopes bool   `json:"unrestricted_scopes,omitempty"` // Opt-in: the client may request any scope
+	ExpirySeconds      *int   `json:"expiry_seconds,omitempty"`      // Optional token lifetime override
+	IsPublic           bool   `json:"is_public,omitempty"`           // Opt-in: redeem authorization codes without a secret
 }
 
 type CreateClientResponse struct {
-	ClientID     string `json:"client_id"`
-	ClientSecret string `json:"client_secret"` // Plain text secret (only returned once)
-	Name         string `json:"name"`
-	Scopes       string `json:"scopes"`
-	IsActive     bool   `json:"is_active"`
+	ClientID      string  `json:"client_id"`
+	ClientSecret  string  `json:"client_secret"` // Plain text secret (only returned once)
+	Name          string  `json:"name"`
+	Scopes        string  `json:"scopes"`
+	AllowedScopes *string `json:"allowed_scopes,omitempty"`
+	ExpirySeconds *int    `json:"expiry_seconds,omitempty"`
+	IsPublic      bool    `json:"is_public"`
+	IsActive      bool    `json:"is_active"`
 }
 
 // Token handles the OAuth2 token endpoint
@@ -83,18 +124,22 @@ func (h *OAuthHandler) Token(w http.ResponseWriter, r *http.Request) {
 
 	// Parse request
 	// Support both JSON body and Form data (standard OAuth2 uses form data, but JSON is common in APIs)
-	var clientID, clientSecret, grantType string
+	var clientID, clientSecret, grantType, requestedScope string
+	var code, codeVerifier, redirectURI string
 
 	contentType := r.Header.Get("Content-Type")
 	if strings.Contains(contentType, "application/json") {
 		var req TokenRequest
-		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-			writeError(w, http.StatusBadRequest, errInvalidRequest, "invalid request body")
+		if !decodeJSONBody(w, r, &req) {
 			return
 		}
 		clientID = req.ClientID
 		clientSecret = req.ClientSecret
 		grantType = req.GrantType
+		requestedScope = req.Scope
+		code = req.Code
+		codeVerifier = req.CodeVerifier
+		redirectURI = req.RedirectURI
 	} else {
 		// Fallback to Form/Basic Auth
 		if err := r.ParseForm(); err != nil {
@@ -102,6 +147,10 @@ func (h *OAuthHandler) Token(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 		grantType = r.FormValue("grant_type")
+		requestedScope = r.FormValue("scope")
+		code = r.FormValue("code")
+		codeVerifier = r.FormValue("code_verifier")
+		redirectURI = r.FormValue("redirect_uri")
 
 		// Check Basic Auth first
 		user, pass, ok := r.BasicAuth()
@@ -114,6 +163,17 @@ func (h *OAuthHandler) Token(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	if grantType == grantTypeAuthorizationCode {
+		h.exchangeAuthorizationCode(w, r, authorizationCodeGrant{
+			ClientID:     clientID,
+			ClientSecret: clientSecret,
+			Code:         code,
+			CodeVerifier: codeVerifier,
+			RedirectURI:  redirectURI,
+		})
+		return
+	}
+
 	if grantType != grantTypeClientCredentials {
 		writeError(w, http.StatusBadRequest, errUnsupportedGrant, "")
 		return
@@ -125,8 +185,13 @@ func (h *OAuthHandler) Token(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Validate Client
-	var OAuth2client models.OAuth2Client
-	if err := h.db.Where("client_id = ? AND is_active = ?", clientID, true).First(&OAuth2client).Error; err != nil {
+	OAuth2client, err := h.clients.GetActiveClient(r.Context(), clientID)
+	if err != nil {
+		if !errors.Is(err, store.ErrNotFound) {
+			slog.Error("Failed to look up client", "error", err, "client_id", clientID)
+			writeError(w, http.StatusInternalServerError, errServerError, "")
+			return
+		}
 		slog.Warn("Client not found or inactive", "client_id", clientID)
 		writeError(w, http.StatusUnauthorized, errInvalidClient, "")
 		return
@@ -139,8 +204,32 @@ func (h *OAuthHandler) Token(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Issue Token
-	token, err := h.tokenService.IssueToken(OAuth2client.ClientID, OAuth2client.Scopes)
+	if err := services.ValidateScopes(requestedScope, !h.strictScopes); err != nil {
+		slog.Warn("Malformed scope requested", "client_id", clientID, "error", err)
+		writeError(w, http.StatusBadRequest, errInvalidScope, err.Error())
+		return
+	}
+
+	// Resolve scopes: without a scope parameter the client's configured scopes are issued.
+	// A requested scope must be within allowed_scopes, unless allowed_scopes is NULL (unrestricted).
+	scopes := OAuth2client.Scopes
+	if requestedScope != "" {
+		if OAuth2client.AllowedScopes == nil {
+			scopes = strings.Join(services.ParseScopes(requestedScope), " ")
+		} else {
+			granted, err := services.FilterGrantedScopes(requestedScope, *OAuth2client.AllowedScopes)
+			if err != nil {
+				slog.Warn("Requested scope not allowed", "client_id", clientID, "error", err)
+				writeError(w, http.StatusBadRequest, errInvalidScope, err.Error())
+				return
+			}
+			scopes = granted
+		}
+	}
+
+	// Issue Token (client-specific expiry falls back to the global config)
+	expiry := OAuth2client.TokenExpiry(h.tokenService.GetExpiryDuration())
+	token, err := h.tokenService.IssueTokenWithExpiry(OAuth2client.ClientID, scopes, expiry)
 	if err != nil {
 		slog.Error("Failed to issue token", "error", err)
 		writeError(w, http.StatusInternalServerError, errServerError, "")
@@ -151,7 +240,8 @@ func (h *OAuthHandler) Token(w http.ResponseWriter, r *http.Request) {
 	resp := TokenResponse{
 		AccessToken: token,
 		TokenType:   tokenTypeBearer,
-		ExpiresIn:   h.tokenService.GetExpiry(),
+		ExpiresIn:   int(expiry.Seconds()),
+		Scope:       scopes,
 	}
 	writeJSON(w, http.StatusOK, resp)
 }
@@ -161,24 +251,32 @@ func (h *OAuthHandler) CreateClient(w http.ResponseWriter, r *http.Request) {
 	limitRequestBody(w, r, 0)
 
 	var req CreateClientRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		writeError(w, http.StatusBadRequest, errInvalidRequest, "invalid request body")
+	if !decodeJSONBody(w, r, &req) {
 		return
 	}
 
 	// Validate required fields
-	if req.ClientID == "" {
-		writeError(w, http.StatusBadRequest, errInvalidRequest, "client_id is required")
+	if msg := validateClientConfig(req.ClientID, req.Name, req.ExpirySeconds); msg != "" {
+		writeError(w, http.StatusBadRequest, errInvalidRequest, msg)
 		return
 	}
-	if req.Name == "" {
-		writeError(w, http.StatusBadRequest, errInvalidRequest, "name is required")
+	if req.UnrestrictedScopes && req.AllowedScopes != "" {
+		writeError(w, http.StatusBadRequest, errInvalidRequest, "allowed_scopes cannot be combined with unrestricted_scopes")
+		return
+	}
+	if msg := h.validateClientScopes(req.Scopes, &req.AllowedScopes); msg != "" {
+		writeError(w, http.StatusBadRequest, errInvalidScope, msg)
 		return
 	}
 
 	// Check if client already exists
-	var existingClient models.OAuth2Client
-	if err := h.db.Where("client_id = ?", req.ClientID).First(&existingClient).Error; err == nil {
+	existing, err := h.clients.ExistingClientIDs(r.Context(), []string{req.ClientID})
+	if err != nil {
+		slog.Error("Failed to check for existing client", "error", err, "client_id", req.ClientID)
+		writeError(w, http.StatusInternalServerError, errServerError, "failed to create client")
+		return
+	}
+	if existing[req.ClientID] {
 		writeError(w, http.StatusConflict, errInvalidRequest, "client_id already exists")
 		return
 	}
@@ -199,16 +297,34 @@ func (h *OAuthHandler) CreateClient(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Create the new client
+	// Create the new client. A new client may only request its own scopes unless it
+	// explicitly opts in to unrestricted scopes (NULL allowed_scopes).
+	var allowedScopes *string
+	switch {
+	case req.UnrestrictedScopes:
+		// Leave allowed_scopes NULL
+	case req.AllowedScopes != "":
+		allowedScopes = &req.AllowedScopes
+	default:
+		allowedScopes = &req.Scopes
+	}
 	newClient := models.OAuth2Client{
-		ClientID:     req.ClientID,
-		ClientSecret: hashedSecret,
-		Name:         req.Name,
-		Scopes:       req.Scopes,
-		IsActive:     true,
+		ClientID:      req.ClientID,
+		ClientSecret:  hashedSecret,
+		Name:          req.Name,
+		Scopes:        req.Scopes,
+		AllowedScopes: allowedScopes,
+		ExpirySeconds: req.ExpirySeconds,
+		IsPublic:      req.IsPublic,
+		IsActive:      true,
 	}
 
-	if err := h.db.Create(&newClient).Error; err != nil {
+	created := []models.OAuth2Client{newClient}
+	if err := h.clients.CreateClients(r.Context(), created); err != nil {
+		if errors.Is(err, store.ErrClientExists) {
+			writeError(w, http.StatusConflict, errInvalidRequest, "client_id already exists")
+			return
+		}
 		slog.Error("Failed to create OAuth2 client", "error", err)
 		writeError(w, http.StatusInternalServerError, errServerError, "failed to create client")
 		return
@@ -218,12 +334,44 @@ func (h *OAuthHandler) CreateClient(w http.ResponseWriter, r *http.Request) {
 
 	// Return the response with the plain text secret (only time it's visible)
 	resp := CreateClientResponse{
-		ClientID:     newClient.ClientID,
-		ClientSecret: clientSecret, // Return plain text secret
-		Name:         newClient.Name,
-		Scopes:       newClient.Scopes,
-		IsActive:     newClient.IsActive,
+		ClientID:      newClient.ClientID,
+		ClientSecret:  clientSecret, // Return plain text secret
+		Name:          newClient.Name,
+		Scopes:        newClient.Scopes,
+		AllowedScopes: newClient.AllowedScopes,
+		ExpirySeconds: newClient.ExpirySeconds,
+		IsPublic:      newClient.IsPublic,
+		IsActive:      newClient.IsActive,
 	}
 
 	writeJSON(w, http.StatusCreated, resp)
 }
+
+// validateClientScopes checks the configured and allowed scopes of a client and returns a
+// description of the problem, or "" if they are valid. allowedScopes may be nil.
+func (h *OAuthHandler) validateClientScopes(scopes string, allowedScopes *string) string {
+	if err := services.ValidateScopes(scopes, !h.strictScopes); err != nil {
+		return "scopes: " + err.Error()
+	}
+	if allowedScopes != nil {
+		if err := services.ValidateScopes(*allowedScopes, !h.strictScopes); err != nil {
+			return "allowed_scopes: " + err.Error()
+		}
+	}
+	return ""
+}
+
+// validateClientConfig checks the client fields shared by create and import.
+// It returns an error description, or "" when the configuration is valid.
+func validateClientConfig(clientID, name string, expirySeconds *int) string {
+	if clientID == "" {
+		return "client_id is required"
+	}
+	if name == "" {
+		return "name is required"
+	}
+	if expirySeconds != nil && (*expirySeconds < minClientExpirySeconds || *expirySeconds > maxClientExpirySeconds) {
+		return fmt.Sprintf("expiry_seconds must be between %d and %d", minClientExpirySeconds, maxClientExpirySeconds)
+	}
+	return ""
+}
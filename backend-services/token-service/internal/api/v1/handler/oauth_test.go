@@ -21,12 +21,14 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"net/url"
+	"os"
 	"strings"
 	"testing"
 
 	"github.com/opensuperapp/opensuperapp/backend-services/token-service/internal/models"
 	"github.com/opensuperapp/opensuperapp/backend-services/token-service/internal/services"
 
+	"github.com/golang-jwt/jwt/v4"
 	"golang.org/x/crypto/bcrypt"
 	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
@@ -40,7 +42,7 @@ func setupTestDB(t *testing.T) *gorm.DB {
 	}
 
 	// Auto-migrate models
-	err = db.AutoMigrate(&models.OAuth2Client{})
+	err = db.AutoMigrate(&models.OAuth2Client{}, &models.AuthorizationCode{})
 	if err != nil {
 		t.Fatalf("Failed to migrate database: %v", err)
 	}
@@ -463,3 +465,347 @@ func TestOAuthHandler_CreateClient_MissingName(t *testing.T) {
 		t.Errorf("Expected error description to mention 'name is required', got %s", errResp["error_description"])
 	}
 }
+
+// parseTokenLifetime returns exp - iat (in seconds) for a token issued by the test token service
+func parseTokenLifetime(t *testing.T, tokenString string) int64 {
+	claims := parseTestToken(t, tokenString)
+	return claims.ExpiresAt.Unix() - claims.IssuedAt.Unix()
+}
+
+// parseTestToken verifies a token issued by the test token service and returns its registered claims
+func parseTestToken(t *testing.T, tokenString string) *jwt.RegisteredClaims {
+	keyBytes, err := os.ReadFile("../../../services/testdata/test-key-1_public.pem")
+	if err != nil {
+		t.Fatalf("Failed to read public key: %v", err)
+	}
+	publicKey, err := jwt.ParseRSAPublicKeyFromPEM(keyBytes)
+	if err != nil {
+		t.Fatalf("Failed to parse public key: %v", err)
+	}
+
+	claims := &jwt.RegisteredClaims{}
+	if _, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+		return publicKey, nil
+	}); err != nil {
+		t.Fatalf("Failed to parse token: %v", err)
+	}
+	return claims
+}
+
+// TestOAuthHandler_Token_ClientExpiry tests that a client's expiry override is applied
+func TestOAuthHandler_Token_ClientExpiry(t *testing.T) {
+	db := setupTestDB(t)
+	client := seedTestClient(t, db)
+	expirySeconds := 300
+	if err := db.Model(client).Update("expiry_seconds", expirySeconds).Error; err != nil {
+		t.Fatalf("Failed to set client expiry: %v", err)
+	}
+	tokenService := setupTestTokenService(t)
+
+	handler := NewOAuthHandler(db, tokenService)
+
+	body, _ := json.Marshal(TokenRequest{
+		GrantType:    "client_credentials",
+		ClientID:     "test-client",
+		ClientSecret: "test-secret",
+	})
+	req := httptest.NewRequest(http.MethodPost, "/oauth/token", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+
+	w := httptest.NewRecorder()
+	handler.Token(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d. Body: %s", w.Code, w.Body.String())
+	}
+
+	var resp TokenResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("Failed to parse response: %v", err)
+	}
+
+	if resp.ExpiresIn != expirySeconds {
+		t.Errorf("Expected expires_in %d, got %d", expirySeconds, resp.ExpiresIn)
+	}
+
+	if lifetime := parseTokenLifetime(t, resp.AccessToken); lifetime != int64(expirySeconds) {
+		t.Errorf("Expected exp - iat == %d, got %d", expirySeconds, lifetime)
+	}
+}
+
+// TestOAuthHandler_CreateClient_ExpirySeconds tests creating a client with an expiry override
+func TestOAuthHandler_CreateClient_ExpirySeconds(t *testing.T) {
+	db := setupTestDB(t)
+	tokenService := setupTestTokenService(t)
+
+	handler := NewOAuthHandler(db, tokenService)
+
+	expirySeconds := 300
+	body, _ := json.Marshal(CreateClientRequest{
+		ClientID:      "short-lived-client",
+		Name:          "Short Lived Client",
+		ExpirySeconds: &expirySeconds,
+	})
+	req := httptest.NewRequest(http.MethodPost, "/oauth/clients", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+
+	w := httptest.NewRecorder()
+	handler.CreateClient(w, req)
+
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status 201, got %d. Body: %s", w.Code, w.Body.String())
+	}
+
+	var dbClient models.OAuth2Client
+	if err := db.Where("client_id = ?", "short-lived-client").First(&dbClient).Error; err != nil {
+		t.Fatalf("Failed to find created client in database: %v", err)
+	}
+	if dbClient.ExpirySeconds == nil || *dbClient.ExpirySeconds != expirySeconds {
+		t.Errorf("Expected stored expiry_seconds %d, got %v", expirySeconds, dbClient.ExpirySeconds)
+	}
+}
+
+// TestOAuthHandler_CreateClient_InvalidExpirySeconds tests rejecting out-of-range expiry overrides
+func TestOAuthHandler_CreateClient_InvalidExpirySeconds(t *testing.T) {
+	db := setupTestDB(t)
+	tokenService := setupTestTokenService(t)
+
+	handler := NewOAuthHandler(db, tokenService)
+
+	for _, expirySeconds := range []int{0, 10, 86401} {
+		body, _ := json.Marshal(CreateClientRequest{
+			ClientID:      "bad-expiry-client",
+			Name:          "Bad Expiry Client",
+			ExpirySeconds: &expirySeconds,
+		})
+		req := httptest.NewRequest(http.MethodPost, "/oauth/clients", bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+
+		w := httptest.NewRecorder()
+		handler.CreateClient(w, req)
+
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("Expected status 400 for expiry_seconds %d, got %d", expirySeconds, w.Code)
+		}
+	}
+}
+
+// requestTokenWithScope requests a client credentials token for test-client with the given scope
+func requestTokenWithScope(t *testing.T, handler *OAuthHandler, scope string) *httptest.ResponseRecorder {
+	formData := url.Values{}
+	formData.Set("grant_type", "client_credentials")
+	formData.Set("client_id", "test-client")
+	formData.Set("client_secret", "test-secret")
+	formData.Set("scope", scope)
+
+	req := httptest.NewRequest(http.MethodPost, "/oauth/token", strings.NewReader(formData.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	w := httptest.NewRecorder()
+	handler.Token(w, req)
+	return w
+}
+
+// TestOAuthHandler_Token_AllowedScopes tests scope validation against a client's allowed scopes
+func TestOAuthHandler_Token_AllowedScopes(t *testing.T) {
+	db := setupTestDB(t)
+	client := seedTestClient(t, db)
+	if err := db.Model(client).Update("allowed_scopes", "read,write").Error; err != nil {
+		t.Fatalf("Failed to set allowed scopes: %v", err)
+	}
+	handler := NewOAuthHandler(db, setupTestTokenService(t))
+
+	tests := []struct {
+		name       string
+		scope      string
+		wantStatus int
+		wantScope  string
+	}{
+		{"all allowed", "read write", http.StatusOK, "read write"},
+		{"subset allowed", "write", http.StatusOK, "write"},
+		{"partial grant", "read admin", http.StatusBadRequest, ""},
+		{"full denial", "admin delete", http.StatusBadRequest, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := requestTokenWithScope(t, handler, tt.scope)
+			if w.Code != tt.wantStatus {
+				t.Fatalf("Expected status %d, got %d. Body: %s", tt.wantStatus, w.Code, w.Body.String())
+			}
+
+			if tt.wantStatus != http.StatusOK {
+				var errResp map[string]string
+				if err := json.Unmarshal(w.Body.Bytes(), &errResp); err != nil {
+					t.Fatalf("Failed to parse error response: %v", err)
+				}
+				if errResp["error"] != "invalid_scope" {
+					t.Errorf("Expected error invalid_scope, got %s", errResp["error"])
+				}
+				return
+			}
+
+			var resp TokenResponse
+			if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("Failed to parse response: %v", err)
+			}
+			if resp.Scope != tt.wantScope {
+				t.Errorf("Expected scope %q, got %q", tt.wantScope, resp.Scope)
+			}
+		})
+	}
+}
+
+// TestOAuthHandler_Token_UnrestrictedScopes tests that clients without allowed scopes may request any scope
+func TestOAuthHandler_Token_UnrestrictedScopes(t *testing.T) {
+	db := setupTestDB(t)
+	seedTestClient(t, db)
+	handler := NewOAuthHandler(db, setupTestTokenService(t))
+
+	w := requestTokenWithScope(t, handler, "anything:goes")
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d. Body: %s", w.Code, w.Body.String())
+	}
+
+	var resp TokenResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("Failed to parse response: %v", err)
+	}
+	if resp.Scope != "anything:goes" {
+		t.Errorf("Expected scope 'anything:goes', got %q", resp.Scope)
+	}
+}
+
+// TestOAuthHandler_CreateClient_AllowedScopesDefault tests that new clients may only request their own
+// scopes unless they opt in to unrestricted scopes
+func TestOAuthHandler_CreateClient_AllowedScopesDefault(t *testing.T) {
+	db := setupTestDB(t)
+	handler := NewOAuthHandler(db, setupTestTokenService(t))
+
+	create := func(req CreateClientRequest) *httptest.ResponseRecorder {
+		body, _ := json.Marshal(req)
+		httpReq := httptest.NewRequest(http.MethodPost, "/oauth/clients", bytes.NewReader(body))
+		httpReq.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		handler.CreateClient(w, httpReq)
+		return w
+	}
+
+	if w := create(CreateClientRequest{ClientID: "restricted", Name: "Restricted", Scopes: "read"}); w.Code != http.StatusCreated {
+		t.Fatalf("Expected status 201, got %d. Body: %s", w.Code, w.Body.String())
+	}
+	if w := create(CreateClientRequest{ClientID: "unrestricted", Name: "Unrestricted", Scopes: "read", UnrestrictedScopes: true}); w.Code != http.StatusCreated {
+		t.Fatalf("Expected status 201, got %d. Body: %s", w.Code, w.Body.String())
+	}
+	if w := create(CreateClientRequest{ClientID: "both", Name: "Both", AllowedScopes: "read", UnrestrictedScopes: true}); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for allowed_scopes with unrestricted_scopes, got %d", w.Code)
+	}
+
+	var restricted, unrestricted models.OAuth2Client
+	if err := db.Where("client_id = ?", "restricted").First(&restricted).Error; err != nil {
+		t.Fatalf("Failed to find created client: %v", err)
+	}
+	if restricted.AllowedScopes == nil || *restricted.AllowedScopes != "read" {
+		t.Errorf("Expected allowed_scopes to default to the client's scopes, got %v", restricted.AllowedScopes)
+	}
+	if err := db.Where("client_id = ?", "unrestricted").First(&unrestricted).Error; err != nil {
+		t.Fatalf("Failed to find created client: %v", err)
+	}
+	if unrestricted.AllowedScopes != nil {
+		t.Errorf("Expected NULL allowed_scopes for an unrestricted client, got %q", *unrestricted.AllowedScopes)
+	}
+}
+
+// TestOAuthHandler_Token_MalformedScope tests that malformed scopes are rejected, and legacy flat scopes only in strict mode
+func TestOAuthHandler_Token_MalformedScope(t *testing.T) {
+	db := setupTestDB(t)
+	seedTestClient(t, db)
+	handler := NewOAuthHandler(db, setupTestTokenService(t))
+
+	if w := requestTokenWithScope(t, handler, "notifications:"); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for malformed scope, got %d", w.Code)
+	}
+	if w := requestTokenWithScope(t, handler, "read"); w.Code != http.StatusOK {
+		t.Errorf("Expected legacy scope to be accepted, got %d. Body: %s", w.Code, w.Body.String())
+	}
+
+	handler.WithStrictScopes(true)
+	w := requestTokenWithScope(t, handler, "read")
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("Expected status 400 for legacy scope in strict mode, got %d", w.Code)
+	}
+	var errResp map[string]string
+	if err := json.Unmarshal(w.Body.Bytes(), &errResp); err != nil {
+		t.Fatalf("Failed to parse error response: %v", err)
+	}
+	if errResp["error"] != "invalid_scope" {
+		t.Errorf("Expected error invalid_scope, got %s", errResp["error"])
+	}
+	if w := requestTokenWithScope(t, handler, "notifications:send"); w.Code != http.StatusOK {
+		t.Errorf("Expected structured scope to be accepted, got %d. Body: %s", w.Code, w.Body.String())
+	}
+}
+
+// TestOAuthHandler_CreateClient_MalformedScopes tests that clients cannot be created with malformed scopes
+func TestOAuthHandler_CreateClient_MalformedScopes(t *testing.T) {
+	db := setupTestDB(t)
+	handler := NewOAuthHandler(db, setupTestTokenService(t))
+
+	for _, req := range []CreateClientRequest{
+		{ClientID: "bad-scopes", Name: "Bad Scopes", Scopes: "notifications:send Users:read"},
+		{ClientID: "bad-allowed", Name: "Bad Allowed", AllowedScopes: "notifications::"},
+	} {
+		body, _ := json.Marshal(req)
+		httpReq := httptest.NewRequest(http.MethodPost, "/oauth/clients", bytes.NewReader(body))
+		httpReq.Header.Set("Content-Type", "application/json")
+
+		w := httptest.NewRecorder()
+		handler.CreateClient(w, httpReq)
+
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("Expected status 400 for client %s, got %d. Body: %s", req.ClientID, w.Code, w.Body.String())
+		}
+	}
+}
+
+// TestOAuthHandler_JSONBody tests that JSON endpoints tell an empty or oversized body apart from a malformed one
+func TestOAuthHandler_JSONBody(t *testing.T) {
+	handler := NewOAuthHandler(setupTestDB(t), setupTestTokenService(t))
+	endpoints := map[string]http.HandlerFunc{
+		"/oauth/token":          handler.Token,
+		"/oauth/clients":        handler.CreateClient,
+		"/oauth/clients/import": handler.ImportClients,
+	}
+	tests := []struct {
+		name        string
+		body        string
+		wantStatus  int
+		wantMessage string
+	}{
+		{"empty", "", http.StatusBadRequest, "empty request body"},
+		{"malformed", "{", http.StatusBadRequest, "invalid request body"},
+		{"too large", `{"name":"` + strings.Repeat("a", 11<<20) + `"}`, http.StatusRequestEntityTooLarge, "request body too large"},
+	}
+
+	for path, endpoint := range endpoints {
+		for _, tt := range tests {
+			t.Run(path+" "+tt.name, func(t *testing.T) {
+				req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(tt.body))
+				req.Header.Set("Content-Type", "application/json")
+				w := httptest.NewRecorder()
+				endpoint(w, req)
+
+				if w.Code != tt.wantStatus {
+					t.Fatalf("Expected status %d, got %d. Body: %s", tt.wantStatus, w.Code, w.Body.String())
+				}
+				var errResp map[string]string
+				if err := json.Unmarshal(w.Body.Bytes(), &errResp); err != nil {
+					t.Fatalf("Failed to parse error response: %v", err)
+				}
+				if errResp["error"] != errInvalidRequest || errResp["error_description"] != tt.wantMessage {
+					t.Errorf("Expected %s with %q, got %v", errInvalidRequest, tt.wantMessage, errResp)
+				}
+			})
+		}
+	}
+}
@@ -16,8 +16,13 @@
 package handler
 
 import (
+	"errors"
 	"log/slog"
 	"net/http"
+	"time"
+
+	"github.com/opensuperapp/opensuperapp/backend-services/token-service/internal/services"
+	"github.com/opensuperapp/opensuperapp/backend-services/token-service/internal/store"
 )
 
 // UserTokenRequest represents a request for a user-context token
@@ -28,14 +33,70 @@ type UserTokenRequest struct {
 	Scope      string `json:"scope,omitempty"`
 }
 
+// userTokenParams is a validated user-context token request with its resolved lifetime
+type userTokenParams struct {
+	userEmail  string
+	microappID string
+	scope      string
+	expiry     time.Duration
+}
+
+// UserTokenPreviewResponse holds the claims a user-context token would carry, without the token
+type UserTokenPreviewResponse struct {
+	Claims    services.UserContextClaims `json:"claims"`
+	ExpiresIn int                        `json:"expires_in"`
+}
+
 // GenerateUserToken generates a microapp-scoped token with user context
 // This is called by the core service when exchanging user tokens
 func (h *OAuthHandler) GenerateUserToken(w http.ResponseWriter, r *http.Request) {
+	params, ok := h.parseUserTokenRequest(w, r)
+	if !ok {
+		return
+	}
+
+	token, err := h.tokenService.GenerateUserTokenWithExpiry(params.userEmail, params.microappID, params.scope, params.expiry)
+	if err != nil {
+		slog.Error("Failed to generate user token", "error", err, "microapp", params.microappID)
+		writeError(w, http.StatusInternalServerError, errServerError, "")
+		return
+	}
+
+	slog.Info("User token generated", "microapp", params.microappID)
+
+	resp := TokenResponse{
+		AccessToken: token,
+		TokenType:   tokenTypeBearer,
+		ExpiresIn:   int(params.expiry.Seconds()),
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// PreviewUserToken returns the claims GenerateUserToken would issue for the same request,
+// without signing a token. It is used to debug scope and audience issues.
+func (h *OAuthHandler) PreviewUserToken(w http.ResponseWriter, r *http.Request) {
+	params, ok := h.parseUserTokenRequest(w, r)
+	if !ok {
+		return
+	}
+
+	slog.Info("User token previewed", "microapp", params.microappID)
+
+	resp := UserTokenPreviewResponse{
+		Claims:    h.tokenService.UserTokenClaims(params.userEmail, params.microappID, params.scope, params.expiry),
+		ExpiresIn: int(params.expiry.Seconds()),
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// parseUserTokenRequest validates a user-context token request and resolves the token lifetime.
+// It writes the error response and returns false when the request cannot be served.
+func (h *OAuthHandler) parseUserTokenRequest(w http.ResponseWriter, r *http.Request) (userTokenParams, bool) {
 	limitRequestBody(w, r, 0)
 
 	if err := r.ParseForm(); err != nil {
 		writeError(w, http.StatusBadRequest, errInvalidRequest, "invalid form data")
-		return
+		return userTokenParams{}, false
 	}
 
 	grantType := r.FormValue("grant_type")
@@ -45,27 +106,28 @@ func (h *OAuthHandler) GenerateUserToken(w http.ResponseWriter, r *http.Request)
 
 	if grantType != grantTypeUserContext {
 		writeError(w, http.StatusBadRequest, errUnsupportedGrant, "")
-		return
+		return userTokenParams{}, false
 	}
 
 	if userEmail == "" || microappID == "" {
 		writeError(w, http.StatusBadRequest, errInvalidRequest, "user_email and microapp_id are required")
-		return
+		return userTokenParams{}, false
 	}
 
-	token, err := h.tokenService.GenerateUserToken(userEmail, microappID, scope)
-	if err != nil {
-		slog.Error("Failed to generate user token", "error", err, "microapp", microappID)
-		writeError(w, http.StatusInternalServerError, errServerError, "")
-		return
+	if err := services.ValidateScopes(scope, !h.strictScopes); err != nil {
+		writeError(w, http.StatusBadRequest, errInvalidScope, err.Error())
+		return userTokenParams{}, false
 	}
 
-	slog.Info("User token generated", "microapp", microappID)
-
-	resp := TokenResponse{
-		AccessToken: token,
-		TokenType:   tokenTypeBearer,
-		ExpiresIn:   h.tokenService.GetExpiry(),
+	// The microapp ID is the OAuth client ID, so use the client's expiry override when one exists
+	expiry := h.tokenService.GetExpiryDuration()
+	if client, err := h.clients.GetActiveClient(r.Context(), microappID); err == nil {
+		expiry = client.TokenExpiry(expiry)
+	} else if !errors.Is(err, store.ErrNotFound) {
+		slog.Error("Failed to look up client expiry", "error", err, "microapp", microappID)
+		writeError(w, http.StatusInternalServerError, errServerError, "")
+		return userTokenParams{}, false
 	}
-	writeJSON(w, http.StatusOK, resp)
+
+	return userTokenParams{userEmail: userEmail, microappID: microappID, scope: scope, expiry: expiry}, true
 }
@@ -20,8 +20,15 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"net/url"
+	"os"
+	"reflect"
 	"strings"
 	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/opensuperapp/opensuperapp/backend-services/token-service/internal/clocktest"
+	"github.com/opensuperapp/opensuperapp/backend-services/token-service/internal/services"
 )
 
 // TestOAuthHandler_GenerateUserToken_Success tests successful user token generation
@@ -138,3 +145,120 @@ func TestOAuthHandler_GenerateUserToken_MissingFields(t *testing.T) {
 		})
 	}
 }
+
+// TestOAuthHandler_GenerateUserToken_ClientExpiry tests that the microapp client's expiry override is applied
+func TestOAuthHandler_GenerateUserToken_ClientExpiry(t *testing.T) {
+	db := setupTestDB(t)
+	client := seedTestClient(t, db)
+	if err := db.Model(client).Update("expiry_seconds", 300).Error; err != nil {
+		t.Fatalf("Failed to set client expiry: %v", err)
+	}
+	tokenService := setupTestTokenService(t)
+
+	handler := NewOAuthHandler(db, tokenService)
+
+	form := url.Values{}
+	form.Set("grant_type", "user_context")
+	form.Set("user_email", "test@example.com")
+	form.Set("microapp_id", client.ClientID)
+
+	req := httptest.NewRequest(http.MethodPost, "/oauth/token/user", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	w := httptest.NewRecorder()
+	handler.GenerateUserToken(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d. Body: %s", w.Code, w.Body.String())
+	}
+
+	var resp TokenResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("Failed to parse response: %v", err)
+	}
+
+	if resp.ExpiresIn != 300 {
+		t.Errorf("Expected expires_in 300, got %d", resp.ExpiresIn)
+	}
+
+	if lifetime := parseTokenLifetime(t, resp.AccessToken); lifetime != 300 {
+		t.Errorf("Expected exp - iat == 300, got %d", lifetime)
+	}
+}
+
+// postUserTokenForm sends a user-context token form to the given handler
+func postUserTokenForm(handlerFunc http.HandlerFunc, path string, form url.Values) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w := httptest.NewRecorder()
+	handlerFunc(w, req)
+	return w
+}
+
+// TestOAuthHandler_PreviewUserToken tests that previewed claims match the claims of an issued token
+func TestOAuthHandler_PreviewUserToken(t *testing.T) {
+	db := setupTestDB(t)
+	client := seedTestClient(t, db)
+	if err := db.Model(client).Update("expiry_seconds", 300).Error; err != nil {
+		t.Fatalf("Failed to set client expiry: %v", err)
+	}
+	tokenService := setupTestTokenService(t)
+	// Freeze time so the preview and the issued token share iat, nbf and exp
+	tokenService.SetClock(clocktest.NewFakeClock(time.Now().Truncate(time.Second)))
+	handler := NewOAuthHandler(db, tokenService)
+
+	form := url.Values{}
+	form.Set("grant_type", "user_context")
+	form.Set("user_email", "test@example.com")
+	form.Set("microapp_id", client.ClientID)
+	form.Set("scope", "notifications:send")
+
+	w := postUserTokenForm(handler.PreviewUserToken, "/oauth/token/user/preview", form)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d. Body: %s", w.Code, w.Body.String())
+	}
+	if strings.Contains(w.Body.String(), "access_token") {
+		t.Errorf("Expected no token in preview, got %s", w.Body.String())
+	}
+	var preview UserTokenPreviewResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &preview); err != nil {
+		t.Fatalf("Failed to parse preview: %v", err)
+	}
+
+	w = postUserTokenForm(handler.GenerateUserToken, "/oauth/token/user", form)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d. Body: %s", w.Code, w.Body.String())
+	}
+	var resp TokenResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("Failed to parse response: %v", err)
+	}
+
+	keyBytes, err := os.ReadFile("../../../services/testdata/test-key-1_public.pem")
+	if err != nil {
+		t.Fatalf("Failed to read public key: %v", err)
+	}
+	publicKey, err := jwt.ParseRSAPublicKeyFromPEM(keyBytes)
+	if err != nil {
+		t.Fatalf("Failed to parse public key: %v", err)
+	}
+	var issued services.UserContextClaims
+	if _, err := jwt.ParseWithClaims(resp.AccessToken, &issued, func(token *jwt.Token) (interface{}, error) {
+		return publicKey, nil
+	}); err != nil {
+		t.Fatalf("Failed to parse token: %v", err)
+	}
+
+	if !reflect.DeepEqual(preview.Claims, issued) {
+		t.Errorf("Expected previewed claims %+v to match issued claims %+v", preview.Claims, issued)
+	}
+	if preview.ExpiresIn != resp.ExpiresIn || preview.ExpiresIn != 300 {
+		t.Errorf("Expected expires_in 300 for both, got preview %d and token %d", preview.ExpiresIn, resp.ExpiresIn)
+	}
+
+	// The preview validates the request like issuance does
+	form.Set("scope", "notifications:")
+	if w := postUserTokenForm(handler.PreviewUserToken, "/oauth/token/user/preview", form); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for a malformed scope, got %d", w.Code)
+	}
+}
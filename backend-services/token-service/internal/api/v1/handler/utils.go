@@ -18,6 +18,8 @@ package handler
 import (
 	"crypto/rand"
 	"encoding/json"
+	"errors"
+	"io"
 	"net/http"
 
 	"golang.org/x/crypto/bcrypt"
@@ -52,6 +54,26 @@ func limitRequestBody(w http.ResponseWriter, r *http.Request, maxBytes int64) {
 	r.Body = http.MaxBytesReader(w, r.Body, maxBytes)
 }
 
+// decodeJSONBody decodes the JSON request body into v. On failure it writes an invalid_request
+// error, distinguishing an empty body and one over the size limit from a malformed one, and
+// returns false.
+func decodeJSONBody(w http.ResponseWriter, r *http.Request, v any) bool {
+	err := json.NewDecoder(r.Body).Decode(v)
+	if err == nil {
+		return true
+	}
+	var maxBytesErr *http.MaxBytesError
+	switch {
+	case errors.Is(err, io.EOF):
+		writeError(w, http.StatusBadRequest, errInvalidRequest, "empty request body")
+	case errors.As(err, &maxBytesErr):
+		writeError(w, http.StatusRequestEntityTooLarge, errInvalidRequest, "request body too large")
+	default:
+		writeError(w, http.StatusBadRequest, errInvalidRequest, "invalid request body")
+	}
+	return false
+}
+
 // hashSecret hashes a plaintext secret using bcrypt with a secure cost factor.
 // The bcrypt algorithm automatically generates a per-secret salt and includes it
 // in the output hash. The returned hash is safe to store in the database.
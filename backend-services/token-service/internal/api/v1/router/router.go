@@ -26,21 +26,35 @@ import (
 	"gorm.io/gorm"
 )
 
-func NewRouter(db *gorm.DB, tokenService *services.TokenService) http.Handler {
+// NewRouter returns the token service routes. With strictScopes, legacy flat scopes are rejected
+// wherever scopes are configured or requested.
+func NewRouter(db *gorm.DB, tokenService *services.TokenService, publicBaseURL string, strictScopes bool) http.Handler {
 	r := chi.NewRouter()
 
+	// Log the X-Request-ID forwarded by the core service so both services' logs can be correlated
+	r.Use(middleware.RequestID)
 	r.Use(middleware.Logger)
 	r.Use(middleware.Recoverer)
 
-	oauthHandler := handler.NewOAuthHandler(db, tokenService)
+	oauthHandler := handler.NewOAuthHandler(db, tokenService).WithStrictScopes(strictScopes)
 	keyHandler := handler.NewKeyHandler(tokenService)
+	discoveryHandler := handler.NewDiscoveryHandler(publicBaseURL)
+	healthHandler := handler.NewHealthHandler(db, tokenService)
+
+	r.Get("/health/live", healthHandler.Live)
+	r.Get("/health/ready", healthHandler.Ready)
 
 	r.Post("/oauth/token", oauthHandler.Token)
 	r.Post("/oauth/token/user", oauthHandler.GenerateUserToken)
+	r.Post("/oauth/token/user/preview", oauthHandler.PreviewUserToken)
 	r.Post("/oauth/clients", oauthHandler.CreateClient)
 	r.Get("/.well-known/jwks.json", keyHandler.GetJWKS)
+	r.Get("/.well-known/jwks.json.sig", keyHandler.GetJWKSSignature)
+	r.Get("/.well-known/openid-configuration", discoveryHandler.GetOpenIDConfiguration)
 	r.Post("/admin/reload-keys", keyHandler.ReloadKeys)
 	r.Post("/admin/active-key", keyHandler.SetActiveKey)
+	r.Get("/admin/clients/export", oauthHandler.ExportClients)
+	r.Post("/admin/clients/import", oauthHandler.ImportClients)
 
 	return r
 
@@ -0,0 +1,91 @@
+// Copyright (c) 2025 WSO2 LLC. (https://www.wso2.com).
+//
+// WSO2 LLC. licenses this file to you under the Apache License,
+// Version 2.0 (the "License"); you may not use this file except
+// in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+package router
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/opensuperapp/opensuperapp/backend-services/token-service/internal/api/v1/handler"
+	"github.com/opensuperapp/opensuperapp/backend-services/token-service/internal/services"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// TestOpenIDConfiguration fetches the discovery document and verifies jwks_uri serves the JWKS
+func TestOpenIDConfiguration(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("Failed to open test database: %v", err)
+	}
+	tokenService, err := services.NewTokenServiceFromDirectory("../../../services/testdata", "test-key-1", 3600)
+	if err != nil {
+		t.Fatalf("Failed to create test token service: %v", err)
+	}
+
+	// The public base URL is only known once the server is listening
+	var r http.Handler
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		r.ServeHTTP(w, req)
+	}))
+	defer server.Close()
+	r = NewRouter(db, tokenService, server.URL+"/", false)
+
+	resp, err := http.Get(server.URL + "/.well-known/openid-configuration")
+	if err != nil {
+		t.Fatalf("Failed to fetch discovery document: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", resp.StatusCode)
+	}
+
+	var doc handler.DiscoveryDocument
+	if err := json.NewDecoder(resp.Body).Decode(&doc); err != nil {
+		t.Fatalf("Failed to parse discovery document: %v", err)
+	}
+
+	if doc.JWKSURI != server.URL+"/.well-known/jwks.json" {
+		t.Errorf("Expected jwks_uri %s, got %s", server.URL+"/.well-known/jwks.json", doc.JWKSURI)
+	}
+	if doc.TokenEndpoint != server.URL+"/oauth/token" {
+		t.Errorf("Expected token_endpoint %s, got %s", server.URL+"/oauth/token", doc.TokenEndpoint)
+	}
+	if doc.Issuer != services.Issuer {
+		t.Errorf("Expected issuer %s, got %s", services.Issuer, doc.Issuer)
+	}
+
+	// jwks_uri must resolve to the JWKS endpoint
+	jwksResp, err := http.Get(doc.JWKSURI)
+	if err != nil {
+		t.Fatalf("Failed to fetch jwks_uri: %v", err)
+	}
+	defer jwksResp.Body.Close()
+
+	var jwks struct {
+		Keys []map[string]interface{} `json:"keys"`
+	}
+	if err := json.NewDecoder(jwksResp.Body).Decode(&jwks); err != nil {
+		t.Fatalf("Failed to parse JWKS: %v", err)
+	}
+	if len(jwks.Keys) == 0 {
+		t.Error("Expected jwks_uri to serve at least one key")
+	}
+}
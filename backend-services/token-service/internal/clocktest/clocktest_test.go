@@ -0,0 +1,67 @@
+// Copyright (c) 2025 WSO2 LLC. (https://www.wso2.com).
+//
+// WSO2 LLC. licenses this file to you under the Apache License,
+// Version 2.0 (the "License"); you may not use this file except
+// in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+package clocktest
+
+import (
+	"testing"
+	"time"
+)
+
+// TestFakeClock_Advance tests that timers fire only once their deadline is reached
+func TestFakeClock_Advance(t *testing.T) {
+	start := time.Date(2025, 1, 15, 10, 0, 0, 0, time.UTC)
+	clock := NewFakeClock(start)
+
+	short := clock.After(time.Second)
+	long := clock.After(time.Minute)
+
+	clock.Advance(500 * time.Millisecond)
+	select {
+	case <-short:
+		t.Fatal("Timer fired before its deadline")
+	default:
+	}
+
+	clock.Advance(500 * time.Millisecond)
+	select {
+	case fired := <-short:
+		if !fired.Equal(start.Add(time.Second)) {
+			t.Errorf("Expected fire time %v, got %v", start.Add(time.Second), fired)
+		}
+	default:
+		t.Fatal("Timer did not fire at its deadline")
+	}
+
+	if clock.Waiters() != 1 {
+		t.Errorf("Expected 1 pending timer, got %d", clock.Waiters())
+	}
+	clock.Advance(time.Minute)
+	select {
+	case <-long:
+	default:
+		t.Fatal("Long timer did not fire")
+	}
+}
+
+// TestFakeClock_ZeroDuration tests that a non-positive duration fires immediately
+func TestFakeClock_ZeroDuration(t *testing.T) {
+	clock := NewFakeClock(time.Now())
+	select {
+	case <-clock.After(0):
+	default:
+		t.Fatal("Expected zero-duration timer to fire immediately")
+	}
+}
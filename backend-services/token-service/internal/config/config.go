@@ -19,6 +19,7 @@ import (
 	"fmt"
 	"log/slog"
 	"os"
+	"strconv"
 
 	"github.com/joho/godotenv"
 )
@@ -29,6 +30,7 @@ const (
 
 type Config struct {
 	Port           string
+	PublicBaseURL  string // Externally reachable base URL, used in the discovery document
 	DBUser         string
 	DBPassword     string
 	DBHost         string
@@ -41,6 +43,13 @@ type Config struct {
 	KeysDir        string // Directory containing multiple key pairs (for zero-downtime rotation)
 	ActiveKeyID    string
 	TokenExpiry    int
+
+	KeyRotationPollIntervalSec int  // How often the keys directory is checked for new keys (directory mode only, 0 disables)
+	StrictScopes               bool // Reject legacy flat scopes; every scope must be resource:action
+	DrainTimeoutSec            int  // How long in-flight requests may run after SIGTERM or SIGINT
+
+	JWKSSigningKeyPath string // Root private key that signs the JWKS (empty disables /.well-known/jwks.json.sig)
+	JWKSSigningKeyID   string // kid of the root key in the JWKS signature header
 }
 
 func Load() *Config {
@@ -62,7 +71,15 @@ func Load() *Config {
 		KeysDir:        getEnv("KEYS_DIR", ""), // Empty means use single-key mode
 		ActiveKeyID:    getEnv("ACTIVE_KEY_ID", "superapp-key-1"),
 		TokenExpiry:    getEnvInt("TOKEN_EXPIRY_SECONDS", 3600),
+
+		KeyRotationPollIntervalSec: getEnvInt("KEY_ROTATION_POLL_INTERVAL_SEC", 30),
+		StrictScopes:               getEnvBool("STRICT_SCOPES", false),
+		DrainTimeoutSec:            getEnvInt("SHUTDOWN_TIMEOUT_SECONDS", 30),
+
+		JWKSSigningKeyPath: getEnv("JWKS_SIGNING_KEY_PATH", ""),
+		JWKSSigningKeyID:   getEnv("JWKS_SIGNING_KEY_ID", "jwks-root"),
 	}
+	cfg.PublicBaseURL = getEnv("PUBLIC_BASE_URL", "http://localhost:"+cfg.Port)
 
 	// Construct DSN
 	// Format: user:password@tcp(host:port)/dbname?charset=utf8mb4&parseTime=True&loc=Local
@@ -79,6 +96,17 @@ func getEnv(key, fallback string) string {
 	return fallback
 }
 
+func getEnvBool(key string, fallback bool) bool {
+	if value := os.Getenv(key); value != "" {
+		boolValue, err := strconv.ParseBool(value)
+		if err == nil {
+			return boolValue
+		}
+		slog.Warn("Invalid boolean value for environment variable, using default", "key", key, "value", value, "default", fallback)
+	}
+	return fallback
+}
+
 func getEnvInt(key string, fallback int) int {
 	if value := os.Getenv(key); value != "" {
 		var intValue int
@@ -0,0 +1,95 @@
+// Copyright (c) 2025 WSO2 LLC. (https://www.wso2.com).
+//
+// WSO2 LLC. licenses this file to you under the Apache License,
+// Version 2.0 (the "License"); you may not use this file except
+// in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+// Package jwk provides helpers for JSON Web Keys.
+package jwk
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+)
+
+// rsaThumbprintMembers holds the required RSA members, declared in lexicographic order
+// so that json.Marshal produces the canonical form required by RFC 7638.
+type rsaThumbprintMembers struct {
+	E   string `json:"e"`
+	Kty string `json:"kty"`
+	N   string `json:"n"`
+}
+
+// ecThumbprintMembers holds the required EC members in lexicographic order
+type ecThumbprintMembers struct {
+	Crv string `json:"crv"`
+	Kty string `json:"kty"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// ComputeJWKThumbprint returns the RFC 7638 JWK thumbprint of an RSA or EC public key:
+// the base64url-encoded (unpadded) SHA-256 hash of the key's required JWK members.
+// Unlike a kid, the thumbprint is derived from the key material, so anyone can recompute it.
+func ComputeJWKThumbprint(key interface{}) (string, error) {
+	var members interface{}
+	switch k := key.(type) {
+	case *rsa.PublicKey:
+		members = rsaThumbprintMembers{
+			E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(k.E)).Bytes()),
+			Kty: "RSA",
+			N:   base64.RawURLEncoding.EncodeToString(k.N.Bytes()),
+		}
+	case *ecdsa.PublicKey:
+		crv, err := curveName(k.Curve)
+		if err != nil {
+			return "", err
+		}
+		// Coordinates are padded to the curve size (RFC 7518 Section 6.2.1.2)
+		size := (k.Curve.Params().BitSize + 7) / 8
+		members = ecThumbprintMembers{
+			Crv: crv,
+			Kty: "EC",
+			X:   base64.RawURLEncoding.EncodeToString(k.X.FillBytes(make([]byte, size))),
+			Y:   base64.RawURLEncoding.EncodeToString(k.Y.FillBytes(make([]byte, size))),
+		}
+	default:
+		return "", fmt.Errorf("unsupported key type %T", key)
+	}
+
+	canonical, err := json.Marshal(members)
+	if err != nil {
+		return "", fmt.Errorf("failed to serialize JWK members: %w", err)
+	}
+	sum := sha256.Sum256(canonical)
+	return base64.RawURLEncoding.EncodeToString(sum[:]), nil
+}
+
+// curveName returns the JWK crv value for a NIST curve
+func curveName(curve elliptic.Curve) (string, error) {
+	switch curve {
+	case elliptic.P256():
+		return "P-256", nil
+	case elliptic.P384():
+		return "P-384", nil
+	case elliptic.P521():
+		return "P-521", nil
+	default:
+		return "", fmt.Errorf("unsupported curve %s", curve.Params().Name)
+	}
+}
@@ -0,0 +1,80 @@
+// Copyright (c) 2025 WSO2 LLC. (https://www.wso2.com).
+//
+// WSO2 LLC. licenses this file to you under the Apache License,
+// Version 2.0 (the "License"); you may not use this file except
+// in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+package jwk
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"math/big"
+	"testing"
+)
+
+// rfc7638Modulus is the RSA modulus of the example key in RFC 7638 Section 3.1
+const rfc7638Modulus = "0vx7agoebGcQSuuPiLJXZptN9nndrQmbXEps2aiAFbWhM78LhWx4cbbfAAtVT86zwu1RK7aPFFxuhDR1L6tSoc_BJECPebWKRXjBZCiFV4n3oknjhMstn64tZ_2W-5JsGY4Hc5n9yBXArwl93lqt7_RN5w6Cf0h4QyQ5v-65YGjQR0_FDW2QvzqY368QQMicAtaSqzs8KJZgnYb9c7d0zgdAZHzu6qMQvRL5hajrn1n91CbOpbISD08qNLyrdkt-bFTWhAI4vMQFh6WeZu0fM4lFd2NcRwr3XPksINHaQ-G_xBniIqbw0Ls1jF44-csFCur-kEgU8awapJzKnqDKgw"
+
+// TestComputeJWKThumbprint_RFC7638Example tests the RSA example from RFC 7638 Section 3.1
+func TestComputeJWKThumbprint_RFC7638Example(t *testing.T) {
+	nBytes, err := base64.RawURLEncoding.DecodeString(rfc7638Modulus)
+	if err != nil {
+		t.Fatalf("Failed to decode modulus: %v", err)
+	}
+	key := &rsa.PublicKey{N: new(big.Int).SetBytes(nBytes), E: 65537}
+
+	thumbprint, err := ComputeJWKThumbprint(key)
+	if err != nil {
+		t.Fatalf("ComputeJWKThumbprint failed: %v", err)
+	}
+
+	want := "NzbLsXh8uDCcd-6MNwXF4W_7noWXFZAfHkxZsRGC9Xs"
+	if thumbprint != want {
+		t.Errorf("Expected thumbprint %s, got %s", want, thumbprint)
+	}
+}
+
+// TestComputeJWKThumbprint_EC tests that EC keys hash the crv, kty, x, y members in order
+func TestComputeJWKThumbprint_EC(t *testing.T) {
+	privateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate EC key: %v", err)
+	}
+	pub := &privateKey.PublicKey
+
+	thumbprint, err := ComputeJWKThumbprint(pub)
+	if err != nil {
+		t.Fatalf("ComputeJWKThumbprint failed: %v", err)
+	}
+
+	x := base64.RawURLEncoding.EncodeToString(pub.X.FillBytes(make([]byte, 32)))
+	y := base64.RawURLEncoding.EncodeToString(pub.Y.FillBytes(make([]byte, 32)))
+	canonical := `{"crv":"P-256","kty":"EC","x":"` + x + `","y":"` + y + `"}`
+	sum := sha256.Sum256([]byte(canonical))
+	want := base64.RawURLEncoding.EncodeToString(sum[:])
+
+	if thumbprint != want {
+		t.Errorf("Expected thumbprint %s, got %s", want, thumbprint)
+	}
+}
+
+// TestComputeJWKThumbprint_Unsupported tests that unsupported key types are rejected
+func TestComputeJWKThumbprint_Unsupported(t *testing.T) {
+	if _, err := ComputeJWKThumbprint("not a key"); err == nil {
+		t.Error("Expected error for unsupported key type")
+	}
+}
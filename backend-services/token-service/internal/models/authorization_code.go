@@ -0,0 +1,34 @@
+// Copyright (c) 2025 WSO2 LLC. (https://www.wso2.com).
+//
+// WSO2 LLC. licenses this file to you under the Apache License,
+// Version 2.0 (the "License"); you may not use this file except
+// in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+package models
+
+import "time"
+
+// AuthorizationCode is a single-use code exchanged at the token endpoint for a user-context token.
+// Every code carries a PKCE challenge so that an intercepted code cannot be redeemed without the verifier.
+type AuthorizationCode struct {
+	ID                  uint       `gorm:"primaryKey" json:"id"`
+	Code                string     `gorm:"type:varchar(255);uniqueIndex;not null" json:"-"`
+	ClientID            string     `gorm:"type:varchar(255);index;not null" json:"client_id"`
+	UserEmail           string     `gorm:"type:varchar(255);not null" json:"user_email"`
+	RedirectURI         string     `gorm:"type:varchar(2048)" json:"redirect_uri"`
+	Scopes              string     `json:"scopes"`
+	CodeChallenge       string     `gorm:"type:varchar(128);not null" json:"-"`
+	CodeChallengeMethod string     `gorm:"type:varchar(10);not null" json:"code_challenge_method"`
+	ExpiresAt           time.Time  `gorm:"not null" json:"expires_at"`
+	UsedAt              *time.Time `json:"used_at,omitempty"` // Set when the code is redeemed; codes are single-use
+	CreatedAt           time.Time  `json:"created_at"`
+}
@@ -24,13 +24,24 @@ import (
 // OAuth2Client represents an OAuth2 client (microapp backend)
 // The ClientID serves as both the OAuth client identifier and the microapp identifier
 type OAuth2Client struct {
-	ID           uint           `gorm:"primaryKey" json:"id"`
-	ClientID     string         `gorm:"type:varchar(255);uniqueIndex;not null" json:"client_id"`
-	ClientSecret string         `gorm:"type:text;not null" json:"-"` // Bcrypt hashed secret (~60 chars)
-	Name         string         `gorm:"not null" json:"name"`
-	Scopes       string         `json:"scopes"` // Comma-separated scopes
-	IsActive     bool           `gorm:"default:true" json:"is_active"`
-	CreatedAt    time.Time      `json:"created_at"`
-	UpdatedAt    time.Time      `json:"updated_at"`
-	DeletedAt    gorm.DeletedAt `gorm:"index" json:"-"`
+	ID            uint           `gorm:"primaryKey" json:"id"`
+	ClientID      string         `gorm:"type:varchar(255);uniqueIndex;not null" json:"client_id"`
+	ClientSecret  string         `gorm:"type:text;not null" json:"-"` // Bcrypt hashed secret (~60 chars)
+	Name          string         `gorm:"not null" json:"name"`
+	Scopes        string         `json:"scopes"`                         // Comma-separated scopes
+	AllowedScopes *string        `json:"allowed_scopes,omitempty"`       // Comma-separated scopes the client may request; NULL is unrestricted
+	ExpirySeconds *int           `json:"expiry_seconds,omitempty"`       // Token lifetime override; NULL uses the global expiry
+	IsPublic      bool           `gorm:"default:false" json:"is_public"` // Public clients redeem authorization codes without a secret
+	IsActive      bool           `gorm:"default:true" json:"is_active"`
+	CreatedAt     time.Time      `json:"created_at"`
+	UpdatedAt     time.Time      `json:"updated_at"`
+	DeletedAt     gorm.DeletedAt `gorm:"index" json:"-"`
+}
+
+// TokenExpiry returns the client's token lifetime override, or fallback when none is set
+func (c *OAuth2Client) TokenExpiry(fallback time.Duration) time.Duration {
+	if c.ExpirySeconds == nil || *c.ExpirySeconds <= 0 {
+		return fallback
+	}
+	return time.Duration(*c.ExpirySeconds) * time.Second
 }
@@ -0,0 +1,31 @@
+// Copyright (c) 2025 WSO2 LLC. (https://www.wso2.com).
+//
+// WSO2 LLC. licenses this file to you under the Apache License,
+// Version 2.0 (the "License"); you may not use this file except
+// in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+package services
+
+import "context"
+
+// JWKSSurrogateKey tags JWKS responses so that a CDN can purge every cached copy at once
+const JWKSSurrogateKey = "jwks"
+
+// CDNPurger evicts cached responses from a CDN by surrogate key (Fastly Surrogate-Key, Cloudflare Cache-Tag)
+type CDNPurger interface {
+	Purge(ctx context.Context, surrogateKeys ...string) error
+}
+
+// NoopPurger is the CDNPurger used when no CDN fronts the JWKS
+type NoopPurger struct{}
+
+func (NoopPurger) Purge(ctx context.Context, surrogateKeys ...string) error { return nil }
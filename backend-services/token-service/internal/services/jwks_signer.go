@@ -0,0 +1,104 @@
+// Copyright (c) 2025 WSO2 LLC. (https://www.wso2.com).
+//
+// WSO2 LLC. licenses this file to you under the Apache License,
+// Version 2.0 (the "License"); you may not use this file except
+// in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+package services
+
+import (
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+// jwksSignatureContentType is the cty header of the detached JWKS signature
+const jwksSignatureContentType = "jwk-set+json"
+
+// jwksSignatureHeader is the protected header of the detached JWKS signature
+type jwksSignatureHeader struct {
+	Alg string `json:"alg"`
+	Kid string `json:"kid,omitempty"`
+	Cty string `json:"cty,omitempty"`
+}
+
+// JWKSSigner signs the published JWKS with a root key that is kept out of the JWKS itself,
+// so consumers can check the key set is authentic before trusting any key in it
+type JWKSSigner struct {
+	keyID      string
+	privateKey *rsa.PrivateKey
+}
+
+// NewJWKSSigner loads the root private key used to sign the JWKS
+func NewJWKSSigner(privateKeyPath, keyID string) (*JWKSSigner, error) {
+	privKeyBytes, err := os.ReadFile(privateKeyPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read JWKS signing key: %w", err)
+	}
+	privateKey, err := jwt.ParseRSAPrivateKeyFromPEM(privKeyBytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse JWKS signing key: %w", err)
+	}
+	return &JWKSSigner{keyID: keyID, privateKey: privateKey}, nil
+}
+
+// Sign returns a detached RS256 JWS (RFC 7515 Appendix F) over the JWKS bytes, in the form
+// "<header>..<signature>". The payload is the exact document served at /.well-known/jwks.json.
+func (s *JWKSSigner) Sign(jwks []byte) (string, error) {
+	header, err := json.Marshal(jwksSignatureHeader{
+		Alg: jwt.SigningMethodRS256.Alg(),
+		Kid: s.keyID,
+		Cty: jwksSignatureContentType,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to encode JWKS signature header: %w", err)
+	}
+
+	encodedHeader := base64.RawURLEncoding.EncodeToString(header)
+	signingInput := encodedHeader + "." + base64.RawURLEncoding.EncodeToString(jwks)
+	signature, err := jwt.SigningMethodRS256.Sign(signingInput, s.privateKey)
+	if err != nil {
+		return "", fmt.Errorf("failed to sign JWKS: %w", err)
+	}
+	return encodedHeader + ".." + signature, nil
+}
+
+// VerifyJWKSSignature checks a detached JWKS signature against the JWKS bytes and the root public key
+func VerifyJWKSSignature(jwks []byte, signature string, publicKey *rsa.PublicKey) error {
+	parts := strings.Split(signature, ".")
+	if len(parts) != 3 || parts[1] != "" {
+		return fmt.Errorf("JWKS signature is not a detached JWS")
+	}
+
+	headerBytes, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return fmt.Errorf("invalid JWKS signature header: %w", err)
+	}
+	var header jwksSignatureHeader
+	if err := json.Unmarshal(headerBytes, &header); err != nil {
+		return fmt.Errorf("invalid JWKS signature header: %w", err)
+	}
+	if header.Alg != jwt.SigningMethodRS256.Alg() {
+		return fmt.Errorf("unexpected JWKS signature algorithm: %s", header.Alg)
+	}
+
+	signingInput := parts[0] + "." + base64.RawURLEncoding.EncodeToString(jwks)
+	if err := jwt.SigningMethodRS256.Verify(signingInput, parts[2], publicKey); err != nil {
+		return fmt.Errorf("JWKS signature verification failed: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,119 @@
+// Copyright (c) 2025 WSO2 LLC. (https://www.wso2.com).
+//
+// WSO2 LLC. licenses this file to you under the Apache License,
+// Version 2.0 (the "License"); you may not use this file except
+// in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+package services
+
+import (
+	"crypto/rsa"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+func loadTestPublicKey(t *testing.T, keyID string) *rsa.PublicKey {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join(testDataDir, keyID+"_public.pem"))
+	if err != nil {
+		t.Fatalf("Failed to read public key: %v", err)
+	}
+	publicKey, err := jwt.ParseRSAPublicKeyFromPEM(data)
+	if err != nil {
+		t.Fatalf("Failed to parse public key: %v", err)
+	}
+	return publicKey
+}
+
+// TestJWKSSigner_SignAndVerify tests that the detached signature verifies only against the signed JWKS and root key
+func TestJWKSSigner_SignAndVerify(t *testing.T) {
+	ts, err := NewTokenServiceFromDirectory(testDataDir, "test-key-1", 3600)
+	if err != nil {
+		t.Fatalf("Failed to create token service: %v", err)
+	}
+	if _, err := ts.GetJWKSSignature(); err == nil {
+		t.Fatal("Expected an error when JWKS signing is not configured")
+	}
+
+	// test-key-2 stands in for the root key
+	signer, err := NewJWKSSigner(filepath.Join(testDataDir, "test-key-2_private.pem"), "jwks-root")
+	if err != nil {
+		t.Fatalf("Failed to create JWKS signer: %v", err)
+	}
+	ts.SetJWKSSigner(signer)
+
+	jwks, err := ts.GetJWKS()
+	if err != nil {
+		t.Fatalf("Failed to get JWKS: %v", err)
+	}
+	signature, err := ts.GetJWKSSignature()
+	if err != nil {
+		t.Fatalf("Failed to sign JWKS: %v", err)
+	}
+
+	parts := strings.Split(signature, ".")
+	if len(parts) != 3 || parts[1] != "" {
+		t.Fatalf("Expected a detached JWS, got %q", signature)
+	}
+
+	rootKey := loadTestPublicKey(t, "test-key-2")
+	if err := VerifyJWKSSignature(jwks, signature, rootKey); err != nil {
+		t.Errorf("Expected signature to verify: %v", err)
+	}
+
+	tampered := []byte(strings.Replace(string(jwks), "test-key-1", "evil-key-1", 1))
+	if err := VerifyJWKSSignature(tampered, signature, rootKey); err == nil {
+		t.Error("Expected tampered JWKS to fail verification")
+	}
+
+	if err := VerifyJWKSSignature(jwks, signature, loadTestPublicKey(t, "test-key-1")); err == nil {
+		t.Error("Expected verification with the wrong root key to fail")
+	}
+}
+
+// TestVerifyJWKSSignature_Malformed tests that signatures that are not detached RS256 JWS are rejected
+func TestVerifyJWKSSignature_Malformed(t *testing.T) {
+	signer, err := NewJWKSSigner(filepath.Join(testDataDir, "test-key-2_private.pem"), "jwks-root")
+	if err != nil {
+		t.Fatalf("Failed to create JWKS signer: %v", err)
+	}
+	jwks := []byte(`{"keys":[]}`)
+	signature, err := signer.Sign(jwks)
+	if err != nil {
+		t.Fatalf("Failed to sign JWKS: %v", err)
+	}
+	parts := strings.Split(signature, ".")
+
+	tests := []struct {
+		name      string
+		signature string
+	}{
+		{"empty", ""},
+		{"attached payload", parts[0] + ".e30." + parts[2]},
+		{"two parts", parts[0] + "." + parts[2]},
+		{"bad header encoding", "!!.." + parts[2]},
+		{"alg none", "eyJhbGciOiJub25lIn0.." + parts[2]},
+	}
+
+	rootKey := loadTestPublicKey(t, "test-key-2")
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := VerifyJWKSSignature(jwks, tt.signature, rootKey); err == nil {
+				t.Errorf("Expected %q to be rejected", tt.signature)
+			}
+		})
+	}
+}
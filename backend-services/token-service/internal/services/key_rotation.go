@@ -0,0 +1,176 @@
+// Copyright (c) 2025 WSO2 LLC. (https://www.wso2.com).
+//
+// WSO2 LLC. licenses this file to you under the Apache License,
+// Version 2.0 (the "License"); you may not use this file except
+// in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+package services
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+	"time"
+)
+
+// StartKeyRotationWatcher polls the keys directory and applies key changes automatically.
+// When key files are added, removed or modified, the keys are reloaded. When key files are
+// added, the newest private key (by modification time) is also promoted to active once its
+// public key is loaded.
+// The returned stop function cancels the watcher and waits for it to exit.
+func (s *TokenService) StartKeyRotationWatcher(interval time.Duration) (stop func()) {
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+
+	if s.keysDir == "" || interval <= 0 {
+		slog.Warn("Key rotation watcher not started", "keys_dir", s.keysDir, "interval", interval)
+		close(done)
+		return cancel
+	}
+
+	snapshot, err := snapshotKeysDir(s.keysDir)
+	if err != nil {
+		slog.Warn("Failed to read keys directory for rotation watcher", "error", err)
+	}
+
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				current, err := snapshotKeysDir(s.keysDir)
+				if err != nil {
+					slog.Warn("Failed to read keys directory for rotation watcher", "error", err)
+					continue
+				}
+				if keysDirUnchanged(snapshot, current) {
+					continue
+				}
+				previous := snapshot
+				snapshot = current
+				s.applyKeyRotation(previous, current)
+			}
+		}
+	}()
+
+	slog.Info("Key rotation watcher started", "keys_dir", s.keysDir, "interval", interval)
+	return func() {
+		cancel()
+		<-done
+	}
+}
+
+// applyKeyRotation reloads keys and, when key files were added, promotes the newest key to
+// active. Modified or removed files only reload the keys, so touching an existing key file
+// cannot replace an active key that was selected manually.
+func (s *TokenService) applyKeyRotation(previous, current map[string]time.Time) {
+	if err := s.ReloadKeys(); err != nil {
+		slog.Error("Key rotation watcher failed to reload keys", "error", err)
+		return
+	}
+	if !keyFilesAdded(previous, current) {
+		return
+	}
+
+	newestKeyID := newestPrivateKeyID(current)
+	activeKeyID := s.GetActiveKeyID()
+	if newestKeyID == "" || newestKeyID == activeKeyID {
+		return
+	}
+
+	// Only promote once the public key is published in the JWKS, otherwise
+	// validators could not verify tokens signed with the new key
+	s.mu.RLock()
+	_, hasPublicKey := s.publicKeys[newestKeyID]
+	s.mu.RUnlock()
+	if !hasPublicKey {
+		slog.Warn("Newest key has no public key yet, not promoting", "key_id", newestKeyID)
+		return
+	}
+
+	// An active key other than the newest one was selected manually (ACTIVE_KEY_ID)
+	if activeKeyID != newestPrivateKeyID(previous) {
+		slog.Warn("Key rotation watcher replacing manually selected active key", "active_key_id", activeKeyID, "key_id", newestKeyID)
+	}
+	if err := s.SetActiveKey(newestKeyID); err != nil {
+		slog.Error("Key rotation watcher failed to promote key", "key_id", newestKeyID, "error", err)
+	}
+}
+
+// keyFilesAdded reports whether current has a key file that previous did not
+func keyFilesAdded(previous, current map[string]time.Time) bool {
+	for name := range current {
+		if _, ok := previous[name]; !ok {
+			return true
+		}
+	}
+	return false
+}
+
+// snapshotKeysDir returns the modification time of every key file in the directory
+func snapshotKeysDir(keysDir string) (map[string]time.Time, error) {
+	entries, err := os.ReadDir(keysDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read keys directory: %w", err)
+	}
+
+	snapshot := make(map[string]time.Time)
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".pem") {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		snapshot[entry.Name()] = info.ModTime()
+	}
+	return snapshot, nil
+}
+
+// keysDirUnchanged reports whether two directory snapshots are identical
+func keysDirUnchanged(previous, current map[string]time.Time) bool {
+	if len(previous) != len(current) {
+		return false
+	}
+	for name, modTime := range current {
+		if prev, ok := previous[name]; !ok || !prev.Equal(modTime) {
+			return false
+		}
+	}
+	return true
+}
+
+// newestPrivateKeyID returns the key ID of the most recently modified private key file
+func newestPrivateKeyID(snapshot map[string]time.Time) string {
+	var newestKeyID string
+	var newestModTime time.Time
+	for name, modTime := range snapshot {
+		if !strings.HasSuffix(name, "_private.pem") {
+			continue
+		}
+		keyID := strings.TrimSuffix(name, "_private.pem")
+		// Break ties on key ID so the result is deterministic
+		if modTime.After(newestModTime) || (modTime.Equal(newestModTime) && keyID > newestKeyID) {
+			newestKeyID = keyID
+			newestModTime = modTime
+		}
+	}
+	return newestKeyID
+}
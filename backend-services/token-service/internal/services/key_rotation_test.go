@@ -0,0 +1,153 @@
+// Copyright (c) 2025 WSO2 LLC. (https://www.wso2.com).
+//
+// WSO2 LLC. licenses this file to you under the Apache License,
+// Version 2.0 (the "License"); you may not use this file except
+// in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+package services
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// copyKeyPair copies a test key pair into dir under a new key ID and sets its modification time
+func copyKeyPair(t *testing.T, srcKeyID, dir, dstKeyID string, modTime time.Time) {
+	t.Helper()
+	for _, suffix := range []string{"_private.pem", "_public.pem"} {
+		data, err := os.ReadFile(filepath.Join(testDataDir, srcKeyID+suffix))
+		if err != nil {
+			t.Fatalf("Failed to read test key: %v", err)
+		}
+		dst := filepath.Join(dir, dstKeyID+suffix)
+		if err := os.WriteFile(dst, data, 0600); err != nil {
+			t.Fatalf("Failed to write test key: %v", err)
+		}
+		if err := os.Chtimes(dst, modTime, modTime); err != nil {
+			t.Fatalf("Failed to set key modification time: %v", err)
+		}
+	}
+}
+
+// TestKeyRotationWatcherPromotesNewKey tests that a new key pair is loaded and promoted to active
+func TestKeyRotationWatcherPromotesNewKey(t *testing.T) {
+	keysDir := t.TempDir()
+	copyKeyPair(t, "test-key-1", keysDir, "key-old", time.Now().Add(-time.Hour))
+
+	ts, err := NewTokenServiceFromDirectory(keysDir, "key-old", 3600)
+	if err != nil {
+		t.Fatalf("Failed to create token service: %v", err)
+	}
+
+	interval := 20 * time.Millisecond
+	stop := ts.StartKeyRotationWatcher(interval)
+	defer stop()
+
+	copyKeyPair(t, "test-key-2", keysDir, "key-new", time.Now())
+
+	deadline := time.Now().Add(50 * interval)
+	for time.Now().Before(deadline) {
+		if ts.GetActiveKeyID() == "key-new" {
+			break
+		}
+		time.Sleep(interval)
+	}
+
+	if ts.GetActiveKeyID() != "key-new" {
+		t.Fatalf("Expected active key key-new, got %s", ts.GetActiveKeyID())
+	}
+
+	// Old key must remain published so previously issued tokens still validate
+	ts.mu.RLock()
+	_, hasOld := ts.publicKeys["key-old"]
+	ts.mu.RUnlock()
+	if !hasOld {
+		t.Error("Expected old public key to remain loaded")
+	}
+}
+
+// TestKeyRotationWatcherStop tests that no changes are applied after the watcher is stopped
+func TestKeyRotationWatcherStop(t *testing.T) {
+	keysDir := t.TempDir()
+	copyKeyPair(t, "test-key-1", keysDir, "key-old", time.Now().Add(-time.Hour))
+
+	ts, err := NewTokenServiceFromDirectory(keysDir, "key-old", 3600)
+	if err != nil {
+		t.Fatalf("Failed to create token service: %v", err)
+	}
+
+	interval := 20 * time.Millisecond
+	stop := ts.StartKeyRotationWatcher(interval)
+	stop()
+
+	copyKeyPair(t, "test-key-2", keysDir, "key-new", time.Now())
+	time.Sleep(5 * interval)
+
+	if ts.GetActiveKeyID() != "key-old" {
+		t.Errorf("Expected active key to remain key-old after stop, got %s", ts.GetActiveKeyID())
+	}
+}
+
+// TestApplyKeyRotation_PromotesOnlyAddedKeys tests that modifying existing key files keeps a manually
+// selected active key, and that adding a key promotes it
+func TestApplyKeyRotation_PromotesOnlyAddedKeys(t *testing.T) {
+	keysDir := t.TempDir()
+	copyKeyPair(t, "test-key-1", keysDir, "key-a", time.Now().Add(-2*time.Hour))
+	copyKeyPair(t, "test-key-2", keysDir, "key-b", time.Now().Add(-time.Hour))
+
+	ts, err := NewTokenServiceFromDirectory(keysDir, "key-a", 3600)
+	if err != nil {
+		t.Fatalf("Failed to create token service: %v", err)
+	}
+	previous, err := snapshotKeysDir(keysDir)
+	if err != nil {
+		t.Fatalf("Failed to snapshot keys directory: %v", err)
+	}
+
+	copyKeyPair(t, "test-key-2", keysDir, "key-b", time.Now())
+	current, err := snapshotKeysDir(keysDir)
+	if err != nil {
+		t.Fatalf("Failed to snapshot keys directory: %v", err)
+	}
+	ts.applyKeyRotation(previous, current)
+	if ts.GetActiveKeyID() != "key-a" {
+		t.Fatalf("Expected the manually selected key-a to stay active after a modification, got %s", ts.GetActiveKeyID())
+	}
+
+	previous = current
+	copyKeyPair(t, "test-key-1", keysDir, "key-c", time.Now().Add(time.Minute))
+	current, err = snapshotKeysDir(keysDir)
+	if err != nil {
+		t.Fatalf("Failed to snapshot keys directory: %v", err)
+	}
+	ts.applyKeyRotation(previous, current)
+	if ts.GetActiveKeyID() != "key-c" {
+		t.Errorf("Expected the added key-c to be promoted, got %s", ts.GetActiveKeyID())
+	}
+}
+
+// TestNewestPrivateKeyID tests selecting the most recently modified private key
+func TestNewestPrivateKeyID(t *testing.T) {
+	now := time.Now()
+	snapshot := map[string]time.Time{
+		"a_private.pem": now.Add(-2 * time.Minute),
+		"a_public.pem":  now.Add(time.Hour),
+		"b_private.pem": now,
+		"c_private.pem": now.Add(-time.Minute),
+	}
+
+	if got := newestPrivateKeyID(snapshot); got != "b" {
+		t.Errorf("Expected newest key b, got %s", got)
+	}
+}
@@ -0,0 +1,81 @@
+// Copyright (c) 2025 WSO2 LLC. (https://www.wso2.com).
+//
+// WSO2 LLC. licenses this file to you under the Apache License,
+// Version 2.0 (the "License"); you may not use this file except
+// in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+package services
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+)
+
+// PKCEMethodS256 is the only supported PKCE code challenge method (RFC 7636).
+// The "plain" method is rejected because it offers no protection if the challenge leaks.
+const PKCEMethodS256 = "S256"
+
+const (
+	minCodeVerifierLength = 43
+	maxCodeVerifierLength = 128
+)
+
+// ErrInvalidCodeVerifier is returned when a code verifier does not meet RFC 7636 requirements
+var ErrInvalidCodeVerifier = errors.New("code_verifier must be 43-128 characters of [A-Za-z0-9-._~]")
+
+// PKCEVerifier derives and verifies PKCE code challenges
+type PKCEVerifier struct{}
+
+// NewPKCEVerifier creates a new PKCEVerifier
+func NewPKCEVerifier() *PKCEVerifier {
+	return &PKCEVerifier{}
+}
+
+// GenerateChallenge returns the S256 challenge for verifier: BASE64URL(SHA256(verifier)) without padding
+func (p *PKCEVerifier) GenerateChallenge(verifier string) (string, error) {
+	if !isValidCodeVerifier(verifier) {
+		return "", ErrInvalidCodeVerifier
+	}
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:]), nil
+}
+
+// VerifyChallenge reports whether verifier matches challenge under method.
+// Only S256 is accepted; any other method, including "plain", fails verification.
+func (p *PKCEVerifier) VerifyChallenge(verifier, challenge, method string) bool {
+	if method != PKCEMethodS256 || challenge == "" {
+		return false
+	}
+	expected, err := p.GenerateChallenge(verifier)
+	if err != nil {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(expected), []byte(challenge)) == 1
+}
+
+// isValidCodeVerifier checks the verifier length and that it only uses unreserved characters
+func isValidCodeVerifier(verifier string) bool {
+	if len(verifier) < minCodeVerifierLength || len(verifier) > maxCodeVerifierLength {
+		return false
+	}
+	for _, c := range verifier {
+		switch {
+		case c >= 'A' && c <= 'Z', c >= 'a' && c <= 'z', c >= '0' && c <= '9':
+		case c == '-', c == '.', c == '_', c == '~':
+		default:
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,115 @@
+// Copyright (c) 2025 WSO2 LLC. (https://www.wso2.com).
+//
+// WSO2 LLC. licenses this file to you under the Apache License,
+// Version 2.0 (the "License"); you may not use this file except
+// in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+package services
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+// RFC 7636 Appendix B example values
+const (
+	rfcCodeVerifier  = "dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk"
+	rfcCodeChallenge = "E9Melhoa2OwvFrEMTJguCHaoeK1t8URWbuGJSstw-cM"
+)
+
+// TestGenerateChallenge_RFCVector tests the challenge against the RFC 7636 example
+func TestGenerateChallenge_RFCVector(t *testing.T) {
+	challenge, err := NewPKCEVerifier().GenerateChallenge(rfcCodeVerifier)
+	if err != nil {
+		t.Fatalf("GenerateChallenge failed: %v", err)
+	}
+	if challenge != rfcCodeChallenge {
+		t.Errorf("Expected challenge %q, got %q", rfcCodeChallenge, challenge)
+	}
+}
+
+// TestGenerateChallenge_NoPadding tests that the challenge is unpadded base64url
+func TestGenerateChallenge_NoPadding(t *testing.T) {
+	challenge, err := NewPKCEVerifier().GenerateChallenge(strings.Repeat("a", 43))
+	if err != nil {
+		t.Fatalf("GenerateChallenge failed: %v", err)
+	}
+	if strings.ContainsAny(challenge, "=+/") {
+		t.Errorf("Expected unpadded base64url challenge, got %q", challenge)
+	}
+	if len(challenge) != 43 {
+		t.Errorf("Expected 43 character challenge, got %d", len(challenge))
+	}
+}
+
+// TestGenerateChallenge_InvalidVerifier tests rejection of verifiers outside RFC 7636 rules
+func TestGenerateChallenge_InvalidVerifier(t *testing.T) {
+	tests := []struct {
+		name     string
+		verifier string
+	}{
+		{"empty", ""},
+		{"too short", strings.Repeat("a", 42)},
+		{"too long", strings.Repeat("a", 129)},
+		{"invalid character", strings.Repeat("a", 42) + "+"},
+		{"space", strings.Repeat("a", 42) + " "},
+	}
+
+	p := NewPKCEVerifier()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := p.GenerateChallenge(tt.verifier); !errors.Is(err, ErrInvalidCodeVerifier) {
+				t.Errorf("Expected ErrInvalidCodeVerifier, got %v", err)
+			}
+		})
+	}
+}
+
+// TestGenerateChallenge_LengthBounds tests that the minimum and maximum verifier lengths are accepted
+func TestGenerateChallenge_LengthBounds(t *testing.T) {
+	p := NewPKCEVerifier()
+	for _, n := range []int{43, 128} {
+		if _, err := p.GenerateChallenge(strings.Repeat("-._~", 32)[:n]); err != nil {
+			t.Errorf("Expected verifier of length %d to be accepted, got %v", n, err)
+		}
+	}
+}
+
+// TestVerifyChallenge tests challenge verification
+func TestVerifyChallenge(t *testing.T) {
+	tests := []struct {
+		name      string
+		verifier  string
+		challenge string
+		method    string
+		want      bool
+	}{
+		{"valid S256", rfcCodeVerifier, rfcCodeChallenge, PKCEMethodS256, true},
+		{"wrong verifier", strings.Repeat("a", 43), rfcCodeChallenge, PKCEMethodS256, false},
+		{"tampered challenge", rfcCodeVerifier, rfcCodeChallenge[:42] + "X", PKCEMethodS256, false},
+		{"plain method rejected", rfcCodeVerifier, rfcCodeVerifier, "plain", false},
+		{"lowercase method rejected", rfcCodeVerifier, rfcCodeChallenge, "s256", false},
+		{"empty method rejected", rfcCodeVerifier, rfcCodeChallenge, "", false},
+		{"empty challenge", rfcCodeVerifier, "", PKCEMethodS256, false},
+		{"invalid verifier", "short", rfcCodeChallenge, PKCEMethodS256, false},
+	}
+
+	p := NewPKCEVerifier()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := p.VerifyChallenge(tt.verifier, tt.challenge, tt.method); got != tt.want {
+				t.Errorf("Expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,157 @@
+// Copyright (c) 2025 WSO2 LLC. (https://www.wso2.com).
+//
+// WSO2 LLC. licenses this file to you under the Apache License,
+// Version 2.0 (the "License"); you may not use this file except
+// in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+package services
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// ErrScopeNotAllowed is returned when a requested scope is not in the client's allowed scopes
+var ErrScopeNotAllowed = errors.New("scope not allowed")
+
+// ErrMalformedScope is returned when a scope is not in resource:action form
+var ErrMalformedScope = errors.New("malformed scope")
+
+// ScopeWildcard is the action that grants every action on a resource, as in "notifications:*"
+const ScopeWildcard = "*"
+
+// scopeNamePattern matches a resource or action name, and a legacy flat scope
+var scopeNamePattern = regexp.MustCompile(`^[a-z][a-z0-9_.-]*$`)
+
+// Scope is a structured resource:action scope
+type Scope struct {
+	Resource string
+	Action   string // ScopeWildcard for every action on the resource
+}
+
+func (s Scope) String() string {
+	return s.Resource + ":" + s.Action
+}
+
+// Covers reports whether holding s permits other: the resources must match and the actions
+// must match or s must be the resource wildcard.
+func (s Scope) Covers(other Scope) bool {
+	return s.Resource == other.Resource && (s.Action == ScopeWildcard || s.Action == other.Action)
+}
+
+// ParseScope parses a structured scope such as "notifications:send" or "notifications:*".
+// Resource and action are lowercase names of letters, digits, '.', '_' and '-' starting with a letter.
+func ParseScope(scope string) (Scope, error) {
+	resource, action, ok := strings.Cut(scope, ":")
+	if !ok || !scopeNamePattern.MatchString(resource) ||
+		(action != ScopeWildcard && !scopeNamePattern.MatchString(action)) {
+		return Scope{}, fmt.Errorf("%w: %q", ErrMalformedScope, scope)
+	}
+	return Scope{Resource: resource, Action: action}, nil
+}
+
+// isLegacyScope reports whether scope is a flat scope from before structured scopes, such as "read"
+func isLegacyScope(scope string) bool {
+	return !strings.Contains(scope, ":") && scopeNamePattern.MatchString(scope)
+}
+
+// ValidateScopes checks every scope in a scope string. Structured scopes must parse; legacy
+// flat scopes are accepted only when allowLegacy is set. The error wraps ErrMalformedScope
+// and names every rejected scope.
+func ValidateScopes(scopes string, allowLegacy bool) error {
+	var malformed []string
+	for _, scope := range ParseScopes(scopes) {
+		if allowLegacy && isLegacyScope(scope) {
+			continue
+		}
+		if _, err := ParseScope(scope); err != nil {
+			malformed = append(malformed, scope)
+		}
+	}
+	if len(malformed) > 0 {
+		return fmt.Errorf("%w: %s", ErrMalformedScope, strings.Join(malformed, " "))
+	}
+	return nil
+}
+
+// ScopeCovers reports whether a granted scope permits a required one. Structured scopes match
+// by resource and action, with "resource:*" covering every action; legacy flat scopes, and
+// scopes that do not parse, only match themselves.
+func ScopeCovers(granted, required string) bool {
+	if granted == required {
+		return true
+	}
+	grantedScope, err := ParseScope(granted)
+	if err != nil {
+		return false
+	}
+	requiredScope, err := ParseScope(required)
+	if err != nil {
+		return false
+	}
+	return grantedScope.Covers(requiredScope)
+}
+
+// ParseScopes splits a scope string on commas and whitespace, dropping empty and duplicate entries
+func ParseScopes(scopes string) []string {
+	fields := strings.FieldsFunc(scopes, func(r rune) bool {
+		return r == ',' || unicode.IsSpace(r)
+	})
+	seen := make(map[string]struct{}, len(fields))
+	result := make([]string, 0, len(fields))
+	for _, scope := range fields {
+		if _, ok := seen[scope]; ok {
+			continue
+		}
+		seen[scope] = struct{}{}
+		result = append(result, scope)
+	}
+	return result
+}
+
+// FilterGrantedScopes checks the requested scopes against the allowed scopes.
+// It returns the requested scopes that are allowed (space-separated, in request order).
+// An allowed "resource:*" scope allows every action on that resource.
+// If any requested scope is not allowed, the returned error wraps ErrScopeNotAllowed
+// and names the rejected scopes; the granted subset is still returned for callers
+// that choose to issue a narrower token.
+func FilterGrantedScopes(requested, allowed string) (string, error) {
+	allowedScopes := ParseScopes(allowed)
+
+	var granted, denied []string
+	for _, scope := range ParseScopes(requested) {
+		if scopeAllowed(scope, allowedScopes) {
+			granted = append(granted, scope)
+		} else {
+			denied = append(denied, scope)
+		}
+	}
+
+	grantedStr := strings.Join(granted, " ")
+	if len(denied) > 0 {
+		return grantedStr, fmt.Errorf("%w: %s", ErrScopeNotAllowed, strings.Join(denied, " "))
+	}
+	return grantedStr, nil
+}
+
+// scopeAllowed reports whether any of the allowed scopes covers the requested one
+func scopeAllowed(requested string, allowed []string) bool {
+	for _, scope := range allowed {
+		if ScopeCovers(scope, requested) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,164 @@
+// Copyright (c) 2025 WSO2 LLC. (https://www.wso2.com).
+//
+// WSO2 LLC. licenses this file to you under the Apache License,
+// Version 2.0 (the "License"); you may not use this file except
+// in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+package services
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// TestParseScopes tests splitting comma and space separated scopes
+func TestParseScopes(t *testing.T) {
+	got := ParseScopes(" read,write  notifications:send read ")
+	want := []string{"read", "write", "notifications:send"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+
+	if got := ParseScopes(""); len(got) != 0 {
+		t.Errorf("Expected no scopes, got %v", got)
+	}
+}
+
+// TestFilterGrantedScopes_AllAllowed tests that allowed scopes are granted
+func TestFilterGrantedScopes_AllAllowed(t *testing.T) {
+	granted, err := FilterGrantedScopes("read write", "read,write,admin")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if granted != "read write" {
+		t.Errorf("Expected granted 'read write', got %q", granted)
+	}
+}
+
+// TestFilterGrantedScopes_Partial tests that a partially allowed request returns the allowed subset and an error
+func TestFilterGrantedScopes_Partial(t *testing.T) {
+	granted, err := FilterGrantedScopes("read admin write", "read,write")
+	if !errors.Is(err, ErrScopeNotAllowed) {
+		t.Fatalf("Expected ErrScopeNotAllowed, got %v", err)
+	}
+	if granted != "read write" {
+		t.Errorf("Expected granted 'read write', got %q", granted)
+	}
+}
+
+// TestFilterGrantedScopes_FullDenial tests that no scopes are granted when none are allowed
+func TestFilterGrantedScopes_FullDenial(t *testing.T) {
+	granted, err := FilterGrantedScopes("admin delete", "read,write")
+	if !errors.Is(err, ErrScopeNotAllowed) {
+		t.Fatalf("Expected ErrScopeNotAllowed, got %v", err)
+	}
+	if granted != "" {
+		t.Errorf("Expected no granted scopes, got %q", granted)
+	}
+
+	// An empty allow-list denies everything
+	if _, err := FilterGrantedScopes("read", ""); !errors.Is(err, ErrScopeNotAllowed) {
+		t.Errorf("Expected ErrScopeNotAllowed with empty allow-list, got %v", err)
+	}
+}
+
+// TestParseScope tests parsing structured scopes and rejecting malformed ones
+func TestParseScope(t *testing.T) {
+	valid := map[string]Scope{
+		"notifications:send":   {Resource: "notifications", Action: "send"},
+		"notifications:*":      {Resource: "notifications", Action: ScopeWildcard},
+		"micro-apps:read_all":  {Resource: "micro-apps", Action: "read_all"},
+		"users.profile:update": {Resource: "users.profile", Action: "update"},
+	}
+	for input, want := range valid {
+		got, err := ParseScope(input)
+		if err != nil {
+			t.Errorf("ParseScope(%q): unexpected error %v", input, err)
+			continue
+		}
+		if got != want {
+			t.Errorf("ParseScope(%q) = %+v, want %+v", input, got, want)
+		}
+		if got.String() != input {
+			t.Errorf("Expected String() %q, got %q", input, got.String())
+		}
+	}
+
+	for _, input := range []string{"", "read", ":send", "notifications:", "notifications:send:now", "Notifications:send", "*:send", "notifications:se*nd", "1app:read"} {
+		if _, err := ParseScope(input); !errors.Is(err, ErrMalformedScope) {
+			t.Errorf("ParseScope(%q): expected ErrMalformedScope, got %v", input, err)
+		}
+	}
+}
+
+// TestScopeCovers tests exact and wildcard matching of granted scopes
+func TestScopeCovers(t *testing.T) {
+	tests := []struct {
+		granted, required string
+		want              bool
+	}{
+		{"notifications:send", "notifications:send", true},
+		{"notifications:*", "notifications:send", true},
+		{"notifications:*", "notifications:*", true},
+		{"notifications:send", "notifications:*", false},
+		{"notifications:*", "users:read", false},
+		{"notifications:read", "notifications:send", false},
+		{"read", "read", true},
+		{"read", "read:all", false},
+		{"*", "notifications:send", false},
+	}
+	for _, tt := range tests {
+		if got := ScopeCovers(tt.granted, tt.required); got != tt.want {
+			t.Errorf("ScopeCovers(%q, %q) = %v, want %v", tt.granted, tt.required, got, tt.want)
+		}
+	}
+}
+
+// TestValidateScopes tests strict and legacy-compatible validation of scope strings
+func TestValidateScopes(t *testing.T) {
+	if err := ValidateScopes("notifications:send users:*", false); err != nil {
+		t.Errorf("Expected structured scopes to be valid, got %v", err)
+	}
+	if err := ValidateScopes("", false); err != nil {
+		t.Errorf("Expected empty scopes to be valid, got %v", err)
+	}
+
+	// Legacy flat scopes are only accepted in compatibility mode
+	if err := ValidateScopes("read notifications:send", true); err != nil {
+		t.Errorf("Expected legacy scope to be accepted, got %v", err)
+	}
+	err := ValidateScopes("read notifications:send", false)
+	if !errors.Is(err, ErrMalformedScope) {
+		t.Fatalf("Expected ErrMalformedScope in strict mode, got %v", err)
+	}
+
+	// Malformed scopes are rejected in both modes, and every one is named
+	err = ValidateScopes("notifications: READ users:read", true)
+	if !errors.Is(err, ErrMalformedScope) {
+		t.Fatalf("Expected ErrMalformedScope, got %v", err)
+	}
+	if got, want := err.Error(), "malformed scope: notifications: READ"; got != want {
+		t.Errorf("Expected error %q, got %q", want, got)
+	}
+}
+
+// TestFilterGrantedScopes_Wildcard tests that an allowed resource wildcard grants every action on it
+func TestFilterGrantedScopes_Wildcard(t *testing.T) {
+	granted, err := FilterGrantedScopes("notifications:send notifications:read users:read", "notifications:*")
+	if !errors.Is(err, ErrScopeNotAllowed) {
+		t.Fatalf("Expected ErrScopeNotAllowed, got %v", err)
+	}
+	if granted != "notifications:send notifications:read" {
+		t.Errorf("Expected granted notification scopes, got %q", granted)
+	}
+}
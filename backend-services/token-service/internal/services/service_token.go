@@ -35,13 +35,18 @@ type ServiceClaims struct {
 // IssueToken generates a signed JWT for a client (service-to-service authentication)
 // The clientID serves as both the OAuth client identifier and the microapp identifier (sub claim)
 func (s *TokenService) IssueToken(clientID, scopes string) (string, error) {
-	now := time.Now()
+	return s.IssueTokenWithExpiry(clientID, scopes, s.expiry)
+}
+
+// IssueTokenWithExpiry generates a signed JWT for a client with a custom lifetime
+func (s *TokenService) IssueTokenWithExpiry(clientID, scopes string, expiry time.Duration) (string, error) {
+	now := s.now()
 	claims := ServiceClaims{
 		RegisteredClaims: jwt.RegisteredClaims{
 			Issuer:    Issuer,
 			Subject:   clientID, // This is the microapp ID
 			Audience:  jwt.ClaimStrings{Audience},
-			ExpiresAt: jwt.NewNumericDate(now.Add(s.expiry)),
+			ExpiresAt: jwt.NewNumericDate(now.Add(expiry)),
 			IssuedAt:  jwt.NewNumericDate(now),
 			NotBefore: jwt.NewNumericDate(now),
 		},
@@ -20,6 +20,7 @@ import (
 	"time"
 
 	"github.com/golang-jwt/jwt/v4"
+	"github.com/opensuperapp/opensuperapp/backend-services/token-service/internal/clocktest"
 )
 
 // TestIssueToken tests service token generation
@@ -124,3 +125,71 @@ func TestServiceTokenExpiry(t *testing.T) {
 		t.Error("Expected error for expired token")
 	}
 }
+
+// TestIssueTokenWithExpiry tests issuing a service token with a custom expiry
+func TestIssueTokenWithExpiry(t *testing.T) {
+	ts, err := NewTokenServiceFromDirectory(testDataDir, "test-key-1", 3600)
+	if err != nil {
+		t.Fatalf("Failed to create token service: %v", err)
+	}
+
+	tokenString, err := ts.IssueTokenWithExpiry("test-client", "read", 300*time.Second)
+	if err != nil {
+		t.Fatalf("Failed to issue token: %v", err)
+	}
+
+	token, err := jwt.ParseWithClaims(tokenString, &ServiceClaims{}, func(token *jwt.Token) (interface{}, error) {
+		kid := token.Header["kid"].(string)
+		return ts.publicKeys[kid], nil
+	})
+	if err != nil {
+		t.Fatalf("Failed to parse token: %v", err)
+	}
+
+	claims := token.Claims.(*ServiceClaims)
+	if lifetime := claims.ExpiresAt.Unix() - claims.IssuedAt.Unix(); lifetime != 300 {
+		t.Errorf("Expected exp - iat == 300, got %d", lifetime)
+	}
+}
+
+// TestIssueTokenWithExpiry_FakeClock tests exact iat/nbf/exp values and the expiry boundary using a fake clock
+func TestIssueTokenWithExpiry_FakeClock(t *testing.T) {
+	ts, err := NewTokenServiceFromDirectory(testDataDir, "test-key-1", 3600)
+	if err != nil {
+		t.Fatalf("Failed to create token service: %v", err)
+	}
+	issuedAt := time.Date(2025, 1, 15, 10, 0, 0, 0, time.UTC)
+	clock := clocktest.NewFakeClock(issuedAt)
+	ts.SetClock(clock)
+
+	tokenString, err := ts.IssueTokenWithExpiry("test-client", "read", 5*time.Minute)
+	if err != nil {
+		t.Fatalf("Failed to issue token: %v", err)
+	}
+
+	// Claims validation is skipped because the fake issue time is in the past
+	parser := jwt.Parser{SkipClaimsValidation: true}
+	claims := &ServiceClaims{}
+	if _, err := parser.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+		return ts.publicKeys["test-key-1"], nil
+	}); err != nil {
+		t.Fatalf("Failed to parse token: %v", err)
+	}
+
+	if !claims.IssuedAt.Time.Equal(issuedAt) || !claims.NotBefore.Time.Equal(issuedAt) {
+		t.Errorf("Expected iat and nbf %v, got iat %v nbf %v", issuedAt, claims.IssuedAt.Time, claims.NotBefore.Time)
+	}
+	expiresAt := issuedAt.Add(5 * time.Minute)
+	if !claims.ExpiresAt.Time.Equal(expiresAt) {
+		t.Errorf("Expected exp %v, got %v", expiresAt, claims.ExpiresAt.Time)
+	}
+
+	clock.Advance(5*time.Minute - time.Second)
+	if !claims.VerifyExpiresAt(clock.Now(), true) {
+		t.Error("Expected token to be valid one second before expiry")
+	}
+	clock.Advance(2 * time.Second)
+	if claims.VerifyExpiresAt(clock.Now(), true) {
+		t.Error("Expected token to be expired one second after expiry")
+	}
+}
@@ -16,6 +16,8 @@
 package services
 
 import (
+	"bytes"
+	"context"
 	"crypto/rsa"
 	"encoding/base64"
 	"encoding/json"
@@ -28,12 +30,21 @@ import (
 	"sync"
 	"time"
 
+	"github.com/opensuperapp/opensuperapp/backend-services/token-service/internal/jwk"
+
 	"github.com/golang-jwt/jwt/v4"
 )
 
 const (
 	Issuer = "superapp"
 	KeyID  = "superapp-key-1" // Default active kid
+
+	// jwkThumbprintMember carries the RFC 7638 thumbprint of each key in the JWKS.
+	// No certificate is published, so the member holds the key thumbprint rather than a certificate hash.
+	jwkThumbprintMember = "x5t#S256"
+
+	// cdnPurgeTimeout bounds the CDN purge after a key reload
+	cdnPurgeTimeout = 10 * time.Second
 )
 
 type TokenService struct {
@@ -43,7 +54,10 @@ type TokenService struct {
 	activeKeyID string                     // Current signing key
 	jwksData    []byte
 	expiry      time.Duration
-	keysDir     string // Directory for key reloading
+	keysDir     string      // Directory for key reloading
+	clock       Clock       // Source of iat/nbf/exp timestamps
+	purger      CDNPurger   // Evicts the cached JWKS from the CDN after a reload
+	jwksSigner  *JWKSSigner // Signs the JWKS for consumers that verify it (optional)
 }
 
 // NewTokenService creates a TokenService with single key set -- only for backward compatibility
@@ -53,6 +67,8 @@ func NewTokenService(privateKeyPath, publicKeyPath, jwksPath string, expirySecon
 		publicKeys:  make(map[string]*rsa.PublicKey),
 		activeKeyID: KeyID, // Default to the constant
 		expiry:      time.Duration(expirySeconds) * time.Second,
+		clock:       SystemClock,
+		purger:      NoopPurger{},
 	}
 
 	// Load Private Key (single key mode for backward compatibility)
@@ -108,6 +124,8 @@ func NewTokenServiceFromDirectory(keysDir string, activeKeyID string, expirySeco
 		activeKeyID: activeKeyID,
 		expiry:      time.Duration(expirySeconds) * time.Second,
 		keysDir:     keysDir,
+		clock:       SystemClock,
+		purger:      NoopPurger{},
 	}
 
 	// Verify active key exists
@@ -141,30 +159,40 @@ func (s *TokenService) ReloadKeys() error {
 		return fmt.Errorf("failed to load keys: %w", err)
 	}
 
-	// Verify active key still exists
-	s.mu.RLock()
-	activeKeyID := s.activeKeyID
-	s.mu.RUnlock()
-
-	if _, ok := privateKeys[activeKeyID]; !ok {
-		return fmt.Errorf("active key %s not found in new keys", activeKeyID)
+	// Build the JWKS before taking the lock so that keys and JWKS are swapped together
+	// and readers never observe a key set that the published JWKS does not match
+	jwksData, err := buildJWKS(publicKeys)
+	if err != nil {
+		return fmt.Errorf("failed to generate JWKS: %w", err)
 	}
 
 	s.mu.Lock()
-	defer s.mu.Unlock()
 
+	// Verify active key still exists. This is checked under the write lock so a
+	// concurrent SetActiveKey cannot promote a key that the reload is about to drop.
+	if _, ok := privateKeys[s.activeKeyID]; !ok {
+		s.mu.Unlock()
+		return fmt.Errorf("active key %s not found in new keys", s.activeKeyID)
+	}
+
+	jwksChanged := !bytes.Equal(s.jwksData, jwksData)
 	s.privateKeys = privateKeys
 	s.publicKeys = publicKeys
-
-	// Regenerate JWKS
-	jwksData, err := s.generateJWKS()
-	if err != nil {
-		slog.Warn("Failed to generate JWKS during reload", "error", err)
-	} else {
-		s.jwksData = jwksData
-	}
+	s.jwksData = jwksData
+	purger := s.purger
+	s.mu.Unlock()
 
 	slog.Info("Keys reloaded successfully", "keys_loaded", len(privateKeys))
+
+	// Evict the old JWKS from edge caches so validators see new keys without waiting for
+	// the CDN TTL. The keys are already live, so a failed purge is only logged.
+	if jwksChanged {
+		ctx, cancel := context.WithTimeout(context.Background(), cdnPurgeTimeout)
+		defer cancel()
+		if err := purger.Purge(ctx, JWKSSurrogateKey); err != nil {
+			slog.Error("Failed to purge JWKS from CDN", "error", err)
+		}
+	}
 	return nil
 }
 
@@ -260,15 +288,26 @@ func loadAndUpdateJWKS(jwksPath string, publicKey *rsa.PublicKey) ([]byte, error
 	nStr := base64.RawURLEncoding.EncodeToString(publicKey.N.Bytes())
 	key["n"] = nStr
 
+	thumbprint, err := jwk.ComputeJWKThumbprint(publicKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compute JWK thumbprint: %w", err)
+	}
+	key[jwkThumbprintMember] = thumbprint
+
 	return json.Marshal(jwks)
 }
 
 // generateJWKS creates a JWKS containing all loaded public keys
 // This enables validators to verify tokens signed by any of the loaded keys
 func (s *TokenService) generateJWKS() ([]byte, error) {
-	keys := make([]map[string]interface{}, 0, len(s.publicKeys))
+	return buildJWKS(s.publicKeys)
+}
+
+// buildJWKS creates a JWKS document from the given public keys
+func buildJWKS(publicKeys map[string]*rsa.PublicKey) ([]byte, error) {
+	keys := make([]map[string]interface{}, 0, len(publicKeys))
 
-	for keyID, publicKey := range s.publicKeys {
+	for keyID, publicKey := range publicKeys {
 		// Encode N (modulus) as base64url
 		nBytes := publicKey.N.Bytes()
 		nStr := base64.RawURLEncoding.EncodeToString(nBytes)
@@ -277,13 +316,19 @@ func (s *TokenService) generateJWKS() ([]byte, error) {
 		eBytes := big.NewInt(int64(publicKey.E)).Bytes()
 		eStr := base64.RawURLEncoding.EncodeToString(eBytes)
 
+		thumbprint, err := jwk.ComputeJWKThumbprint(publicKey)
+		if err != nil {
+			return nil, fmt.Errorf("failed to compute JWK thumbprint for key %s: %w", keyID, err)
+		}
+
 		keys = append(keys, map[string]interface{}{
-			"kty": "RSA",
-			"use": "sig",
-			"kid": keyID,
-			"n":   nStr,
-			"e":   eStr,
-			"alg": "RS256",
+			"kty":               "RSA",
+			"use":               "sig",
+			"kid":               keyID,
+			"n":                 nStr,
+			"e":                 eStr,
+			"alg":               "RS256",
+			jwkThumbprintMember: thumbprint,
 		})
 	}
 
@@ -294,6 +339,35 @@ func (s *TokenService) generateJWKS() ([]byte, error) {
 	return json.Marshal(jwks)
 }
 
+// SetClock replaces the clock used to timestamp issued tokens (for tests)
+func (s *TokenService) SetClock(clock Clock) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.clock = clock
+}
+
+// SetPurger replaces the CDN purger called when a reload changes the JWKS
+func (s *TokenService) SetPurger(purger CDNPurger) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.purger = purger
+}
+
+// SetJWKSSigner enables the detached JWKS signature served next to the JWKS
+func (s *TokenService) SetJWKSSigner(signer *JWKSSigner) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.jwksSigner = signer
+}
+
+// now returns the current time from the service clock
+func (s *TokenService) now() time.Time {
+	s.mu.RLock()
+	clock := s.clock
+	s.mu.RUnlock()
+	return clock.Now()
+}
+
 // GetJWKS returns the cached JWKS data
 func (s *TokenService) GetJWKS() ([]byte, error) {
 	s.mu.RLock()
@@ -305,11 +379,51 @@ func (s *TokenService) GetJWKS() ([]byte, error) {
 	return s.jwksData, nil
 }
 
+// GetJWKSSignature returns the detached signature of the JWKS returned by GetJWKS
+func (s *TokenService) GetJWKSSignature() (string, error) {
+	s.mu.RLock()
+	signer := s.jwksSigner
+	jwksData := s.jwksData
+	s.mu.RUnlock()
+
+	if signer == nil {
+		return "", fmt.Errorf("JWKS signing not configured")
+	}
+	if len(jwksData) == 0 {
+		return "", fmt.Errorf("JWKS not available")
+	}
+	return signer.Sign(jwksData)
+}
+
+// Keyfunc resolves the public key for a token's kid header and can be passed to jwt.Parse
+// to validate tokens issued by this service. Keys are read under the read lock so a
+// concurrent ReloadKeys never exposes a partially swapped key set.
+func (s *TokenService) Keyfunc(token *jwt.Token) (interface{}, error) {
+	if _, ok := token.Method.(*jwt.SigningMethodRSA); !ok {
+		return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+	}
+	keyID, _ := token.Header["kid"].(string)
+
+	s.mu.RLock()
+	publicKey, ok := s.publicKeys[keyID]
+	s.mu.RUnlock()
+
+	if !ok {
+		return nil, fmt.Errorf("unknown key %s", keyID)
+	}
+	return publicKey, nil
+}
+
 // GetExpiry returns the token expiry duration in seconds
 func (s *TokenService) GetExpiry() int {
 	return int(s.expiry.Seconds())
 }
 
+// GetExpiryDuration returns the default token expiry duration
+func (s *TokenService) GetExpiryDuration() time.Duration {
+	return s.expiry
+}
+
 // SetActiveKey sets the active signing key
 // This allows for key rotation without restarting the service
 func (s *TokenService) SetActiveKey(keyID string) error {
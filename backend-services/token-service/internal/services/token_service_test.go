@@ -16,11 +16,16 @@
 package services
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"os"
 	"path/filepath"
+	"sync"
 	"testing"
 
+	"github.com/opensuperapp/opensuperapp/backend-services/token-service/internal/jwk"
+
 	"github.com/golang-jwt/jwt/v4"
 )
 
@@ -171,7 +176,7 @@ func TestGenerateJWKS(t *testing.T) {
 			t.Fatalf("Key %d is not a map", i)
 		}
 
-		requiredFields := []string{"kty", "use", "kid", "n", "e", "alg"}
+		requiredFields := []string{"kty", "use", "kid", "n", "e", "alg", "x5t#S256"}
 		for _, field := range requiredFields {
 			if _, ok := key[field]; !ok {
 				t.Errorf("Key %d missing field %s", i, field)
@@ -185,6 +190,15 @@ func TestGenerateJWKS(t *testing.T) {
 		if key["alg"] != "RS256" {
 			t.Errorf("Key %d has wrong alg: %v", i, key["alg"])
 		}
+
+		kid, _ := key["kid"].(string)
+		want, err := jwk.ComputeJWKThumbprint(ts.publicKeys[kid])
+		if err != nil {
+			t.Fatalf("Failed to compute thumbprint for %s: %v", kid, err)
+		}
+		if key["x5t#S256"] != want {
+			t.Errorf("Key %s has wrong x5t#S256: got %v, want %s", kid, key["x5t#S256"], want)
+		}
 	}
 }
 
@@ -325,6 +339,58 @@ func TestReloadKeys(t *testing.T) {
 	}
 }
 
+// recordingPurger records the surrogate keys of every CDN purge
+type recordingPurger struct {
+	purges [][]string
+	err    error
+}
+
+func (p *recordingPurger) Purge(ctx context.Context, surrogateKeys ...string) error {
+	p.purges = append(p.purges, surrogateKeys)
+	return p.err
+}
+
+// TestReloadKeys_PurgesCDN tests that the JWKS is purged from the CDN only when a reload changes it
+func TestReloadKeys_PurgesCDN(t *testing.T) {
+	tmpDir := t.TempDir()
+	copyFile(t, filepath.Join(testDataDir, "test-key-1_private.pem"), filepath.Join(tmpDir, "test-key-1_private.pem"))
+	copyFile(t, filepath.Join(testDataDir, "test-key-1_public.pem"), filepath.Join(tmpDir, "test-key-1_public.pem"))
+
+	ts, err := NewTokenServiceFromDirectory(tmpDir, "test-key-1", 3600)
+	if err != nil {
+		t.Fatalf("Failed to create token service: %v", err)
+	}
+	purger := &recordingPurger{}
+	ts.SetPurger(purger)
+
+	// Reloading unchanged keys leaves the cached JWKS alone
+	if err := ts.ReloadKeys(); err != nil {
+		t.Fatalf("Failed to reload keys: %v", err)
+	}
+	if len(purger.purges) != 0 {
+		t.Fatalf("Expected no purge when the JWKS is unchanged, got %v", purger.purges)
+	}
+
+	copyFile(t, filepath.Join(testDataDir, "test-key-2_private.pem"), filepath.Join(tmpDir, "test-key-2_private.pem"))
+	copyFile(t, filepath.Join(testDataDir, "test-key-2_public.pem"), filepath.Join(tmpDir, "test-key-2_public.pem"))
+	if err := ts.ReloadKeys(); err != nil {
+		t.Fatalf("Failed to reload keys: %v", err)
+	}
+	if len(purger.purges) != 1 || len(purger.purges[0]) != 1 || purger.purges[0][0] != JWKSSurrogateKey {
+		t.Fatalf("Expected one purge of %q, got %v", JWKSSurrogateKey, purger.purges)
+	}
+
+	// A failed purge does not fail the reload, since the new keys are already live
+	purger.err = errors.New("cdn unavailable")
+	os.Remove(filepath.Join(tmpDir, "test-key-2_public.pem"))
+	if err := ts.ReloadKeys(); err != nil {
+		t.Fatalf("Expected reload to succeed despite the purge failing, got %v", err)
+	}
+	if len(purger.purges) != 2 {
+		t.Errorf("Expected a second purge, got %v", purger.purges)
+	}
+}
+
 // TestReloadKeys_NoDir tests reload without directory configured
 func TestReloadKeys_NoDir(t *testing.T) {
 	privateKeyPath := filepath.Join(testDataDir, "test-key-1_private.pem")
@@ -344,6 +410,83 @@ func TestReloadKeys_NoDir(t *testing.T) {
 	}
 }
 
+// TestReloadKeys_ConcurrentIssueAndValidate issues and validates tokens while keys are reloaded
+// repeatedly; run with -race to catch unsynchronized access to the key set
+func TestReloadKeys_ConcurrentIssueAndValidate(t *testing.T) {
+	tmpDir := t.TempDir()
+	copyFile(t, filepath.Join(testDataDir, "test-key-1_private.pem"), filepath.Join(tmpDir, "test-key-1_private.pem"))
+	copyFile(t, filepath.Join(testDataDir, "test-key-1_public.pem"), filepath.Join(tmpDir, "test-key-1_public.pem"))
+
+	ts, err := NewTokenServiceFromDirectory(tmpDir, "test-key-1", 3600)
+	if err != nil {
+		t.Fatalf("Failed to create token service: %v", err)
+	}
+
+	// Staged here so the reloader goroutine never has to call t.Fatal
+	stagingDir := t.TempDir()
+	copyFile(t, filepath.Join(testDataDir, "test-key-2_private.pem"), filepath.Join(stagingDir, "test-key-2_private.pem"))
+	copyFile(t, filepath.Join(testDataDir, "test-key-2_public.pem"), filepath.Join(stagingDir, "test-key-2_public.pem"))
+
+	const iterations = 50
+	var wg sync.WaitGroup
+	errs := make(chan error, 4*iterations)
+
+	// Reloader: repeatedly adds and removes test-key-2 so every reload swaps in a different key set
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < iterations; i++ {
+			for _, name := range []string{"test-key-2_private.pem", "test-key-2_public.pem"} {
+				if i%2 == 0 {
+					if err := os.Link(filepath.Join(stagingDir, name), filepath.Join(tmpDir, name)); err != nil {
+						errs <- err
+					}
+				} else if err := os.Remove(filepath.Join(tmpDir, name)); err != nil {
+					errs <- err
+				}
+			}
+			if err := ts.ReloadKeys(); err != nil {
+				errs <- err
+			}
+		}
+	}()
+
+	for w := 0; w < 3; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < iterations; i++ {
+				token, err := ts.IssueToken("test-client", "notifications:send")
+				if err != nil {
+					errs <- err
+					continue
+				}
+				if _, err := jwt.Parse(token, ts.Keyfunc); err != nil {
+					errs <- err
+				}
+
+				jwksBytes, err := ts.GetJWKS()
+				if err != nil {
+					errs <- err
+					continue
+				}
+				var jwks struct {
+					Keys []map[string]interface{} `json:"keys"`
+				}
+				if err := json.Unmarshal(jwksBytes, &jwks); err != nil {
+					errs <- err
+				}
+			}
+		}()
+	}
+
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+}
+
 // Helper to copy files
 func copyFile(t *testing.T, src, dst string) {
 	data, err := os.ReadFile(src)
@@ -33,21 +33,12 @@ type UserContextClaims struct {
 // GenerateUserToken generates a token for a microapp frontend with user context
 // This is used when a microapp frontend needs to call its own backend
 func (s *TokenService) GenerateUserToken(userEmail, microappID, scopes string) (string, error) {
-	now := time.Now()
-	claims := UserContextClaims{
-		RegisteredClaims: jwt.RegisteredClaims{
-			Issuer:    Issuer,
-			Subject:   userEmail,                    // User email as subject (who the token represents)
-			Audience:  jwt.ClaimStrings{microappID}, // Microapp ID as audience (intended recipient)
-			ExpiresAt: jwt.NewNumericDate(now.Add(s.expiry)),
-			IssuedAt:  jwt.NewNumericDate(now),
-			NotBefore: jwt.NewNumericDate(now),
-		},
-		MicroappID: microappID,
-		Scopes:     scopes,
-		Email:      userEmail,
-	}
+	return s.GenerateUserTokenWithExpiry(userEmail, microappID, scopes, s.expiry)
+}
 
+// GenerateUserTokenWithExpiry generates a user-context token with a custom lifetime
+func (s *TokenService) GenerateUserTokenWithExpiry(userEmail, microappID, scopes string, expiry time.Duration) (string, error) {
+	claims := s.UserTokenClaims(userEmail, microappID, scopes, expiry)
 	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
 
 	s.mu.RLock()
@@ -62,3 +53,22 @@ func (s *TokenService) GenerateUserToken(userEmail, microappID, scopes string) (
 	token.Header["kid"] = activeKeyID
 	return token.SignedString(privateKey)
 }
+
+// UserTokenClaims returns the claims of a user-context token issued now, without signing it.
+// GenerateUserTokenWithExpiry signs exactly these claims.
+func (s *TokenService) UserTokenClaims(userEmail, microappID, scopes string, expiry time.Duration) UserContextClaims {
+	now := s.now()
+	return UserContextClaims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    Issuer,
+			Subject:   userEmail,                    // User email as subject (who the token represents)
+			Audience:  jwt.ClaimStrings{microappID}, // Microapp ID as audience (intended recipient)
+			ExpiresAt: jwt.NewNumericDate(now.Add(expiry)),
+			IssuedAt:  jwt.NewNumericDate(now),
+			NotBefore: jwt.NewNumericDate(now),
+		},
+		MicroappID: microappID,
+		Scopes:     scopes,
+		Email:      userEmail,
+	}
+}
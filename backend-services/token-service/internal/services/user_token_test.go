@@ -252,3 +252,29 @@ func TestUserTokenClaims(t *testing.T) {
 		t.Error("NotBefore is nil")
 	}
 }
+
+// TestGenerateUserTokenWithExpiry tests generating a user token with a custom expiry
+func TestGenerateUserTokenWithExpiry(t *testing.T) {
+	ts, err := NewTokenServiceFromDirectory(testDataDir, "test-key-1", 3600)
+	if err != nil {
+		t.Fatalf("Failed to create token service: %v", err)
+	}
+
+	tokenString, err := ts.GenerateUserTokenWithExpiry("user@example.com", "test-microapp", "read", 300*time.Second)
+	if err != nil {
+		t.Fatalf("Failed to generate user token: %v", err)
+	}
+
+	token, err := jwt.ParseWithClaims(tokenString, &UserContextClaims{}, func(token *jwt.Token) (interface{}, error) {
+		kid := token.Header["kid"].(string)
+		return ts.publicKeys[kid], nil
+	})
+	if err != nil {
+		t.Fatalf("Failed to parse token: %v", err)
+	}
+
+	claims := token.Claims.(*UserContextClaims)
+	if lifetime := claims.ExpiresAt.Unix() - claims.IssuedAt.Unix(); lifetime != 300 {
+		t.Errorf("Expected exp - iat == 300, got %d", lifetime)
+	}
+}
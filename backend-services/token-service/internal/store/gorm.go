@@ -0,0 +1,125 @@
+// Copyright (c) 2025 WSO2 LLC. (https://www.wso2.com).
+//
+// WSO2 LLC. licenses this file to you under the Apache License,
+// Version 2.0 (the "License"); you may not use this file except
+// in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+package store
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/opensuperapp/opensuperapp/backend-services/token-service/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// GormStore implements ClientStore and AuthorizationCodeStore on the primary database
+type GormStore struct {
+	db *gorm.DB
+}
+
+// NewGormStore creates a store backed by db
+func NewGormStore(db *gorm.DB) *GormStore {
+	return &GormStore{db: db}
+}
+
+func (s *GormStore) GetActiveClient(ctx context.Context, clientID string) (*models.OAuth2Client, error) {
+	var client models.OAuth2Client
+	if err := s.db.WithContext(ctx).Where("client_id = ? AND is_active = ?", clientID, true).First(&client).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return &client, nil
+}
+
+func (s *GormStore) ListClients(ctx context.Context) ([]models.OAuth2Client, error) {
+	var clients []models.OAuth2Client
+	if err := s.db.WithContext(ctx).Order("client_id").Find(&clients).Error; err != nil {
+		return nil, err
+	}
+	return clients, nil
+}
+
+func (s *GormStore) ExistingClientIDs(ctx context.Context, clientIDs []string) (map[string]bool, error) {
+	existing := make(map[string]bool)
+	if len(clientIDs) == 0 {
+		return existing, nil
+	}
+	// Unscoped so soft-deleted clients, which still hold the unique client_id, are included
+	var ids []string
+	if err := s.db.WithContext(ctx).Unscoped().Model(&models.OAuth2Client{}).
+		Where("client_id IN ?", clientIDs).
+		Pluck("client_id", &ids).Error; err != nil {
+		return nil, err
+	}
+	for _, id := range ids {
+		existing[id] = true
+	}
+	return existing, nil
+}
+
+func (s *GormStore) CreateClients(ctx context.Context, clients []models.OAuth2Client) error {
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for i := range clients {
+			client := &clients[i]
+			var count int64
+			if err := tx.Unscoped().Model(&models.OAuth2Client{}).Where("client_id = ?", client.ClientID).Count(&count).Error; err != nil {
+				return err
+			}
+			if count > 0 {
+				return ErrClientExists
+			}
+			isActive := client.IsActive
+			if err := tx.Create(client).Error; err != nil {
+				return err
+			}
+			// is_active has a database default of true, so an inactive client must be updated explicitly
+			if !isActive {
+				if err := tx.Model(client).Update("is_active", false).Error; err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+}
+
+func (s *GormStore) CreateAuthorizationCode(ctx context.Context, code *models.AuthorizationCode) error {
+	return s.db.WithContext(ctx).Create(code).Error
+}
+
+func (s *GormStore) GetAuthorizationCode(ctx context.Context, code, clientID string) (*models.AuthorizationCode, error) {
+	var authCode models.AuthorizationCode
+	if err := s.db.WithContext(ctx).Where("code = ? AND client_id = ?", code, clientID).First(&authCode).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return &authCode, nil
+}
+
+func (s *GormStore) RedeemAuthorizationCode(ctx context.Context, id uint, usedAt time.Time) (bool, error) {
+	// The used_at guard makes concurrent redemptions of the same code fail
+	result := s.db.WithContext(ctx).Model(&models.AuthorizationCode{}).
+		Where("id = ? AND used_at IS NULL", id).
+		Update("used_at", usedAt)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
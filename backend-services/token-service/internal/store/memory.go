@@ -0,0 +1,142 @@
+// Copyright (c) 2025 WSO2 LLC. (https://www.wso2.com).
+//
+// WSO2 LLC. licenses this file to you under the Apache License,
+// Version 2.0 (the "License"); you may not use this file except
+// in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+package store
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/opensuperapp/opensuperapp/backend-services/token-service/internal/models"
+)
+
+var errDuplicateCode = errors.New("authorization code already exists")
+
+// MemoryStore implements ClientStore and AuthorizationCodeStore in process memory.
+// It is meant for tests and single-instance development setups; data is lost on restart.
+type MemoryStore struct {
+	mu      sync.Mutex
+	clients map[string]models.OAuth2Client
+	codes   map[uint]models.AuthorizationCode
+	nextID  uint
+}
+
+// NewMemoryStore creates an empty in-memory store
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		clients: make(map[string]models.OAuth2Client),
+		codes:   make(map[uint]models.AuthorizationCode),
+	}
+}
+
+func (s *MemoryStore) GetActiveClient(ctx context.Context, clientID string) (*models.OAuth2Client, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	client, ok := s.clients[clientID]
+	if !ok || !client.IsActive || client.DeletedAt.Valid {
+		return nil, ErrNotFound
+	}
+	return &client, nil
+}
+
+func (s *MemoryStore) ListClients(ctx context.Context) ([]models.OAuth2Client, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	clients := make([]models.OAuth2Client, 0, len(s.clients))
+	for _, client := range s.clients {
+		if !client.DeletedAt.Valid {
+			clients = append(clients, client)
+		}
+	}
+	sort.Slice(clients, func(i, j int) bool { return clients[i].ClientID < clients[j].ClientID })
+	return clients, nil
+}
+
+func (s *MemoryStore) ExistingClientIDs(ctx context.Context, clientIDs []string) (map[string]bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	existing := make(map[string]bool)
+	for _, id := range clientIDs {
+		if _, ok := s.clients[id]; ok {
+			existing[id] = true
+		}
+	}
+	return existing, nil
+}
+
+func (s *MemoryStore) CreateClients(ctx context.Context, clients []models.OAuth2Client) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	seen := make(map[string]struct{}, len(clients))
+	for _, client := range clients {
+		if _, ok := s.clients[client.ClientID]; ok {
+			return ErrClientExists
+		}
+		if _, ok := seen[client.ClientID]; ok {
+			return ErrClientExists
+		}
+		seen[client.ClientID] = struct{}{}
+	}
+	now := time.Now()
+	for i := range clients {
+		s.nextID++
+		clients[i].ID = s.nextID
+		clients[i].CreatedAt = now
+		clients[i].UpdatedAt = now
+		s.clients[clients[i].ClientID] = clients[i]
+	}
+	return nil
+}
+
+func (s *MemoryStore) CreateAuthorizationCode(ctx context.Context, code *models.AuthorizationCode) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, existing := range s.codes {
+		if existing.Code == code.Code {
+			return errDuplicateCode
+		}
+	}
+	s.nextID++
+	code.ID = s.nextID
+	code.CreatedAt = time.Now()
+	s.codes[code.ID] = *code
+	return nil
+}
+
+func (s *MemoryStore) GetAuthorizationCode(ctx context.Context, code, clientID string) (*models.AuthorizationCode, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, authCode := range s.codes {
+		if authCode.Code == code && authCode.ClientID == clientID {
+			return &authCode, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+func (s *MemoryStore) RedeemAuthorizationCode(ctx context.Context, id uint, usedAt time.Time) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	authCode, ok := s.codes[id]
+	if !ok || authCode.UsedAt != nil {
+		return false, nil
+	}
+	authCode.UsedAt = &usedAt
+	s.codes[id] = authCode
+	return true, nil
+}
@@ -0,0 +1,57 @@
+// Copyright (c) 2025 WSO2 LLC. (https://www.wso2.com).
+//
+// WSO2 LLC. licenses this file to you under the Apache License,
+// Version 2.0 (the "License"); you may not use this file except
+// in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+// Package store defines the persistence interfaces of the token service. The GORM implementation
+// backed by the primary database is the default; operators can plug other backends (for example
+// Redis for high-churn grant data) by implementing the interfaces.
+package store
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/opensuperapp/opensuperapp/backend-services/token-service/internal/models"
+)
+
+var (
+	// ErrNotFound is returned when a client or authorization code does not exist
+	ErrNotFound = errors.New("not found")
+	// ErrClientExists is returned when a client_id is already taken, including by a deleted client
+	ErrClientExists = errors.New("client_id already exists")
+)
+
+// ClientStore persists OAuth2 clients
+type ClientStore interface {
+	// GetActiveClient returns an active client, or ErrNotFound
+	GetActiveClient(ctx context.Context, clientID string) (*models.OAuth2Client, error)
+	// ListClients returns every client that has not been deleted, ordered by client_id
+	ListClients(ctx context.Context) ([]models.OAuth2Client, error)
+	// ExistingClientIDs returns which of the given client_ids are taken, including by deleted clients
+	ExistingClientIDs(ctx context.Context, clientIDs []string) (map[string]bool, error)
+	// CreateClients stores all clients or none; it returns ErrClientExists if any client_id is taken
+	CreateClients(ctx context.Context, clients []models.OAuth2Client) error
+}
+
+// AuthorizationCodeStore persists single-use authorization codes
+type AuthorizationCodeStore interface {
+	// CreateAuthorizationCode stores a new code; code values are unique and a duplicate fails
+	CreateAuthorizationCode(ctx context.Context, code *models.AuthorizationCode) error
+	// GetAuthorizationCode returns the code issued to clientID, or ErrNotFound
+	GetAuthorizationCode(ctx context.Context, code, clientID string) (*models.AuthorizationCode, error)
+	// RedeemAuthorizationCode marks the code used at usedAt. It reports false if the code was already
+	// used, so that only one of several concurrent redemptions succeeds.
+	RedeemAuthorizationCode(ctx context.Context, id uint, usedAt time.Time) (bool, error)
+}
@@ -0,0 +1,179 @@
+// Copyright (c) 2025 WSO2 LLC. (https://www.wso2.com).
+//
+// WSO2 LLC. licenses this file to you under the Apache License,
+// Version 2.0 (the "License"); you may not use this file except
+// in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+package store
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/opensuperapp/opensuperapp/backend-services/token-service/internal/models"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// backend is a store implementation under test
+type backend interface {
+	ClientStore
+	AuthorizationCodeStore
+}
+
+// backends returns a fresh instance of every store implementation
+func backends(t *testing.T) map[string]backend {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("Failed to open test database: %v", err)
+	}
+	// Every connection to :memory: opens a separate database, so the concurrent tests must share one
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatalf("Failed to get database handle: %v", err)
+	}
+	sqlDB.SetMaxOpenConns(1)
+	if err := db.AutoMigrate(&models.OAuth2Client{}, &models.AuthorizationCode{}); err != nil {
+		t.Fatalf("Failed to migrate database: %v", err)
+	}
+	return map[string]backend{
+		"gorm":   NewGormStore(db),
+		"memory": NewMemoryStore(),
+	}
+}
+
+// TestStore_Clients tests creating, listing and looking up clients, including inactive ones
+func TestStore_Clients(t *testing.T) {
+	ctx := context.Background()
+	for name, s := range backends(t) {
+		t.Run(name, func(t *testing.T) {
+			err := s.CreateClients(ctx, []models.OAuth2Client{
+				{ClientID: "beta", ClientSecret: "hash", Name: "Beta", IsActive: true},
+				{ClientID: "alpha", ClientSecret: "hash", Name: "Alpha", IsActive: false},
+			})
+			if err != nil {
+				t.Fatalf("CreateClients failed: %v", err)
+			}
+
+			client, err := s.GetActiveClient(ctx, "beta")
+			if err != nil || client.Name != "Beta" {
+				t.Errorf("Expected active client beta, got %+v, %v", client, err)
+			}
+			if _, err := s.GetActiveClient(ctx, "alpha"); !errors.Is(err, ErrNotFound) {
+				t.Errorf("Expected ErrNotFound for an inactive client, got %v", err)
+			}
+			if _, err := s.GetActiveClient(ctx, "missing"); !errors.Is(err, ErrNotFound) {
+				t.Errorf("Expected ErrNotFound for a missing client, got %v", err)
+			}
+
+			clients, err := s.ListClients(ctx)
+			if err != nil {
+				t.Fatalf("ListClients failed: %v", err)
+			}
+			if len(clients) != 2 || clients[0].ClientID != "alpha" || clients[0].IsActive || clients[1].ClientID != "beta" {
+				t.Errorf("Expected alpha (inactive) then beta, got %+v", clients)
+			}
+
+			existing, err := s.ExistingClientIDs(ctx, []string{"alpha", "gamma"})
+			if err != nil {
+				t.Fatalf("ExistingClientIDs failed: %v", err)
+			}
+			if !existing["alpha"] || existing["gamma"] {
+				t.Errorf("Expected only alpha to exist, got %v", existing)
+			}
+		})
+	}
+}
+
+// TestStore_CreateClients_AllOrNothing tests that a taken client_id fails the whole batch
+func TestStore_CreateClients_AllOrNothing(t *testing.T) {
+	ctx := context.Background()
+	for name, s := range backends(t) {
+		t.Run(name, func(t *testing.T) {
+			if err := s.CreateClients(ctx, []models.OAuth2Client{{ClientID: "taken", ClientSecret: "hash", Name: "Taken", IsActive: true}}); err != nil {
+				t.Fatalf("CreateClients failed: %v", err)
+			}
+
+			err := s.CreateClients(ctx, []models.OAuth2Client{
+				{ClientID: "fresh", ClientSecret: "hash", Name: "Fresh", IsActive: true},
+				{ClientID: "taken", ClientSecret: "hash", Name: "Again", IsActive: true},
+			})
+			if !errors.Is(err, ErrClientExists) {
+				t.Fatalf("Expected ErrClientExists, got %v", err)
+			}
+			if _, err := s.GetActiveClient(ctx, "fresh"); !errors.Is(err, ErrNotFound) {
+				t.Errorf("Expected fresh not to be created, got %v", err)
+			}
+		})
+	}
+}
+
+// TestStore_AuthorizationCodes tests that a code is found only for its client and can be redeemed once
+func TestStore_AuthorizationCodes(t *testing.T) {
+	ctx := context.Background()
+	for name, s := range backends(t) {
+		t.Run(name, func(t *testing.T) {
+			code := &models.AuthorizationCode{
+				Code:                "code-1",
+				ClientID:            "client-1",
+				UserEmail:           "user@example.com",
+				CodeChallenge:       "challenge",
+				CodeChallengeMethod: "S256",
+				ExpiresAt:           time.Now().Add(time.Minute),
+			}
+			if err := s.CreateAuthorizationCode(ctx, code); err != nil {
+				t.Fatalf("CreateAuthorizationCode failed: %v", err)
+			}
+			if err := s.CreateAuthorizationCode(ctx, &models.AuthorizationCode{Code: "code-1", ClientID: "client-1", CodeChallenge: "c", CodeChallengeMethod: "S256", ExpiresAt: time.Now()}); err == nil {
+				t.Error("Expected a duplicate code to be rejected")
+			}
+
+			if _, err := s.GetAuthorizationCode(ctx, "code-1", "client-2"); !errors.Is(err, ErrNotFound) {
+				t.Errorf("Expected ErrNotFound for another client, got %v", err)
+			}
+			stored, err := s.GetAuthorizationCode(ctx, "code-1", "client-1")
+			if err != nil {
+				t.Fatalf("GetAuthorizationCode failed: %v", err)
+			}
+			if stored.UserEmail != "user@example.com" || stored.UsedAt != nil {
+				t.Errorf("Unexpected stored code: %+v", stored)
+			}
+
+			// Concurrent redemptions: exactly one wins
+			var wins int32
+			var wg sync.WaitGroup
+			for i := 0; i < 5; i++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					if ok, err := s.RedeemAuthorizationCode(ctx, stored.ID, time.Now()); err == nil && ok {
+						atomic.AddInt32(&wins, 1)
+					}
+				}()
+			}
+			wg.Wait()
+			if wins != 1 {
+				t.Errorf("Expected exactly one redemption to succeed, got %d", wins)
+			}
+
+			redeemed, err := s.GetAuthorizationCode(ctx, "code-1", "client-1")
+			if err != nil || redeemed.UsedAt == nil {
+				t.Errorf("Expected the code to be marked used, got %+v, %v", redeemed, err)
+			}
+		})
+	}
+}
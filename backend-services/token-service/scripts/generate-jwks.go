@@ -23,6 +23,8 @@ import (
 	"math/big"
 	"os"
 
+	"github.com/opensuperapp/opensuperapp/backend-services/token-service/internal/jwk"
+
 	"github.com/golang-jwt/jwt/v4"
 )
 
@@ -50,8 +52,16 @@ func main() {
 		os.Exit(1)
 	}
 
+	thumbprint, err := jwk.ComputeJWKThumbprint(pubKey)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error computing JWK thumbprint: %v\n", err)
+		os.Exit(1)
+	}
+	// Printed to stderr so stdout stays valid JSON
+	fmt.Fprintf(os.Stderr, "JWK thumbprint (RFC 7638, SHA-256) for %s: %s\n", keyID, thumbprint)
+
 	// Create JWKS
-	jwks := createJWKS(pubKey, keyID)
+	jwks := createJWKS(pubKey, keyID, thumbprint)
 
 	// Output as JSON
 	encoder := json.NewEncoder(os.Stdout)
@@ -62,7 +72,7 @@ func main() {
 	}
 }
 
-func createJWKS(pubKey *rsa.PublicKey, keyID string) map[string]interface{} {
+func createJWKS(pubKey *rsa.PublicKey, keyID, thumbprint string) map[string]interface{} {
 	// Encode N (modulus) as base64url
 	nBytes := pubKey.N.Bytes()
 	nStr := base64.RawURLEncoding.EncodeToString(nBytes)
@@ -74,12 +84,13 @@ func createJWKS(pubKey *rsa.PublicKey, keyID string) map[string]interface{} {
 	return map[string]interface{}{
 		"keys": []map[string]interface{}{
 			{
-				"kty": "RSA",
-				"use": "sig",
-				"kid": keyID,
-				"n":   nStr,
-				"e":   eStr,
-				"alg": "RS256",
+				"kty":      "RSA",
+				"use":      "sig",
+				"kid":      keyID,
+				"n":        nStr,
+				"e":        eStr,
+				"alg":      "RS256",
+				"x5t#S256": thumbprint,
 			},
 		},
 	}